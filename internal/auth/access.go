@@ -2,6 +2,7 @@ package auth
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 	"wiki-go/internal/config"
 )
@@ -18,7 +19,7 @@ func CanAccessDocument(path string, session *Session, cfg *config.Config) bool {
 
 	// If no rule matches, default behavior depends on wiki privacy
 	if rule == nil {
-		if cfg.Wiki.Private {
+		if cfg.Wiki.AccessMode == config.AccessModePrivate || cfg.Wiki.Private {
 			// If private, only authenticated users can access
 			return session != nil
 		}
@@ -29,6 +30,60 @@ func CanAccessDocument(path string, session *Session, cfg *config.Config) bool {
 	return checkAccessRule(rule, session)
 }
 
+// Who can read documents at a path besides admins, from the fewest users to the most
+const (
+	audienceNobody   = iota // A rule with an unknown access level
+	audienceGroups          // Members of the rule's groups
+	audienceSignedIn        // Any signed-in user
+	audienceEveryone        // Visitors too
+)
+
+// documentAudience returns who can read documents at path, with the groups
+// for audienceGroups, deciding the way CanAccessDocument does
+func documentAudience(path string, cfg *config.Config) (int, []string) {
+	rule := findMatchingRule(path, cfg.AccessRules)
+	if rule == nil {
+		if cfg.Wiki.AccessMode == config.AccessModePrivate || cfg.Wiki.Private {
+			return audienceSignedIn, nil
+		}
+		return audienceEveryone, nil
+	}
+	switch rule.Access {
+	case "public":
+		return audienceEveryone, nil
+	case "private":
+		return audienceSignedIn, nil
+	case "restricted":
+		return audienceGroups, rule.Groups
+	}
+	return audienceNobody, nil
+}
+
+// WidensAccess reports whether a document moved from path from to path to
+// becomes readable by users who couldn't read it before, e.g. when it moves
+// from an internal namespace to a public one
+func WidensAccess(from, to string, cfg *config.Config) bool {
+	fromAudience, fromGroups := documentAudience(from, cfg)
+	toAudience, toGroups := documentAudience(to, cfg)
+	if toAudience != fromAudience {
+		return toAudience > fromAudience
+	}
+	if toAudience == audienceGroups {
+		for _, group := range toGroups {
+			if !slices.Contains(fromGroups, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchingAccessRule returns the rule that decides who can access path: the
+// first of rules whose pattern matches it, or nil when none does
+func MatchingAccessRule(path string, rules []config.AccessRule) *config.AccessRule {
+	return findMatchingRule(path, rules)
+}
+
 func findMatchingRule(path string, rules []config.AccessRule) *config.AccessRule {
 	for _, rule := range rules {
 		if matchPattern(rule.Pattern, path) {
@@ -5,12 +5,14 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"log"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"slices"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 	"wiki-go/internal/config"
 	"wiki-go/internal/crypto"
@@ -24,12 +26,20 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	LastAccessed time.Time `json:"last_accessed"`
+	Agent        string    `json:"agent,omitempty"`   // Fingerprint of the browser the session was created from
+	Network      string    `json:"network,omitempty"` // Fingerprint of the subnet the session was created from
+	Refresh      bool      `json:"refresh,omitempty"` // A refresh token, which can only mint new sessions
 }
 
 var (
-	sessions     = make(map[string]Session)
-	mu           sync.RWMutex
-	sessionStore *SessionStore
+	// sessions holds the sessions; an in-memory store until InitSessionStore
+	sessions SessionStore = NewMemorySessionStore("")
+
+	// cleanupRunning reports whether the background session cleanup goroutine is alive
+	cleanupRunning atomic.Bool
+	// stopCleanup is closed by StopSessionStore to end the cleanup goroutine
+	stopCleanup chan struct{}
+	cleanupDone chan struct{}
 )
 
 // IsExpired checks if the session has expired
@@ -37,55 +47,154 @@ func (s *Session) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
 
-// InitSessionStore initializes the session store and loads existing sessions
-func InitSessionStore(filePath string) error {
-	sessionStore = NewSessionStore(filePath)
-	loadedSessions, err := sessionStore.LoadSessions()
+// InitSessionStore makes store hold the sessions and starts removing expired
+// sessions in the background
+func InitSessionStore(store SessionStore) {
+	sessions = store
+
+	// Start background cleanup goroutine
+	cleanupRunning.Store(true)
+	stopCleanup = make(chan struct{})
+	cleanupDone = make(chan struct{})
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		defer cleanupRunning.Store(false)
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if _, err := store.RemoveExpired(); err != nil {
+				log.Printf("Error removing expired sessions: %v", err)
+			}
+		}
+	}(stopCleanup, cleanupDone)
+}
+
+// StopSessionStore stops the background cleanup worker and closes the session
+// store, which writes sessions kept in memory to disk so logins survive a
+// restart. It is safe to call when the session store was never initialized.
+func StopSessionStore() error {
+	if stopCleanup == nil {
+		return nil
+	}
+
+	close(stopCleanup)
+	<-cleanupDone
+	stopCleanup = nil
+
+	return sessions.Close()
+}
+
+// SessionCleanupRunning reports whether the background session cleanup worker is running
+func SessionCleanupRunning() bool {
+	return cleanupRunning.Load()
+}
+
+// ActiveSessionCount returns the number of unexpired sessions
+func ActiveSessionCount() int {
+	all, err := sessions.All()
 	if err != nil {
-		return err
+		log.Printf("Error listing sessions: %v", err)
+		return 0
 	}
 
-	mu.Lock()
-	sessions = loadedSessions
-	// Cleanup expired sessions on startup
-	for token, session := range sessions {
-		if session.IsExpired() {
-			delete(sessions, token)
+	count := 0
+	for _, session := range all {
+		if !session.IsExpired() && !session.Refresh {
+			count++
 		}
 	}
-	mu.Unlock()
+	return count
+}
 
-	// Start background cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour)
-		defer ticker.Stop()
-		for range ticker.C {
-			// We need to pass the map to CleanupExpiredSessions
-			// But we need to be careful about concurrency.
-			// The CleanupExpiredSessions in session_store.go as I wrote it
-			// takes the map and modifies it.
-			// However, the map 'sessions' is global here and protected by 'mu'.
-			// The SessionStore.CleanupExpiredSessions I wrote assumes it owns the map or locks it.
-			// But here 'sessions' is the global map.
-
-			// Let's adjust the strategy.
-			// We should lock 'mu', perform cleanup on 'sessions', and then save.
-			mu.Lock()
-			deleted := 0
-			for token, session := range sessions {
-				if session.IsExpired() {
-					delete(sessions, token)
-					deleted++
-				}
+// SyncSessions brings active sessions in line with a reloaded user list. Sessions
+// of users that no longer exist or are disabled are ended; the rest keep going
+// with the user's current role and groups. It returns the number of sessions
+// ended.
+func SyncSessions(users []config.User) int {
+	byName := make(map[string]config.User, len(users))
+	for _, user := range users {
+		byName[user.Username] = user
+	}
+
+	all, err := sessions.All()
+	if err != nil {
+		log.Printf("Error listing sessions in SyncSessions: %v", err)
+		return 0
+	}
+
+	ended := 0
+	for token, session := range all {
+		user, ok := byName[session.Username]
+		if !ok || user.Disabled {
+			if err := sessions.Delete(token); err != nil {
+				log.Printf("Error ending session in SyncSessions: %v", err)
+				continue
 			}
-			if deleted > 0 {
-				sessionStore.SaveSessions(sessions)
+			ended++
+			continue
+		}
+		if session.Role != user.Role || !slices.Equal(session.Groups, user.Groups) {
+			session.Role = user.Role
+			session.Groups = user.Groups
+			if err := sessions.Put(token, session); err != nil {
+				log.Printf("Error saving session in SyncSessions: %v", err)
 			}
-			mu.Unlock()
 		}
-	}()
+	}
+	return ended
+}
 
-	return nil
+// RevokeRefreshTokens revokes the refresh tokens of username, ending "keep me
+// logged in" on every device without ending current sessions. It returns the
+// number of tokens revoked.
+func RevokeRefreshTokens(username string) int {
+	all, err := sessions.All()
+	if err != nil {
+		log.Printf("Error listing sessions in RevokeRefreshTokens: %v", err)
+		return 0
+	}
+
+	revoked := 0
+	for token, session := range all {
+		if !session.Refresh || session.Username != username {
+			continue
+		}
+		if err := sessions.Delete(token); err != nil {
+			log.Printf("Error revoking refresh token in RevokeRefreshTokens: %v", err)
+			continue
+		}
+		revoked++
+	}
+	return revoked
+}
+
+// EndSessions ends the sessions of username, including those kept logged in
+// with a refresh token, signing the user out on every device. It returns the
+// number of sessions ended.
+func EndSessions(username string) int {
+	all, err := sessions.All()
+	if err != nil {
+		log.Printf("Error listing sessions in EndSessions: %v", err)
+		return 0
+	}
+
+	ended := 0
+	for token, session := range all {
+		if session.Username != username {
+			continue
+		}
+		if err := sessions.Delete(token); err != nil {
+			log.Printf("Error ending session in EndSessions: %v", err)
+			continue
+		}
+		ended++
+	}
+	return ended
 }
 
 // hashToken returns the SHA256 hash of the token
@@ -104,38 +213,55 @@ func GenerateSessionToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// CreateSession creates a new session for the user
-func CreateSession(w http.ResponseWriter, username string, role string, groups []string, keepLoggedIn bool, cfg *config.Config) error {
-	token, err := GenerateSessionToken()
-	if err != nil {
+const (
+	// sessionLifetime is how long a session token is valid
+	sessionLifetime = 24 * time.Hour
+	// refreshLifetime is how long "keep me logged in" lasts: a refresh token
+	// valid this long mints new sessions as they expire
+	refreshLifetime = 30 * 24 * time.Hour
+)
+
+// ErrInvalidRefreshToken is returned by RefreshSession when the request has no
+// refresh token, or one that is unknown, expired or used from another client
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// CreateSession creates a new session for the user signing in with r. With
+// keepLoggedIn the user also gets a refresh token, which RefreshSession trades
+// for a new session once this one expires, so that no token that grants
+// access lasts long.
+func CreateSession(w http.ResponseWriter, r *http.Request, username string, role string, groups []string, keepLoggedIn bool, cfg *config.Config) error {
+	if err := startSession(w, r, username, role, groups, cfg); err != nil {
 		return err
 	}
-
-	// Set cookie expiration time based on keepLoggedIn flag
-	maxAge := 3600 * 24 // 24 hours by default
 	if keepLoggedIn {
-		maxAge = 3600 * 24 * 30 // 30 days for persistent login
+		return issueRefreshToken(w, r, username, role, groups, time.Now().Add(refreshLifetime), cfg)
+	}
+	return nil
+}
+
+// startSession stores a new session and sets the cookies that carry it
+func startSession(w http.ResponseWriter, r *http.Request, username string, role string, groups []string, cfg *config.Config) error {
+	token, err := GenerateSessionToken()
+	if err != nil {
+		return err
 	}
 
 	hashedToken := hashToken(token)
+	agent, network := fingerprint(r, token, cfg.Server.TrustProxy)
 
-	mu.Lock()
-	sessions[hashedToken] = Session{
+	err = sessions.Put(hashedToken, Session{
 		Username:     username,
 		Role:         role,
 		Groups:       groups,
 		CreatedAt:    time.Now(),
-		ExpiresAt:    time.Now().Add(time.Duration(maxAge) * time.Second),
+		ExpiresAt:    time.Now().Add(sessionLifetime),
 		LastAccessed: time.Now(),
+		Agent:        agent,
+		Network:      network,
+	})
+	if err != nil {
+		return err
 	}
-	if sessionStore != nil {
-		if err := sessionStore.SaveSessions(sessions); err != nil {
-			log.Printf("Error saving sessions in CreateSession: %v", err)
-		}
-	} else {
-		log.Println("Warning: sessionStore is nil in CreateSession")
-	}
-	mu.Unlock()
 
 	// Set the secure HTTP-only session token cookie
 	http.SetCookie(w, &http.Cookie{
@@ -143,58 +269,178 @@ func CreateSession(w http.ResponseWriter, username string, role string, groups [
 		Value:    token,
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   !cfg.Server.AllowInsecureCookies,
+		Secure:   config.SecureCookies(cfg),
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   maxAge,
+		MaxAge:   int(sessionLifetime.Seconds()),
 	})
 
-	// Set a non-HTTP-only cookie for the username to be accessible by JavaScript
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_user",
-		Value:    username,
-		Path:     "/",
-		HttpOnly: false,
-		Secure:   !cfg.Server.AllowInsecureCookies,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   maxAge,
+	// Scripts learn who is signed in from /api/whoami. There is no cookie
+	// naming the user, which anyone could set and nothing could verify.
+	return nil
+}
+
+// issueRefreshToken stores a refresh token valid until expiresAt and sets its
+// cookie. The cookie is only sent to the API, where it is used and revoked.
+func issueRefreshToken(w http.ResponseWriter, r *http.Request, username string, role string, groups []string, expiresAt time.Time, cfg *config.Config) error {
+	token, err := GenerateSessionToken()
+	if err != nil {
+		return err
+	}
+
+	agent, network := fingerprint(r, token, cfg.Server.TrustProxy)
+	err = sessions.Put(hashToken(token), Session{
+		Username:     username,
+		Role:         role,
+		Groups:       groups,
+		CreatedAt:    time.Now(),
+		ExpiresAt:    expiresAt,
+		LastAccessed: time.Now(),
+		Agent:        agent,
+		Network:      network,
+		Refresh:      true,
 	})
+	if err != nil {
+		return err
+	}
 
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    token,
+		Path:     "/api/",
+		HttpOnly: true,
+		Secure:   config.SecureCookies(cfg),
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+	})
 	return nil
 }
 
+// RefreshSession trades the refresh token of the request for a new session
+// and returns it. The refresh token is used up and replaced by one that
+// expires at the same time, so a copied token works at most once.
+func RefreshSession(w http.ResponseWriter, r *http.Request, cfg *config.Config) (*Session, error) {
+	c, err := r.Cookie("refresh_token")
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	hashedToken := hashToken(c.Value)
+	refresh, exists, err := sessions.Get(hashedToken)
+	if err != nil {
+		return nil, err
+	}
+	if !exists || !refresh.Refresh {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err := sessions.Delete(hashedToken); err != nil {
+		return nil, err
+	}
+	if refresh.IsExpired() {
+		return nil, ErrInvalidRefreshToken
+	}
+	if changed := fingerprintMismatch(r, c.Value, &refresh); changed != "" {
+		log.Printf("Warning: refresh token of %s used from another %s, possible hijack; token revoked", refresh.Username, changed)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The user may have been removed, disabled or given another role since the
+	// token was issued, so the new session is made from the user as it is now
+	i := slices.IndexFunc(cfg.Users, func(u config.User) bool { return u.Username == refresh.Username })
+	if i < 0 || cfg.Users[i].Disabled {
+		return nil, ErrInvalidRefreshToken
+	}
+	user := cfg.Users[i]
+
+	if err := startSession(w, r, user.Username, user.Role, user.Groups, cfg); err != nil {
+		return nil, err
+	}
+	if err := issueRefreshToken(w, r, user.Username, user.Role, user.Groups, refresh.ExpiresAt, cfg); err != nil {
+		return nil, err
+	}
+	return &Session{Username: user.Username, Role: user.Role, Groups: user.Groups}, nil
+}
+
 // GetSession retrieves the session for the current request
 func GetSession(r *http.Request) *Session {
+	return lookupSession(r, true)
+}
+
+// PeekSession retrieves the session for the current request like GetSession,
+// but without counting the request as activity of the user, for requests the
+// browser makes on its own such as polling the session status
+func PeekSession(r *http.Request) *Session {
+	return lookupSession(r, false)
+}
+
+// lookupSession retrieves the session for the request, updating when it was
+// last accessed if touch is set
+func lookupSession(r *http.Request, touch bool) *Session {
 	c, err := r.Cookie("session_token")
 	if err != nil {
 		return nil
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
 	hashedToken := hashToken(c.Value)
-	session, exists := sessions[hashedToken]
-	if !exists {
+	session, exists, err := sessions.Get(hashedToken)
+	if err != nil {
+		log.Printf("Error reading session: %v", err)
+		return nil
+	}
+	if !exists || session.Refresh {
 		return nil
 	}
 
 	if session.IsExpired() {
-		delete(sessions, hashedToken)
-		if sessionStore != nil {
-			sessionStore.SaveSessions(sessions)
-		}
+		sessions.Delete(hashedToken)
 		return nil
 	}
 
+	// A session used from another browser or network than it was created
+	// from may have had its token stolen
+	if changed := fingerprintMismatch(r, c.Value, &session); changed != "" {
+		log.Printf("Warning: session of %s used from another %s, possible hijack; session ended", session.Username, changed)
+		sessions.Delete(hashedToken)
+		return nil
+	}
+
+	if !touch {
+		return &session
+	}
+
 	// Update LastAccessed
 	session.LastAccessed = time.Now()
-	sessions[hashedToken] = session
+	if err := sessions.Touch(hashedToken, session); err != nil {
+		log.Printf("Error updating session: %v", err)
+	}
 
 	return &session
 }
 
+// HasRefreshToken reports whether the request carries a refresh token, which
+// RefreshSession may trade for a new session. The token is only checked when
+// it is used.
+func HasRefreshToken(r *http.Request) bool {
+	_, err := r.Cookie("refresh_token")
+	return err == nil
+}
+
 // ClearSession removes the session from the sessions map and clears the cookie
 func ClearSession(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	// Revoke the refresh token too, so signing out ends "keep me logged in"
+	if c, err := r.Cookie("refresh_token"); err == nil {
+		if err := sessions.Delete(hashToken(c.Value)); err != nil {
+			log.Printf("Error revoking refresh token in ClearSession: %v", err)
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     "refresh_token",
+			Value:    "",
+			Path:     "/api/",
+			HttpOnly: true,
+			Secure:   config.SecureCookies(cfg),
+			MaxAge:   -1,
+		})
+	}
+
 	c, err := r.Cookie("session_token")
 	if err != nil {
 		return
@@ -202,18 +448,9 @@ func ClearSession(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 
 	hashedToken := hashToken(c.Value)
 
-	mu.Lock()
-	if _, exists := sessions[hashedToken]; exists {
-		delete(sessions, hashedToken)
-		if sessionStore != nil {
-			if err := sessionStore.SaveSessions(sessions); err != nil {
-				log.Printf("Error saving sessions in ClearSession: %v", err)
-			}
-		}
-	} else {
-		log.Printf("Warning: Session not found during logout for token hash: %s", hashedToken)
+	if err := sessions.Delete(hashedToken); err != nil {
+		log.Printf("Error ending session in ClearSession: %v", err)
 	}
-	mu.Unlock()
 
 	// Clear the session token cookie
 	http.SetCookie(w, &http.Cookie{
@@ -221,25 +458,26 @@ func ClearSession(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   !cfg.Server.AllowInsecureCookies,
+		Secure:   config.SecureCookies(cfg),
 		MaxAge:   -1,
 	})
 
-	// Clear the session user cookie
+	// Clear the session_user cookie set by earlier versions
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session_user",
 		Value:    "",
 		Path:     "/",
 		HttpOnly: false,
-		Secure:   !cfg.Server.AllowInsecureCookies,
+		Secure:   config.SecureCookies(cfg),
 		MaxAge:   -1,
 	})
 }
 
-// ValidateCredentials validates user credentials against the config
+// ValidateCredentials validates user credentials against the config. Disabled
+// users are rejected as if their password were wrong.
 func ValidateCredentials(username, password string, cfg *config.Config) (bool, string, []string) {
 	for _, user := range cfg.Users {
-		if user.Username == username && crypto.CheckPasswordHash(password, user.Password) {
+		if user.Username == username && !user.Disabled && crypto.CheckPasswordHash(password, user.Password) {
 			// Use the user's role and groups
 			return true, user.Role, user.Groups
 		}
@@ -268,6 +506,16 @@ func RequireAuth(r *http.Request, cfg *config.Config) bool {
 	return CanAccessDocument(path, session, cfg)
 }
 
+// CanContribute reports whether the user may make changes that are open to any
+// signed-in user, such as posting comments. In read-only-public mode only
+// editors and admins can change anything.
+func CanContribute(r *http.Request, cfg *config.Config) bool {
+	if cfg.Wiki.AccessMode == config.AccessModeReadOnlyPublic {
+		return RequireRole(r, config.RoleEditor)
+	}
+	return GetSession(r) != nil
+}
+
 // RequireRole checks if user has required role or higher
 func RequireRole(r *http.Request, requiredRole string) bool {
 	session := GetSession(r)
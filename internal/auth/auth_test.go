@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/crypto"
+)
+
+// requestWith returns a request carrying the cookies set on rec
+func requestWith(rec *httptest.ResponseRecorder, cookies ...string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/refresh", nil)
+	for _, c := range rec.Result().Cookies() {
+		for _, name := range cookies {
+			if c.Name == name && c.MaxAge >= 0 {
+				req.AddCookie(c)
+			}
+		}
+	}
+	return req
+}
+
+func TestRefreshSession(t *testing.T) {
+	tests := []struct {
+		name         string
+		keepLoggedIn bool
+		wantRefresh  bool
+	}{
+		{"kept logged in", true, true},
+		{"session only", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions = NewMemorySessionStore("")
+			cfg := &config.Config{Users: []config.User{{Username: "ed", Role: config.RoleEditor}}}
+
+			login := httptest.NewRecorder()
+			if err := CreateSession(login, httptest.NewRequest(http.MethodPost, "/api/login", nil), "ed", config.RoleEditor, nil, tt.keepLoggedIn, cfg); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range login.Result().Cookies() {
+				if c.Name == "session_token" && c.MaxAge != int(sessionLifetime.Seconds()) {
+					t.Errorf("session cookie lasts %ds", c.MaxAge)
+				}
+			}
+			if got := ActiveSessionCount(); got != 1 {
+				t.Errorf("ActiveSessionCount() = %d, want 1", got)
+			}
+
+			// A refresh token is no session
+			if GetSession(requestWith(login, "refresh_token")) != nil {
+				t.Error("refresh token accepted as a session")
+			}
+			withToken := requestWith(login, "refresh_token")
+			if c, err := withToken.Cookie("refresh_token"); err == nil {
+				withToken.AddCookie(&http.Cookie{Name: "session_token", Value: c.Value})
+				if GetSession(withToken) != nil {
+					t.Error("refresh token accepted as a session cookie")
+				}
+			}
+
+			refreshed := httptest.NewRecorder()
+			session, err := RefreshSession(refreshed, requestWith(login, "refresh_token"), cfg)
+			if !tt.wantRefresh {
+				if !errors.Is(err, ErrInvalidRefreshToken) {
+					t.Fatalf("RefreshSession() error = %v, want ErrInvalidRefreshToken", err)
+				}
+				return
+			}
+			if err != nil || session.Username != "ed" || session.Role != config.RoleEditor {
+				t.Fatalf("RefreshSession() = %+v, %v", session, err)
+			}
+			if GetSession(requestWith(refreshed, "session_token")) == nil {
+				t.Error("new session not valid")
+			}
+
+			// The refresh token was used up and replaced
+			if _, err := RefreshSession(httptest.NewRecorder(), requestWith(login, "refresh_token"), cfg); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("reused refresh token: error = %v", err)
+			}
+
+			// Signing out revokes the refresh token
+			ClearSession(httptest.NewRecorder(), requestWith(refreshed, "session_token", "refresh_token"), cfg)
+			if _, err := RefreshSession(httptest.NewRecorder(), requestWith(refreshed, "refresh_token"), cfg); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("refresh after sign out: error = %v", err)
+			}
+		})
+	}
+}
+
+// A refresh makes the session of the user as configured now, not as the
+// token remembers it
+func TestRefreshSessionUsesCurrentUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		users []config.User
+		role  string // Of the new session; empty when the refresh is refused
+	}{
+		{"unchanged", []config.User{{Username: "ed", Role: config.RoleEditor}}, config.RoleEditor},
+		{"demoted", []config.User{{Username: "ed", Role: config.RoleViewer}}, config.RoleViewer},
+		{"disabled", []config.User{{Username: "ed", Role: config.RoleEditor, Disabled: true}}, ""},
+		{"deleted", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions = NewMemorySessionStore("")
+			login := httptest.NewRecorder()
+			if err := CreateSession(login, httptest.NewRequest(http.MethodPost, "/api/login", nil), "ed", config.RoleEditor, []string{"dev"}, true, &config.Config{}); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &config.Config{Users: tt.users}
+			session, err := RefreshSession(httptest.NewRecorder(), requestWith(login, "refresh_token"), cfg)
+			if tt.role == "" {
+				if !errors.Is(err, ErrInvalidRefreshToken) {
+					t.Errorf("RefreshSession() = %+v, %v; want ErrInvalidRefreshToken", session, err)
+				}
+				return
+			}
+			if err != nil || session.Role != tt.role || len(session.Groups) != 0 {
+				t.Errorf("RefreshSession() = %+v, %v; want role %s without groups", session, err, tt.role)
+			}
+		})
+	}
+}
+
+func TestRevokeRefreshTokens(t *testing.T) {
+	sessions = NewMemorySessionStore("")
+	cfg := &config.Config{Users: []config.User{{Username: "ed", Role: config.RoleEditor}, {Username: "vi", Role: config.RoleEditor}}}
+
+	var recs []*httptest.ResponseRecorder
+	for _, username := range []string{"ed", "ed", "vi"} {
+		rec := httptest.NewRecorder()
+		if err := CreateSession(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil), username, config.RoleEditor, nil, true, cfg); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+
+	if got := RevokeRefreshTokens("ed"); got != 2 {
+		t.Errorf("RevokeRefreshTokens() = %d, want 2", got)
+	}
+	for i, rec := range recs {
+		// Current sessions go on
+		if GetSession(requestWith(rec, "session_token")) == nil {
+			t.Errorf("session %d ended", i)
+		}
+		_, err := RefreshSession(httptest.NewRecorder(), requestWith(rec, "refresh_token"), cfg)
+		if revoked := i < 2; revoked != errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("refresh token %d: error = %v", i, err)
+		}
+	}
+}
+
+func TestEndSessions(t *testing.T) {
+	sessions = NewMemorySessionStore("")
+	cfg := &config.Config{Users: []config.User{{Username: "ed", Role: config.RoleEditor}, {Username: "vi", Role: config.RoleEditor}}}
+
+	var recs []*httptest.ResponseRecorder
+	for _, username := range []string{"ed", "ed", "vi"} {
+		rec := httptest.NewRecorder()
+		if err := CreateSession(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil), username, config.RoleEditor, nil, true, cfg); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+
+	// Each login made a session and a refresh token
+	if got := EndSessions("ed"); got != 4 {
+		t.Errorf("EndSessions() = %d, want 4", got)
+	}
+	for i, rec := range recs {
+		ended := i < 2
+		if (GetSession(requestWith(rec, "session_token")) == nil) != ended {
+			t.Errorf("session %d: ended = %t, want %t", i, !ended, ended)
+		}
+		_, err := RefreshSession(httptest.NewRecorder(), requestWith(rec, "refresh_token"), cfg)
+		if ended != errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("refresh token %d: error = %v", i, err)
+		}
+	}
+}
+
+func TestValidateCredentialsDisabled(t *testing.T) {
+	hash, err := crypto.HashPassword("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Users: []config.User{
+		{Username: "ed", Password: hash, Role: config.RoleEditor},
+		{Username: "vi", Password: hash, Role: config.RoleViewer, Disabled: true},
+	}}
+	if valid, _, _ := ValidateCredentials("ed", "secret", cfg); !valid {
+		t.Error("enabled user rejected")
+	}
+	if valid, _, _ := ValidateCredentials("vi", "secret", cfg); valid {
+		t.Error("disabled user accepted")
+	}
+}
+
+// Identity comes only from the session token; session_user is no longer set
+// and a forged one changes nothing
+func TestSessionUserCookieIgnored(t *testing.T) {
+	sessions = NewMemorySessionStore("")
+	cfg := &config.Config{}
+
+	login := httptest.NewRecorder()
+	if err := CreateSession(login, httptest.NewRequest(http.MethodPost, "/api/login", nil), "vi", config.RoleViewer, nil, false, cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range login.Result().Cookies() {
+		if c.Name == "session_user" {
+			t.Errorf("session_user cookie set: %q", c.Value)
+		}
+	}
+
+	forged := &http.Cookie{Name: "session_user", Value: "admin"}
+	tests := []struct {
+		name     string
+		req      *http.Request
+		wantUser string
+	}{
+		{"forged cookie only", httptest.NewRequest(http.MethodGet, "/", nil), ""},
+		{"forged cookie with a session", requestWith(login, "session_token"), "vi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.AddCookie(forged)
+			got := ""
+			if session := GetSession(tt.req); session != nil {
+				got = session.Username
+			}
+			if got != tt.wantUser {
+				t.Errorf("session user = %q, want %q", got, tt.wantUser)
+			}
+			if RequireRole(tt.req, config.RoleAdmin) {
+				t.Error("forged session_user granted the admin role")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"sync/atomic"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/ipfilter"
+)
+
+// sessionBinding is what sessions are checked against on every request
+type sessionBinding struct {
+	mode       string // One of the config.SessionBinding values; "" checks nothing
+	trustProxy bool   // Take the client address from proxy headers
+}
+
+var binding atomic.Pointer[sessionBinding]
+
+// SetSessionBinding sets what sessions stay bound to: config.SessionBindingNone,
+// config.SessionBindingUserAgent or config.SessionBindingUserAgentIP. Sessions
+// are always fingerprinted when created, so a stricter binding applies to
+// existing sessions too.
+func SetSessionBinding(mode string, trustProxy bool) {
+	binding.Store(&sessionBinding{mode: mode, trustProxy: trustProxy})
+}
+
+// minorVersions matches the minor parts of version numbers, which browsers
+// change with every update
+var minorVersions = regexp.MustCompile(`(\d+)(?:[._]\d+)+`)
+
+// fingerprint returns hashes of the browser and the network of the client
+// making r. They are salted with the session token, which the session store
+// doesn't keep, so the store holds nothing that identifies the client.
+func fingerprint(r *http.Request, token string, trustProxy bool) (agent, network string) {
+	ua := minorVersions.ReplaceAllString(r.UserAgent(), "$1")
+	agent = fingerprintHash(token, "agent", ua)
+
+	// Clients keep their subnet while their address changes within it
+	var subnet netip.Prefix
+	if addr, ok := ipfilter.ClientAddr(r, trustProxy); ok {
+		bits := 48
+		if addr.Is4() || addr.Is4In6() {
+			addr, bits = addr.Unmap(), 24
+		}
+		subnet, _ = addr.Prefix(bits)
+	}
+	network = fingerprintHash(token, "network", subnet.String())
+	return agent, network
+}
+
+func fingerprintHash(token, kind, value string) string {
+	sum := sha256.Sum256([]byte(token + "\x00" + kind + "\x00" + value))
+	return hex.EncodeToString(sum[:16])
+}
+
+// fingerprintMismatch returns what about the client making r differs from
+// the one session was created by, under the configured binding, or "" when
+// the request may use the session
+func fingerprintMismatch(r *http.Request, token string, session *Session) string {
+	b := binding.Load()
+	if b == nil || b.mode == "" || b.mode == config.SessionBindingNone || session.Agent == "" {
+		// Sessions created before fingerprinting can't be checked
+		return ""
+	}
+	agent, network := fingerprint(r, token, b.trustProxy)
+	if agent != session.Agent {
+		return "browser"
+	}
+	if b.mode == config.SessionBindingUserAgentIP && network != session.Network {
+		return "network"
+	}
+	return ""
+}
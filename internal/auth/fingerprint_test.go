@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestSessionBinding(t *testing.T) {
+	const (
+		firefox    = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+		firefoxFix = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0.1"
+		chrome     = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	)
+	tests := []struct {
+		name   string
+		mode   string
+		agent  string
+		remote string
+		want   bool // The session is still valid
+	}{
+		{"same client", config.SessionBindingUserAgentIP, firefox, "192.0.2.10:1234", true},
+		{"browser update", config.SessionBindingUserAgent, firefoxFix, "192.0.2.10:1234", true},
+		{"other browser", config.SessionBindingUserAgent, chrome, "192.0.2.10:1234", false},
+		{"other network, agent only", config.SessionBindingUserAgent, firefox, "198.51.100.7:1234", true},
+		{"same subnet", config.SessionBindingUserAgentIP, firefox, "192.0.2.99:4321", true},
+		{"other network", config.SessionBindingUserAgentIP, firefox, "198.51.100.7:1234", false},
+		{"other browser, unbound", config.SessionBindingNone, chrome, "198.51.100.7:1234", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions = NewMemorySessionStore("")
+			SetSessionBinding(tt.mode, false)
+			t.Cleanup(func() { SetSessionBinding("", false) })
+
+			login := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+			login.Header.Set("User-Agent", firefox)
+			login.RemoteAddr = "192.0.2.10:1234"
+			rec := httptest.NewRecorder()
+			if err := CreateSession(rec, login, "ed", config.RoleEditor, nil, false, &config.Config{}); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", tt.agent)
+			req.RemoteAddr = tt.remote
+			for _, c := range rec.Result().Cookies() {
+				req.AddCookie(c)
+			}
+			if got := GetSession(req) != nil; got != tt.want {
+				t.Fatalf("session valid = %t, want %t", got, tt.want)
+			}
+			// A rejected session is ended, not just refused once
+			req.Header.Set("User-Agent", firefox)
+			req.RemoteAddr = "192.0.2.10:1234"
+			if got := GetSession(req) != nil; got != tt.want {
+				t.Errorf("session valid from its own client = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedisSessionPrefix starts the keys of sessions kept in Redis
+const DefaultRedisSessionPrefix = "wikigo:session:"
+
+// redisTimeout bounds each round trip to Redis
+const redisTimeout = 5 * time.Second
+
+// The replies of the commands the store sends are small: a session, a page of
+// SCAN keys or the sessions of a page. Larger lengths are refused rather than
+// allocated, as are arrays nested deeper than a SCAN reply.
+const (
+	maxRedisBulk  = 1 << 20
+	maxRedisArray = 1 << 16
+	maxRedisDepth = 2
+)
+
+// RedisSessionStore keeps sessions in Redis, so several wiki instances can
+// share them. Each session is a JSON value whose TTL ends with the session,
+// leaving expiry to Redis.
+type RedisSessionStore struct {
+	address  string
+	password string
+	db       int
+	prefix   string
+
+	mu     sync.Mutex // Serializes use of the connection
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from Redis
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedisSessionStore returns a store for the Redis server at address
+// (host:port). The connection is opened on first use. An empty prefix uses
+// DefaultRedisSessionPrefix.
+func NewRedisSessionStore(address, password string, db int, prefix string) *RedisSessionStore {
+	if prefix == "" {
+		prefix = DefaultRedisSessionPrefix
+	}
+	return &RedisSessionStore{address: address, password: password, db: db, prefix: prefix}
+}
+
+// Ping checks that Redis can be reached with the configured credentials
+func (s *RedisSessionStore) Ping() error {
+	_, err := s.do("PING")
+	return err
+}
+
+// Get returns a stored session
+func (s *RedisSessionStore) Get(hash string) (Session, bool, error) {
+	reply, err := s.do("GET", s.prefix+hash)
+	if err != nil || reply == nil {
+		return Session{}, false, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return Session{}, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return Session{}, false, fmt.Errorf("decoding session: %w", err)
+	}
+	return session, true, nil
+}
+
+// Put stores a session with a TTL ending at its expiry
+func (s *RedisSessionStore) Put(hash string, session Session) error {
+	ttl := time.Until(session.ExpiresAt).Milliseconds()
+	if ttl <= 0 {
+		return s.Delete(hash)
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = s.do("SET", s.prefix+hash, string(data), "PX", strconv.FormatInt(ttl, 10))
+	return err
+}
+
+// Touch does nothing. Nothing reads the last use back, and writing it on
+// every request would cost a round trip and could store again a session that
+// was deleted since it was read.
+func (s *RedisSessionStore) Touch(hash string, session Session) error {
+	return nil
+}
+
+// Delete removes a session
+func (s *RedisSessionStore) Delete(hash string) error {
+	_, err := s.do("DEL", s.prefix+hash)
+	return err
+}
+
+// All returns every session under the prefix
+func (s *RedisSessionStore) All() (map[string]Session, error) {
+	sessions := make(map[string]Session)
+	cursor := "0"
+	for {
+		reply, err := s.do("SCAN", cursor, "MATCH", s.prefix+"*", "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return nil, errors.New("redis: unexpected SCAN reply")
+		}
+		cursorBytes, _ := page[0].([]byte)
+		keys, ok := page[1].([]interface{})
+		if cursorBytes == nil || !ok {
+			return nil, errors.New("redis: unexpected SCAN reply")
+		}
+
+		if len(keys) > 0 {
+			args := []string{"MGET"}
+			for _, key := range keys {
+				if key, ok := key.([]byte); ok {
+					args = append(args, string(key))
+				}
+			}
+			reply, err := s.do(args...)
+			if err != nil {
+				return nil, err
+			}
+			values, ok := reply.([]interface{})
+			if !ok || len(values) != len(args)-1 {
+				return nil, errors.New("redis: unexpected MGET reply")
+			}
+			for i, value := range values {
+				data, ok := value.([]byte)
+				if !ok {
+					continue // Expired since the scan
+				}
+				var session Session
+				if err := json.Unmarshal(data, &session); err != nil {
+					continue
+				}
+				sessions[strings.TrimPrefix(args[i+1], s.prefix)] = session
+			}
+		}
+
+		cursor = string(cursorBytes)
+		if cursor == "0" || cursor == "" {
+			return sessions, nil
+		}
+	}
+}
+
+// RemoveExpired removes nothing; Redis expires sessions through their TTL
+func (s *RedisSessionStore) RemoveExpired() (int, error) {
+	return 0, nil
+}
+
+// Close closes the connection
+func (s *RedisSessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// do sends a command and returns its reply: nil, int64, []byte for strings
+// or []interface{} for arrays. After a network error or a malformed reply the
+// connection is dropped, as its state is unknown; the next command dials again.
+func (s *RedisSessionStore) do(args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply, err := s.roundTrip(args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) && s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+// roundTrip sends one command on the connection, opening it if needed
+func (s *RedisSessionStore) roundTrip(args []string) (interface{}, error) {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+	s.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if err := writeRedisCommand(s.conn, args); err != nil {
+		return nil, err
+	}
+	return readRedisReply(s.reader)
+}
+
+// connect dials Redis, authenticates and selects the database
+func (s *RedisSessionStore) connect() error {
+	conn, err := net.DialTimeout("tcp", s.address, redisTimeout)
+	if err != nil {
+		return err
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if s.password != "" {
+		setup = append(setup, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	for _, args := range setup {
+		if _, err := s.roundTrip(args); err != nil {
+			conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRedisCommand writes args as a RESP array of bulk strings
+func writeRedisCommand(w io.Writer, args []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// readRedisReply reads one RESP reply
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	return readRedisValue(r, 0)
+}
+
+// readRedisValue reads a reply nested depth arrays deep. Only the types the
+// store's commands return are understood: simple strings, errors, integers,
+// bulk strings and arrays.
+func readRedisValue(r *bufio.Reader, depth int) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line, ok := strings.CutSuffix(line, "\r\n")
+	if !ok || line == "" {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed integer %q", line)
+		}
+		return n, nil
+	case '$':
+		n, err := redisLength(line, maxRedisBulk)
+		if err != nil || n < 0 {
+			return nil, err // $-1 is a nil reply
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		if data[n] != '\r' || data[n+1] != '\n' {
+			return nil, errors.New("redis: bulk string not terminated")
+		}
+		return data[:n], nil
+	case '*':
+		if depth >= maxRedisDepth {
+			return nil, errors.New("redis: reply nested too deep")
+		}
+		n, err := redisLength(line, maxRedisArray)
+		if err != nil || n < 0 {
+			return nil, err // *-1 is a nil reply
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRedisValue(r, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// redisLength parses the length of a bulk string or array reply: -1 for nil,
+// or 0 to max
+func redisLength(line string, max int) (int, error) {
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < -1 || n > max {
+		return 0, fmt.Errorf("redis: invalid length in %q", line)
+	}
+	return n, nil
+}
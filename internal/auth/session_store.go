@@ -2,37 +2,164 @@ package auth
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
+
+	"wiki-go/internal/config"
 )
 
-// SessionStore manages session persistence
-type SessionStore struct {
+// SessionStore keeps sessions keyed by the SHA256 hash of their token. A store
+// shared between instances, such as Redis, lets any instance behind a load
+// balancer serve any signed-in user.
+type SessionStore interface {
+	// Get returns the session stored under hash; ok is false when there is none
+	Get(hash string) (session Session, ok bool, err error)
+	// Put stores a session until its ExpiresAt
+	Put(hash string, session Session) error
+	// Touch records that a session was used. It may be kept in memory only, as
+	// it's called on every request.
+	Touch(hash string, session Session) error
+	// Delete removes a session; deleting a missing session is not an error
+	Delete(hash string) error
+	// All returns every stored session by hash
+	All() (map[string]Session, error)
+	// RemoveExpired removes expired sessions and returns how many it removed
+	RemoveExpired() (int, error)
+	// Close releases the store, persisting sessions where needed
+	Close() error
+}
+
+// OpenSessionStore returns the session store configured in server.sessions.
+// Sessions kept in memory are saved to temp/sessions.json below the wiki root
+// so logins survive a restart.
+func OpenSessionStore(cfg *config.Config) (SessionStore, error) {
+	switch cfg.Server.Sessions.Store {
+	case "", config.SessionStoreMemory:
+		return NewMemorySessionStore(filepath.Join(cfg.Wiki.RootDir, "temp", "sessions.json")), nil
+	case config.SessionStoreRedis:
+		redis := cfg.Server.Sessions.Redis
+		store := NewRedisSessionStore(redis.Address, redis.Password, redis.DB, redis.Prefix)
+		if err := store.Ping(); err != nil {
+			return nil, fmt.Errorf("connecting to redis at %s: %w", redis.Address, err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q", cfg.Server.Sessions.Store)
+	}
+}
+
+// MemorySessionStore keeps sessions in memory and, when it has a file path,
+// saves them to that file on every change
+type MemorySessionStore struct {
 	mu       sync.RWMutex
+	sessions map[string]Session
 	filePath string
 }
 
-// NewSessionStore creates a new session store
-func NewSessionStore(filePath string) *SessionStore {
+// NewMemorySessionStore creates a memory store, loading the sessions saved in
+// filePath. An empty filePath keeps sessions in memory only.
+func NewMemorySessionStore(filePath string) *MemorySessionStore {
+	s := &MemorySessionStore{sessions: make(map[string]Session), filePath: filePath}
+	if filePath == "" {
+		return s
+	}
+
 	// Ensure directory exists
-	if dir := filepath.Dir(filePath); dir != "" {
-		if err := os.MkdirAll(dir, 0o700); err != nil {
-			log.Printf("Error creating session store directory: %v", err)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+		log.Printf("Error creating session store directory: %v", err)
+	}
+	if err := s.load(); err != nil {
+		log.Printf("Error loading sessions from %s: %v", filePath, err)
+	}
+	for token, session := range s.sessions {
+		if session.IsExpired() {
+			delete(s.sessions, token)
 		}
 	}
+	return s
+}
+
+// Get returns a stored session
+func (s *MemorySessionStore) Get(hash string) (Session, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[hash]
+	return session, ok, nil
+}
+
+// Put stores a session and saves the sessions to disk
+func (s *MemorySessionStore) Put(hash string, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[hash] = session
+	return s.save()
+}
+
+// Touch updates a session in memory only; it's saved with the next change
+func (s *MemorySessionStore) Touch(hash string, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[hash]; ok {
+		s.sessions[hash] = session
+	}
+	return nil
+}
+
+// Delete removes a session and saves the sessions to disk
+func (s *MemorySessionStore) Delete(hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[hash]; !ok {
+		return nil
+	}
+	delete(s.sessions, hash)
+	return s.save()
+}
+
+// All returns a copy of the stored sessions
+func (s *MemorySessionStore) All() (map[string]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := make(map[string]Session, len(s.sessions))
+	for hash, session := range s.sessions {
+		all[hash] = session
+	}
+	return all, nil
+}
+
+// RemoveExpired removes expired sessions, saving to disk if there were any
+func (s *MemorySessionStore) RemoveExpired() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return &SessionStore{
-		filePath: filePath,
+	deleted := 0
+	for token, session := range s.sessions {
+		if session.IsExpired() {
+			delete(s.sessions, token)
+			deleted++
+		}
 	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	return deleted, s.save()
 }
 
-// SaveSessions saves the sessions to disk
-func (s *SessionStore) SaveSessions(sessions map[string]Session) error {
+// Close saves the sessions, including the last use of each
+func (s *MemorySessionStore) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.save()
+}
+
+// save writes the sessions to disk; the caller holds s.mu
+func (s *MemorySessionStore) save() error {
+	if s.filePath == "" {
+		return nil
+	}
 
 	// Create a temporary file
 	tempFile := s.filePath + ".tmp"
@@ -43,90 +170,30 @@ func (s *SessionStore) SaveSessions(sessions map[string]Session) error {
 
 	// Encode sessions to JSON
 	encoder := json.NewEncoder(f)
-	if err := encoder.Encode(sessions); err != nil {
+	if err := encoder.Encode(s.sessions); err != nil {
 		f.Close()
 		return err
 	}
 	f.Close()
 
 	// Rename temporary file to actual file (atomic operation)
-	if err := os.Rename(tempFile, s.filePath); err != nil {
-		return err
-	}
-
-	return nil
+	return os.Rename(tempFile, s.filePath)
 }
 
-// LoadSessions loads sessions from disk
-func (s *SessionStore) LoadSessions() (map[string]Session, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	sessions := make(map[string]Session)
-
-	// Check if file exists
-	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
-		return sessions, nil
+// load reads the sessions saved on disk
+func (s *MemorySessionStore) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
 	}
-
-	// Read file
-	data, err := ioutil.ReadFile(s.filePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Decode JSON
-	if err := json.Unmarshal(data, &sessions); err != nil {
-		// If file is corrupted, return empty map and log error
+	if err := json.Unmarshal(data, &s.sessions); err != nil {
+		// If file is corrupted, start empty and log error
 		log.Printf("Error decoding session file: %v", err)
-		return sessions, nil
-	}
-
-	return sessions, nil
-}
-
-// CleanupExpiredSessions removes expired sessions from the map and saves to disk
-func (s *SessionStore) CleanupExpiredSessions(sessions map[string]Session) {
-	s.mu.Lock()
-	defer s.mu.Unlock() // Lock for the whole operation to ensure consistency
-
-	deleted := 0
-
-	for token, session := range sessions {
-		if session.IsExpired() {
-			delete(sessions, token)
-			deleted++
-		}
-	}
-
-	if deleted > 0 {
-		// We need to save the cleaned sessions.
-		// Since we are already holding the lock, we can't call SaveSessions directly
-		// because it also tries to acquire the lock.
-		// We should refactor SaveSessions or duplicate the logic here.
-		// For simplicity and correctness with the current structure, let's duplicate the save logic
-		// but without the lock acquisition.
-
-		// Create a temporary file
-		tempFile := s.filePath + ".tmp"
-		f, err := os.OpenFile(tempFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
-		if err != nil {
-			log.Printf("Error creating temp session file during cleanup: %v", err)
-			return
-		}
-
-		// Encode sessions to JSON
-		encoder := json.NewEncoder(f)
-		if err := encoder.Encode(sessions); err != nil {
-			f.Close()
-			log.Printf("Error encoding sessions during cleanup: %v", err)
-			return
-		}
-		f.Close()
-
-		// Rename temporary file to actual file (atomic operation)
-		if err := os.Rename(tempFile, s.filePath); err != nil {
-			log.Printf("Error renaming session file during cleanup: %v", err)
-		}
+		s.sessions = make(map[string]Session)
 	}
+	return nil
 }
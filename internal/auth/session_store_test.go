@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers the commands RedisSessionStore sends, ignoring TTLs
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	password string
+}
+
+func (f *fakeRedis) serve(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		reply, err := readRedisReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		f.mu.Lock()
+		var out string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authed = args[1] == f.password
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		case cmd == "PING":
+			out = "+PONG\r\n"
+		case cmd == "SET":
+			f.values[args[1]] = args[2]
+			out = "+OK\r\n"
+		case cmd == "GET":
+			out = bulk(f.values[args[1]], f.has(args[1]))
+		case cmd == "DEL":
+			delete(f.values, args[1])
+			out = ":1\r\n"
+		case cmd == "MGET":
+			out = "*" + strconv.Itoa(len(args)-1) + "\r\n"
+			for _, key := range args[1:] {
+				out += bulk(f.values[key], f.has(key))
+			}
+		case cmd == "SCAN":
+			prefix := strings.TrimSuffix(args[3], "*")
+			var keys []string
+			for key := range f.values {
+				if strings.HasPrefix(key, prefix) {
+					keys = append(keys, key)
+				}
+			}
+			out = "*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(keys)) + "\r\n"
+			for _, key := range keys {
+				out += bulk(key, true)
+			}
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		conn.Write([]byte(out))
+	}
+}
+
+func (f *fakeRedis) has(key string) bool {
+	_, ok := f.values[key]
+	return ok
+}
+
+func bulk(value string, ok bool) string {
+	if !ok {
+		return "$-1\r\n"
+	}
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
+
+func TestSessionStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) SessionStore{
+		"memory": func(t *testing.T) SessionStore {
+			return NewMemorySessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+		},
+		"redis": func(t *testing.T) SessionStore {
+			fake := &fakeRedis{values: map[string]string{}, password: "secret"}
+			store := NewRedisSessionStore(fake.serve(t), "secret", 0, "")
+			if err := store.Ping(); err != nil {
+				t.Fatal(err)
+			}
+			return store
+		},
+	}
+
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+
+			session := Session{Username: "alice", Role: "editor", Groups: []string{"dev"}, ExpiresAt: time.Now().Add(time.Hour)}
+			if err := store.Put("a", session); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Put("b", Session{Username: "bob", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok, err := store.Get("a")
+			if err != nil || !ok || got.Username != "alice" || got.Role != "editor" || len(got.Groups) != 1 {
+				t.Errorf("Get = %+v, %t, %v", got, ok, err)
+			}
+			if _, ok, err := store.Get("missing"); ok || err != nil {
+				t.Errorf("Get(missing) = %t, %v", ok, err)
+			}
+
+			all, err := store.All()
+			if err != nil || len(all) != 2 || all["b"].Username != "bob" {
+				t.Errorf("All = %+v, %v", all, err)
+			}
+
+			if err := store.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+			// A request that read the session before it was deleted touches it
+			if err := store.Touch("a", session); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok, _ := store.Get("a"); ok {
+				t.Error("session still stored after Delete")
+			}
+			if err := store.Delete("a"); err != nil {
+				t.Errorf("Delete of a missing session = %v", err)
+			}
+		})
+	}
+}
+
+func TestMemorySessionStorePersists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sessions.json")
+	store := NewMemorySessionStore(file)
+	store.Put("live", Session{Username: "alice", ExpiresAt: time.Now().Add(time.Hour)})
+	store.Put("expired", Session{Username: "bob", ExpiresAt: time.Now().Add(-time.Hour)})
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := NewMemorySessionStore(file)
+	if _, ok, _ := reopened.Get("live"); !ok {
+		t.Error("live session was not loaded")
+	}
+	if _, ok, _ := reopened.Get("expired"); ok {
+		t.Error("expired session was loaded")
+	}
+}
+
+func TestRedisSessionStoreRejectsWrongPassword(t *testing.T) {
+	fake := &fakeRedis{values: map[string]string{}, password: "secret"}
+	store := NewRedisSessionStore(fake.serve(t), "wrong", 0, "")
+	defer store.Close()
+	if err := store.Ping(); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Ping = %v, want WRONGPASS", err)
+	}
+}
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    string // fmt.Sprint of the value when there's no error
+		wantErr string
+	}{
+		{"simple string", "+OK\r\n", "[79 75]", ""},
+		{"integer", ":42\r\n", "42", ""},
+		{"bulk string", "$5\r\nhello\r\n", "[104 101 108 108 111]", ""},
+		{"nil bulk string", "$-1\r\n", "<nil>", ""},
+		{"scan page", "*2\r\n$1\r\n0\r\n*1\r\n$1\r\na\r\n", "[[48] [[97]]]", ""},
+		{"error", "-ERR wrong type\r\n", "", "ERR wrong type"},
+		{"bare newline", "+OK\n", "", "malformed reply"},
+		{"empty line", "\r\n", "", "malformed reply"},
+		{"unknown type", "%1\r\n", "", "unexpected reply"},
+		{"bad integer", ":x\r\n", "", "malformed integer"},
+		{"bad length", "$x\r\n", "", "invalid length"},
+		{"negative length", "$-2\r\nab\r\n", "", "invalid length"},
+		{"huge bulk string", "$1073741824\r\n", "", "invalid length"},
+		{"huge array", "*1073741824\r\n", "", "invalid length"},
+		{"unterminated bulk string", "$2\r\nabcd\r\n", "", "not terminated"},
+		{"nested too deep", "*1\r\n*1\r\n*0\r\n", "", "nested too deep"},
+		{"partial line", "+OK", "", "EOF"},
+		{"partial bulk string", "$5\r\nhel", "", "EOF"},
+		{"partial array", "*2\r\n:1\r\n", "", "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRedisReply(bufio.NewReader(strings.NewReader(tt.reply)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("readRedisReply() = %v, %v; want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || fmt.Sprint(got) != tt.want {
+				t.Errorf("readRedisReply() = %v, %v; want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Replies of the wrong type or cut short fail the command without breaking
+// the store; the next command dials again
+func TestRedisSessionStoreBadReplies(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	replies := []struct {
+		reply  string
+		hangUp bool // Close the connection after the reply
+	}{
+		{":1\r\n", false},
+		{"*3\r\n$1\r\n0\r\n", true},
+		{"*1\r\n:1\r\n", false},
+		{"$-1\r\n", false},
+	}
+	go func() {
+		for len(replies) > 0 {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			for len(replies) > 0 {
+				if _, err := readRedisReply(r); err != nil {
+					break
+				}
+				next := replies[0]
+				replies = replies[1:]
+				conn.Write([]byte(next.reply))
+				if next.hangUp {
+					break
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	store := NewRedisSessionStore(listener.Addr().String(), "", 0, "")
+	defer store.Close()
+	if _, _, err := store.Get("a"); err == nil || !strings.Contains(err.Error(), "unexpected GET reply") {
+		t.Errorf("Get with an integer reply = %v", err)
+	}
+	if _, err := store.All(); err == nil {
+		t.Error("All with a partial SCAN reply succeeded")
+	}
+	if _, err := store.All(); err == nil || !strings.Contains(err.Error(), "unexpected SCAN reply") {
+		t.Errorf("All with a short SCAN reply = %v", err)
+	}
+	if _, ok, err := store.Get("a"); ok || err != nil {
+		t.Errorf("Get after the errors = %t, %v", ok, err)
+	}
+}
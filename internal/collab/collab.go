@@ -0,0 +1,343 @@
+// Package collab lets several editors change a document at the same time.
+//
+// Every document being edited has a room holding its current text. Editors
+// send their changes as operations based on the version of the text they
+// last saw; the room transforms each one against the changes made since
+// (operational transformation), applies it, acknowledges it to the sender
+// and relays it to the others, so all copies converge. Editors also share
+// their cursor positions.
+//
+// Rooms only hold the text being edited: it is saved through the normal save
+// path, and a room told about a save that changed the text differently
+// replaces its copy and sends the whole document to its editors.
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"wiki-go/internal/websocket"
+)
+
+// maxHistory is how many operations a room keeps for transforming changes
+// based on older versions; editors further behind are sent the whole text
+const maxHistory = 1000
+
+// sendQueue is how many messages may wait for a slow editor before it is
+// disconnected
+const sendQueue = 64
+
+// writeTimeout limits how long sending a message to an editor may take
+const writeTimeout = 10 * time.Second
+
+// Conn is the connection to an editor, such as a *websocket.Conn
+type Conn interface {
+	ReadMessage() (int, []byte, error)
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	Close(code int, reason string) error
+}
+
+// Message is sent between the editors and a room, as JSON text messages
+type Message struct {
+	// Type is "init" (sent on joining), "op", "ack", "cursor", "join",
+	// "leave", "sync" (the whole text was replaced), "saved" or "error"
+	Type    string     `json:"type"`
+	Version int        `json:"version,omitempty"` // Version an op is based on, or the version reached
+	Op      *Operation `json:"op,omitempty"`
+	Index   *int       `json:"index,omitempty"`   // Cursor position in UTF-16 code units
+	Content *string    `json:"content,omitempty"` // Whole text, for init and sync
+	User    string     `json:"user,omitempty"`
+	ID      int        `json:"id,omitempty"`    // Connection of the user, as a user may edit in several tabs
+	Peers   []Peer     `json:"peers,omitempty"` // Other editors, for init
+	Message string     `json:"message,omitempty"`
+}
+
+// Peer is an editor in a room
+type Peer struct {
+	User   string `json:"user"`
+	ID     int    `json:"id"`
+	Cursor int    `json:"cursor"`
+}
+
+// Hub holds the rooms of the documents being edited. It is safe for
+// concurrent use.
+type Hub struct {
+	load func(docPath string) (string, error)
+
+	mu     sync.Mutex
+	rooms  map[string]*room
+	nextID int
+}
+
+// NewHub returns a hub that reads the saved text of a document with load
+func NewHub(load func(docPath string) (string, error)) *Hub {
+	return &Hub{load: load, rooms: make(map[string]*room)}
+}
+
+type room struct {
+	mu      sync.Mutex
+	text    []uint16
+	version int
+	history []*Operation // Operations that produced the last len(history) versions
+	clients map[*client]bool
+}
+
+type client struct {
+	id     int
+	user   string
+	cursor int
+	conn   Conn
+	send   chan []byte
+	done   chan struct{}
+}
+
+// Serve joins the editor user, connected through conn, to the room of
+// docPath and relays messages until the connection is closed
+func (h *Hub) Serve(docPath, user string, conn Conn) error {
+	docPath = normalize(docPath)
+	r, c, err := h.join(docPath, user, conn)
+	if err != nil {
+		conn.Close(websocket.CloseInternalError, "failed to open the document")
+		return err
+	}
+	defer conn.Close(websocket.CloseNormal, "")
+	defer h.leave(docPath, r, c)
+
+	go c.writeLoop()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return nil
+		}
+		var m Message
+		if err := json.Unmarshal(data, &m); err != nil {
+			c.queue(Message{Type: "error", Message: "invalid message"})
+			continue
+		}
+		r.receive(c, m)
+	}
+}
+
+// join adds an editor to the room of docPath, opening it if needed, and
+// sends the editor the current text
+func (h *Hub) join(docPath, user string, conn Conn) (*room, *client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := h.rooms[docPath]
+	if r == nil {
+		content, err := h.load(docPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = &room{text: utf16.Encode([]rune(content)), clients: make(map[*client]bool)}
+		h.rooms[docPath] = r
+	}
+	h.nextID++
+	c := &client{id: h.nextID, user: user, conn: conn, send: make(chan []byte, sendQueue), done: make(chan struct{})}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	peers := []Peer{}
+	for other := range r.clients {
+		peers = append(peers, Peer{User: other.user, ID: other.id, Cursor: other.cursor})
+	}
+	content := string(utf16.Decode(r.text))
+	c.queue(Message{Type: "init", Version: r.version, Content: &content, ID: c.id, Peers: peers})
+	r.broadcast(c, Message{Type: "join", User: user, ID: c.id})
+	r.clients[c] = true
+	return r, c, nil
+}
+
+// leave removes an editor and closes the room once nobody is left
+func (h *Hub) leave(docPath string, r *room, c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.clients[c] {
+		delete(r.clients, c)
+		close(c.done)
+		r.broadcast(nil, Message{Type: "leave", User: c.user, ID: c.id})
+	}
+	if len(r.clients) == 0 && h.rooms[docPath] == r {
+		delete(h.rooms, docPath)
+	}
+}
+
+// Saved tells the room of docPath, if it is open, that user saved the
+// document. When the saved text differs from the room's, the room takes the
+// saved text and sends it to every editor.
+func (h *Hub) Saved(docPath, user string) {
+	docPath = normalize(docPath)
+	h.mu.Lock()
+	r := h.rooms[docPath]
+	h.mu.Unlock()
+	if r == nil {
+		return
+	}
+	content, err := h.load(docPath)
+	if err != nil {
+		log.Printf("Warning: Failed to read %s after it was saved: %v", docPath, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if string(utf16.Decode(r.text)) == content {
+		r.broadcast(nil, Message{Type: "saved", User: user, Version: r.version})
+		return
+	}
+	r.text = utf16.Encode([]rune(content))
+	r.version++
+	r.history = nil
+	for c := range r.clients {
+		c.cursor = min(c.cursor, len(r.text))
+	}
+	r.broadcast(nil, Message{Type: "sync", User: user, Version: r.version, Content: &content})
+}
+
+// Close disconnects the editors of docPath and of the documents below it,
+// e.g. once they were moved or deleted, telling them why
+func (h *Hub) Close(docPath, reason string) {
+	docPath = normalize(docPath)
+	h.mu.Lock()
+	var closing []*room
+	for p, r := range h.rooms {
+		if p == docPath || docPath == "/" || strings.HasPrefix(p, docPath+"/") {
+			closing = append(closing, r)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, r := range closing {
+		r.mu.Lock()
+		for c := range r.clients {
+			c.conn.Close(websocket.CloseGoingAway, reason)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Editors returns the number of editors connected to the room of docPath
+func (h *Hub) Editors(docPath string) int {
+	h.mu.Lock()
+	r := h.rooms[normalize(docPath)]
+	h.mu.Unlock()
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.clients)
+}
+
+// receive handles a message from editor c
+func (r *room) receive(c *client, m Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch m.Type {
+	case "op":
+		if m.Op == nil {
+			c.queue(Message{Type: "error", Message: "missing op"})
+			return
+		}
+		op, err := r.rebase(m.Op, m.Version)
+		var text []uint16
+		if err == nil {
+			text, err = op.Apply(r.text)
+		}
+		if err != nil {
+			// The editor is out of step; start it over from the current text
+			content := string(utf16.Decode(r.text))
+			c.queue(Message{Type: "sync", Version: r.version, Content: &content, Message: err.Error()})
+			return
+		}
+		r.text = text
+		r.version++
+		r.history = append(r.history, op)
+		if len(r.history) > maxHistory {
+			r.history = r.history[len(r.history)-maxHistory:]
+		}
+		for other := range r.clients {
+			other.cursor = op.TransformIndex(other.cursor)
+		}
+		c.queue(Message{Type: "ack", Version: r.version})
+		r.broadcast(c, Message{Type: "op", Version: r.version, Op: op, User: c.user, ID: c.id})
+
+	case "cursor":
+		if m.Index == nil {
+			return
+		}
+		c.cursor = max(0, min(*m.Index, len(r.text)))
+		r.broadcast(c, Message{Type: "cursor", Index: &c.cursor, User: c.user, ID: c.id})
+
+	default:
+		c.queue(Message{Type: "error", Message: "unknown message type"})
+	}
+}
+
+// rebase transforms op, made on the given version of the text, against the
+// operations applied since
+func (r *room) rebase(op *Operation, version int) (*Operation, error) {
+	first := r.version - len(r.history)
+	if version < first || version > r.version {
+		return nil, errors.New("version is no longer available")
+	}
+	for _, applied := range r.history[version-first:] {
+		var err error
+		if op, _, err = Transform(op, applied); err != nil {
+			return nil, err
+		}
+	}
+	return op, nil
+}
+
+// broadcast queues m for every editor but except
+func (r *room) broadcast(except *client, m Message) {
+	for c := range r.clients {
+		if c != except {
+			c.queue(m)
+		}
+	}
+}
+
+// queue sends m to the editor without blocking. An editor that doesn't keep
+// up is disconnected; it reconnects and starts over from the current text.
+func (c *client) queue(m Message) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		c.conn.Close(websocket.ClosePolicyViolation, "too slow")
+	}
+}
+
+func (c *client) writeLoop() {
+	for {
+		select {
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.conn.Close(websocket.CloseInternalError, "write failed")
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// normalize returns docPath as a URL path with a leading and no trailing slash
+func normalize(docPath string) string {
+	return "/" + strings.Trim(docPath, "/")
+}
@@ -0,0 +1,142 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConn is an editor connection driven by the test
+type fakeConn struct {
+	in   chan []byte
+	out  chan Message
+	once sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{in: make(chan []byte, 16), out: make(chan Message, 16)}
+}
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	data, ok := <-c.in
+	if !ok {
+		return 0, nil, errors.New("closed")
+	}
+	return 1, data, nil
+}
+
+func (c *fakeConn) WriteMessage(messageType int, data []byte) error {
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c.out <- m
+	return nil
+}
+
+func (c *fakeConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *fakeConn) Close(int, string) error {
+	c.once.Do(func() { close(c.in) })
+	return nil
+}
+
+func (c *fakeConn) sendJSON(t *testing.T, v string) {
+	t.Helper()
+	c.in <- []byte(v)
+}
+
+// expect returns the next message of type messageType, skipping others
+func (c *fakeConn) expect(t *testing.T, messageType string) Message {
+	t.Helper()
+	for {
+		select {
+		case m := <-c.out:
+			if m.Type == messageType {
+				return m
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no %s message", messageType)
+		}
+	}
+}
+
+func TestConcurrentEditsConverge(t *testing.T) {
+	saved := "hello"
+	hub := NewHub(func(string) (string, error) { return saved, nil })
+
+	alice, bob := newFakeConn(), newFakeConn()
+	go hub.Serve("/doc", "alice", alice)
+	if init := alice.expect(t, "init"); *init.Content != "hello" || init.Version != 0 {
+		t.Fatalf("alice init = %+v", init)
+	}
+	go hub.Serve("doc/", "bob", bob)
+	if init := bob.expect(t, "init"); len(init.Peers) != 1 || init.Peers[0].User != "alice" {
+		t.Fatalf("bob init = %+v", init)
+	}
+	alice.expect(t, "join")
+
+	// Both edit version 0: alice appends, bob prepends before he sees her edit
+	alice.sendJSON(t, `{"type":"op","version":0,"op":[5," world"]}`)
+	if ack := alice.expect(t, "ack"); ack.Version != 1 {
+		t.Errorf("alice ack = %+v", ack)
+	}
+	if op := bob.expect(t, "op"); op.User != "alice" || op.Version != 1 {
+		t.Errorf("bob got %+v", op)
+	}
+	bob.sendJSON(t, `{"type":"op","version":0,"op":["Oh, ",5]}`)
+	if ack := bob.expect(t, "ack"); ack.Version != 2 {
+		t.Errorf("bob ack = %+v", ack)
+	}
+
+	// Alice gets bob's edit transformed against hers
+	op := alice.expect(t, "op")
+	data, _ := json.Marshal(op.Op)
+	if op.User != "bob" || string(data) != `["Oh, ",11]` {
+		t.Errorf("alice got %s from %s", data, op.User)
+	}
+
+	// A save of the converged text is announced; a different one replaces it
+	saved = "Oh, hello world"
+	hub.Saved("doc", "alice")
+	if m := bob.expect(t, "saved"); m.User != "alice" {
+		t.Errorf("saved = %+v", m)
+	}
+	saved = "Replaced"
+	hub.Saved("doc", "bob")
+	if m := alice.expect(t, "sync"); *m.Content != "Replaced" || m.Version != 3 {
+		t.Errorf("sync = %+v", m)
+	}
+
+	// An op based on a version before the sync starts the editor over
+	bob.sendJSON(t, `{"type":"op","version":2,"op":[15,"!"]}`)
+	if m := bob.expect(t, "sync"); *m.Content != "Replaced" {
+		t.Errorf("resync = %+v", m)
+	}
+
+	alice.Close(1000, "")
+	bob.Close(1000, "")
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Editors("/doc") > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := hub.Editors("/doc"); n != 0 {
+		t.Errorf("editors after closing = %d", n)
+	}
+}
+
+func TestCursorsAreShared(t *testing.T) {
+	hub := NewHub(func(string) (string, error) { return "hello", nil })
+	alice, bob := newFakeConn(), newFakeConn()
+	go hub.Serve("/doc", "alice", alice)
+	alice.expect(t, "init")
+	go hub.Serve("/doc", "bob", bob)
+	bob.expect(t, "init")
+
+	alice.sendJSON(t, `{"type":"cursor","index":99}`)
+	if m := bob.expect(t, "cursor"); m.User != "alice" || *m.Index != 5 {
+		t.Errorf("cursor = %+v, want alice at the end", m)
+	}
+}
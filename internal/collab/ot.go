@@ -0,0 +1,261 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ErrLengthMismatch is returned for an operation that doesn't span the text
+// it is applied to or transformed against
+var ErrLengthMismatch = errors.New("collab: operation length doesn't match the document")
+
+// component is a step of an operation: retain N > 0 units, delete -N units
+// when N < 0, or insert Text when N == 0
+type component struct {
+	N    int
+	Text string
+}
+
+// Operation is an edit of a whole text: it walks the text from the start,
+// retaining, deleting and inserting as it goes. Lengths are counted in UTF-16
+// code units, as JavaScript strings are, so browsers and the server agree on
+// positions. In JSON an operation is the array used by ot.js: positive
+// numbers retain, negative numbers delete and strings are inserted.
+type Operation struct {
+	components []component
+	baseLen    int // Length of the text the operation applies to
+	targetLen  int // Length of the text it produces
+}
+
+// Retain keeps the next n units of the text
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.baseLen += n
+	o.targetLen += n
+	if last := len(o.components) - 1; last >= 0 && o.components[last].N > 0 {
+		o.components[last].N += n
+	} else {
+		o.components = append(o.components, component{N: n})
+	}
+	return o
+}
+
+// Insert inserts text at the current position
+func (o *Operation) Insert(text string) *Operation {
+	if text == "" {
+		return o
+	}
+	o.targetLen += utf16Len(text)
+	last := len(o.components) - 1
+	switch {
+	case last >= 0 && isInsert(o.components[last]):
+		o.components[last].Text += text
+	case last >= 0 && o.components[last].N < 0:
+		// Inserts go before deletes at the same position, so that equal
+		// edits have one representation
+		if last > 0 && isInsert(o.components[last-1]) {
+			o.components[last-1].Text += text
+		} else {
+			o.components = append(o.components, o.components[last])
+			o.components[last] = component{Text: text}
+		}
+	default:
+		o.components = append(o.components, component{Text: text})
+	}
+	return o
+}
+
+// Delete removes the next n units of the text
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.baseLen += n
+	if last := len(o.components) - 1; last >= 0 && o.components[last].N < 0 {
+		o.components[last].N -= n
+	} else {
+		o.components = append(o.components, component{N: -n})
+	}
+	return o
+}
+
+// BaseLen is the length of the text the operation applies to
+func (o *Operation) BaseLen() int {
+	return o.baseLen
+}
+
+// IsNoop reports whether the operation leaves the text unchanged
+func (o *Operation) IsNoop() bool {
+	return len(o.components) == 0 || (len(o.components) == 1 && o.components[0].N > 0)
+}
+
+// Apply returns text with the operation applied
+func (o *Operation) Apply(text []uint16) ([]uint16, error) {
+	if len(text) != o.baseLen {
+		return nil, ErrLengthMismatch
+	}
+	result := make([]uint16, 0, o.targetLen)
+	pos := 0
+	for _, c := range o.components {
+		switch {
+		case c.N > 0:
+			result = append(result, text[pos:pos+c.N]...)
+			pos += c.N
+		case c.N < 0:
+			pos -= c.N
+		default:
+			result = append(result, utf16.Encode([]rune(c.Text))...)
+		}
+	}
+	return result, nil
+}
+
+// TransformIndex returns where position index of the text the operation
+// applies to ends up in the text it produces, e.g. to move a cursor
+func (o *Operation) TransformIndex(index int) int {
+	newIndex, pos := index, 0
+	for _, c := range o.components {
+		if pos > index {
+			break
+		}
+		switch {
+		case c.N > 0:
+			pos += c.N
+		case c.N < 0:
+			newIndex -= min(-c.N, index-pos)
+			pos -= c.N
+		default:
+			newIndex += utf16Len(c.Text)
+		}
+	}
+	return newIndex
+}
+
+// Transform takes two operations a and b made concurrently on the same text
+// and returns a' and b' such that applying a then b' gives the same text as
+// applying b then a'. Where both insert at the same position, the text
+// inserted by a comes first.
+func Transform(a, b *Operation) (*Operation, *Operation, error) {
+	if a.baseLen != b.baseLen {
+		return nil, nil, ErrLengthMismatch
+	}
+	aPrime, bPrime := &Operation{}, &Operation{}
+	as, bs := a.components, b.components
+	var ac, bc *component
+	next := func(list *[]component) *component {
+		if len(*list) == 0 {
+			return nil
+		}
+		c := (*list)[0]
+		*list = (*list)[1:]
+		return &c
+	}
+	ac, bc = next(&as), next(&bs)
+
+	for ac != nil || bc != nil {
+		if ac != nil && isInsert(*ac) {
+			aPrime.Insert(ac.Text)
+			bPrime.Retain(utf16Len(ac.Text))
+			ac = next(&as)
+			continue
+		}
+		if bc != nil && isInsert(*bc) {
+			aPrime.Retain(utf16Len(bc.Text))
+			bPrime.Insert(bc.Text)
+			bc = next(&bs)
+			continue
+		}
+		if ac == nil || bc == nil {
+			return nil, nil, ErrLengthMismatch
+		}
+
+		switch {
+		case ac.N > 0 && bc.N > 0:
+			n := min(ac.N, bc.N)
+			aPrime.Retain(n)
+			bPrime.Retain(n)
+			ac.N -= n
+			bc.N -= n
+		case ac.N < 0 && bc.N < 0:
+			// Both deleted the same text
+			n := min(-ac.N, -bc.N)
+			ac.N += n
+			bc.N += n
+		case ac.N < 0 && bc.N > 0:
+			n := min(-ac.N, bc.N)
+			aPrime.Delete(n)
+			ac.N += n
+			bc.N -= n
+		case ac.N > 0 && bc.N < 0:
+			n := min(ac.N, -bc.N)
+			bPrime.Delete(n)
+			ac.N -= n
+			bc.N += n
+		}
+		if ac.N == 0 {
+			ac = next(&as)
+		}
+		if bc.N == 0 {
+			bc = next(&bs)
+		}
+	}
+	return aPrime, bPrime, nil
+}
+
+// MarshalJSON encodes the operation as an ot.js array
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	list := make([]any, len(o.components))
+	for i, c := range o.components {
+		if isInsert(c) {
+			list[i] = c.Text
+		} else {
+			list[i] = c.N
+		}
+	}
+	return json.Marshal(list)
+}
+
+// UnmarshalJSON decodes an ot.js array of numbers and strings
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var list []any
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*o = Operation{}
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			o.Insert(v)
+		case float64:
+			n := int(v)
+			if float64(n) != v || n == 0 {
+				return fmt.Errorf("collab: invalid operation component %v", v)
+			}
+			if n > 0 {
+				o.Retain(n)
+			} else {
+				o.Delete(-n)
+			}
+		default:
+			return fmt.Errorf("collab: invalid operation component %v", v)
+		}
+	}
+	return nil
+}
+
+func isInsert(c component) bool {
+	return c.N == 0
+}
+
+// utf16Len returns the length of s in UTF-16 code units
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
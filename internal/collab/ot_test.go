@@ -0,0 +1,127 @@
+package collab
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"unicode/utf16"
+)
+
+// randomOperation returns a random edit of a text of length n
+func randomOperation(rng *rand.Rand, n int) *Operation {
+	op := &Operation{}
+	for pos := 0; pos < n; {
+		step := 1 + rng.Intn(n-pos)
+		switch rng.Intn(3) {
+		case 0:
+			op.Retain(step)
+			pos += step
+		case 1:
+			op.Delete(step)
+			pos += step
+		default:
+			op.Insert([]string{"a", "bc", "é", "😀"}[rng.Intn(4)])
+		}
+	}
+	if rng.Intn(2) == 0 {
+		op.Insert("z")
+	}
+	return op
+}
+
+func TestTransformConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		text := utf16.Encode([]rune("héllo wörld 😀 text"[:rng.Intn(10)+5]))
+		a, b := randomOperation(rng, len(text)), randomOperation(rng, len(text))
+
+		aPrime, bPrime, err := Transform(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		afterA, err := a.Apply(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		afterB, err := b.Apply(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		viaA, err := bPrime.Apply(afterA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		viaB, err := aPrime.Apply(afterB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(utf16.Decode(viaA)) != string(utf16.Decode(viaB)) {
+			t.Fatalf("a then b' = %q, b then a' = %q", string(utf16.Decode(viaA)), string(utf16.Decode(viaB)))
+		}
+	}
+}
+
+func TestTransformTies(t *testing.T) {
+	text := utf16.Encode([]rune("ac"))
+	a := (&Operation{}).Retain(1).Insert("X").Retain(1)
+	b := (&Operation{}).Retain(1).Insert("Y").Retain(1)
+	aPrime, _, err := Transform(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	afterB, _ := b.Apply(text)
+	got, _ := aPrime.Apply(afterB)
+	if string(utf16.Decode(got)) != "aXYc" {
+		t.Errorf("result = %q, want the first operation's insert first", string(utf16.Decode(got)))
+	}
+}
+
+func TestOperationJSON(t *testing.T) {
+	var op Operation
+	if err := json.Unmarshal([]byte(`[2,"😀",-1,3]`), &op); err != nil {
+		t.Fatal(err)
+	}
+	if op.BaseLen() != 6 || op.targetLen != 7 {
+		t.Errorf("lengths = %d, %d; want 6, 7", op.BaseLen(), op.targetLen)
+	}
+	data, err := json.Marshal(&op)
+	if err != nil || string(data) != `[2,"😀",-1,3]` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+
+	for _, invalid := range []string{`[0]`, `[1.5]`, `[true]`, `{}`} {
+		if err := json.Unmarshal([]byte(invalid), &op); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", invalid)
+		}
+	}
+}
+
+func TestApplyRejectsWrongLength(t *testing.T) {
+	op := (&Operation{}).Retain(3)
+	if _, err := op.Apply(utf16.Encode([]rune("ab"))); err != ErrLengthMismatch {
+		t.Errorf("err = %v, want ErrLengthMismatch", err)
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	// "abcdef" -> "aXYdf": retain 1, insert XY, delete 2, retain 1, delete 1, retain 1
+	op := (&Operation{}).Retain(1).Insert("XY").Delete(2).Retain(1).Delete(1).Retain(1)
+	tests := []struct {
+		index int
+		want  int
+	}{
+		{0, 0},
+		{1, 3},
+		{2, 3},
+		{3, 3},
+		{4, 4},
+		{5, 4},
+		{6, 5},
+	}
+
+	for _, tt := range tests {
+		if got := op.TransformIndex(tt.index); got != tt.want {
+			t.Errorf("TransformIndex(%d) = %d, want %d", tt.index, got, tt.want)
+		}
+	}
+}
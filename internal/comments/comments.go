@@ -1,6 +1,7 @@
 package comments
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
@@ -21,10 +22,21 @@ type Comment struct {
 	Content       string        // Raw markdown content
 	RenderedHTML  template.HTML // Rendered HTML (not stored, generated on read)
 	FormattedTime string        // Formatted timestamp for display
+	Anonymous     bool          // Posted by a visitor who was not signed in; Author is the name they gave, if any
+	Email         string        // Email address an anonymous commenter gave, for admins only
 }
 
-// AddComment creates a new comment for a document
-func AddComment(documentPath, content, username string) error {
+// anonymousAuthor is kept beside the comment file of an anonymous comment, as
+// {timestamp}_{name}.json, since the file name can't tell a visitor's name
+// from a username
+type anonymousAuthor struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// AddComment creates a new comment for a document. dir is the directory
+// holding the comments of every document.
+func AddComment(dir, documentPath, content, username string) error {
 	// Generate timestamp in YYYYMMDDhhmmss format
 	timestamp := time.Now().Format("20060102150405")
 
@@ -35,7 +47,7 @@ func AddComment(documentPath, content, username string) error {
 	filename := fmt.Sprintf("%s_%s.md", timestamp, safeUsername)
 
 	// Ensure comment directory exists
-	commentDir := filepath.Join("data/comments", documentPath)
+	commentDir := filepath.Join(dir, documentPath)
 	if err := os.MkdirAll(commentDir, 0755); err != nil {
 		return fmt.Errorf("failed to create comment directory: %w", err)
 	}
@@ -44,10 +56,34 @@ func AddComment(documentPath, content, username string) error {
 	return os.WriteFile(filepath.Join(commentDir, filename), []byte(content), 0644)
 }
 
-// GetComments retrieves all comments for a document
-func GetComments(documentPath string) ([]Comment, error) {
+// AddAnonymousComment creates a new comment for a document from a visitor who
+// is not signed in. name and email are what the visitor gave and may be empty.
+func AddAnonymousComment(dir, documentPath, content, name, email string) error {
+	fileName := sanitizeUsername(name)
+	if fileName == "" {
+		fileName = "anonymous"
+	}
+	id := fmt.Sprintf("%s_%s", time.Now().Format("20060102150405"), fileName)
+
+	commentDir := filepath.Join(dir, documentPath)
+	if err := os.MkdirAll(commentDir, 0755); err != nil {
+		return fmt.Errorf("failed to create comment directory: %w", err)
+	}
+
+	author, err := json.Marshal(anonymousAuthor{Name: name, Email: email})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(commentDir, id+".json"), author, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(commentDir, id+".md"), []byte(content), 0644)
+}
+
+// GetComments retrieves all comments for a document from dir
+func GetComments(dir, documentPath string) ([]Comment, error) {
 	// Read comment directory
-	commentDir := filepath.Join("data/comments", documentPath)
+	commentDir := filepath.Join(dir, documentPath)
 	files, err := os.ReadDir(commentDir)
 	if os.IsNotExist(err) {
 		return []Comment{}, nil // No comments yet
@@ -85,13 +121,22 @@ func GetComments(documentPath string) ([]Comment, error) {
 				continue // Can't read file
 			}
 
-			comments = append(comments, Comment{
+			comment := Comment{
 				ID:            file.Name(),
 				Author:        parts[1], // Username from filename
 				Timestamp:     timestamp,
 				TimestampUnix: timestampUnix,
 				Content:       string(content),
-			})
+			}
+			if data, err := os.ReadFile(filepath.Join(commentDir, strings.TrimSuffix(file.Name(), ".md")+".json")); err == nil {
+				var author anonymousAuthor
+				if json.Unmarshal(data, &author) == nil {
+					comment.Anonymous = true
+					comment.Author = author.Name
+					comment.Email = author.Email
+				}
+			}
+			comments = append(comments, comment)
 		}
 	}
 
@@ -103,8 +148,8 @@ func GetComments(documentPath string) ([]Comment, error) {
 	return comments, nil
 }
 
-// DeleteComment deletes a comment if the user is an admin
-func DeleteComment(commentID string, documentPath string, userIsAdmin bool) error {
+// DeleteComment deletes a comment from dir if the user is an admin
+func DeleteComment(dir, commentID, documentPath string, userIsAdmin bool) error {
 	if !userIsAdmin {
 		return errors.New("only admins can delete comments")
 	}
@@ -114,8 +159,11 @@ func DeleteComment(commentID string, documentPath string, userIsAdmin bool) erro
 		return errors.New("invalid comment ID")
 	}
 
-	// Delete the comment file
-	commentPath := filepath.Join("data/comments", documentPath, commentID)
+	// Delete the comment file, and the author of an anonymous comment
+	commentPath := filepath.Join(dir, documentPath, commentID)
+	if err := os.Remove(strings.TrimSuffix(commentPath, ".md") + ".json"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return os.Remove(commentPath)
 }
 
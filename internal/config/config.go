@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 	"wiki-go/internal/crypto"
 	"wiki-go/internal/roles"
 
@@ -18,12 +20,102 @@ var (
 	ConfigFilePath string = "data/config.yaml"
 )
 
+// current is the running configuration. Changes replace it rather than write
+// to it, so whoever loaded it keeps a consistent view.
+var current atomic.Pointer[Config]
+
+// Current returns the running configuration
+func Current() *Config {
+	return current.Load()
+}
+
+// SetCurrent makes c the running configuration. c must not be modified
+// afterwards; change a copy and set that instead.
+func SetCurrent(c *Config) {
+	current.Store(c)
+}
+
+// DefaultCompressionMinSize is the smallest response compressed when
+// server.compression.min_size isn't set
+const DefaultCompressionMinSize = 1024
+
+// DefaultHomePage is where the homepage document is stored, relative to the wiki root
+const DefaultHomePage = "pages/home"
+
+// Default names of the directories inside the wiki root holding the version
+// history, the comments and the trash
+const (
+	DefaultVersionsDir = "versions"
+	DefaultCommentsDir = "comments"
+	DefaultTrashDir    = ".trash"
+)
+
+// DefaultRenderCacheSize is the number of rendered documents kept in memory by default
+const DefaultRenderCacheSize = 500
+
+// Wiki access modes
+const (
+	AccessModePublic         = "public"           // Anyone can read; signed-in users can comment
+	AccessModeReadOnlyPublic = "read-only-public" // Anyone can read; only editors and admins can change anything
+	AccessModePrivate        = "private"          // Only signed-in users can read
+)
+
+// Built-in TLS modes
+const (
+	TLSModeStatic = "static" // Certificate and key from files
+	TLSModeACME   = "acme"   // Certificates obtained automatically from an ACME CA
+)
+
+// Session stores
+const (
+	SessionStoreMemory = "memory" // This instance's memory, saved to disk
+	SessionStoreRedis  = "redis"  // A Redis server shared by every instance
+)
+
+// How visitors who are not signed in may comment
+const (
+	AnonymousCommentsOff       = "off"        // Only signed-in users comment
+	AnonymousCommentsAnonymous = "anonymous"  // Shown as "Anonymous"
+	AnonymousCommentsName      = "name"       // Under a name the visitor gives
+	AnonymousCommentsNameEmail = "name_email" // Under a name, with an email address kept for admins
+)
+
+// Canonical forms of page URLs
+const (
+	TrailingSlashStrip = "strip" // /guides/setup
+	TrailingSlashAdd   = "add"   // /guides/setup/
+	TrailingSlashKeep  = "keep"  // Either form, without redirects
+)
+
+// Session bindings: what a session token stays tied to after login
+const (
+	SessionBindingNone        = "none"          // The token works from anywhere
+	SessionBindingUserAgent   = "user_agent"    // The browser and its major version
+	SessionBindingUserAgentIP = "user_agent_ip" // The browser and the client's network (IPv4 /24, IPv6 /48)
+)
+
+// Login error styles: what a failed login tells the client
+const (
+	LoginErrorsGeneric  = "generic"  // "Invalid credentials" for every failure, bans included
+	LoginErrorsDetailed = "detailed" // Tells a ban, and how long it lasts, from a wrong password
+)
+
+// Search backends
+const (
+	SearchBackendScan   = "scan"   // No index; every search reads every document
+	SearchBackendMemory = "memory" // An index built in memory on every start
+	SearchBackendDisk   = "disk"   // An index saved to disk, updated on start for changed documents
+)
+
 // User represents a user with authentication credentials
 type User struct {
-	Username string   `yaml:"username" json:"username"`
-	Password string   `yaml:"password" json:"password,omitempty"`
-	Role     string   `yaml:"role" json:"role"`                       // "admin", "editor", or "viewer"
-	Groups   []string `yaml:"groups,omitempty" json:"groups,omitempty"` // Optional groups for access control
+	Username string    `yaml:"username" json:"username"`
+	Password string    `yaml:"password" json:"password,omitempty"`
+	Role     string    `yaml:"role" json:"role"`                             // "admin", "editor", or "viewer"
+	Groups   []string  `yaml:"groups,omitempty" json:"groups,omitempty"`     // Optional groups for access control
+	Created  time.Time `yaml:"created,omitempty" json:"created,omitempty"`   // When the account was created; zero for accounts that predate tracking
+	Language string    `yaml:"language,omitempty" json:"language,omitempty"` // Preferred language for server messages; empty follows the browser
+	Disabled bool      `yaml:"disabled,omitempty" json:"disabled,omitempty"` // Disabled users can't sign in but stay credited with their edits
 }
 
 // AccessRule defines a path-based access control rule
@@ -46,25 +138,106 @@ type Config struct {
 	Server struct {
 		Host string `yaml:"host"`
 		Port int    `yaml:"port"`
+		// Public address of the wiki, e.g. "https://wiki.example.com", used in
+		// links meant for outside it such as sitemaps and QR codes; empty uses
+		// the address each request was made to
+		BaseURL string `yaml:"base_url"`
 		// When set to true, allows cookies to be sent over non-HTTPS connections.
 		// WARNING: Only enable this in trusted environments like a homelab
 		// where HTTPS is not available. This reduces security by allowing
 		// cookies to be transmitted in plain text.
 		AllowInsecureCookies bool `yaml:"allow_insecure_cookies"`
-		// Enable native TLS. When true, application will run over HTTPS using the
-		// supplied certificate and key paths.
-		SSL      bool   `yaml:"ssl"`
-		SSLCert  string `yaml:"ssl_cert"`
-		SSLKey   string `yaml:"ssl_key"`
+		// Native TLS settings of older config files; moved into TLS on load
+		SSL     bool   `yaml:"ssl"`
+		SSLCert string `yaml:"ssl_cert"`
+		SSLKey  string `yaml:"ssl_key"`
+		// Built-in HTTPS. Secure cookies and HSTS are always on when enabled.
+		TLS struct {
+			Mode     string `yaml:"mode"` // "" (plain HTTP), "static" or "acme"
+			CertFile string `yaml:"cert_file"`
+			KeyFile  string `yaml:"key_file"`
+			ACME     struct {
+				Hosts     []string `yaml:"hosts"`
+				Email     string   `yaml:"email"`
+				Directory string   `yaml:"directory"` // Empty uses Let's Encrypt
+			} `yaml:"acme"`
+			RedirectHTTP string `yaml:"redirect_http"` // Listen address answering plain HTTP with a redirect to HTTPS, e.g. ":80"
+		} `yaml:"tls"`
+		// Prometheus metrics endpoint (/metrics)
+		Metrics struct {
+			Enabled bool   `yaml:"enabled"`
+			Listen  string `yaml:"listen"` // Optional separate listen address, e.g. "127.0.0.1:9100"
+		} `yaml:"metrics"`
+		// Minimum level for structured logs: "debug", "info", "warn" or "error"
+		LogLevel string `yaml:"log_level"`
+		// Gzip compression of responses for clients that accept it
+		Compression struct {
+			Enabled bool `yaml:"enabled"`
+			MinSize int  `yaml:"min_size"` // Smaller responses are sent uncompressed, in bytes
+		} `yaml:"compression"`
+		// Limits on client requests, against slow or oversized ones tying up the
+		// server. Timeouts are in seconds; 0 disables one.
+		Limits struct {
+			ReadHeaderTimeout int `yaml:"read_header_timeout"` // Reading the request headers
+			ReadTimeout       int `yaml:"read_timeout"`        // Reading the whole request, body included
+			WriteTimeout      int `yaml:"write_timeout"`       // Writing the response
+			IdleTimeout       int `yaml:"idle_timeout"`        // Waiting for the next request on a kept-alive connection
+			MaxHeaderBytes    int `yaml:"max_header_bytes"`    // Size of the request headers in KB
+			MaxBodySize       int `yaml:"max_body_size"`       // Request bodies in KB, except document content and files; 0 is unlimited
+		} `yaml:"limits"`
+		// Client IP restrictions, applied before authentication. Entries are CIDR
+		// ranges or single addresses; denied ranges take precedence.
+		AllowedCIDRs []string `yaml:"allowed_cidrs"`
+		DeniedCIDRs  []string `yaml:"denied_cidrs"`
+		// Trust X-Forwarded-For / X-Real-IP from a reverse proxy for the client IP
+		TrustProxy bool `yaml:"trust_proxy"`
+		// Protective response headers
+		Headers struct {
+			ContentSecurityPolicy string `yaml:"content_security_policy"` // Empty uses the built-in policy; "-" sends none; {nonce} is replaced per request
+			CSPReportOnly         bool   `yaml:"csp_report_only"`         // Report violations without enforcing the policy
+			HSTSMaxAge            int    `yaml:"hsts_max_age"`            // Seconds; 0 disables. Only sent over HTTPS
+			HSTSIncludeSubdomains bool   `yaml:"hsts_include_subdomains"`
+			FrameOptions          string `yaml:"frame_options"`   // X-Frame-Options: "DENY", "SAMEORIGIN" or empty to omit
+			ReferrerPolicy        string `yaml:"referrer_policy"` // Empty omits the header
+		} `yaml:"headers"`
+		// WebDAV access to the documents tree at /dav/
+		WebDAV struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"webdav"`
+		// Where sessions are kept; redis lets several instances share them
+		Sessions struct {
+			Store string `yaml:"store"` // "memory" or "redis"
+			Redis struct {
+				Address  string `yaml:"address"` // host:port
+				Password string `yaml:"password"`
+				DB       int    `yaml:"db"`
+				Prefix   string `yaml:"prefix"` // Start of session keys
+			} `yaml:"redis"`
+		} `yaml:"sessions"`
+		// Page each role lands on after signing in on the login page, unless the
+		// login was for another page; empty is the homepage
+		Landing struct {
+			Admin  string `yaml:"admin"`
+			Editor string `yaml:"editor"`
+			Viewer string `yaml:"viewer"`
+		} `yaml:"landing"`
+		// Canonical form of page URLs: "strip" or "add" a trailing slash, redirecting
+		// the other form to it, or "keep" both
+		TrailingSlash string `yaml:"trailing_slash"`
 	} `yaml:"server"`
 	Wiki struct {
 		RootDir                     string `yaml:"root_dir"`
 		DocumentsDir                string `yaml:"documents_dir"`
+		HomePage                    string `yaml:"home_page"`    // Directory of the document served at "/", relative to root_dir
+		VersionsDir                 string `yaml:"versions_dir"` // Directory of the version history, relative to root_dir
+		CommentsDir                 string `yaml:"comments_dir"` // Directory of the comments, relative to root_dir
+		TrashDir                    string `yaml:"trash_dir"`    // Directory of the trash, relative to root_dir
 		Title                       string `yaml:"title"`
 		Owner                       string `yaml:"owner"`
 		Notice                      string `yaml:"notice"`
 		Timezone                    string `yaml:"timezone"`
 		Private                     bool   `yaml:"private"`
+		AccessMode                  string `yaml:"access_mode"`                     // "public", "read-only-public" or "private"; overrides private when set
 		DisableComments             bool   `yaml:"disable_comments"`                // Disable comments system-wide when true
 		DisableFileUploadChecking   bool   `yaml:"disable_file_upload_checking"`    // Disable mimetype checking for file uploads when true
 		EnableLinkEmbedding         bool   `yaml:"enable_link_embedding"`           // Enable automatic link embedding from clipboard when true
@@ -72,13 +245,108 @@ type Config struct {
 		DisableContentMaxWidth      bool   `yaml:"disable_content_max_width"`       // Disable 900px content width limit when true
 		AlwaysOpenChildrenInSidebar bool   `yaml:"always_open_children_in_sidebar"` // Always open children in sidebar
 		MaxVersions                 int    `yaml:"max_versions"`
-		MaxUploadSize               int    `yaml:"max_upload_size"` // Maximum upload file size in MB
-		Language                    string `yaml:"language"`        // Default language for the wiki
+		MaxUploadSize               int    `yaml:"max_upload_size"`       // Maximum upload file size in MB
+		MaxDocumentSize             int    `yaml:"max_document_size"`     // Largest document content in KB that can be saved or imported; 0 is unlimited
+		DocumentSizeWarning         int    `yaml:"document_size_warning"` // Saving content larger than this many KB warns; 0 never warns
+		Language                    string `yaml:"language"`              // Default language for the wiki
+		WikiLinkResolution          string `yaml:"wikilink_resolution"`   // How ambiguous [[WikiLink]] titles resolve: "nearest", "shortest" or "first"
+		RenderCacheSize             int    `yaml:"render_cache_size"`     // Number of rendered documents kept in memory; 0 disables the cache
+		RelatedDocuments            int    `yaml:"related_documents"`     // Number of related documents suggested below each document; 0 hides them
+		ReadingSpeed                int    `yaml:"reading_speed"`         // Words per minute for reading time estimates; 0 hides them
+		Trash                       struct {
+			Enabled       bool `yaml:"enabled"`        // Deleting moves documents to the trash instead of removing them
+			RetentionDays int  `yaml:"retention_days"` // Days before trashed documents are purged; 0 keeps them until emptied
+		} `yaml:"trash"`
+		Review struct {
+			IntervalDays int  `yaml:"interval_days"` // Days a review postpones a document's reviewBy date, unless it sets reviewEvery
+			ShowBanner   bool `yaml:"show_banner"`   // Show readers a banner on documents past their reviewBy date
+		} `yaml:"review"`
+		Search struct {
+			Backend       string `yaml:"backend"`        // Search index: "scan", "memory" or "disk"
+			ContextLength int    `yaml:"context_length"` // Characters of result excerpts shown on each side of the first match
+			Fuzzy         bool   `yaml:"fuzzy"`          // Also find documents with words a few edits from misspelled search words
+			MaxDistance   int    `yaml:"max_distance"`   // Most edits a fuzzy match may need
+		} `yaml:"search"`
+		Uploads struct {
+			MaxFileSize        int      `yaml:"max_file_size"`        // Maximum attachment size in MB; 0 uses max_upload_size
+			AllowedTypes       []string `yaml:"allowed_types"`        // MIME types ("image/png", "image/*") or extensions ("pdf") attachments may have; empty allows every supported type
+			MaxDocumentStorage int      `yaml:"max_document_storage"` // Maximum total size in MB of one document's attachments; 0 is unlimited
+			// Malware scanner every attachment is checked with before it is saved
+			Scan struct {
+				Provider       string `yaml:"provider"`        // "" (no scanning) or "clamd"
+				Address        string `yaml:"address"`         // clamd unix socket path or host:port
+				TimeoutSeconds int    `yaml:"timeout_seconds"` // Limit on one scan
+				Async          bool   `yaml:"async"`           // Accept uploads at once and keep them in quarantine until they pass
+			} `yaml:"scan"`
+		} `yaml:"uploads"`
+		// Proposed edits from readers who cannot edit, applied once an editor accepts them
+		Suggestions struct {
+			Enabled        bool `yaml:"enabled"`
+			AllowAnonymous bool `yaml:"allow_anonymous"` // Also take suggestions from visitors who are not signed in
+			MaxPerHour     int  `yaml:"max_per_hour"`    // Suggestions per client IP and hour; 0 is unlimited
+		} `yaml:"suggestions"`
+		Comments                    struct {
+			// Limits on how often comments can be posted; a max of 0 disables that limit
+			RateLimit struct {
+				MaxPerIP      int `yaml:"max_per_ip"`
+				MaxPerUser    int `yaml:"max_per_user"`
+				WindowSeconds int `yaml:"window_seconds"`
+			} `yaml:"rate_limit"`
+			MinRole            string `yaml:"min_role"`              // Lowest role allowed to comment: "viewer", "editor" or "admin"
+			MinAccountAgeHours int    `yaml:"min_account_age_hours"` // Accounts younger than this cannot comment; 0 disables the check
+			// Comments from visitors who are not signed in: "off", "anonymous", "name" or
+			// "name_email". Signed-in users always comment under their username.
+			Anonymous string `yaml:"anonymous"`
+			// Documents only accept comments when their front matter says `comments: true`;
+			// otherwise they do unless it says `comments: false`
+			DisabledByDefault bool `yaml:"disabled_by_default"`
+			// Anti-spam challenge required from viewers before a comment is accepted
+			Challenge struct {
+				Provider      string `yaml:"provider"` // "", "hcaptcha", "turnstile" or "pow"
+				SiteKey       string `yaml:"site_key"`
+				SecretKey     string `yaml:"secret_key"`
+				PowDifficulty int    `yaml:"pow_difficulty"` // Leading zero bits required for proof of work
+			} `yaml:"challenge"`
+		} `yaml:"comments"`
+		// Markdown extensions used by the renderer
+		Markdown struct {
+			Tables          bool `yaml:"tables"`
+			Strikethrough   bool `yaml:"strikethrough"`
+			Autolinks       bool `yaml:"autolinks"`
+			TaskLists       bool `yaml:"task_lists"`
+			Footnotes       bool `yaml:"footnotes"`
+			DefinitionLists bool `yaml:"definition_lists"`
+			RawHTML         bool `yaml:"raw_html"` // HTML written in documents; still sanitized when enabled
+			HardWraps       bool `yaml:"hard_wraps"`
+			Emoji           bool `yaml:"emoji"` // :shortcode: replacement outside code
+		} `yaml:"markdown"`
+		// Paths below the documents directory no document may be created, moved
+		// or renamed into, such as those of the wiki's own routes
+		ReservedPaths []string `yaml:"reserved_paths"`
+		// Groups whose editors may move documents where more users can read them,
+		// such as from an internal namespace to a public one; admins always may
+		PublishGroups []string `yaml:"publish_groups"`
+		// Name of the file holding a document's content in its directory, and
+		// names it is also looked up under, such as index.md or README.md for
+		// trees imported from git-hosted docs; document.md always resolves
+		IndexFile      string   `yaml:"index_file"`
+		IndexFallbacks []string `yaml:"index_fallbacks"`
+		// Let editors of the same document see each other's changes and cursors
+		// as they type
+		RealtimeEditing bool `yaml:"realtime_editing"`
 	} `yaml:"wiki"`
 	Users       []User       `yaml:"users"`
 	AccessRules []AccessRule `yaml:"access_rules,omitempty"`
 	Security    struct {
-		PasswordStrength int `yaml:"passwordstrength"`
+		PasswordStrength int `yaml:"passwordstrength"` // bcrypt cost
+		// How new passwords are hashed: "bcrypt" or "argon2id". Hashes made
+		// another way are replaced when their user next signs in.
+		PasswordAlgorithm string `yaml:"password_algorithm"`
+		Argon2            struct {
+			MemoryKiB   uint32 `yaml:"memory_kib"`
+			Iterations  uint32 `yaml:"iterations"`
+			Parallelism uint8  `yaml:"parallelism"`
+		} `yaml:"argon2"`
 		LoginBan struct {
 			Enabled           bool `yaml:"enabled"`
 			MaxFailures       int  `yaml:"max_failures"`
@@ -86,7 +354,23 @@ type Config struct {
 			InitialBanSeconds int  `yaml:"initial_ban_seconds"`
 			MaxBanSeconds     int  `yaml:"max_ban_seconds"`
 		} `yaml:"login_ban"`
+		// What a failed login tells the client: "generic" or "detailed"
+		LoginErrors string `yaml:"login_errors"`
+		// What a session stays bound to; a request from elsewhere ends it as a
+		// possible hijack: "none", "user_agent" or "user_agent_ip"
+		SessionBinding string `yaml:"session_binding"`
+		// Allowlist-based sanitization of rendered HTML
+		Sanitize struct {
+			Enabled           bool     `yaml:"enabled"` // Comments are sanitized even when disabled
+			AllowedTags       []string `yaml:"allowed_tags"`
+			AllowedAttributes []string `yaml:"allowed_attributes"`
+			IframeHosts       []string `yaml:"iframe_hosts"`
+		} `yaml:"sanitize"`
 	} `yaml:"security"`
+
+	// Settings overridden from the environment, restored when the file is written
+	env      []envOverride
+	envAdmin *envAdmin
 }
 
 // LoadConfig loads the configuration from a YAML file
@@ -96,16 +380,52 @@ func LoadConfig(path string) (*Config, error) {
 	config.Server.Host = "0.0.0.0" // Set to localhost for local development
 	config.Server.Port = 8080
 	config.Server.AllowInsecureCookies = false // Default to secure cookies
-	config.Server.SSL = false
-	config.Server.SSLCert = ""
-	config.Server.SSLKey = ""
+	config.Server.TLS.Mode = ""
+	config.Server.TLS.CertFile = ""
+	config.Server.TLS.KeyFile = ""
+	config.Server.TLS.ACME.Hosts = []string{}
+	config.Server.TLS.ACME.Email = ""
+	config.Server.TLS.ACME.Directory = ""
+	config.Server.TLS.RedirectHTTP = ""
+	config.Server.Metrics.Enabled = false
+	config.Server.Metrics.Listen = ""
+	config.Server.LogLevel = "info"
+	config.Server.Compression.Enabled = true
+	config.Server.Compression.MinSize = DefaultCompressionMinSize
+	config.Server.Limits.ReadHeaderTimeout = 10
+	config.Server.Limits.ReadTimeout = 60
+	config.Server.Limits.WriteTimeout = 120
+	config.Server.Limits.IdleTimeout = 120
+	config.Server.Limits.MaxHeaderBytes = 64
+	config.Server.Limits.MaxBodySize = 1024
+	config.Server.AllowedCIDRs = []string{}
+	config.Server.DeniedCIDRs = []string{}
+	config.Server.TrustProxy = false
+	config.Server.Headers.ContentSecurityPolicy = ""
+	config.Server.Headers.CSPReportOnly = false
+	config.Server.Headers.HSTSMaxAge = 31536000 // 1 year
+	config.Server.Headers.HSTSIncludeSubdomains = false
+	config.Server.Headers.FrameOptions = "SAMEORIGIN"
+	config.Server.Headers.ReferrerPolicy = "strict-origin-when-cross-origin"
+	config.Server.WebDAV.Enabled = false
+	config.Server.Sessions.Store = SessionStoreMemory
+	config.Server.Sessions.Redis.Address = "localhost:6379"
+	config.Server.Sessions.Redis.Password = ""
+	config.Server.Sessions.Redis.DB = 0
+	config.Server.Sessions.Redis.Prefix = "wikigo:session:"
+	config.Server.TrailingSlash = TrailingSlashStrip
 	config.Wiki.RootDir = "data"
 	config.Wiki.DocumentsDir = "documents"
+	config.Wiki.HomePage = DefaultHomePage
+	config.Wiki.VersionsDir = DefaultVersionsDir
+	config.Wiki.CommentsDir = DefaultCommentsDir
+	config.Wiki.TrashDir = DefaultTrashDir
 	config.Wiki.Title = "📚 Wiki-Go"
 	config.Wiki.Owner = "wiki.example.com"
 	config.Wiki.Notice = "Copyright :::year::: © All rights reserved."
 	config.Wiki.Timezone = "America/Vancouver"
 	config.Wiki.Private = false
+	config.Wiki.AccessMode = "" // Derived from the private flag by normalizeAccessMode
 	config.Wiki.DisableComments = false
 	config.Wiki.DisableFileUploadChecking = false // Default to false - always check file uploads
 	config.Wiki.EnableLinkEmbedding = false
@@ -115,15 +435,71 @@ func LoadConfig(path string) (*Config, error) {
 	config.Wiki.MaxVersions = 10   // Default value
 	config.Wiki.MaxUploadSize = 10 // Default value
 	config.Wiki.Language = "en"    // Default to English
-	config.Users = []User{}        // Initialize empty users array
+	config.Wiki.WikiLinkResolution = "nearest"
+	config.Wiki.RenderCacheSize = DefaultRenderCacheSize
+	config.Wiki.RelatedDocuments = 5
+	config.Wiki.ReadingSpeed = 200
+	config.Wiki.MaxDocumentSize = 1024
+	config.Wiki.DocumentSizeWarning = 256
+	config.Wiki.ReservedPaths = []string{"api", "static", "login", "dav", "metrics", "healthz", "readyz", "sitemap"}
+	config.Wiki.PublishGroups = []string{}
+	config.Wiki.IndexFile = "document.md"
+	config.Wiki.IndexFallbacks = []string{}
+	config.Wiki.Trash.Enabled = true
+	config.Wiki.Trash.RetentionDays = 30
+	config.Wiki.Review.IntervalDays = 180
+	config.Wiki.Review.ShowBanner = false
+	config.Wiki.Search.Backend = SearchBackendMemory
+	config.Wiki.Search.ContextLength = 100
+	config.Wiki.Search.Fuzzy = true
+	config.Wiki.Search.MaxDistance = 2
+	config.Wiki.Uploads.MaxFileSize = 0
+	config.Wiki.Uploads.AllowedTypes = []string{}
+	config.Wiki.Uploads.MaxDocumentStorage = 0
+	config.Wiki.Uploads.Scan.Provider = ""
+	config.Wiki.Uploads.Scan.Address = "/var/run/clamav/clamd.ctl"
+	config.Wiki.Uploads.Scan.TimeoutSeconds = 30
+	config.Wiki.Uploads.Scan.Async = false
+	config.Wiki.Suggestions.Enabled = true
+	config.Wiki.Suggestions.AllowAnonymous = false
+	config.Wiki.Suggestions.MaxPerHour = 10
+	config.Wiki.Comments.RateLimit.MaxPerIP = 20
+	config.Wiki.Comments.RateLimit.MaxPerUser = 5
+	config.Wiki.Comments.RateLimit.WindowSeconds = 60
+	config.Wiki.Comments.MinRole = RoleViewer
+	config.Wiki.Comments.MinAccountAgeHours = 0
+	config.Wiki.Comments.Anonymous = AnonymousCommentsOff
+	config.Wiki.Comments.DisabledByDefault = false
+	config.Wiki.Comments.Challenge.Provider = ""
+	config.Wiki.Comments.Challenge.PowDifficulty = 16
+	config.Wiki.Markdown.Tables = true
+	config.Wiki.Markdown.Strikethrough = true
+	config.Wiki.Markdown.Autolinks = true
+	config.Wiki.Markdown.TaskLists = true
+	config.Wiki.Markdown.Footnotes = true
+	config.Wiki.Markdown.DefinitionLists = true
+	config.Wiki.Markdown.RawHTML = true
+	config.Wiki.Markdown.HardWraps = true
+	config.Wiki.Markdown.Emoji = true
+	config.Users = []User{} // Initialize empty users array
 
 	// Security defaults
 	config.Security.PasswordStrength = 14
+	config.Security.PasswordAlgorithm = crypto.AlgorithmBcrypt
+	config.Security.Argon2.MemoryKiB = 64 * 1024
+	config.Security.Argon2.Iterations = 3
+	config.Security.Argon2.Parallelism = 2
 	config.Security.LoginBan.Enabled = true
 	config.Security.LoginBan.MaxFailures = 5
 	config.Security.LoginBan.WindowSeconds = 180
 	config.Security.LoginBan.InitialBanSeconds = 60
 	config.Security.LoginBan.MaxBanSeconds = 86400 // 24h
+	config.Security.LoginErrors = LoginErrorsDetailed
+	config.Security.SessionBinding = SessionBindingUserAgent
+	config.Security.Sanitize.Enabled = true
+	config.Security.Sanitize.AllowedTags = []string{}
+	config.Security.Sanitize.AllowedAttributes = []string{}
+	config.Security.Sanitize.IframeHosts = []string{"www.youtube.com", "youtube.com", "www.youtube-nocookie.com", "player.vimeo.com"}
 
 	// Read config file
 	data, err := os.ReadFile(path)
@@ -171,17 +547,57 @@ func LoadConfig(path string) (*Config, error) {
 				GetConfigTemplate(),
 				config.Server.Host,
 				config.Server.Port,
+				config.Server.BaseURL,
 				config.Server.AllowInsecureCookies,
-				config.Server.SSL,
-				config.Server.SSLCert,
-				config.Server.SSLKey,
+				config.Server.TLS.Mode,
+				config.Server.TLS.CertFile,
+				config.Server.TLS.KeyFile,
+				FormatStringList(config.Server.TLS.ACME.Hosts),
+				config.Server.TLS.ACME.Email,
+				config.Server.TLS.ACME.Directory,
+				config.Server.TLS.RedirectHTTP,
+				config.Server.Metrics.Enabled,
+				config.Server.Metrics.Listen,
+				config.Server.LogLevel,
+				config.Server.Compression.Enabled,
+				config.Server.Compression.MinSize,
+				config.Server.Limits.ReadHeaderTimeout,
+				config.Server.Limits.ReadTimeout,
+				config.Server.Limits.WriteTimeout,
+				config.Server.Limits.IdleTimeout,
+				config.Server.Limits.MaxHeaderBytes,
+				config.Server.Limits.MaxBodySize,
+				FormatStringList(config.Server.AllowedCIDRs),
+				FormatStringList(config.Server.DeniedCIDRs),
+				config.Server.TrustProxy,
+				config.Server.Headers.ContentSecurityPolicy,
+				config.Server.Headers.CSPReportOnly,
+				config.Server.Headers.HSTSMaxAge,
+				config.Server.Headers.HSTSIncludeSubdomains,
+				config.Server.Headers.FrameOptions,
+				config.Server.Headers.ReferrerPolicy,
+				config.Server.WebDAV.Enabled,
+				config.Server.Sessions.Store,
+				config.Server.Sessions.Redis.Address,
+				config.Server.Sessions.Redis.Password,
+				config.Server.Sessions.Redis.DB,
+				config.Server.Sessions.Redis.Prefix,
+				config.Server.Landing.Admin,
+				config.Server.Landing.Editor,
+				config.Server.Landing.Viewer,
+				config.Server.TrailingSlash,
 				config.Wiki.RootDir,
 				config.Wiki.DocumentsDir,
+				config.Wiki.HomePage,
+				config.Wiki.VersionsDir,
+				config.Wiki.CommentsDir,
+				config.Wiki.TrashDir,
 				config.Wiki.Title,
 				config.Wiki.Owner,
 				config.Wiki.Notice,
 				config.Wiki.Timezone,
 				config.Wiki.Private,
+				config.Wiki.AccessMode,
 				config.Wiki.DisableComments,
 				config.Wiki.DisableFileUploadChecking,
 				config.Wiki.EnableLinkEmbedding,
@@ -190,13 +606,72 @@ func LoadConfig(path string) (*Config, error) {
 				config.Wiki.AlwaysOpenChildrenInSidebar,
 				config.Wiki.MaxVersions,
 				config.Wiki.MaxUploadSize,
+				config.Wiki.MaxDocumentSize,
+				config.Wiki.DocumentSizeWarning,
 				config.Wiki.Language,
+				config.Wiki.WikiLinkResolution,
+				config.Wiki.RenderCacheSize,
+				config.Wiki.RelatedDocuments,
+				config.Wiki.ReadingSpeed,
+				FormatStringList(config.Wiki.ReservedPaths),
+				FormatStringList(config.Wiki.PublishGroups),
+				config.Wiki.IndexFile,
+				FormatStringList(config.Wiki.IndexFallbacks),
+				config.Wiki.RealtimeEditing,
+				config.Wiki.Trash.Enabled,
+				config.Wiki.Trash.RetentionDays,
+				config.Wiki.Review.IntervalDays,
+				config.Wiki.Review.ShowBanner,
+				config.Wiki.Search.Backend,
+				config.Wiki.Search.ContextLength,
+				config.Wiki.Search.Fuzzy,
+				config.Wiki.Search.MaxDistance,
+				config.Wiki.Uploads.MaxFileSize,
+				FormatStringList(config.Wiki.Uploads.AllowedTypes),
+				config.Wiki.Uploads.MaxDocumentStorage,
+				config.Wiki.Uploads.Scan.Provider,
+				config.Wiki.Uploads.Scan.Address,
+				config.Wiki.Uploads.Scan.TimeoutSeconds,
+				config.Wiki.Uploads.Scan.Async,
+				config.Wiki.Suggestions.Enabled,
+				config.Wiki.Suggestions.AllowAnonymous,
+				config.Wiki.Suggestions.MaxPerHour,
+				config.Wiki.Comments.RateLimit.MaxPerIP,
+				config.Wiki.Comments.RateLimit.MaxPerUser,
+				config.Wiki.Comments.RateLimit.WindowSeconds,
+				config.Wiki.Comments.MinRole,
+				config.Wiki.Comments.MinAccountAgeHours,
+				config.Wiki.Comments.Anonymous,
+				config.Wiki.Comments.DisabledByDefault,
+				config.Wiki.Comments.Challenge.Provider,
+				config.Wiki.Comments.Challenge.SiteKey,
+				config.Wiki.Comments.Challenge.SecretKey,
+				config.Wiki.Comments.Challenge.PowDifficulty,
+				config.Wiki.Markdown.Tables,
+				config.Wiki.Markdown.Strikethrough,
+				config.Wiki.Markdown.Autolinks,
+				config.Wiki.Markdown.TaskLists,
+				config.Wiki.Markdown.Footnotes,
+				config.Wiki.Markdown.DefinitionLists,
+				config.Wiki.Markdown.RawHTML,
+				config.Wiki.Markdown.HardWraps,
+				config.Wiki.Markdown.Emoji,
 				config.Security.PasswordStrength,
+				config.Security.PasswordAlgorithm,
+				config.Security.Argon2.MemoryKiB,
+				config.Security.Argon2.Iterations,
+				config.Security.Argon2.Parallelism,
 				config.Security.LoginBan.Enabled,
 				config.Security.LoginBan.MaxFailures,
 				config.Security.LoginBan.WindowSeconds,
 				config.Security.LoginBan.InitialBanSeconds,
 				config.Security.LoginBan.MaxBanSeconds,
+				config.Security.LoginErrors,
+				config.Security.SessionBinding,
+				config.Security.Sanitize.Enabled,
+				FormatStringList(config.Security.Sanitize.AllowedTags),
+				FormatStringList(config.Security.Sanitize.AllowedAttributes),
+				FormatStringList(config.Security.Sanitize.IframeHosts),
 				usersStr.String(),
 				accessRulesStr.String(),
 			)
@@ -206,6 +681,11 @@ func LoadConfig(path string) (*Config, error) {
 			if err != nil {
 				return nil, err
 			}
+			if err := applyEnv(config, envLookup); err != nil {
+				return nil, err
+			}
+			normalizeAccessMode(config)
+			normalizeTLS(config)
 			return config, nil
 		}
 		return nil, err
@@ -217,6 +697,10 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	// Older configs only have the private flag; derive the access mode from it
+	normalizeAccessMode(config)
+	normalizeTLS(config)
+
 	// Ensure the on-disk configuration includes every setting present in the current template.
 	// This will rewrite the file ONLY when new settings have been introduced that are not
 	// present in the user's existing config.yaml. The user's current values will be
@@ -226,34 +710,207 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	// Environment variables take precedence over the file
+	if err := applyEnv(config, envLookup); err != nil {
+		return nil, err
+	}
+	normalizeAccessMode(config)
+	normalizeTLS(config)
+
 	// Migrate user roles from is_admin to role - this is now done in main.go
 
 	return config, nil
 }
 
+// normalizeAccessMode makes the access mode valid, deriving it from the private
+// flag when unset, and keeps the flag in line with the mode
+func normalizeAccessMode(config *Config) {
+	config.Wiki.AccessMode = strings.ToLower(strings.TrimSpace(config.Wiki.AccessMode))
+	switch config.Wiki.AccessMode {
+	case AccessModePublic, AccessModeReadOnlyPublic, AccessModePrivate:
+	default:
+		if config.Wiki.Private {
+			config.Wiki.AccessMode = AccessModePrivate
+		} else {
+			config.Wiki.AccessMode = AccessModePublic
+		}
+	}
+	config.Wiki.Private = config.Wiki.AccessMode == AccessModePrivate
+}
+
+// normalizeTLS moves the ssl settings of older configs into server.tls
+func normalizeTLS(config *Config) {
+	if config.Server.SSL && config.Server.TLS.Mode == "" {
+		config.Server.TLS.Mode = TLSModeStatic
+		config.Server.TLS.CertFile = config.Server.SSLCert
+		config.Server.TLS.KeyFile = config.Server.SSLKey
+	}
+	config.Server.SSL, config.Server.SSLCert, config.Server.SSLKey = false, "", ""
+	config.Server.TLS.Mode = strings.ToLower(strings.TrimSpace(config.Server.TLS.Mode))
+}
+
+// VersionsPath returns the directory holding the version history of the wiki
+func VersionsPath(cfg *Config) string {
+	return storageDir(cfg, cfg.Wiki.VersionsDir, DefaultVersionsDir)
+}
+
+// CommentsPath returns the directory holding the comments of the wiki
+func CommentsPath(cfg *Config) string {
+	return storageDir(cfg, cfg.Wiki.CommentsDir, DefaultCommentsDir)
+}
+
+// TrashPath returns the directory holding the wiki's deleted documents
+func TrashPath(cfg *Config) string {
+	return storageDir(cfg, cfg.Wiki.TrashDir, DefaultTrashDir)
+}
+
+// storageDir joins a directory name configured under wiki to the wiki root,
+// using the default name when none is set
+func storageDir(cfg *Config, name, defaultName string) string {
+	if name == "" {
+		name = defaultName
+	}
+	return filepath.Join(cfg.Wiki.RootDir, name)
+}
+
+// TLSEnabled reports whether the server itself serves HTTPS
+func TLSEnabled(cfg *Config) bool {
+	return cfg.Server.TLS.Mode != ""
+}
+
+// SecureCookies reports whether cookies are only sent over HTTPS. They always
+// are with built-in TLS; behind a proxy, unless allow_insecure_cookies is set.
+func SecureCookies(cfg *Config) bool {
+	return TLSEnabled(cfg) || !cfg.Server.AllowInsecureCookies
+}
+
+// PasswordParams returns how new passwords are hashed
+func PasswordParams(cfg *Config) crypto.HashParams {
+	return crypto.HashParams{
+		Algorithm:   cfg.Security.PasswordAlgorithm,
+		Cost:        cfg.Security.PasswordStrength,
+		Memory:      cfg.Security.Argon2.MemoryKiB,
+		Iterations:  cfg.Security.Argon2.Iterations,
+		Parallelism: cfg.Security.Argon2.Parallelism,
+	}
+}
+
 // GetConfigTemplate returns the template for the config file with comments
 func GetConfigTemplate() string {
 	return `server:
     host: "%s"
     port: %d
+    # Public address of the wiki, e.g. https://wiki.example.com, used in sitemaps and
+    # QR codes. Set it when the wiki is behind a proxy; empty uses the request's address.
+    base_url: "%s"
     # When set to true, allows cookies to be sent over non-HTTPS connections.
     # WARNING: Only enable this in trusted environments like a homelab
     # where HTTPS is not available. This reduces security by allowing
     # cookies to be transmitted in plain text.
     allow_insecure_cookies: %t
-    # Enable native TLS. When true, application will run over HTTPS using the
-    # supplied certificate and key paths.
-    ssl: %t
-    ssl_cert: "%s"
-    ssl_key: "%s"
+    # Built-in HTTPS, instead of a reverse proxy. mode is empty for plain HTTP,
+    # "static" for the certificate and key in cert_file and key_file, or "acme" for
+    # certificates obtained and renewed automatically for acme.hosts from Let's Encrypt
+    # (or the CA at acme.directory). ACME validates over this port, so it must be
+    # reachable on 443, or over redirect_http on :80. Cookies are always secure
+    # and HSTS is sent with TLS on.
+    tls:
+        mode: "%s"
+        cert_file: "%s"
+        key_file: "%s"
+        acme:
+            hosts: [%s]
+            email: "%s"
+            directory: "%s"
+        # Also listen here (e.g. ":80") and redirect plain HTTP requests to HTTPS
+        redirect_http: "%s"
+    # Expose Prometheus metrics at /metrics. When listen is set (e.g. "127.0.0.1:9100"),
+    # metrics are served only on that address instead of the main listener.
+    metrics:
+        enabled: %t
+        listen: "%s"
+    # Minimum level for structured JSON logs: debug, info, warn or error
+    log_level: "%s"
+    # Gzip-compress responses of at least min_size bytes for clients that accept it.
+    # Images and other already-compressed files are sent as they are.
+    compression:
+        enabled: %t
+        min_size: %d
+    # Limits on client requests, against slow or oversized requests tying up the server.
+    # Timeouts are in seconds (0 disables one); uploads, imports and backup downloads
+    # aren't cut off by read_timeout and write_timeout. max_body_size (KB, 0 = unlimited)
+    # applies to API requests other than document content and files, which are bounded
+    # by max_document_size and max_upload_size; larger requests get a 413.
+    limits:
+        read_header_timeout: %d
+        read_timeout: %d
+        write_timeout: %d
+        idle_timeout: %d
+        max_header_bytes: %d
+        max_body_size: %d
+    # Restrict access by client IP (CIDR ranges or single addresses), e.g. ["10.0.0.0/8", "192.168.1.5"].
+    # An empty allow list allows everyone not denied. Denied ranges take precedence.
+    allowed_cidrs: [%s]
+    denied_cidrs: [%s]
+    # Set to true when running behind a reverse proxy so the client IP is read from
+    # X-Forwarded-For / X-Real-IP. Leave false when directly exposed, as clients can forge these headers.
+    trust_proxy: %t
+    # Security headers added to every response
+    headers:
+        # Content-Security-Policy. Leave empty for the built-in policy, which works with
+        # Mermaid, MathJax and video embeds; set "-" to send no policy at all. A custom policy
+        # can use {nonce} for the per-request nonce carried by the wiki's inline scripts.
+        content_security_policy: "%s"
+        # Only report CSP violations in the browser console instead of blocking them
+        csp_report_only: %t
+        # Strict-Transport-Security max-age in seconds, sent only over HTTPS (0 disables)
+        hsts_max_age: %d
+        hsts_include_subdomains: %t
+        # X-Frame-Options (DENY or SAMEORIGIN); also sets CSP frame-ancestors with the built-in policy
+        frame_options: "%s"
+        referrer_policy: "%s"
+    # Serve the documents tree over WebDAV at /dav/ so it can be mounted as a network drive.
+    # Clients sign in with their wiki username and password (HTTP Basic auth); use HTTPS.
+    webdav:
+        enabled: %t
+    # Where sessions are kept: memory (saved to disk on this instance) or redis, which
+    # lets several instances behind a load balancer share sessions without sticky routing
+    sessions:
+        store: "%s"
+        redis:
+            address: "%s"
+            password: "%s"
+            db: %d
+            prefix: "%s"
+    # Page each role lands on after signing in on the login page, e.g. "/dashboard"
+    # (empty = homepage). A login started from another page returns to that page.
+    landing:
+        admin: "%s"
+        editor: "%s"
+        viewer: "%s"
+    # Canonical form of page URLs: strip (/guides/setup) or add (/guides/setup/) the
+    # trailing slash, redirecting the other form with a 301, or keep to serve both
+    trailing_slash: "%s"
 wiki:
     root_dir: "%s"
     documents_dir: "%s"
+    # Directory of the homepage document, relative to root_dir; point it at a
+    # document (e.g. documents/welcome) to make that document the landing page
+    home_page: "%s"
+    # Directories of the version history, the comments and the trash, relative to
+    # root_dir. Existing data is moved on the next start when they are renamed.
+    versions_dir: "%s"
+    comments_dir: "%s"
+    trash_dir: "%s"
     title: "%s"
     owner: "%s"
     notice: "%s"
     timezone: "%s"
     private: %t
+    # Who can read and change the wiki: public (anyone reads, signed-in users comment),
+    # read-only-public (anyone reads, only editors and admins change anything)
+    # or private (sign-in required to read). Takes precedence over private.
+    access_mode: "%s"
     disable_comments: %t
     disable_file_upload_checking: %t
     enable_link_embedding: %t
@@ -263,11 +920,135 @@ wiki:
     max_versions: %d
     # Maximum file upload size in MB
     max_upload_size: %d
+    # Largest document in KB that can be saved or imported (0 = unlimited), and the
+    # size in KB above which saving warns that a document is getting large (0 = never)
+    max_document_size: %d
+    document_size_warning: %d
     # Default language for the wiki interface (en, es, etc.)
     language: "%s"
+    # How [[WikiLink]] titles shared by several documents are resolved:
+    # nearest (closest to the linking page), shortest (shallowest path) or first (alphabetical)
+    wikilink_resolution: "%s"
+    # Number of rendered documents kept in memory (0 = render on every view)
+    render_cache_size: %d
+    # Number of related documents, by shared tags and wording, suggested below each document (0 = none)
+    related_documents: %d
+    # Words per minute used to estimate reading times (0 = don't show word counts and reading times)
+    reading_speed: %d
+    # Paths no document may be created, moved or renamed into, along with those below
+    # them, so documents don't hide the wiki's own routes
+    reserved_paths: [%s]
+    # Groups whose editors may move a document, or a category, where the access rules
+    # let more users read it, e.g. from internal/ to public/. Admins always may; other
+    # editors can only move documents among places with the same or fewer readers.
+    publish_groups: [%s]
+    # File holding each document's content, which new documents are written to
+    index_file: "%s"
+    # Other files a document's content is read from when its index_file is missing,
+    # first match wins, e.g. [README.md, index.md]; document.md is always tried last
+    index_fallbacks: [%s]
+    # Editors of the same document see each other's changes and cursors as they type,
+    # over a WebSocket to /api/collab/{path}
+    realtime_editing: %t
+    # Deleted documents are kept in the trash, with their history and comments, until
+    # purged after retention_days (0 = keep until deleted from the trash)
+    trash:
+        enabled: %t
+        retention_days: %d
+    # Documents with a reviewBy date in their front matter are listed as needing review
+    # once it passes. Marking one reviewed moves the date interval_days ahead.
+    review:
+        interval_days: %d
+        show_banner: %t
+    # Index used to find the documents matching a search: scan (none, read every document),
+    # memory (built on every start) or disk (saved in root_dir/temp, so a restart only
+    # reindexes the documents changed in the meantime)
+    search:
+        backend: "%s"
+        # Characters of each result's excerpt shown before and after the first match
+        context_length: %d
+        # Also list documents with words up to max_distance typos (one per four letters)
+        # from the search words, after the exact matches
+        fuzzy: %t
+        max_distance: %d
+    # Limits on document attachments. max_file_size is in MB (0 = max_upload_size),
+    # allowed_types narrows the supported file types to MIME types ("image/png", "image/*")
+    # or extensions ("pdf") checked against the uploaded content (empty = all supported types),
+    # and max_document_storage caps the MB of attachments of one document (0 = unlimited)
+    uploads:
+        max_file_size: %d
+        allowed_types: [%s]
+        max_document_storage: %d
+        # Check attachments for malware with a ClamAV daemon (provider: clamd) listening on
+        # a unix socket path or host:port. Infected files are rejected and logged. With async
+        # the upload is accepted at once and kept in quarantine until the scan passes.
+        scan:
+            provider: "%s"
+            address: "%s"
+            timeout_seconds: %d
+            async: %t
+    # Readers who cannot edit a document may suggest a new version of it, which editors
+    # review as a diff and accept or reject. max_per_hour limits suggestions per client IP
+    # (0 = unlimited); allow_anonymous also takes them from visitors who are not signed in.
+    suggestions:
+        enabled: %t
+        allow_anonymous: %t
+        max_per_hour: %d
+    comments:
+        # Maximum comments per client IP and per user within the window (0 = unlimited)
+        rate_limit:
+            max_per_ip: %d
+            max_per_user: %d
+            window_seconds: %d
+        # Lowest role allowed to post comments (viewer, editor or admin)
+        min_role: "%s"
+        # Minimum account age in hours before a user can comment (0 = no minimum)
+        min_account_age_hours: %d
+        # Comments from visitors who are not signed in, on documents they can read: off,
+        # anonymous (shown as Anonymous), name (a name is required) or name_email (a name and
+        # an email address, which only admins see, are required). Not in read-only-public mode.
+        anonymous: "%s"
+        # Whether documents only accept comments with "comments: true" in their front matter.
+        # Otherwise documents accept them unless their front matter says "comments: false".
+        disabled_by_default: %t
+        # Challenge viewers must pass before commenting: "" (none), hcaptcha, turnstile or pow.
+        # hcaptcha and turnstile need the site and secret keys from the provider.
+        challenge:
+            provider: "%s"
+            site_key: "%s"
+            secret_key: "%s"
+            pow_difficulty: %d
+    # Markdown features available in documents
+    markdown:
+        # | tables |
+        tables: %t
+        # ~~strikethrough~~
+        strikethrough: %t
+        # Turn bare URLs and www. addresses into links
+        autolinks: %t
+        # - [ ] and - [x] checkboxes
+        task_lists: %t
+        # [^1] references with [^1]: definitions
+        footnotes: %t
+        # Term lines followed by ": definition"
+        definition_lists: %t
+        # HTML tags written in documents; when false they are shown as text
+        raw_html: %t
+        # Single line breaks become <br>
+        hard_wraps: %t
+        # :smile: style shortcodes become emoji
+        emoji: %t
 security:
     # cost factor for bcrypt password hashing
     passwordstrength: %d
+    # Algorithm for new password hashes: bcrypt or argon2id. Existing hashes keep
+    # working and are upgraded when their user signs in.
+    password_algorithm: "%s"
+    argon2:
+        # Memory in KiB, passes over it and threads used to hash one password
+        memory_kib: %d
+        iterations: %d
+        parallelism: %d
     login_ban:
         # Enable protection against brute force login attacks
         enabled: %t
@@ -279,6 +1060,23 @@ security:
         initial_ban_seconds: %d
         # Maximum ban duration in seconds (24 hours)
         max_ban_seconds: %d
+    # What a failed login tells the client: generic answers "invalid credentials"
+    # to every failure, so a ban can't be told from a wrong password; detailed
+    # reports bans and when to retry
+    login_errors: "%s"
+    # Tie sessions to the browser they were created in (user_agent), also to the client's
+    # network (user_agent_ip; signs out users whose IP changes, e.g. on mobile), or to
+    # nothing (none). A stolen session token used elsewhere ends the session.
+    session_binding: "%s"
+    sanitize:
+        # Strip scripts, event handlers and javascript: URLs from rendered documents.
+        # Comments are always sanitized.
+        enabled: %t
+        # Extra HTML tags and attributes to allow on top of the built-in allowlist
+        allowed_tags: [%s]
+        allowed_attributes: [%s]
+        # Hosts iframes may load (video embeds)
+        iframe_hosts: [%s]
 users:
 %s
 access_rules:
@@ -290,6 +1088,18 @@ func FormatUserEntry(user User) string {
 	entry := fmt.Sprintf("    - username: %s\n      password: %s\n      role: %s",
 		user.Username, user.Password, user.Role)
 
+	if !user.Created.IsZero() {
+		entry += fmt.Sprintf("\n      created: %s", user.Created.UTC().Format(time.RFC3339))
+	}
+
+	if user.Language != "" {
+		entry += fmt.Sprintf("\n      language: %s", user.Language)
+	}
+
+	if user.Disabled {
+		entry += "\n      disabled: true"
+	}
+
 	if len(user.Groups) > 0 {
 		entry += "\n      groups:"
 		for _, group := range user.Groups {
@@ -299,6 +1109,15 @@ func FormatUserEntry(user User) string {
 	return entry
 }
 
+// FormatStringList formats values as the items of a YAML flow sequence
+func FormatStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // FormatAccessRuleEntry formats a single access rule entry for the config file
 func FormatAccessRuleEntry(rule AccessRule) string {
 	entry := fmt.Sprintf("    - pattern: \"%s\"\n      access: %s", rule.Pattern, rule.Access)
@@ -313,6 +1132,9 @@ func FormatAccessRuleEntry(rule AccessRule) string {
 
 // SaveConfig saves the configuration to a writer
 func SaveConfig(cfg *Config, w io.Writer) error {
+	// Values from environment variables stay out of the file
+	cfg = cfg.fileValues()
+
 	// Format all users
 	var usersStr strings.Builder
 	for _, user := range cfg.Users {
@@ -336,17 +1158,57 @@ func SaveConfig(cfg *Config, w io.Writer) error {
 		GetConfigTemplate(),
 		cfg.Server.Host,
 		cfg.Server.Port,
+		cfg.Server.BaseURL,
 		cfg.Server.AllowInsecureCookies,
-		cfg.Server.SSL,
-		cfg.Server.SSLCert,
-		cfg.Server.SSLKey,
+		cfg.Server.TLS.Mode,
+		cfg.Server.TLS.CertFile,
+		cfg.Server.TLS.KeyFile,
+		FormatStringList(cfg.Server.TLS.ACME.Hosts),
+		cfg.Server.TLS.ACME.Email,
+		cfg.Server.TLS.ACME.Directory,
+		cfg.Server.TLS.RedirectHTTP,
+		cfg.Server.Metrics.Enabled,
+		cfg.Server.Metrics.Listen,
+		cfg.Server.LogLevel,
+		cfg.Server.Compression.Enabled,
+		cfg.Server.Compression.MinSize,
+		cfg.Server.Limits.ReadHeaderTimeout,
+		cfg.Server.Limits.ReadTimeout,
+		cfg.Server.Limits.WriteTimeout,
+		cfg.Server.Limits.IdleTimeout,
+		cfg.Server.Limits.MaxHeaderBytes,
+		cfg.Server.Limits.MaxBodySize,
+		FormatStringList(cfg.Server.AllowedCIDRs),
+		FormatStringList(cfg.Server.DeniedCIDRs),
+		cfg.Server.TrustProxy,
+		cfg.Server.Headers.ContentSecurityPolicy,
+		cfg.Server.Headers.CSPReportOnly,
+		cfg.Server.Headers.HSTSMaxAge,
+		cfg.Server.Headers.HSTSIncludeSubdomains,
+		cfg.Server.Headers.FrameOptions,
+		cfg.Server.Headers.ReferrerPolicy,
+		cfg.Server.WebDAV.Enabled,
+		cfg.Server.Sessions.Store,
+		cfg.Server.Sessions.Redis.Address,
+		cfg.Server.Sessions.Redis.Password,
+		cfg.Server.Sessions.Redis.DB,
+		cfg.Server.Sessions.Redis.Prefix,
+		cfg.Server.Landing.Admin,
+		cfg.Server.Landing.Editor,
+		cfg.Server.Landing.Viewer,
+		cfg.Server.TrailingSlash,
 		cfg.Wiki.RootDir,
 		cfg.Wiki.DocumentsDir,
+		cfg.Wiki.HomePage,
+		cfg.Wiki.VersionsDir,
+		cfg.Wiki.CommentsDir,
+		cfg.Wiki.TrashDir,
 		cfg.Wiki.Title,
 		cfg.Wiki.Owner,
 		cfg.Wiki.Notice,
 		cfg.Wiki.Timezone,
 		cfg.Wiki.Private,
+		cfg.Wiki.AccessMode,
 		cfg.Wiki.DisableComments,
 		cfg.Wiki.DisableFileUploadChecking,
 		cfg.Wiki.EnableLinkEmbedding,
@@ -355,13 +1217,72 @@ func SaveConfig(cfg *Config, w io.Writer) error {
 		cfg.Wiki.AlwaysOpenChildrenInSidebar,
 		cfg.Wiki.MaxVersions,
 		cfg.Wiki.MaxUploadSize,
+		cfg.Wiki.MaxDocumentSize,
+		cfg.Wiki.DocumentSizeWarning,
 		cfg.Wiki.Language,
+		cfg.Wiki.WikiLinkResolution,
+		cfg.Wiki.RenderCacheSize,
+		cfg.Wiki.RelatedDocuments,
+		cfg.Wiki.ReadingSpeed,
+		FormatStringList(cfg.Wiki.ReservedPaths),
+		FormatStringList(cfg.Wiki.PublishGroups),
+		cfg.Wiki.IndexFile,
+		FormatStringList(cfg.Wiki.IndexFallbacks),
+		cfg.Wiki.RealtimeEditing,
+		cfg.Wiki.Trash.Enabled,
+		cfg.Wiki.Trash.RetentionDays,
+		cfg.Wiki.Review.IntervalDays,
+		cfg.Wiki.Review.ShowBanner,
+		cfg.Wiki.Search.Backend,
+		cfg.Wiki.Search.ContextLength,
+		cfg.Wiki.Search.Fuzzy,
+		cfg.Wiki.Search.MaxDistance,
+		cfg.Wiki.Uploads.MaxFileSize,
+		FormatStringList(cfg.Wiki.Uploads.AllowedTypes),
+		cfg.Wiki.Uploads.MaxDocumentStorage,
+		cfg.Wiki.Uploads.Scan.Provider,
+		cfg.Wiki.Uploads.Scan.Address,
+		cfg.Wiki.Uploads.Scan.TimeoutSeconds,
+		cfg.Wiki.Uploads.Scan.Async,
+		cfg.Wiki.Suggestions.Enabled,
+		cfg.Wiki.Suggestions.AllowAnonymous,
+		cfg.Wiki.Suggestions.MaxPerHour,
+		cfg.Wiki.Comments.RateLimit.MaxPerIP,
+		cfg.Wiki.Comments.RateLimit.MaxPerUser,
+		cfg.Wiki.Comments.RateLimit.WindowSeconds,
+		cfg.Wiki.Comments.MinRole,
+		cfg.Wiki.Comments.MinAccountAgeHours,
+		cfg.Wiki.Comments.Anonymous,
+		cfg.Wiki.Comments.DisabledByDefault,
+		cfg.Wiki.Comments.Challenge.Provider,
+		cfg.Wiki.Comments.Challenge.SiteKey,
+		cfg.Wiki.Comments.Challenge.SecretKey,
+		cfg.Wiki.Comments.Challenge.PowDifficulty,
+		cfg.Wiki.Markdown.Tables,
+		cfg.Wiki.Markdown.Strikethrough,
+		cfg.Wiki.Markdown.Autolinks,
+		cfg.Wiki.Markdown.TaskLists,
+		cfg.Wiki.Markdown.Footnotes,
+		cfg.Wiki.Markdown.DefinitionLists,
+		cfg.Wiki.Markdown.RawHTML,
+		cfg.Wiki.Markdown.HardWraps,
+		cfg.Wiki.Markdown.Emoji,
 		cfg.Security.PasswordStrength,
+		cfg.Security.PasswordAlgorithm,
+		cfg.Security.Argon2.MemoryKiB,
+		cfg.Security.Argon2.Iterations,
+		cfg.Security.Argon2.Parallelism,
 		cfg.Security.LoginBan.Enabled,
 		cfg.Security.LoginBan.MaxFailures,
 		cfg.Security.LoginBan.WindowSeconds,
 		cfg.Security.LoginBan.InitialBanSeconds,
 		cfg.Security.LoginBan.MaxBanSeconds,
+		cfg.Security.LoginErrors,
+		cfg.Security.SessionBinding,
+		cfg.Security.Sanitize.Enabled,
+		FormatStringList(cfg.Security.Sanitize.AllowedTags),
+		FormatStringList(cfg.Security.Sanitize.AllowedAttributes),
+		FormatStringList(cfg.Security.Sanitize.IframeHosts),
 		usersStr.String(),
 		accessRulesStr.String(),
 	)
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigAccessMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		wiki        string
+		wantMode    string
+		wantPrivate bool
+	}{
+		{"legacy private", "wiki:\n    private: true\n", AccessModePrivate, true},
+		{"legacy public", "wiki:\n    private: false\n", AccessModePublic, false},
+		{"neither", "wiki:\n    title: Docs\n", AccessModePublic, false},
+		{"access mode wins", "wiki:\n    private: true\n    access_mode: read-only-public\n", AccessModeReadOnlyPublic, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.wiki), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Wiki.AccessMode != tt.wantMode || cfg.Wiki.Private != tt.wantPrivate {
+				t.Errorf("access mode = %q (private %t), want %q (private %t)", cfg.Wiki.AccessMode, cfg.Wiki.Private, tt.wantMode, tt.wantPrivate)
+			}
+
+			// The completed file keeps the mode when read again
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "access_mode: \"" + tt.wantMode + "\""; !strings.Contains(string(saved), want) {
+				t.Errorf("saved config lacks %s", want)
+			}
+			if again, err := LoadConfig(path); err != nil || again.Wiki.AccessMode != tt.wantMode {
+				t.Errorf("reloaded access mode = %q, %v", again.Wiki.AccessMode, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// setting. The rest of the name is the setting's YAML path in upper case joined
+// by underscores, e.g. server.port is WIKIGO_SERVER_PORT and
+// wiki.comments.rate_limit.max_per_ip is WIKIGO_WIKI_COMMENTS_RATE_LIMIT_MAX_PER_IP.
+// Lists are comma-separated.
+//
+// Precedence is environment, then config file, then built-in defaults. Overridden
+// values are used at runtime but never written back to the config file.
+const EnvPrefix = "WIKIGO_"
+
+// Environment variables for the admin account, which lives in the users list
+// rather than at a fixed path. WIKIGO_ADMIN_PASSWORD_HASH takes a bcrypt hash
+// and sets the password of the admin named by WIKIGO_ADMIN_USERNAME ("admin"
+// by default), creating the account if the file doesn't have it.
+const (
+	EnvAdminUsername     = EnvPrefix + "ADMIN_USERNAME"
+	EnvAdminPasswordHash = EnvPrefix + "ADMIN_PASSWORD_HASH"
+)
+
+// envOverride remembers the file value of a setting replaced from the environment
+type envOverride struct {
+	index    []int       // Field index path within Config
+	original interface{} // Value before the override
+}
+
+// envAdmin remembers the file state of the admin account set from the environment
+type envAdmin struct {
+	username string
+	password string // Password hash from the file
+	created  bool   // The account isn't in the file at all
+}
+
+// applyEnv overlays environment variables on c. lookup is os.LookupEnv outside
+// tests. Invalid values are reported together and leave the setting unchanged.
+func applyEnv(c *Config, lookup func(string) (string, bool)) error {
+	var errs []error
+	_, privateSet := lookup(EnvPrefix + "WIKI_PRIVATE")
+	_, accessModeSet := lookup(EnvPrefix + "WIKI_ACCESS_MODE")
+
+	var walk func(v reflect.Value, index []int, name string)
+	walk = func(v reflect.Value, index []int, name string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || tag == "" || tag == "-" {
+				continue
+			}
+			fieldIndex := append(append([]int{}, index...), i)
+			fieldName := name + "_" + strings.ToUpper(tag)
+			fv := v.Field(i)
+
+			if fv.Kind() == reflect.Struct {
+				walk(fv, fieldIndex, fieldName)
+				continue
+			}
+			raw, ok := lookup(fieldName)
+			if !ok {
+				continue
+			}
+
+			original := fv.Interface()
+			switch fv.Kind() {
+			case reflect.String:
+				fv.SetString(raw)
+			case reflect.Bool:
+				b, err := strconv.ParseBool(strings.TrimSpace(raw))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %q is not a boolean", fieldName, raw))
+					continue
+				}
+				fv.SetBool(b)
+			case reflect.Int:
+				n, err := strconv.Atoi(strings.TrimSpace(raw))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %q is not a number", fieldName, raw))
+					continue
+				}
+				fv.SetInt(int64(n))
+			case reflect.Slice:
+				if fv.Type().Elem().Kind() != reflect.String {
+					// Lists of users and access rules have no fixed path
+					continue
+				}
+				values := []string{}
+				for _, value := range strings.Split(raw, ",") {
+					if value = strings.TrimSpace(value); value != "" {
+						values = append(values, value)
+					}
+				}
+				fv.Set(reflect.ValueOf(values))
+			default:
+				continue
+			}
+			c.env = append(c.env, envOverride{index: fieldIndex, original: original})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), nil, strings.TrimSuffix(EnvPrefix, "_"))
+
+	// The private flag only decides the access mode when no mode is given, as in the file
+	if privateSet && !accessModeSet {
+		original := c.Wiki.AccessMode
+		if c.Wiki.Private {
+			c.Wiki.AccessMode = AccessModePrivate
+		} else if c.Wiki.AccessMode == AccessModePrivate {
+			c.Wiki.AccessMode = AccessModePublic
+		}
+		field, _ := reflect.TypeOf(c).Elem().FieldByName("Wiki")
+		modeField, _ := field.Type.FieldByName("AccessMode")
+		c.env = append(c.env, envOverride{index: append(field.Index, modeField.Index...), original: original})
+	}
+
+	if hash, ok := lookup(EnvAdminPasswordHash); ok {
+		username, _ := lookup(EnvAdminUsername)
+		if username = strings.TrimSpace(username); username == "" {
+			username = "admin"
+		}
+		admin := &envAdmin{username: username, created: true}
+		for i, user := range c.Users {
+			if user.Username == username {
+				admin.password = user.Password
+				admin.created = false
+				c.Users[i].Password = hash
+				break
+			}
+		}
+		if admin.created {
+			// Copy so the file's user list is left untouched
+			c.Users = append(append([]User{}, c.Users...), User{Username: username, Password: hash, Role: RoleAdmin})
+		}
+		c.envAdmin = admin
+	} else if _, ok := lookup(EnvAdminUsername); ok {
+		errs = append(errs, fmt.Errorf("%s: requires %s", EnvAdminUsername, EnvAdminPasswordHash))
+	}
+
+	return errors.Join(errs...)
+}
+
+// fileValues returns a copy of c with settings overridden from the environment
+// restored to their config file values, for writing the config file
+func (c *Config) fileValues() *Config {
+	if len(c.env) == 0 && c.envAdmin == nil {
+		return c
+	}
+	restored := *c
+	v := reflect.ValueOf(&restored).Elem()
+	for i := len(c.env) - 1; i >= 0; i-- {
+		override := c.env[i]
+		v.FieldByIndex(override.index).Set(reflect.ValueOf(override.original))
+	}
+
+	if admin := c.envAdmin; admin != nil {
+		users := make([]User, 0, len(c.Users))
+		for _, user := range c.Users {
+			if user.Username == admin.username {
+				if admin.created {
+					continue
+				}
+				user.Password = admin.password
+			}
+			users = append(users, user)
+		}
+		restored.Users = users
+	}
+	restored.Wiki.Private = restored.Wiki.AccessMode == AccessModePrivate
+	return &restored
+}
+
+// envLookup is the environment lookup used by LoadConfig
+var envLookup = os.LookupEnv
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"WIKIGO_SERVER_PORT":                         "9000",
+		"WIKIGO_SERVER_METRICS_ENABLED":              "true",
+		"WIKIGO_SERVER_ALLOWED_CIDRS":                "10.0.0.0/8, 192.168.1.5",
+		"WIKIGO_WIKI_PRIVATE":                        "true",
+		"WIKIGO_WIKI_COMMENTS_RATE_LIMIT_MAX_PER_IP": "3",
+		"WIKIGO_ADMIN_PASSWORD_HASH":                 "$2a$10$envhash",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	c := &Config{}
+	c.Server.Port = 8080
+	c.Wiki.AccessMode = AccessModePublic
+	c.Users = []User{{Username: "admin", Password: "$2a$10$filehash", Role: RoleAdmin}}
+
+	if err := applyEnv(c, lookup); err != nil {
+		t.Fatalf("applyEnv() error = %v", err)
+	}
+	normalizeAccessMode(c)
+
+	if c.Server.Port != 9000 {
+		t.Errorf("Server.Port = %d, want 9000", c.Server.Port)
+	}
+	if !c.Server.Metrics.Enabled {
+		t.Errorf("Server.Metrics.Enabled = false, want true")
+	}
+	if want := []string{"10.0.0.0/8", "192.168.1.5"}; !reflect.DeepEqual(c.Server.AllowedCIDRs, want) {
+		t.Errorf("Server.AllowedCIDRs = %q, want %q", c.Server.AllowedCIDRs, want)
+	}
+	if c.Wiki.AccessMode != AccessModePrivate || !c.Wiki.Private {
+		t.Errorf("access mode = %q (private %v), want private", c.Wiki.AccessMode, c.Wiki.Private)
+	}
+	if c.Wiki.Comments.RateLimit.MaxPerIP != 3 {
+		t.Errorf("Wiki.Comments.RateLimit.MaxPerIP = %d, want 3", c.Wiki.Comments.RateLimit.MaxPerIP)
+	}
+	if c.Users[0].Password != "$2a$10$envhash" {
+		t.Errorf("admin password = %q, want the environment hash", c.Users[0].Password)
+	}
+
+	// Overrides are not written to the config file
+	var buf bytes.Buffer
+	if err := SaveConfig(c, &buf); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	written := buf.String()
+	for _, want := range []string{"port: 8080", "allowed_cidrs: []", "access_mode: \"public\"", "$2a$10$filehash"} {
+		if !strings.Contains(written, want) {
+			t.Errorf("saved config doesn't contain %q", want)
+		}
+	}
+	if strings.Contains(written, "envhash") {
+		t.Errorf("saved config contains values from the environment")
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	env := map[string]string{
+		"WIKIGO_SERVER_PORT": "eighty",
+		"WIKIGO_SERVER_SSL":  "maybe",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	c := &Config{}
+	c.Server.Port = 8080
+	err := applyEnv(c, lookup)
+	if err == nil {
+		t.Fatal("applyEnv() error = nil, want errors for invalid values")
+	}
+	for _, want := range []string{"WIKIGO_SERVER_PORT", "WIKIGO_SERVER_SSL"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error doesn't mention %s: %v", want, err)
+		}
+	}
+	if c.Server.Port != 8080 {
+		t.Errorf("Server.Port = %d, want the file value to be kept", c.Server.Port)
+	}
+}
@@ -131,6 +131,90 @@ func GetMaxUploadSizeFormatted(cfg *Config) string {
 	return "20MB"
 }
 
+// GetMaxAttachmentSizeBytes returns the maximum size of an attachment upload in
+// bytes, which is max_upload_size unless uploads.max_file_size is set
+func GetMaxAttachmentSizeBytes(cfg *Config) int64 {
+	if cfg != nil && cfg.Wiki.Uploads.MaxFileSize > 0 {
+		return int64(cfg.Wiki.Uploads.MaxFileSize) * 1024 * 1024
+	}
+	return GetMaxUploadSizeBytes(cfg)
+}
+
+// GetMaxAttachmentSizeFormatted returns the maximum attachment size in a human-readable format
+func GetMaxAttachmentSizeFormatted(cfg *Config) string {
+	if cfg != nil && cfg.Wiki.Uploads.MaxFileSize > 0 {
+		return fmt.Sprintf("%dMB", cfg.Wiki.Uploads.MaxFileSize)
+	}
+	return GetMaxUploadSizeFormatted(cfg)
+}
+
+// GetMaxDocumentStorageBytes returns the maximum total size of one document's
+// attachments in bytes, or 0 when it is unlimited
+func GetMaxDocumentStorageBytes(cfg *Config) int64 {
+	if cfg != nil && cfg.Wiki.Uploads.MaxDocumentStorage > 0 {
+		return int64(cfg.Wiki.Uploads.MaxDocumentStorage) * 1024 * 1024
+	}
+	return 0
+}
+
+// matchesUploadType reports whether an uploads.allowed_types entry, a MIME type
+// such as "image/png" or "image/*" or an extension such as "pdf", covers the
+// file type
+func matchesUploadType(entry string, fileType FileTypeConfig) bool {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	if prefix, ok := strings.CutSuffix(entry, "/*"); ok {
+		return strings.HasPrefix(fileType.MimeType, prefix+"/")
+	}
+	if strings.Contains(entry, "/") {
+		return fileType.MimeType == entry
+	}
+	return fileType.Extension == strings.TrimPrefix(entry, ".")
+}
+
+// IsSupportedUploadType checks if an uploads.allowed_types entry covers at
+// least one of the allowed file types
+func IsSupportedUploadType(entry string) bool {
+	for _, fileType := range AllowedFileTypes {
+		if matchesUploadType(entry, fileType) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAllowedUploadType checks if attachments with the given extension may be
+// uploaded under the uploads.allowed_types setting. An empty setting allows
+// every file type.
+func IsAllowedUploadType(cfg *Config, ext string) bool {
+	if cfg == nil || len(cfg.Wiki.Uploads.AllowedTypes) == 0 {
+		return true
+	}
+	ext = strings.TrimPrefix(strings.ToLower(ext), ".")
+	for _, fileType := range AllowedFileTypes {
+		if fileType.Extension != ext {
+			continue
+		}
+		for _, entry := range cfg.Wiki.Uploads.AllowedTypes {
+			if matchesUploadType(entry, fileType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetAllowedUploadExtensions returns the extensions of the file types
+// attachments may have under the uploads.allowed_types setting
+func GetAllowedUploadExtensions(cfg *Config) []string {
+	var extensions []string
+	for _, ext := range GetAllowedExtensions() {
+		if IsAllowedUploadType(cfg, ext) {
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
 // ShouldVerifyContentType checks if a given extension should have its content type verified
 func ShouldVerifyContentType(ext string) bool {
 	// Remove the leading dot if present
@@ -0,0 +1,413 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wiki-go/internal/challenge"
+	"wiki-go/internal/crypto"
+	"wiki-go/internal/ipfilter"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Validate checks the configuration for problems that would otherwise surface as
+// confusing failures at runtime. All problems are reported together, one per
+// line of the returned error, each naming the offending config.yaml key.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	// Server
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port: %d is not a valid port (1-65535)", c.Server.Port)
+	}
+	if c.Server.BaseURL != "" {
+		u, err := url.Parse(c.Server.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			add("server.base_url: %q must be an http or https URL without query or fragment", c.Server.BaseURL)
+		}
+	}
+	switch c.Server.TLS.Mode {
+	case "":
+	case TLSModeStatic:
+		if c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "" {
+			add("server.tls: cert_file and key_file are required when mode is static")
+		}
+		for key, path := range map[string]string{"server.tls.cert_file": c.Server.TLS.CertFile, "server.tls.key_file": c.Server.TLS.KeyFile} {
+			if path == "" {
+				continue
+			}
+			if _, err := os.Stat(path); err != nil {
+				add("%s: cannot read %s: %v", key, path, errors.Unwrap(err))
+			}
+		}
+	case TLSModeACME:
+		if len(c.Server.TLS.ACME.Hosts) == 0 {
+			add("server.tls.acme.hosts: at least one host name is required when mode is acme")
+		}
+		for _, host := range c.Server.TLS.ACME.Hosts {
+			if host == "" || strings.ContainsAny(host, "*:/ ") || net.ParseIP(host) != nil {
+				add("server.tls.acme.hosts: %q is not a DNS name a certificate can be obtained for", host)
+			}
+		}
+	default:
+		add("server.tls.mode: %q must be empty, static or acme", c.Server.TLS.Mode)
+	}
+	if redirect := c.Server.TLS.RedirectHTTP; redirect != "" {
+		if c.Server.TLS.Mode == "" {
+			add("server.tls.redirect_http: set while server.tls.mode is empty")
+		}
+		if _, port, err := net.SplitHostPort(redirect); err != nil {
+			add("server.tls.redirect_http: %q is not a host:port address", redirect)
+		} else if port == strconv.Itoa(c.Server.Port) {
+			add("server.tls.redirect_http: port %s is already used by server.port", port)
+		}
+	}
+	if listen := c.Server.Metrics.Listen; listen != "" {
+		if !c.Server.Metrics.Enabled {
+			add("server.metrics.listen: set while server.metrics.enabled is false")
+		}
+		if _, port, err := net.SplitHostPort(listen); err != nil {
+			add("server.metrics.listen: %q is not a host:port address", listen)
+		} else if port == strconv.Itoa(c.Server.Port) {
+			add("server.metrics.listen: port %s is already used by server.port", port)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Server.LogLevel)) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		add("server.log_level: %q is not one of debug, info, warn or error", c.Server.LogLevel)
+	}
+	if c.Server.Compression.MinSize < 0 {
+		add("server.compression.min_size: must not be negative")
+	}
+	for key, value := range map[string]int{
+		"server.limits.read_header_timeout": c.Server.Limits.ReadHeaderTimeout, "server.limits.read_timeout": c.Server.Limits.ReadTimeout,
+		"server.limits.write_timeout": c.Server.Limits.WriteTimeout, "server.limits.idle_timeout": c.Server.Limits.IdleTimeout,
+		"server.limits.max_header_bytes": c.Server.Limits.MaxHeaderBytes, "server.limits.max_body_size": c.Server.Limits.MaxBodySize,
+	} {
+		if value < 0 {
+			add("%s: must not be negative", key)
+		}
+	}
+	if _, err := ipfilter.New(c.Server.AllowedCIDRs, c.Server.DeniedCIDRs, c.Server.TrustProxy); err != nil {
+		add("server.allowed_cidrs/denied_cidrs: %v", err)
+	}
+	switch strings.ToUpper(c.Server.Headers.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		add("server.headers.frame_options: %q must be DENY, SAMEORIGIN or empty", c.Server.Headers.FrameOptions)
+	}
+	if c.Server.Headers.HSTSMaxAge < 0 {
+		add("server.headers.hsts_max_age: must not be negative")
+	}
+	switch c.Server.Sessions.Store {
+	case "", SessionStoreMemory:
+	case SessionStoreRedis:
+		if _, _, err := net.SplitHostPort(c.Server.Sessions.Redis.Address); err != nil {
+			add("server.sessions.redis.address: %q is not a host:port address", c.Server.Sessions.Redis.Address)
+		}
+	default:
+		add("server.sessions.store: %q must be memory or redis", c.Server.Sessions.Store)
+	}
+	switch c.Server.TrailingSlash {
+	case "", TrailingSlashStrip, TrailingSlashAdd, TrailingSlashKeep:
+	default:
+		add("server.trailing_slash: %q must be strip, add or keep", c.Server.TrailingSlash)
+	}
+	for key, landing := range map[string]string{
+		"server.landing.admin": c.Server.Landing.Admin, "server.landing.editor": c.Server.Landing.Editor, "server.landing.viewer": c.Server.Landing.Viewer,
+	} {
+		if landing != "" && (!strings.HasPrefix(landing, "/") || strings.HasPrefix(landing, "//") || strings.Contains(landing, "\\")) {
+			add("%s: %q must be a path on this wiki, starting with /", key, landing)
+		}
+	}
+
+	// Wiki storage
+	if c.Wiki.RootDir == "" {
+		add("wiki.root_dir: is required")
+	} else if err := checkWritableDir(c.Wiki.RootDir); err != nil {
+		add("wiki.root_dir: %v", err)
+	}
+	docs := c.Wiki.DocumentsDir
+	switch {
+	case docs == "":
+		add("wiki.documents_dir: is required")
+	case filepath.IsAbs(docs) || !filepath.IsLocal(docs):
+		add("wiki.documents_dir: %q must be a directory name inside wiki.root_dir", docs)
+	case c.Wiki.RootDir != "":
+		if err := checkWritableDir(filepath.Join(c.Wiki.RootDir, docs)); err != nil {
+			add("wiki.documents_dir: %v", err)
+		}
+	}
+
+	if home := c.Wiki.HomePage; home != "" && (filepath.IsAbs(home) || !filepath.IsLocal(home)) {
+		add("wiki.home_page: %q must be a path inside wiki.root_dir", home)
+	}
+
+	// Data directories must not overlap the documents, the homepage or each other
+	inUse := map[string]string{
+		strings.ToLower(filepath.Clean(docs)): "wiki.documents_dir",
+		strings.ToLower(strings.Split(filepath.ToSlash(filepath.Clean(c.Wiki.HomePage)), "/")[0]): "wiki.home_page",
+	}
+	for _, dir := range []struct{ key, name, defaultName string }{
+		{"wiki.versions_dir", c.Wiki.VersionsDir, DefaultVersionsDir},
+		{"wiki.comments_dir", c.Wiki.CommentsDir, DefaultCommentsDir},
+		{"wiki.trash_dir", c.Wiki.TrashDir, DefaultTrashDir},
+	} {
+		name := dir.name
+		if name == "" {
+			name = dir.defaultName
+		}
+		if filepath.IsAbs(name) || !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+			add("%s: %q must be a directory name inside wiki.root_dir", dir.key, name)
+			continue
+		}
+		// Case-insensitive file systems would see these as the same directory
+		if other, ok := inUse[strings.ToLower(name)]; ok {
+			add("%s: %q is already used by %s", dir.key, name, other)
+			continue
+		}
+		inUse[strings.ToLower(name)] = dir.key
+	}
+
+	// Wiki settings
+	if _, err := time.LoadLocation(c.Wiki.Timezone); err != nil {
+		add("wiki.timezone: unknown time zone %q", c.Wiki.Timezone)
+	}
+	if c.Wiki.MaxVersions < 0 {
+		add("wiki.max_versions: must not be negative")
+	}
+	if c.Wiki.MaxUploadSize <= 0 {
+		add("wiki.max_upload_size: must be a positive number of MB")
+	}
+	if c.Wiki.MaxDocumentSize < 0 {
+		add("wiki.max_document_size: must not be negative")
+	}
+	if c.Wiki.DocumentSizeWarning < 0 {
+		add("wiki.document_size_warning: must not be negative")
+	} else if c.Wiki.MaxDocumentSize > 0 && c.Wiki.DocumentSizeWarning >= c.Wiki.MaxDocumentSize {
+		add("wiki.document_size_warning: must be below wiki.max_document_size")
+	}
+	if c.Wiki.RenderCacheSize < 0 {
+		add("wiki.render_cache_size: must not be negative")
+	}
+	if c.Wiki.RelatedDocuments < 0 {
+		add("wiki.related_documents: must not be negative")
+	}
+	if c.Wiki.ReadingSpeed < 0 {
+		add("wiki.reading_speed: must not be negative")
+	}
+	for _, reserved := range c.Wiki.ReservedPaths {
+		if p := strings.Trim(reserved, "/"); p == "" || slices.Contains(strings.Split(p, "/"), "..") {
+			add("wiki.reserved_paths: %q is not a path below the documents directory", reserved)
+		}
+	}
+	for _, group := range c.Wiki.PublishGroups {
+		if strings.TrimSpace(group) == "" {
+			add("wiki.publish_groups: group names must not be empty")
+		}
+	}
+	if c.Wiki.IndexFile != "" && !isIndexFileName(c.Wiki.IndexFile) {
+		add("wiki.index_file: %q is not a markdown file name", c.Wiki.IndexFile)
+	}
+	for _, name := range c.Wiki.IndexFallbacks {
+		if !isIndexFileName(name) {
+			add("wiki.index_fallbacks: %q is not a markdown file name", name)
+		}
+	}
+	if c.Wiki.Trash.RetentionDays < 0 {
+		add("wiki.trash.retention_days: must not be negative")
+	}
+	if c.Wiki.Review.IntervalDays <= 0 {
+		add("wiki.review.interval_days: must be a positive number of days")
+	}
+	if c.Wiki.Search.ContextLength < 0 {
+		add("wiki.search.context_length: must not be negative")
+	}
+	if c.Wiki.Search.MaxDistance < 0 {
+		add("wiki.search.max_distance: must not be negative")
+	}
+	if c.Wiki.Uploads.MaxFileSize < 0 {
+		add("wiki.uploads.max_file_size: must not be negative")
+	}
+	if c.Wiki.Uploads.MaxDocumentStorage < 0 {
+		add("wiki.uploads.max_document_storage: must not be negative")
+	}
+	for _, t := range c.Wiki.Uploads.AllowedTypes {
+		if !IsSupportedUploadType(t) {
+			add("wiki.uploads.allowed_types: %q is not a supported MIME type or extension", t)
+		}
+	}
+	switch c.Wiki.Uploads.Scan.Provider {
+	case "":
+	case "clamd":
+		if c.Wiki.Uploads.Scan.Address == "" {
+			add("wiki.uploads.scan.address: required for the clamd provider")
+		}
+	default:
+		add("wiki.uploads.scan.provider: %q must be empty or clamd", c.Wiki.Uploads.Scan.Provider)
+	}
+	if c.Wiki.Uploads.Scan.TimeoutSeconds < 0 {
+		add("wiki.uploads.scan.timeout_seconds: must not be negative")
+	}
+	if c.Wiki.Suggestions.MaxPerHour < 0 {
+		add("wiki.suggestions.max_per_hour: must not be negative")
+	}
+	switch c.Wiki.Search.Backend {
+	case "", SearchBackendScan, SearchBackendMemory, SearchBackendDisk:
+	default:
+		add("wiki.search.backend: %q must be scan, memory or disk", c.Wiki.Search.Backend)
+	}
+	switch c.Wiki.WikiLinkResolution {
+	case "", "nearest", "shortest", "first":
+	default:
+		add("wiki.wikilink_resolution: %q must be nearest, shortest or first", c.Wiki.WikiLinkResolution)
+	}
+	if role := c.Wiki.Comments.MinRole; role != "" && !validRole(role) {
+		add("wiki.comments.min_role: %q is not a known role (admin, editor, viewer)", role)
+	}
+	switch c.Wiki.Comments.Anonymous {
+	case "", AnonymousCommentsOff, AnonymousCommentsAnonymous, AnonymousCommentsName, AnonymousCommentsNameEmail:
+	default:
+		add("wiki.comments.anonymous: %q must be off, anonymous, name or name_email", c.Wiki.Comments.Anonymous)
+	}
+	challengeSettings := c.Wiki.Comments.Challenge
+	if _, err := challenge.New(challengeSettings.Provider, challengeSettings.SecretKey, challengeSettings.PowDifficulty); err != nil {
+		add("wiki.comments.challenge.provider: %v", err)
+	} else {
+		switch strings.ToLower(strings.TrimSpace(challengeSettings.Provider)) {
+		case challenge.ProviderHCaptcha, challenge.ProviderTurnstile:
+			if challengeSettings.SiteKey == "" || challengeSettings.SecretKey == "" {
+				add("wiki.comments.challenge: site_key and secret_key are required for %s", challengeSettings.Provider)
+			}
+		}
+	}
+
+	// Users
+	if len(c.Users) == 0 {
+		add("users: at least one user is required")
+	}
+	seen := make(map[string]bool, len(c.Users))
+	admins := 0
+	for i, user := range c.Users {
+		name := user.Username
+		if name == "" {
+			add("users[%d].username: is required", i)
+			name = fmt.Sprintf("users[%d]", i)
+		} else if seen[name] {
+			add("users: duplicate username %q", name)
+		}
+		seen[name] = true
+		if !validRole(user.Role) {
+			add("users.%s.role: %q is not a known role (admin, editor, viewer)", name, user.Role)
+		}
+		if user.Role == RoleAdmin {
+			admins++
+		}
+		if !crypto.IsPasswordHash(user.Password) {
+			add("users.%s.password: is not a bcrypt hash", name)
+		}
+	}
+	if len(c.Users) > 0 && admins == 0 {
+		add("users: at least one admin is required")
+	}
+
+	// Access rules
+	for i, rule := range c.AccessRules {
+		if strings.TrimSpace(rule.Pattern) == "" {
+			add("access_rules[%d].pattern: is required", i)
+		}
+		switch rule.Access {
+		case "public", "private", "restricted":
+		default:
+			add("access_rules[%d].access: %q must be public, private or restricted", i, rule.Access)
+		}
+	}
+
+	// Security
+	if c.Security.PasswordStrength < bcrypt.MinCost || c.Security.PasswordStrength > bcrypt.MaxCost {
+		add("security.passwordstrength: %d must be between %d and %d", c.Security.PasswordStrength, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	switch c.Security.PasswordAlgorithm {
+	case "", crypto.AlgorithmBcrypt:
+	case crypto.AlgorithmArgon2id:
+		if a := c.Security.Argon2; a.MemoryKiB < 8*uint32(a.Parallelism) || a.Iterations == 0 || a.Parallelism == 0 {
+			add("security.argon2: iterations and parallelism must be positive and memory_kib at least 8 per thread")
+		}
+	default:
+		add("security.password_algorithm: %q must be bcrypt or argon2id", c.Security.PasswordAlgorithm)
+	}
+	if ban := c.Security.LoginBan; ban.Enabled && (ban.MaxFailures <= 0 || ban.WindowSeconds <= 0 || ban.InitialBanSeconds <= 0 || ban.MaxBanSeconds < ban.InitialBanSeconds) {
+		add("security.login_ban: max_failures, window_seconds and initial_ban_seconds must be positive and max_ban_seconds at least initial_ban_seconds")
+	}
+	switch c.Security.LoginErrors {
+	case "", LoginErrorsGeneric, LoginErrorsDetailed:
+	default:
+		add("security.login_errors: %q must be generic or detailed", c.Security.LoginErrors)
+	}
+	switch c.Security.SessionBinding {
+	case "", SessionBindingNone, SessionBindingUserAgent, SessionBindingUserAgentIP:
+	default:
+		add("security.session_binding: %q must be none, user_agent or user_agent_ip", c.Security.SessionBinding)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validRole reports whether role is one of the roles RequireRole knows about
+func validRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleEditor, RoleViewer:
+		return true
+	}
+	return false
+}
+
+// checkWritableDir reports an error unless path is a writable directory, or
+// doesn't exist yet but can be created under its nearest existing parent
+func checkWritableDir(path string) error {
+	dir := path
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot access %s: %v", dir, errors.Unwrap(err))
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("cannot access %s", path)
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// isIndexFileName reports whether name can hold document content: a markdown
+// file directly in the document's directory
+func isIndexFileName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".md") && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/crypto"
+)
+
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	hash, err := crypto.HashPassword("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{}
+	c.Server.Port = 8080
+	c.Wiki.RootDir = t.TempDir()
+	c.Wiki.DocumentsDir = "documents"
+	c.Wiki.Timezone = "UTC"
+	c.Wiki.MaxUploadSize = 10
+	c.Wiki.Review.IntervalDays = 180
+	c.Security.PasswordStrength = 10
+	c.Users = []User{{Username: "admin", Password: hash, Role: RoleAdmin}}
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string // Substrings expected in the error; none means valid
+	}{
+		{
+			name:   "Valid configuration",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "Reserved path outside the documents",
+			modify: func(c *Config) { c.Wiki.ReservedPaths = []string{"api", "../etc", "/"} },
+			want:   []string{`wiki.reserved_paths: "../etc"`, `wiki.reserved_paths: "/"`},
+		},
+		{
+			name: "Negative request limits",
+			modify: func(c *Config) {
+				c.Server.Limits.ReadTimeout = -1
+				c.Server.Limits.MaxBodySize = -1
+			},
+			want: []string{"server.limits.read_timeout", "server.limits.max_body_size"},
+		},
+		{
+			name:   "Empty publish group",
+			modify: func(c *Config) { c.Wiki.PublishGroups = []string{"docs", " "} },
+			want:   []string{"wiki.publish_groups"},
+		},
+		{
+			name: "Document size warning above the limit",
+			modify: func(c *Config) {
+				c.Wiki.MaxDocumentSize = 512
+				c.Wiki.DocumentSizeWarning = 1024
+			},
+			want: []string{"wiki.document_size_warning"},
+		},
+		{
+			name: "Index files that aren't markdown files",
+			modify: func(c *Config) {
+				c.Wiki.IndexFile = "index.html"
+				c.Wiki.IndexFallbacks = []string{"README.md", "docs/index.md"}
+			},
+			want: []string{`wiki.index_file: "index.html"`, `wiki.index_fallbacks: "docs/index.md"`},
+		},
+		{
+			name:   "Invalid port",
+			modify: func(c *Config) { c.Server.Port = 0 },
+			want:   []string{"server.port"},
+		},
+		{
+			name:   "Relative base URL",
+			modify: func(c *Config) { c.Server.BaseURL = "wiki.example.com" },
+			want:   []string{`server.base_url: "wiki.example.com"`},
+		},
+		{
+			name: "Static TLS without certificate",
+			modify: func(c *Config) {
+				c.Server.TLS.Mode = TLSModeStatic
+			},
+			want: []string{"server.tls: cert_file and key_file are required"},
+		},
+		{
+			name: "ACME with a wildcard host",
+			modify: func(c *Config) {
+				c.Server.TLS.Mode = TLSModeACME
+				c.Server.TLS.ACME.Hosts = []string{"*.example.com"}
+				c.Server.TLS.RedirectHTTP = "80"
+			},
+			want: []string{`server.tls.acme.hosts: "*.example.com"`, "server.tls.redirect_http"},
+		},
+		{
+			name: "Redirect without TLS",
+			modify: func(c *Config) {
+				c.Server.TLS.RedirectHTTP = ":80"
+			},
+			want: []string{"server.tls.redirect_http: set while server.tls.mode is empty"},
+		},
+		{
+			name: "Unknown role and duplicate user",
+			modify: func(c *Config) {
+				c.Users = append(c.Users, User{Username: "admin", Password: c.Users[0].Password, Role: "owner"})
+			},
+			want: []string{`duplicate username "admin"`, `users.admin.role: "owner"`},
+		},
+		{
+			name: "Plain text password",
+			modify: func(c *Config) {
+				c.Users[0].Password = "admin"
+			},
+			want: []string{"users.admin.password: is not a bcrypt hash"},
+		},
+		{
+			name: "No admin",
+			modify: func(c *Config) {
+				c.Users[0].Role = RoleEditor
+			},
+			want: []string{"at least one admin"},
+		},
+		{
+			name: "Documents dir outside root",
+			modify: func(c *Config) {
+				c.Wiki.DocumentsDir = "../documents"
+			},
+			want: []string{"wiki.documents_dir"},
+		},
+		{
+			name: "Homepage outside root",
+			modify: func(c *Config) {
+				c.Wiki.HomePage = "../home"
+			},
+			want: []string{"wiki.home_page"},
+		},
+		{
+			name: "Data directories overlapping",
+			modify: func(c *Config) {
+				c.Wiki.VersionsDir = "Documents"
+				c.Wiki.CommentsDir = "../comments"
+				c.Wiki.TrashDir = "versions"
+			},
+			want: []string{
+				`wiki.versions_dir: "Documents" is already used by wiki.documents_dir`,
+				`wiki.comments_dir: "../comments" must be a directory name`,
+			},
+		},
+		{
+			name: "Data directory in the homepage path",
+			modify: func(c *Config) {
+				c.Wiki.HomePage = "pages/home"
+				c.Wiki.TrashDir = "pages"
+			},
+			want: []string{`wiki.trash_dir: "pages" is already used by wiki.home_page`},
+		},
+		{
+			name: "Root dir is a file",
+			modify: func(c *Config) {
+				c.Wiki.RootDir = filepath.Join("validate_test.go", "data")
+			},
+			want: []string{"wiki.root_dir: cannot access"},
+		},
+		{
+			name: "Unsupported upload type",
+			modify: func(c *Config) {
+				c.Wiki.Uploads.AllowedTypes = []string{"image/*", "application/x-msdownload"}
+			},
+			want: []string{`wiki.uploads.allowed_types: "application/x-msdownload"`},
+		},
+		{
+			name: "Scanner without an address",
+			modify: func(c *Config) {
+				c.Wiki.Uploads.Scan.Provider = "clamd"
+				c.Wiki.Uploads.Scan.Address = ""
+			},
+			want: []string{"wiki.uploads.scan.address"},
+		},
+		{
+			name:   "Unknown password algorithm",
+			modify: func(c *Config) { c.Security.PasswordAlgorithm = "md5" },
+			want:   []string{`security.password_algorithm: "md5"`},
+		},
+		{
+			name: "Argon2id without memory",
+			modify: func(c *Config) {
+				c.Security.PasswordAlgorithm = "argon2id"
+				c.Security.Argon2.Iterations = 3
+				c.Security.Argon2.Parallelism = 2
+			},
+			want: []string{"security.argon2"},
+		},
+		{
+			name:   "Unknown login error style",
+			modify: func(c *Config) { c.Security.LoginErrors = "verbose" },
+			want:   []string{`security.login_errors: "verbose"`},
+		},
+		{
+			name:   "Unknown session binding",
+			modify: func(c *Config) { c.Security.SessionBinding = "ip" },
+			want:   []string{`security.session_binding: "ip"`},
+		},
+		{
+			name:   "Unknown trailing slash style",
+			modify: func(c *Config) { c.Server.TrailingSlash = "remove" },
+			want:   []string{`server.trailing_slash: "remove"`},
+		},
+		{
+			name:   "Unknown anonymous comment mode",
+			modify: func(c *Config) { c.Wiki.Comments.Anonymous = "guest" },
+			want:   []string{`wiki.comments.anonymous: "guest"`},
+		},
+		{
+			name: "Several problems are combined",
+			modify: func(c *Config) {
+				c.Server.LogLevel = "verbose"
+				c.Server.DeniedCIDRs = []string{"not-an-ip"}
+				c.Wiki.Timezone = "Mars/Olympus"
+			},
+			want: []string{"server.log_level", "server.allowed_cidrs/denied_cidrs", "wiki.timezone"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig(t)
+			tt.modify(c)
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected errors containing %q, got none", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -1,9 +1,51 @@
 package crypto
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Password hashing algorithms. Hashes carry their algorithm and parameters, so
+// any stored hash can be checked whatever the current settings are.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// argon2idPrefix starts argon2id hashes, which are stored in the PHC string
+// format: $argon2id$v=19$m=<KiB>,t=<iterations>,p=<threads>$<salt>$<key>
+const argon2idPrefix = "$argon2id$"
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// HashParams selects how new passwords are hashed
+type HashParams struct {
+	Algorithm string // AlgorithmBcrypt when empty
+	Cost      int    // bcrypt cost
+
+	// argon2id parameters
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// argon2Params are the parameters an argon2id hash was made with
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt, key   []byte
+}
+
 // HashPassword creates a bcrypt hash of the password
 func HashPassword(password string, passwordstrength int) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), passwordstrength)
@@ -13,8 +55,96 @@ func HashPassword(password string, passwordstrength int) (string, error) {
 	return string(bytes), nil
 }
 
-// CheckPasswordHash compares a bcrypt hashed password with its possible plaintext equivalent
+// HashPasswordWith hashes the password with the algorithm and parameters of params
+func HashPasswordWith(password string, params HashParams) (string, error) {
+	switch params.Algorithm {
+	case "", AlgorithmBcrypt:
+		return HashPassword(password, params.Cost)
+	case AlgorithmArgon2id:
+		if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+			return "", fmt.Errorf("argon2id memory, iterations and parallelism must be positive")
+		}
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+		return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+			params.Memory, params.Iterations, params.Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("unknown password hashing algorithm %q", params.Algorithm)
+	}
+}
+
+// CheckPasswordHash compares a bcrypt or argon2id hashed password with its
+// possible plaintext equivalent
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		p, err := parseArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+		return subtle.ConstantTimeCompare(key, p.key) == 1
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// IsPasswordHash reports whether hash is a well-formed bcrypt or argon2id hash
+func IsPasswordHash(hash string) bool {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		_, err := parseArgon2id(hash)
+		return err == nil
+	}
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other
+// parameters than params, so that it should be replaced the next time the
+// password is known
+func NeedsRehash(hash string, params HashParams) bool {
+	switch params.Algorithm {
+	case "", AlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != params.Cost
+	case AlgorithmArgon2id:
+		if !strings.HasPrefix(hash, argon2idPrefix) {
+			return true
+		}
+		p, err := parseArgon2id(hash)
+		return err != nil || p.memory != params.Memory || p.iterations != params.Iterations || p.parallelism != params.Parallelism
+	default:
+		// Hashing with an unknown algorithm fails; keep what works
+		return false
+	}
+}
+
+// parseArgon2id reads an argon2id hash in the PHC string format
+func parseArgon2id(hash string) (argon2Params, error) {
+	var p argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, fmt.Errorf("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+	if p.memory == 0 || p.iterations == 0 || p.parallelism == 0 {
+		return p, fmt.Errorf("argon2id parameters must be positive")
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return p, fmt.Errorf("malformed argon2id key")
+	}
+	return p, nil
+}
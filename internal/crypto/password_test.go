@@ -0,0 +1,74 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordHashing(t *testing.T) {
+	bcrypt4 := HashParams{Algorithm: AlgorithmBcrypt, Cost: 4}
+	bcrypt5 := HashParams{Algorithm: AlgorithmBcrypt, Cost: 5}
+	argon := HashParams{Algorithm: AlgorithmArgon2id, Memory: 64, Iterations: 1, Parallelism: 1}
+	argonMore := HashParams{Algorithm: AlgorithmArgon2id, Memory: 128, Iterations: 1, Parallelism: 1}
+
+	tests := []struct {
+		name       string
+		params     HashParams
+		prefix     string
+		rehashWith []HashParams // Params the hash is outdated for
+		keepWith   []HashParams // Params the hash is current for
+	}{
+		{"bcrypt", bcrypt4, "$2a$04$", []HashParams{bcrypt5, argon}, []HashParams{bcrypt4, {Cost: 4}}},
+		{"argon2id", argon, "$argon2id$v=19$m=64,t=1,p=1$", []HashParams{bcrypt4, argonMore}, []HashParams{argon}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := HashPasswordWith("secret", tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash = %q, want prefix %q", hash, tt.prefix)
+			}
+			if !IsPasswordHash(hash) {
+				t.Errorf("IsPasswordHash(%q) = false", hash)
+			}
+			if !CheckPasswordHash("secret", hash) {
+				t.Error("right password rejected")
+			}
+			if CheckPasswordHash("Secret", hash) {
+				t.Error("wrong password accepted")
+			}
+			for _, params := range tt.rehashWith {
+				if !NeedsRehash(hash, params) {
+					t.Errorf("NeedsRehash(%+v) = false", params)
+				}
+			}
+			for _, params := range tt.keepWith {
+				if NeedsRehash(hash, params) {
+					t.Errorf("NeedsRehash(%+v) = true", params)
+				}
+			}
+		})
+	}
+}
+
+func TestIsPasswordHash(t *testing.T) {
+	tests := []struct {
+		hash string
+		want bool
+	}{
+		{"secret", false},
+		{"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$", false},
+		{"$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5", false},
+		{"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5", false},
+		{"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hash, func(t *testing.T) {
+			if got := IsPasswordHash(tt.hash); got != tt.want {
+				t.Errorf("IsPasswordHash(%q) = %t, want %t", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package csp provides per-request Content-Security-Policy nonces for inline scripts.
+package csp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+)
+
+// Placeholder is replaced with the request's nonce in custom policies
+const Placeholder = "{nonce}"
+
+type contextKey struct{}
+
+// NewNonce returns a random base64url nonce suitable for a 'nonce-...' source
+func NewNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// WithNonce returns a copy of ctx carrying the nonce
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, contextKey{}, nonce)
+}
+
+// Nonce returns the nonce attached to the request, or "" if there is none
+func Nonce(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	nonce, _ := r.Context().Value(contextKey{}).(string)
+	return nonce
+}
@@ -0,0 +1,227 @@
+// Package diff compares texts line by line and formats the differences as
+// unified diffs, the format of diff -u and git diff.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is what an Edit does with its line
+type Op int
+
+const (
+	Equal  Op = iota // The line is in both texts
+	Delete           // The line is only in the old text
+	Insert           // The line is only in the new text
+)
+
+// Edit is one line of the comparison of two texts
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// maxEdits bounds the work and memory of a comparison. Texts further apart
+// are still compared, but the rest of them is shown as replaced wholesale.
+const maxEdits = 2000
+
+// Lines returns the shortest list of edits turning the lines a into the lines
+// b, using Myers' algorithm
+func Lines(a, b []string) []Edit {
+	// Lines shared at the start and end need no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	return edits
+}
+
+// myers finds the edits between a and b that have no common first or last line
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replace(a, b)
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k = x-y. trace[d]
+	// keeps diagonals -d..d of v as they were before step d, the only ones
+	// step d reads, to walk back the path.
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return replace(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset] // Down: insert from b
+			} else {
+				x = v[k-1+offset] + 1 // Right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d)
+			}
+		}
+	}
+	return replace(a, b)
+}
+
+// backtrack walks the path of a search that reached the end after d edits
+// from the end back to the start
+func backtrack(a, b []string, trace [][]int, d int) []Edit {
+	var reversed []Edit
+	x, y := len(a), len(b)
+	for ; d > 0; d-- {
+		v := trace[d] // v[k+d] is diagonal k
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			reversed = append(reversed, Edit{Equal, a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, Edit{Insert, b[y]})
+		} else {
+			x--
+			reversed = append(reversed, Edit{Delete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		reversed = append(reversed, Edit{Equal, a[x]})
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// replace deletes every line of a and inserts every line of b
+func replace(a, b []string) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, Edit{Delete, line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Insert, line})
+	}
+	return edits
+}
+
+// SplitLines splits text into lines without their line endings. A final line
+// ending does not start another line.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified formats the edits between two texts, named aName and bName, as a
+// unified diff with context unchanged lines around each change. Identical
+// texts have an empty diff.
+func Unified(aName, bName string, edits []Edit, context int) string {
+	var out strings.Builder
+	// Line numbers in a and b of edits[i], 1-based
+	aLine, bLine := 1, 1
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			aLine, bLine = aLine+1, bLine+1
+			i++
+			continue
+		}
+
+		// A hunk runs from context lines before this change to context lines
+		// after the last change that is no more than 2*context lines later
+		start := max(i-context, 0)
+		for j := start; j < i; j++ {
+			aLine, bLine = aLine-1, bLine-1
+		}
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Op != Equal {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(edits))
+
+		aCount, bCount := 0, 0
+		for _, e := range edits[start:end] {
+			if e.Op != Insert {
+				aCount++
+			}
+			if e.Op != Delete {
+				bCount++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case Equal:
+				out.WriteString(" ")
+			case Delete:
+				out.WriteString("-")
+			case Insert:
+				out.WriteString("+")
+			}
+			out.WriteString(e.Text)
+			out.WriteString("\n")
+		}
+
+		aLine += aCount
+		bLine += bCount
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk's lines. An empty range
+// starts at the line before it, as diff -u writes it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string // One character per edit: = - +
+	}{
+		{"identical", "a\nb\nc", "a\nb\nc", "==="},
+		{"empty old", "", "a\nb", "++"},
+		{"empty new", "a\nb", "", "--"},
+		{"changed line", "a\nb\nc", "a\nx\nc", "=-+="},
+		{"insert and delete", "a\nb\nc\nd", "b\nc\nx\nd", "-==+="},
+		{"moved block", "a\nb\nc\nd", "c\nd\na\nb", "--==++"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			for _, e := range Lines(SplitLines(tt.a), SplitLines(tt.b)) {
+				got.WriteByte("=-+"[e.Op])
+			}
+			if got.String() != tt.want {
+				t.Errorf("edits = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "# Title\n1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	tests := []struct {
+		name    string
+		b       string
+		context int
+		want    string
+	}{
+		{"identical", a, 3, ""},
+		{"one change", "# Title\n1\n2\n3\nfour\n5\n6\n7\n8\n9\n", 1,
+			"--- old\n+++ new\n@@ -4,3 +4,3 @@\n 3\n-4\n+four\n 5\n"},
+		{"separate hunks", "# Heading\n1\n2\n3\n4\n5\n6\n7\n8\nnine\n", 1,
+			"--- old\n+++ new\n@@ -1,2 +1,2 @@\n-# Title\n+# Heading\n 1\n@@ -9,2 +9,2 @@\n 8\n-9\n+nine\n"},
+		{"close changes share a hunk", "# Title\n1\nx\n3\n4\ny\n6\n7\n8\n9\n", 1,
+			"--- old\n+++ new\n@@ -2,6 +2,6 @@\n 1\n-2\n+x\n 3\n 4\n-5\n+y\n 6\n"},
+		{"pure insertion", "# Title\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", 0,
+			"--- old\n+++ new\n@@ -10,0 +11 @@\n+10\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", Lines(SplitLines(a), SplitLines(tt.b)), tt.context); got != tt.want {
+				t.Errorf("Unified =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesReconstruct(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	randomLines := func() []string {
+		lines := make([]string, r.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.IntN(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		var gotA, gotB []string
+		for _, e := range Lines(a, b) {
+			if e.Op != Insert {
+				gotA = append(gotA, e.Text)
+			}
+			if e.Op != Delete {
+				gotB = append(gotB, e.Text)
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("edits of %q -> %q don't reproduce them: %q, %q", a, b, gotA, gotB)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package doclock serializes read-modify-write cycles on document files and
+// writes them atomically.
+package doclock
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+type entry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+var (
+	mu    sync.Mutex
+	locks = make(map[string]*entry)
+)
+
+// Lock acquires the edit lock for key, usually a document's file path, and
+// returns the function that releases it. Locks are held in-process only.
+func Lock(key string) (unlock func()) {
+	key = filepath.Clean(key)
+
+	mu.Lock()
+	e, ok := locks[key]
+	if !ok {
+		e = &entry{}
+		locks[key] = e
+	}
+	e.refs++
+	mu.Unlock()
+
+	e.mu.Lock()
+	return func() {
+		e.mu.Unlock()
+		mu.Lock()
+		e.refs--
+		if e.refs == 0 {
+			delete(locks, key)
+		}
+		mu.Unlock()
+	}
+}
+
+// LockAll acquires the edit locks for several keys, such as the source and
+// target of a move. Keys are locked in sorted order so that two callers
+// locking the same keys can't deadlock.
+func LockAll(keys ...string) (unlock func()) {
+	sorted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, filepath.Clean(key))
+	}
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	unlocks := make([]func(), 0, len(sorted))
+	for _, key := range sorted {
+		unlocks = append(unlocks, Lock(key))
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
+
+// WriteFile writes data to path the way os.WriteFile does, but through a
+// temporary file in the same directory that then replaces path. Readers see
+// either the old or the new content, never a partly written file. Callers
+// that read the file before writing it should hold its lock.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// Removing fails harmlessly once the file has been renamed
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
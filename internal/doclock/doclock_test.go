@@ -0,0 +1,144 @@
+package doclock
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentWriters hammers one file with locked read-modify-write cycles
+// and unlocked atomic writes while readers check that every read is complete
+func TestConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "document.md")
+	if err := WriteFile(path, []byte("0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 16
+	const rounds = 50
+
+	// Large bodies make a torn write likely to show if writes weren't atomic
+	body := func(n int) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("%08d\n", n)), 4096)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*rounds)
+	stop := make(chan struct{})
+
+	// Readers
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(data) == 1 {
+					continue
+				}
+				first := data[:9]
+				if len(data) != 9*4096 || !bytes.Equal(data, bytes.Repeat(first, 4096)) {
+					errs <- fmt.Errorf("read a partial or mixed file of %d bytes", len(data))
+					return
+				}
+			}
+		}()
+	}
+
+	// Writers: each round increments the counter stored in a second file under
+	// the lock, then rewrites the document with a body derived from it
+	counter := filepath.Join(filepath.Dir(path), "counter")
+	if err := WriteFile(counter, []byte("0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				unlock := Lock(path)
+				raw, err := os.ReadFile(counter)
+				if err != nil {
+					unlock()
+					errs <- err
+					return
+				}
+				n, _ := strconv.Atoi(string(raw))
+				n++
+				if err := WriteFile(counter, []byte(strconv.Itoa(n)), 0644); err != nil {
+					unlock()
+					errs <- err
+					return
+				}
+				if err := WriteFile(path, body(n), 0644); err != nil {
+					unlock()
+					errs <- err
+					return
+				}
+				unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	raw, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw); got != strconv.Itoa(writers*rounds) {
+		t.Errorf("counter = %s, want %d; locked updates were lost", got, writers*rounds)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("directory has %d entries, want 2", len(entries))
+	}
+}
+
+func TestLockAllOrder(t *testing.T) {
+	// Opposite argument orders must not deadlock
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			LockAll("a", "b")()
+		}()
+		go func() {
+			defer wg.Done()
+			LockAll("b", "a", "b")()
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(locks) != 0 {
+		t.Errorf("%d locks left in the table", len(locks))
+	}
+}
@@ -0,0 +1,564 @@
+// Package docx converts a parsed markdown document into a Word (.docx) file.
+//
+// The converter walks a Goldmark AST and emits WordprocessingML using only the
+// standard library. Headings, paragraphs, emphasis, links, lists, block quotes,
+// code, tables and embedded images are mapped onto the built-in Word styles
+// declared in styles.xml so the output can be restyled in Word.
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF decoder for image dimensions
+	_ "image/jpeg" // Register JPEG decoder for image dimensions
+	_ "image/png"  // Register PNG decoder for image dimensions
+	"io"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// ImageResolver returns the bytes of the image referenced by dest. Returning
+// ok=false leaves the image out and renders its alt text instead.
+type ImageResolver func(dest string) (data []byte, ok bool)
+
+// Page geometry used to fit images: 6.5in usable width at 96 DPI
+const (
+	emuPerPixel   = 9525
+	maxImageWidth = 624 // pixels
+)
+
+// Numbering definitions declared in numbering.xml
+const (
+	bulletAbstractNum  = 0
+	decimalAbstractNum = 1
+	bulletNumID        = 1
+)
+
+type converter struct {
+	source  []byte
+	resolve ImageResolver
+
+	body    bytes.Buffer
+	rels    []relationship
+	media   []mediaFile
+	numbers []int // abstract numbering of each ordered list instance, indexed by numID-2
+
+	// Block context
+	listDepth int
+	listNumID int
+	quote     bool
+}
+
+type relationship struct {
+	id       string
+	typ      string
+	target   string
+	external bool
+}
+
+type mediaFile struct {
+	name string
+	data []byte
+}
+
+type runStyle struct {
+	bold, italic, strike, code, link bool
+}
+
+// Write renders the document rooted at doc (parsed from source) as a .docx file
+func Write(w io.Writer, source []byte, doc ast.Node, resolve ImageResolver) error {
+	c := &converter{source: source, resolve: resolve}
+	c.blocks(doc)
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", packageRelsXML},
+		{"word/document.xml", c.documentXML()},
+		{"word/styles.xml", stylesXML},
+		{"word/numbering.xml", c.numberingXML()},
+		{"word/_rels/document.xml.rels", c.documentRelsXML()},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+	for _, m := range c.media {
+		fw, err := zw.Create("word/media/" + m.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(m.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// blocks renders every block-level child of n
+func (c *converter) blocks(n ast.Node) {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		c.block(child)
+	}
+}
+
+func (c *converter) block(n ast.Node) {
+	switch node := n.(type) {
+	case *ast.Heading:
+		c.paragraph(fmt.Sprintf("Heading%d", node.Level), node)
+
+	case *ast.Paragraph, *ast.TextBlock:
+		c.paragraph("", node)
+
+	case *ast.Blockquote:
+		prev := c.quote
+		c.quote = true
+		c.blocks(node)
+		c.quote = prev
+
+	case *ast.List:
+		c.list(node)
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		c.codeBlock(node)
+
+	case *ast.ThematicBreak:
+		c.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+
+	case *east.Table:
+		c.table(node)
+
+	case *east.DefinitionTerm:
+		c.paragraph("DefinitionTerm", node)
+
+	case *east.DefinitionDescription:
+		c.blocksWithStyle(node, "DefinitionDescription")
+
+	case *ast.HTMLBlock:
+		// Raw HTML has no Word equivalent and is skipped
+
+	default:
+		c.blocks(node)
+	}
+}
+
+// blocksWithStyle renders the children of n, styling plain paragraphs with style
+func (c *converter) blocksWithStyle(n ast.Node, style string) {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child.(type) {
+		case *ast.Paragraph, *ast.TextBlock:
+			c.paragraph(style, child)
+		default:
+			c.block(child)
+		}
+	}
+}
+
+// paragraph writes a single w:p containing the inline children of n
+func (c *converter) paragraph(style string, n ast.Node) {
+	c.body.WriteString("<w:p>")
+	c.paragraphProperties(style)
+	c.inlines(n, runStyle{})
+	c.body.WriteString("</w:p>")
+}
+
+func (c *converter) paragraphProperties(style string) {
+	if style == "" && c.quote {
+		style = "Quote"
+	}
+	if style == "" && c.listDepth > 0 {
+		style = "ListParagraph"
+	}
+	if style == "" && c.listNumID == 0 {
+		return
+	}
+
+	c.body.WriteString("<w:pPr>")
+	if style != "" {
+		fmt.Fprintf(&c.body, `<w:pStyle w:val="%s"/>`, style)
+	}
+	if c.listNumID != 0 {
+		fmt.Fprintf(&c.body, `<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, c.listDepth-1, c.listNumID)
+	}
+	c.body.WriteString("</w:pPr>")
+}
+
+// list renders list items; only the first paragraph of an item carries the bullet
+func (c *converter) list(node *ast.List) {
+	numID := bulletNumID
+	if node.IsOrdered() {
+		c.numbers = append(c.numbers, node.Start)
+		numID = len(c.numbers) + 1
+	}
+
+	c.listDepth++
+	for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+		first := true
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			switch child.(type) {
+			case *ast.Paragraph, *ast.TextBlock:
+				if first {
+					c.listNumID = numID
+					first = false
+				}
+				c.paragraph("", child)
+				c.listNumID = 0
+			default:
+				c.block(child)
+			}
+		}
+	}
+	c.listDepth--
+}
+
+func (c *converter) codeBlock(n ast.Node) {
+	lines := n.Lines()
+	c.body.WriteString(`<w:p><w:pPr><w:pStyle w:val="Code"/></w:pPr>`)
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		line := strings.TrimRight(string(segment.Value(c.source)), "\r\n")
+		if i > 0 {
+			c.body.WriteString("<w:r><w:br/></w:r>")
+		}
+		c.run(line, runStyle{})
+	}
+	c.body.WriteString("</w:p>")
+}
+
+func (c *converter) table(node *east.Table) {
+	c.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr>`)
+	for row := node.FirstChild(); row != nil; row = row.NextSibling() {
+		_, header := row.(*east.TableHeader)
+		c.body.WriteString("<w:tr>")
+		if header {
+			c.body.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			c.body.WriteString("<w:tc><w:p>")
+			if tc, ok := cell.(*east.TableCell); ok {
+				if jc := alignment(tc.Alignment); jc != "" {
+					fmt.Fprintf(&c.body, `<w:pPr><w:jc w:val="%s"/></w:pPr>`, jc)
+				}
+			}
+			c.inlines(cell, runStyle{bold: header})
+			c.body.WriteString("</w:p></w:tc>")
+		}
+		c.body.WriteString("</w:tr>")
+	}
+	c.body.WriteString("</w:tbl>")
+	// Word requires a paragraph between adjacent tables and at the end of a cell
+	c.body.WriteString("<w:p/>")
+}
+
+func alignment(a east.Alignment) string {
+	switch a {
+	case east.AlignLeft:
+		return "left"
+	case east.AlignRight:
+		return "right"
+	case east.AlignCenter:
+		return "center"
+	}
+	return ""
+}
+
+// inlines renders the inline children of n with the given run style
+func (c *converter) inlines(n ast.Node, style runStyle) {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		c.inline(child, style)
+	}
+}
+
+func (c *converter) inline(n ast.Node, style runStyle) {
+	switch node := n.(type) {
+	case *ast.Text:
+		c.run(string(node.Value(c.source)), style)
+		if node.HardLineBreak() {
+			c.body.WriteString("<w:r><w:br/></w:r>")
+		} else if node.SoftLineBreak() {
+			c.run(" ", style)
+		}
+
+	case *ast.String:
+		c.run(string(node.Value), style)
+
+	case *ast.Emphasis:
+		if node.Level >= 2 {
+			style.bold = true
+		} else {
+			style.italic = true
+		}
+		c.inlines(node, style)
+
+	case *east.Strikethrough:
+		style.strike = true
+		c.inlines(node, style)
+
+	case *ast.CodeSpan:
+		style.code = true
+		c.inlines(node, style)
+
+	case *ast.Link:
+		c.hyperlink(string(node.Destination), style, func(style runStyle) { c.inlines(node, style) })
+
+	case *ast.AutoLink:
+		url := string(node.URL(c.source))
+		label := string(node.Label(c.source))
+		c.hyperlink(url, style, func(style runStyle) { c.run(label, style) })
+
+	case *ast.Image:
+		c.image(node, style)
+
+	case *east.TaskCheckBox:
+		if node.IsChecked {
+			c.run("☑ ", style)
+		} else {
+			c.run("☐ ", style)
+		}
+
+	case *ast.RawHTML:
+		// Inline HTML is skipped
+
+	default:
+		c.inlines(node, style)
+	}
+}
+
+// run writes a text run, preserving leading and trailing whitespace
+func (c *converter) run(text string, style runStyle) {
+	if text == "" {
+		return
+	}
+	c.body.WriteString("<w:r>")
+	if style.bold || style.italic || style.strike || style.code || style.link {
+		c.body.WriteString("<w:rPr>")
+		if style.link {
+			c.body.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+		} else if style.code {
+			c.body.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+		}
+		if style.bold {
+			c.body.WriteString("<w:b/>")
+		}
+		if style.italic {
+			c.body.WriteString("<w:i/>")
+		}
+		if style.strike {
+			c.body.WriteString("<w:strike/>")
+		}
+		c.body.WriteString("</w:rPr>")
+	}
+	c.body.WriteString(`<w:t xml:space="preserve">`)
+	xml.EscapeText(&c.body, []byte(text))
+	c.body.WriteString("</w:t></w:r>")
+}
+
+// hyperlink wraps the runs produced by content in an external hyperlink
+func (c *converter) hyperlink(target string, style runStyle, content func(runStyle)) {
+	if target == "" || strings.HasPrefix(target, "#") {
+		content(style)
+		return
+	}
+	id := c.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", target, true)
+	fmt.Fprintf(&c.body, `<w:hyperlink r:id="%s">`, id)
+	style.link = true
+	content(style)
+	c.body.WriteString("</w:hyperlink>")
+}
+
+// image embeds a resolvable PNG, JPEG or GIF scaled to the page width, falling
+// back to the alt text for anything else
+func (c *converter) image(node *ast.Image, style runStyle) {
+	alt := altText(node, c.source)
+	dest := string(node.Destination)
+
+	var data []byte
+	var ok bool
+	if c.resolve != nil {
+		data, ok = c.resolve(dest)
+	}
+	var cfg image.Config
+	var format string
+	var err error
+	if ok {
+		cfg, format, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if !ok || err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		if alt == "" {
+			alt = dest
+		}
+		c.run("["+alt+"]", style)
+		return
+	}
+
+	index := len(c.media) + 1
+	name := fmt.Sprintf("image%d.%s", index, format)
+	c.media = append(c.media, mediaFile{name: name, data: data})
+	id := c.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "media/"+name, false)
+
+	width, height := cfg.Width, cfg.Height
+	if width > maxImageWidth {
+		height = height * maxImageWidth / width
+		width = maxImageWidth
+	}
+	cx, cy := width*emuPerPixel, height*emuPerPixel
+
+	var escapedAlt bytes.Buffer
+	xml.EscapeText(&escapedAlt, []byte(alt))
+	fmt.Fprintf(&c.body, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`+
+		`<wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/>`+
+		`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`+
+		`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, index, index, escapedAlt.String(), index, name, id, cx, cy)
+}
+
+func altText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			b.Write(t.Value(source))
+		case *ast.String:
+			b.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+func (c *converter) addRel(typ, target string, external bool) string {
+	// rId1 and rId2 are reserved for styles and numbering
+	id := fmt.Sprintf("rId%d", len(c.rels)+3)
+	c.rels = append(c.rels, relationship{id: id, typ: typ, target: target, external: external})
+	return id
+}
+
+func (c *converter) documentXML() string {
+	return xml.Header +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing">` +
+		`<w:body>` + c.body.String() +
+		`<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>` +
+		`</w:body></w:document>`
+}
+
+func (c *converter) documentRelsXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	b.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	b.WriteString(`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for _, rel := range c.rels {
+		var target bytes.Buffer
+		xml.EscapeText(&target, []byte(rel.target))
+		mode := ""
+		if rel.external {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&b, `<Relationship Id="%s" Type="%s" Target="%s"%s/>`, rel.id, rel.typ, target.String(), mode)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// numberingXML declares one bullet list and a restartable decimal list per ordered list
+func (c *converter) numberingXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+
+	bullets := []string{"•", "◦", "▪"}
+	fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d">`, bulletAbstractNum)
+	for lvl := 0; lvl < 9; lvl++ {
+		fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+			lvl, bullets[lvl%len(bullets)], 720*(lvl+1))
+	}
+	b.WriteString(`</w:abstractNum>`)
+
+	formats := []string{"decimal", "lowerLetter", "lowerRoman"}
+	fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d">`, decimalAbstractNum)
+	for lvl := 0; lvl < 9; lvl++ {
+		fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%%%d."/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+			lvl, formats[lvl%len(formats)], lvl+1, 720*(lvl+1))
+	}
+	b.WriteString(`</w:abstractNum>`)
+
+	fmt.Fprintf(&b, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/></w:num>`, bulletNumID, bulletAbstractNum)
+	for i, start := range c.numbers {
+		if start < 1 {
+			start = 1
+		}
+		fmt.Fprintf(&b, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, i+2, decimalAbstractNum)
+		for lvl := 0; lvl < 9; lvl++ {
+			fmt.Fprintf(&b, `<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride>`, lvl, start)
+		}
+		b.WriteString(`</w:num>`)
+	}
+
+	b.WriteString(`</w:numbering>`)
+	return b.String()
+}
+
+const contentTypesXML = xml.Header +
+	`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Default Extension="png" ContentType="image/png"/>` +
+	`<Default Extension="jpeg" ContentType="image/jpeg"/>` +
+	`<Default Extension="gif" ContentType="image/gif"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+	`</Types>`
+
+const packageRelsXML = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`</Relationships>`
+
+const stylesXML = xml.Header +
+	`<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="264" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="300" w:after="100"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="60"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="24"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:i/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="60"/><w:ind w:left="720"/><w:contextualSpacing/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="BFBFBF"/></w:pBdr><w:ind w:left="360"/></w:pPr><w:rPr><w:i/><w:color w:val="595959"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/><w:spacing w:after="120" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="DefinitionTerm"><w:name w:val="Definition Term"/><w:basedOn w:val="Normal"/><w:next w:val="DefinitionDescription"/><w:pPr><w:keepNext/><w:spacing w:after="0"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="DefinitionDescription"><w:name w:val="Definition Description"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="720"/></w:pPr></w:style>` +
+	`<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/></w:rPr></w:style>` +
+	`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>` +
+	`<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>` +
+	`<w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`</w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>` +
+	`</w:styles>`
@@ -0,0 +1,107 @@
+// Package events is an in-process publish/subscribe bus for content events.
+//
+// Handlers publish an event once a change is done, and subscribers such as
+// caches, counters and indexes react to it without the handlers knowing about
+// them. Subscribers register at startup and are called synchronously, in the
+// order they subscribed, so the wiki's derived state is up to date by the time
+// the handler responds.
+package events
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// Event is a change to the wiki's content. Document paths in events are
+// relative to the documents directory, without leading or trailing slashes.
+type Event interface {
+	event()
+}
+
+// DocumentCreated is published when a document is created
+type DocumentCreated struct {
+	Path string
+	User string
+}
+
+// DocumentEdited is published when a new revision of a document is saved
+type DocumentEdited struct {
+	Path string
+	User string
+}
+
+// DocumentMoved is published when a document or category is moved or renamed.
+// Old and New are the paths before and after; documents below a category move
+// with it without events of their own.
+type DocumentMoved struct {
+	Old  string
+	New  string
+	User string
+}
+
+// DocumentDeleted is published when a document or category is deleted or
+// moved to the trash
+type DocumentDeleted struct {
+	Path string
+	User string
+}
+
+// CommentPosted is published when a comment is added to a document
+type CommentPosted struct {
+	Path string
+	User string
+}
+
+func (DocumentCreated) event() {}
+func (DocumentEdited) event()  {}
+func (DocumentMoved) event()   {}
+func (DocumentDeleted) event() {}
+func (CommentPosted) event()   {}
+
+// Bus delivers published events to its subscribers. It is safe for concurrent use.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+// NewBus returns a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls fn for every event of type E published on bus
+func Subscribe[E Event](bus *Bus, fn func(E)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.subscribers = append(bus.subscribers, func(e Event) {
+		if e, ok := e.(E); ok {
+			fn(e)
+		}
+	})
+}
+
+// Publish delivers e to the subscribers of its type. A subscriber that panics
+// is logged and doesn't keep the others from seeing the event.
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, fn := range subscribers {
+		deliver(fn, e)
+	}
+}
+
+func deliver(fn func(Event), e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Warning: Subscriber to %T panicked: %v", e, r)
+		}
+	}()
+	fn(e)
+}
+
+// CleanPath returns a document path in the form events carry it
+func CleanPath(docPath string) string {
+	return strings.Trim(strings.ReplaceAll(docPath, "\\", "/"), "/")
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	bus := NewBus()
+	var moved []DocumentMoved
+	var all []Event
+	Subscribe(bus, func(e DocumentMoved) { moved = append(moved, e) })
+	Subscribe(bus, func(e Event) { all = append(all, e) })
+
+	bus.Publish(DocumentEdited{Path: "a", User: "editor"})
+	bus.Publish(DocumentMoved{Old: "a", New: "b", User: "editor"})
+
+	if want := []DocumentMoved{{Old: "a", New: "b", User: "editor"}}; !reflect.DeepEqual(moved, want) {
+		t.Errorf("moved = %+v, want %+v", moved, want)
+	}
+	if len(all) != 2 {
+		t.Errorf("all = %+v, want both events", all)
+	}
+}
+
+func TestPublishRecoversFromPanics(t *testing.T) {
+	bus := NewBus()
+	delivered := false
+	Subscribe(bus, func(e CommentPosted) { panic("broken subscriber") })
+	Subscribe(bus, func(e CommentPosted) { delivered = true })
+
+	bus.Publish(CommentPosted{Path: "a", User: "reader"})
+
+	if !delivered {
+		t.Error("event not delivered after a subscriber panicked")
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/guides/setup/", "guides/setup"},
+		{`guides\setup`, "guides/setup"},
+		{"/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := CleanPath(tt.input); got != tt.want {
+				t.Errorf("CleanPath(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
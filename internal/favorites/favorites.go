@@ -0,0 +1,165 @@
+// Package favorites keeps the documents each user starred for quick access.
+//
+// The favorites of all users are stored in <root>/favorites.json, mapping
+// usernames to document paths in the order they were starred.
+package favorites
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the favorites file inside the wiki root
+const FileName = "favorites.json"
+
+// mu serializes changes to favorites files, which are read and written whole
+var mu sync.Mutex
+
+// Favorite is a starred document
+type Favorite struct {
+	Path  string    `json:"path"` // URL path of the document; "/" is the homepage
+	Added time.Time `json:"added"`
+}
+
+// List holds the favorites of the users of one wiki
+type List struct {
+	rootDir string
+}
+
+// New returns the favorites of the wiki stored in rootDir
+func New(rootDir string) *List {
+	return &List{rootDir: rootDir}
+}
+
+func (l *List) file() string {
+	return filepath.Join(l.rootDir, FileName)
+}
+
+// Get returns the favorites of username, in the order they were added
+func (l *List) Get(username string) ([]Favorite, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	if all[username] == nil {
+		return []Favorite{}, nil
+	}
+	return all[username], nil
+}
+
+// Add stars docPath for username. It reports false when it was already starred.
+func (l *List) Add(username, docPath string) (bool, error) {
+	docPath = normalize(docPath)
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := l.load()
+	if err != nil {
+		return false, err
+	}
+	for _, f := range all[username] {
+		if f.Path == docPath {
+			return false, nil
+		}
+	}
+	all[username] = append(all[username], Favorite{Path: docPath, Added: time.Now().UTC()})
+	return true, l.save(all)
+}
+
+// Remove unstars docPath for username. It reports false when it wasn't starred.
+func (l *List) Remove(username, docPath string) (bool, error) {
+	docPath = normalize(docPath)
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := l.load()
+	if err != nil {
+		return false, err
+	}
+	for i, f := range all[username] {
+		if f.Path == docPath {
+			all[username] = append(all[username][:i], all[username][i+1:]...)
+			if len(all[username]) == 0 {
+				delete(all, username)
+			}
+			return true, l.save(all)
+		}
+	}
+	return false, nil
+}
+
+// Move points the favorites of oldPath and every document below it at their
+// new location under newPath, for every user
+func (l *List) Move(oldPath, newPath string) error {
+	oldPath, newPath = normalize(oldPath), normalize(newPath)
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := l.load()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for username, favorites := range all {
+		moved := make([]Favorite, 0, len(favorites))
+		seen := make(map[string]bool, len(favorites))
+		for _, f := range favorites {
+			switch {
+			case f.Path == oldPath:
+				f.Path = newPath
+				changed = true
+			case oldPath != "/" && strings.HasPrefix(f.Path, oldPath+"/"):
+				f.Path = newPath + strings.TrimPrefix(f.Path, oldPath)
+				changed = true
+			}
+			// A document moved onto one already starred is listed once
+			if !seen[f.Path] {
+				seen[f.Path] = true
+				moved = append(moved, f)
+			}
+		}
+		all[username] = moved
+	}
+	if !changed {
+		return nil
+	}
+	return l.save(all)
+}
+
+func (l *List) load() (map[string][]Favorite, error) {
+	all := make(map[string][]Favorite)
+	data, err := os.ReadFile(l.file())
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (l *List) save(all map[string][]Favorite) error {
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.rootDir, 0755); err != nil {
+		return err
+	}
+	tmp := l.file() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.file())
+}
+
+// normalize returns docPath as a URL path with a leading and no trailing slash
+func normalize(docPath string) string {
+	return "/" + strings.Trim(docPath, "/")
+}
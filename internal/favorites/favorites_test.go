@@ -0,0 +1,81 @@
+package favorites
+
+import (
+	"slices"
+	"testing"
+)
+
+func paths(t *testing.T, l *List, username string) []string {
+	t.Helper()
+	favorites, err := l.Get(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range favorites {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+func TestAddRemove(t *testing.T) {
+	l := New(t.TempDir())
+	for _, p := range []string{"guide", "/guide/", "/", "faq"} {
+		if _, err := l.Add("ed", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if added, _ := l.Add("ed", "faq"); added {
+		t.Error("starring twice reported as added")
+	}
+	if got, want := paths(t, l, "ed"), []string{"/guide", "/", "/faq"}; !slices.Equal(got, want) {
+		t.Errorf("favorites = %v, want %v", got, want)
+	}
+	if got := paths(t, l, "vi"); got != nil {
+		t.Errorf("favorites of another user = %v", got)
+	}
+
+	if removed, err := l.Remove("ed", "/guide"); !removed || err != nil {
+		t.Errorf("Remove() = %t, %v", removed, err)
+	}
+	if removed, _ := l.Remove("ed", "/guide"); removed {
+		t.Error("removing twice reported as removed")
+	}
+	if got, want := paths(t, l, "ed"), []string{"/", "/faq"}; !slices.Equal(got, want) {
+		t.Errorf("favorites = %v, want %v", got, want)
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string
+	}{
+		{"document", "guide/setup", "setup", []string{"/guide", "/setup", "/guides", "/faq"}},
+		{"category", "guide", "docs/guide", []string{"/docs/guide", "/docs/guide/setup", "/guides", "/faq"}},
+		{"onto a favorite", "guides", "faq", []string{"/guide", "/guide/setup", "/faq"}},
+		{"not starred", "other", "elsewhere", []string{"/guide", "/guide/setup", "/guides", "/faq"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(t.TempDir())
+			for _, p := range []string{"guide", "guide/setup", "guides", "faq"} {
+				if _, err := l.Add("ed", p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l.Add("vi", "guide/setup")
+
+			if err := l.Move(tt.from, tt.to); err != nil {
+				t.Fatal(err)
+			}
+			if got := paths(t, l, "ed"); !slices.Equal(got, tt.want) {
+				t.Errorf("favorites = %v, want %v", got, tt.want)
+			}
+			if got := paths(t, l, "vi"); slices.Contains(got, "/"+tt.from) {
+				t.Errorf("favorites of another user not moved: %v", got)
+			}
+		})
+	}
+}
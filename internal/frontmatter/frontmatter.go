@@ -2,6 +2,8 @@ package frontmatter
 
 import (
 	"bytes"
+	"fmt"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -11,9 +13,72 @@ import (
 // This can be expanded with additional fields in the future
 type Metadata struct {
 	Layout string `yaml:"layout,omitempty"`
+	Lang   string `yaml:"lang,omitempty"` // Language of the document; variants live in document.<lang>.md
+	Tags   Tags   `yaml:"tags,omitempty"`
+	// Periodic review: the document is stale after ReviewBy (YYYY-MM-DD)
+	ReviewBy    string `yaml:"reviewBy,omitempty"`
+	ReviewEvery int    `yaml:"reviewEvery,omitempty"` // Days a review postpones ReviewBy; the wiki default when 0
+	ReviewedBy  string `yaml:"reviewedBy,omitempty"`
+	ReviewedAt  string `yaml:"reviewedAt,omitempty"`
+	// Minutes shown as the reading time instead of the estimate from the word count
+	ReadingTime int `yaml:"readingTime,omitempty"`
+	// Path of the document that replaced this one, e.g. after a merge; readers are sent there
+	Redirect string `yaml:"redirect,omitempty"`
+	// Other paths the document is served at, e.g. short names or where it used to be
+	Aliases []string `yaml:"aliases,omitempty"`
+	// Whether the document accepts comments; unset follows wiki.comments.disabled_by_default
+	Comments *bool `yaml:"comments,omitempty"`
+	// One-line summary, shown in the listing of the document's category when it lists descriptions
+	Description string `yaml:"description,omitempty"`
+	// How a category's document lists the category's children below its content
+	Children Children `yaml:"children,omitempty"`
 	// Add additional fields here as needed
 }
 
+// Tags are keywords describing a document. They are written as a YAML list or
+// as a single comma-separated string.
+type Tags []string
+
+// UnmarshalYAML accepts both forms of tags, trimming and dropping empty ones
+func (t *Tags) UnmarshalYAML(value *yaml.Node) error {
+	var list []string
+	if value.Kind == yaml.ScalarNode {
+		list = strings.Split(value.Value, ",")
+	} else if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*t = (*t)[:0]
+	for _, tag := range list {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+// Children controls the listing of a category's children below the content of
+// its document. It is written as a mapping, or as `children: false` to hide the
+// listing.
+type Children struct {
+	Hidden       bool   `yaml:"hidden,omitempty"`       // Don't list the children
+	Heading      string `yaml:"heading,omitempty"`      // Shown above the listing
+	Descriptions bool   `yaml:"descriptions,omitempty"` // Show the description of each child under its title
+}
+
+// UnmarshalYAML accepts both forms of the listing settings
+func (c *Children) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var show bool
+		if err := value.Decode(&show); err != nil {
+			return err
+		}
+		*c = Children{Hidden: !show}
+		return nil
+	}
+	type plain Children
+	return value.Decode((*plain)(c))
+}
+
 // Parse extracts and parses frontmatter from markdown content
 // Returns the parsed metadata and the content without frontmatter
 func Parse(content string) (Metadata, string, bool) {
@@ -89,4 +154,52 @@ func Add(content string, metadata Metadata) (string, error) {
 
 	// Construct new content with frontmatter
 	return "---\n" + buf.String() + "---\n\n" + contentWithoutFM, nil
-}
\ No newline at end of file
+}
+
+// SetFields sets top-level front matter fields to string values, adding front
+// matter when there is none. Other fields, their order and the body are kept.
+func SetFields(content string, fields map[string]string) (string, error) {
+	body := content
+	var doc yaml.Node
+	if HasFrontmatter(content) {
+		if err := yaml.Unmarshal([]byte(Extract(content)), &doc); err != nil {
+			return content, err
+		}
+		_, body, _ = Parse(content)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return content, fmt.Errorf("front matter is not a mapping")
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fields[key]}
+		found := false
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				mapping.Content[i+1] = value
+				found = true
+				break
+			}
+		}
+		if !found {
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return content, err
+	}
+	return "---\n" + buf.String() + "---\n\n" + body, nil
+}
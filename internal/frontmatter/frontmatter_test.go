@@ -0,0 +1,104 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		fields  map[string]string
+		want    string
+	}{
+		{
+			name:    "Adds front matter",
+			content: "# Title\n",
+			fields:  map[string]string{"reviewBy": "2026-01-01", "reviewedBy": "admin"},
+			want:    "---\nreviewBy: \"2026-01-01\"\nreviewedBy: admin\n---\n\n# Title\n",
+		},
+		{
+			name:    "Keeps other fields in order",
+			content: "---\nlayout: kanban\nreviewBy: 2025-01-01\ntags: [a, b]\n---\n\n# Title\n",
+			fields:  map[string]string{"reviewBy": "2026-01-01"},
+			want:    "---\nlayout: kanban\nreviewBy: \"2026-01-01\"\ntags: [a, b]\n---\n\n# Title\n",
+		},
+		{
+			name:    "Quotes values YAML would read as another type",
+			content: "---\nlayout: kanban\n---\nBody",
+			fields:  map[string]string{"reviewedBy": "true"},
+			want:    "---\nlayout: kanban\nreviewedBy: \"true\"\n---\n\nBody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetFields(tt.content, tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+			metadata, _, ok := Parse(got)
+			if !ok {
+				t.Fatal("result has no front matter")
+			}
+			if by, ok := tt.fields["reviewBy"]; ok && metadata.ReviewBy != by {
+				t.Errorf("ReviewBy = %q, want %q", metadata.ReviewBy, by)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"List", "---\ntags: [go, \" wiki \"]\n---\n", []string{"go", "wiki"}},
+		{"Block list", "---\ntags:\n  - go\n  - wiki\n---\n", []string{"go", "wiki"}},
+		{"Comma-separated string", "---\ntags: go, wiki,\n---\n", []string{"go", "wiki"}},
+		{"None", "---\nlayout: kanban\n---\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, _, ok := Parse(tt.content)
+			if !ok {
+				t.Fatal("front matter not parsed")
+			}
+			if strings.Join(metadata.Tags, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Tags = %q, want %q", metadata.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChildren(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Children
+	}{
+		{"Mapping", "---\nchildren:\n  heading: Sections\n  descriptions: true\n---\n", Children{Heading: "Sections", Descriptions: true}},
+		{"Hidden", "---\nchildren:\n  hidden: true\n---\n", Children{Hidden: true}},
+		{"False", "---\nchildren: false\n---\n", Children{Hidden: true}},
+		{"True", "---\nchildren: true\n---\n", Children{}},
+		{"None", "---\nlayout: kanban\n---\n", Children{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, _, ok := Parse(tt.content)
+			if !ok {
+				t.Fatal("front matter not parsed")
+			}
+			if metadata.Children != tt.want {
+				t.Errorf("Children = %+v, want %+v", metadata.Children, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package gitbundle writes the history of a file as a git bundle: a single
+// file that `git clone` and `git fetch` accept like a remote repository.
+//
+// Each version of the file becomes a commit on top of the previous one, with
+// its author, time and message. The objects are written to the bundle's
+// packfile without deltas, using only the standard library, so no git
+// installation is needed to produce it.
+package gitbundle
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Branch is the branch the bundle's history is on; HEAD points to it as well
+const Branch = "refs/heads/main"
+
+// Object types, as numbered in packfiles
+const (
+	objCommit = 1
+	objTree   = 2
+	objBlob   = 3
+)
+
+var typeNames = map[int]string{objCommit: "commit", objTree: "tree", objBlob: "blob"}
+
+// Version is one state of the file
+type Version struct {
+	Content []byte
+	Author  string // Shown as the commit's author and committer; "" is written as "Unknown"
+	Time    time.Time
+	Message string // "" is written as "Update <filename>"
+}
+
+// ErrNoVersions is returned when there is no history to write
+var ErrNoVersions = errors.New("gitbundle: no versions to write")
+
+type object struct {
+	kind int
+	data []byte
+	id   [sha1.Size]byte
+}
+
+func newObject(kind int, data []byte) object {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", typeNames[kind], len(data))
+	h.Write(data)
+	o := object{kind: kind, data: data}
+	copy(o.id[:], h.Sum(nil))
+	return o
+}
+
+// Write writes a bundle to w whose history holds filename in each of versions,
+// oldest first, and returns the id of the last commit
+func Write(w io.Writer, filename string, versions []Version) (string, error) {
+	if len(versions) == 0 {
+		return "", ErrNoVersions
+	}
+	if filename == "" || strings.ContainsAny(filename, "/\x00") {
+		return "", fmt.Errorf("gitbundle: invalid file name %q", filename)
+	}
+
+	var objects []object
+	seen := make(map[[sha1.Size]byte]bool)
+	add := func(o object) [sha1.Size]byte {
+		if !seen[o.id] {
+			seen[o.id] = true
+			objects = append(objects, o)
+		}
+		return o.id
+	}
+
+	var parent [sha1.Size]byte
+	for i, v := range versions {
+		blob := add(newObject(objBlob, v.Content))
+
+		var tree bytes.Buffer
+		fmt.Fprintf(&tree, "100644 %s\x00", filename)
+		tree.Write(blob[:])
+		treeID := add(newObject(objTree, tree.Bytes()))
+
+		var commit bytes.Buffer
+		fmt.Fprintf(&commit, "tree %x\n", treeID)
+		if i > 0 {
+			fmt.Fprintf(&commit, "parent %x\n", parent)
+		}
+		signature := signature(v.Author, v.Time)
+		fmt.Fprintf(&commit, "author %s\ncommitter %s\n\n", signature, signature)
+		message := strings.TrimSpace(v.Message)
+		if message == "" {
+			message = "Update " + filename
+		}
+		commit.WriteString(message + "\n")
+		parent = add(newObject(objCommit, commit.Bytes()))
+	}
+
+	head := hex.EncodeToString(parent[:])
+	header := "# v2 git bundle\n" + head + " " + Branch + "\n" + head + " HEAD\n\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return "", err
+	}
+	if err := writePack(w, objects); err != nil {
+		return "", err
+	}
+	return head, nil
+}
+
+// signature formats the author or committer of a commit
+func signature(name string, t time.Time) string {
+	// Angle brackets and newlines would end the name early
+	name = strings.Map(func(r rune) rune {
+		if r == '<' || r == '>' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Unknown"
+	}
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%s <> %d %c%02d%02d", name, t.Unix(), sign, offset/3600, offset%3600/60)
+}
+
+// writePack writes objects as a version 2 packfile, each compressed on its own
+func writePack(w io.Writer, objects []object) error {
+	h := sha1.New()
+	pw := io.MultiWriter(w, h)
+
+	header := []byte("PACK")
+	header = binary.BigEndian.AppendUint32(header, 2)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(objects)))
+	if _, err := pw.Write(header); err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		// Type and size: 3 bits of type and 4 bits of size, then 7 bits of
+		// size per byte while the high bit is set
+		size := len(o.data)
+		b := byte(o.kind<<4) | byte(size&0x0f)
+		size >>= 4
+		var entry []byte
+		for size > 0 {
+			entry = append(entry, b|0x80)
+			b = byte(size & 0x7f)
+			size >>= 7
+		}
+		entry = append(entry, b)
+		if _, err := pw.Write(entry); err != nil {
+			return err
+		}
+
+		zw := zlib.NewWriter(pw)
+		if _, err := zw.Write(o.data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(h.Sum(nil))
+	return err
+}
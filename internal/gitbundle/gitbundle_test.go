@@ -0,0 +1,119 @@
+package gitbundle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjectIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		kind int
+		data string
+		want string
+	}{
+		{"blob", objBlob, "hello\n", "ce013625030ba8dba906f756967f9e9ca394464a"},
+		{"empty blob", objBlob, "", "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		{"empty tree", objTree, "", "4b825dc642cb6eb9a060e54bf8d69288fbee4904"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newObject(tt.kind, []byte(tt.data))
+			if got := hex.EncodeToString(o.id[:]); got != tt.want {
+				t.Errorf("id = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		author string
+		time   time.Time
+		want   string
+	}{
+		{"utc", "alice", time.Unix(1700000000, 0).UTC(), "alice <> 1700000000 +0000"},
+		{"negative offset", "bob", time.Unix(1700000000, 0).In(time.FixedZone("", -(5*3600 + 30*60))), "bob <> 1700000000 -0530"},
+		{"unknown author", "", time.Unix(0, 0).UTC(), "Unknown <> 0 +0000"},
+		{"brackets removed", "eve <x@y>", time.Unix(0, 0).UTC(), "eve x@y <> 0 +0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signature(tt.author, tt.time); got != tt.want {
+				t.Errorf("signature = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	if _, err := Write(&bytes.Buffer{}, "document.md", nil); err != ErrNoVersions {
+		t.Errorf("no versions: err = %v, want ErrNoVersions", err)
+	}
+	if _, err := Write(&bytes.Buffer{}, "a/b.md", []Version{{}}); err == nil {
+		t.Error("file name with a slash: expected an error")
+	}
+}
+
+// TestWriteClone checks the bundle with git itself, when it is installed
+func TestWriteClone(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+
+	versions := []Version{
+		{Content: []byte("# Draft\n"), Author: "alice", Time: time.Unix(1700000000, 0).UTC(), Message: "First draft"},
+		{Content: []byte("# Draft\n\nMore text\n"), Author: "bob", Time: time.Unix(1700003600, 0).UTC()},
+		// Restoring an earlier version reuses its blob and tree
+		{Content: []byte("# Draft\n"), Author: "alice", Time: time.Unix(1700007200, 0).UTC(), Message: "Restore"},
+	}
+	var buf bytes.Buffer
+	head, err := Write(&buf, "document.md", versions)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "doc.bundle")
+	if err := os.WriteFile(bundle, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git := func(workDir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(gitPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return string(out)
+	}
+
+	clone := filepath.Join(dir, "clone")
+	git(dir, "clone", "--quiet", bundle, clone)
+
+	if got := strings.TrimSpace(git(clone, "rev-parse", "HEAD")); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	git(clone, "fsck", "--strict")
+
+	log := git(clone, "log", "--reverse", "--format=%an|%at|%s")
+	want := "alice|1700000000|First draft\nbob|1700003600|Update document.md\nalice|1700007200|Restore\n"
+	if log != want {
+		t.Errorf("log = %q, want %q", log, want)
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "document.md")); string(content) != "# Draft\n" {
+		t.Errorf("checked out content = %q", content)
+	}
+}
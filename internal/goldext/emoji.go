@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"log"
 	"strings"
+	"sync/atomic"
 
 	"wiki-go/internal/resources"
 )
@@ -18,6 +19,15 @@ type EmojiData struct {
 // Global emoji map
 var emojis map[string]string
 
+// emojiDisabled turns EmojiPreprocessor into a no-op; replacement is on by default
+var emojiDisabled atomic.Bool
+
+// SetEmojiEnabled switches :shortcode: replacement on or off, following the
+// markdown emoji setting
+func SetEmojiEnabled(enabled bool) {
+	emojiDisabled.Store(!enabled)
+}
+
 // init loads emoji data from the JSON file
 func init() {
 	// Initialize the map
@@ -62,6 +72,10 @@ func init() {
 // EmojiPreprocessor replaces emoji shortcodes with Unicode emoji characters
 // but avoids processing text inside code blocks
 func EmojiPreprocessor(markdown string, _ string) string {
+	if emojiDisabled.Load() || !strings.Contains(markdown, ":") {
+		return markdown
+	}
+
 	// Process line by line instead of relying on regex which might fail on large documents
 	lines := strings.Split(markdown, "\n")
 	var result []string
@@ -99,10 +113,7 @@ func EmojiPreprocessor(markdown string, _ string) string {
 			// Even segments (0, 2, 4...) are outside inline code
 			if i%2 == 0 {
 				// Apply all replacements to non-code segments
-				for shortcode, emoji := range emojis {
-					segment = strings.ReplaceAll(segment, shortcode, emoji)
-				}
-				processedLine += segment
+				processedLine += replaceShortcodes(segment)
 			} else {
 				// Odd segments (1, 3, 5...) are inside inline code - preserve them
 				processedLine += "`" + segment + "`"
@@ -113,4 +124,31 @@ func EmojiPreprocessor(markdown string, _ string) string {
 	}
 
 	return strings.Join(result, "\n")
-}
\ No newline at end of file
+}
+
+// replaceShortcodes replaces every known :shortcode: in text. A colon that doesn't
+// start a known shortcode is kept and may still end one, so "10:30 :smile:" works.
+func replaceShortcodes(text string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(text, ':')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start+1:], ':')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		if emoji, ok := emojis[text[start:end+1]]; ok {
+			sb.WriteString(text[:start])
+			sb.WriteString(emoji)
+			text = text[end+1:]
+			continue
+		}
+		sb.WriteString(text[:end])
+		text = text[end:]
+	}
+	sb.WriteString(text)
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package goldext
+
+import (
+	"testing"
+)
+
+func TestEmojiPreprocessor(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Basic shortcode",
+			input:    "Hello :smile:",
+			expected: "Hello 😄",
+		},
+		{
+			name:     "Adjacent shortcodes",
+			input:    ":smile::smile:",
+			expected: "😄😄",
+		},
+		{
+			name:     "Unknown shortcode before a known one",
+			input:    "at 10:30 :smile:",
+			expected: "at 10:30 😄",
+		},
+		{
+			name:     "Inline code is preserved",
+			input:    "Use `:smile:` for :smile:",
+			expected: "Use `:smile:` for 😄",
+		},
+		{
+			name:     "Code fence is preserved",
+			input:    "```\n:smile:\n```\n:smile:",
+			expected: "```\n:smile:\n```\n😄",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EmojiPreprocessor(tt.input, "")
+			if result != tt.expected {
+				t.Errorf("Expected: %q, got: %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		SetEmojiEnabled(false)
+		defer SetEmojiEnabled(true)
+		if result := EmojiPreprocessor("Hello :smile:", ""); result != "Hello :smile:" {
+			t.Errorf("Expected shortcode to be kept, got: %q", result)
+		}
+	})
+}
@@ -105,6 +105,18 @@ func joinSections(sections []Section) string {
 	return result.String()
 }
 
+// ReplaceOutsideCode applies replace to the parts of markdown outside code,
+// math and mermaid sections, the parts in which links are rendered
+func ReplaceOutsideCode(markdown string, replace func(text string) string) string {
+	sections := splitCodeSections(markdown)
+	for i := range sections {
+		if !sections[i].isCode {
+			sections[i].content = replace(sections[i].content)
+		}
+	}
+	return joinSections(sections)
+}
+
 // LinkPreprocessor resolves local file references
 func LinkPreprocessor(markdown string, docPath string) string {
 	// This is a simplified implementation
@@ -248,7 +260,7 @@ func resolveLocalPath(path, docPath string) string {
 
 	// Handle the homepage special case
 	if docPath == "" || docPath == "/" {
-		// Homepage files are stored in "pages/home"
+		// Homepage files are served under "pages/home"
 		result := "/api/files/pages/home/" + escapedPath
 		// fmt.Printf("[resolveLocalPath] docPath: '%s', path: '%s', result: '%s' (homepage)\n", docPath, path, result)
 		return result
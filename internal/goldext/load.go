@@ -7,6 +7,7 @@ package goldext
 // We don't actually use them directly, but they're needed for the compiler to include the preprocessors
 var (
 	_ = LinkPreprocessor
+	_ = WikiLinkPreprocessor
 	_ = MermaidPreprocessor
 	_ = DirectionPreprocessor
 	_ = MP4Preprocessor
@@ -37,6 +38,9 @@ func init() {
 	// Step 1: Process Mermaid FIRST, before any other processors can touch the content
 	RegisterPreprocessor(MermaidPreprocessor) // Process mermaid diagrams first
 
+	// Step 2: Convert [[WikiLinks]] to regular links before link processing
+	RegisterPreprocessor(WikiLinkPreprocessor)
+
 	// Step 3: Register preprocessors that handle code blocks
 	RegisterPreprocessor(LinkPreprocessor)      // Process links and images
 	RegisterPreprocessor(DirectionPreprocessor) // Process RTL/LTR blocks
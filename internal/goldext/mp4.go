@@ -22,7 +22,7 @@ func TransformMP4Path(videoPath string, docPath string) string {
 
 	// Handle the homepage special case
 	if docPath == "" || docPath == "/" {
-		// Homepage files are stored in "pages/home"
+		// Homepage files are served under "pages/home"
 		return "/api/files/pages/home/" + escapedPath
 	}
 
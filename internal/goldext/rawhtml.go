@@ -0,0 +1,26 @@
+package goldext
+
+import (
+	"regexp"
+	"strings"
+)
+
+// rawHTMLPattern matches HTML tags and comments written in markdown. Autolinks
+// such as <https://example.com> and <user@example.com> don't match.
+var rawHTMLPattern = regexp.MustCompile(`(?s)<!--.*?-->|</?[A-Za-z][A-Za-z0-9-]*(\s[^<>]*)?/?>`)
+
+// EscapeRawHTML escapes HTML tags and comments outside code so they render as
+// text. It runs before the preprocessors, which still emit their own HTML, when
+// raw HTML is disabled in the markdown configuration.
+func EscapeRawHTML(markdown string) string {
+	sections := splitCodeSections(markdown)
+	for i, section := range sections {
+		if section.isCode {
+			continue
+		}
+		sections[i].content = rawHTMLPattern.ReplaceAllStringFunc(section.content, func(tag string) string {
+			return "&lt;" + strings.TrimPrefix(tag, "<")
+		})
+	}
+	return joinSections(sections)
+}
@@ -9,9 +9,28 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// documentIndex reports whether the file at a path holds a document's content;
+// until SetDocumentIndex it is document.md
+var documentIndex atomic.Pointer[func(path string) bool]
+
+// SetDocumentIndex sets how the shortcodes that list documents recognize the
+// files holding their content, following the configured index files
+func SetDocumentIndex(isIndex func(path string) bool) {
+	documentIndex.Store(&isIndex)
+}
+
+// isDocumentIndex reports whether the file at path holds a document's content
+func isDocumentIndex(path string) bool {
+	if isIndex := documentIndex.Load(); isIndex != nil {
+		return (*isIndex)(path)
+	}
+	return filepath.Base(path) == "document.md"
+}
+
 // ShortcodesPreprocessor processes shortcodes in markdown text
 // Supports: :::year:::, :::stats count=*:::, :::stats recent=N:::
 // Avoids processing shortcodes inside code blocks
@@ -187,7 +206,7 @@ func renderRecentEdits(w *strings.Builder, count int) {
 	w.WriteString("</div>\n")
 }
 
-// countDocuments counts the number of document.md files in a directory
+// countDocuments counts the number of documents in a directory
 func countDocuments(dirPath string) int {
 	count := 0
 
@@ -202,8 +221,8 @@ func countDocuments(dirPath string) int {
 			return nil // Skip errors
 		}
 
-		// Count only document.md files
-		if !info.IsDir() && filepath.Base(path) == "document.md" {
+		// Count only the files holding document content
+		if !info.IsDir() && isDocumentIndex(path) {
 			count++
 		}
 
@@ -228,8 +247,8 @@ func getRecentDocuments(dirPath string, count int) []Document {
 			return nil // Skip errors
 		}
 
-		// Process only document.md files
-		if !info.IsDir() && filepath.Base(path) == "document.md" {
+		// Process only the files holding document content
+		if !info.IsDir() && isDocumentIndex(path) {
 			// Get the document directory
 			docDir := filepath.Dir(path)
 
@@ -0,0 +1,171 @@
+package goldext
+
+import (
+	"html"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// WikiLinkPattern matches [[target]], [[target|label]] and [[target#anchor|label]]
+var WikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+?)(?:\|([^\[\]\n]+?))?\]\]`)
+
+// Strategies for resolving a wiki link whose title matches several documents
+const (
+	WikiLinkNearest  = "nearest"  // Prefer the document closest to the linking document in the tree
+	WikiLinkShortest = "shortest" // Prefer the document with the shallowest path
+	WikiLinkFirst    = "first"    // Prefer the alphabetically first path
+)
+
+// WikiLinkTarget is a document that wiki links can resolve to
+type WikiLinkTarget struct {
+	Title string
+	Path  string // URL path, e.g. "/guides/setup"
+}
+
+// WikiLinkIndex returns every linkable document. It is installed by the handlers
+// package at startup; when nil, all wiki links render as unresolved.
+var WikiLinkIndex func() []WikiLinkTarget
+
+// WikiLinkResolution selects how ambiguous titles are resolved (see WikiLink* constants)
+var WikiLinkResolution = WikiLinkNearest
+
+// WikiLinkPreprocessor converts [[...]] links into markdown links to the matching
+// document, or into a "create page" link when no document matches
+func WikiLinkPreprocessor(markdown string, docPath string) string {
+	if !strings.Contains(markdown, "[[") {
+		return markdown
+	}
+
+	var targets []WikiLinkTarget
+	if WikiLinkIndex != nil {
+		targets = WikiLinkIndex()
+	}
+	currentPath := "/" + strings.Trim(docPath, "/")
+
+	sections := splitCodeSections(markdown)
+	for i := range sections {
+		if sections[i].isCode {
+			continue
+		}
+		sections[i].content = WikiLinkPattern.ReplaceAllStringFunc(sections[i].content, func(match string) string {
+			parts := WikiLinkPattern.FindStringSubmatch(match)
+			target := strings.TrimSpace(parts[1])
+			label := strings.TrimSpace(parts[2])
+
+			anchor := ""
+			if idx := strings.Index(target, "#"); idx >= 0 {
+				anchor = target[idx:]
+				target = strings.TrimSpace(target[:idx])
+			}
+			if label == "" {
+				label = target
+			}
+
+			// [[#anchor]] links within the current page
+			if target == "" && anchor != "" {
+				if label == "" {
+					label = strings.TrimPrefix(anchor, "#")
+				}
+				return "[" + escapeLinkLabel(label) + "](" + anchor + ")"
+			}
+
+			if resolved, ok := ResolveWikiLink(target, currentPath, targets); ok {
+				return "[" + escapeLinkLabel(label) + "](" + strings.ReplaceAll(resolved, " ", "%20") + anchor + ")"
+			}
+
+			// Unresolved: link to where the page would live so it can be created
+			createPath := "/" + slugifyWikiTarget(target)
+			return `<a href="` + html.EscapeString(createPath) + `" class="wikilink wikilink-missing" title="Create page">` +
+				html.EscapeString(label) + `</a>`
+		})
+	}
+	return joinSections(sections)
+}
+
+// ResolveWikiLink finds the document a wiki link target refers to. A target matches
+// a document's full path, its last path segment (slug) or its title, case-insensitively.
+func ResolveWikiLink(target, currentPath string, targets []WikiLinkTarget) (string, bool) {
+	if target == "" {
+		return "", false
+	}
+	wanted := strings.ToLower(strings.Trim(target, "/"))
+	wantedSlug := slugifyWikiTarget(target)
+
+	var candidates []string
+	for _, t := range targets {
+		p := strings.ToLower(strings.Trim(t.Path, "/"))
+		if p == wanted || p == wantedSlug {
+			// An exact path is never ambiguous
+			return t.Path, true
+		}
+		slug := path.Base(p)
+		if slug == wanted || slug == wantedSlug || strings.EqualFold(t.Title, target) {
+			candidates = append(candidates, t.Path)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", false
+	case 1:
+		return candidates[0], true
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if betterWikiLinkCandidate(c, best, currentPath) {
+			best = c
+		}
+	}
+	return best, true
+}
+
+// betterWikiLinkCandidate reports whether a should be preferred over b
+func betterWikiLinkCandidate(a, b, currentPath string) bool {
+	switch WikiLinkResolution {
+	case WikiLinkFirst:
+		return a < b
+	case WikiLinkShortest:
+		if da, db := strings.Count(a, "/"), strings.Count(b, "/"); da != db {
+			return da < db
+		}
+		return a < b
+	default:
+		ca, cb := commonPrefixDepth(a, currentPath), commonPrefixDepth(b, currentPath)
+		if ca != cb {
+			return ca > cb
+		}
+		if da, db := strings.Count(a, "/"), strings.Count(b, "/"); da != db {
+			return da < db
+		}
+		return a < b
+	}
+}
+
+// commonPrefixDepth counts the leading path segments a and b share
+func commonPrefixDepth(a, b string) int {
+	as := strings.Split(strings.Trim(a, "/"), "/")
+	bs := strings.Split(strings.Trim(b, "/"), "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return n
+}
+
+// slugifyWikiTarget turns a link target such as "Getting Started" into a path such as "getting-started"
+func slugifyWikiTarget(target string) string {
+	segments := strings.Split(strings.Trim(target, "/"), "/")
+	for i, segment := range segments {
+		segment = strings.ToLower(strings.TrimSpace(segment))
+		segment = strings.Join(strings.Fields(segment), "-")
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// escapeLinkLabel escapes characters that would end a markdown link label early
+func escapeLinkLabel(label string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(label)
+}
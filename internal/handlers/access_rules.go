@@ -3,10 +3,14 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
+	"wiki-go/internal/utils"
 )
 
 // AccessRulesHandler handles requests for access rules
@@ -26,6 +30,12 @@ func AccessRulesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Handle applying a rule to a whole category
+	if path == "/apply" && r.Method == http.MethodPost {
+		ApplyAccessRuleHandler(w, r)
+		return
+	}
+
 	// Handle root endpoint /api/access-rules
 	if path == "" || path == "/" {
 		switch r.Method {
@@ -65,6 +75,7 @@ func AccessRulesHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetAccessRulesHandler returns the list of access rules
 func GetAccessRulesHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"rules": cfg.AccessRules,
@@ -73,6 +84,9 @@ func GetAccessRulesHandler(w http.ResponseWriter, r *http.Request) {
 
 // CreateAccessRuleHandler creates a new access rule
 func CreateAccessRuleHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
 	var rule config.AccessRule
 	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
 		sendJSONError(w, "Invalid request payload", http.StatusBadRequest, err.Error())
@@ -94,6 +108,7 @@ func CreateAccessRuleHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create a copy of the current config
 	updatedConfig := *cfg
+	updatedConfig.AccessRules = slices.Clone(cfg.AccessRules)
 
 	// Add the new rule
 	updatedConfig.AccessRules = append(updatedConfig.AccessRules, rule)
@@ -105,7 +120,7 @@ func CreateAccessRuleHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -118,6 +133,9 @@ func CreateAccessRuleHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateAccessRuleHandler updates an existing access rule
 func UpdateAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
 	if index < 0 || index >= len(cfg.AccessRules) {
 		sendJSONError(w, "Rule not found", http.StatusNotFound, "")
 		return
@@ -144,6 +162,7 @@ func UpdateAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int)
 
 	// Create a copy of the current config
 	updatedConfig := *cfg
+	updatedConfig.AccessRules = slices.Clone(cfg.AccessRules)
 
 	// Update the rule
 	updatedConfig.AccessRules[index] = rule
@@ -155,7 +174,7 @@ func UpdateAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int)
 	}
 
 	// Update global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -167,6 +186,9 @@ func UpdateAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int)
 
 // DeleteAccessRuleHandler deletes an access rule
 func DeleteAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
 	if index < 0 || index >= len(cfg.AccessRules) {
 		sendJSONError(w, "Rule not found", http.StatusNotFound, "")
 		return
@@ -174,6 +196,7 @@ func DeleteAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int)
 
 	// Create a copy of the current config
 	updatedConfig := *cfg
+	updatedConfig.AccessRules = slices.Clone(cfg.AccessRules)
 
 	// Remove the rule
 	updatedConfig.AccessRules = append(updatedConfig.AccessRules[:index], updatedConfig.AccessRules[index+1:]...)
@@ -185,7 +208,7 @@ func DeleteAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int)
 	}
 
 	// Update global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -196,6 +219,9 @@ func DeleteAccessRuleHandler(w http.ResponseWriter, r *http.Request, index int)
 
 // ReorderAccessRulesHandler reorders the access rules
 func ReorderAccessRulesHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
 	var req struct {
 		Indices []int `json:"indices"`
 	}
@@ -232,7 +258,7 @@ func ReorderAccessRulesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -241,3 +267,156 @@ func ReorderAccessRulesHandler(w http.ResponseWriter, r *http.Request) {
 		"rules":   newRules,
 	})
 }
+
+// ApplyAccessRuleRequest sets the access of a category and every document below it
+type ApplyAccessRuleRequest struct {
+	Path        string   `json:"path"` // Category path, e.g. "finance"
+	Access      string   `json:"access"`
+	Groups      []string `json:"groups,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// "override" (the default) removes the rules of documents below the category,
+	// "merge" keeps them ahead of the new rule and adds its groups to their
+	// restricted rules
+	Mode string `json:"mode,omitempty"`
+}
+
+// AffectedDocument is a document below a category and the rule that decides
+// its access after a rule was applied to the category
+type AffectedDocument struct {
+	Path    string   `json:"path"`
+	Pattern string   `json:"pattern"`
+	Access  string   `json:"access"`
+	Groups  []string `json:"groups,omitempty"`
+}
+
+// ApplyAccessRuleResponse summarizes the effect of applying a rule to a category
+type ApplyAccessRuleResponse struct {
+	Success   bool               `json:"success"`
+	Message   string             `json:"message"`
+	Rule      config.AccessRule  `json:"rule"`
+	Removed   int                `json:"removed"` // Rules of documents below the category that were dropped
+	Merged    int                `json:"merged"`  // Restricted rules below the category that gained the groups
+	Documents []AffectedDocument `json:"documents"`
+}
+
+// ApplyAccessRuleHandler applies an access rule to a category and all documents
+// below it at once, as a recursive rule ahead of any other rule that matches
+// them, and returns the access each of those documents ends up with
+func ApplyAccessRuleHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
+	var req ApplyAccessRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	category := documentKey(req.Path)
+	if category == "" {
+		sendJSONError(w, "Recursive rules on root (/**) are not allowed.", http.StatusBadRequest, "")
+		return
+	}
+	if info, err := os.Stat(documentDir("/" + category)); err != nil || !info.IsDir() {
+		sendJSONError(w, "Category not found", http.StatusNotFound, "")
+		return
+	}
+	switch req.Access {
+	case "public", "private", "restricted":
+	default:
+		sendJSONError(w, "Access must be public, private or restricted", http.StatusBadRequest, "")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = "override"
+	}
+	if req.Mode != "override" && req.Mode != "merge" {
+		sendJSONError(w, "Mode must be override or merge", http.StatusBadRequest, "")
+		return
+	}
+
+	rule := config.AccessRule{Pattern: "/" + category + "/**", Access: req.Access, Groups: req.Groups, Description: req.Description}
+	rules, removed, merged := applyAccessRule(cfg.AccessRules, rule, category, req.Mode == "merge")
+
+	updatedConfig := *cfg
+	updatedConfig.AccessRules = rules
+	if err := saveConfig(config.ConfigFilePath, &updatedConfig); err != nil {
+		sendJSONError(w, "Failed to save configuration", http.StatusInternalServerError, err.Error())
+		return
+	}
+	config.SetCurrent(&updatedConfig)
+
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		sendJSONError(w, "Failed to list documents", http.StatusInternalServerError, err.Error())
+		return
+	}
+	affected := []AffectedDocument{}
+	for _, doc := range documents {
+		if !isSameOrDescendant(doc.Path, category) {
+			continue
+		}
+		if match := auth.MatchingAccessRule(doc.Path, rules); match != nil {
+			affected = append(affected, AffectedDocument{Path: doc.Path, Pattern: match.Pattern, Access: match.Access, Groups: match.Groups})
+		}
+	}
+	sort.Slice(affected, func(i, j int) bool { return affected[i].Path < affected[j].Path })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ApplyAccessRuleResponse{
+		Success:   true,
+		Message:   "Access rule applied successfully",
+		Rule:      rule,
+		Removed:   removed,
+		Merged:    merged,
+		Documents: affected,
+	})
+}
+
+// applyAccessRule returns rules with rule, a recursive rule for category, put
+// first. The rules of the category and the documents below it, whose patterns
+// lie within the category, are dropped; when merging they are kept just ahead
+// of rule instead, restricted ones gaining its groups if it is restricted too.
+// Other rules keep their order, and as rule only matches within the category
+// the access of documents elsewhere doesn't change.
+func applyAccessRule(rules []config.AccessRule, rule config.AccessRule, category string, merge bool) (updated []config.AccessRule, removed, merged int) {
+	var inside, outside []config.AccessRule
+	for _, existing := range rules {
+		pattern := strings.Trim(existing.Pattern, "/")
+		if pattern == category || strings.HasPrefix(pattern, category+"/") {
+			inside = append(inside, existing)
+		} else {
+			outside = append(outside, existing)
+		}
+	}
+
+	if !merge {
+		return append([]config.AccessRule{rule}, outside...), len(inside), 0
+	}
+	for i, existing := range inside {
+		if existing.Access != "restricted" || rule.Access != "restricted" {
+			continue
+		}
+		groups := slices.Clone(existing.Groups)
+		for _, group := range rule.Groups {
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+		if len(groups) != len(existing.Groups) {
+			inside[i].Groups = groups
+			merged++
+		}
+	}
+	// The category's own recursive rule is replaced by the new one
+	inside = slices.DeleteFunc(inside, func(existing config.AccessRule) bool {
+		if existing.Pattern == rule.Pattern || strings.Trim(existing.Pattern, "/") == category+"/**" {
+			removed++
+			return true
+		}
+		return false
+	})
+	updated = append(inside, rule)
+	return append(updated, outside...), removed, merged
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestApplyAccessRule(t *testing.T) {
+	existing := []config.AccessRule{
+		{Pattern: "/finance/reports/**", Access: "restricted", Groups: []string{"auditors"}},
+		{Pattern: "/finance/**", Access: "public"},
+		{Pattern: "/financial", Access: "private"},
+		{Pattern: "/**", Access: "public"},
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		want      int
+		patterns  []string            // Rule patterns afterwards, in order
+		documents map[string]string   // Document path to the pattern deciding its access
+		groups    map[string][]string // Groups of a rule afterwards, by pattern
+	}{
+		{"override", `{"path":"finance","access":"restricted","groups":["finance"]}`, http.StatusOK,
+			[]string{"/finance/**", "/financial", "/**"},
+			map[string]string{"/finance": "/finance/**", "/finance/reports": "/finance/**", "/finance/reports/q1": "/finance/**"},
+			map[string][]string{"/finance/**": {"finance"}}},
+		{"merge", `{"path":"/finance/","access":"restricted","groups":["finance"],"mode":"merge"}`, http.StatusOK,
+			[]string{"/finance/reports/**", "/finance/**", "/financial", "/**"},
+			map[string]string{"/finance": "/finance/**", "/finance/reports": "/finance/reports/**", "/finance/reports/q1": "/finance/reports/**"},
+			map[string][]string{"/finance/reports/**": {"auditors", "finance"}, "/finance/**": {"finance"}}},
+		{"root", `{"path":"/","access":"private"}`, http.StatusBadRequest, nil, nil, nil},
+		{"missing category", `{"path":"missing","access":"private"}`, http.StatusNotFound, nil, nil, nil},
+		{"bad access", `{"path":"finance","access":"secret"}`, http.StatusBadRequest, nil, nil, nil},
+		{"bad mode", `{"path":"finance","access":"private","mode":"replace"}`, http.StatusBadRequest, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t, "finance", "finance/reports", "finance/reports/q1", "financial")
+			testCfg.AccessRules = slices.Clone(existing)
+			previous := config.ConfigFilePath
+			config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+			t.Cleanup(func() { config.ConfigFilePath = previous })
+
+			rec := httptest.NewRecorder()
+			ApplyAccessRuleHandler(rec, httptest.NewRequest(http.MethodPost, "/api/access-rules/apply", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				if !slices.EqualFunc(config.Current().AccessRules, existing, func(a, b config.AccessRule) bool { return a.Pattern == b.Pattern }) {
+					t.Errorf("rules changed on error: %+v", config.Current().AccessRules)
+				}
+				return
+			}
+
+			var patterns []string
+			for _, rule := range config.Current().AccessRules {
+				patterns = append(patterns, rule.Pattern)
+				if groups, ok := tt.groups[rule.Pattern]; ok && !slices.Equal(rule.Groups, groups) {
+					t.Errorf("groups of %s = %v, want %v", rule.Pattern, rule.Groups, groups)
+				}
+			}
+			if !slices.Equal(patterns, tt.patterns) {
+				t.Errorf("patterns = %v, want %v", patterns, tt.patterns)
+			}
+
+			var response ApplyAccessRuleResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, doc := range response.Documents {
+				got[doc.Path] = doc.Pattern
+			}
+			if len(got) != len(tt.documents) {
+				t.Errorf("documents = %v, want %v", got, tt.documents)
+			}
+			for doc, pattern := range tt.documents {
+				if got[doc] != pattern {
+					t.Errorf("%s decided by %q, want %q", doc, got[doc], pattern)
+				}
+			}
+		})
+	}
+}
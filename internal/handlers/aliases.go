@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/utils"
+)
+
+// aliasIndexTTL bounds how stale the alias index may get when documents change
+// outside the wiki, e.g. over WebDAV
+const aliasIndexTTL = 10 * time.Second
+
+// DocumentAlias is a path declared in a document's `aliases` front matter that
+// is served by redirecting to the document
+type DocumentAlias struct {
+	Alias string `json:"alias"` // URL path of the alias
+	Path  string `json:"path"`  // URL path of the document declaring it
+}
+
+// AliasConflict is a declared alias that is not served because its path is
+// already taken
+type AliasConflict struct {
+	Alias  string `json:"alias"`
+	Path   string `json:"path"`   // URL path of the document declaring it
+	Reason string `json:"reason"` // "document" or "alias"
+	With   string `json:"with"`   // The document at the path, or the one whose alias wins
+}
+
+// AliasesResponse is the JSON response of the alias report
+type AliasesResponse struct {
+	Success   bool            `json:"success"`
+	Aliases   []DocumentAlias `json:"aliases"`
+	Conflicts []AliasConflict `json:"conflicts"`
+}
+
+// aliasIndex maps the alias paths of the documents to the documents
+type aliasIndex struct {
+	targets   map[string]string // Document key of an alias to the URL path of its document
+	aliases   []DocumentAlias
+	conflicts []AliasConflict
+}
+
+var (
+	aliasMu    sync.Mutex
+	aliasCache *aliasIndex
+	aliasBuilt time.Time
+)
+
+// documentAliases returns the alias index, rebuilding it when it expires
+func documentAliases() *aliasIndex {
+	aliasMu.Lock()
+	defer aliasMu.Unlock()
+
+	if aliasCache != nil && time.Since(aliasBuilt) < aliasIndexTTL {
+		return aliasCache
+	}
+	aliasCache = buildAliasIndex()
+	aliasBuilt = time.Now()
+	for _, conflict := range aliasCache.conflicts {
+		log.Printf("Warning: Alias %s of %s is taken by the %s %s", conflict.Alias, conflict.Path, conflict.Reason, conflict.With)
+	}
+	return aliasCache
+}
+
+// invalidateAliasIndex makes the next alias lookup read the documents again
+func invalidateAliasIndex() {
+	aliasMu.Lock()
+	aliasCache = nil
+	aliasMu.Unlock()
+}
+
+// buildAliasIndex reads the aliases of every document. An alias is skipped when
+// a document or category exists at its path, which always wins, or when an
+// earlier document, in path order, declared it already.
+func buildAliasIndex() *aliasIndex {
+	cfg := config.Current()
+	index := &aliasIndex{
+		targets:   map[string]string{},
+		aliases:   []DocumentAlias{},
+		conflicts: []AliasConflict{},
+	}
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		log.Printf("Warning: Failed to build alias index: %v", err)
+	}
+	sort.Slice(documents, func(i, j int) bool { return documents[i].Path < documents[j].Path })
+
+	for _, doc := range documents {
+		content, err := os.ReadFile(utils.DocumentFile(documentDir(doc.Path)))
+		if err != nil {
+			continue
+		}
+		metadata, _, _ := frontmatter.Parse(string(content))
+		for _, alias := range metadata.Aliases {
+			key := documentKey(alias)
+			if key == "" || key == documentKey(doc.Path) {
+				continue
+			}
+			aliasPath := "/" + key
+
+			if info, err := os.Stat(documentDir(aliasPath)); err == nil && info.IsDir() {
+				index.conflicts = append(index.conflicts, AliasConflict{Alias: aliasPath, Path: doc.Path, Reason: "document", With: aliasPath})
+				continue
+			}
+			if other, ok := index.targets[key]; ok {
+				if other != doc.Path {
+					index.conflicts = append(index.conflicts, AliasConflict{Alias: aliasPath, Path: doc.Path, Reason: "alias", With: other})
+				}
+				continue
+			}
+			index.targets[key] = doc.Path
+			index.aliases = append(index.aliases, DocumentAlias{Alias: aliasPath, Path: doc.Path})
+		}
+	}
+	return index
+}
+
+// aliasTarget returns the URL path of the document that declares urlPath as an
+// alias, or "" when none does
+func aliasTarget(urlPath string) string {
+	key := documentKey(urlPath)
+	if key == "" {
+		return ""
+	}
+	return documentAliases().targets[key]
+}
+
+// AliasesHandler lists the document aliases that are served and those that
+// conflict with a document or another alias
+func AliasesHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	index := documentAliases()
+	session := auth.GetSession(r)
+	response := AliasesResponse{Success: true, Aliases: []DocumentAlias{}, Conflicts: []AliasConflict{}}
+	for _, alias := range index.aliases {
+		if auth.CanAccessDocument(alias.Path, session, cfg) {
+			response.Aliases = append(response.Aliases, alias)
+		}
+	}
+	for _, conflict := range index.conflicts {
+		if auth.CanAccessDocument(conflict.Path, session, cfg) {
+			response.Conflicts = append(response.Conflicts, conflict)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// serveAlias redirects a request for a path that is an alias to the document
+// declaring it, keeping the query string. It reports whether it did; aliases
+// of documents the reader can't access are treated as missing.
+func serveAlias(w http.ResponseWriter, r *http.Request, urlPath string) bool {
+	cfg := config.Current()
+	target := aliasTarget(urlPath)
+	if target == "" || !auth.CanAccessDocument(target, auth.GetSession(r), cfg) {
+		return false
+	}
+	target = escapePathSegments(target)
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+	return true
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestDocumentAliases(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides/setup", "taken", "other")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "guides/setup", "---\naliases: [setup, /old/setup/, taken, shared, guides/setup]\n---\n\n# Setup")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "other", "---\naliases: [shared]\n---\n\n# Other")
+	invalidateAliasIndex()
+	t.Cleanup(invalidateAliasIndex)
+
+	tests := []struct {
+		target   string
+		location string
+	}{
+		{"/setup", "/guides/setup"},
+		{"/old/setup?mode=print", "/guides/setup?mode=print"},
+		{"/shared", "/guides/setup"},
+		{"/taken", ""},
+		{"/missing", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rec := httptest.NewRecorder()
+			served := serveAlias(rec, req, req.URL.Path)
+
+			if served != (tt.location != "") || rec.Header().Get("Location") != tt.location {
+				t.Errorf("served = %v, Location = %q, want %q", served, rec.Header().Get("Location"), tt.location)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/aliases", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	AliasesHandler(rec, req)
+
+	var resp AliasesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	var aliases []string
+	for _, alias := range resp.Aliases {
+		aliases = append(aliases, alias.Alias+" "+alias.Path)
+	}
+	if want := []string{"/setup /guides/setup", "/old/setup /guides/setup", "/shared /guides/setup"}; !slices.Equal(aliases, want) {
+		t.Errorf("aliases = %q, want %q", aliases, want)
+	}
+	want := []AliasConflict{
+		{Alias: "/taken", Path: "/guides/setup", Reason: "document", With: "/taken"},
+		{Alias: "/shared", Path: "/other", Reason: "alias", With: "/guides/setup"},
+	}
+	if !slices.Equal(resp.Conflicts, want) {
+		t.Errorf("conflicts = %+v, want %+v", resp.Conflicts, want)
+	}
+}
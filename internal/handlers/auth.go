@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"html/template"
 	"log"
 	"net/http"
-	"net"
 	"path/filepath"
+	"slices"
 	"strconv"
-	"strings"
+	"time"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
 	"wiki-go/internal/ban"
 	"wiki-go/internal/crypto"
+	"wiki-go/internal/csp"
 	"wiki-go/internal/resources"
 	"wiki-go/internal/i18n"
+	"wiki-go/internal/ipfilter"
+	"wiki-go/internal/lastlogin"
+	"wiki-go/internal/metrics"
 	"wiki-go/internal/roles"
 	"wiki-go/internal/version"
 )
@@ -23,33 +29,25 @@ type LoginRequest struct {
 	Username    string `json:"username"`
 	Password    string `json:"password"`
 	KeepLoggedIn bool   `json:"keepLoggedIn"`
+	// Page to return to after signing in; ?next= on the request works too
+	Next string `json:"next,omitempty"`
 }
 
 // loginBan handles IP-based banning for failed login attempts.
 var loginBan *ban.BanList
 
-// clientIP extracts the real client IP address, considering proxy headers.
+// clientIP returns the address of the client. Proxy headers are only read
+// with server.trust_proxy, as any client can send them.
 func clientIP(r *http.Request) string {
-	// Prioritise common proxy headers
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		// X-Forwarded-For may contain multiple IPs, the first is the client
-		if comma := strings.Index(ip, ","); comma != -1 {
-			return strings.TrimSpace(ip[:comma])
-		}
-		return strings.TrimSpace(ip)
-	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return ip
-	}
-	// Fallback to RemoteAddr
-	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
-		return host
+	if addr, ok := ipfilter.ClientAddr(r, config.Current().Server.TrustProxy); ok {
+		return addr.String()
 	}
 	return r.RemoteAddr // as-is (unlikely path)
 }
 
 // LoginHandler handles API login requests
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != http.MethodPost {
@@ -76,13 +74,8 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	// If IP is currently banned, short-circuit before doing any work.
 	if loginBan != nil {
 		if remaining := loginBan.IsBanned(ip); remaining > 0 {
-			w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())))
-			w.WriteHeader(http.StatusTooManyRequests)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success":    false,
-				"retryAfter": int(remaining.Seconds()),
-				"message":    "Too many failed logins; try again later",
-			})
+			metrics.LoginAttempts.Inc("banned")
+			loginFailed(w, remaining)
 			return
 		}
 	}
@@ -90,33 +83,28 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	// Validate credentials
 	valid, role, groups := auth.ValidateCredentials(req.Username, req.Password, cfg)
 	if !valid {
+		metrics.LoginAttempts.Inc("failure")
 		if loginBan != nil {
 			if dur, bannedNow := loginBan.RegisterFailure(ip); bannedNow {
 				// Immediately inform client of new ban
-				w.Header().Set("Retry-After", strconv.Itoa(int(dur.Seconds())))
-				w.WriteHeader(http.StatusTooManyRequests)
-				json.NewEncoder(w).Encode(map[string]interface{}{
-					"success":    false,
-					"retryAfter": int(dur.Seconds()),
-					"message":    "Too many failed logins; try again later",
-				})
+				loginFailed(w, dur)
 				return
 			}
 		}
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Invalid credentials",
-		})
+		loginFailed(w, 0)
 		return
 	}
 
+	metrics.LoginAttempts.Inc("success")
 	if loginBan != nil {
 		loginBan.Clear(ip) // successful login resets failures / ban
 	}
 
+	// Now that the password is known, bring its hash up to the current settings
+	upgradePasswordHash(req.Username, req.Password)
+
 	// Create session
-	if err := auth.CreateSession(w, req.Username, role, groups, req.KeepLoggedIn, cfg); err != nil {
+	if err := auth.CreateSession(w, r, req.Username, role, groups, req.KeepLoggedIn, cfg); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -124,14 +112,90 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	// ip is only taken from proxy headers behind a trusted proxy, so users
+	// can rely on it to spot sign-ins that weren't theirs
+	if err := userLogins().Record(req.Username, ip, time.Now()); err != nil {
+		log.Printf("Warning: Failed to record login of %s: %v", req.Username, err)
+	}
+
+	next := req.Next
+	if next == "" {
+		next = r.URL.Query().Get("next")
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Login successful",
+		"success":  true,
+		"message":  "Login successful",
+		"redirect": loginRedirect(next, role),
 	})
 }
 
+// loginFailed answers a failed login. A ban, when banned is positive, is only
+// told apart from wrong credentials when security.login_errors is "detailed";
+// otherwise every failure looks the same to the client.
+func loginFailed(w http.ResponseWriter, banned time.Duration) {
+	cfg := config.Current()
+	if banned > 0 && cfg.Security.LoginErrors != config.LoginErrorsGeneric {
+		w.Header().Set("Retry-After", strconv.Itoa(int(banned.Seconds())))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    false,
+			"retryAfter": int(banned.Seconds()),
+			"message":    "Too many failed logins; try again later",
+		})
+		return
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"message": "Invalid credentials",
+	})
+}
+
+// userLogins returns when the users of the wiki last signed in
+func userLogins() *lastlogin.Store {
+	cfg := config.Current()
+	return lastlogin.New(cfg.Wiki.RootDir)
+}
+
+// upgradePasswordHash rehashes the password of username when its stored hash
+// was made with another algorithm or cost than configured. Failing to do so
+// doesn't fail the login; the old hash keeps working.
+func upgradePasswordHash(username, password string) {
+	cfg := config.Current()
+	params := config.PasswordParams(cfg)
+	i := slices.IndexFunc(cfg.Users, func(u config.User) bool { return u.Username == username })
+	if i < 0 || !crypto.NeedsRehash(cfg.Users[i].Password, params) {
+		return
+	}
+	oldHash := cfg.Users[i].Password
+	hashedPassword, err := crypto.HashPasswordWith(password, params)
+	if err != nil {
+		log.Printf("Error rehashing password of %s: %v", username, err)
+		return
+	}
+
+	// Apply the new hash to the configuration as it is now, unless the user
+	// was changed in the meantime
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg = config.Current()
+	i = slices.IndexFunc(cfg.Users, func(u config.User) bool { return u.Username == username })
+	if i < 0 || cfg.Users[i].Password != oldHash {
+		return
+	}
+	updatedConfig := *cfg
+	updatedConfig.Users = slices.Clone(cfg.Users)
+	updatedConfig.Users[i].Password = hashedPassword
+	if err := saveConfig(config.ConfigFilePath, &updatedConfig); err != nil {
+		log.Printf("Error saving rehashed password of %s: %v", username, err)
+		return
+	}
+	config.SetCurrent(&updatedConfig)
+	log.Printf("Rehashed password of %s with %s", username, cmp.Or(params.Algorithm, crypto.AlgorithmBcrypt))
+}
+
 // CheckAuthHandler checks if the user is authenticated
 func CheckAuthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -155,8 +219,51 @@ func CheckAuthHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// RefreshHandler trades the refresh token of a user who chose to stay logged
+// in for a new session
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	session, err := auth.RefreshSession(w, r, cfg)
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing session: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to create session",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"username": session.Username,
+		"role":     session.Role,
+		"groups":   session.Groups,
+	})
+}
+
 // LogoutHandler handles user logout
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
 	w.Header().Set("Content-Type", "application/json")
 
 	auth.ClearSession(w, r, cfg)
@@ -169,20 +276,28 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 
 // LoginPageHandler renders the login page
 func LoginPageHandler(w http.ResponseWriter, r *http.Request) {
-	// If user is already logged in, redirect to home page
+	cfg := config.Current()
+
+	// A user who is already logged in goes where the login would have taken them
 	session := auth.GetSession(r)
 	if session != nil {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
+		next := r.URL.Query().Get("next")
+		if next == "" {
+			next = r.URL.Query().Get("redirect")
+		}
+		http.Redirect(w, r, loginRedirect(next, session.Role), http.StatusSeeOther)
 		return
 	}
 
 	// Prepare the data for the template
 	data := struct {
-		Config *config.Config
-		Theme  string
+		Config   *config.Config
+		Theme    string
+		CSPNonce string
 	}{
-		Config: cfg,
-		Theme:  "light", // Default theme
+		Config:   cfg,
+		Theme:    "light", // Default theme
+		CSPNonce: csp.Nonce(r),
 	}
 
 	// Get theme from cookie if available
@@ -221,6 +336,8 @@ func LoginPageHandler(w http.ResponseWriter, r *http.Request) {
 
 // CheckDefaultPasswordHandler checks if the default admin password is still in use
 func CheckDefaultPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Default admin credentials (typically admin/admin)
 	defaultUsername := "admin"
 	defaultPassword := "admin"
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/crypto"
+)
+
+func TestLoginRedirect(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t)
+	hash, err := crypto.HashPassword("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCfg.Users = []config.User{
+		{Username: "ed", Password: hash, Role: config.RoleEditor},
+		{Username: "vi", Password: hash, Role: config.RoleViewer},
+	}
+	testCfg.Server.Landing.Editor = "/dashboard"
+
+	tests := []struct {
+		name     string
+		username string
+		next     string // In the body
+		query    string
+		want     string
+	}{
+		{"role landing page", "ed", "", "", "/dashboard"},
+		{"no landing page", "vi", "", "", "/"},
+		{"next", "ed", "/guide/setup?lang=de", "", "/guide/setup?lang=de"},
+		{"next in query", "vi", "", "?next=/guide", "/guide"},
+		{"absolute next", "ed", "https://evil.example/", "", "/dashboard"},
+		{"protocol-relative next", "vi", "//evil.example/", "", "/"},
+		{"backslash next", "vi", "/\\evil.example/", "", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(LoginRequest{Username: tt.username, Password: "secret", Next: tt.next})
+			req := httptest.NewRequest(http.MethodPost, "/api/login"+tt.query, strings.NewReader(string(body)))
+			rec := httptest.NewRecorder()
+			LoginHandler(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+			}
+			var resp struct{ Redirect string }
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Redirect != tt.want {
+				t.Errorf("redirect = %q, want %q", resp.Redirect, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithm  string
+		cost       int
+		wantPrefix string
+	}{
+		{"current", crypto.AlgorithmBcrypt, 4, "$2a$04$"},
+		{"higher cost", crypto.AlgorithmBcrypt, 5, "$2a$05$"},
+		{"argon2id", crypto.AlgorithmArgon2id, 4, "$argon2id$v=19$m=64,t=1,p=1$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t)
+			hash, err := crypto.HashPassword("secret", 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCfg.Users = []config.User{{Username: "ed", Password: hash, Role: config.RoleEditor}}
+			testCfg.Security.PasswordAlgorithm = tt.algorithm
+			testCfg.Security.PasswordStrength = tt.cost
+			testCfg.Security.Argon2.MemoryKiB = 64
+			testCfg.Security.Argon2.Iterations = 1
+			testCfg.Security.Argon2.Parallelism = 1
+
+			previous := config.ConfigFilePath
+			config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+			t.Cleanup(func() { config.ConfigFilePath = previous })
+
+			for range 2 {
+				rec := httptest.NewRecorder()
+				LoginHandler(rec, httptest.NewRequest(http.MethodPost, "/api/login",
+					strings.NewReader(`{"username":"ed","password":"secret"}`)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+				}
+			}
+			if got := config.Current().Users[0].Password; !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("password hash = %q, want prefix %q", got, tt.wantPrefix)
+			}
+			saved, err := os.ReadFile(config.ConfigFilePath)
+			if rehashed := config.Current().Users[0].Password != hash; rehashed != (err == nil) {
+				t.Fatalf("config saved = %t after rehashing = %t", err == nil, rehashed)
+			}
+			if err == nil && !strings.Contains(string(saved), config.Current().Users[0].Password) {
+				t.Error("rehashed password not saved")
+			}
+		})
+	}
+}
+
+func TestLoginRecordsLastLogin(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t)
+	hash, err := crypto.HashPassword("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCfg.Users = []config.User{
+		{Username: "ad", Password: hash, Role: config.RoleAdmin},
+		{Username: "vi", Password: hash, Role: config.RoleViewer},
+	}
+	testCfg.Server.TrustProxy = true
+
+	login := func(ip string) *http.Cookie {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"username":"ad","password":"secret"}`))
+		req.Header.Set("X-Real-IP", ip)
+		rec := httptest.NewRecorder()
+		LoginHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+		}
+		return rec.Result().Cookies()[0]
+	}
+	login("10.0.0.1")
+	cookie := login("10.0.0.2")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	WhoamiHandler(rec, req)
+	var whoami whoamiResponse
+	if err := json.NewDecoder(rec.Body).Decode(&whoami); err != nil {
+		t.Fatal(err)
+	}
+	if whoami.LastLogin == nil || whoami.LastLogin.IP != "10.0.0.2" {
+		t.Errorf("last login = %+v", whoami.LastLogin)
+	}
+	if whoami.PreviousLogin == nil || whoami.PreviousLogin.IP != "10.0.0.1" {
+		t.Errorf("previous login = %+v", whoami.PreviousLogin)
+	}
+
+	// Without a proxy in front, the header is the client's own word
+	config.Current().Server.TrustProxy = false
+	cookie = login("10.0.0.3")
+	req = httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	WhoamiHandler(rec, req)
+	whoami = whoamiResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&whoami); err != nil {
+		t.Fatal(err)
+	}
+	if whoami.LastLogin == nil || whoami.LastLogin.IP != "192.0.2.1" {
+		t.Errorf("last login with a spoofed header = %+v", whoami.LastLogin)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	GetUsersHandler(rec, req)
+	var users struct{ Users []UserResponse }
+	if err := json.NewDecoder(rec.Body).Decode(&users); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users.Users {
+		if signedIn := user.LastLogin != nil; signedIn != (user.Username == "ad") {
+			t.Errorf("last login of %s = %v", user.Username, user.LastLogin)
+		}
+	}
+}
+
+func TestLoginErrors(t *testing.T) {
+	tests := []struct {
+		style      string
+		wantBanned int // Status of logins once the client is banned
+	}{
+		{config.LoginErrorsDetailed, http.StatusTooManyRequests},
+		{config.LoginErrorsGeneric, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t)
+			hash, err := crypto.HashPassword("secret", 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCfg.Users = []config.User{{Username: "ed", Password: hash, Role: config.RoleEditor}}
+			testCfg.Security.LoginErrors = tt.style
+			testCfg.Security.LoginBan.Enabled = true
+			testCfg.Security.LoginBan.MaxFailures = 2
+			testCfg.Security.LoginBan.WindowSeconds = 60
+			testCfg.Security.LoginBan.InitialBanSeconds = 60
+			testCfg.Security.LoginBan.MaxBanSeconds = 60
+			InitLoginBan(testCfg)
+			t.Cleanup(func() { loginBan = nil })
+
+			for i, password := range []string{"wrong", "wrong", "secret"} {
+				rec := httptest.NewRecorder()
+				LoginHandler(rec, httptest.NewRequest(http.MethodPost, "/api/login",
+					strings.NewReader(`{"username":"ed","password":"`+password+`"}`)))
+				want := http.StatusUnauthorized
+				if i > 0 {
+					want = tt.wantBanned
+				}
+				if rec.Code != want {
+					t.Errorf("login %d: status = %d, want %d", i, rec.Code, want)
+				}
+				if retry := rec.Header().Get("Retry-After") != ""; retry != (rec.Code == http.StatusTooManyRequests) {
+					t.Errorf("login %d: Retry-After = %q", i, rec.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+// A rehash on login applies to the configuration as changed by an admin in
+// the meantime
+func TestRehashKeepsConcurrentChanges(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t)
+	hash, err := crypto.HashPassword("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCfg.Users = []config.User{{Username: "ed", Password: hash, Role: config.RoleEditor}}
+	testCfg.Security.PasswordStrength = 5
+	previous := config.ConfigFilePath
+	config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { config.ConfigFilePath = previous })
+
+	configMu.Lock()
+	done := make(chan struct{})
+	go func() {
+		upgradePasswordHash("ed", "secret")
+		close(done)
+	}()
+	disabled := *testCfg
+	disabled.Users = []config.User{{Username: "ed", Password: hash, Role: config.RoleEditor, Disabled: true}}
+	config.SetCurrent(&disabled)
+	configMu.Unlock()
+	<-done
+
+	user := config.Current().Users[0]
+	if !user.Disabled {
+		t.Error("rehash enabled the user again")
+	}
+	if !strings.HasPrefix(user.Password, "$2a$05$") {
+		t.Errorf("password hash = %q, want it rehashed", user.Password)
+	}
+}
+
+// Rate limits and login bans key on clientIP, so a client must not choose it
+// by sending proxy headers
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		headers    map[string]string
+		want       string
+	}{
+		{"connection", false, nil, "192.0.2.1"},
+		{"forwarded without proxy", false, map[string]string{"X-Forwarded-For": "203.0.113.9"}, "192.0.2.1"},
+		{"real IP without proxy", false, map[string]string{"X-Real-IP": "203.0.113.9"}, "192.0.2.1"},
+		{"forwarded by proxy", true, map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.7"}, "198.51.100.7"},
+		{"real IP from proxy", true, map[string]string{"X-Real-IP": "203.0.113.9"}, "203.0.113.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t)
+			testCfg.Server.TrustProxy = tt.trustProxy
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			if got := clientIP(req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
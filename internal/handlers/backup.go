@@ -299,6 +299,7 @@ func DownloadBackupHandler(w http.ResponseWriter, r *http.Request, cfg *config.C
 
 	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
 	w.Header().Set("Content-Type", "application/zip")
+	liftTimeouts(w)
 	http.ServeFile(w, r, filePath)
 }
 
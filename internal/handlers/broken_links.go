@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/goldext"
+	"wiki-go/internal/utils"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// BrokenLink is an internal link whose target does not exist
+type BrokenLink struct {
+	Source string `json:"source"` // URL path of the document containing the link
+	Target string `json:"target"` // Link destination as written
+	Text   string `json:"text"`   // Link text
+	Kind   string `json:"kind"`   // "markdown" or "wikilink"
+}
+
+// BrokenLinksResponse is the JSON response of the broken links report
+type BrokenLinksResponse struct {
+	Success     bool         `json:"success"`
+	GeneratedAt time.Time    `json:"generatedAt"`
+	Scanned     int          `json:"scanned"`
+	Links       []BrokenLink `json:"links"`
+}
+
+// The last report is cached until a refresh is requested
+var (
+	brokenLinksMu     sync.Mutex
+	brokenLinksReport *BrokenLinksResponse
+)
+
+// BrokenLinksHandler scans every document for internal links whose target is missing.
+// The result is cached; pass ?refresh=1 to rescan.
+func BrokenLinksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	brokenLinksMu.Lock()
+	defer brokenLinksMu.Unlock()
+
+	if brokenLinksReport == nil || r.URL.Query().Get("refresh") == "1" {
+		report, err := scanBrokenLinks()
+		if err != nil {
+			sendJSONError(w, "Failed to scan documents", http.StatusInternalServerError, err.Error())
+			return
+		}
+		brokenLinksReport = report
+	}
+
+	json.NewEncoder(w).Encode(brokenLinksReport)
+}
+
+// scanBrokenLinks checks the links of the homepage and every document
+func scanBrokenLinks() (*BrokenLinksResponse, error) {
+	cfg := config.Current()
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]goldext.WikiLinkTarget, 0, len(documents))
+	for _, doc := range documents {
+		targets = append(targets, goldext.WikiLinkTarget{Title: doc.Title, Path: doc.Path})
+	}
+
+	report := &BrokenLinksResponse{
+		Success:     true,
+		GeneratedAt: time.Now(),
+		Links:       []BrokenLink{},
+	}
+
+	// The homepage lives outside the documents directory
+	sources := []string{"/"}
+	for _, doc := range documents {
+		sources = append(sources, doc.Path)
+	}
+
+	for _, source := range sources {
+		dir := documentDir(source)
+		content, err := os.ReadFile(utils.DocumentFile(dir))
+		if err != nil {
+			continue
+		}
+		report.Scanned++
+		report.Links = append(report.Links, findBrokenLinks(source, dir, string(content), targets)...)
+	}
+
+	return report, nil
+}
+
+// documentDir maps a document URL path to its directory on disk
+func documentDir(urlPath string) string {
+	cfg := config.Current()
+	if urlPath == "/" {
+		return homePageDir(cfg)
+	}
+	return filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, filepath.FromSlash(strings.TrimPrefix(urlPath, "/")))
+}
+
+// versionsDir maps a document URL path ("/" for the homepage) and translation
+// ("" for document.md) to the directory holding its versions and revision metadata
+func versionsDir(urlPath, lang string) string {
+	cfg := config.Current()
+	dir, _ := documentDataDirs(cfg, urlPath)
+	if urlPath == "/" {
+		dir = homePageVersionsDir(cfg)
+	}
+	if lang != "" {
+		dir = filepath.Join(dir, strings.TrimSuffix(utils.TranslationFileName(lang), ".md"))
+	}
+	return dir
+}
+
+// findBrokenLinks returns the broken markdown and wiki links of one document
+func findBrokenLinks(source, dir, content string, targets []goldext.WikiLinkTarget) []BrokenLink {
+	var broken []BrokenLink
+
+	if _, body, hasFrontmatter := frontmatter.Parse(content); hasFrontmatter {
+		content = body
+	}
+	src := []byte(content)
+
+	doc := utils.NewMarkdown().Parser().Parse(text.NewReader(src))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *ast.CodeBlock, *ast.FencedCodeBlock, *ast.CodeSpan:
+			return ast.WalkSkipChildren, nil
+		}
+
+		var dest string
+		switch node := n.(type) {
+		case *ast.Link:
+			dest = string(node.Destination)
+		case *ast.Image:
+			dest = string(node.Destination)
+		case *ast.Paragraph, *ast.TextBlock, *ast.Heading:
+			// Wiki links are not markdown syntax; the parser splits their brackets
+			// into separate text nodes, so match against the block's whole text
+			broken = append(broken, findBrokenWikiLinks(source, inlineText(n, src), targets)...)
+			return ast.WalkContinue, nil
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		if !internalLinkExists(dest, dir) {
+			broken = append(broken, BrokenLink{Source: source, Target: dest, Text: inlineText(n, src), Kind: "markdown"})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	return broken
+}
+
+// findBrokenWikiLinks returns the wiki links in content that match no document
+func findBrokenWikiLinks(source, content string, targets []goldext.WikiLinkTarget) []BrokenLink {
+	var broken []BrokenLink
+	for _, match := range goldext.WikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		target := strings.TrimSpace(match[1])
+		if idx := strings.Index(target, "#"); idx >= 0 {
+			target = strings.TrimSpace(target[:idx])
+		}
+		if target == "" {
+			continue
+		}
+		if _, ok := goldext.ResolveWikiLink(target, source, targets); !ok {
+			label := strings.TrimSpace(match[2])
+			if label == "" {
+				label = target
+			}
+			broken = append(broken, BrokenLink{Source: source, Target: target, Text: label, Kind: "wikilink"})
+		}
+	}
+	return broken
+}
+
+// internalLinkExists reports whether an internal link destination resolves to a
+// document or attachment. External and non-document links are always treated as valid.
+func internalLinkExists(dest, dir string) bool {
+	cfg := config.Current()
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return true
+	}
+	p := u.Path
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+
+	switch {
+	case strings.HasPrefix(p, "/api/files/"):
+		rel := filepath.FromSlash(strings.TrimPrefix(p, "/api/files/"))
+		if strings.HasPrefix(p, "/api/files/pages/") {
+			return pathExists(filepath.Join(cfg.Wiki.RootDir, rel))
+		}
+		return pathExists(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, rel))
+
+	case strings.HasPrefix(p, "/api/") || strings.HasPrefix(p, "/static/"):
+		return true
+
+	case strings.HasPrefix(p, "/"):
+		if p == "/" {
+			return true
+		}
+		return pathExists(documentDir(path.Clean(p))) || aliasTarget(p) != ""
+
+	default:
+		// Relative links point at an attachment or a child document
+		return pathExists(filepath.Join(dir, filepath.FromSlash(p)))
+	}
+}
+
+func pathExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+// inlineText returns the plain text content of a node, skipping code spans
+func inlineText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := child.(type) {
+		case *ast.CodeSpan:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			b.Write(c.Value(src))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte('\n')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/utils"
+)
+
+// CategoryOrderRequest is the payload for updating a category's sort order
+type CategoryOrderRequest struct {
+	Path  string   `json:"path"`  // Category path relative to the documents directory, "" for the top level
+	Mode  string   `json:"mode"`  // "alphabetical", "modified", "created" or "manual"
+	Order []string `json:"order"` // Child directory names for manual mode
+}
+
+// CategoryOrderResponse describes a category's sort settings and its ordered children
+type CategoryOrderResponse struct {
+	Success  bool     `json:"success"`
+	Message  string   `json:"message,omitempty"`
+	Path     string   `json:"path"`
+	Mode     string   `json:"mode"`
+	Order    []string `json:"order,omitempty"`
+	Children []string `json:"children"`
+}
+
+// CategoryOrderHandler reads (GET ?path=) or updates (POST) the sort order of a category
+func CategoryOrderHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var path string
+	var req CategoryOrderRequest
+
+	switch r.Method {
+	case http.MethodGet:
+		path = r.URL.Query().Get("path")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "Invalid request body", http.StatusBadRequest, err.Error())
+			return
+		}
+		if !utils.IsValidSortMode(req.Mode) {
+			sendJSONError(w, "Invalid sort mode", http.StatusBadRequest, "")
+			return
+		}
+		path = req.Path
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	path = strings.Trim(filepath.ToSlash(filepath.Clean("/"+path)), "/")
+	categoryDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path)
+	if info, err := os.Stat(categoryDir); err != nil || !info.IsDir() {
+		sendJSONError(w, "Category not found", http.StatusNotFound, "")
+		return
+	}
+
+	children, err := listChildDirs(categoryDir)
+	if err != nil {
+		sendJSONError(w, "Failed to read category", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		order := utils.CategoryOrder{Mode: req.Mode}
+		if req.Mode == utils.SortManual {
+			// Keep only names that are actual children, without duplicates
+			existing := make(map[string]bool, len(children))
+			for _, name := range children {
+				existing[name] = true
+			}
+			for _, name := range req.Order {
+				if existing[name] {
+					order.Order = append(order.Order, name)
+					existing[name] = false
+				}
+			}
+		}
+		if err := utils.SaveCategoryOrder(categoryDir, order); err != nil {
+			sendJSONError(w, "Failed to save sort order", http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	order := utils.LoadCategoryOrder(categoryDir)
+	utils.SortCategoryChildren(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, config.VersionsPath(cfg), path, children)
+
+	json.NewEncoder(w).Encode(CategoryOrderResponse{
+		Success:  true,
+		Path:     path,
+		Mode:     order.Mode,
+		Order:    order.Order,
+		Children: children,
+	})
+}
+
+// listChildDirs returns the names of the visible subdirectories of dir
+func listChildDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/collab"
+	"wiki-go/internal/config"
+	"wiki-go/internal/roles"
+	"wiki-go/internal/utils"
+	"wiki-go/internal/websocket"
+)
+
+// collabHub holds the documents open for real-time editing
+var collabHub = collab.NewHub(func(docPath string) (string, error) {
+	content, err := os.ReadFile(utils.DocumentFile(documentDir(docPath)))
+	return string(content), err
+})
+
+// CollabHandler connects an editor to the real-time editing session of a
+// document over a WebSocket on /api/collab/{path}. Edits and cursor moves are
+// relayed between everyone editing the document; saving still goes through
+// /api/save, after which the session continues from the saved text.
+func CollabHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if !cfg.Wiki.RealtimeEditing {
+		sendJSONError(w, "Real-time editing is disabled", http.StatusNotFound, "")
+		return
+	}
+
+	session := auth.GetSession(r)
+	if session == nil || (session.Role != roles.RoleAdmin && session.Role != roles.RoleEditor) {
+		sendJSONError(w, "Editor or admin role required", http.StatusForbidden, "")
+		return
+	}
+
+	docPath := "/" + strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/collab"), "/")
+	if !auth.CanAccessDocument(docPath, session, cfg) {
+		sendJSONError(w, "Forbidden", http.StatusForbidden, "")
+		return
+	}
+	if _, err := os.Stat(utils.DocumentFile(documentDir(docPath))); err != nil {
+		sendJSONError(w, "Document not found", http.StatusNotFound, "")
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	collabHub.Serve(docPath, session.Username, conn)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCollabHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		path    string
+		want    int
+	}{
+		{"disabled", false, "/api/collab/a", http.StatusNotFound},
+		{"missing document", true, "/api/collab/missing", http.StatusNotFound},
+		{"not a WebSocket handshake", true, "/api/collab/a", http.StatusUpgradeRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+			testCfg.Wiki.RealtimeEditing = tt.enabled
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			CollabHandler(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
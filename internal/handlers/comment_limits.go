@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/challenge"
+	"wiki-go/internal/comments"
+	"wiki-go/internal/config"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/ratelimit"
+	"wiki-go/internal/roles"
+)
+
+// Comment posting limiters, keyed by client IP and by username
+var (
+	commentIPLimiter   *ratelimit.Limiter
+	commentUserLimiter *ratelimit.Limiter
+)
+
+// commentChallenge verifies the anti-spam challenge; nil when none is configured
+var commentChallenge challenge.Verifier
+
+// InitCommentLimits configures comment rate limiting and the anti-spam
+// challenge from cfg.Wiki.Comments
+func InitCommentLimits(cfg *config.Config) {
+	settings := cfg.Wiki.Comments.Challenge
+	verifier, err := challenge.New(settings.Provider, settings.SecretKey, settings.PowDifficulty)
+	if err != nil {
+		log.Printf("Warning: comment challenge disabled: %v", err)
+	}
+	commentChallenge = verifier
+
+	limits := cfg.Wiki.Comments.RateLimit
+	window := time.Duration(limits.WindowSeconds) * time.Second
+	if window <= 0 {
+		window = time.Minute
+	}
+	commentIPLimiter = ratelimit.New(limits.MaxPerIP, window)
+	commentUserLimiter = ratelimit.New(limits.MaxPerUser, window)
+}
+
+// canPostComments reports whether the session meets the configured minimum role
+// and account age for commenting, and why not when it doesn't
+func canPostComments(r *http.Request, session *auth.Session) (bool, string) {
+	cfg := config.Current()
+	if minRole := cfg.Wiki.Comments.MinRole; minRole != "" && !auth.RequireRole(r, minRole) {
+		return false, "Your role is not allowed to post comments"
+	}
+
+	if hours := cfg.Wiki.Comments.MinAccountAgeHours; hours > 0 {
+		for _, user := range cfg.Users {
+			// Accounts created before creation times were recorded are treated as established
+			if user.Username == session.Username && !user.Created.IsZero() &&
+				time.Since(user.Created) < time.Duration(hours)*time.Hour {
+				return false, "Your account is too new to post comments"
+			}
+		}
+	}
+
+	return true, ""
+}
+
+// documentAcceptsComments reports whether a document with content takes
+// comments: not when it has the <!-- no comments --> marker, and otherwise as
+// the comments flag of its front matter says, falling back to the wiki default
+func documentAcceptsComments(content string) bool {
+	cfg := config.Current()
+	if !comments.AreCommentsAllowed(content) {
+		return false
+	}
+	if metadata, _, _ := frontmatter.Parse(content); metadata.Comments != nil {
+		return *metadata.Comments
+	}
+	return !cfg.Wiki.Comments.DisabledByDefault
+}
+
+// maxAnonymousNameLength is the longest name, in characters, an anonymous
+// commenter may give
+const maxAnonymousNameLength = 50
+
+// anonymousCommentMode returns how visitors who are not signed in may comment,
+// as wiki.comments.anonymous sets it, or "" when they can't
+func anonymousCommentMode() string {
+	cfg := config.Current()
+	mode := cfg.Wiki.Comments.Anonymous
+	if mode == "" || mode == config.AnonymousCommentsOff || cfg.Wiki.DisableComments ||
+		cfg.Wiki.AccessMode == config.AccessModeReadOnlyPublic {
+		return ""
+	}
+	return mode
+}
+
+// anonymousCommentAuthor checks the name and email address an anonymous
+// commenter gave against what the anonymous comment mode asks for, and returns
+// the ones to keep. Names are shown escaped, but may not contain markup or
+// control characters, nor be the username of an account.
+func anonymousCommentAuthor(name, email string) (string, string, error) {
+	cfg := config.Current()
+	mode := anonymousCommentMode()
+	if mode == config.AnonymousCommentsAnonymous {
+		return "", "", nil
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", errors.New("A name is required to comment without signing in")
+	}
+	if utf8.RuneCountInString(name) > maxAnonymousNameLength {
+		return "", "", errors.New("Name is too long")
+	}
+	if strings.IndexFunc(name, func(c rune) bool { return unicode.IsControl(c) || c == '<' || c == '>' }) >= 0 {
+		return "", "", errors.New("Name contains characters that are not allowed")
+	}
+	for _, user := range cfg.Users {
+		if strings.EqualFold(user.Username, name) {
+			return "", "", errors.New("That name belongs to an account; sign in to use it")
+		}
+	}
+
+	if mode != config.AnonymousCommentsNameEmail {
+		return name, "", nil
+	}
+	email = strings.TrimSpace(email)
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return "", "", errors.New("A valid email address is required to comment without signing in")
+	}
+	return name, email, nil
+}
+
+// allowCommentRate records a comment attempt against the per-IP and per-user
+// limits; anonymous comments, with an empty username, are only limited per IP.
+// When a limit is exceeded it writes a 429 response and returns false.
+func allowCommentRate(w http.ResponseWriter, r *http.Request, username string) bool {
+	allowed, retryAfter := commentIPLimiter.Allow(clientIP(r))
+	if allowed && username != "" {
+		allowed, retryAfter = commentUserLimiter.Allow(username)
+	}
+	if allowed {
+		return true
+	}
+
+	seconds := int(retryAfter.Seconds()) + 1
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    false,
+		"retryAfter": seconds,
+		"message":    "Too many comments; please wait before posting again",
+	})
+	return false
+}
+
+// challengeRequired reports whether the session must solve a challenge to comment.
+// Editors and admins are trusted and exempt.
+func challengeRequired(r *http.Request) bool {
+	return commentChallenge != nil && !auth.RequireRole(r, roles.RoleEditor)
+}
+
+// CommentChallengeHandler tells the comment form which challenge to present.
+// For proof of work it also issues a fresh challenge to solve.
+func CommentChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":  true,
+		"required": false,
+	}
+	if challengeRequired(r) {
+		response["required"] = true
+		response["provider"] = cfg.Wiki.Comments.Challenge.Provider
+		switch v := commentChallenge.(type) {
+		case *challenge.ProofOfWork:
+			response["challenge"] = v.NewChallenge()
+			response["difficulty"] = v.Difficulty
+		default:
+			response["siteKey"] = cfg.Wiki.Comments.Challenge.SiteKey
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
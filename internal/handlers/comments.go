@@ -10,13 +10,21 @@ import (
 
 	"wiki-go/internal/auth"
 	"wiki-go/internal/comments"
+	"wiki-go/internal/config"
+	"wiki-go/internal/events"
 	"wiki-go/internal/roles"
+	"wiki-go/internal/sanitize"
 	"wiki-go/internal/utils"
 )
 
 // CommentRequest represents the request body for adding a comment
 type CommentRequest struct {
-	Content string `json:"content"`
+	Content        string `json:"content"`
+	ChallengeToken string `json:"challengeToken,omitempty"` // CAPTCHA token or "challenge:nonce" proof of work
+	// Author of an anonymous comment, as wiki.comments.anonymous asks for;
+	// ignored for signed-in users
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
 }
 
 // CommentResponse represents the response for a comment operation
@@ -27,6 +35,8 @@ type CommentResponse struct {
 
 // AddCommentHandler handles requests to add a comment to a document
 func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
 		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
@@ -39,18 +49,6 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is authenticated
-	session := auth.GetSession(r)
-	if session == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Authentication required",
-		})
-		return
-	}
-
 	// Get the document path from the request
 	docPath := strings.TrimPrefix(r.URL.Path, "/api/comments/add/")
 	if docPath == "" {
@@ -61,9 +59,36 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 	// Clean and normalize the path
 	docPath = utils.SanitizePath(docPath)
 
+	// Check if user is authenticated; visitors may comment anonymously on the
+	// documents they can read when the wiki allows it
+	session := auth.GetSession(r)
+	if session == nil {
+		if anonymousCommentMode() == "" || !auth.CanAccessDocument("/"+filepath.ToSlash(docPath), nil, cfg) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "Authentication required",
+			})
+			return
+		}
+	} else {
+		// In read-only-public mode only editors and admins may post
+		if !auth.CanContribute(r, cfg) {
+			sendJSONError(w, "Only editors can make changes on this wiki", http.StatusForbidden, "")
+			return
+		}
+
+		// Check the configured minimum role and account age
+		if ok, reason := canPostComments(r, session); !ok {
+			sendJSONError(w, reason, http.StatusForbidden, "")
+			return
+		}
+	}
+
 	// Check if the document exists
 	documentDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
-	fullDocPath := filepath.Join(documentDir, docPath, "document.md")
+	fullDocPath := utils.DocumentFile(filepath.Join(documentDir, docPath))
 
 	if _, err := os.Stat(fullDocPath); os.IsNotExist(err) {
 		sendJSONError(w, "Document not found", http.StatusNotFound, "")
@@ -78,7 +103,7 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if comments are allowed for this document
-	if !comments.AreCommentsAllowed(string(content)) {
+	if !documentAcceptsComments(string(content)) {
 		sendJSONError(w, "Comments are not allowed for this document", http.StatusForbidden, "")
 		return
 	}
@@ -96,12 +121,44 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check the name and email address of an anonymous commenter
+	var name, email string
+	if session == nil {
+		var err error
+		if name, email, err = anonymousCommentAuthor(req.Name, req.Email); err != nil {
+			sendJSONError(w, err.Error(), http.StatusBadRequest, "")
+			return
+		}
+	}
+
+	// Viewers must pass the anti-spam challenge when one is configured
+	if challengeRequired(r) {
+		if err := commentChallenge.Verify(r.Context(), req.ChallengeToken, clientIP(r)); err != nil {
+			sendJSONError(w, "Challenge verification failed", http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	// Enforce per-IP and per-user posting limits
+	username := ""
+	if session != nil {
+		username = session.Username
+	}
+	if !allowCommentRate(w, r, username) {
+		return
+	}
+
 	// Add the comment
-	err = comments.AddComment(docPath, req.Content, session.Username)
+	if session != nil {
+		err = comments.AddComment(config.CommentsPath(cfg), docPath, req.Content, session.Username)
+	} else {
+		err = comments.AddAnonymousComment(config.CommentsPath(cfg), docPath, req.Content, name, email)
+	}
 	if err != nil {
 		sendJSONError(w, "Failed to add comment", http.StatusInternalServerError, err.Error())
 		return
 	}
+	publish(events.CommentPosted{Path: events.CleanPath(docPath), User: username})
 
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
@@ -113,6 +170,8 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetCommentsHandler handles requests to get comments for a document
 func GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Only allow GET requests
 	if r.Method != http.MethodGet {
 		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
@@ -152,16 +211,22 @@ func GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
 	docPath = utils.SanitizePath(docPath)
 
 	// Get comments for the document
-	commentsList, err := comments.GetComments(docPath)
+	commentsList, err := comments.GetComments(config.CommentsPath(cfg), docPath)
 	if err != nil {
 		sendJSONError(w, "Failed to get comments", http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Process comments for rendering
+	// Process comments for rendering; the email addresses of anonymous
+	// commenters are for admins only
+	isAdmin := auth.RequireRole(r, roles.RoleAdmin)
 	for i := range commentsList {
+		if !isAdmin {
+			commentsList[i].Email = ""
+		}
 		// Render markdown content with template.HTML
-		commentsList[i].RenderedHTML = template.HTML(utils.RenderMarkdown(commentsList[i].Content))
+		// Comments are always sanitized, even when documents are not
+		commentsList[i].RenderedHTML = template.HTML(sanitize.Comment(string(utils.RenderMarkdown(commentsList[i].Content))))
 		// Format timestamp
 		commentsList[i].FormattedTime = comments.FormatCommentTime(commentsList[i].Timestamp)
 	}
@@ -176,6 +241,8 @@ func GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteCommentHandler handles requests to delete a comment
 func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Only allow DELETE requests
 	if r.Method != http.MethodDelete {
 		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
@@ -215,7 +282,7 @@ func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
 	docPath := strings.Join(parts[:len(parts)-1], "/")
 
 	// Delete the comment
-	err := comments.DeleteComment(commentID, docPath, true)
+	err := comments.DeleteComment(config.CommentsPath(cfg), commentID, docPath, true)
 	if err != nil {
 		sendJSONError(w, "Failed to delete comment", http.StatusInternalServerError, err.Error())
 		return
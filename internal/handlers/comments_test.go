@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/comments"
+	"wiki-go/internal/config"
+)
+
+func TestAddAnonymousComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		signedIn   bool
+		body       string
+		want       int
+		wantAuthor string
+	}{
+		{"off", config.AnonymousCommentsOff, false, `{"content":"hi","name":"Ann"}`, http.StatusUnauthorized, ""},
+		{"shown as anonymous", config.AnonymousCommentsAnonymous, false, `{"content":"hi","name":"Ann"}`, http.StatusOK, ""},
+		{"name", config.AnonymousCommentsName, false, `{"content":"hi","name":" Ann "}`, http.StatusOK, "Ann"},
+		{"name missing", config.AnonymousCommentsName, false, `{"content":"hi"}`, http.StatusBadRequest, ""},
+		{"name with markup", config.AnonymousCommentsName, false, `{"content":"hi","name":"<b>Ann</b>"}`, http.StatusBadRequest, ""},
+		{"name of an account", config.AnonymousCommentsName, false, `{"content":"hi","name":"Editor"}`, http.StatusBadRequest, ""},
+		{"name and email", config.AnonymousCommentsNameEmail, false, `{"content":"hi","name":"Ann","email":"ann@example.com"}`, http.StatusOK, "Ann"},
+		{"invalid email", config.AnonymousCommentsNameEmail, false, `{"content":"hi","name":"Ann","email":"Ann <ann@example.com>"}`, http.StatusBadRequest, ""},
+		{"session ignores the name", config.AnonymousCommentsName, true, `{"content":"hi","name":"Ann"}`, http.StatusOK, "editor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+			testCfg.Users = []config.User{{Username: "editor", Role: config.RoleEditor}}
+			testCfg.Wiki.Comments.Anonymous = tt.mode
+			InitCommentLimits(testCfg)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/comments/add/a", strings.NewReader(tt.body))
+			if tt.signedIn {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			AddCommentHandler(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			list, err := comments.GetComments(config.CommentsPath(testCfg), "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != http.StatusOK {
+				if len(list) != 0 {
+					t.Errorf("comment saved: %+v", list)
+				}
+				return
+			}
+			if len(list) != 1 || list[0].Author != tt.wantAuthor || list[0].Anonymous == tt.signedIn {
+				t.Errorf("comments = %+v, want one by %q", list, tt.wantAuthor)
+			}
+		})
+	}
+}
+
+func TestDocumentAcceptsComments(t *testing.T) {
+	tests := []struct {
+		name              string
+		content           string
+		disabledByDefault bool
+		want              bool
+	}{
+		{"default", "# Doc", false, true},
+		{"disabled by default", "# Doc", true, false},
+		{"turned off", "---\ncomments: false\n---\n# Doc", false, false},
+		{"turned on", "---\ncomments: true\n---\n# Doc", true, true},
+		{"marker wins", "---\ncomments: true\n---\n# Doc\n<!-- no comments -->", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t)
+			testCfg.Wiki.Comments.DisabledByDefault = tt.disabledByDefault
+			if got := documentAcceptsComments(tt.content); got != tt.want {
+				t.Errorf("documentAcceptsComments = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Posting to a document that doesn't take comments is forbidden
+	testCfg, cookie := newMoveTestWiki(t, "policy")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "policy", "---\ncomments: false\n---\n# Policy")
+	InitCommentLimits(testCfg)
+	req := httptest.NewRequest(http.MethodPost, "/api/comments/add/policy", strings.NewReader(`{"content":"hi"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	AddCommentHandler(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
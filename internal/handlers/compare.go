@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/diff"
+)
+
+// CompareResponse is the JSON response of the document comparison endpoint
+type CompareResponse struct {
+	Success   bool   `json:"success"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Identical bool   `json:"identical"`
+	Added     int    `json:"added"`   // Lines only in the to document
+	Removed   int    `json:"removed"` // Lines only in the from document
+	Diff      string `json:"diff"`    // Unified diff of the markdown, empty when identical
+}
+
+// comparePath returns the document path of a comparison parameter, relative
+// to the documents directory; "" is the homepage
+func comparePath(raw string) string {
+	p := strings.Trim(path.Clean("/"+raw), "/")
+	if p == homeDocPath {
+		return ""
+	}
+	return p
+}
+
+// CompareDocumentsHandler diffs the markdown of two documents, given by the
+// from and to query parameters, e.g. to find what sets near-identical pages
+// apart before merging them. The user needs read access to both.
+func CompareDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("from") == "" || q.Get("to") == "" {
+		sendJSONError(w, "Both from and to are required", http.StatusBadRequest, "")
+		return
+	}
+	from, to := comparePath(q.Get("from")), comparePath(q.Get("to"))
+
+	context := 3
+	if raw := q.Get("context"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			sendJSONError(w, "context must be a number of lines", http.StatusBadRequest, "")
+			return
+		}
+		context = min(n, 100)
+	}
+
+	session := auth.GetSession(r)
+	contents := make([]string, 2)
+	for i, docPath := range []string{from, to} {
+		if !auth.CanAccessDocument("/"+docPath, session, cfg) {
+			if session == nil {
+				sendJSONError(w, "Authentication required", http.StatusUnauthorized, "")
+				return
+			}
+			sendJSONError(w, "Access denied", http.StatusForbidden, "")
+			return
+		}
+		file, _ := documentFile(docPath, "")
+		content, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				sendJSONError(w, "Document not found", http.StatusNotFound, "/"+docPath)
+				return
+			}
+			sendJSONError(w, "Failed to read document", http.StatusInternalServerError, "")
+			return
+		}
+		contents[i] = string(content)
+	}
+
+	edits := diff.Lines(diff.SplitLines(contents[0]), diff.SplitLines(contents[1]))
+	resp := CompareResponse{Success: true, From: "/" + from, To: "/" + to}
+	for _, e := range edits {
+		switch e.Op {
+		case diff.Insert:
+			resp.Added++
+		case diff.Delete:
+			resp.Removed++
+		}
+	}
+	resp.Identical = resp.Added == 0 && resp.Removed == 0
+	resp.Diff = diff.Unified(resp.From, resp.To, edits, context)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestCompareDocuments(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "setup", "setup-copy", "secret")
+	root := testCfg.Wiki.RootDir
+	writeTestDocument(t, root, "setup", "# Setup\n\nInstall the compiler.\nRun the tests.\n")
+	writeTestDocument(t, root, "setup-copy", "# Setup\n\nInstall the toolchain.\nRun the tests.\n")
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/secret", Access: "restricted", Groups: []string{"staff"}}}
+
+	tests := []struct {
+		name      string
+		query     string
+		signedIn  bool
+		wantCode  int
+		wantDiff  string
+		identical bool
+	}{
+		{"diff", "from=/setup&to=setup-copy", false, http.StatusOK,
+			"--- /setup\n+++ /setup-copy\n@@ -1,4 +1,4 @@\n # Setup\n \n-Install the compiler.\n+Install the toolchain.\n Run the tests.\n", false},
+		{"identical", "from=setup&to=setup/&context=0", false, http.StatusOK, "", true},
+		{"missing document", "from=setup&to=nope", false, http.StatusNotFound, "", false},
+		{"no access signed out", "from=setup&to=secret", false, http.StatusUnauthorized, "", false},
+		{"no access signed in", "from=secret&to=setup", true, http.StatusForbidden, "", false},
+		{"path escaping the wiki", "from=setup&to=../../etc/passwd", false, http.StatusNotFound, "", false},
+		{"missing parameter", "from=setup", false, http.StatusBadRequest, "", false},
+		{"bad context", "from=setup&to=setup&context=-1", false, http.StatusBadRequest, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/compare?"+tt.query, nil)
+			if tt.signedIn {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			CompareDocumentsHandler(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var resp CompareResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Diff != tt.wantDiff || resp.Identical != tt.identical {
+				t.Errorf("diff = %q (identical %t), want %q (identical %t)", resp.Diff, resp.Identical, tt.wantDiff, tt.identical)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/events"
+	"wiki-go/internal/roles"
+	"wiki-go/internal/utils"
+)
+
+// documentETag returns the strong ETag of a document's markdown
+func documentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// documentAPIPath returns the document path of a /api/document/ request,
+// relative to the documents directory; "" is the homepage
+func documentAPIPath(r *http.Request) string {
+	p := cleanPath(strings.TrimPrefix(r.URL.Path, "/api/document"))
+	if p == homeDocPath {
+		return ""
+	}
+	return p
+}
+
+// GetDocumentContentHandler returns the raw markdown of a document, front
+// matter included, with an ETag to send back in If-Match when replacing it.
+// The optional ?lang= reads a translation instead. HEAD requests get the same
+// headers, among them the title in X-Document-Title, without the markdown, so
+// clients can check that a document exists and whether it changed cheaply.
+func GetDocumentContentHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	docPath := documentAPIPath(r)
+	session := auth.GetSession(r)
+	if !auth.CanAccessDocument("/"+docPath, session, cfg) {
+		if session == nil {
+			sendJSONError(w, "Authentication required", http.StatusUnauthorized, "")
+			return
+		}
+		sendJSONError(w, "Access denied", http.StatusForbidden, "")
+		return
+	}
+
+	lang, ok := translationParam(r)
+	if !ok {
+		sendJSONError(w, "Invalid language", http.StatusBadRequest, "")
+		return
+	}
+	file, _ := documentFile(docPath, lang)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			sendJSONError(w, "Document not found", http.StatusNotFound, "")
+			return
+		}
+		sendJSONError(w, "Failed to read document", http.StatusInternalServerError, "")
+		return
+	}
+
+	etag := documentETag(content)
+	edited, _ := utils.LastEdit(file, versionsDir("/"+docPath, lang))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !edited.IsZero() {
+		w.Header().Set("Last-Modified", edited.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, edited) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if cfg.Wiki.ReadingSpeed > 0 {
+		words, minutes := utils.DocumentReadingTime(string(content), cfg.Wiki.ReadingSpeed)
+		w.Header().Set("X-Word-Count", strconv.Itoa(words))
+		w.Header().Set("X-Reading-Time", strconv.Itoa(minutes))
+	}
+	// Titles may be in any language; headers only carry ASCII reliably
+	w.Header().Set("X-Document-Title", url.PathEscape(utils.GetDocumentTitle(filepath.Dir(file))))
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(content)
+}
+
+// PutDocumentContentHandler replaces the raw markdown of a document, or
+// creates it, keeping the previous content as a version. Paths of new
+// documents must already be slugs. If-Match makes the write conditional on the
+// document being unchanged since it was read, and If-None-Match: * on it not
+// existing yet; either fails with 412 Precondition Failed. The optional
+// ?message= describes the change and ?lang= writes a translation.
+func PutDocumentContentHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	session := auth.GetSession(r)
+	if session == nil || (session.Role != roles.RoleAdmin && session.Role != roles.RoleEditor) {
+		sendJSONError(w, "Unauthorized. Admin or editor access required.", http.StatusUnauthorized, "")
+		return
+	}
+
+	docPath := documentAPIPath(r)
+	if docPath != "" && docPath != utils.SanitizePath(docPath) {
+		sendJSONError(w, "Invalid document path", http.StatusBadRequest, "Paths may only contain letters, digits, '-', '_' and '/'")
+		return
+	}
+	if !auth.CanAccessDocument("/"+docPath, session, cfg) {
+		sendJSONError(w, "Access denied", http.StatusForbidden, "")
+		return
+	}
+	lang, ok := translationParam(r)
+	if !ok {
+		sendJSONError(w, "Invalid language", http.StatusBadRequest, "")
+		return
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.GetMaxUploadSizeBytes(cfg)))
+	if err != nil {
+		sendJSONError(w, "Document too large", http.StatusRequestEntityTooLarge, "Maximum size is "+config.GetMaxUploadSizeFormatted(cfg))
+		return
+	}
+	if err := checkDocumentSize(content); err != nil {
+		sendJSONError(w, "Document too large", http.StatusRequestEntityTooLarge, "Maximum size is "+maxDocumentSizeText())
+		return
+	}
+
+	file, relativePath := documentFile(docPath, lang)
+	unlock := doclock.Lock(file)
+	defer unlock()
+
+	current, err := os.ReadFile(file)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		sendJSONError(w, "Failed to read document", http.StatusInternalServerError, "")
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && (!exists || !matchesETag(ifMatch, documentETag(current))) {
+		if exists {
+			w.Header().Set("ETag", documentETag(current))
+		}
+		sendJSONError(w, "Document was changed", http.StatusPreconditionFailed, "The document does not match If-Match; read it again before saving")
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && exists {
+		w.Header().Set("ETag", documentETag(current))
+		sendJSONError(w, "Document already exists", http.StatusPreconditionFailed, "")
+		return
+	}
+	if reserved, ok := reservedPath(docPath, cfg); ok && !exists {
+		sendJSONError(w, fmt.Sprintf("The path %q is reserved", reserved), http.StatusBadRequest, "Documents can't be created at or below a reserved path")
+		return
+	}
+
+	message := strings.TrimSpace(r.URL.Query().Get("message"))
+	if err := writeDocumentRevision(file, relativePath, content, session.Username, message); err != nil {
+		log.Printf("Error saving document %s: %v", file, err)
+		sendJSONError(w, "Failed to save document", http.StatusInternalServerError, "")
+		return
+	}
+	status, result := http.StatusOK, "Document saved successfully"
+	if !exists {
+		status, result = http.StatusCreated, "Document created successfully"
+		publish(events.DocumentCreated{Path: events.CleanPath(docPath), User: session.Username})
+	} else {
+		publish(events.DocumentEdited{Path: events.CleanPath(docPath), User: session.Username})
+	}
+	response := map[string]interface{}{
+		"success": true,
+		"message": result,
+	}
+	if warning := documentSizeWarning(content); warning != "" {
+		response["warning"] = warning
+	}
+	w.Header().Set("ETag", documentETag(content))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocumentContentRoundTrip(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides")
+	testCfg.Wiki.MaxVersions = 10
+
+	req := httptest.NewRequest(http.MethodGet, "/api/document/guides", nil)
+	rec := httptest.NewRecorder()
+	DocumentHandler(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "# guides" {
+		t.Fatalf("GET = %d %q", rec.Code, rec.Body.String())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET sent no ETag")
+	}
+
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/document/guides", strings.NewReader(body))
+		req.AddCookie(cookie)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		DocumentHandler(rec, req)
+		return rec
+	}
+
+	if rec := put(etag, "# Guides\n\nFirst"); rec.Code != http.StatusOK {
+		t.Fatalf("PUT with current ETag = %d (%s)", rec.Code, rec.Body.String())
+	}
+	// The ETag read before the first write is stale now
+	rec = put(etag, "# Guides\n\nSecond")
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PUT with stale ETag = %d, want %d", rec.Code, http.StatusPreconditionFailed)
+	}
+	if got := rec.Header().Get("ETag"); got != documentETag([]byte("# Guides\n\nFirst")) {
+		t.Errorf("412 ETag = %q, want the current document's", got)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(testCfg.Wiki.RootDir, "documents", "guides", "document.md"))
+	if string(content) != "# Guides\n\nFirst" {
+		t.Errorf("document = %q", content)
+	}
+	versions, _ := filepath.Glob(filepath.Join(testCfg.Wiki.RootDir, "versions", "documents", "guides", "*.md"))
+	if len(versions) != 1 {
+		t.Errorf("versions = %q, want the original content kept", versions)
+	}
+}
+
+func TestPutDocumentContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		status int
+	}{
+		{"create", "/api/document/guides/setup", "", "", http.StatusCreated},
+		{"create only if absent", "/api/document/guides", "If-None-Match", "*", http.StatusPreconditionFailed},
+		{"if-match on missing document", "/api/document/guides/setup", "If-Match", `"abc"`, http.StatusPreconditionFailed},
+		{"not a slug", "/api/document/guides/Set%20up", "", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cookie := newMoveTestWiki(t, "guides")
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader("# Setup"))
+			req.AddCookie(cookie)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			DocumentHandler(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPutDocumentContentRequiresEditor(t *testing.T) {
+	newMoveTestWiki(t, "guides")
+	req := httptest.NewRequest(http.MethodPut, "/api/document/guides", strings.NewReader("# Changed"))
+	rec := httptest.NewRecorder()
+	DocumentHandler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHeadDocument(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "guides")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "guides", "# Guides & Tips\n\nBody")
+
+	get := httptest.NewRecorder()
+	DocumentHandler(get, httptest.NewRequest(http.MethodGet, "/api/document/guides", nil))
+
+	tests := []struct {
+		name  string
+		path  string
+		match string
+		want  int
+	}{
+		{"document", "/api/document/guides", "", http.StatusOK},
+		{"unchanged", "/api/document/guides", get.Header().Get("ETag"), http.StatusNotModified},
+		{"missing", "/api/document/missing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodHead, tt.path, nil)
+			if tt.match != "" {
+				req.Header.Set("If-None-Match", tt.match)
+			}
+			rec := httptest.NewRecorder()
+			DocumentHandler(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("HEAD sent a body: %q", rec.Body.String())
+			}
+			for _, header := range []string{"ETag", "Last-Modified", "Content-Length", "X-Document-Title"} {
+				if got, want := rec.Header().Get(header), get.Header().Get(header); got == "" || got != want {
+					t.Errorf("%s = %q, want %q as for GET", header, got, want)
+				}
+			}
+			if got := rec.Header().Get("X-Document-Title"); got != "Guides%20&%20Tips" {
+				t.Errorf("X-Document-Title = %q", got)
+			}
+		})
+	}
+}
+
+// If-Match and If-None-Match share the list matching of matchesETag
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		list string
+		want bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{`"ab"`, false},
+	}
+	for _, tt := range tests {
+		if got := matchesETag(tt.list, `"abc"`); got != tt.want {
+			t.Errorf("matchesETag(%q) = %t, want %t", tt.list, got, tt.want)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", tt.list)
+		if got := notModified(req, `W/"abc"`, time.Time{}); got != tt.want {
+			t.Errorf("notModified with If-None-Match %q = %t, want %t", tt.list, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"wiki-go/internal/config"
+)
+
+// documentKey returns docPath, relative to the documents directory, in the one
+// spelling the data kept beside documents is stored under: slash-separated and
+// cleaned, without leading or trailing slashes
+func documentKey(docPath string) string {
+	return strings.Trim(path.Clean("/"+filepath.ToSlash(docPath)), "/")
+}
+
+// documentDataDirs returns the directories holding the version history and the
+// comments of the document at docPath, relative to the documents directory.
+// Both are derived from the same documentKey, so a document's versions and
+// comments are always moved, trashed and deleted together. The version history
+// sets documents apart from the homepage in a "documents" directory; comments,
+// which only documents have, don't need to. An empty docPath returns the
+// directories holding the data of every document.
+func documentDataDirs(cfg *config.Config, docPath string) (versions, comments string) {
+	key := filepath.FromSlash(documentKey(docPath))
+	return filepath.Join(config.VersionsPath(cfg), "documents", key), filepath.Join(config.CommentsPath(cfg), key)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestDocumentDataDirs(t *testing.T) {
+	testCfg := &config.Config{}
+	testCfg.Wiki.RootDir = "root"
+	testCfg.Wiki.CommentsDir = "discussion"
+
+	tests := []struct {
+		name     string
+		docPath  string
+		versions string
+		comments string
+	}{
+		{"plain", "guides/setup", "root/versions/documents/guides/setup", "root/discussion/guides/setup"},
+		{"leading and trailing slashes", "/guides/setup/", "root/versions/documents/guides/setup", "root/discussion/guides/setup"},
+		{"unclean", "guides/./x/../setup", "root/versions/documents/guides/setup", "root/discussion/guides/setup"},
+		{"below documents", "documents/setup", "root/versions/documents/documents/setup", "root/discussion/documents/setup"},
+		{"below pages", "pages/setup", "root/versions/documents/pages/setup", "root/discussion/pages/setup"},
+		{"escaping", "../../setup", "root/versions/documents/setup", "root/discussion/setup"},
+		{"every document", "", "root/versions/documents", "root/discussion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, comments := documentDataDirs(testCfg, tt.docPath)
+			if versions != filepath.FromSlash(tt.versions) {
+				t.Errorf("versions = %s, want %s", versions, tt.versions)
+			}
+			if comments != filepath.FromSlash(tt.comments) {
+				t.Errorf("comments = %s, want %s", comments, tt.comments)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"wiki-go/internal/config"
+)
+
+// errDocumentTooLarge is returned by readDocumentBody for content larger than
+// wiki.max_document_size
+var errDocumentTooLarge = errors.New("document too large")
+
+// maxDocumentBytes returns the most bytes a document's content may have, or 0
+// when wiki.max_document_size leaves it unlimited
+func maxDocumentBytes() int64 {
+	cfg := config.Current()
+	return int64(cfg.Wiki.MaxDocumentSize) * 1024
+}
+
+// readDocumentBody reads the content of a document from the request body,
+// failing with errDocumentTooLarge once it exceeds wiki.max_document_size.
+// Reading stops there, so a huge body isn't held in memory.
+func readDocumentBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body := r.Body
+	if limit := maxDocumentBytes(); limit > 0 {
+		body = http.MaxBytesReader(w, body, limit)
+	}
+	content, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, errDocumentTooLarge
+	}
+	return content, err
+}
+
+// checkDocumentSize returns errDocumentTooLarge for content larger than
+// wiki.max_document_size, for content that doesn't come from a request body
+func checkDocumentSize(content []byte) error {
+	if limit := maxDocumentBytes(); limit > 0 && int64(len(content)) > limit {
+		return errDocumentTooLarge
+	}
+	return nil
+}
+
+// maxDocumentSizeText formats wiki.max_document_size for error messages
+func maxDocumentSizeText() string {
+	cfg := config.Current()
+	return formatKB(cfg.Wiki.MaxDocumentSize)
+}
+
+// documentSizeWarning returns a warning for content larger than
+// wiki.document_size_warning, which is saved all the same, or ""
+func documentSizeWarning(content []byte) string {
+	cfg := config.Current()
+	warnAt := cfg.Wiki.DocumentSizeWarning
+	if warnAt <= 0 || len(content) <= warnAt*1024 {
+		return ""
+	}
+	return fmt.Sprintf("This document is %s, more than the recommended %s; consider splitting it, as large documents are slow to render and search",
+		formatKB((len(content)+1023)/1024), formatKB(warnAt))
+}
+
+// formatKB formats a size in KB, in MB when it is a whole number of them
+func formatKB(kb int) string {
+	if kb >= 1024 && kb%1024 == 0 {
+		return fmt.Sprintf("%dMB", kb/1024)
+	}
+	return fmt.Sprintf("%dKB", kb)
+}
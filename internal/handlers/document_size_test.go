@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveHandlerDocumentSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int // Bytes of content saved
+		status  int
+		warning bool
+	}{
+		{"small", 512, http.StatusOK, false},
+		{"above the warning", 1536, http.StatusOK, true},
+		{"at the limit", 2048, http.StatusOK, true},
+		{"above the limit", 2049, http.StatusRequestEntityTooLarge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "guides")
+			testCfg.Wiki.MaxDocumentSize = 2
+			testCfg.Wiki.DocumentSizeWarning = 1
+
+			content := strings.Repeat("a", tt.size)
+			req := httptest.NewRequest(http.MethodPost, "/api/save/guides", strings.NewReader(content))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			SaveHandler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			var response struct {
+				Message string `json:"message"`
+				Warning string `json:"warning"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if (response.Warning != "") != tt.warning {
+				t.Errorf("warning = %q, want one: %v", response.Warning, tt.warning)
+			}
+
+			saved, _ := os.ReadFile(filepath.Join(testCfg.Wiki.RootDir, "documents", "guides", "document.md"))
+			if tt.status == http.StatusOK && string(saved) != content {
+				t.Errorf("document has %d bytes, want %d", len(saved), tt.size)
+			}
+			if tt.status == http.StatusRequestEntityTooLarge {
+				if string(saved) != "# guides" {
+					t.Errorf("document = %q, want it unchanged", saved)
+				}
+				if !strings.Contains(response.Message, "2KB") {
+					t.Errorf("message = %q, want the limit", response.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestPutDocumentContentTooLarge(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides")
+	testCfg.Wiki.MaxDocumentSize = 1
+
+	req := httptest.NewRequest(http.MethodPut, "/api/document/guides", strings.NewReader(strings.Repeat("a", 1025)))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	DocumentHandler(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusRequestEntityTooLarge, rec.Body.String())
+	}
+}
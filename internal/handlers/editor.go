@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -11,6 +11,9 @@ import (
 	"strings"
 	"time"
 	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/events"
 	"wiki-go/internal/i18n"
 	"wiki-go/internal/roles"
 	"wiki-go/internal/utils"
@@ -18,6 +21,8 @@ import (
 
 // SourceHandler handles requests to get the raw markdown content of a page
 func SourceHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Add cache control headers to prevent caching
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
@@ -47,14 +52,25 @@ func SourceHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the path from the URL, removing the /api/source prefix
 	path := strings.TrimPrefix(r.URL.Path, "/api/source")
 
+	// Optional ?lang= selects a translation instead of document.md
+	lang, ok := translationParam(r)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid language",
+		})
+		return
+	}
+
 	var docPath string
 	var dirPath string
 
 	// Special case for homepage (root path)
 	if path == "" || path == "/" {
 		// For the homepage, we use the pages directory
-		docPath = filepath.Join(cfg.Wiki.RootDir, "pages", "home", "document.md")
-		dirPath = filepath.Join(cfg.Wiki.RootDir, "pages", "home")
+		dirPath = homePageDir(cfg)
+		docPath, lang = translationPath(dirPath, lang)
 	} else {
 		// Clean and normalize the path
 		path = filepath.Clean(path)
@@ -63,7 +79,12 @@ func SourceHandler(w http.ResponseWriter, r *http.Request) {
 
 		// Get the full filesystem path, adding the documents subdirectory
 		dirPath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path)
-		docPath = filepath.Join(dirPath, "document.md")
+		docPath, lang = translationPath(dirPath, lang)
+	}
+
+	// A new translation starts from the default document
+	if lang != "" && !utils.TranslationExists(dirPath, lang) {
+		docPath = utils.DocumentFile(dirPath)
 	}
 
 	// Read the markdown file
@@ -139,14 +160,78 @@ func SaveHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the path from the URL, removing the /api/save prefix
 	path := strings.TrimPrefix(r.URL.Path, "/api/save")
 
-	var docPath string
-	var relativePath string // To store path relative to the documents dir
+	// Optional ?lang= saves a translation instead of document.md
+	lang, ok := translationParam(r)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid language",
+		})
+		return
+	}
+
+	docPath, relativePath := documentFile(path, lang)
+
+	// Read the request body (new content)
+	content, err := readDocumentBody(w, r)
+	if errors.Is(err, errDocumentTooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Document too large. Maximum size is " + maxDocumentSizeText() + ".",
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to read request body",
+		})
+		return
+	}
+	defer r.Body.Close()
+
+	// Concurrent saves and task toggles of the same document are serialized
+	unlock := doclock.Lock(docPath)
+	defer unlock()
+
+	message := strings.TrimSpace(r.URL.Query().Get("message"))
+	if err := writeDocumentRevision(docPath, relativePath, content, session.Username, message); err != nil {
+		log.Printf("Error saving document %s: %v", docPath, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to save document",
+		})
+		return
+	}
+
+	// The title may have changed
+	publish(events.DocumentEdited{Path: events.CleanPath(path), User: session.Username})
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Document saved successfully",
+	}
+	if warning := documentSizeWarning(content); warning != "" {
+		response["warning"] = warning
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// documentFile resolves the URL path of a document, with an optional translation
+// lang, to the markdown file to edit and the versions path relative to the wiki root
+func documentFile(path, lang string) (docPath, relativePath string) {
+	cfg := config.Current()
 
 	// Special case for homepage (root path)
 	if path == "" || path == "/" {
 		// For the homepage, we use the pages directory
-		docPath = filepath.Join(cfg.Wiki.RootDir, "pages", "home", "document.md")
-		relativePath = "pages/home"
+		docPath, lang = translationPath(homePageDir(cfg), lang)
+		relativePath = homePagePath(cfg)
 	} else {
 		// Clean and normalize the path
 		path = filepath.Clean(path)
@@ -157,33 +242,30 @@ func SaveHandler(w http.ResponseWriter, r *http.Request) {
 		relativePath = "documents/" + path
 
 		// Get the full filesystem path, adding the documents subdirectory
-		docPath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path, "document.md")
+		docPath, lang = translationPath(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path), lang)
 	}
 
-	// Read the request body (new content)
-	content, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Failed to read request body",
-		})
-		return
+	// Translations keep their own history next to the document's versions
+	if lang != "" {
+		relativePath += "/" + strings.TrimSuffix(utils.TranslationFileName(lang), ".md")
 	}
-	defer r.Body.Close()
+	return docPath, relativePath
+}
+
+// writeDocumentRevision writes content to docPath, first snapshotting the current
+// content into versions/<relativePath> and afterwards recording who made the new
+// revision and why. Callers hold the document's doclock.
+func writeDocumentRevision(docPath, relativePath string, content []byte, author, message string) error {
+	cfg := config.Current()
+	versionDir := filepath.Join(config.VersionsPath(cfg), relativePath)
 
 	// VERSION CONTROL: Save current version before overwriting
-	// Check if the document already exists
-	if _, err := os.Stat(docPath); err == nil && cfg.Wiki.MaxVersions > 0 {
-		// Document exists, read its current content
+	if cfg.Wiki.MaxVersions > 0 {
 		currentContent, err := os.ReadFile(docPath)
 		if err == nil && len(currentContent) > 0 {
 			// Create timestamp for version filename
 			timestamp := time.Now().Format("20060102150405") // Format: yyyymmddhhmmss
 
-			// Create versions directory path that mirrors the document path
-			versionDir := filepath.Join(cfg.Wiki.RootDir, "versions", relativePath)
-
 			// Ensure versions directory exists
 			if err := os.MkdirAll(versionDir, 0755); err == nil {
 				// Create version file path with timestamp
@@ -192,6 +274,9 @@ func SaveHandler(w http.ResponseWriter, r *http.Request) {
 				// Save the current content as a version
 				_ = os.WriteFile(versionPath, currentContent, 0644) // Ignore error for now
 
+				// The metadata of the content we just archived moves with it
+				utils.ArchiveCurrentMeta(versionDir, timestamp)
+
 				// Log the versioning
 				log.Printf("Created version: %s", versionPath)
 
@@ -202,31 +287,27 @@ func SaveHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create directory if it doesn't exist
-	dir := filepath.Dir(docPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Failed to create directory",
-		})
-		return
+	if err := os.MkdirAll(filepath.Dir(docPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	// Write the content to the file
-	if err := os.WriteFile(docPath, content, 0644); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Failed to save document",
-		})
-		return
+	if err := doclock.WriteFile(docPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
 	}
+	indexSearchFile(docPath)
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Document saved successfully",
-	})
+	if cfg.Wiki.MaxVersions > 0 {
+		meta := utils.VersionMeta{
+			Author:  author,
+			Message: message,
+			Time:    time.Now().Format("20060102150405"),
+		}
+		if err := utils.WriteCurrentMeta(versionDir, meta); err != nil {
+			log.Printf("Warning: Failed to write revision metadata: %v", err)
+		}
+	}
+	return nil
 }
 
 // CreateDocumentRequest represents the JSON payload for creating a new document
@@ -250,6 +331,8 @@ type ErrorResponse struct {
 
 // CreateDocumentHandler handles the API endpoint for creating new documents
 func CreateDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
 		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
@@ -292,6 +375,10 @@ func CreateDocumentHandler(w http.ResponseWriter, r *http.Request) {
 		sendJSONError(w, "Invalid path after sanitization", http.StatusBadRequest, "")
 		return
 	}
+	if reserved, ok := reservedPath(cleanPath, cfg); ok {
+		sendJSONError(w, fmt.Sprintf("The path %q is reserved", reserved), http.StatusBadRequest, "Documents can't be created at or below a reserved path")
+		return
+	}
 
 	log.Printf("Creating document: Title=%s, Path=%s, CleanPath=%s", req.Title, req.Path, cleanPath)
 
@@ -316,8 +403,12 @@ func CreateDocumentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create the document.md file inside the directory
-	docFile := filepath.Join(fullPath, "document.md")
+	// Create the document's content file inside the directory
+	docFile := utils.DocumentFile(fullPath)
+
+	// Another request creating or moving a document to the same path must wait
+	unlock := doclock.Lock(docFile)
+	defer unlock()
 
 	// Check if file already exists
 	if _, err := os.Stat(docFile); err == nil {
@@ -346,13 +437,16 @@ func CreateDocumentHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Write to the file
-	err = os.WriteFile(docFile, []byte(content), 0644)
+	err = doclock.WriteFile(docFile, []byte(content), 0644)
 	if err != nil {
 		log.Printf("Error creating document: %v", err)
 		sendJSONError(w, "Failed to create document", http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// New titles must be resolvable by [[WikiLinks]] right away
+	publish(events.DocumentCreated{Path: cleanPath, User: session.Username})
+
 	// Return success
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]interface{}{
@@ -382,20 +476,15 @@ func sendJSONError(w http.ResponseWriter, message string, statusCode int, errorD
 	log.Printf("Error response: %s (%d) - %s", message, statusCode, errorDetails)
 }
 
-// DocumentHandler is a combined handler for document operations (GET, DELETE)
+// DocumentHandler is a combined handler for document operations (GET, PUT, DELETE)
 func DocumentHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodDelete:
 		DeleteDocumentHandler(w, r)
-	case http.MethodGet:
-		// For now just return the document path
-		docPath := strings.TrimPrefix(r.URL.Path, "/api/document")
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"path":    docPath,
-			"message": "Document retrieval not yet implemented",
-		})
+	case http.MethodGet, http.MethodHead:
+		GetDocumentContentHandler(w, r)
+	case http.MethodPut:
+		PutDocumentContentHandler(w, r)
 	default:
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -408,6 +497,8 @@ func DocumentHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteDocumentHandler handles requests to delete a document
 func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Only process DELETE requests
 	if r.Method != http.MethodDelete {
 		w.Header().Set("Content-Type", "application/json")
@@ -445,6 +536,16 @@ func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 	documentDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
 	fullPath := filepath.Join(documentDir, docPath)
 
+	// Neither can a document chosen as the homepage with wiki.home_page
+	if home := homePageDocument(cfg); home != "" && strings.Trim(filepath.ToSlash(docPath), "/") == home {
+		sendJSONError(w, "Cannot delete homepage", http.StatusBadRequest, "The homepage cannot be deleted")
+		return
+	}
+
+	// A save of the document in progress finishes before it is deleted
+	unlock := doclock.Lock(utils.DocumentFile(fullPath))
+	defer unlock()
+
 	// Check if file exists
 	fileInfo, err := os.Stat(fullPath)
 	if err != nil {
@@ -461,6 +562,29 @@ func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Documents go to the trash, with their versions and comments, unless it is disabled
+	if cfg.Wiki.Trash.Enabled && fileInfo.IsDir() {
+		entry, err := moveToTrash(docPath, session.Username)
+		if entry == nil {
+			sendJSONError(w, "Error deleting document", http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		log.Printf("Moved %s to the trash as %s", fullPath, entry.ID)
+		publish(events.DocumentDeleted{Path: events.CleanPath(docPath), User: session.Username})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Document moved to trash",
+			"trashId": entry.ID,
+		})
+		return
+	}
+
 	// Delete the file or directory recursively
 	if fileInfo.IsDir() {
 		// Use RemoveAll to recursively delete the directory and all its contents
@@ -478,22 +602,9 @@ func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Deleted file: %s", fullPath)
 	}
 
-	// Also delete the corresponding versions directory
-	var versionsPath string
-	if docPath == "pages/home" {
-		// For homepage, use the new path
-		versionsPath = filepath.Join(cfg.Wiki.RootDir, "versions", "pages", "home")
-	} else if strings.HasPrefix(docPath, "documents/") {
-		// Path already includes "documents/" prefix
-		versionsPath = filepath.Join(cfg.Wiki.RootDir, "versions", docPath)
-	} else {
-		// Add "documents/" prefix for regular documents
-		versionsPath = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", docPath)
-		if strings.HasSuffix(fullPath, ".md") {
-			// If we're deleting a .md file, remove the .md extension from the versions path
-			versionsPath = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", strings.TrimSuffix(docPath, ".md"))
-		}
-	}
+	// Also delete the corresponding versions and comments directories; those of
+	// a deleted .md file are named without the extension
+	versionsPath, commentsPath := documentDataDirs(cfg, strings.TrimSuffix(docPath, ".md"))
 
 	// Check if versions directory exists before attempting to delete
 	if _, err := os.Stat(versionsPath); err == nil {
@@ -505,9 +616,6 @@ func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Also delete the corresponding comments directory
-	commentsPath := filepath.Join(cfg.Wiki.RootDir, "comments", docPath)
-
 	// Check if comments directory exists before attempting to delete
 	if _, err := os.Stat(commentsPath); err == nil {
 		if err := os.RemoveAll(commentsPath); err != nil {
@@ -518,6 +626,8 @@ func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	publish(events.DocumentDeleted{Path: events.CleanPath(docPath), User: session.Username})
+
 	// Return success response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -9,6 +9,7 @@ import (
 
     "wiki-go/internal/auth"
     "wiki-go/internal/config"
+    "wiki-go/internal/csp"
     "wiki-go/internal/i18n"
     "wiki-go/internal/types"
     "wiki-go/internal/utils"
@@ -29,7 +30,7 @@ func NotFoundHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config)
     }
 
     // Navigation tree
-    nav, err := utils.BuildNavigation(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+    nav, err := utils.BuildNavigation(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, config.VersionsPath(cfg))
     if err != nil {
         http.Error(w, "Error building navigation: "+err.Error(), http.StatusInternalServerError)
         return
@@ -64,6 +65,7 @@ func NotFoundHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config)
         IsAuthenticated:    isAuthenticated,
         UserRole:           userRole,
         LastModified:       time.Now(),
+        CSPNonce:           csp.Nonce(r),
     }
 
     // Render the not-found specific template fragment into .Content
@@ -80,5 +82,5 @@ func NotFoundHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config)
     data.Content = template.HTML(buf.String())
 
     // Render full page using the standard renderer (base.html + data)
-    renderTemplate(w, data)
+    renderTemplate(w, r, data)
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"log"
+
+	"wiki-go/internal/events"
+)
+
+// eventBus carries content events from the handlers to the package state
+// derived from the documents, which subscribes to it in subscribeEvents
+var eventBus = subscribeEvents(events.NewBus())
+
+// publish announces a change to the wiki's content on the event bus
+func publish(e events.Event) {
+	eventBus.Publish(e)
+}
+
+// subscribeEvents registers the subscribers that keep caches, counters and
+// indexes in step with the documents, and returns bus
+func subscribeEvents(bus *events.Bus) *events.Bus {
+	// Titles resolved by [[WikiLinks]] and rendered HTML go stale with any change
+	events.Subscribe(bus, func(e events.DocumentCreated) {
+		invalidateWikiLinkIndex()
+	})
+	events.Subscribe(bus, func(e events.DocumentEdited) {
+		invalidateWikiLinkIndex()
+		renderCache.Invalidate(e.Path)
+	})
+	events.Subscribe(bus, func(e events.DocumentDeleted) {
+		invalidateWikiLinkIndex()
+		renderCache.Invalidate(e.Path)
+	})
+
+	// Real-time editing sessions continue from what was saved, and end when
+	// their document goes away
+	events.Subscribe(bus, func(e events.DocumentEdited) {
+		collabHub.Saved(e.Path, e.User)
+	})
+	events.Subscribe(bus, func(e events.DocumentDeleted) {
+		collabHub.Close(e.Path, "document deleted")
+	})
+
+	events.Subscribe(bus, func(e events.DocumentMoved) {
+		// Rendered HTML depends on the document path, e.g. for attachment links
+		renderCache.Invalidate(e.Old)
+
+		// Carry view counts over to the new location
+		if viewCounts != nil {
+			viewCounts.Move(e.Old, e.New)
+		}
+
+		// Keep the favorites of the document and those below it
+		if err := favoriteList().Move(e.Old, e.New); err != nil {
+			log.Printf("Warning: Failed to move favorites from %s to %s: %v", e.Old, e.New, err)
+		}
+
+		// Search the documents under their new paths
+		moveSearchIndex(e.Old, e.New)
+
+		collabHub.Close(e.Old, "document moved")
+	})
+	return bus
+}
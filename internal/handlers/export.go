@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/docx"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/utils"
+
+	"github.com/yuin/goldmark/text"
+)
+
+// docxContentType is the MIME type of Word documents
+const docxContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// ExportDocxHandler converts a document to a Word file and sends it as a download.
+// URL format: /api/export/docx/{document-path}; an empty path exports the homepage.
+func ExportDocxHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/export/docx")
+	path = strings.Trim(strings.ReplaceAll(path, "\\", "/"), "/")
+	if strings.Contains(path, "..") {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	// Respect the same access rules as viewing the page
+	logicalPath := "/" + path
+	session := auth.GetSession(r)
+	if !auth.CanAccessDocument(logicalPath, session, cfg) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var dirPath string
+	var filesPrefix string // Prefix of this document's attachments under /api/files/
+	if path == "" {
+		dirPath = homePageDir(cfg)
+		filesPrefix = homeDocPath
+	} else {
+		dirPath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path)
+		filesPrefix = path
+	}
+
+	content, err := os.ReadFile(utils.DocumentFile(dirPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "Document not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to read document", http.StatusInternalServerError)
+		return
+	}
+
+	// Parse with the same Goldmark configuration used for HTML rendering
+	if _, body, hasFrontmatter := frontmatter.Parse(string(content)); hasFrontmatter {
+		content = []byte(body)
+	}
+	doc := utils.NewMarkdown().Parser().Parse(text.NewReader(content))
+
+	resolve := func(dest string) ([]byte, bool) {
+		return readAttachment(cfg, session, dest, filesPrefix)
+	}
+
+	var buf bytes.Buffer
+	if err := docx.Write(&buf, content, doc, resolve); err != nil {
+		http.Error(w, "Failed to export document", http.StatusInternalServerError)
+		return
+	}
+
+	filename := filepath.Base(dirPath)
+	if path == "" {
+		filename = "home"
+	}
+	w.Header().Set("Content-Type", docxContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+".docx\"")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write(buf.Bytes())
+}
+
+// readAttachment loads a local image referenced from a document, either as a
+// bare attachment name or through the /api/files/ URL. Remote URLs are not fetched,
+// and attachments of documents the session cannot access are skipped.
+func readAttachment(cfg *config.Config, session *auth.Session, dest, filesPrefix string) ([]byte, bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return nil, false
+	}
+	dest = u.Path
+
+	var rel string
+	if strings.HasPrefix(dest, "/api/files/") {
+		rel = strings.TrimPrefix(dest, "/api/files/")
+	} else if !strings.HasPrefix(dest, "/") {
+		rel = filesPrefix + "/" + dest
+	} else {
+		return nil, false
+	}
+
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	if strings.Contains(rel, "..") || strings.HasSuffix(rel, ".md") {
+		return nil, false
+	}
+
+	// Same logical path mapping as ServeFileHandler
+	docPath := filepath.ToSlash(filepath.Dir(rel))
+	logicalPath := "/" + docPath
+	if docPath == homeDocPath {
+		logicalPath = "/"
+	} else if strings.HasPrefix(docPath, homeDocPath+"/") {
+		logicalPath = "/" + strings.TrimPrefix(docPath, homeDocPath+"/")
+	}
+	if !auth.CanAccessDocument(logicalPath, session, cfg) {
+		return nil, false
+	}
+
+	var filePath string
+	if strings.HasPrefix(rel, "pages/") {
+		filePath = pagesFilePath(cfg, rel)
+	} else {
+		filePath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, rel)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
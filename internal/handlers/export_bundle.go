@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/gitbundle"
+	"wiki-go/internal/utils"
+)
+
+// ExportBundleHandler sends the version history of a document as a git bundle,
+// one commit per stored version followed by the current content, which can be
+// cloned with `git clone <file>.bundle`.
+// URL format: /api/export/bundle/{document-path}; an empty path exports the homepage.
+func ExportBundleHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/export/bundle")
+	path = strings.Trim(strings.ReplaceAll(path, "\\", "/"), "/")
+	if strings.Contains(path, "..") {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	urlPath := "/" + path
+	if !auth.CanAccessDocument(urlPath, auth.GetSession(r), cfg) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	docFile := utils.DocumentFile(documentDir(urlPath))
+	current, err := os.ReadFile(docFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "Document not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to read document", http.StatusInternalServerError)
+		return
+	}
+
+	versionDir := versionsDir(urlPath, "")
+	history, err := documentHistory(versionDir)
+	if err != nil {
+		http.Error(w, "Failed to read version history", http.StatusInternalServerError)
+		return
+	}
+
+	edited, author := utils.LastEdit(docFile, versionDir)
+	latest := gitbundle.Version{Content: current, Author: author, Time: edited}
+	if author != "" {
+		latest.Message = utils.ReadCurrentMeta(versionDir).Message
+	}
+	history = append(history, latest)
+
+	var buf bytes.Buffer
+	if _, err := gitbundle.Write(&buf, filepath.Base(docFile), history); err != nil {
+		http.Error(w, "Failed to export version history", http.StatusInternalServerError)
+		return
+	}
+
+	filename := filepath.Base(documentDir(urlPath))
+	if path == "" {
+		filename = "home"
+	}
+	w.Header().Set("Content-Type", "application/x-git-bundle")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+".bundle\"")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write(buf.Bytes())
+}
+
+// documentHistory reads the stored versions in versionDir, oldest first, with
+// their revision metadata. A version's time is when it was edited, falling back
+// to when it was archived for versions stored without metadata.
+func documentHistory(versionDir string) ([]gitbundle.Version, error) {
+	files, err := os.ReadDir(versionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var timestamps []string
+	for _, file := range files {
+		timestamp := strings.TrimSuffix(file.Name(), ".md")
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") && len(timestamp) == 14 && utils.IsNumeric(timestamp) {
+			timestamps = append(timestamps, timestamp)
+		}
+	}
+	sort.Strings(timestamps)
+
+	history := make([]gitbundle.Version, 0, len(timestamps)+1)
+	for _, timestamp := range timestamps {
+		content, err := os.ReadFile(filepath.Join(versionDir, timestamp+".md"))
+		if err != nil {
+			return nil, err
+		}
+		meta := utils.ReadVersionMeta(versionDir, timestamp)
+		edited, err := time.ParseInLocation("20060102150405", meta.Time, time.Local)
+		if err != nil {
+			edited, _ = time.ParseInLocation("20060102150405", timestamp, time.Local)
+		}
+		history = append(history, gitbundle.Version{
+			Content: content,
+			Author:  meta.Author,
+			Time:    edited,
+			Message: meta.Message,
+		})
+	}
+	return history, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportBundleHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"document", http.MethodGet, "/api/export/bundle/a", http.StatusOK},
+		{"missing document", http.MethodGet, "/api/export/bundle/missing", http.StatusNotFound},
+		{"traversal", http.MethodGet, "/api/export/bundle/../a", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/api/export/bundle/a", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.URL.Path = tt.path
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			ExportBundleHandler(rec, req, testCfg)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusOK && !strings.HasPrefix(rec.Body.String(), "# v2 git bundle\n") {
+				t.Errorf("body doesn't start with a bundle header: %q", rec.Body.String()[:min(40, rec.Body.Len())])
+			}
+		})
+	}
+}
+
+func TestDocumentHistory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20240102000000.md":   "second",
+		"20240102000000.json": `{"author":"bob","message":"Reword","time":"20240101120000"}`,
+		"20240101000000.md":   "first",
+		"current.json":        `{"author":"carol","time":"20240103000000"}`,
+		"notes.md":            "not a version",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := documentHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d versions, want 2", len(history))
+	}
+	if string(history[0].Content) != "first" || history[0].Author != "" {
+		t.Errorf("first version = %q by %q", history[0].Content, history[0].Author)
+	}
+	if got := history[0].Time.Format("20060102150405"); got != "20240101000000" {
+		t.Errorf("first version time = %s, want the archive time", got)
+	}
+	second := history[1]
+	if string(second.Content) != "second" || second.Author != "bob" || second.Message != "Reword" {
+		t.Errorf("second version = %+v", second)
+	}
+	if got := second.Time.Format("20060102150405"); got != "20240101120000" {
+		t.Errorf("second version time = %s, want the edit time", got)
+	}
+
+	if history, err := documentHistory(filepath.Join(dir, "missing")); err != nil || len(history) != 0 {
+		t.Errorf("missing directory: %v, %v", history, err)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/utils"
+)
+
+// maxExportDocuments is the most documents one ZIP export may ask for
+const maxExportDocuments = 1000
+
+// ExportZipRequest is the body of a POST to /api/export/zip
+type ExportZipRequest struct {
+	Paths []string `json:"paths"` // Document paths; "" or "/" is the homepage
+}
+
+// exportDocument is a document included in a ZIP export
+type exportDocument struct {
+	name string // Directory inside the archive
+	dir  string // Directory on disk
+}
+
+// ExportZipHandler streams the markdown and attachments of the requested
+// documents as a ZIP laid out like the wiki tree. Documents are given as
+// repeated ?path= parameters (GET) or as ExportZipRequest (POST). Child
+// documents are not included unless requested. Documents the session can't
+// read, or that don't exist, are left out and listed in X-Skipped-Documents.
+func ExportZipHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	var paths []string
+	switch r.Method {
+	case http.MethodGet:
+		paths = r.URL.Query()["path"]
+	case http.MethodPost:
+		var req ExportZipRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			sendJSONError(w, "Invalid request body", http.StatusBadRequest, "")
+			return
+		}
+		paths = req.Paths
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+	if len(paths) == 0 {
+		sendJSONError(w, "No documents selected", http.StatusBadRequest, "")
+		return
+	}
+	if len(paths) > maxExportDocuments {
+		sendJSONError(w, "Too many documents", http.StatusBadRequest, fmt.Sprintf("At most %d documents can be exported at once", maxExportDocuments))
+		return
+	}
+
+	session := auth.GetSession(r)
+	var documents []exportDocument
+	var skipped []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		p = strings.Trim(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		doc := exportDocument{name: p, dir: filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, filepath.FromSlash(p))}
+		if p == "" {
+			doc = exportDocument{name: homeDocPath, dir: homePageDir(cfg)}
+		}
+		if !auth.CanAccessDocument("/"+p, session, cfg) {
+			skipped = append(skipped, "/"+p)
+			continue
+		}
+		if _, err := os.Stat(utils.DocumentFile(doc.dir)); err != nil {
+			skipped = append(skipped, "/"+p)
+			continue
+		}
+		documents = append(documents, doc)
+	}
+	if len(documents) == 0 {
+		sendJSONError(w, "No readable documents selected", http.StatusNotFound, "")
+		return
+	}
+
+	if len(skipped) > 0 {
+		escaped := make([]string, len(skipped))
+		for i, p := range skipped {
+			escaped[i] = url.PathEscape(p)
+		}
+		w.Header().Set("X-Skipped-Documents", strings.Join(escaped, ","))
+	}
+	filename := fmt.Sprintf("export_%s.zip", time.Now().Format("20060102150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	// Headers are sent with the first write; errors after that can only be logged
+	archive := zip.NewWriter(w)
+	for _, doc := range documents {
+		if err := addExportDocument(archive, doc); err != nil {
+			log.Printf("Error exporting %s: %v", doc.name, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Error finishing ZIP export: %v", err)
+	}
+}
+
+// addExportDocument writes the files of one document directory to the
+// archive: document.md, its translations and attachments, but not hidden
+// files or child documents
+func addExportDocument(archive *zip.Writer, doc exportDocument) error {
+	entries, err := os.ReadDir(doc.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(doc.name, entry.Name())
+		header.Method = zip.Deflate
+
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(filepath.Join(doc.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
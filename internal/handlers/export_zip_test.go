@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestExportZip(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides", "guides/setup", "finance")
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/finance/**", Access: "restricted", Groups: []string{"finance"}}}
+	if err := os.WriteFile(filepath.Join(testCfg.Wiki.RootDir, "documents", "guides", "setup", "diagram.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/zip?path=guides/setup&path=/finance&path=missing&path=guides/setup/", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	ExportZipHandler(rec, req, testCfg)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if got, want := rec.Header().Get("X-Skipped-Documents"), "%2Ffinance,%2Fmissing"; got != want {
+		t.Errorf("X-Skipped-Documents = %q, want %q", got, want)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	// Only the requested document: not its parent, not the restricted one
+	want := []string{"guides/setup/diagram.png", "guides/setup/document.md"}
+	if !slices.Equal(names, want) {
+		t.Errorf("archive contains %q, want %q", names, want)
+	}
+}
+
+func TestExportZipNothingReadable(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "finance")
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/finance/**", Access: "restricted", Groups: []string{"finance"}}}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/export/zip", bytes.NewReader([]byte(`{"paths":["finance"]}`)))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	ExportZipHandler(rec, req, testCfg)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/favorites"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/utils"
+)
+
+// favoriteList returns the favorites of the users of the wiki
+func favoriteList() *favorites.List {
+	cfg := config.Current()
+	return favorites.New(cfg.Wiki.RootDir)
+}
+
+// FavoriteDocument is an entry of a user's favorites
+type FavoriteDocument struct {
+	Title string    `json:"title"`
+	Path  string    `json:"path"`
+	Added time.Time `json:"added"`
+}
+
+// FavoritesHandler manages the favorites of the signed-in user: GET
+// /api/favorites lists them, and GET, PUT and DELETE /api/favorites/{path}
+// tell whether a document is starred, star it and unstar it. An empty path is
+// the homepage.
+func FavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	session := auth.GetSession(r)
+	if session == nil {
+		sendJSONError(w, "Authentication required", http.StatusUnauthorized, "")
+		return
+	}
+
+	rest, hasPath := strings.CutPrefix(r.URL.Path, "/api/favorites/")
+	if !hasPath {
+		if r.Method != http.MethodGet {
+			sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+			return
+		}
+		listFavorites(w, session)
+		return
+	}
+
+	docPath := "/" + strings.Trim(rest, "/")
+	if !auth.CanAccessDocument(docPath, session, cfg) {
+		sendJSONError(w, "Forbidden", http.StatusForbidden, "")
+		return
+	}
+
+	list := favoriteList()
+	starred := false
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodGet:
+		current, err := list.Get(session.Username)
+		if err != nil {
+			sendJSONError(w, "Failed to read favorites", http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, f := range current {
+			starred = starred || f.Path == docPath
+		}
+
+	case http.MethodPut:
+		if _, err := os.Stat(utils.DocumentFile(documentDir(docPath))); err != nil {
+			sendJSONError(w, "Document not found", http.StatusNotFound, "")
+			return
+		}
+		added, err := list.Add(session.Username, docPath)
+		if err != nil {
+			sendJSONError(w, "Failed to save favorites", http.StatusInternalServerError, err.Error())
+			return
+		}
+		if added {
+			status = http.StatusCreated
+		}
+		starred = true
+
+	case http.MethodDelete:
+		if _, err := list.Remove(session.Username, docPath); err != nil {
+			sendJSONError(w, "Failed to save favorites", http.StatusInternalServerError, err.Error())
+			return
+		}
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(favoriteResponse{Success: true, Path: docPath, Starred: starred})
+}
+
+// listFavorites writes the favorites of the user of session that still exist
+// and that they can read. Favorites of deleted documents are kept, so they
+// come back with the document when it is restored from the trash.
+func listFavorites(w http.ResponseWriter, session *auth.Session) {
+	cfg := config.Current()
+	current, err := favoriteList().Get(session.Username)
+	if err != nil {
+		sendJSONError(w, "Failed to read favorites", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	documents := []FavoriteDocument{}
+	for _, f := range current {
+		if !auth.CanAccessDocument(f.Path, session, cfg) {
+			continue
+		}
+		dir := documentDir(f.Path)
+		if _, err := os.Stat(utils.DocumentFile(dir)); err != nil {
+			continue
+		}
+		title := i18n.Translate("nav.home")
+		if f.Path != "/" {
+			title = utils.GetDocumentTitle(dir)
+		}
+		documents = append(documents, FavoriteDocument{Title: title, Path: f.Path, Added: f.Added})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(favoritesResponse{Success: true, Favorites: documents})
+}
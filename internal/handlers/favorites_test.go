@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFavorites(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guide", "guide/setup", "faq")
+
+	do := func(method, path string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		if strings.HasPrefix(path, "/api/document/move") {
+			MoveDocumentHandler(rec, req, testCfg)
+		} else {
+			FavoritesHandler(rec, req)
+		}
+		return rec
+	}
+	list := func() []string {
+		t.Helper()
+		var resp favoritesResponse
+		if err := json.NewDecoder(do(http.MethodGet, "/api/favorites", "").Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, f := range resp.Favorites {
+			paths = append(paths, f.Path)
+		}
+		return paths
+	}
+
+	steps := []struct {
+		method, path string
+		wantCode     int
+		wantStarred  bool
+	}{
+		{http.MethodPut, "/api/favorites/guide/setup", http.StatusCreated, true},
+		{http.MethodPut, "/api/favorites/guide/setup/", http.StatusOK, true},
+		{http.MethodPut, "/api/favorites/faq", http.StatusCreated, true},
+		{http.MethodPut, "/api/favorites/missing", http.StatusNotFound, false},
+		{http.MethodGet, "/api/favorites/faq", http.StatusOK, true},
+		{http.MethodDelete, "/api/favorites/faq", http.StatusOK, false},
+		{http.MethodGet, "/api/favorites/faq", http.StatusOK, false},
+	}
+	for _, step := range steps {
+		rec := do(step.method, step.path, "")
+		if rec.Code != step.wantCode {
+			t.Fatalf("%s %s: status = %d, want %d (%s)", step.method, step.path, rec.Code, step.wantCode, rec.Body.String())
+		}
+		if rec.Code >= 300 {
+			continue
+		}
+		var resp favoriteResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Starred != step.wantStarred {
+			t.Errorf("%s %s: response = %+v, %v; want starred %t", step.method, step.path, resp, err, step.wantStarred)
+		}
+	}
+	if got := strings.Join(list(), ","); got != "/guide/setup" {
+		t.Errorf("favorites = %s, want /guide/setup", got)
+	}
+
+	// Favorites follow their document when its category moves
+	if rec := do(http.MethodPost, "/api/document/move", `{"sourcePath":"guide","targetPath":"faq"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if got := strings.Join(list(), ","); got != "/faq/guide/setup" {
+		t.Errorf("favorites after move = %s, want /faq/guide/setup", got)
+	}
+
+	// Only for signed-in users
+	rec := httptest.NewRecorder()
+	FavoritesHandler(rec, httptest.NewRequest(http.MethodGet, "/api/favorites", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("signed out: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -15,6 +16,7 @@ import (
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
 	"wiki-go/internal/i18n"
+	"wiki-go/internal/logging"
 	"wiki-go/internal/utils"
 )
 
@@ -45,6 +47,8 @@ type DocumentsResponse struct {
 	Success   bool       `json:"success"`
 	Message   string     `json:"message,omitempty"`
 	Documents []Document `json:"documents"`
+	// NextCursor continues a listing requested with a limit; empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // FolderInfo represents information about a folder
@@ -81,12 +85,24 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	}
 
 	// Get the maximum upload size from the config
-	maxUploadSize := config.GetMaxUploadSizeBytes(cfg)
-	maxUploadSizeFormatted := config.GetMaxUploadSizeFormatted(cfg)
-
-	// Parse the multipart form with a maximum size
-	err := r.ParseMultipartForm(maxUploadSize)
+	maxUploadSize := config.GetMaxAttachmentSizeBytes(cfg)
+	maxUploadSizeFormatted := config.GetMaxAttachmentSizeFormatted(cfg)
+
+	// Parse the multipart form, leaving room beyond the file for the other
+	// fields and the multipart framing
+	liftTimeouts(w)
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize+1<<20)
+	err := r.ParseMultipartForm(32 << 20)
 	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(FileResponse{
+				Success: false,
+				Message: "File too large. Maximum size is " + maxUploadSizeFormatted + ".",
+			})
+			return
+		}
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(FileResponse{
 			Success: false,
@@ -113,14 +129,14 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 
 	// Special case for homepage
 	if docPath == "" || docPath == "/" {
-		docPath = "pages/home"
+		docPath = homeDocPath
 	}
 
 	// Determine the full filesystem path to the document's directory
 	var uploadDir string
 	if strings.HasPrefix(docPath, "pages/") {
 		// For pages directory (like homepage), don't add the documents directory
-		uploadDir = filepath.Join(cfg.Wiki.RootDir, docPath)
+		uploadDir = pagesFilePath(cfg, docPath)
 	} else {
 		// For regular documents
 		uploadDir = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, docPath)
@@ -148,17 +164,54 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	}
 	defer file.Close()
 
-	// Validate file extension
+	if fileHeader.Size > maxUploadSize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		json.NewEncoder(w).Encode(FileResponse{
+			Success: false,
+			Message: "File too large. Maximum size is " + maxUploadSizeFormatted + ".",
+		})
+		return
+	}
+
+	// Validate file extension. A configured list of allowed types is enforced
+	// even when upload checking is disabled.
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if !cfg.Wiki.DisableFileUploadChecking && !config.IsAllowedExtension(ext) {
-		w.WriteHeader(http.StatusBadRequest)
+	restrictTypes := len(cfg.Wiki.Uploads.AllowedTypes) > 0
+	if (!cfg.Wiki.DisableFileUploadChecking || restrictTypes) &&
+		(!config.IsAllowedExtension(ext) || !config.IsAllowedUploadType(cfg, ext)) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
 		json.NewEncoder(w).Encode(FileResponse{
 			Success: false,
-			Message: "Invalid file type. Allowed extensions: " + config.GetAllowedExtensionsDisplayText(),
+			Message: "Invalid file type. Allowed extensions: " + strings.Join(config.GetAllowedUploadExtensions(cfg), ", "),
 		})
 		return
 	}
 
+	// Create safe filename - remove any potentially unsafe characters
+	filename := sanitizeFilename(fileHeader.Filename)
+
+	// Keep the document's attachments within its storage cap. A file of the
+	// same name is replaced, so its size is not counted.
+	if maxStorage := config.GetMaxDocumentStorageBytes(cfg); maxStorage > 0 {
+		used, err := attachmentsSize(uploadDir, filename)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(FileResponse{
+				Success: false,
+				Message: "Failed to read document attachments.",
+			})
+			return
+		}
+		if used+fileHeader.Size > maxStorage {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(FileResponse{
+				Success: false,
+				Message: fmt.Sprintf("Document attachment storage full. Attachments of a document may total at most %dMB.", cfg.Wiki.Uploads.MaxDocumentStorage),
+			})
+			return
+		}
+	}
+
 	// Read a larger buffer to better detect the actual content type
 	buffer := make([]byte, 8192)
 	n, err := file.Read(buffer)
@@ -184,7 +237,7 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	}
 
 	// Check if MIME type validation is disabled in settings
-	if !cfg.Wiki.DisableFileUploadChecking {
+	if !cfg.Wiki.DisableFileUploadChecking || restrictTypes {
 		// Use enhanced detection for content type
 		detectedContentType, err := detectFileContentType(buffer, fileHeader.Filename)
 		if err != nil {
@@ -204,7 +257,7 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 			// Debug info for file validation issues
 			debugFileValidation(buffer, fileHeader.Filename, detectedContentType, expectedContentType)
 
-			w.WriteHeader(http.StatusBadRequest)
+			w.WriteHeader(http.StatusUnsupportedMediaType)
 			json.NewEncoder(w).Encode(FileResponse{
 				Success: false,
 				Message: i18n.Translate("attachments.error_content_mismatch"),
@@ -213,8 +266,35 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 		}
 	}
 
-	// Create safe filename - remove any potentially unsafe characters
-	filename := sanitizeFilename(fileHeader.Filename)
+	// Scan the upload for malware before anyone can download it. Asynchronous
+	// scans happen in quarantine once the file is saved.
+	logger := logging.FromContext(r.Context()).With("user", session.Username, "document", docPath, "file", filename)
+	if uploadScanner != nil && !cfg.Wiki.Uploads.Scan.Async {
+		threat, err := uploadScanner.Scan(r.Context(), file)
+		if err == nil {
+			_, err = file.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			logger.Error("upload scan failed", "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(FileResponse{
+				Success: false,
+				Message: "The file could not be scanned for malware. Try again later.",
+			})
+			return
+		}
+		if threat != "" {
+			logger.Warn("infected upload rejected", "threat", threat)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(FileResponse{
+				Success: false,
+				Message: "The file was rejected by the malware scanner.",
+			})
+			return
+		}
+	}
+
+	var content io.Reader = file
 
 	// Special handling for SVG files to prevent XSS attacks
 	if strings.ToLower(filepath.Ext(filename)) == ".svg" && !cfg.Wiki.DisableFileUploadChecking {
@@ -239,38 +319,35 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 			})
 			return
 		}
+		content = bytes.NewReader(sanitizedSVG)
+	}
 
-		// Full path where the file will be saved
-		savePath := filepath.Join(uploadDir, filename)
+	// Full path where the file will be saved
+	savePath := filepath.Join(uploadDir, filename)
+
+	// Create URL path for the file
+	urlPath := filepath.Join("/api/files", docPath, filename)
+	// Replace backslashes with forward slashes for URLs
+	urlPath = strings.ReplaceAll(urlPath, "\\", "/")
 
-		// Write the sanitized SVG directly to the file
-		if err := os.WriteFile(savePath, sanitizedSVG, 0644); err != nil {
+	if uploadScanner != nil && cfg.Wiki.Uploads.Scan.Async {
+		if err := quarantineUpload(content, savePath, logger); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(FileResponse{
 				Success: false,
-				Message: "Failed to save sanitized SVG file.",
+				Message: "Failed to save uploaded file.",
 			})
 			return
 		}
-
-		// Create URL path for the file
-		urlPath := filepath.Join("/api/files", docPath, filename)
-		// Replace backslashes with forward slashes for URLs
-		urlPath = strings.ReplaceAll(urlPath, "\\", "/")
-
-		// Return success response
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusAccepted)
 		json.NewEncoder(w).Encode(FileResponse{
 			Success: true,
-			Message: "File uploaded successfully.",
+			Message: "File uploaded. It becomes available once it passes the malware scan.",
 			URL:     urlPath,
 		})
 		return
 	}
 
-	// Full path where the file will be saved
-	savePath := filepath.Join(uploadDir, filename)
-
 	// Create destination file
 	dst, err := os.Create(savePath)
 	if err != nil {
@@ -284,7 +361,7 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	defer dst.Close()
 
 	// Copy the uploaded file to the destination file
-	_, err = io.Copy(dst, file)
+	_, err = io.Copy(dst, content)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(FileResponse{
@@ -294,10 +371,8 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 		return
 	}
 
-	// Create URL path for the file
-	urlPath := filepath.Join("/api/files", docPath, filename)
-	// Replace backslashes with forward slashes for URLs
-	urlPath = strings.ReplaceAll(urlPath, "\\", "/")
+	// Links to attachments render differently once they exist or are gone
+	renderCache.Purge()
 
 	// Return success response
 	w.WriteHeader(http.StatusOK)
@@ -308,6 +383,31 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	})
 }
 
+// attachmentsSize returns the total size of the attachments in a document's
+// directory, leaving out the file named except
+func attachmentsSize(dir, except string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == except || utils.IsDocumentFile(name) || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return 0, err
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
 // ListFilesHandler returns a list of files in the document's directory
 func ListFilesHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	// Set appropriate headers
@@ -331,7 +431,7 @@ func ListFilesHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 
 	// Special case for homepage
 	if path == "" || path == "/" {
-		path = "pages/home"
+		path = homeDocPath
 	}
 
 	// Clean and normalize the path
@@ -341,11 +441,11 @@ func ListFilesHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 
 	// Determine logical path for access check
 	logicalPath := "/" + path
-	if path == "pages/home" {
+	if path == homeDocPath {
 		logicalPath = "/"
-	} else if strings.HasPrefix(path, "pages/home/") {
+	} else if strings.HasPrefix(path, homeDocPath+"/") {
 		// Handle potential sub-resources of homepage if any
-		logicalPath = "/" + strings.TrimPrefix(path, "pages/home/")
+		logicalPath = "/" + strings.TrimPrefix(path, homeDocPath+"/")
 	}
 
 	// Check access permissions
@@ -363,7 +463,7 @@ func ListFilesHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 	var dirPath string
 	if strings.HasPrefix(path, "pages/") {
 		// For pages directory (like homepage), don't add the documents directory
-		dirPath = filepath.Join(cfg.Wiki.RootDir, path)
+		dirPath = pagesFilePath(cfg, path)
 	} else {
 		// For regular documents
 		dirPath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path)
@@ -397,8 +497,8 @@ func ListFilesHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 			continue
 		}
 
-		// Skip the document.md file
-		if file.Name() == "document.md" {
+		// Skip the document.md file, its translations and hidden files such as the category order manifest
+		if utils.IsDocumentFile(file.Name()) || strings.HasPrefix(file.Name(), ".") {
 			continue
 		}
 
@@ -492,7 +592,7 @@ func DeleteFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	var filePath string
 	if strings.HasPrefix(path, "pages/") {
 		// For pages directory (like homepage), don't add the documents directory
-		filePath = filepath.Join(cfg.Wiki.RootDir, path)
+		filePath = pagesFilePath(cfg, path)
 	} else {
 		// For regular documents
 		filePath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path)
@@ -519,8 +619,8 @@ func DeleteFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 		return
 	}
 
-	// Don't allow deleting document.md
-	if filepath.Base(filePath) == "document.md" {
+	// Don't allow deleting document.md or its translations
+	if utils.IsDocumentFile(filepath.Base(filePath)) {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(FileResponse{
 			Success: false,
@@ -540,6 +640,9 @@ func DeleteFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 		return
 	}
 
+	// Links to attachments render differently once they exist or are gone
+	renderCache.Purge()
+
 	// Return success response
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(FileResponse{
@@ -573,10 +676,10 @@ func ServeFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 	
 	// Determine logical path for access check
 	logicalPath := "/" + docPath
-	if docPath == "pages/home" {
+	if docPath == homeDocPath {
 		logicalPath = "/"
-	} else if strings.HasPrefix(docPath, "pages/home/") {
-		logicalPath = "/" + strings.TrimPrefix(docPath, "pages/home/")
+	} else if strings.HasPrefix(docPath, homeDocPath+"/") {
+		logicalPath = "/" + strings.TrimPrefix(docPath, homeDocPath+"/")
 	} else if docPath == "." {
 		// Should not happen for valid document attachments, but handle gracefully
 		logicalPath = "/"
@@ -592,7 +695,7 @@ func ServeFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 	var filePath string
 	if strings.HasPrefix(path, "pages/") {
 		// For pages directory (like homepage), don't add the documents directory
-		filePath = filepath.Join(cfg.Wiki.RootDir, path)
+		filePath = pagesFilePath(cfg, path)
 	} else {
 		// For regular documents
 		filePath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path)
@@ -620,8 +723,8 @@ func ServeFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config
 		return
 	}
 
-	// SECURITY CHECK: Block access to files named "document" with any extension
-	if strings.ToLower(filepath.Base(filePath)) == "document.md" {
+	// SECURITY CHECK: Block access to the files holding document content
+	if name := filepath.Base(filePath); strings.ToLower(name) == "document.md" || utils.IsIndexFile(name) {
 		http.Error(w, "File not found", http.StatusNotFound)
 		return
 	}
@@ -1039,56 +1142,70 @@ func ListDocumentsHandler(w http.ResponseWriter, r *http.Request, cfg *config.Co
 		return
 	}
 
+	page, err := parseListPage(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DocumentsResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Paths to scan for documents
 	documentsPath := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
 
-	var documents []Document
-
-	// Find all documents in documents directory
-	err := filepath.WalkDir(documentsPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Check if this is a document.md file
-		if !d.IsDir() && d.Name() == "document.md" {
-			// Get the directory path (document path)
-			docDir := filepath.Dir(path)
-			// Convert to relative path
-			relPath, err := filepath.Rel(cfg.Wiki.RootDir, docDir)
+	// A page of documents, with a cursor for the next one
+	if page.limit > 0 {
+		documents := make([]Document, 0, page.limit)
+		lastPath, nextCursor := "", ""
+		err := walkAfter(documentsPath, page.after, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
-				return nil // Skip this file
+				return err
 			}
-
-			// Format the path for use in URLs
-			relPath = strings.ReplaceAll(relPath, "\\", "/")
-
-			// Get the document title from the markdown file
-			title := extractTitleFromMarkdown(path)
-			if title == "" {
-				// If no title found, use the parent directory name
-				title = filepath.Base(docDir)
+			if d.IsDir() || !utils.IsDocumentIndex(path) {
+				return nil
 			}
-
-			// For regular documents, we want to remove the documents/ prefix
-			// since it's not part of the visible URL
-			if strings.HasPrefix(relPath, cfg.Wiki.DocumentsDir) {
-				relPath = strings.TrimPrefix(relPath, cfg.Wiki.DocumentsDir)
-				// Remove any leading slash that might remain
-				relPath = strings.TrimPrefix(relPath, "/")
+			if len(documents) == page.limit {
+				// There is at least one more document
+				nextCursor = encodeCursor(lastPath)
+				return fs.SkipAll
 			}
-
-			// Add to documents list
-			documents = append(documents, Document{
-				Title: title,
-				Path:  "/" + relPath,
+			documents = append(documents, documentListEntry(cfg, path))
+			lastPath, _ = filepath.Rel(documentsPath, path)
+			return nil
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DocumentsResponse{
+				Success: false,
+				Message: "Failed to list documents: " + err.Error(),
 			})
+			return
 		}
 
-		return nil
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(DocumentsResponse{
+			Success:    true,
+			Documents:  documents,
+			NextCursor: nextCursor,
+		})
+		return
+	}
+
+	// Without a limit every document is listed, streamed as the tree is walked
+	stream := newJSONArrayWriter(w, `{"success":true,"documents":[`, "]}\n")
+	err = filepath.WalkDir(documentsPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !utils.IsDocumentIndex(path) {
+			return nil
+		}
+		return stream.Write(documentListEntry(cfg, path))
 	})
 
-	if err != nil {
+	if err != nil && !stream.Started() {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(DocumentsResponse{
 			Success: false,
@@ -1096,13 +1213,46 @@ func ListDocumentsHandler(w http.ResponseWriter, r *http.Request, cfg *config.Co
 		})
 		return
 	}
+	if err != nil {
+		// Too late to change the status; end the listing where it stopped
+		fmt.Printf("Error listing documents: %v\n", err)
+	}
+	stream.Close()
+}
 
-	// Return the documents list
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(DocumentsResponse{
-		Success:   true,
-		Documents: documents,
-	})
+// documentListEntry describes the document whose document.md is at path for
+// the document picker
+func documentListEntry(cfg *config.Config, path string) Document {
+	// Get the directory path (document path)
+	docDir := filepath.Dir(path)
+	// Convert to relative path
+	relPath, err := filepath.Rel(cfg.Wiki.RootDir, docDir)
+	if err != nil {
+		relPath = docDir
+	}
+
+	// Format the path for use in URLs
+	relPath = strings.ReplaceAll(relPath, "\\", "/")
+
+	// Get the document title from the markdown file
+	title := extractTitleFromMarkdown(path)
+	if title == "" {
+		// If no title found, use the parent directory name
+		title = filepath.Base(docDir)
+	}
+
+	// For regular documents, we want to remove the documents/ prefix
+	// since it's not part of the visible URL
+	if strings.HasPrefix(relPath, cfg.Wiki.DocumentsDir) {
+		relPath = strings.TrimPrefix(relPath, cfg.Wiki.DocumentsDir)
+		// Remove any leading slash that might remain
+		relPath = strings.TrimPrefix(relPath, "/")
+	}
+
+	return Document{
+		Title: title,
+		Path:  "/" + relPath,
+	}
 }
 
 // extractTitleFromMarkdown reads a markdown file and extracts the first h1 heading
@@ -1207,10 +1357,10 @@ func RenameFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 
 	// If not found in documents, try pages directory
 	if !fileFound && strings.HasPrefix(path, "pages/") {
-		currentPagesPath := filepath.Join(cfg.Wiki.RootDir, path)
+		currentPagesPath := pagesFilePath(cfg, path)
 		if fileExists(currentPagesPath) {
 			currentFilePath = currentPagesPath
-			newFilePath = filepath.Join(cfg.Wiki.RootDir, newPath)
+			newFilePath = pagesFilePath(cfg, newPath)
 			fileFound = true
 			fmt.Printf("File found in pages path: %s\n", currentFilePath)
 		}
@@ -1221,7 +1371,7 @@ func RenameFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	if !fileFound {
 		// Check if the destination file already exists with the new name
 		possibleNewPathInDocuments := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, newPath)
-		possibleNewPathInPages := filepath.Join(cfg.Wiki.RootDir, newPath)
+		possibleNewPathInPages := pagesFilePath(cfg, newPath)
 
 		if fileExists(possibleNewPathInDocuments) ||
 		   (strings.HasPrefix(newPath, "pages/") && fileExists(possibleNewPathInPages)) {
@@ -1279,6 +1429,9 @@ func RenameFileHandler(w http.ResponseWriter, r *http.Request, cfg *config.Confi
 	fmt.Printf("File renamed successfully: %s -> %s\n", currentFilePath, newFilePath)
 	fmt.Printf("URL path: %s\n", urlPath)
 
+	// Links to attachments render differently once they exist or are gone
+	renderCache.Purge()
+
 	// Return success response
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(FileResponse{
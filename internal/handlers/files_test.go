@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// uploadTestRequest returns a request uploading content as filename to the document doc
+func uploadTestRequest(t *testing.T, cookie *http.Cookie, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("docPath", "doc")
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/files/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.AddCookie(cookie)
+	return req
+}
+
+func TestUploadFileLimits(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 64)...)
+
+	tests := []struct {
+		name         string
+		filename     string
+		content      []byte
+		maxFileSize  int
+		allowedTypes []string
+		maxStorage   int
+		existing     int // Size of an existing attachment old.txt
+		wantCode     int
+	}{
+		{"allowed", "pic.png", png, 0, nil, 0, 0, http.StatusOK},
+		{"allowed by MIME wildcard", "pic.png", png, 0, []string{"image/*"}, 0, 0, http.StatusOK},
+		{"allowed by extension", "notes.txt", []byte("notes"), 0, []string{"pdf", ".txt"}, 0, 0, http.StatusOK},
+		{"type not allowed", "notes.txt", []byte("notes"), 0, []string{"image/*"}, 0, 0, http.StatusUnsupportedMediaType},
+		{"unknown extension", "tool.exe", []byte("MZ"), 0, nil, 0, 0, http.StatusUnsupportedMediaType},
+		{"content not matching extension", "pic.png", []byte("just text"), 0, []string{"image/png"}, 0, 0, http.StatusUnsupportedMediaType},
+		{"file too large", "big.txt", bytes.Repeat([]byte("a"), 1<<20+1), 1, nil, 0, 0, http.StatusRequestEntityTooLarge},
+		{"body far too large", "big.txt", bytes.Repeat([]byte("a"), 3<<20), 1, nil, 0, 0, http.StatusRequestEntityTooLarge},
+		{"within storage", "notes.txt", bytes.Repeat([]byte("a"), 1000), 0, nil, 1, 1<<20 - 1000, http.StatusOK},
+		{"storage full", "notes.txt", bytes.Repeat([]byte("a"), 1001), 0, nil, 1, 1<<20 - 1000, http.StatusRequestEntityTooLarge},
+		{"replacing does not count twice", "old.txt", bytes.Repeat([]byte("a"), 1<<20), 0, nil, 1, 1 << 20, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "doc")
+			testCfg.Wiki.Uploads.MaxFileSize = tt.maxFileSize
+			testCfg.Wiki.Uploads.AllowedTypes = tt.allowedTypes
+			testCfg.Wiki.Uploads.MaxDocumentStorage = tt.maxStorage
+			dir := filepath.Join(testCfg.Wiki.RootDir, testCfg.Wiki.DocumentsDir, "doc")
+			if tt.existing > 0 {
+				if err := os.WriteFile(filepath.Join(dir, "old.txt"), make([]byte, tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			req := uploadTestRequest(t, cookie, tt.filename, tt.content)
+			rec := httptest.NewRecorder()
+			UploadFileHandler(rec, req, testCfg)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			_, err := os.Stat(filepath.Join(dir, tt.filename))
+			if saved := err == nil; saved != (tt.wantCode == http.StatusOK || tt.filename == "old.txt") {
+				t.Errorf("file saved = %t with status %d", saved, rec.Code)
+			}
+		})
+	}
+}
+
+// fakeScanner reports content containing "EICAR" as infected
+type fakeScanner struct{ err error }
+
+func (s fakeScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	content, _ := io.ReadAll(r)
+	if s.err != nil {
+		return "", s.err
+	}
+	if bytes.Contains(content, []byte("EICAR")) {
+		return "Eicar-Test-Signature", nil
+	}
+	return "", nil
+}
+
+func TestUploadFileScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		scanErr   error
+		async     bool
+		wantCode  int
+		wantSaved bool
+	}{
+		{"clean", "notes", nil, false, http.StatusOK, true},
+		{"infected", "EICAR notes", nil, false, http.StatusUnprocessableEntity, false},
+		{"scanner down", "notes", errors.New("connection refused"), false, http.StatusServiceUnavailable, false},
+		{"clean after quarantine", "notes", nil, true, http.StatusAccepted, true},
+		{"infected in quarantine", "EICAR notes", nil, true, http.StatusAccepted, false},
+		{"scanner down in quarantine", "notes", errors.New("connection refused"), true, http.StatusAccepted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "doc")
+			testCfg.Wiki.Uploads.Scan.Async = tt.async
+			previous := uploadScanner
+			uploadScanner = fakeScanner{tt.scanErr}
+			t.Cleanup(func() { uploadScanner = previous })
+
+			rec := httptest.NewRecorder()
+			UploadFileHandler(rec, uploadTestRequest(t, cookie, "notes.txt", []byte(tt.content)), testCfg)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			quarantineScans.Wait()
+
+			saved, err := os.ReadFile(filepath.Join(testCfg.Wiki.RootDir, testCfg.Wiki.DocumentsDir, "doc", "notes.txt"))
+			if (err == nil) != tt.wantSaved {
+				t.Fatalf("file saved = %t, want %t", err == nil, tt.wantSaved)
+			}
+			if tt.wantSaved && string(saved) != tt.content {
+				t.Errorf("saved %q, want %q", saved, tt.content)
+			}
+			if left, _ := os.ReadDir(quarantineDir()); len(left) > 0 {
+				t.Errorf("%d files left in quarantine", len(left))
+			}
+		})
+	}
+}
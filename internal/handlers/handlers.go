@@ -2,28 +2,124 @@ package handlers
 
 import (
 	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
 	"wiki-go/internal/i18n"
+	"wiki-go/internal/sanitize"
+	"wiki-go/internal/storage"
+	"wiki-go/internal/utils"
 )
 
-var cfg *config.Config
+// wikiStorage returns the storage holding the files below cfg.Wiki.RootDir
+func wikiStorage(cfg *config.Config) storage.Storage {
+	return storage.NewFS(cfg.Wiki.RootDir)
+}
+
+// storageName returns dir, a directory below cfg.Wiki.RootDir such as
+// config.VersionsPath(cfg), by the slash-separated name wikiStorage uses
+func storageName(cfg *config.Config, dir string) string {
+	rel, err := filepath.Rel(cfg.Wiki.RootDir, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	return filepath.ToSlash(rel)
+}
 
 // InitHandlers initializes the handlers with the given configuration
-func InitHandlers(config *config.Config) {
-	cfg = config
+func InitHandlers(cfg *config.Config) {
+	config.SetCurrent(cfg)
 
 	// Initialize i18n package
 	if err := i18n.Initialize(cfg); err != nil {
 		log.Printf("Warning: Failed to initialize i18n package: %v", err)
 	}
 
+	// Settings that can also be changed by reloading the config file
+	applyConfig()
+
+	// Resolve [[WikiLinks]] against the documents tree
+	initWikiLinks()
+
+	// Load document view counts
+	initViewCounts()
+
+	// Purge expired documents from the trash
+	initTrash()
+
+	// Open the search index and catch it up with the documents
+	initSearchIndex()
+
+	// Drop attachments whose scan was interrupted by a restart
+	initQuarantine()
+
+	// Register state-derived gauges when metrics are enabled
+	if cfg.Server.Metrics.Enabled {
+		initMetrics()
+	}
+
+	// Routes are now managed in the routes package
+}
+
+// applyConfig (re)initializes the package state derived from cfg. It runs at
+// startup and again after the configuration is reloaded.
+func applyConfig() {
+	cfg := config.Current()
+
 	// Initialise IP-based ban list for login attempts
 	InitLoginBan(cfg)
 
-	// Routes are now managed in the routes package
+	// Rate limits for posting comments
+	InitCommentLimits(cfg)
+
+	// Malware scanner for attachments
+	InitUploadScanner(cfg)
+
+	// Rate limit for edit suggestions
+	InitSuggestions(cfg)
+
+	// What sessions stay bound to
+	auth.SetSessionBinding(cfg.Security.SessionBinding, cfg.Server.TrustProxy)
+
+	// Allowlist for sanitizing rendered HTML
+	sanitize.Configure(cfg.Security.Sanitize.Enabled, cfg.Security.Sanitize.AllowedTags,
+		cfg.Security.Sanitize.AllowedAttributes, cfg.Security.Sanitize.IframeHosts)
+
+	// Rendered documents are kept per configuration
+	renderCache.Resize(cfg.Wiki.RenderCacheSize)
+	renderCache.Purge()
+
+	// Files holding the content of documents
+	utils.SetIndexFiles(cfg.Wiki.IndexFile, cfg.Wiki.IndexFallbacks)
+
+	// Markdown extensions for the shared renderer
+	utils.ConfigureMarkdown(utils.MarkdownOptions{
+		Tables:          cfg.Wiki.Markdown.Tables,
+		Strikethrough:   cfg.Wiki.Markdown.Strikethrough,
+		Autolinks:       cfg.Wiki.Markdown.Autolinks,
+		TaskLists:       cfg.Wiki.Markdown.TaskLists,
+		Footnotes:       cfg.Wiki.Markdown.Footnotes,
+		DefinitionLists: cfg.Wiki.Markdown.DefinitionLists,
+		RawHTML:         cfg.Wiki.Markdown.RawHTML,
+		HardWraps:       cfg.Wiki.Markdown.HardWraps,
+		Emoji:           cfg.Wiki.Markdown.Emoji,
+	})
 }
 
 // We don't need this anymore since main.go handles the routing
 // func handlePage(w http.ResponseWriter, r *http.Request) {
 //     PageHandler(w, r, cfg)
 // }
+
+// liftTimeouts clears the read and write deadlines of server.limits for a
+// request that moves a whole file, such as an upload or a backup download,
+// which can take longer than a page on a slow connection. It is only used
+// once the user is known to be allowed, so it doesn't open the door to slow
+// clients.
+func liftTimeouts(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/version"
+)
+
+// startTime records when the process started, used for uptime reporting
+var startTime = time.Now()
+
+// HealthResponse represents the response body of the liveness probe
+type HealthResponse struct {
+	Status    string `json:"status"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Uptime    string `json:"uptime"`
+}
+
+// ReadyResponse represents the response body of the readiness probe
+type ReadyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// HealthHandler reports that the process is alive. It never requires authentication.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(HealthResponse{
+		Status:    "ok",
+		Version:   version.Version,
+		GoVersion: runtime.Version(),
+		Uptime:    time.Since(startTime).Round(time.Second).String(),
+	})
+}
+
+// ReadyHandler reports whether the wiki is ready to serve traffic: the configuration
+// is loaded, the documents directory is writable and background workers are running.
+// It never requires authentication.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	checks := make(map[string]string)
+	ready := true
+
+	// Configuration must be loaded before anything else can work
+	if cfg == nil {
+		checks["config"] = "not loaded"
+		ready = false
+	} else {
+		checks["config"] = "ok"
+
+		// Documents directory must exist and accept writes. The probe is
+		// unauthenticated, so the reason is logged rather than returned.
+		documentsDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+		if err := checkDirWritable(documentsDir, filepath.Join(cfg.Wiki.RootDir, "temp")); err != nil {
+			log.Printf("Warning: readiness check of the documents directory failed: %v", err)
+			checks["documents_dir"] = "unavailable"
+			ready = false
+		} else {
+			checks["documents_dir"] = "ok"
+		}
+	}
+
+	// Background session cleanup must be running
+	if auth.SessionCleanupRunning() {
+		checks["session_cleanup"] = "ok"
+	} else {
+		checks["session_cleanup"] = "not running"
+		ready = false
+	}
+
+	response := ReadyResponse{
+		Status: "ready",
+		Checks: checks,
+	}
+	statusCode := http.StatusOK
+	if !ready {
+		response.Status = "not ready"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkDirWritable verifies that dir is a directory whose mode allows writes,
+// on a filesystem that accepts them. The write is tried in probeDir, so that
+// nothing is created among the documents.
+func checkDirWritable(dir, probeDir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &os.PathError{Op: "stat", Path: dir, Err: os.ErrInvalid}
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		return &os.PathError{Op: "stat", Path: dir, Err: os.ErrPermission}
+	}
+
+	if err := os.MkdirAll(probeDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(probeDir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
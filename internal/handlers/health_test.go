@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The readiness probe is unauthenticated: it reveals no paths and leaves the
+// documents directory alone
+func TestReadyHandlerDocumentsDir(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "guide")
+	docsDir := filepath.Join(testCfg.Wiki.RootDir, testCfg.Wiki.DocumentsDir)
+
+	ready := func() (string, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		ReadyHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		body := rec.Body.String()
+		var resp ReadyResponse
+		if err := json.NewDecoder(strings.NewReader(body)).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Checks["documents_dir"], body
+	}
+
+	if check, _ := ready(); check != "ok" {
+		t.Errorf("documents_dir = %q, want ok", check)
+	}
+	entries, err := os.ReadDir(docsDir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("documents directory holds %d entries after the probe, %v", len(entries), err)
+	}
+
+	if err := os.RemoveAll(docsDir); err != nil {
+		t.Fatal(err)
+	}
+	check, body := ready()
+	if check != "unavailable" {
+		t.Errorf("documents_dir = %q, want unavailable", check)
+	}
+	if strings.Contains(body, testCfg.Wiki.RootDir) {
+		t.Errorf("response reveals the path: %s", body)
+	}
+}
@@ -7,11 +7,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
 	"wiki-go/internal/i18n"
 	"wiki-go/internal/types"
 	"wiki-go/internal/utils"
@@ -459,8 +459,8 @@ These shortcodes display document statistics like total count or recent changes.
 
 // EnsureHomepageExists creates the default homepage if it doesn't exist
 func EnsureHomepageExists(cfg *config.Config) error {
-	homepageDir := filepath.Join(cfg.Wiki.RootDir, "pages", "home")
-	homepagePath := filepath.Join(homepageDir, "document.md")
+	homepageDir := homePageDir(cfg)
+	homepagePath := utils.DocumentFile(homepageDir)
 
 	// Check if homepage directory exists, if not create it
 	if _, err := os.Stat(homepageDir); os.IsNotExist(err) {
@@ -471,7 +471,7 @@ func EnsureHomepageExists(cfg *config.Config) error {
 
 	// Check if homepage file exists, if not create it
 	if _, err := os.Stat(homepagePath); os.IsNotExist(err) {
-		if err := os.WriteFile(homepagePath, []byte(defaultHomepageContent), 0644); err != nil {
+		if err := doclock.WriteFile(homepagePath, []byte(defaultHomepageContent), 0644); err != nil {
 			return fmt.Errorf("failed to create homepage file: %w", err)
 		}
 		fmt.Println("Created default homepage at", homepagePath)
@@ -526,7 +526,7 @@ func HomeHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	}
 
 	// Get navigation items
-	nav, err := utils.BuildNavigation(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	nav, err := utils.BuildNavigation(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, config.VersionsPath(cfg))
 	if err != nil {
 		log.Printf("Error building navigation: %v", err)
 		http.Error(w, "Failed to build navigation", http.StatusInternalServerError)
@@ -541,8 +541,10 @@ func HomeHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	// Mark active navigation item
 	utils.MarkActiveNavItem(nav, "/")
 
-	// Get the homepage path from the pages directory
-	homepagePath := filepath.Join(cfg.Wiki.RootDir, "pages", "home", "document.md")
+	// Get the homepage path from the pages directory, in the reader's language if translated
+	homepageDir := homePageDir(cfg)
+	variant, documentLang, translations := selectTranslation(w, r, homepageDir, "/")
+	homepagePath := utils.TranslationFile(homepageDir, variant)
 
 	// Always read the content from disk on each request to ensure
 	// we display the most up-to-date version
@@ -560,11 +562,8 @@ func HomeHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	}
 
 	// Get file information for last modified date
-	docInfo, err := os.Stat(homepagePath)
-	var lastModified time.Time
-	if err == nil {
-		lastModified = docInfo.ModTime()
-	} else {
+	lastModified, lastEditedBy := utils.LastEdit(homepagePath, versionsDir("/", variant))
+	if lastModified.IsZero() {
 		lastModified = time.Now()
 	}
 
@@ -579,13 +578,24 @@ func HomeHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	}
 
 	// Render the markdown content
-	renderedContent := template.HTML(utils.RenderMarkdown(string(content)))
+	renderedContent := template.HTML(renderDocument(content, ""))
 	
 	// If content is empty but home document exists, ensure we have something truthy for template conditions
 	if strings.TrimSpace(string(renderedContent)) == "" {
 		renderedContent = template.HTML(" ") // Single space to make it truthy but effectively empty
 	}
 
+	var viewCount int64
+	if !isEditMode {
+		viewCount = recordView(r, "/")
+	}
+
+	// Print mode renders just the document, without navigation chrome
+	if isPrintMode(r) {
+		renderPrintPage(w, r, cfg, i18n.Translate("nav.home"), renderedContent, lastModified)
+		return
+	}
+
 	// Render the page
 	data := &types.PageData{
 		Navigation:         &types.NavTree{Root: nav, AlwaysOpen: cfg.Wiki.AlwaysOpenChildrenInSidebar},
@@ -597,10 +607,14 @@ func HomeHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		AvailableLanguages: i18n.GetAvailableLanguages(),
 		IsAuthenticated:    isAuthenticated,
 		UserRole:           userRole,
-		DocPath:            "pages/home", // Special path for homepage
+		DocPath:            homeDocPath, // Special path for homepage
 		IsEditMode:         isEditMode,
 		RawContent:         rawContent,
+		ViewCount:          viewCount,
+		LastEditedBy:       lastEditedBy,
+		DocumentLang:       documentLang,
+		Translations:       translations,
 	}
 
-	renderTemplate(w, data)
+	renderDocumentTemplate(w, r, data)
 }
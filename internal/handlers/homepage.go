@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"wiki-go/internal/config"
+)
+
+// homeDocPath is the document path clients send for the homepage in API
+// requests, wherever wiki.home_page stores it
+const homeDocPath = "pages/home"
+
+// homePagePath returns the configured homepage directory relative to the wiki
+// root, slash-separated and cleaned
+func homePagePath(cfg *config.Config) string {
+	if home := strings.Trim(path.Clean("/"+filepath.ToSlash(cfg.Wiki.HomePage)), "/"); home != "" {
+		return home
+	}
+	return config.DefaultHomePage
+}
+
+// homePageDir returns the directory holding the homepage document
+func homePageDir(cfg *config.Config) string {
+	return filepath.Join(cfg.Wiki.RootDir, filepath.FromSlash(homePagePath(cfg)))
+}
+
+// homePageVersionsDir returns the directory holding the homepage's versions
+func homePageVersionsDir(cfg *config.Config) string {
+	return filepath.Join(config.VersionsPath(cfg), filepath.FromSlash(homePagePath(cfg)))
+}
+
+// homePageDocument returns the homepage's path relative to the documents
+// directory, or "" when the homepage is stored outside of it
+func homePageDocument(cfg *config.Config) string {
+	docs := path.Clean(filepath.ToSlash(cfg.Wiki.DocumentsDir))
+	if rel, ok := strings.CutPrefix(homePagePath(cfg), docs+"/"); ok {
+		return rel
+	}
+	return ""
+}
+
+// isHomePage reports whether docPath, relative to the documents directory,
+// refers to the homepage: the root, homeDocPath, or the configured homepage
+// when it is a document. The comparison is on the cleaned path, and on the
+// directory itself so that another spelling of it on a case-insensitive file
+// system still matches; similar names such as "homepage" don't.
+func isHomePage(docPath string, cfg *config.Config) bool {
+	docPath = strings.Trim(path.Clean("/"+filepath.ToSlash(docPath)), "/")
+	if docPath == "" || docPath == homeDocPath {
+		return true
+	}
+	if homePageDocument(cfg) == "" {
+		return false
+	}
+	if docPath == homePageDocument(cfg) {
+		return true
+	}
+	dir, err := os.Stat(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, filepath.FromSlash(docPath)))
+	if err != nil {
+		return false
+	}
+	home, err := os.Stat(homePageDir(cfg))
+	return err == nil && os.SameFile(dir, home)
+}
+
+// pagesFilePath maps a path below "pages/" in an API request to the file
+// system. Paths below homeDocPath are in the configured homepage directory.
+func pagesFilePath(cfg *config.Config, p string) string {
+	if p == homeDocPath || strings.HasPrefix(p, homeDocPath+"/") {
+		return filepath.Join(homePageDir(cfg), filepath.FromSlash(strings.TrimPrefix(p, homeDocPath)))
+	}
+	return filepath.Join(cfg.Wiki.RootDir, filepath.FromSlash(p))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsHomePage(t *testing.T) {
+	tests := []struct {
+		name     string
+		homePage string // wiki.home_page; empty uses the default
+		path     string
+		want     bool
+	}{
+		{"root", "", "", true},
+		{"slash", "", "/", true},
+		{"default homepage", "", "pages/home", true},
+		{"trailing slash", "", "pages/home/", true},
+		{"leading slash", "", "/pages/home", true},
+		{"unclean path", "", "pages/./x/../home", true},
+		{"different case", "", "PAGES/HOME", false},
+		{"document named homepage", "", "homepage", false},
+		{"nested document named homepage", "", "guides/homepage", false},
+		{"below the homepage", "", "pages/home/child", false},
+		{"parent of the homepage", "", "pages", false},
+		{"configured document", "documents/welcome", "welcome", true},
+		{"configured document with slashes", "documents/welcome", "/welcome/", true},
+		{"default stays protected", "documents/welcome", "pages/home", true},
+		{"below the configured document", "documents/welcome", "welcome/child", false},
+		{"similar name", "documents/welcome", "welcome-back", false},
+		{"homepage outside the documents", "pages/landing", "landing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t, "welcome", "welcome/child", "welcome-back", "homepage", "guides/homepage")
+			testCfg.Wiki.HomePage = tt.homePage
+			if got := isHomePage(tt.path, testCfg); got != tt.want {
+				t.Errorf("isHomePage(%q) with home_page %q = %v, want %v", tt.path, tt.homePage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveDocumentNamedHomepage(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides", "guides/homepage", "archive")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(`{"sourcePath":"guides/homepage","targetPath":"archive"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MoveDocumentHandler(rec, req, testCfg)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	moved := filepath.Join(testCfg.Wiki.RootDir, "documents", "archive", "homepage", "document.md")
+	if _, err := os.Stat(moved); err != nil {
+		t.Errorf("document was not moved: %v", err)
+	}
+}
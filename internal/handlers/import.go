@@ -15,6 +15,8 @@ import (
 	"time"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/utils"
 )
 
 // ImportResponse represents the response for the import API
@@ -34,6 +36,7 @@ type ImportStatusResponse struct {
 	ErrorCount   int          `json:"errorCount"`
 	ImportedFiles []ImportedFile `json:"importedFiles,omitempty"`
 	Errors       []string     `json:"errors,omitempty"`
+	Warnings     []string     `json:"warnings,omitempty"` // Content that was imported but not fully converted
 	Message      string       `json:"message,omitempty"`
 }
 
@@ -47,7 +50,9 @@ type ImportedFile struct {
 var importJobs = make(map[string]*ImportStatusResponse)
 var importJobsMutex sync.RWMutex
 
-// ImportHandler handles the import of documents from a ZIP file
+// ImportHandler handles the import of documents from a ZIP file of markdown
+// files, or from a MediaWiki XML dump (.xml) placed under the optional
+// "target" category
 func ImportHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	// Set appropriate headers
 	w.Header().Set("Content-Type", "application/json")
@@ -78,6 +83,7 @@ func ImportHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	maxUploadSizeFormatted := config.GetMaxUploadSizeFormatted(cfg)
 
 	// Parse the multipart form with a maximum size
+	liftTimeouts(w)
 	err := r.ParseMultipartForm(maxUploadSize)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -90,6 +96,9 @@ func ImportHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 
 	// Get the uploaded file
 	file, fileHeader, err := r.FormFile("zipFile")
+	if err != nil {
+		file, fileHeader, err = r.FormFile("file")
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ImportResponse{
@@ -101,11 +110,12 @@ func ImportHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	defer file.Close()
 
 	// Validate file extension
-	if !strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".zip") {
+	isMediaWiki := strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".xml")
+	if !isMediaWiki && !strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".zip") {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ImportResponse{
 			Success: false,
-			Message: "Invalid file type. Only ZIP files are allowed.",
+			Message: "Invalid file type. Only ZIP files and MediaWiki XML dumps are allowed.",
 		})
 		return
 	}
@@ -137,7 +147,11 @@ func ImportHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	importJobsMutex.Unlock()
 
 	// Start the import process in a goroutine
-	go processImportFromBytes(fileBytes, jobID, cfg)
+	if isMediaWiki {
+		go processMediaWikiImport(fileBytes, jobID, r.FormValue("target"), cfg)
+	} else {
+		go processImportFromBytes(fileBytes, jobID, cfg)
+	}
 
 	// Return success response with job ID
 	w.WriteHeader(http.StatusOK)
@@ -279,11 +293,18 @@ func processMarkdownFile(file *zip.File, jobID string, cfg *config.Config) error
 	}
 	defer fileReader.Close()
 
-	// Read the file content
-	content, err := io.ReadAll(fileReader)
+	// Read the file content, skipping documents the editor couldn't save
+	reader := io.Reader(fileReader)
+	if limit := maxDocumentBytes(); limit > 0 {
+		reader = io.LimitReader(fileReader, limit+1)
+	}
+	content, err := io.ReadAll(reader)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %v", err)
 	}
+	if checkDocumentSize(content) != nil {
+		return fmt.Errorf("document too large, the maximum size is %s", maxDocumentSizeText())
+	}
 
 	// Determine the target path based on the file's path in the ZIP
 	originalPath := file.Name
@@ -301,11 +322,13 @@ func processMarkdownFile(file *zip.File, jobID string, cfg *config.Config) error
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
-	// Write the content to document.md in the target directory
-	docPath := filepath.Join(docDir, "document.md")
+	// Write the content to the document's file in the target directory
+	docPath := utils.DocumentFile(docDir)
 	
-	// Ensure the content has proper permissions
-	err = os.WriteFile(docPath, content, 0644)
+	// Written atomically under the document's edit lock
+	unlock := doclock.Lock(docPath)
+	err = doclock.WriteFile(docPath, content, 0644)
+	unlock()
 	if err != nil {
 		return fmt.Errorf("failed to write file: %v", err)
 	}
@@ -405,6 +428,16 @@ func addImportedFile(jobID, originalPath, newPath string) {
 	}
 }
 
+// addImportWarning adds a warning to the job status
+func addImportWarning(jobID, warning string) {
+	importJobsMutex.Lock()
+	defer importJobsMutex.Unlock()
+
+	if job, exists := importJobs[jobID]; exists {
+		job.Warnings = append(job.Warnings, warning)
+	}
+}
+
 // addImportError adds an error to the job status
 func addImportError(jobID, errorMsg string) {
 	importJobsMutex.Lock()
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/mediawiki"
+	"wiki-go/internal/utils"
+
+	"github.com/gosimple/slug"
+)
+
+// processMediaWikiImport creates a document below target for every page in the
+// main namespace of a MediaWiki XML dump. Subpages ("Parent/Child") become
+// child documents. Existing documents are left alone.
+func processMediaWikiImport(data []byte, jobID, target string, cfg *config.Config) {
+	target = strings.Trim(path.Clean("/"+strings.ReplaceAll(target, "\\", "/")), "/")
+	totalPages := bytes.Count(data, []byte("<page>"))
+	if totalPages == 0 {
+		updateImportStatus(jobID, "failed", 0, "", "No pages found in the MediaWiki dump.")
+		return
+	}
+
+	dump := mediawiki.NewDump(bytes.NewReader(data))
+	converter := &mediawiki.Converter{
+		Namespaces: dump.Namespaces(),
+		Link: func(title string) string {
+			return mediaWikiPagePath(target, title)
+		},
+	}
+
+	processed, otherNamespaces := 0, 0
+	for {
+		page, err := dump.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			updateImportStatus(jobID, "failed", 0, "", fmt.Sprintf("Failed to read MediaWiki dump: %v", err))
+			return
+		}
+		updateImportStatusFile(jobID, page.Title)
+
+		switch {
+		case page.Namespace != mediawiki.NamespaceMain:
+			otherNamespaces++
+		case page.Redirect != "":
+			addImportWarning(jobID, fmt.Sprintf("%s: redirect to %s was not imported", page.Title, page.Redirect))
+		default:
+			if err := importMediaWikiPage(page, converter, target, jobID, cfg); err != nil {
+				addImportError(jobID, fmt.Sprintf("Error importing %s: %v", page.Title, err))
+			}
+		}
+
+		processed++
+		updateImportStatusProgress(jobID, min(processed*100/totalPages, 99))
+	}
+	if otherNamespaces > 0 {
+		addImportWarning(jobID, fmt.Sprintf("%d pages outside the main namespace (talk, user, template, file, category...) were skipped", otherNamespaces))
+	}
+	invalidateWikiLinkIndex()
+
+	importJobsMutex.RLock()
+	status := importJobs[jobID]
+	importJobsMutex.RUnlock()
+
+	switch {
+	case status.SuccessCount == 0 && status.ErrorCount == 0:
+		updateImportStatus(jobID, "failed", 100, "", "No pages in the main namespace to import.")
+	case status.ErrorCount == 0:
+		updateImportStatus(jobID, "completed", 100, "", "Import completed successfully.")
+	case status.SuccessCount == 0:
+		updateImportStatus(jobID, "failed", 100, "", "Import failed. No pages were imported successfully.")
+	default:
+		updateImportStatus(jobID, "completed", 100, "", fmt.Sprintf("Import completed with %d errors.", status.ErrorCount))
+	}
+}
+
+// importMediaWikiPage converts one page and writes it as a new document,
+// reporting what the conversion left as it was as warnings
+func importMediaWikiPage(page *mediawiki.Page, converter *mediawiki.Converter, target, jobID string, cfg *config.Config) error {
+	docPath := mediaWikiPagePath(target, page.Title)
+	if docPath == target {
+		return fmt.Errorf("cannot derive a document path from the title")
+	}
+	docFile := utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, filepath.FromSlash(docPath)))
+
+	unlock := doclock.Lock(docFile)
+	defer unlock()
+	if _, err := os.Stat(docFile); err == nil {
+		return fmt.Errorf("a document already exists at /%s", docPath)
+	}
+
+	markdown, notes := converter.Convert(page.Title, page.Text)
+	content := "# " + path.Base(page.Title) + "\n\n" + markdown
+	if checkDocumentSize([]byte(content)) != nil {
+		return fmt.Errorf("document too large, the maximum size is %s", maxDocumentSizeText())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(docFile), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := doclock.WriteFile(docFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	for _, note := range notes {
+		addImportWarning(jobID, page.Title+": "+note)
+	}
+	addImportedFile(jobID, page.Title, "/"+docPath)
+	return nil
+}
+
+// mediaWikiPagePath returns the document path below target for a page title,
+// slugging each subpage segment
+func mediaWikiPagePath(target, title string) string {
+	segments := strings.Split(strings.ReplaceAll(title, "_", " "), "/")
+	for i, segment := range segments {
+		segments[i] = slug.Make(segment)
+	}
+	return path.Join(target, path.Join(segments...))
+}
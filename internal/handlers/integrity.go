@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/utils"
+)
+
+// OrphanedData is the version history and comments kept for a document that no
+// longer exists, as a move or delete that failed halfway leaves behind
+type OrphanedData struct {
+	Path     string `json:"path"`     // URL path of the missing document
+	Versions int    `json:"versions"` // Files of its version history
+	Comments int    `json:"comments"` // Files of its comments
+	Size     int64  `json:"size"`     // Bytes they take up
+}
+
+// IntegrityReport is the JSON response of the integrity check
+type IntegrityReport struct {
+	Success bool           `json:"success"`
+	Orphans []OrphanedData `json:"orphans"`
+}
+
+// IntegrityCleanupRequest selects the orphaned data to clean up
+type IntegrityCleanupRequest struct {
+	Paths []string `json:"paths,omitempty"` // URL paths from the report; empty cleans up all
+}
+
+// IntegrityCleanupResponse lists what the cleanup removed
+type IntegrityCleanupResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Cleaned []string `json:"cleaned"`          // URL paths whose data was removed
+	Trash   []string `json:"trash,omitempty"`  // IDs of the trash entries holding it
+	Failed  []string `json:"failed,omitempty"` // URL paths whose data couldn't be removed
+}
+
+// IntegrityHandler reports the versions and comments that have no document
+// (GET /api/integrity) and cleans them up (POST /api/integrity/cleanup): into
+// the trash when it is enabled, where they can be restored, or for good
+func IntegrityHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/integrity" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(IntegrityReport{Success: true, Orphans: findOrphanedData()})
+	case r.URL.Path == "/api/integrity/cleanup" && r.Method == http.MethodPost:
+		cleanupOrphanedData(w, r)
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+	}
+}
+
+// findOrphanedData walks the version history and comments of the documents and
+// returns the data kept for paths that have no document, sorted by path
+func findOrphanedData() []OrphanedData {
+	cfg := config.Current()
+	versionsRoot, commentsRoot := documentDataDirs(cfg, "")
+	found := map[string]*OrphanedData{}
+	for _, root := range []string{versionsRoot, commentsRoot} {
+		filepath.WalkDir(root, func(dir string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() || dir == root {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			// Translation histories are counted with their document
+			if isTranslationHistory(d.Name()) {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return nil
+			}
+			urlPath := "/" + filepath.ToSlash(rel)
+			if _, err := os.Stat(utils.DocumentFile(documentDir(urlPath))); err == nil {
+				return nil
+			}
+
+			files, size := orphanedFiles(dir)
+			if files == 0 {
+				return nil
+			}
+			orphan := found[urlPath]
+			if orphan == nil {
+				orphan = &OrphanedData{Path: urlPath}
+				found[urlPath] = orphan
+			}
+			if root == versionsRoot {
+				orphan.Versions += files
+			} else {
+				orphan.Comments += files
+			}
+			orphan.Size += size
+			return nil
+		})
+	}
+
+	orphans := []OrphanedData{}
+	for _, orphan := range found {
+		orphans = append(orphans, *orphan)
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Path < orphans[j].Path })
+	return orphans
+}
+
+// orphanedFiles counts the files and bytes of the data directly in dir that
+// belong to its document, leaving out the data of documents below it
+func orphanedFiles(dir string) (files int, size int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0
+	}
+	for _, entry := range entries {
+		if !ownsDataEntry(entry.Name(), entry.IsDir()) {
+			continue
+		}
+		filepath.WalkDir(filepath.Join(dir, entry.Name()), func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			files++
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	return files, size
+}
+
+// ownsDataEntry reports whether an entry of a document's versions or comments
+// directory belongs to the document: its files and translation histories do,
+// the directories of documents below it don't
+func ownsDataEntry(name string, isDir bool) bool {
+	return !isDir || isTranslationHistory(name)
+}
+
+// isTranslationHistory reports whether a directory of a document's versions
+// holds the history of one of its translations
+func isTranslationHistory(name string) bool {
+	_, ok := utils.TranslationLanguage(name + ".md")
+	return ok
+}
+
+// cleanupOrphanedData removes the orphaned data the request selects
+func cleanupOrphanedData(w http.ResponseWriter, r *http.Request) {
+	var req IntegrityCleanupRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "Invalid request body", http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	selected := make([]string, len(req.Paths))
+	for i, p := range req.Paths {
+		selected[i] = "/" + documentKey(p)
+	}
+
+	username := ""
+	if session := auth.GetSession(r); session != nil {
+		username = session.Username
+	}
+	response := IntegrityCleanupResponse{Success: true, Cleaned: []string{}}
+	for _, orphan := range findOrphanedData() {
+		if len(selected) > 0 && !slices.Contains(selected, orphan.Path) {
+			continue
+		}
+		id, err := removeOrphanedData(orphan.Path, username)
+		if err != nil {
+			response.Failed = append(response.Failed, orphan.Path)
+			continue
+		}
+		response.Cleaned = append(response.Cleaned, orphan.Path)
+		if id != "" {
+			response.Trash = append(response.Trash, id)
+		}
+	}
+
+	response.Message = "Orphaned data cleaned up"
+	if len(response.Failed) > 0 {
+		response.Success = false
+		response.Message = "Some orphaned data could not be cleaned up"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// removeOrphanedData moves the data kept for the missing document at urlPath
+// to the trash, returning the ID of the entry, or deletes it when the trash is
+// disabled. A document created at the path meanwhile keeps its data.
+func removeOrphanedData(urlPath, username string) (string, error) {
+	cfg := config.Current()
+	docFile := utils.DocumentFile(documentDir(urlPath))
+	unlock := doclock.Lock(docFile)
+	defer unlock()
+	if _, err := os.Stat(docFile); err == nil {
+		return "", os.ErrExist
+	}
+
+	if cfg.Wiki.Trash.Enabled {
+		entry, err := trashBin().MoveOrphaned(documentKey(urlPath), username, ownsDataEntry)
+		if entry == nil {
+			return "", err
+		}
+		return entry.ID, err
+	}
+
+	versions, comments := documentDataDirs(cfg, urlPath)
+	for _, dir := range []string{versions, comments} {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !ownsDataEntry(entry.Name(), entry.IsDir()) {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return "", err
+			}
+		}
+		os.Remove(dir)
+	}
+	return "", nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIntegrityHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		trash   bool
+		body    string
+		cleaned []string // Paths whose data is gone afterwards
+		kept    []string // Orphans still reported afterwards
+	}{
+		{"all to trash", true, "", []string{"/gone", "/a/gone"}, nil},
+		{"selected", true, `{"paths":["gone"]}`, []string{"/gone"}, []string{"/a/gone"}},
+		{"without trash", false, `{"paths":["/a/gone"]}`, []string{"/a/gone"}, []string{"/gone"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+			testCfg.Wiki.Trash.Enabled = tt.trash
+			versions, comments := documentDataDirs(testCfg, "")
+			for _, file := range []string{
+				filepath.Join(versions, "a", "20260101000000.md"),
+				filepath.Join(versions, "gone", "20260101000000.md"),
+				filepath.Join(versions, "gone", "document.fr", "20260101000000.md"),
+				filepath.Join(comments, "a", "gone", "1.md"),
+			} {
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			report := integrityReport(t)
+			if len(report.Orphans) != 2 || report.Orphans[0].Path != "/a/gone" || report.Orphans[1].Path != "/gone" {
+				t.Fatalf("orphans = %+v", report.Orphans)
+			}
+			if gone := report.Orphans[1]; gone.Versions != 2 || gone.Comments != 0 || gone.Size != 8 {
+				t.Errorf("orphaned data of /gone = %+v", gone)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/integrity/cleanup", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			IntegrityHandler(rec, req)
+			var response IntegrityCleanupResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || !response.Success {
+				t.Fatalf("cleanup = %+v, %v", response, err)
+			}
+			if len(response.Cleaned) != len(tt.cleaned) {
+				t.Errorf("cleaned = %v, want %v", response.Cleaned, tt.cleaned)
+			}
+			if tt.trash != (len(response.Trash) == len(tt.cleaned)) {
+				t.Errorf("trash entries = %v", response.Trash)
+			}
+
+			report = integrityReport(t)
+			if len(report.Orphans) != len(tt.kept) || (len(tt.kept) > 0 && report.Orphans[0].Path != tt.kept[0]) {
+				t.Errorf("orphans after cleanup = %+v, want %v", report.Orphans, tt.kept)
+			}
+			if _, err := os.Stat(filepath.Join(versions, "a", "20260101000000.md")); err != nil {
+				t.Errorf("versions of an existing document were removed: %v", err)
+			}
+		})
+	}
+}
+
+// integrityReport runs the integrity check
+func integrityReport(t *testing.T) IntegrityReport {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	IntegrityHandler(rec, httptest.NewRequest(http.MethodGet, "/api/integrity", nil))
+	var report IntegrityReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/utils"
+)
+
+// Default and maximum number of link suggestions returned
+const (
+	defaultLinkSuggestions = 10
+	maxLinkSuggestions     = 50
+)
+
+// LinkSuggestion is a single autocomplete candidate
+type LinkSuggestion struct {
+	Title string `json:"title"`
+	Path  string `json:"path"`
+	Score int    `json:"score"`
+}
+
+// LinkSuggestHandler returns documents whose title or slug matches a partial query,
+// ranked by relevance. URL format: /api/links/suggest?q={query}&limit={n}
+func LinkSuggestHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	limit := defaultLinkSuggestions
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = min(n, maxLinkSuggestions)
+	}
+
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		sendJSONError(w, "Failed to list documents", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	session := auth.GetSession(r)
+	suggestions := []LinkSuggestion{}
+	for _, doc := range documents {
+		if !auth.CanAccessDocument(doc.Path, session, cfg) {
+			continue
+		}
+		score := linkMatchScore(query, doc)
+		if score == 0 {
+			continue
+		}
+		suggestions = append(suggestions, LinkSuggestion{Title: doc.Title, Path: doc.Path, Score: score})
+	}
+
+	// Best matches first, then shorter (more specific) titles, then alphabetically
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Title) != len(b.Title) {
+			return len(a.Title) < len(b.Title)
+		}
+		return a.Path < b.Path
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"suggestions": suggestions,
+	})
+}
+
+// linkMatchScore rates how well a document matches a lowercase query; 0 means no match.
+// An empty query matches everything with the lowest score.
+func linkMatchScore(query string, doc utils.DocumentEntry) int {
+	if query == "" {
+		return 1
+	}
+
+	title := strings.ToLower(doc.Title)
+	slug := strings.ToLower(path.Base(doc.Path))
+	fullPath := strings.ToLower(strings.TrimPrefix(doc.Path, "/"))
+
+	switch {
+	case title == query || slug == query:
+		return 100
+	case strings.HasPrefix(title, query):
+		return 80
+	case strings.HasPrefix(slug, query):
+		return 70
+	case hasWordPrefix(title, query):
+		return 60
+	case strings.Contains(title, query):
+		return 40
+	case strings.Contains(fullPath, query):
+		return 20
+	}
+	return 0
+}
+
+// hasWordPrefix reports whether any word of s starts with prefix
+func hasWordPrefix(s, prefix string) bool {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_' || r == '/'
+	})
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
 	"wiki-go/internal/frontmatter"
 	"wiki-go/internal/utils"
 )
@@ -70,6 +72,10 @@ func AddLinkHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Get document path
 	docPath := getDocumentPath(decodedPath)
+
+	// The document is read and written back under its edit lock
+	unlock := doclock.Lock(docPath)
+	defer unlock()
 	
 	// Read current document
 	content, err := os.ReadFile(docPath)
@@ -164,6 +170,10 @@ func EditLinkHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Get document path
 	docPath := getDocumentPath(decodedPath)
+
+	// The document is read and written back under its edit lock
+	unlock := doclock.Lock(docPath)
+	defer unlock()
 	
 	// Read current document
 	content, err := os.ReadFile(docPath)
@@ -285,6 +295,10 @@ func DeleteLinkHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Get document path
 	docPath := getDocumentPath(decodedPath)
+
+	// The document is read and written back under its edit lock
+	unlock := doclock.Lock(docPath)
+	defer unlock()
 	
 	// Read current document
 	content, err := os.ReadFile(docPath)
@@ -412,13 +426,15 @@ func parseDate(dateStr string) time.Time {
 }
 
 func getDocumentPath(path string) string {
+	cfg := config.Current()
+
 	// Clean and normalize the path
 	path = filepath.Clean(path)
 	path = strings.TrimSuffix(path, "/")
 	path = strings.ReplaceAll(path, "\\", "/")
 
 	// Build the full filesystem path
-	return filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path, "document.md")
+	return utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, path))
 }
 
 func generateLinksMarkdown(linksData *frontmatter.LinksData, originalContent string) (string, error) {
@@ -506,6 +522,8 @@ func generateLinksMarkdown(linksData *frontmatter.LinksData, originalContent str
 }
 
 func saveDocumentWithVersioning(docPath, relativePath string, content []byte) error {
+	cfg := config.Current()
+
 	// VERSION CONTROL: Save current version before overwriting (same logic as SaveHandler)
 	if _, err := os.Stat(docPath); err == nil && cfg.Wiki.MaxVersions > 0 {
 		// Document exists, read its current content
@@ -515,7 +533,7 @@ func saveDocumentWithVersioning(docPath, relativePath string, content []byte) er
 			timestamp := time.Now().Format("20060102150405")
 
 			// Create versions directory path that mirrors the document path
-			versionDir := filepath.Join(cfg.Wiki.RootDir, "versions", "documents", relativePath)
+			versionDir := filepath.Join(config.VersionsPath(cfg), "documents", relativePath)
 
 			// Ensure versions directory exists
 			if err := os.MkdirAll(versionDir, 0755); err == nil {
@@ -541,7 +559,7 @@ func saveDocumentWithVersioning(docPath, relativePath string, content []byte) er
 	}
 
 	// Write the content to the file
-	if err := os.WriteFile(docPath, content, 0644); err != nil {
+	if err := doclock.WriteFile(docPath, content, 0644); err != nil {
 		return fmt.Errorf("failed to save document: %v", err)
 	}
 
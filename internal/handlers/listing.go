@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxListLimit caps the page size of paginated listings
+const maxListLimit = 1000
+
+// listPage is the limit and cursor of a paginated listing. Listings walk the
+// documents directory in lexical order; the cursor names the file of the last
+// item returned, so the next page resumes after it without buffering or
+// re-reading the items before it. A zero limit means the whole listing, which
+// is then streamed as it is walked.
+type listPage struct {
+	limit int
+	after string // Path of the last returned file relative to the walked root
+}
+
+// parseListPage reads the limit and cursor query parameters
+func parseListPage(r *http.Request) (listPage, error) {
+	var page listPage
+	q := r.URL.Query()
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return page, errors.New("limit must be a positive number")
+		}
+		page.limit = min(n, maxListLimit)
+	}
+	if raw := q.Get("cursor"); raw != "" {
+		if page.limit == 0 {
+			return page, errors.New("cursor requires limit")
+		}
+		after, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil || !fs.ValidPath(string(after)) {
+			return page, errors.New("invalid cursor")
+		}
+		page.after = string(after)
+	}
+	return page, nil
+}
+
+// encodeCursor returns the cursor resuming a listing after the file at rel
+func encodeCursor(rel string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(filepath.ToSlash(rel)))
+}
+
+// walkOrderLess reports whether slash-separated path a is visited before b by
+// filepath.WalkDir, which sorts each directory's entries by name
+func walkOrderLess(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// walkAfter walks root like filepath.WalkDir but skips every entry up to and
+// including the file after, relative to root. Directories that lie entirely
+// before it are not descended into.
+func walkAfter(root, after string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if after != "" && d != nil {
+			rel, relErr := filepath.Rel(root, path)
+			if relErr == nil && rel != "." {
+				rel = filepath.ToSlash(rel)
+				if d.IsDir() {
+					if !strings.HasPrefix(after, rel+"/") && walkOrderLess(rel, after) {
+						return filepath.SkipDir
+					}
+				} else if !walkOrderLess(after, rel) {
+					return nil
+				}
+			}
+		}
+		return fn(path, d, err)
+	})
+}
+
+// jsonArrayWriter streams the elements of a JSON array to a response so that
+// long listings don't have to be held in memory
+type jsonArrayWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	started bool
+	prefix  string // Written before the first element, ends with "["
+	suffix  string // Written after the last element, starts with "]"
+}
+
+func newJSONArrayWriter(w http.ResponseWriter, prefix, suffix string) *jsonArrayWriter {
+	return &jsonArrayWriter{w: w, enc: json.NewEncoder(w), prefix: prefix, suffix: suffix}
+}
+
+// Write appends one element to the array
+func (a *jsonArrayWriter) Write(v interface{}) error {
+	if !a.started {
+		a.started = true
+		if _, err := a.w.Write([]byte(a.prefix)); err != nil {
+			return err
+		}
+	} else if _, err := a.w.Write([]byte(",")); err != nil {
+		return err
+	}
+	return a.enc.Encode(v)
+}
+
+// Started reports whether anything has been written, after which the status
+// can no longer change
+func (a *jsonArrayWriter) Started() bool {
+	return a.started
+}
+
+// Close ends the array and the enclosing document
+func (a *jsonArrayWriter) Close() error {
+	if !a.started {
+		a.started = true
+		if _, err := a.w.Write([]byte(a.prefix)); err != nil {
+			return err
+		}
+	}
+	_, err := a.w.Write([]byte(a.suffix))
+	return err
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWalkAfterPages(t *testing.T) {
+	root := t.TempDir()
+	// "a-b" sorts before "a/..." as a string but is walked after the "a" directory
+	files := []string{"a/document.md", "a/x/document.md", "a-b/document.md", "b/c/d/document.md", "b/document.md", "c/document.md"}
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# doc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(after string, limit int) []string {
+		var got []string
+		walkAfter(root, after, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if len(got) == limit {
+				return fs.SkipAll
+			}
+			rel, _ := filepath.Rel(root, path)
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		})
+		return got
+	}
+
+	if got := list("", len(files)+1); !slices.Equal(got, files) {
+		t.Fatalf("full walk = %v, want %v", got, files)
+	}
+
+	// Paging through two at a time visits every file exactly once, in order
+	var paged []string
+	after := ""
+	for {
+		page := list(after, 2)
+		if len(page) == 0 {
+			break
+		}
+		paged = append(paged, page...)
+		after = page[len(page)-1]
+	}
+	if !slices.Equal(paged, files) {
+		t.Errorf("paged walk = %v, want %v", paged, files)
+	}
+}
+
+func TestWalkOrderLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a/document.md", "a-b/document.md", true},
+		{"a-b/document.md", "a/document.md", false},
+		{"a", "a/document.md", true},
+		{"a/document.md", "a/document.md", false},
+		{"b/c", "b/document.md", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" < "+tt.b, func(t *testing.T) {
+			if got := walkOrderLess(tt.a, tt.b); got != tt.want {
+				t.Errorf("walkOrderLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
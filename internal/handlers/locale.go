@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/i18n"
+)
+
+// requestLanguage returns the language for server-generated messages in response
+// to r: the signed-in user's preference, else the browser's Accept-Language
+func requestLanguage(r *http.Request) string {
+	cfg := config.Current()
+	preferred := ""
+	if session := auth.GetSession(r); session != nil {
+		for _, user := range cfg.Users {
+			if user.Username == session.Username {
+				preferred = user.Language
+				break
+			}
+		}
+	}
+	return i18n.RequestLanguage(r, preferred)
+}
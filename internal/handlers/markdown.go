@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"io"
+	"errors"
 	"net/http"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/utils"
@@ -29,7 +29,11 @@ func RenderMarkdownHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read the markdown content from the request body
-	markdown, err := io.ReadAll(r.Body)
+	markdown, err := readDocumentBody(w, r)
+	if errors.Is(err, errDocumentTooLarge) {
+		http.Error(w, "Document too large. Maximum size is "+maxDocumentSizeText()+".", http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
 		return
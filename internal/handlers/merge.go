@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/roles"
+	"wiki-go/internal/utils"
+)
+
+// Ways of merging a secondary document into a primary one
+const (
+	MergeAppend   = "append"   // The secondary's content goes below the primary's
+	MergeSections = "sections" // Sections go under the primary's sections of the same heading
+)
+
+// What becomes of the secondary document after a merge
+const (
+	MergeThenTrash    = "trash"    // It is moved to the trash
+	MergeThenRedirect = "redirect" // It is replaced by a redirect to the primary
+)
+
+// MergeRequest is the JSON payload of a merge
+type MergeRequest struct {
+	Primary      string `json:"primary"`      // Document that receives the content
+	Secondary    string `json:"secondary"`    // Document merged into it
+	Mode         string `json:"mode"`         // MergeAppend (default) or MergeSections
+	Then         string `json:"then"`         // MergeThenTrash (default) or MergeThenRedirect
+	RewriteLinks bool   `json:"rewriteLinks"` // Point links to the secondary at the primary
+}
+
+// MergeResponse is the JSON response of a merge
+type MergeResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Primary string `json:"primary"`
+	TrashID string `json:"trashId,omitempty"` // Trash entry of the secondary, to undo
+}
+
+// MergeDocumentsHandler merges a secondary document into a primary one, e.g.
+// after finding near-duplicates. The primary keeps its front matter and gets
+// a new version; the secondary's attachments move to it. The secondary is
+// then trashed or turned into a redirect, and links to it can be rewritten.
+func MergeDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+	session := auth.GetSession(r)
+	if session == nil || (session.Role != roles.RoleAdmin && session.Role != roles.RoleEditor) {
+		sendJSONError(w, "Unauthorized. Admin or editor access required.", http.StatusUnauthorized, "")
+		return
+	}
+
+	var req MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest, err.Error())
+		return
+	}
+	primary, secondary := comparePath(req.Primary), comparePath(req.Secondary)
+	if req.Mode == "" {
+		req.Mode = MergeAppend
+	}
+	if req.Then == "" {
+		req.Then = MergeThenTrash
+	}
+
+	switch {
+	case req.Primary == "" || req.Secondary == "":
+		sendJSONError(w, "Both primary and secondary are required", http.StatusBadRequest, "")
+		return
+	case req.Mode != MergeAppend && req.Mode != MergeSections:
+		sendJSONError(w, "mode must be append or sections", http.StatusBadRequest, "")
+		return
+	case req.Then != MergeThenTrash && req.Then != MergeThenRedirect:
+		sendJSONError(w, "then must be trash or redirect", http.StatusBadRequest, "")
+		return
+	case req.Then == MergeThenTrash && !cfg.Wiki.Trash.Enabled:
+		sendJSONError(w, "The trash is disabled", http.StatusBadRequest, "Use then: redirect to keep the secondary document as a redirect")
+		return
+	case primary == "" || secondary == "" || primary == homePageDocument(cfg) || secondary == homePageDocument(cfg):
+		sendJSONError(w, "The homepage cannot be merged", http.StatusBadRequest, "")
+		return
+	case isSameOrDescendant(primary, secondary) || isSameOrDescendant(secondary, primary):
+		sendJSONError(w, "A document cannot be merged with itself, its parent or its children", http.StatusBadRequest, "")
+		return
+	}
+	for _, docPath := range []string{primary, secondary} {
+		if !auth.CanAccessDocument("/"+docPath, session, cfg) {
+			sendJSONError(w, "Access denied", http.StatusForbidden, "")
+			return
+		}
+	}
+
+	primaryFile, primaryRelative := documentFile(primary, "")
+	secondaryFile, secondaryRelative := documentFile(secondary, "")
+	secondaryDir := filepath.Dir(secondaryFile)
+
+	// Lock both documents; released early before links are rewritten
+	unlock := sync.OnceFunc(doclock.LockAll(primaryFile, secondaryFile))
+	defer unlock()
+
+	primaryContent, err := os.ReadFile(primaryFile)
+	if err == nil {
+		var secondaryContent []byte
+		if secondaryContent, err = os.ReadFile(secondaryFile); err == nil {
+			err = checkMergeable(secondaryDir, filepath.Dir(primaryFile))
+			if err == nil {
+				primaryContent = []byte(mergeMarkdown(string(primaryContent), string(secondaryContent), secondary, primary, req.Mode))
+				err = checkDocumentSize(primaryContent)
+			}
+		}
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			sendJSONError(w, "Document not found", http.StatusNotFound, "")
+		case errors.Is(err, errMergeConflict):
+			sendJSONError(w, "The documents cannot be merged", http.StatusConflict, err.Error())
+		case errors.Is(err, errDocumentTooLarge):
+			sendJSONError(w, "Merged document too large", http.StatusRequestEntityTooLarge, "Maximum size is "+maxDocumentSizeText())
+		default:
+			sendJSONError(w, "Failed to read documents", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// Attachments move first, so the merged content's links to them resolve
+	if err := moveAttachments(secondaryDir, filepath.Dir(primaryFile)); err != nil {
+		sendJSONError(w, "Failed to move attachments", http.StatusInternalServerError, err.Error())
+		return
+	}
+	message := "Merged /" + secondary
+	if err := writeDocumentRevision(primaryFile, primaryRelative, primaryContent, session.Username, message); err != nil {
+		sendJSONError(w, "Failed to save merged document", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := MergeResponse{Success: true, Message: "Documents merged", Primary: "/" + primary}
+	if req.Then == MergeThenRedirect {
+		stub := fmt.Sprintf("---\nredirect: /%s\n---\n\n# %s\n\nMerged into [[/%s]].\n",
+			primary, extractTitleFromMarkdown(secondaryFile), primary)
+		if err := writeDocumentRevision(secondaryFile, secondaryRelative, []byte(stub), session.Username, "Merged into /"+primary); err != nil {
+			log.Printf("Warning: Failed to replace merged document %s with a redirect: %v", secondary, err)
+		}
+	} else {
+		entry, err := moveToTrash(secondary, session.Username)
+		if entry == nil {
+			sendJSONError(w, "Merged, but failed to move the secondary document to the trash", http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		resp.TrashID = entry.ID
+	}
+	renderCache.Invalidate(primary)
+	renderCache.Invalidate(secondary)
+
+	// Rewriting links takes the locks of the documents it changes
+	unlock()
+	if req.RewriteLinks {
+		rewriteWikiLinks(secondary, primary)
+	} else {
+		invalidateWikiLinkIndex()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// errMergeConflict marks documents that can't be merged as they are
+var errMergeConflict = errors.New("merge conflict")
+
+// checkMergeable reports whether the document in secondaryDir can be merged
+// into the one in primaryDir: it has no child documents, which would be
+// trashed with it, and no attachment named like one of the primary's
+func checkMergeable(secondaryDir, primaryDir string) error {
+	entries, err := os.ReadDir(secondaryDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return fmt.Errorf("%w: the secondary document has child documents; move them first", errMergeConflict)
+		}
+		if isDocumentFile(entry.Name()) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(primaryDir, entry.Name())); err == nil {
+			return fmt.Errorf("%w: both documents have an attachment named %s", errMergeConflict, entry.Name())
+		}
+	}
+	return nil
+}
+
+// isDocumentFile reports whether name is a document's markdown or a translation
+func isDocumentFile(name string) bool {
+	return utils.IsIndexFile(name) || (strings.HasPrefix(name, "document.") && strings.HasSuffix(name, ".md"))
+}
+
+// moveAttachments moves the attachments of the document in fromDir to toDir
+func moveAttachments(fromDir, toDir string) error {
+	entries, err := os.ReadDir(fromDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || isDocumentFile(entry.Name()) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(fromDir, entry.Name()), filepath.Join(toDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeMarkdown returns primary with the body of secondary, the document at
+// secondaryPath, merged in by mode. The secondary's title becomes a section
+// heading and links to its attachments point at primaryPath.
+func mergeMarkdown(primary, secondary, secondaryPath, primaryPath, mode string) string {
+	if _, body, ok := frontmatter.Parse(secondary); ok {
+		secondary = body
+	}
+	secondary = replaceAttachmentLinks(secondary, secondaryPath, primaryPath)
+	title, body := splitTitle(secondary)
+	if title == "" {
+		title = path.Base(secondaryPath)
+	}
+	marker := fmt.Sprintf("<!-- Merged from /%s -->", secondaryPath)
+	primary = strings.TrimRight(primary, "\n")
+
+	if mode == MergeSections {
+		// Sections of the secondary go below the primary's of the same
+		// heading; the rest is appended as usual
+		primarySections := splitSections(primary)
+		secondarySections := splitSections(body)
+		rest := strings.Trim(secondarySections[0].text, "\n")
+		for _, section := range secondarySections[1:] {
+			if i := findSection(primarySections, section.heading); i >= 0 {
+				primarySections[i].text = strings.TrimRight(primarySections[i].text, "\n") +
+					"\n\n" + marker + "\n\n" + strings.Trim(section.body(), "\n") + "\n\n"
+			} else {
+				rest = strings.TrimLeft(rest+"\n\n"+strings.Trim(section.text, "\n"), "\n")
+			}
+		}
+		var merged strings.Builder
+		for _, section := range primarySections {
+			merged.WriteString(section.text)
+		}
+		primary = strings.TrimRight(merged.String(), "\n")
+		if strings.TrimSpace(rest) == "" {
+			return primary + "\n"
+		}
+		body = rest
+	}
+
+	return primary + "\n\n---\n\n" + marker + "\n\n## " + title + "\n\n" + strings.Trim(body, "\n") + "\n"
+}
+
+// splitTitle separates the first-level heading starting markdown from the rest
+func splitTitle(markdown string) (title, rest string) {
+	trimmed := strings.TrimLeft(markdown, "\n")
+	if !strings.HasPrefix(trimmed, "# ") {
+		return "", markdown
+	}
+	line, rest, _ := strings.Cut(trimmed, "\n")
+	return strings.TrimSpace(strings.TrimPrefix(line, "# ")), rest
+}
+
+// mergeSection is a second-level section of a document: its heading line, if
+// any, and the lines up to the next one
+type mergeSection struct {
+	heading string // Heading text; "" for the text before the first heading
+	text    string // The section's markdown, heading line included
+}
+
+// body returns the section's markdown without its heading line
+func (s mergeSection) body() string {
+	if s.heading == "" {
+		return s.text
+	}
+	_, body, _ := strings.Cut(s.text, "\n")
+	return body
+}
+
+// splitSections splits markdown at its second-level headings, ignoring those
+// in code blocks. The sections together are markdown again.
+func splitSections(markdown string) []mergeSection {
+	sections := []mergeSection{{}}
+	fence := ""
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		case strings.HasPrefix(line, "## "):
+			sections = append(sections, mergeSection{heading: strings.TrimSpace(strings.TrimPrefix(trimmed, "## "))})
+		}
+		sections[len(sections)-1].text += line
+	}
+	return sections
+}
+
+// findSection returns the index of the section with heading, ignoring case, or -1
+func findSection(sections []mergeSection, heading string) int {
+	for i, section := range sections {
+		if section.heading != "" && strings.EqualFold(section.heading, heading) {
+			return i
+		}
+	}
+	return -1
+}
+
+// redirectTarget returns the local URL path of a redirect front matter value,
+// or "" when there is none
+func redirectTarget(redirect string) string {
+	redirect = strings.TrimSpace(redirect)
+	if redirect == "" || strings.Contains(redirect, "://") {
+		return ""
+	}
+	return "/" + strings.Trim(path.Clean("/"+redirect), "/")
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeMarkdown(t *testing.T) {
+	primary := "---\ntags: [go]\n---\n\n# Setup\n\nIntro.\n\n## Install\n\nRun the installer.\n\n## Usage\n\nRun it.\n"
+	secondary := "---\ntags: [old]\n---\n\n# Setup notes\n\nMore intro.\n\n## install\n\n![shot](/api/files/old/shot.png)\n\n## Troubleshooting\n\nRestart.\n"
+
+	tests := []struct {
+		name string
+		mode string
+		want string
+	}{
+		{"append", MergeAppend, "---\ntags: [go]\n---\n\n# Setup\n\nIntro.\n\n## Install\n\nRun the installer.\n\n## Usage\n\nRun it.\n\n---\n\n<!-- Merged from /old -->\n\n## Setup notes\n\nMore intro.\n\n## install\n\n![shot](/api/files/new/shot.png)\n\n## Troubleshooting\n\nRestart.\n"},
+		{"sections", MergeSections, "---\ntags: [go]\n---\n\n# Setup\n\nIntro.\n\n## Install\n\nRun the installer.\n\n<!-- Merged from /old -->\n\n![shot](/api/files/new/shot.png)\n\n## Usage\n\nRun it.\n\n---\n\n<!-- Merged from /old -->\n\n## Setup notes\n\nMore intro.\n\n## Troubleshooting\n\nRestart.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeMarkdown(primary, secondary, "old", "new", tt.mode); got != tt.want {
+				t.Errorf("merged =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDocuments(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guide", "guide-copy", "other", "parent", "parent/child")
+	root := testCfg.Wiki.RootDir
+	docs := filepath.Join(root, testCfg.Wiki.DocumentsDir)
+	testCfg.Wiki.Trash.Enabled = true
+	testCfg.Wiki.MaxVersions = 10
+	writeTestDocument(t, root, "guide", "# Guide\n\nThe original.\n")
+	writeTestDocument(t, root, "guide-copy", "# Guide copy\n\n![logo](/api/files/guide-copy/logo.png)\n")
+	writeTestDocument(t, root, "other", "# Other\n\nSee [[guide-copy]] and [[/guide-copy]].\n")
+	if err := os.WriteFile(filepath.Join(docs, "guide-copy", "logo.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	merge := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/document/merge", strings.NewReader(body))
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		MergeDocumentsHandler(rec, req)
+		return rec
+	}
+
+	for _, tt := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"into itself", `{"primary":"guide","secondary":"guide/"}`, http.StatusBadRequest},
+		{"into its child", `{"primary":"parent/child","secondary":"parent"}`, http.StatusBadRequest},
+		{"with children", `{"primary":"guide","secondary":"parent"}`, http.StatusConflict},
+		{"missing", `{"primary":"guide","secondary":"nope"}`, http.StatusNotFound},
+		{"bad mode", `{"primary":"guide","secondary":"other","mode":"zip"}`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := merge(tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	rec := merge(`{"primary":"guide","secondary":"guide-copy","rewriteLinks":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var resp MergeResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.TrashID == "" {
+		t.Errorf("response = %+v, want a trash entry", resp)
+	}
+
+	read := func(rel string) string {
+		data, _ := os.ReadFile(filepath.Join(docs, filepath.FromSlash(rel)))
+		return string(data)
+	}
+	if merged := read("guide/document.md"); !strings.Contains(merged, "The original.") || !strings.Contains(merged, "![logo](/api/files/guide/logo.png)") {
+		t.Errorf("merged document =\n%s", merged)
+	}
+	if _, err := os.Stat(filepath.Join(docs, "guide", "logo.png")); err != nil {
+		t.Errorf("attachment did not move: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(docs, "guide-copy")); !os.IsNotExist(err) {
+		t.Errorf("secondary document still exists: %v", err)
+	}
+	if got := read("other/document.md"); got != "# Other\n\nSee [[guide]] and [[/guide]].\n" {
+		t.Errorf("links were not rewritten: %q", got)
+	}
+	if versions, _ := os.ReadDir(filepath.Join(root, "versions", "documents", "guide")); len(versions) == 0 {
+		t.Error("no version of the primary was kept")
+	}
+
+	// A redirect replaces the secondary instead of trashing it
+	rec = merge(`{"primary":"guide","secondary":"other","then":"redirect"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if got := read("other/document.md"); !strings.HasPrefix(got, "---\nredirect: /guide\n---\n") {
+		t.Errorf("secondary = %q, want a redirect", got)
+	}
+}
+
+// A merge that would make the primary larger than wiki.max_document_size
+// leaves both documents alone
+func TestMergeOverSizeLimit(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guide", "guide-copy")
+	root := testCfg.Wiki.RootDir
+	testCfg.Wiki.Trash.Enabled = true
+	testCfg.Wiki.MaxDocumentSize = 1 // KB
+	original := "# Guide\n\n" + strings.Repeat("x", 600) + "\n"
+	writeTestDocument(t, root, "guide", original)
+	writeTestDocument(t, root, "guide-copy", "# Guide copy\n\n"+strings.Repeat("y", 600)+"\n")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/document/merge", strings.NewReader(`{"primary":"guide","secondary":"guide-copy"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MergeDocumentsHandler(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "documents", "guide", "document.md")); string(data) != original {
+		t.Errorf("primary changed to %d bytes", len(data))
+	}
+	if _, err := os.Stat(filepath.Join(root, "documents", "guide-copy")); err != nil {
+		t.Errorf("secondary is gone: %v", err)
+	}
+}
+
+func TestRedirectTarget(t *testing.T) {
+	tests := []struct {
+		redirect string
+		want     string
+	}{
+		{"", ""},
+		{"/guide", "/guide"},
+		{"guide/setup/", "/guide/setup"},
+		{"//evil.example", "/evil.example"},
+		{"https://evil.example", ""},
+		{"../../etc", "/etc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.redirect, func(t *testing.T) {
+			if got := redirectTarget(tt.redirect); got != tt.want {
+				t.Errorf("redirectTarget(%q) = %q, want %q", tt.redirect, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/metrics"
+	"wiki-go/internal/utils"
+)
+
+var metricsOnce sync.Once
+
+// initMetrics registers gauges that are computed from wiki state on every scrape
+func initMetrics() {
+	metricsOnce.Do(func() {
+		metrics.NewGaugeFunc("wikigo_active_sessions",
+			"Number of active (unexpired) user sessions.",
+			func() float64 {
+				return float64(auth.ActiveSessionCount())
+			})
+
+		metrics.NewGaugeFunc("wikigo_documents",
+			"Number of documents in the documents directory.",
+			func() float64 {
+				return float64(countDocuments())
+			})
+
+		metrics.NewGaugeFunc("wikigo_render_cache_entries",
+			"Number of rendered documents held in the render cache.",
+			func() float64 {
+				return float64(renderCache.Len())
+			})
+	})
+}
+
+// countDocuments walks the documents directory and counts document.md files
+func countDocuments() int {
+	cfg := config.Current()
+	if cfg == nil {
+		return 0
+	}
+
+	count := 0
+	documentsDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	filepath.WalkDir(documentsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() && utils.IsDocumentIndex(path) {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// MetricsHandler serves all registered metrics in the Prometheus text format
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	metrics.Write(w)
+}
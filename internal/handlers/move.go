@@ -2,13 +2,23 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"io/fs"
+	"mime"
 	"net/http"
-	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/events"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/logging"
+	"wiki-go/internal/metrics"
+	"wiki-go/internal/utils"
 )
 
 // MoveRequest represents the request to move or rename a document or category
@@ -20,45 +30,91 @@ type MoveRequest struct {
 
 // MoveResponse represents the response for a move/rename operation
 type MoveResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	NewPath string `json:"newPath,omitempty"`
-	OldPath string `json:"oldPath,omitempty"`
+	Success  bool          `json:"success"`
+	Message  string        `json:"message"`
+	NewPath  string        `json:"newPath,omitempty"`
+	OldPath  string        `json:"oldPath,omitempty"`
+	Conflict *MoveConflict `json:"conflict,omitempty"` // Set when the move is refused with 409 Conflict
 }
 
+// Kinds of MoveConflict
+const (
+	ConflictDocument  = "document"  // A document exists at the target
+	ConflictDirectory = "directory" // A non-empty directory without a document exists at the target
+)
+
+// MoveConflict describes what occupies the target of a refused move
+type MoveConflict struct {
+	Path string `json:"path"` // Occupied path relative to the documents directory
+	Type string `json:"type"` // ConflictDocument or ConflictDirectory
+}
+
+// maxMoveRequestSize bounds the body of a move request, which only carries paths
+const maxMoveRequestSize = 64 << 10
+
 // MoveDocumentHandler handles requests to move or rename a document or category
 func MoveDocumentHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
-	// Set JSON content type header
-	w.Header().Set("Content-Type", "application/json")
+	// Messages are returned in the user's language
+	lang := requestLanguage(r)
 
 	// Only process POST requests
 	if r.Method != http.MethodPost {
-		sendJSONResponse(w, false, "Method not allowed", http.StatusMethodNotAllowed, "", "")
+		sendJSONResponse(w, false, i18n.T(lang, "error.method_not_allowed"), http.StatusMethodNotAllowed, "", "")
 		return
 	}
 
+	logger := logging.FromContext(r.Context())
+
+	// Record the outcome of every attempted move for metrics
+	moveResult := "error"
+	defer func() {
+		metrics.MoveOperations.Inc(moveResult)
+	}()
+
 	// Check authentication
 	session := auth.GetSession(r)
 	if session == nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Unauthorized. Admin or editor access required.",
-		})
+		sendJSONResponse(w, false, i18n.T(lang, "move.unauthorized"), http.StatusUnauthorized, "", "")
+		return
+	}
+	if !auth.RequireRole(r, config.RoleEditor) {
+		sendJSONResponse(w, false, i18n.T(lang, "move.forbidden"), http.StatusForbidden, "", "")
 		return
 	}
 
-	// Parse the request body
+	// Parse the request body. Unknown fields are refused, so a misspelled
+	// field fails the move instead of leaving a path empty.
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		sendJSONResponse(w, false, i18n.T(lang, "error.unsupported_media_type"), http.StatusUnsupportedMediaType, "", "")
+		return
+	}
 	var moveReq MoveRequest
-	err := json.NewDecoder(r.Body).Decode(&moveReq)
-	if err != nil {
-		sendJSONResponse(w, false, "Invalid request format", http.StatusBadRequest, "", "")
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMoveRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&moveReq); err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			sendJSONResponse(w, false, i18n.T(lang, "error.request_too_large"), http.StatusRequestEntityTooLarge, "", "")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			sendJSONResponse(w, false, i18n.T(lang, "error.unknown_field", field), http.StatusBadRequest, "", "")
+		default:
+			sendJSONResponse(w, false, i18n.T(lang, "error.invalid_request"), http.StatusBadRequest, "", "")
+		}
 		return
 	}
 
 	// Validate request
 	if moveReq.SourcePath == "" {
-		sendJSONResponse(w, false, "Source path is required", http.StatusBadRequest, "", "")
+		sendJSONResponse(w, false, i18n.T(lang, "move.source_required"), http.StatusBadRequest, "", "")
+		return
+	}
+
+	// The source must be inside the documents directory. Its names aren't
+	// validated, as documents created before that must still be movable
+	if slices.Contains(strings.Split(strings.ReplaceAll(moveReq.SourcePath, "\\", "/"), "/"), "..") {
+		sendJSONResponse(w, false, i18n.T(lang, "move.invalid_source"), http.StatusBadRequest, "", "")
 		return
 	}
 
@@ -68,24 +124,37 @@ func MoveDocumentHandler(w http.ResponseWriter, r *http.Request, cfg *config.Con
 
 	// If target path is empty, set it to root
 	if moveReq.TargetPath == "" && moveReq.NewSlug == "" {
-		sendJSONResponse(w, false, "Either target path or new slug must be provided", http.StatusBadRequest, "", "")
+		sendJSONResponse(w, false, i18n.T(lang, "move.target_required"), http.StatusBadRequest, "", "")
 		return
 	}
 
 	// Prevent moving the homepage
-	if moveReq.SourcePath == "" || moveReq.SourcePath == "/" ||
-		moveReq.SourcePath == "pages/home" || strings.EqualFold(moveReq.SourcePath, "pages/home") ||
-		strings.HasSuffix(moveReq.SourcePath, "/homepage") {
-		sendJSONResponse(w, false, "Cannot move or rename the home page", http.StatusBadRequest, "", "")
+	if isHomePage(moveReq.SourcePath, cfg) {
+		sendJSONResponse(w, false, i18n.T(lang, "move.home_source"), http.StatusBadRequest, "", "")
 		return
 	}
 
-	// Also prevent setting the target path to the homepage
-	if moveReq.TargetPath == "pages/home" || strings.EqualFold(moveReq.TargetPath, "pages/home") {
-		sendJSONResponse(w, false, "Cannot move or rename to the home page location", http.StatusBadRequest, "", "")
+	// Also prevent setting the target path to the homepage; an empty target is the root
+	if moveReq.TargetPath != "" && isHomePage(moveReq.TargetPath, cfg) {
+		sendJSONResponse(w, false, i18n.T(lang, "move.home_target"), http.StatusBadRequest, "", "")
 		return
 	}
 
+	// Refuse names that some platforms can't store, rather than failing the
+	// rename with an obscure error or moving to a name the OS altered
+	if moveReq.TargetPath != "" {
+		if segment, err := utils.ValidatePath(moveReq.TargetPath); err != nil {
+			sendJSONResponse(w, false, i18n.T(lang, "move.invalid_name", segment, err.Error()), http.StatusBadRequest, "", "")
+			return
+		}
+	}
+	if moveReq.NewSlug != "" {
+		if err := utils.ValidateName(moveReq.NewSlug); err != nil {
+			sendJSONResponse(w, false, i18n.T(lang, "move.invalid_name", moveReq.NewSlug, err.Error()), http.StatusBadRequest, "", "")
+			return
+		}
+	}
+
 	// Determine if this is a rename or move operation
 	isRename := moveReq.NewSlug != ""
 	
@@ -97,27 +166,32 @@ func MoveDocumentHandler(w http.ResponseWriter, r *http.Request, cfg *config.Con
 	// If we're moving to the root (empty target path) and the source is not already at the root
 	if moveReq.TargetPath == "" && sourceDir != "." {
 		moveToRoot = true
-		log.Printf("Detected move to root operation: %s -> %s", moveReq.SourcePath, moveReq.NewSlug)
+		logger.Debug("detected move to root operation", "source", moveReq.SourcePath, "new_slug", moveReq.NewSlug)
 	}
 	
 	// Determine if this is a move operation
 	isMove := moveReq.TargetPath != "" || moveToRoot
 	
-	log.Printf("Operation analysis: sourceBase=%s, sourceDir=%s, moveToRoot=%v", 
-		sourceBase, sourceDir, moveToRoot)
-
-	// Build the full source path
+	logger.Debug("move operation analysis",
+		"source_base", sourceBase, "source_dir", sourceDir, "move_to_root", moveToRoot)
+
+	// Files are moved through the wiki's storage; the full paths are the keys of
+	// the edit locks and of the category sort order
+	store := wikiStorage(cfg)
+	documentsName := func(p string) string {
+		return path.Join(filepath.ToSlash(cfg.Wiki.DocumentsDir), filepath.ToSlash(p))
+	}
 	documentDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
 	fullSourcePath := filepath.Join(documentDir, moveReq.SourcePath)
 
 	// Check if source exists
-	_, err = os.Stat(fullSourcePath)
+	_, err := store.Stat(documentsName(moveReq.SourcePath))
 	if err != nil {
-		if os.IsNotExist(err) {
-			sendJSONResponse(w, false, "Source document or category not found", http.StatusNotFound, "", "")
+		if errors.Is(err, fs.ErrNotExist) {
+			sendJSONResponse(w, false, i18n.T(lang, "move.source_not_found"), http.StatusNotFound, "", "")
 			return
 		}
-		sendJSONResponse(w, false, "Error accessing source: "+err.Error(), http.StatusInternalServerError, "", "")
+		sendJSONResponse(w, false, i18n.T(lang, "move.source_error", err.Error()), http.StatusInternalServerError, "", "")
 		return
 	}
 
@@ -126,8 +200,9 @@ func MoveDocumentHandler(w http.ResponseWriter, r *http.Request, cfg *config.Con
 	var newPath string
 
 	// Log the request details for debugging
-	log.Printf("Move request: SourcePath=%s, TargetPath=%s, NewSlug=%s", moveReq.SourcePath, moveReq.TargetPath, moveReq.NewSlug)
-	log.Printf("Operation type: isRename=%v, isMove=%v", isRename, isMove)
+	logger.Debug("move request",
+		"source", moveReq.SourcePath, "target", moveReq.TargetPath, "new_slug", moveReq.NewSlug,
+		"is_rename", isRename, "is_move", isMove)
 
 	if isRename && !isMove {
 		// Rename operation (change slug only)
@@ -162,124 +237,146 @@ func MoveDocumentHandler(w http.ResponseWriter, r *http.Request, cfg *config.Con
 		fullTargetPath = filepath.Join(documentDir, newPath)
 	} else {
 		// This case should not happen due to earlier validation
-		sendJSONResponse(w, false, "Either new slug or target path must be provided", http.StatusBadRequest, "", "")
+		sendJSONResponse(w, false, i18n.T(lang, "move.target_required"), http.StatusBadRequest, "", "")
 		return
 	}
 	
 	// Log the calculated paths
-	log.Printf("Calculated paths: newPath=%s, fullTargetPath=%s", newPath, fullTargetPath)
+	logger.Debug("calculated move paths", "new_path", newPath, "full_target_path", fullTargetPath)
+
+	// A category can't be moved onto or below itself; the rename would fail
+	// halfway or leave the category unreachable
+	if isSameOrDescendant(newPath, moveReq.SourcePath) {
+		message := "move.into_itself"
+		if isSameOrDescendant(moveReq.SourcePath, newPath) {
+			message = "move.same_path"
+		}
+		sendJSONResponse(w, false, i18n.T(lang, message), http.StatusBadRequest, "", "")
+		return
+	}
+
+	// Documents can't take the place of the wiki's own routes, whether they
+	// would get there by the target path or the new slug
+	if reserved, ok := reservedPath(filepath.ToSlash(newPath), cfg); ok {
+		sendJSONResponse(w, false, i18n.T(lang, "move.reserved_path", reserved), http.StatusBadRequest, "", "")
+		return
+	}
+
+	// Editors must be able to read what they move and where they move it. A
+	// move where more users can read the document, e.g. from the internal
+	// namespace to the public one, publishes it and needs the publish permission.
+	if !auth.CanAccessDocument("/"+filepath.ToSlash(moveReq.SourcePath), session, cfg) {
+		sendJSONResponse(w, false, i18n.T(lang, "move.source_denied"), http.StatusForbidden, "", "")
+		return
+	}
+	if !auth.CanAccessDocument("/"+filepath.ToSlash(newPath), session, cfg) {
+		sendJSONResponse(w, false, i18n.T(lang, "move.target_denied"), http.StatusForbidden, "", "")
+		return
+	}
+	if !canPublish(session) {
+		if doc, ok := widenedDocument(fullSourcePath, moveReq.SourcePath, newPath); ok {
+			sendJSONResponse(w, false, i18n.T(lang, "move.widens_access", doc, documentNamespace(newPath)), http.StatusForbidden, "", "")
+			return
+		}
+	}
 
 	// Check if target already exists, but only if it's not the same as the source
-	// We need to check if the document.md file exists at the target path
-	targetDocPath := filepath.Join(fullTargetPath, "document.md")
+	// We need to check if a document file exists at the target path
+	targetDocPath := utils.DocumentFile(fullTargetPath)
+
+	// Hold the edit locks of both documents from the target checks until the
+	// rename, so a concurrent save, create or move can't slip in between
+	unlock := sync.OnceFunc(doclock.LockAll(utils.DocumentFile(fullSourcePath), targetDocPath))
+	defer unlock()
 	
 	// Skip the check if the target is the same as the source (just in a different location)
 	// This allows moving a document to the root with the same name
 	if fullTargetPath != fullSourcePath {
-		if _, err := os.Stat(targetDocPath); err == nil {
+		if slices.ContainsFunc(utils.IndexFiles(), func(name string) bool {
+			_, err := store.Stat(documentsName(newPath) + "/" + name)
+			return err == nil
+		}) {
 			// Check if this is a case-only rename (e.g., "test" to "Test")
 			sourceBaseLower := strings.ToLower(filepath.Base(moveReq.SourcePath))
 			targetBaseLower := strings.ToLower(moveReq.NewSlug)
 			
 			// If it's not a case-only rename, then it's a conflict
 			if sourceBaseLower != targetBaseLower || filepath.Dir(fullSourcePath) == filepath.Dir(fullTargetPath) {
-				sendJSONResponse(w, false, "A document already exists at the target location", http.StatusConflict, "", "")
+				sendMoveConflict(w, i18n.T(lang, "move.document_exists"), newPath, ConflictDocument)
 				return
 			}
 		}
 		
 		// Also check if the directory itself exists and is not empty
-		if info, err := os.Stat(fullTargetPath); err == nil && info.IsDir() {
+		if info, err := store.Stat(documentsName(newPath)); err == nil && info.IsDir {
 			// Check if the directory is empty
-			entries, err := os.ReadDir(fullTargetPath)
+			entries, err := store.List(documentsName(newPath))
 			if err == nil && len(entries) > 0 {
-				sendJSONResponse(w, false, "Target directory already exists and is not empty", http.StatusConflict, "", "")
+				sendMoveConflict(w, i18n.T(lang, "move.target_not_empty"), newPath, ConflictDirectory)
 				return
 			}
 		}
 	}
 
-	// Create target directory if it doesn't exist
-	targetDir := filepath.Dir(fullTargetPath)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		sendJSONResponse(w, false, "Failed to create target directory: "+err.Error(), http.StatusInternalServerError, "", "")
-		return
-	}
-
 	// Log paths for debugging
-	log.Printf("Moving document from %s to %s", fullSourcePath, fullTargetPath)
+	logger.Debug("moving document", "from", fullSourcePath, "to", fullTargetPath)
 	
 	// Check if source and target are the same
 	if fullSourcePath == fullTargetPath {
-		log.Printf("WARNING: Source and target paths are the same! This will cause an error.")
-		sendJSONResponse(w, false, "Source and target paths are the same", http.StatusBadRequest, "", "")
+		logger.Warn("source and target paths are the same", "path", fullSourcePath)
+		sendJSONResponse(w, false, i18n.T(lang, "move.same_path"), http.StatusBadRequest, "", "")
 		return
 	}
 	
-	// Move the document or category
-	if err := os.Rename(fullSourcePath, fullTargetPath); err != nil {
-		log.Printf("Error moving document: %v", err)
-		sendJSONResponse(w, false, "Failed to move: "+err.Error(), http.StatusInternalServerError, "", "")
+	// Move the document or category; its target directory is created as needed
+	if err := store.Move(documentsName(moveReq.SourcePath), documentsName(newPath)); err != nil {
+		logger.Error("failed to move document", "from", fullSourcePath, "to", fullTargetPath, "error", err)
+		sendJSONResponse(w, false, i18n.T(lang, "move.rename_failed", err.Error()), http.StatusInternalServerError, "", "")
 		return
 	}
 
-	// Handle versions directory
-	var versionsSourcePath, versionsTargetPath string
-
-	if moveReq.SourcePath == "pages/home" {
-		// For homepage, use the new paths
-		versionsSourcePath = filepath.Join(cfg.Wiki.RootDir, "versions", "pages", "home")
-	} else if strings.HasPrefix(moveReq.SourcePath, "documents/") {
-		// Source path already includes "documents/" prefix
-		versionsSourcePath = filepath.Join(cfg.Wiki.RootDir, "versions", moveReq.SourcePath)
-	} else {
-		// Add "documents/" prefix for regular documents
-		versionsSourcePath = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", moveReq.SourcePath)
-	}
-
-	if newPath == "pages/home" {
-		// For homepage, use the new paths
-		versionsTargetPath = filepath.Join(cfg.Wiki.RootDir, "versions", "pages", "home")
-	} else if strings.HasPrefix(newPath, "documents/") {
-		// Target path already includes "documents/" prefix
-		versionsTargetPath = filepath.Join(cfg.Wiki.RootDir, "versions", newPath)
-	} else {
-		// Add "documents/" prefix for regular documents
-		versionsTargetPath = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", newPath)
+	// A rename within the same category keeps its place in a manual sort order
+	if filepath.Dir(fullSourcePath) == filepath.Dir(fullTargetPath) {
+		if err := utils.RenameInCategoryOrder(filepath.Dir(fullSourcePath), filepath.Base(fullSourcePath), filepath.Base(fullTargetPath)); err != nil {
+			logger.Warn("failed to update category sort order", "error", err)
+		}
 	}
 
-	// Check if versions directory exists
-	if _, err := os.Stat(versionsSourcePath); err == nil {
-		// Create parent directory for versions if needed
-		if err := os.MkdirAll(filepath.Dir(versionsTargetPath), 0755); err != nil {
-			log.Printf("Warning: Failed to create versions target directory: %v", err)
-		} else {
-			// Move versions directory
-			if err := os.Rename(versionsSourcePath, versionsTargetPath); err != nil {
-				log.Printf("Warning: Failed to move versions directory: %v", err)
+	// Move the versions and comments directories, where there are any. Revision
+	// metadata (*.json sidecars) lives with the versions, so it travels along.
+	versionsSource, commentsSource := documentDataDirs(cfg, moveReq.SourcePath)
+	versionsTarget, commentsTarget := documentDataDirs(cfg, newPath)
+	for _, data := range []struct{ kind, source, target string }{
+		{"versions", versionsSource, versionsTarget},
+		{"comments", commentsSource, commentsTarget},
+	} {
+		source, target := storageName(cfg, data.source), storageName(cfg, data.target)
+		if _, err := store.Stat(source); err == nil {
+			if err := store.Move(source, target); err != nil {
+				logger.Warn("failed to move "+data.kind+" directory", "error", err)
 			}
 		}
 	}
 
-	// Handle comments directory
-	commentsSourcePath := filepath.Join(cfg.Wiki.RootDir, "comments", moveReq.SourcePath)
-	commentsTargetPath := filepath.Join(cfg.Wiki.RootDir, "comments", newPath)
+	// Rewriting links takes the locks of the documents it changes, including this one
+	unlock()
 
-	// Check if comments directory exists
-	if _, err := os.Stat(commentsSourcePath); err == nil {
-		// Create parent directory for comments if needed
-		if err := os.MkdirAll(filepath.Dir(commentsTargetPath), 0755); err != nil {
-			log.Printf("Warning: Failed to create comments target directory: %v", err)
-		} else {
-			// Move comments directory
-			if err := os.Rename(commentsSourcePath, commentsTargetPath); err != nil {
-				log.Printf("Warning: Failed to move comments directory: %v", err)
-			}
-		}
-	}
+	// Keep [[WikiLinks]] that address the document by path or slug pointing at it
+	rewriteWikiLinks(moveReq.SourcePath, newPath)
+
+	publish(events.DocumentMoved{Old: moveReq.SourcePath, New: events.CleanPath(newPath), User: session.Username})
 
 	// Return success response with both old and new paths
-	sendJSONResponse(w, true, "Document moved successfully", http.StatusOK, newPath, moveReq.SourcePath)
+	moveResult = "success"
+	sendJSONResponse(w, true, i18n.T(lang, "move.success"), http.StatusOK, newPath, moveReq.SourcePath)
+}
+
+// isSameOrDescendant reports whether path is base itself or lies below it.
+// Both are slash-separated paths relative to the documents directory.
+func isSameOrDescendant(path, base string) bool {
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	base = strings.Trim(filepath.ToSlash(base), "/")
+	return path == base || strings.HasPrefix(path, base+"/")
 }
 
 // Helper function to clean and normalize a path
@@ -299,19 +396,87 @@ func cleanPath(path string) string {
 
 // Helper function to send a JSON response
 func sendJSONResponse(w http.ResponseWriter, success bool, message string, statusCode int, newPath string, oldPath string) {
-	w.WriteHeader(statusCode)
-	response := MoveResponse{
+	writeMoveResponse(w, statusCode, MoveResponse{
 		Success: success,
 		Message: message,
+		NewPath: newPath,
+		OldPath: oldPath,
+	})
+}
+
+// canPublish reports whether the user of session may move documents where
+// more users can read them: admins, and editors in a group of
+// wiki.publish_groups
+func canPublish(session *auth.Session) bool {
+	cfg := config.Current()
+	if session.Role == config.RoleAdmin {
+		return true
 	}
+	return session.Role == config.RoleEditor && slices.ContainsFunc(session.Groups, func(group string) bool {
+		return slices.Contains(cfg.Wiki.PublishGroups, group)
+	})
+}
+
+// widenedDocument returns the path of a document that moving sourcePath,
+// stored at fullSourcePath, to newPath would let more users read, checking
+// every category below a moved category as access rules can single them out
+func widenedDocument(fullSourcePath, sourcePath, newPath string) (string, bool) {
+	cfg := config.Current()
+	var widened string
+	filepath.WalkDir(fullSourcePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(fullSourcePath, p)
+		if err != nil {
+			return nil
+		}
+		from := "/" + filepath.ToSlash(filepath.Join(sourcePath, rel))
+		if auth.WidensAccess(from, "/"+filepath.ToSlash(filepath.Join(newPath, rel)), cfg) {
+			widened = from
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return widened, widened != ""
+}
 
-	if newPath != "" {
-		response.NewPath = newPath
+// documentNamespace returns the top-level category of a document path, which
+// namespaces like public/ and internal/ are, or "/" for a top-level document
+func documentNamespace(docPath string) string {
+	namespace, _, found := strings.Cut(strings.Trim(filepath.ToSlash(docPath), "/"), "/")
+	if !found {
+		return "/"
 	}
+	return "/" + namespace
+}
 
-	if oldPath != "" {
-		response.OldPath = oldPath
+// isJSONContentType reports whether a request's Content-Type header declares
+// JSON. Requests without one are taken as JSON, as scripts often leave it out.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
 	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
 
+// sendMoveConflict refuses a move with 409 Conflict, describing what is at newPath
+func sendMoveConflict(w http.ResponseWriter, message, newPath, conflictType string) {
+	writeMoveResponse(w, http.StatusConflict, MoveResponse{
+		Success: false,
+		Message: message,
+		Conflict: &MoveConflict{
+			Path: filepath.ToSlash(newPath),
+			Type: conflictType,
+		},
+	})
+}
+
+// writeMoveResponse sends every response of MoveDocumentHandler, so each has
+// the JSON content type set before its status is written
+func writeMoveResponse(w http.ResponseWriter, statusCode int, response MoveResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,651 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/events"
+)
+
+// newMoveTestWiki creates a wiki root with the given documents and returns its
+// config and a session cookie for an editor
+func newMoveTestWiki(t *testing.T, docs ...string) (*config.Config, *http.Cookie) {
+	t.Helper()
+	testCfg := &config.Config{}
+	testCfg.Wiki.RootDir = t.TempDir()
+	testCfg.Wiki.DocumentsDir = "documents"
+	testCfg.Server.AllowInsecureCookies = true
+	testCfg.Security.PasswordStrength = 4 // As test passwords are hashed
+
+	// Some helpers read the package configuration
+	previous := config.Current()
+	config.SetCurrent(testCfg)
+	t.Cleanup(func() { config.SetCurrent(previous) })
+
+	for _, doc := range docs {
+		dir := filepath.Join(testCfg.Wiki.RootDir, testCfg.Wiki.DocumentsDir, filepath.FromSlash(doc))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "document.md"), []byte("# "+doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return testCfg, moveTestSession(t, testCfg, "editor", config.RoleEditor)
+}
+
+// moveTestSession signs in username with role and returns the session cookie
+func moveTestSession(t *testing.T, testCfg *config.Config, username, role string) *http.Cookie {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := auth.CreateSession(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil), username, role, nil, false, testCfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "session_token" {
+			return c
+		}
+	}
+	t.Fatal("no session cookie")
+	return nil
+}
+
+func TestMoveRejectsSelfAndDescendants(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"move into itself", `{"sourcePath":"a","targetPath":"a"}`},
+		{"move into child", `{"sourcePath":"a","targetPath":"a/b"}`},
+		{"move into nested descendant", `{"sourcePath":"a","targetPath":"a/b/c"}`},
+		{"move and rename into child", `{"sourcePath":"a","targetPath":"a/b","newSlug":"x"}`},
+		{"rename onto itself", `{"sourcePath":"a/b","targetPath":"a","newSlug":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a", "a/b", "a/b/c")
+
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+			var resp MoveResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Success {
+				t.Errorf("response = %+v, %v; want success false", resp, err)
+			}
+
+			// The tree is left as it was
+			for _, doc := range []string{"a", "a/b", "a/b/c"} {
+				path := filepath.Join(testCfg.Wiki.RootDir, "documents", filepath.FromSlash(doc), "document.md")
+				if _, err := os.Stat(path); err != nil {
+					t.Errorf("%s: %v", doc, err)
+				}
+			}
+		})
+	}
+}
+
+func TestIsSameOrDescendant(t *testing.T) {
+	tests := []struct {
+		path, base string
+		want       bool
+	}{
+		{"a", "a", true},
+		{"a/b", "a", true},
+		{"a/b/c", "a", true},
+		{"/a/b/", "a", true},
+		{"ab", "a", false},
+		{"b/a", "a", false},
+		{"a", "a/b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" in "+tt.base, func(t *testing.T) {
+			if got := isSameOrDescendant(tt.path, tt.base); got != tt.want {
+				t.Errorf("isSameOrDescendant(%q, %q) = %v, want %v", tt.path, tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveRewritesAbsoluteAttachmentLinks(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "a", "b")
+	docsDir := filepath.Join(testCfg.Wiki.RootDir, "documents")
+	write := func(rel, content string) {
+		if err := os.WriteFile(filepath.Join(docsDir, filepath.FromSlash(rel)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a/logo.png", "png")
+	write("a/document.md", "# A\n\n![logo](/api/files/a/logo.png)\n")
+	write("b/document.md", "# B\n\nSee [the logo](/api/files/a/logo.png) and [not it](/api/files/ab/logo.png).\n")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(`{"sourcePath":"a","targetPath":"b"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MoveDocumentHandler(rec, req, testCfg)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	// The attachment moved with the document and the embed points at its new location
+	if _, err := os.Stat(filepath.Join(docsDir, "b", "a", "logo.png")); err != nil {
+		t.Fatalf("attachment did not move: %v", err)
+	}
+	read := func(rel string) string {
+		data, err := os.ReadFile(filepath.Join(docsDir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if got, want := read("b/a/document.md"), "# A\n\n![logo](/api/files/b/a/logo.png)\n"; got != want {
+		t.Errorf("moved document = %q, want %q", got, want)
+	}
+	if got, want := read("b/document.md"), "# B\n\nSee [the logo](/api/files/b/a/logo.png) and [not it](/api/files/ab/logo.png).\n"; got != want {
+		t.Errorf("linking document = %q, want %q", got, want)
+	}
+}
+
+func TestReplaceAttachmentLinks(t *testing.T) {
+	tests := []struct {
+		name             string
+		content          string
+		oldPath, newPath string
+		want             string
+	}{
+		{
+			name:    "image",
+			content: "![x](/api/files/a/img.png)",
+			oldPath: "a", newPath: "b/a",
+			want: "![x](/api/files/b/a/img.png)",
+		},
+		{
+			name:    "document below a moved category",
+			content: "[x](/api/files/a/child/file.pdf)",
+			oldPath: "a", newPath: "z",
+			want: "[x](/api/files/z/child/file.pdf)",
+		},
+		{
+			name:    "html attribute and reference definition",
+			content: "<img src=\"/api/files/a/i.png\">\n[ref]: /api/files/a/doc.pdf",
+			oldPath: "a", newPath: "b",
+			want: "<img src=\"/api/files/b/i.png\">\n[ref]: /api/files/b/doc.pdf",
+		},
+		{
+			name:    "escaped path",
+			content: "![x](/api/files/my%20docs/img.png)",
+			oldPath: "my docs", newPath: "archive/my docs",
+			want: "![x](/api/files/archive/my%20docs/img.png)",
+		},
+		{
+			name:    "other document with a shared prefix",
+			content: "![x](/api/files/ab/img.png)",
+			oldPath: "a", newPath: "b",
+			want: "![x](/api/files/ab/img.png)",
+		},
+		{
+			name:    "external URL",
+			content: "![x](https://example.com/api/files/a/img.png)",
+			oldPath: "a", newPath: "b",
+			want: "![x](https://example.com/api/files/a/img.png)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceAttachmentLinks(tt.content, tt.oldPath, tt.newPath); got != tt.want {
+				t.Errorf("replaceAttachmentLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveConflictDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want MoveConflict
+	}{
+		{"document at target", `{"sourcePath":"a","newSlug":"b"}`, MoveConflict{Path: "b", Type: ConflictDocument}},
+		{"non-empty directory at target", `{"sourcePath":"a","targetPath":"c"}`, MoveConflict{Path: "c/a", Type: ConflictDirectory}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a", "b", "c")
+			// c/a holds an attachment but no document
+			dir := filepath.Join(testCfg.Wiki.RootDir, "documents", "c", "a")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("x"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusConflict, rec.Body.String())
+			}
+			var resp MoveResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Success || resp.Conflict == nil || *resp.Conflict != tt.want {
+				t.Errorf("response = %+v, conflict %+v; want conflict %+v", resp, resp.Conflict, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveRejectsNonPortableNames(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"colon in slug", `{"sourcePath":"a","newSlug":"a:b"}`},
+		{"trailing dot in slug", `{"sourcePath":"a","newSlug":"notes."}`},
+		{"device name in slug", `{"sourcePath":"a","newSlug":"con"}`},
+		{"device name in target", `{"sourcePath":"a","targetPath":"docs/LPT1"}`},
+		{"wildcard in target", `{"sourcePath":"a","targetPath":"docs*"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "move.invalid_name") {
+				t.Errorf("status = %d, body = %s; want 400 for the name", rec.Code, rec.Body.String())
+			}
+			if _, err := os.Stat(filepath.Join(testCfg.Wiki.RootDir, testCfg.Wiki.DocumentsDir, "a", "document.md")); err != nil {
+				t.Errorf("source was moved: %v", err)
+			}
+		})
+	}
+}
+
+func TestMoveRejectsSourcesOutsideDocuments(t *testing.T) {
+	for _, source := range []string{"../config.yaml", "a/../../config.yaml", `..\config.yaml`} {
+		t.Run(source, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+			configFile := filepath.Join(testCfg.Wiki.RootDir, "config.yaml")
+			if err := os.WriteFile(configFile, []byte("server: {}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			body, _ := json.Marshal(MoveRequest{SourcePath: source, TargetPath: "x"})
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(string(body)))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "move.invalid_source") {
+				t.Errorf("status = %d, body = %s; want 400 for the source", rec.Code, rec.Body.String())
+			}
+			if _, err := os.Stat(configFile); err != nil {
+				t.Errorf("config file was moved: %v", err)
+			}
+		})
+	}
+}
+
+func TestMovePublishesEvent(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "a")
+
+	var moved []events.DocumentMoved
+	events.Subscribe(eventBus, func(e events.DocumentMoved) { moved = append(moved, e) })
+
+	req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(`{"sourcePath":"a","targetPath":"docs"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MoveDocumentHandler(rec, req, testCfg)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	want := []events.DocumentMoved{{Old: "a", New: "docs/a", User: "editor"}}
+	if !reflect.DeepEqual(moved, want) {
+		t.Errorf("events = %+v, want %+v", moved, want)
+	}
+}
+
+func TestMoveUsesConfiguredDataDirs(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "a")
+	testCfg.Wiki.VersionsDir = "history"
+	testCfg.Wiki.CommentsDir = "discussion"
+
+	root := testCfg.Wiki.RootDir
+	for _, file := range []string{
+		filepath.Join(root, "history", "documents", "a", "20240101000000.md"),
+		filepath.Join(root, "discussion", "a", "20240101000000_bob.md"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(`{"sourcePath":"a","targetPath":"docs"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MoveDocumentHandler(rec, req, testCfg)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	for _, file := range []string{
+		filepath.Join(root, "history", "documents", "docs", "a", "20240101000000.md"),
+		filepath.Join(root, "discussion", "docs", "a", "20240101000000_bob.md"),
+	} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("%s wasn't moved along: %v", file, err)
+		}
+	}
+}
+
+func TestMoveRelocatesVersionsAndComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		body   string
+		target string
+		other  string // Document whose history must stay where it is
+	}{
+		{"plain path", "guide", `{"sourcePath":"guide","targetPath":"docs"}`, "docs/guide", "other"},
+		{"below pages", "pages/guide", `{"sourcePath":"pages/guide","targetPath":"docs"}`, "docs/guide", "guide"},
+		{"below documents", "documents/guide", `{"sourcePath":"documents/guide","targetPath":"docs"}`, "docs/guide", "guide"},
+		{"renamed below pages", "pages/guide", `{"sourcePath":"pages/guide","targetPath":"pages","newSlug":"manual"}`, "pages/manual", "guide"},
+		{"moved into pages", "guide", `{"sourcePath":"guide","targetPath":"pages"}`, "pages/guide", "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A document's "pages/" or "documents/" prefix is part of its path,
+			// so the history of tt.other is not the moved document's
+			testCfg, cookie := newMoveTestWiki(t, tt.source, tt.other)
+			root := testCfg.Wiki.RootDir
+			write := func(file string) {
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			write(filepath.Join(root, "versions", "documents", filepath.FromSlash(tt.source), "20240101000000.md"))
+			write(filepath.Join(root, "comments", filepath.FromSlash(tt.source), "20240101000000_bob.md"))
+			write(filepath.Join(root, "versions", "documents", tt.other, "20240101000001.md"))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			for _, file := range []string{
+				filepath.Join(root, "versions", "documents", filepath.FromSlash(tt.target), "20240101000000.md"),
+				filepath.Join(root, "comments", filepath.FromSlash(tt.target), "20240101000000_bob.md"),
+				filepath.Join(root, "versions", "documents", tt.other, "20240101000001.md"),
+			} {
+				if _, err := os.Stat(file); err != nil {
+					t.Errorf("missing after the move: %v", err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(root, "versions", "documents", filepath.FromSlash(tt.source))); !os.IsNotExist(err) {
+				t.Errorf("versions of %s were left behind", tt.source)
+			}
+		})
+	}
+}
+
+func TestMoveKeepsVersionsAndCommentsTogether(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides/setup")
+	root := testCfg.Wiki.RootDir
+	files := map[string]string{
+		"version": filepath.Join("versions", "documents", "guides", "setup", "20240101000000.md"),
+		"comment": filepath.Join("comments", "guides", "setup", "20240101000000_bob.md"),
+	}
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, file), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The source is spelled with extra slashes, as clients may send it
+	req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(`{"sourcePath":"/guides/setup/","targetPath":"manuals"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MoveDocumentHandler(rec, req, testCfg)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	for kind, file := range files {
+		moved := strings.Replace(file, filepath.Join("guides", "setup"), filepath.Join("manuals", "setup"), 1)
+		if _, err := os.Stat(filepath.Join(root, moved)); err != nil {
+			t.Errorf("%s wasn't relocated: %v", kind, err)
+		}
+		if _, err := os.Stat(filepath.Join(root, file)); !os.IsNotExist(err) {
+			t.Errorf("%s was left at %s", kind, file)
+		}
+	}
+}
+
+func TestMoveErrorResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		signIn  bool
+		body    string
+		status  int
+		message string
+	}{
+		{"unauthorized", http.MethodPost, false, `{"sourcePath":"a","targetPath":"docs"}`, http.StatusUnauthorized, "move.unauthorized"},
+		{"wrong method", http.MethodGet, true, "", http.StatusMethodNotAllowed, "error.method_not_allowed"},
+		{"invalid body", http.MethodPost, true, "{", http.StatusBadRequest, "error.invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+
+			req := httptest.NewRequest(tt.method, "/api/document/move", strings.NewReader(tt.body))
+			if tt.signIn {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+			}
+			want := map[string]interface{}{"success": false, "message": tt.message}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("body = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMoveRejectsMalformedBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		message     string // Start of the expected message key
+	}{
+		{"json", "application/json; charset=utf-8", `{"sourcePath":"a","targetPath":"docs"}`, http.StatusOK, ""},
+		{"no content type", "", `{"sourcePath":"a","targetPath":"docs"}`, http.StatusOK, ""},
+		{"form", "application/x-www-form-urlencoded", "sourcePath=a&targetPath=docs", http.StatusUnsupportedMediaType, "error.unsupported_media_type"},
+		{"text", "text/plain", `{"sourcePath":"a","targetPath":"docs"}`, http.StatusUnsupportedMediaType, "error.unsupported_media_type"},
+		{"misspelled field", "application/json", `{"source_path":"a","targetPath":"docs"}`, http.StatusBadRequest, "error.unknown_field"},
+		{"truncated", "application/json", `{"sourcePath":"a",`, http.StatusBadRequest, "error.invalid_request"},
+		{"oversized", "application/json", `{"sourcePath":"` + strings.Repeat("a", maxMoveRequestSize) + `"}`, http.StatusRequestEntityTooLarge, "error.request_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a", "docs")
+
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			var got MoveResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+			}
+			if !strings.HasPrefix(got.Message, tt.message) {
+				t.Errorf("message = %q, want %s", got.Message, tt.message)
+			}
+			if tt.status != http.StatusOK {
+				if _, err := os.Stat(filepath.Join(testCfg.Wiki.RootDir, "documents", "a", "document.md")); err != nil {
+					t.Errorf("document moved although the request was refused: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveRequiresEditorRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want int
+	}{
+		{config.RoleViewer, http.StatusForbidden},
+		{config.RoleEditor, http.StatusOK},
+		{config.RoleAdmin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t, "a", "docs")
+			cookie := moveTestSession(t, testCfg, tt.role, tt.role)
+
+			body := `{"sourcePath":"a","targetPath":"docs"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			_, err := os.Stat(filepath.Join(testCfg.Wiki.RootDir, "documents", "a", "document.md"))
+			if moved := os.IsNotExist(err); moved != (tt.want == http.StatusOK) {
+				t.Errorf("document moved = %v", moved)
+			}
+		})
+	}
+}
+
+func TestMoveAcrossNamespaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		groups  []string
+		publish []string // wiki.publish_groups
+		body    string
+		status  int
+		message string // Start of the expected message key when refused
+	}{
+		{"within a namespace", config.RoleEditor, []string{"staff"}, nil, `{"sourcePath":"internal/a","targetPath":"internal/b"}`, http.StatusOK, ""},
+		{"to a wider namespace", config.RoleEditor, []string{"staff"}, nil, `{"sourcePath":"internal/a","targetPath":"public"}`, http.StatusForbidden, "move.widens_access"},
+		{"by a publisher", config.RoleEditor, []string{"staff", "docs"}, []string{"docs"}, `{"sourcePath":"internal/a","targetPath":"public"}`, http.StatusOK, ""},
+		{"by an admin", config.RoleAdmin, nil, nil, `{"sourcePath":"internal/a","targetPath":"public"}`, http.StatusOK, ""},
+		{"to a narrower namespace", config.RoleEditor, []string{"staff"}, nil, `{"sourcePath":"public/p","targetPath":"internal"}`, http.StatusOK, ""},
+		{"category with a restricted child", config.RoleEditor, []string{"staff"}, nil, `{"sourcePath":"public/cat","targetPath":"public/p"}`, http.StatusForbidden, "move.widens_access"},
+		{"unreadable source", config.RoleEditor, nil, nil, `{"sourcePath":"internal/a","targetPath":"public"}`, http.StatusForbidden, "move.source_denied"},
+		{"unreadable target", config.RoleEditor, nil, nil, `{"sourcePath":"public/p","targetPath":"internal"}`, http.StatusForbidden, "move.target_denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t, "internal", "internal/a", "internal/b", "public", "public/p", "public/cat", "public/cat/secret")
+			testCfg.AccessRules = []config.AccessRule{
+				{Pattern: "/public/cat/secret/**", Access: "restricted", Groups: []string{"staff"}},
+				{Pattern: "/internal/**", Access: "restricted", Groups: []string{"staff"}},
+				{Pattern: "/public/**", Access: "public"},
+			}
+			testCfg.Wiki.PublishGroups = tt.publish
+
+			rec := httptest.NewRecorder()
+			if err := auth.CreateSession(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil), "user", tt.role, tt.groups, false, testCfg); err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			for _, c := range rec.Result().Cookies() {
+				req.AddCookie(c)
+			}
+			rec = httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			var got MoveResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got.Message, tt.message) {
+				t.Errorf("message = %q, want %s", got.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestDocumentNamespace(t *testing.T) {
+	tests := map[string]string{
+		"public/guides/setup": "/public",
+		"/internal/a/":        "/internal",
+		"faq":                 "/",
+	}
+	for docPath, want := range tests {
+		if got := documentNamespace(docPath); got != want {
+			t.Errorf("documentNamespace(%q) = %q, want %q", docPath, got, want)
+		}
+	}
+}
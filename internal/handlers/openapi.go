@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"wiki-go/internal/comments"
+	"wiki-go/internal/config"
+	"wiki-go/internal/presence"
+	"wiki-go/internal/utils"
+	"wiki-go/internal/version"
+)
+
+// APIOperation documents one endpoint of the JSON API. The OpenAPI spec is
+// generated from these, with the request and response schemas read from the
+// Go types the handlers encode and decode.
+type APIOperation struct {
+	Method  string
+	Path    string // OpenAPI path; {path} is a document path such as guides/setup
+	Tag     string
+	Summary string
+	Access  string            // Role required: "" for anyone the wiki lets read, "session" for any signed-in user, "editor" or "admin"
+	Query   map[string]string // Query parameters and their descriptions
+
+	Request     any    // Zero value of the request body type; nil when there is no body
+	RequestType string // Media type of the request body, application/json when empty
+	Response    any    // Zero value of the success response type; nil when there is no body
+	ContentType string // Media type of the success response, application/json when empty
+}
+
+// statusResponse is the body of most error responses and of simple successes
+type statusResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"` // Details, for some errors
+}
+
+// sessionResponse is the body of a successful /api/check-auth
+type sessionResponse struct {
+	Success  bool     `json:"success"`
+	Username string   `json:"username"`
+	Role     string   `json:"role"`
+	Groups   []string `json:"groups"`
+}
+
+// commentsResponse is the body of a successful comment listing
+type commentsResponse struct {
+	Success  bool               `json:"success"`
+	Comments []comments.Comment `json:"comments"`
+}
+
+// suggestionsResponse is the body of GET /api/suggestions
+type suggestionsResponse struct {
+	Success     bool                `json:"success"`
+	Suggestions []SuggestionSummary `json:"suggestions"`
+}
+
+// suggestionResponse is the body of GET /api/suggestions/{id}
+type suggestionResponse struct {
+	Success    bool             `json:"success"`
+	Suggestion SuggestionDetail `json:"suggestion"`
+}
+
+// quickSwitchResponse is the body of GET /api/quick-switch
+type quickSwitchResponse struct {
+	Success   bool                  `json:"success"`
+	Documents []utils.DocumentEntry `json:"documents"`
+}
+
+// favoritesResponse is the body of GET /api/favorites
+type favoritesResponse struct {
+	Success   bool               `json:"success"`
+	Favorites []FavoriteDocument `json:"favorites"`
+}
+
+// favoriteResponse is the body of the requests on /api/favorites/{path}
+type favoriteResponse struct {
+	Success bool   `json:"success"`
+	Path    string `json:"path"`
+	Starred bool   `json:"starred"`
+}
+
+// presenceResponse is the body of the requests on /api/presence/{path}. A
+// heartbeat lists the other editors, GET and DELETE everyone still editing.
+type presenceResponse struct {
+	Success bool              `json:"success"`
+	Path    string            `json:"path"`
+	Editors []presence.Editor `json:"editors"`
+}
+
+// apiOperations lists the documented endpoints. Routes are checked against it
+// in the routes package tests.
+var apiOperations = []APIOperation{
+	{Method: http.MethodPost, Path: "/api/login", Tag: "auth", Summary: "Sign in and receive a session cookie",
+		Request: LoginRequest{}, Response: statusResponse{}},
+	{Method: http.MethodPost, Path: "/api/refresh", Tag: "auth", Summary: "Trade the refresh token cookie of a kept login for a new session",
+		Response: sessionResponse{}},
+	{Method: http.MethodPost, Path: "/api/logout", Tag: "auth", Summary: "End the current session",
+		Response: statusResponse{}},
+	{Method: http.MethodGet, Path: "/api/check-auth", Tag: "auth", Summary: "Describe the signed-in user", Access: "session",
+		Response: sessionResponse{}},
+	{Method: http.MethodGet, Path: "/api/whoami", Tag: "auth", Summary: "Describe the signed-in user and what they may do", Access: "session",
+		Response: whoamiResponse{}},
+	{Method: http.MethodGet, Path: "/api/session/status", Tag: "auth", Summary: "Tell how long the session has left without counting as activity",
+		Response: SessionStatusResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/source/{path}", Tag: "content", Summary: "Read the markdown of a document", Access: "editor",
+		Query: map[string]string{"lang": "Language of a translation to read instead of the document"}, Response: "", ContentType: "text/plain"},
+	{Method: http.MethodPost, Path: "/api/save/{path}", Tag: "content", Summary: "Replace the markdown of a document, keeping the previous content as a version", Access: "editor",
+		Query:   map[string]string{"lang": "Language of a translation to save instead of the document", "message": "Description of the change"},
+		Request: "", RequestType: "text/plain", Response: statusResponse{}},
+	{Method: http.MethodPost, Path: "/api/document/create", Tag: "content", Summary: "Create a document", Access: "editor",
+		Request: CreateDocumentRequest{}, Response: CreateDocumentResponse{}},
+	{Method: http.MethodGet, Path: "/api/document/{path}", Tag: "content", Summary: "Read the raw markdown of a document, with its ETag",
+		Query: map[string]string{"lang": "Language of a translation to read instead of the document"}, Response: "", ContentType: "text/markdown"},
+	{Method: http.MethodHead, Path: "/api/document/{path}", Tag: "content", Summary: "Check a document without reading it: ETag, Last-Modified, Content-Length and its title in X-Document-Title",
+		Query: map[string]string{"lang": "Language of a translation to check instead of the document"}},
+	{Method: http.MethodPut, Path: "/api/document/{path}", Tag: "content", Summary: "Replace or create a document; send If-Match with the ETag read to avoid overwriting changes", Access: "editor",
+		Query:   map[string]string{"lang": "Language of a translation to write instead of the document", "message": "Description of the change"},
+		Request: "", RequestType: "text/markdown", Response: statusResponse{}},
+	{Method: http.MethodDelete, Path: "/api/document/{path}", Tag: "content", Summary: "Delete a document and its children", Access: "editor",
+		Response: statusResponse{}},
+	{Method: http.MethodGet, Path: "/api/compare", Tag: "content", Summary: "Diff the markdown of two documents",
+		Query: map[string]string{
+			"from":    "Path of the first document",
+			"to":      "Path of the second document",
+			"context": "Unchanged lines shown around each change (default 3, max 100)",
+		},
+		Response: CompareResponse{}},
+	{Method: http.MethodPost, Path: "/api/document/move", Tag: "content", Summary: "Move or rename a document or category", Access: "editor",
+		Request: MoveRequest{}, Response: MoveResponse{}},
+	{Method: http.MethodPost, Path: "/api/document/merge", Tag: "content", Summary: "Merge a secondary document into a primary one, then trash it or leave a redirect", Access: "editor",
+		Request: MergeRequest{}, Response: MergeResponse{}},
+	{Method: http.MethodGet, Path: "/api/aliases", Tag: "content", Summary: "List the aliases declared in front matter and those taken by a document or another alias", Access: "editor",
+		Response: AliasesResponse{}},
+	{Method: http.MethodPost, Path: "/api/suggestions", Tag: "content", Summary: "Suggest a new version of a document for editors to review",
+		Request: SuggestionRequest{}, Response: statusResponse{}},
+	{Method: http.MethodGet, Path: "/api/suggestions", Tag: "content", Summary: "List pending edit suggestions", Access: "editor",
+		Query: map[string]string{"path": "Only list suggestions for this document"}, Response: suggestionsResponse{}},
+	{Method: http.MethodGet, Path: "/api/suggestions/{id}", Tag: "content", Summary: "Review an edit suggestion as a diff of the current document", Access: "editor",
+		Response: suggestionResponse{}},
+	{Method: http.MethodDelete, Path: "/api/suggestions/{id}", Tag: "content", Summary: "Reject an edit suggestion", Access: "editor",
+		Response: statusResponse{}},
+	{Method: http.MethodPost, Path: "/api/suggestions/accept", Tag: "content", Summary: "Apply an edit suggestion as a new revision", Access: "editor",
+		Request: AcceptSuggestionRequest{}, Response: statusResponse{}},
+	{Method: http.MethodGet, Path: "/api/quick-switch", Tag: "content", Summary: "List the title and path of every readable document; send If-None-Match to revalidate a kept copy",
+		Response: quickSwitchResponse{}},
+	{Method: http.MethodGet, Path: "/api/favorites", Tag: "content", Summary: "List the documents the signed-in user starred", Access: "session",
+		Response: favoritesResponse{}},
+	{Method: http.MethodGet, Path: "/api/favorites/{path}", Tag: "content", Summary: "Tell whether the signed-in user starred a document", Access: "session",
+		Response: favoriteResponse{}},
+	{Method: http.MethodPut, Path: "/api/favorites/{path}", Tag: "content", Summary: "Star a document", Access: "session",
+		Response: favoriteResponse{}},
+	{Method: http.MethodDelete, Path: "/api/favorites/{path}", Tag: "content", Summary: "Unstar a document", Access: "session",
+		Response: favoriteResponse{}},
+	{Method: http.MethodGet, Path: "/api/presence/{path}", Tag: "content", Summary: "List the users editing a document", Access: "editor",
+		Response: presenceResponse{}},
+	{Method: http.MethodPut, Path: "/api/presence/{path}", Tag: "content", Summary: "Announce that the signed-in user is editing a document and list the other editors; repeat within 45 seconds to stay listed", Access: "editor",
+		Response: presenceResponse{}},
+	{Method: http.MethodDelete, Path: "/api/presence/{path}", Tag: "content", Summary: "Stop being listed as editing a document", Access: "editor",
+		Response: presenceResponse{}},
+	{Method: http.MethodPost, Path: "/api/export/zip", Tag: "content", Summary: "Download documents as a ZIP archive",
+		Request: ExportZipRequest{}, Response: []byte(nil), ContentType: "application/zip"},
+	{Method: http.MethodGet, Path: "/api/export/bundle/{path}", Tag: "content", Summary: "Download the version history of a document as a git bundle, one commit per version", Access: "editor",
+		Response: []byte(nil), ContentType: "application/x-git-bundle"},
+	{Method: http.MethodGet, Path: "/api/qr/{path}", Tag: "content", Summary: "Get a QR code of the URL of a document, for printed signs",
+		Query: map[string]string{"format": "svg (default) or png", "scale": "Pixels per module of a PNG (default 8, max 32)"}, Response: "", ContentType: "image/svg+xml"},
+
+	{Method: http.MethodGet, Path: "/api/comments/{path}", Tag: "comments", Summary: "List the comments of a document",
+		Response: commentsResponse{}},
+	{Method: http.MethodPost, Path: "/api/comments/add/{path}", Tag: "comments", Summary: "Comment on a document", Access: "session",
+		Request: CommentRequest{}, Response: CommentResponse{}},
+	{Method: http.MethodDelete, Path: "/api/comments/delete/{path}/{commentId}", Tag: "comments", Summary: "Delete a comment", Access: "admin",
+		Response: statusResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/related/{path}", Tag: "search", Summary: "List the documents most related to a document by shared tags and wording",
+		Query: map[string]string{"limit": "Number of documents (default 5, max 50)"}, Response: RelatedResponse{}},
+	{Method: http.MethodPost, Path: "/api/search", Tag: "search", Summary: "Search document contents",
+		Query: map[string]string{
+			"limit":  "Page size; without it all results are streamed",
+			"cursor": "Value of the X-Next-Cursor header of the previous page",
+		},
+		Request: SearchRequest{}, Response: []SearchResult{}},
+
+	{Method: http.MethodGet, Path: "/api/stats", Tag: "admin", Summary: "Wiki statistics", Access: "admin",
+		Response: StatsResponse{}},
+	{Method: http.MethodGet, Path: "/api/integrity", Tag: "admin", Summary: "List the versions and comments left behind by documents that no longer exist", Access: "admin",
+		Response: IntegrityReport{}},
+	{Method: http.MethodPost, Path: "/api/integrity/cleanup", Tag: "admin", Summary: "Move orphaned versions and comments to the trash", Access: "admin",
+		Request: IntegrityCleanupRequest{}, Response: IntegrityCleanupResponse{}},
+	{Method: http.MethodPost, Path: "/api/reindex", Tag: "admin", Summary: "Rebuild the search index and the caches derived from the documents in the background", Access: "admin",
+		Response: ReindexResponse{}},
+	{Method: http.MethodGet, Path: "/api/reindex", Tag: "admin", Summary: "Report the progress of the running or last rebuild", Access: "admin",
+		Response: ReindexResponse{}},
+	{Method: http.MethodGet, Path: "/api/review", Tag: "admin", Summary: "List the documents past their reviewBy date", Access: "admin",
+		Query: map[string]string{"all": "1 to include every document with a review date"}, Response: ReviewReportResponse{}},
+	{Method: http.MethodPost, Path: "/api/review/mark", Tag: "content", Summary: "Mark a document as reviewed and schedule its next review", Access: "editor",
+		Request: MarkReviewedRequest{}, Response: MarkReviewedResponse{}},
+	{Method: http.MethodPost, Path: "/api/access-rules/apply", Tag: "admin", Summary: "Apply an access rule to a category and every document below it", Access: "admin",
+		Request: ApplyAccessRuleRequest{}, Response: ApplyAccessRuleResponse{}},
+}
+
+// APIOperations returns the documented endpoints of the JSON API
+func APIOperations() []APIOperation {
+	return apiOperations
+}
+
+// OpenAPIHandler serves an OpenAPI 3 description of the JSON API
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPISpec(cfg))
+}
+
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// openAPISpec builds the OpenAPI document for apiOperations
+func openAPISpec(cfg *config.Config) map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]map[string]any{}
+	errorSchema := schemaOf(reflect.TypeOf(statusResponse{}), schemas)
+
+	for _, op := range apiOperations {
+		success := map[string]any{"description": "Success"}
+		if op.Response != nil {
+			success["content"] = mediaContent(op.ContentType, op.Response, schemas)
+		}
+		operation := map[string]any{
+			"tags":    []string{op.Tag},
+			"summary": op.Summary,
+			"responses": map[string]any{
+				"200":     success,
+				"default": map[string]any{"description": "Error", "content": map[string]any{"application/json": map[string]any{"schema": errorSchema}}},
+			},
+		}
+		switch op.Access {
+		case "":
+			operation["description"] = "Follows the wiki's access mode and access rules."
+		case "session":
+			operation["description"] = "Requires a signed-in user."
+			operation["security"] = []map[string][]string{{"session": {}}}
+		default:
+			operation["description"] = "Requires the " + op.Access + " role."
+			operation["security"] = []map[string][]string{{"session": {}}}
+		}
+
+		var params []map[string]any
+		for _, m := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+			param := map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]string{"type": "string"}}
+			if m[1] == "path" {
+				param["description"] = "Document path, which may contain slashes"
+			}
+			params = append(params, param)
+		}
+		for _, name := range slices.Sorted(maps.Keys(op.Query)) {
+			params = append(params, map[string]any{"name": name, "in": "query", "description": op.Query[name], "schema": map[string]string{"type": "string"}})
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{"required": true, "content": mediaContent(op.RequestType, op.Request, schemas)}
+		}
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]any{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   cfg.Wiki.Title + " API",
+			"version": version.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"session": map[string]string{"type": "apiKey", "in": "cookie", "name": "session_token"},
+			},
+		},
+	}
+}
+
+// mediaContent returns the content map of a request or response body
+func mediaContent(mediaType string, value any, schemas map[string]any) map[string]any {
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	schema := schemaOf(reflect.TypeOf(value), schemas)
+	if mediaType != "application/json" {
+		schema = map[string]any{"type": "string"}
+		if !strings.HasPrefix(mediaType, "text/") {
+			schema["format"] = "binary"
+		}
+	}
+	return map[string]any{mediaType: map[string]any{"schema": schema}}
+}
+
+// schemaOf returns the JSON schema of t as encoding/json encodes it. Named
+// structs are added to schemas and referenced.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+	default:
+		return map[string]any{}
+	}
+
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	ref := map[string]any{"$ref": "#/components/schemas/" + string(name)}
+	if _, ok := schemas[string(name)]; ok {
+		return ref
+	}
+	schemas[string(name)] = nil // Placeholder for recursive types
+
+	properties := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldName, options, _ := strings.Cut(tag, ",")
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		properties[fieldName] = schemaOf(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, fieldName)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if required != nil {
+		schema["required"] = required
+	}
+	schemas[string(name)] = schema
+	return ref
+}
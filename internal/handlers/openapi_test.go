@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestOpenAPISchemaFollowsJSONTags(t *testing.T) {
+	schemas := map[string]any{}
+	schemaOf(reflect.TypeOf(MoveResponse{}), schemas)
+
+	move, ok := schemas["MoveResponse"].(map[string]any)
+	if !ok {
+		t.Fatalf("MoveResponse schema missing: %v", schemas)
+	}
+	var properties []string
+	for name := range move["properties"].(map[string]any) {
+		properties = append(properties, name)
+	}
+	slices.Sort(properties)
+	if want := []string{"conflict", "message", "newPath", "oldPath", "success"}; !slices.Equal(properties, want) {
+		t.Errorf("properties = %q, want %q", properties, want)
+	}
+	if required := move["required"].([]string); !slices.Equal(required, []string{"success", "message"}) {
+		t.Errorf("required = %q", required)
+	}
+	if _, ok := schemas["MoveConflict"]; !ok {
+		t.Error("nested MoveConflict schema missing")
+	}
+}
+
+// TestOpenAPISessionResponse checks the documented /api/check-auth body
+// against what the handler sends
+func TestOpenAPISessionResponse(t *testing.T) {
+	_, cookie := newMoveTestWiki(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/check-auth", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	CheckAuthHandler(rec, req)
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	schemas := map[string]any{}
+	schemaOf(reflect.TypeOf(sessionResponse{}), schemas)
+	for name := range schemas["SessionResponse"].(map[string]any)["properties"].(map[string]any) {
+		if _, ok := got[name]; !ok {
+			t.Errorf("documented field %q missing from response %v", name, got)
+		}
+	}
+	for name := range got {
+		if _, ok := schemas["SessionResponse"].(map[string]any)["properties"].(map[string]any)[name]; !ok {
+			t.Errorf("response field %q is not documented", name)
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	testCfg := &config.Config{}
+	testCfg.Wiki.Title = "Wiki"
+	rec := httptest.NewRecorder()
+	OpenAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil), testCfg)
+
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI == "" || spec.Paths["/api/document/move"]["post"] == nil {
+		t.Errorf("spec lacks the move operation: %s", rec.Body.String())
+	}
+}
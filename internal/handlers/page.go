@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"html"
 	"html/template"
 	"net/http"
 	"net/url"
@@ -14,6 +15,8 @@ import (
 	"wiki-go/internal/comments"
 	"wiki-go/internal/config"
 	"wiki-go/internal/i18n"
+	"wiki-go/internal/roles"
+	"wiki-go/internal/sanitize"
 	"wiki-go/internal/types"
 	"wiki-go/internal/utils"
 	"wiki-go/internal/frontmatter"
@@ -72,12 +75,12 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 			return
 		}
 		// Show 403 Forbidden if authenticated but unauthorized
-		http.Error(w, "Forbidden", http.StatusForbidden)
+		http.Error(w, i18n.T(requestLanguage(r), "error.forbidden"), http.StatusForbidden)
 		return
 	}
 
 	// Build navigation
-	nav, err := utils.BuildNavigation(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	nav, err := utils.BuildNavigation(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, config.VersionsPath(cfg))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -97,6 +100,9 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	// Check if path exists
 	info, err := os.Stat(fsPath)
 	if err != nil || !info.IsDir() {
+		if serveAlias(w, r, decodedPath) {
+			return
+		}
 		NotFoundHandler(w, r, cfg)
 		return
 	}
@@ -118,12 +124,30 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	var lastModified time.Time
 	var dirContent template.HTML
 	var rawContent string // Raw markdown content for edit mode
+	var viewCount int64
+	var lastEditedBy string
+	var wordCount, readingTime int
+	var children frontmatter.Children // How the category's children are listed
 
-	// Look for document.md in the directory
-	docPath := filepath.Join(fsPath, "document.md")
+	// Look for the document's content file in the directory
+	docPath := utils.DocumentFile(fsPath)
 	docInfo, err := os.Stat(docPath)
+	var documentLang string
+	var translations []types.Translation
 	if err == nil {
-		// Read and render document.md if it exists
+		// Show the language variant that best matches the reader
+		var variant string
+		variant, documentLang, translations = selectTranslation(w, r, fsPath, path)
+		if variant != "" {
+			docPath = filepath.Join(fsPath, utils.TranslationFileName(variant))
+			docInfo, err = os.Stat(docPath)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Read and render the document if it exists
 		mdContent, err := os.ReadFile(docPath)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -137,23 +161,44 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 
 		// Parse frontmatter to get document layout
 		metadata, _, hasFrontmatter := frontmatter.Parse(string(mdContent))
+
+		// A document replaced by another sends its readers there; editors can
+		// still open it in edit mode
+		if target := redirectTarget(metadata.Redirect); target != "" && !isEditMode && target != "/"+strings.Trim(decodedPath, "/") {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
 		documentLayout := ""
 		if hasFrontmatter {
 			documentLayout = metadata.Layout
 		}
+		children = metadata.Children
 
 		// Use the document path for rendering to handle local file references
-		content = template.HTML(utils.RenderMarkdownWithPath(string(mdContent), decodedPath))
+		content = template.HTML(renderDocument(mdContent, decodedPath))
 		
 		// If content is empty but document exists, ensure we have something truthy for template conditions
 		if strings.TrimSpace(string(content)) == "" {
 			content = template.HTML(" ") // Single space to make it truthy but effectively empty
 		}
 		
-		lastModified = docInfo.ModTime()
+		lastModified, lastEditedBy = utils.LastEdit(docPath, versionsDir(decodedPath, variant))
+		if cfg.Wiki.ReadingSpeed > 0 {
+			wordCount, readingTime = utils.DocumentReadingTime(string(mdContent), cfg.Wiki.ReadingSpeed)
+		}
 
 		// Update the document layout in the page data
 		navItem.DocumentLayout = documentLayout
+
+		if !isEditMode {
+			viewCount = recordView(r, decodedPath)
+		}
+	}
+
+	// Print mode renders just the document, without navigation chrome
+	if isPrintMode(r) && docInfo != nil {
+		renderPrintPage(w, r, cfg, navItem.Title, content, lastModified)
+		return
 	}
 
 	// List directory contents
@@ -163,23 +208,44 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		return
 	}
 
-	// Build directory listing HTML
-	var dirItems []string
+	// Collect subdirectories and order them by the category's sort settings
+	var dirNames []string
 	for _, f := range files {
-		if !f.IsDir() || strings.HasPrefix(f.Name(), ".") || f.Name() == "document.md" {
-			continue // Skip non-directories, hidden files, and document.md
+		if !f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue // Skip non-directories and hidden files
 		}
+		dirNames = append(dirNames, f.Name())
+	}
+	utils.SortCategoryChildren(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, config.VersionsPath(cfg), decodedPath, dirNames)
 
-		dirName := f.Name()
+	// Build directory listing HTML, unless the category's document hides it
+	var dirItems []string
+	if children.Hidden {
+		dirNames = nil
+	}
+	for _, dirName := range dirNames {
 		urlPath := filepath.Join(path, dirName)
 
-		// Check if subdirectory has a document.md
-		subDocPath := filepath.Join(fsPath, dirName, "document.md")
+		// Check if subdirectory has a document
+		subDocPath := utils.DocumentFile(filepath.Join(fsPath, dirName))
 		if _, err := os.Stat(subDocPath); err == nil {
 			// Use the GetDocumentTitle function which includes emoji processing
 			dirTitle := utils.GetDocumentTitle(filepath.Join(fsPath, dirName))
-			dirItems = append(dirItems, fmt.Sprintf(`<div class="directory-item is-dir"><a href="%s">%s</a></div>`,
-				urlPath, dirTitle))
+			readingTimeLabel, descriptionLabel := "", ""
+			if cfg.Wiki.ReadingSpeed > 0 || children.Descriptions {
+				if subContent, err := os.ReadFile(subDocPath); err == nil {
+					if cfg.Wiki.ReadingSpeed > 0 {
+						if _, minutes := utils.DocumentReadingTime(string(subContent), cfg.Wiki.ReadingSpeed); minutes > 0 {
+							readingTimeLabel = fmt.Sprintf(` <span class="reading-time">%s</span>`, i18n.T(requestLanguage(r), "reading.minutes", minutes))
+						}
+					}
+					if subMetadata, _, _ := frontmatter.Parse(string(subContent)); children.Descriptions && subMetadata.Description != "" {
+						descriptionLabel = fmt.Sprintf(`<div class="directory-description">%s</div>`, html.EscapeString(subMetadata.Description))
+					}
+				}
+			}
+			dirItems = append(dirItems, fmt.Sprintf(`<div class="directory-item is-dir"><a href="%s">%s</a>%s%s</div>`,
+				urlPath, dirTitle, readingTimeLabel, descriptionLabel))
 			continue
 		}
 
@@ -217,36 +283,57 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		userRole = session.Role
 	}
 
-	// Comments are only available for documents
+	// Review reminders and comments are only available for documents.
+	// Translations share the review schedule, comments and settings of document.md.
+	var reviewBy string
+	var reviewOverdue bool
+	var related []types.DocumentLink
 	if isDocument {
+		if !isEditMode {
+			related = relatedLinks(decodedPath, session)
+		}
+		mdContent, _ := os.ReadFile(utils.DocumentFile(fsPath))
+		if status, ok := documentReviewStatus(string(mdContent)); ok {
+			reviewBy, reviewOverdue = status.ReviewBy, status.Stale
+		}
+
 		// UNCONDITIONALLY check system-wide setting first
 		if cfg.Wiki.DisableComments {
 			// If comments are disabled system-wide, force commentsAllowed to false
 			commentsAllowed = false
 		} else {
 			// Only check document-specific settings if system allows comments
-			mdContent, _ := os.ReadFile(docPath)
-			commentsAllowed = comments.AreCommentsAllowed(string(mdContent))
+			commentsAllowed = documentAcceptsComments(string(mdContent))
 
 			// Only load comments if they're allowed
 			if commentsAllowed {
-				commentsList, _ = comments.GetComments(decodedPath)
+				commentsList, _ = comments.GetComments(config.CommentsPath(cfg), decodedPath)
 
 				// Process comments (render markdown, format timestamps)
 				for i := range commentsList {
-					// Use template.HTML to properly render the HTML without escaping
-					commentsList[i].RenderedHTML = template.HTML(utils.RenderMarkdown(commentsList[i].Content))
+					// Comments are always sanitized, even when documents are not
+					commentsList[i].RenderedHTML = template.HTML(sanitize.Comment(string(utils.RenderMarkdown(commentsList[i].Content))))
 					commentsList[i].FormattedTime = comments.FormatCommentTime(commentsList[i].Timestamp)
+					if userRole != roles.RoleAdmin {
+						commentsList[i].Email = ""
+					}
 				}
 			}
 		}
 	}
 
+	// Visitors who are not signed in see the anonymous comment form, if any
+	anonymousComments := ""
+	if !isAuthenticated {
+		anonymousComments = anonymousCommentMode()
+	}
+
 	// Prepare template data
 	data := &types.PageData{
 		Navigation:         &types.NavTree{Root: nav, AlwaysOpen: cfg.Wiki.AlwaysOpenChildrenInSidebar},
 		Content:            content,
 		DirContent:         dirContent,
+		DirHeading:         children.Heading,
 		Breadcrumbs:        breadcrumbs,
 		Config:             cfg,
 		LastModified:       lastModified,
@@ -255,14 +342,24 @@ func PageHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		Comments:           commentsList,
 		CommentsAllowed:    commentsAllowed,
 		IsAuthenticated:    isAuthenticated,
+		AnonymousComments:  anonymousComments,
 		UserRole:           userRole,
 		DocPath:            decodedPath,
 		DocumentLayout:     navItem.DocumentLayout,
 		IsEditMode:         isEditMode,
 		RawContent:         rawContent, // Pass raw markdown content for edit mode
+		ViewCount:          viewCount,
+		LastEditedBy:       lastEditedBy,
+		DocumentLang:       documentLang,
+		Translations:       translations,
+		ReviewBy:           reviewBy,
+		ReviewOverdue:      reviewOverdue,
+		RelatedDocuments:   related,
+		WordCount:          wordCount,
+		ReadingTime:        readingTime,
 	}
 
-	renderTemplate(w, data)
+	renderDocumentTemplate(w, r, data)
 }
 
 // generateBreadcrumbs creates a breadcrumb trail from a path
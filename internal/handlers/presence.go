@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/presence"
+)
+
+// presenceTTL is how long an editor counts as present after their last
+// heartbeat; the editor sends one every 15 seconds
+const presenceTTL = 45 * time.Second
+
+// editorPresence tracks who has which document open in the editor
+var editorPresence = presence.New(presenceTTL)
+
+// PresenceHandler tells editors who else is editing a document. PUT
+// /api/presence/{path} is the heartbeat of an open editor and returns the
+// other editors, GET lists everyone editing the document and DELETE is sent
+// when the editor closes. Presence is advisory and never blocks a save.
+func PresenceHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	session := auth.GetSession(r)
+	if session == nil {
+		sendJSONError(w, "Authentication required", http.StatusUnauthorized, "")
+		return
+	}
+
+	docPath := "/" + strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/presence"), "/")
+	if !auth.CanAccessDocument(docPath, session, cfg) {
+		sendJSONError(w, "Forbidden", http.StatusForbidden, "")
+		return
+	}
+
+	var editors []presence.Editor
+	switch r.Method {
+	case http.MethodGet:
+		editors = editorPresence.Editors(docPath)
+	case http.MethodPut:
+		editors = editorPresence.Heartbeat(docPath, session.Username)
+	case http.MethodDelete:
+		editorPresence.Leave(docPath, session.Username)
+		editors = editorPresence.Editors(docPath)
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(presenceResponse{Success: true, Path: docPath, Editors: editors})
+}
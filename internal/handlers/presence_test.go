@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/presence"
+)
+
+func TestPresenceHandler(t *testing.T) {
+	testCfg, editorCookie := newMoveTestWiki(t, "a")
+
+	rec := httptest.NewRecorder()
+	if err := auth.CreateSession(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil), "bob", config.RoleEditor, nil, false, testCfg); err != nil {
+		t.Fatal(err)
+	}
+	bobCookie := rec.Result().Cookies()[0]
+
+	previous := editorPresence
+	editorPresence = presence.New(presenceTTL)
+	t.Cleanup(func() { editorPresence = previous })
+
+	tests := []struct {
+		name   string
+		method string
+		cookie *http.Cookie
+		want   []string
+	}{
+		{"bob opens the editor", http.MethodPut, bobCookie, []string{}},
+		{"editor sees bob", http.MethodPut, editorCookie, []string{"bob"}},
+		{"both are listed", http.MethodGet, editorCookie, []string{"bob", "editor"}},
+		{"bob closes the editor", http.MethodDelete, bobCookie, []string{"editor"}},
+		{"editor is alone", http.MethodPut, editorCookie, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/presence/a", nil)
+			req.AddCookie(tt.cookie)
+			rec := httptest.NewRecorder()
+			PresenceHandler(rec, req)
+
+			var resp presenceResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, %v", rec.Code, err)
+			}
+			got := []string{}
+			for _, e := range resp.Editors {
+				got = append(got, e.Username)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("editors = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("editors = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
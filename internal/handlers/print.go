@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/resources"
+	"wiki-go/internal/utils"
+)
+
+// PrintPageData is passed to the print template
+type PrintPageData struct {
+	Config       *config.Config
+	Title        string
+	Content      template.HTML
+	URL          string
+	PrintedAt    string
+	LastModified string
+	PrintedFrom  string
+	PrintedOn    string
+	LastEdited   string
+}
+
+// isPrintMode reports whether the request asks for the print-friendly rendering
+func isPrintMode(r *http.Request) bool {
+	return r.URL.Query().Get("print") == "1"
+}
+
+// renderPrintPage writes a standalone, chrome-free page containing only the
+// document title, its rendered content and a footer with the URL and date
+func renderPrintPage(w http.ResponseWriter, r *http.Request, cfg *config.Config, title string, content template.HTML, lastModified time.Time) {
+	const dateFormat = "2006-01-02 15:04"
+
+	pageURL := getBaseURL(r, cfg) + r.URL.Path
+
+	data := PrintPageData{
+		Config:       cfg,
+		Title:        title,
+		Content:      content,
+		URL:          pageURL,
+		PrintedAt:    utils.FormatTimeInTimezone(time.Now(), cfg.Wiki.Timezone, dateFormat),
+		LastModified: utils.FormatTimeInTimezone(lastModified, cfg.Wiki.Timezone, dateFormat),
+		PrintedFrom:  i18n.Translate("print.printed_from"),
+		PrintedOn:    i18n.Translate("print.printed_on"),
+		LastEdited:   i18n.Translate("footer.last_edited"),
+	}
+
+	tmpl, err := template.ParseFS(resources.GetTemplatesFS(), "templates/print.html")
+	if err != nil {
+		http.Error(w, "Error parsing print template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Error rendering print template: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"image/png"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/qrcode"
+	"wiki-go/internal/utils"
+)
+
+// QR code PNGs are drawn with this many pixels per module unless ?scale= asks
+// for another size, up to maxQRCodeScale
+const (
+	defaultQRCodeScale = 8
+	maxQRCodeScale     = 32
+)
+
+// QRCodeHandler serves a QR code of the URL of a document, for signs and
+// handouts that link to it: GET /api/qr/{path}. The code is an SVG, which
+// prints sharp at any size, or a PNG with ?format=png. Only readers of the
+// document get one.
+func QRCodeHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	docPath := "/" + strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/qr"), "/")
+	if strings.Contains(docPath, "..") {
+		sendJSONError(w, "Invalid path", http.StatusBadRequest, "")
+		return
+	}
+	if !auth.CanAccessDocument(docPath, auth.GetSession(r), cfg) {
+		sendJSONError(w, "Forbidden", http.StatusForbidden, "")
+		return
+	}
+	if docPath != "/" {
+		if _, err := os.Stat(utils.DocumentFile(documentDir(docPath))); err != nil {
+			sendJSONError(w, "Document not found", http.StatusNotFound, "")
+			return
+		}
+	}
+
+	code, err := qrcode.Encode(documentURL(r, docPath))
+	if errors.Is(err, qrcode.ErrTooLong) {
+		sendJSONError(w, "The document's URL is too long for a QR code", http.StatusBadRequest, "")
+		return
+	} else if err != nil {
+		sendJSONError(w, "Failed to make QR code", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := path.Base(docPath)
+	if docPath == "/" {
+		name = "home"
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Content-Disposition", `inline; filename="`+name+`-qr.svg"`)
+		w.Write(code.SVG())
+	case "png":
+		scale := defaultQRCodeScale
+		if s := r.URL.Query().Get("scale"); s != "" {
+			if scale, err = strconv.Atoi(s); err != nil || scale < 1 || scale > maxQRCodeScale {
+				sendJSONError(w, "scale must be a number from 1 to "+strconv.Itoa(maxQRCodeScale), http.StatusBadRequest, "")
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Disposition", `inline; filename="`+name+`-qr.png"`)
+		png.Encode(w, code.Image(scale))
+	default:
+		sendJSONError(w, "format must be svg or png", http.StatusBadRequest, "")
+	}
+}
+
+// documentURL returns the absolute URL of the page of the document at docPath,
+// in its canonical form
+func documentURL(r *http.Request, docPath string) string {
+	cfg := config.Current()
+	u := getBaseURL(r, cfg) + escapePathSegments(docPath)
+	if docPath != "/" && cfg.Server.TrailingSlash == config.TrailingSlashAdd {
+		u += "/"
+	}
+	return u
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestQRCodeHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		private  bool
+		signedIn bool
+		want     int
+		wantType string
+	}{
+		{"svg", "/api/qr/guides/setup", false, false, http.StatusOK, "image/svg+xml"},
+		{"png", "/api/qr/guides/setup?format=png&scale=2", false, false, http.StatusOK, "image/png"},
+		{"homepage", "/api/qr/", false, false, http.StatusOK, "image/svg+xml"},
+		{"missing document", "/api/qr/missing", false, false, http.StatusNotFound, ""},
+		{"private wiki signed out", "/api/qr/guides/setup", true, false, http.StatusForbidden, ""},
+		{"private wiki signed in", "/api/qr/guides/setup", true, true, http.StatusOK, "image/svg+xml"},
+		{"unknown format", "/api/qr/guides/setup?format=gif", false, false, http.StatusBadRequest, ""},
+		{"scale too large", "/api/qr/guides/setup?format=png&scale=100", false, false, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "guides/setup")
+			if tt.private {
+				testCfg.Wiki.AccessMode = config.AccessModePrivate
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.signedIn {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			QRCodeHandler(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); tt.wantType != "" && got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestDocumentURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		baseURL       string
+		trailingSlash string
+		path          string
+		want          string
+	}{
+		{"request host", "", "", "/guides/setup", "http://example.com/guides/setup"},
+		{"base URL", "https://wiki.example.org/", "", "/guides/setup", "https://wiki.example.org/guides/setup"},
+		{"trailing slash", "https://wiki.example.org", config.TrailingSlashAdd, "/guides/setup", "https://wiki.example.org/guides/setup/"},
+		{"homepage", "https://wiki.example.org", config.TrailingSlashAdd, "/", "https://wiki.example.org/"},
+		{"escaped", "https://wiki.example.org", "", "/notes/q&a page", "https://wiki.example.org/notes/q&a%20page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t)
+			testCfg.Server.BaseURL = tt.baseURL
+			testCfg.Server.TrailingSlash = tt.trailingSlash
+
+			req := httptest.NewRequest(http.MethodGet, "/api/qr"+strings.ReplaceAll(tt.path, " ", "%20"), nil)
+			if got := documentURL(req, tt.path); got != tt.want {
+				t.Errorf("documentURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/utils"
+)
+
+// QuickSwitchHandler returns the title and path of every document the user
+// can read, for a quick switcher that matches them as the user types. The
+// list carries an ETag, so clients can keep it and revalidate with
+// If-None-Match, which is answered with 304 Not Modified until a document is
+// added, renamed, moved or removed.
+func QuickSwitchHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	session := auth.GetSession(r)
+	documents := []utils.DocumentEntry{}
+	if auth.CanAccessDocument("/", session, cfg) {
+		if _, err := os.Stat(utils.DocumentFile(documentDir("/"))); err == nil {
+			documents = append(documents, utils.DocumentEntry{Title: i18n.Translate("nav.home"), Path: "/"})
+		}
+	}
+	for _, target := range wikiLinkIndex() {
+		if auth.CanAccessDocument(target.Path, session, cfg) {
+			documents = append(documents, utils.DocumentEntry{Title: target.Title, Path: target.Path})
+		}
+	}
+
+	body, err := json.Marshal(quickSwitchResponse{Success: true, Documents: documents})
+	if err != nil {
+		sendJSONError(w, "Failed to list documents", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The list differs per user, so only the browser may keep it
+	etag := documentETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if notModified(r, etag, time.Time{}) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestQuickSwitch(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guide", "guide/setup", "secret")
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/secret", Access: "restricted", Groups: []string{"staff"}}}
+	invalidateWikiLinkIndex()
+	t.Cleanup(invalidateWikiLinkIndex)
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/quick-switch", nil)
+		req.AddCookie(cookie)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		QuickSwitchHandler(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var resp quickSwitchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{}
+	for _, doc := range resp.Documents {
+		paths[doc.Path] = doc.Title
+	}
+	if paths["/guide/setup"] != "guide/setup" || paths["/guide"] != "guide" {
+		t.Errorf("documents = %v", resp.Documents)
+	}
+	if _, ok := paths["/secret"]; ok {
+		t.Error("restricted document listed")
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified {
+		t.Errorf("revalidation: status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	// A new title changes the list
+	writeTestDocument(t, testCfg.Wiki.RootDir, "guide", "# User Guide\n")
+	invalidateWikiLinkIndex()
+	rec = get(etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("after retitling a document: status = %d, ETag = %s", rec.Code, rec.Header().Get("ETag"))
+	}
+}
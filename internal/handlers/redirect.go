@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/roles"
+)
+
+// safeRedirect returns target if it is a path on this wiki, and the homepage
+// otherwise, so that a redirect taken from a request cannot send users to
+// another site
+func safeRedirect(target string) string {
+	if !isLocalRedirect(target) {
+		return "/"
+	}
+	return target
+}
+
+// isLocalRedirect reports whether browsers resolve target, used as a
+// Location, to a path on this host. Percent-encoded forms are checked too, as
+// a target may be decoded again by whatever follows it.
+func isLocalRedirect(target string) bool {
+	for range 3 {
+		if !isLocalPath(target) {
+			return false
+		}
+		decoded, err := url.PathUnescape(target)
+		if err != nil {
+			return false
+		}
+		if decoded == target {
+			return true
+		}
+		target = decoded
+	}
+	// Still encoded after several rounds; no legitimate path looks like that
+	return false
+}
+
+// isLocalPath reports whether target, taken literally, is a path on this host
+func isLocalPath(target string) bool {
+	if !strings.HasPrefix(target, "/") {
+		return false
+	}
+	// Browsers read "//host" and "/\host" as URLs of another host, and drop
+	// tabs and line breaks anywhere in a URL
+	if len(target) > 1 && (target[1] == '/' || target[1] == '\\') {
+		return false
+	}
+	for _, c := range target {
+		if c < 0x20 || c == 0x7f {
+			return false
+		}
+	}
+	u, err := url.Parse(target)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// loginRedirect returns where a user with role goes after signing in: next,
+// the page the login was started from, if it is on this wiki, and otherwise
+// the landing page configured for the role
+func loginRedirect(next, role string) string {
+	cfg := config.Current()
+	if next != "" && isLocalRedirect(next) {
+		return next
+	}
+	landing := ""
+	switch role {
+	case roles.RoleAdmin:
+		landing = cfg.Server.Landing.Admin
+	case roles.RoleEditor:
+		landing = cfg.Server.Landing.Editor
+	case roles.RoleViewer:
+		landing = cfg.Server.Landing.Viewer
+	}
+	if landing == "" {
+		return "/"
+	}
+	return safeRedirect(landing)
+}
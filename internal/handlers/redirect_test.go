@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSafeRedirect(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/", "/"},
+		{"/guide/setup", "/guide/setup"},
+		{"/guide/setup?mode=edit#install", "/guide/setup?mode=edit#install"},
+		{"/caf%C3%A9", "/caf%C3%A9"},
+		{"", "/"},
+		{"guide", "/"},
+		{"https://evil.example/", "/"},
+		{"HTTPS://evil.example", "/"},
+		{"javascript:alert(1)", "/"},
+		{"//evil.example", "/"},
+		{"//evil.example/guide", "/"},
+		{"/\\evil.example", "/"},
+		{"\\\\evil.example", "/"},
+		{"/\t/evil.example", "/"},
+		{"/\n/evil.example", "/"},
+		{"%2F%2Fevil.example", "/"},
+		{"/%2Fevil.example", "/"},
+		{"/%5Cevil.example", "/"},
+		{"/%252Fevil.example", "/"},
+		{"/%09/evil.example", "/"},
+		{"/%25252525252F", "/"},
+		{"/%zz", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := safeRedirect(tt.target); got != tt.want {
+				t.Errorf("safeRedirect(%q) = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginPageRedirectsSignedInUsers(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t)
+	testCfg.Server.Landing.Editor = "/dashboard"
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "/dashboard"},
+		{"?next=/guide", "/guide"},
+		{"?redirect=%2Fguide%3Fmode%3Dedit", "/guide?mode=edit"},
+		{"?next=//evil.example", "/dashboard"},
+		{"?redirect=https://evil.example", "/dashboard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/login"+tt.query, nil)
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			LoginPageHandler(rec, req)
+			if rec.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+			}
+			if got := rec.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Signed-out visitors get the login form
+	rec := httptest.NewRecorder()
+	LoginPageHandler(rec, httptest.NewRequest(http.MethodGet, "/login?next=//evil.example", nil))
+	if rec.Code == http.StatusSeeOther {
+		t.Errorf("signed-out visitor redirected to %q", rec.Header().Get("Location"))
+	}
+}
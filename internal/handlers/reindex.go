@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+)
+
+// Steps of a reindex job, in order
+const (
+	reindexStepCaches = "caches" // Dropping rendered documents and the indexes built from documents
+	reindexStepSearch = "search" // Reading every document into the search index
+	reindexStepLinks  = "links"  // Rebuilding the wiki link and alias indexes
+)
+
+// ReindexJob is a rebuild of the state derived from the documents
+type ReindexJob struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`         // "processing" or "completed"
+	Step         string     `json:"step,omitempty"` // What is being rebuilt while processing
+	Progress     int        `json:"progress"`       // Percent of the markdown files indexed
+	TotalFiles   int        `json:"totalFiles"`     // Markdown files to index; 0 without a search index
+	IndexedFiles int        `json:"indexedFiles"`
+	Documents    int        `json:"documents"`   // Documents in the rebuilt link index
+	PrunedViews  int        `json:"prunedViews"` // View counts dropped as their document is gone
+	StartedBy    string     `json:"startedBy"`
+	StartedAt    time.Time  `json:"startedAt"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ReindexResponse is the JSON response of the reindex endpoint
+type ReindexResponse struct {
+	Success bool        `json:"success"`
+	Job     *ReindexJob `json:"job,omitempty"` // The running or last job; nil if none ran yet
+}
+
+var (
+	reindexMu sync.Mutex
+	// reindexJob is the running or last reindex job
+	reindexJob *ReindexJob
+)
+
+// ReindexHandler rebuilds the search index, the wiki link, alias and related
+// document indexes, the broken links report and the rendered documents from
+// the files, and drops the view counts of documents that are gone, as needed
+// after restoring a backup or importing documents outside the wiki. POST starts a
+// rebuild in the background, or returns the one running, so it is safe to
+// repeat; GET reports its progress. Reads are served from the existing state
+// until it is replaced.
+func ReindexHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ReindexResponse{Success: true, Job: currentReindexJob()})
+	case http.MethodPost:
+		username := ""
+		if session := auth.GetSession(r); session != nil {
+			username = session.Username
+		}
+		job := startReindex(username)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(ReindexResponse{Success: true, Job: job})
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+	}
+}
+
+// currentReindexJob returns a copy of the running or last job, or nil
+func currentReindexJob() *ReindexJob {
+	reindexMu.Lock()
+	defer reindexMu.Unlock()
+	if reindexJob == nil {
+		return nil
+	}
+	job := *reindexJob
+	return &job
+}
+
+// startReindex starts a reindex job unless one is running, and returns a copy
+// of the job that is
+func startReindex(username string) *ReindexJob {
+	reindexMu.Lock()
+	if reindexJob == nil || reindexJob.Status != "processing" {
+		reindexJob = &ReindexJob{
+			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			Status:    "processing",
+			Step:      reindexStepCaches,
+			StartedBy: username,
+			StartedAt: time.Now(),
+		}
+		go runReindex(reindexJob.ID)
+	}
+	reindexMu.Unlock()
+	return currentReindexJob()
+}
+
+// updateReindex changes the job with id, if it is still the current one
+func updateReindex(id string, update func(*ReindexJob)) {
+	reindexMu.Lock()
+	defer reindexMu.Unlock()
+	if reindexJob != nil && reindexJob.ID == id {
+		update(reindexJob)
+	}
+}
+
+// runReindex rebuilds the derived state for job id
+func runReindex(id string) {
+	start := time.Now()
+
+	// Everything built from the documents is dropped first, so whatever is
+	// read during the rebuild is built again from the current files
+	invalidateWikiLinkIndex()
+	renderCache.Purge()
+
+	updateReindex(id, func(j *ReindexJob) {
+		j.Step = reindexStepSearch
+		if searchBackend != nil {
+			j.TotalFiles = countMarkdownFiles()
+		}
+	})
+	rebuildSearchIndex(func() {
+		updateReindex(id, func(j *ReindexJob) {
+			j.IndexedFiles++
+			if j.TotalFiles > 0 {
+				j.Progress = min(100, j.IndexedFiles*100/j.TotalFiles)
+			}
+		})
+	})
+
+	updateReindex(id, func(j *ReindexJob) { j.Step = reindexStepLinks })
+	invalidateWikiLinkIndex()
+	documents := len(wikiLinkIndex())
+	documentAliases()
+
+	// The broken links report is scanned again when next requested
+	brokenLinksMu.Lock()
+	brokenLinksReport = nil
+	brokenLinksMu.Unlock()
+	prunedViews := pruneViewCounts()
+
+	updateReindex(id, func(j *ReindexJob) {
+		finished := time.Now()
+		j.Status = "completed"
+		j.Step = ""
+		j.Progress = 100
+		j.Documents = documents
+		j.PrunedViews = prunedViews
+		j.FinishedAt = &finished
+	})
+	log.Printf("Reindexed %d documents in %s, dropping the view counts of %d missing ones", documents, time.Since(start).Round(time.Millisecond), prunedViews)
+}
+
+// countMarkdownFiles counts the markdown files the search index reads
+func countMarkdownFiles() int {
+	cfg := config.Current()
+	count := 0
+	filepath.WalkDir(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(strings.ToLower(path), ".md") {
+			count++
+		}
+		return nil
+	})
+	return count
+}
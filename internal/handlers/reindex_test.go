@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"wiki-go/internal/search"
+	"wiki-go/internal/views"
+)
+
+func TestReindexHandler(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "bread", "guides/setup")
+	root := testCfg.Wiki.RootDir
+	writeTestDocument(t, root, "bread", "# Bread\n\nKnead the dough.")
+
+	searchBackend = search.NewMemory()
+	t.Cleanup(func() {
+		searchBackend = nil
+		searchReady.Store(false)
+		searchStale.Store(false)
+		reindexJob = nil
+	})
+	syncSearchIndex("")
+
+	store, err := views.Open(filepath.Join(root, "stats", "views.json"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewCounts = store
+	t.Cleanup(func() {
+		store.Close()
+		viewCounts = nil
+		brokenLinksReport = nil
+	})
+	store.Record("/bread", "ip:192.0.2.1")
+	store.Record("/gone", "ip:192.0.2.1")
+	brokenLinksReport = &BrokenLinksResponse{Success: true}
+
+	// A file restored from a backup keeps its old modification time, which a
+	// sync takes for unchanged
+	file := filepath.Join(root, testCfg.Wiki.DocumentsDir, "bread", "document.md")
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestDocument(t, root, "bread", "# Bread\n\nProof the sourdough.")
+	if err := os.Chtimes(file, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	syncSearchIndex("")
+	if paths, _ := searchBackend.Query([]string{"sourdough"}); len(paths) != 0 {
+		t.Fatalf("sync indexed the restored file: %v", paths)
+	}
+
+	rec := httptest.NewRecorder()
+	ReindexHandler(rec, httptest.NewRequest(http.MethodPost, "/api/reindex", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202 (%s)", rec.Code, rec.Body.String())
+	}
+
+	var job *ReindexJob
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		rec := httptest.NewRecorder()
+		ReindexHandler(rec, httptest.NewRequest(http.MethodGet, "/api/reindex", nil))
+		var response ReindexResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		job = response.Job
+		if job != nil && job.Status == "completed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("reindex did not complete: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if job.TotalFiles != 2 || job.IndexedFiles != 2 || job.Progress != 100 || job.Documents != 2 || job.PrunedViews != 1 {
+		t.Errorf("job = %+v", job)
+	}
+	if store.Count("/bread") != 1 || store.Count("/gone") != 0 {
+		t.Errorf("views of /bread = %d and /gone = %d, want 1 and 0", store.Count("/bread"), store.Count("/gone"))
+	}
+	brokenLinksMu.Lock()
+	if brokenLinksReport != nil {
+		t.Error("broken links report kept across the reindex")
+	}
+	brokenLinksMu.Unlock()
+	if paths, _ := searchBackend.Query([]string{"sourdough"}); !slices.Contains(paths, "bread/document.md") {
+		t.Errorf("search index after reindex = %v, want bread/document.md", paths)
+	}
+}
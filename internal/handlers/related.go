@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/types"
+	"wiki-go/internal/utils"
+)
+
+const (
+	// relatedIndexTTL bounds how long changes made outside the wiki, which
+	// don't invalidate the index, take to show in related documents
+	relatedIndexTTL = 5 * time.Minute
+	// relatedTagWeight is the share of the score given to shared tags; the
+	// rest comes from the overlap of the documents' wording
+	relatedTagWeight = 0.5
+)
+
+// RelatedDocument is a document suggested as related to another
+type RelatedDocument struct {
+	Title string  `json:"title"`
+	Path  string  `json:"path"`
+	Score float64 `json:"score"` // Similarity from 0 to 1
+}
+
+// RelatedResponse is the JSON response of the related documents endpoint
+type RelatedResponse struct {
+	Success   bool              `json:"success"`
+	Documents []RelatedDocument `json:"documents"`
+}
+
+// relatedDocument is a document of the related documents index
+type relatedDocument struct {
+	title string
+	path  string
+	tags  map[string]bool
+	terms map[string]float64 // TF-IDF weights, normalized to unit length
+}
+
+var (
+	relatedMu    sync.Mutex
+	relatedDocs  []*relatedDocument
+	relatedBuilt time.Time
+	// relatedRankings caches every other document ranked by similarity, by path
+	relatedRankings map[string][]RelatedDocument
+)
+
+// relatedStopWords are frequent words that say nothing about a document's subject
+var relatedStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+	"you": true, "all": true, "can": true, "has": true, "have": true, "this": true,
+	"that": true, "with": true, "from": true, "they": true, "will": true, "would": true,
+	"there": true, "their": true, "what": true, "when": true, "which": true, "was": true,
+	"were": true, "been": true, "into": true, "than": true, "then": true, "them": true,
+	"these": true, "those": true, "your": true, "its": true, "our": true, "also": true,
+	"use": true, "using": true, "how": true, "more": true, "other": true, "some": true,
+	"such": true, "only": true, "any": true, "each": true, "may": true, "should": true,
+	"http": true, "https": true, "www": true, "com": true,
+}
+
+// invalidateRelatedDocuments drops the related documents index so the next
+// request rebuilds it from the current contents
+func invalidateRelatedDocuments() {
+	relatedMu.Lock()
+	relatedDocs = nil
+	relatedRankings = nil
+	relatedMu.Unlock()
+}
+
+// relatedRanking returns every other document ranked by similarity to the one
+// at urlPath, most similar first. Rankings are cached until the index is rebuilt.
+func relatedRanking(urlPath string) []RelatedDocument {
+	relatedMu.Lock()
+	defer relatedMu.Unlock()
+
+	if relatedDocs == nil || time.Since(relatedBuilt) > relatedIndexTTL {
+		relatedDocs = buildRelatedIndex()
+		relatedBuilt = time.Now()
+		relatedRankings = make(map[string][]RelatedDocument)
+	}
+	if ranking, ok := relatedRankings[urlPath]; ok {
+		return ranking
+	}
+
+	var current *relatedDocument
+	for _, doc := range relatedDocs {
+		if doc.path == urlPath {
+			current = doc
+			break
+		}
+	}
+	ranking := []RelatedDocument{}
+	if current != nil {
+		for _, doc := range relatedDocs {
+			if doc == current {
+				continue
+			}
+			if score := relatedScore(current, doc); score > 0 {
+				ranking = append(ranking, RelatedDocument{Title: doc.title, Path: doc.path, Score: math.Round(score*1000) / 1000})
+			}
+		}
+		sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].Score > ranking[j].Score })
+	}
+	relatedRankings[urlPath] = ranking
+	return ranking
+}
+
+// relatedDocumentsFor returns up to limit documents related to the one at
+// urlPath that session may read
+func relatedDocumentsFor(urlPath string, session *auth.Session, limit int) []RelatedDocument {
+	cfg := config.Current()
+	related := []RelatedDocument{}
+	for _, doc := range relatedRanking(urlPath) {
+		if len(related) == limit {
+			break
+		}
+		if auth.CanAccessDocument(doc.Path, session, cfg) {
+			related = append(related, doc)
+		}
+	}
+	return related
+}
+
+// relatedScore combines the tags two documents share with the cosine
+// similarity of their wording
+func relatedScore(a, b *relatedDocument) float64 {
+	var cosine float64
+	small, large := a.terms, b.terms
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	for term, weight := range small {
+		cosine += weight * large[term]
+	}
+
+	var tags float64
+	if len(a.tags) > 0 && len(b.tags) > 0 {
+		shared := 0
+		for tag := range a.tags {
+			if b.tags[tag] {
+				shared++
+			}
+		}
+		tags = float64(shared) / float64(len(a.tags)+len(b.tags)-shared)
+	}
+	return (1-relatedTagWeight)*cosine + relatedTagWeight*tags
+}
+
+// buildRelatedIndex reads the tags and term weights of every document
+func buildRelatedIndex() []*relatedDocument {
+	cfg := config.Current()
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		log.Printf("Warning: Failed to build related documents index: %v", err)
+	}
+
+	docs := make([]*relatedDocument, 0, len(documents))
+	counts := make([]map[string]int, 0, len(documents))
+	frequency := make(map[string]int) // Number of documents containing each term
+	for _, entry := range documents {
+		content, err := os.ReadFile(utils.DocumentFile(documentDir(entry.Path)))
+		if err != nil {
+			continue
+		}
+		metadata, body, _ := frontmatter.Parse(string(content))
+
+		doc := &relatedDocument{title: entry.Title, path: entry.Path, tags: make(map[string]bool)}
+		for _, tag := range metadata.Tags {
+			doc.tags[strings.ToLower(tag)] = true
+		}
+		termCounts := relatedTerms(body)
+		for term := range termCounts {
+			frequency[term]++
+		}
+		docs = append(docs, doc)
+		counts = append(counts, termCounts)
+	}
+
+	for i, doc := range docs {
+		doc.terms = make(map[string]float64, len(counts[i]))
+		var norm float64
+		for term, count := range counts[i] {
+			// Terms found in every document don't tell documents apart
+			idf := math.Log(float64(len(docs)) / float64(frequency[term]))
+			if idf <= 0 {
+				continue
+			}
+			weight := (1 + math.Log(float64(count))) * idf
+			doc.terms[term] = weight
+			norm += weight * weight
+		}
+		norm = math.Sqrt(norm)
+		for term := range doc.terms {
+			doc.terms[term] /= norm
+		}
+	}
+	return docs
+}
+
+// relatedTerms counts the words of markdown, lowercased, leaving out short
+// words, numbers and stop words
+func relatedTerms(markdown string) map[string]int {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(markdown), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) < 3 || relatedStopWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		counts[word]++
+	}
+	return counts
+}
+
+// relatedLinks returns the related documents shown below a document
+func relatedLinks(urlPath string, session *auth.Session) []types.DocumentLink {
+	cfg := config.Current()
+	if cfg.Wiki.RelatedDocuments <= 0 || urlPath == "/" {
+		return nil
+	}
+	var links []types.DocumentLink
+	for _, doc := range relatedDocumentsFor(urlPath, session, cfg.Wiki.RelatedDocuments) {
+		links = append(links, types.DocumentLink{Title: doc.Title, Path: doc.Path})
+	}
+	return links
+}
+
+// RelatedHandler returns the documents most related to a document by shared
+// tags and wording. URL format: /api/related/{document-path}.
+// Query parameter limit sets the number of results (default 5, max 50).
+func RelatedHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	docPath := "/" + strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/related"), "/")
+	session := auth.GetSession(r)
+	if !auth.CanAccessDocument(docPath, session, cfg) {
+		sendJSONError(w, "Forbidden", http.StatusForbidden, "")
+		return
+	}
+
+	limit := 5
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, 50)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RelatedResponse{
+		Success:   true,
+		Documents: relatedDocumentsFor(docPath, session, limit),
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestRelatedDocuments(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "go/setup", "go/testing", "cooking/bread", "private/go-notes")
+	root := testCfg.Wiki.RootDir
+	writeTestDocument(t, root, "go/setup", "---\ntags: [go, tooling]\n---\n\n# Setup\n\nInstall the compiler and configure modules for the project.")
+	writeTestDocument(t, root, "go/testing", "---\ntags: go\n---\n\n# Testing\n\nRun tests with the compiler toolchain; modules are downloaded first.")
+	writeTestDocument(t, root, "cooking/bread", "# Bread\n\nKnead the dough and let it rise overnight.")
+	writeTestDocument(t, root, "private/go-notes", "---\ntags: [go]\n---\n\n# Notes\n\nCompiler and modules notes.")
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/private/**", Access: "restricted", Groups: []string{"staff"}}}
+	invalidateRelatedDocuments()
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"shared tags and words, restricted hidden", "/go/setup", []string{"/go/testing"}},
+		{"unrelated document", "/cooking/bread", []string{}},
+		{"unknown document", "/missing", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RelatedHandler(rec, httptest.NewRequest(http.MethodGet, "/api/related"+tt.path, nil))
+
+			var resp RelatedResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("%v (%d)", err, rec.Code)
+			}
+			got := []string{}
+			for _, doc := range resp.Documents {
+				got = append(got, doc.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("related = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Saving a document drops the cached rankings
+	writeTestDocument(t, root, "cooking/bread", "---\ntags: [go]\n---\n\n# Bread\n\nCompiler modules.")
+	invalidateWikiLinkIndex()
+	if related := relatedDocumentsFor("/cooking/bread", nil, 5); len(related) != 2 {
+		t.Errorf("related after save = %+v, want both readable go documents", related)
+	}
+}
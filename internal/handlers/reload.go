@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/logging"
+)
+
+// restartSetting is a setting that is read once at startup, so changing it in
+// the config file only takes effect after a restart
+type restartSetting struct {
+	key string                             // Key in config.yaml
+	get func(c *config.Config) interface{} // Reads the setting
+	set func(dst, src *config.Config)      // Copies the running value back
+}
+
+var restartSettings = []restartSetting{
+	{"server.host", func(c *config.Config) interface{} { return c.Server.Host }, func(d, s *config.Config) { d.Server.Host = s.Server.Host }},
+	{"server.port", func(c *config.Config) interface{} { return c.Server.Port }, func(d, s *config.Config) { d.Server.Port = s.Server.Port }},
+	{"server.tls", func(c *config.Config) interface{} { return c.Server.TLS }, func(d, s *config.Config) { d.Server.TLS = s.Server.TLS }},
+	{"server.metrics", func(c *config.Config) interface{} { return c.Server.Metrics }, func(d, s *config.Config) { d.Server.Metrics = s.Server.Metrics }},
+	{"server.compression", func(c *config.Config) interface{} { return c.Server.Compression }, func(d, s *config.Config) { d.Server.Compression = s.Server.Compression }},
+	// The timeouts and max_header_bytes are set on the http.Server at startup;
+	// max_body_size is read for each request, so it applies on reload
+	{"server.limits", func(c *config.Config) interface{} {
+		limits := c.Server.Limits
+		limits.MaxBodySize = 0
+		return limits
+	}, func(d, s *config.Config) {
+		maxBodySize := d.Server.Limits.MaxBodySize
+		d.Server.Limits = s.Server.Limits
+		d.Server.Limits.MaxBodySize = maxBodySize
+	}},
+	{"server.allowed_cidrs", func(c *config.Config) interface{} { return c.Server.AllowedCIDRs }, func(d, s *config.Config) { d.Server.AllowedCIDRs = s.Server.AllowedCIDRs }},
+	{"server.denied_cidrs", func(c *config.Config) interface{} { return c.Server.DeniedCIDRs }, func(d, s *config.Config) { d.Server.DeniedCIDRs = s.Server.DeniedCIDRs }},
+	{"server.trust_proxy", func(c *config.Config) interface{} { return c.Server.TrustProxy }, func(d, s *config.Config) { d.Server.TrustProxy = s.Server.TrustProxy }},
+	{"server.headers", func(c *config.Config) interface{} { return c.Server.Headers }, func(d, s *config.Config) { d.Server.Headers = s.Server.Headers }},
+	{"server.sessions", func(c *config.Config) interface{} { return c.Server.Sessions }, func(d, s *config.Config) { d.Server.Sessions = s.Server.Sessions }},
+	{"wiki.root_dir", func(c *config.Config) interface{} { return c.Wiki.RootDir }, func(d, s *config.Config) { d.Wiki.RootDir = s.Wiki.RootDir }},
+	{"wiki.documents_dir", func(c *config.Config) interface{} { return c.Wiki.DocumentsDir }, func(d, s *config.Config) { d.Wiki.DocumentsDir = s.Wiki.DocumentsDir }},
+	{"wiki.versions_dir", func(c *config.Config) interface{} { return c.Wiki.VersionsDir }, func(d, s *config.Config) { d.Wiki.VersionsDir = s.Wiki.VersionsDir }},
+	{"wiki.comments_dir", func(c *config.Config) interface{} { return c.Wiki.CommentsDir }, func(d, s *config.Config) { d.Wiki.CommentsDir = s.Wiki.CommentsDir }},
+	{"wiki.trash_dir", func(c *config.Config) interface{} { return c.Wiki.TrashDir }, func(d, s *config.Config) { d.Wiki.TrashDir = s.Wiki.TrashDir }},
+	{"wiki.search.backend", func(c *config.Config) interface{} { return c.Wiki.Search.Backend }, func(d, s *config.Config) { d.Wiki.Search.Backend = s.Wiki.Search.Backend }},
+}
+
+// ReloadConfigHandler re-reads the config file and applies it without a restart.
+// Settings that are only read at startup keep their running values and are
+// listed in restartRequired when the file changes them. Sessions of users that
+// were removed from the file are ended.
+func ReloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	// Check if user is authenticated and has admin role
+	session := auth.GetSession(r)
+	if session == nil || session.Role != config.RoleAdmin {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized, "")
+		return
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg = config.Current()
+
+	// LoadConfig writes a default config when the file is missing, which must not
+	// replace a running configuration
+	if _, err := os.Stat(config.ConfigFilePath); err != nil {
+		sendJSONError(w, "Failed to read configuration", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	newConfig, err := config.LoadConfig(config.ConfigFilePath)
+	if err != nil {
+		sendJSONError(w, "Failed to load configuration", http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := newConfig.Validate(); err != nil {
+		sendJSONError(w, "Invalid configuration", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	restartRequired := []string{}
+	for _, setting := range restartSettings {
+		if !reflect.DeepEqual(setting.get(cfg), setting.get(newConfig)) {
+			restartRequired = append(restartRequired, setting.key)
+			setting.set(newConfig, cfg)
+		}
+	}
+
+	// Requests already running keep the configuration they started with
+	config.SetCurrent(newConfig)
+	cfg = newConfig
+
+	applyConfig()
+	logging.SetLevel(cfg.Server.LogLevel)
+	invalidateWikiLinkIndex()
+	loggedOut := auth.SyncSessions(cfg.Users)
+
+	message := "Configuration reloaded"
+	if len(restartRequired) > 0 {
+		message = "Configuration reloaded; some settings require a restart"
+	}
+	logging.FromContext(r.Context()).Info("configuration reloaded",
+		"user", session.Username, "sessions_ended", loggedOut, "restart_required", restartRequired)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":         true,
+		"message":         message,
+		"restartRequired": restartRequired,
+		"sessionsEnded":   loggedOut,
+	})
+}
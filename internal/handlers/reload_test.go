@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+// Reloading replaces the running configuration instead of writing to it, so
+// requests holding the old one keep a consistent view
+func TestReloadReplacesConfig(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t)
+	testCfg.Wiki.Title = "Old"
+	cookie := moveTestSession(t, testCfg, "admin", config.RoleAdmin)
+
+	previous := config.ConfigFilePath
+	config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { config.ConfigFilePath = previous })
+	updated, err := config.LoadConfig(config.ConfigFilePath) // Writes the defaults
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated.Wiki.Title = "New"
+	f, err := os.Create(config.ConfigFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(updated, f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/reload", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	ReloadConfigHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	if got := config.Current(); got == testCfg || got.Wiki.Title != "New" {
+		t.Errorf("running config = %p with title %q, want a new one titled New", got, got.Wiki.Title)
+	}
+	if testCfg.Wiki.Title != "Old" {
+		t.Errorf("old config changed to title %q", testCfg.Wiki.Title)
+	}
+}
+
+// The server timeouts need a restart, but the body size limit applies at once
+func TestReloadServerLimits(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t)
+	cookie := moveTestSession(t, testCfg, "admin", config.RoleAdmin)
+
+	previous := config.ConfigFilePath
+	config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { config.ConfigFilePath = previous })
+	running, err := config.LoadConfig(config.ConfigFilePath) // Writes the defaults
+	if err != nil {
+		t.Fatal(err)
+	}
+	running.Wiki.RootDir = testCfg.Wiki.RootDir
+	config.SetCurrent(running)
+
+	updated := *running
+	updated.Server.Limits.ReadTimeout = running.Server.Limits.ReadTimeout + 1
+	updated.Server.Limits.MaxBodySize = running.Server.Limits.MaxBodySize + 1
+	f, err := os.Create(config.ConfigFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(&updated, f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/reload", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	ReloadConfigHandler(rec, req)
+	var resp struct{ RestartRequired []string }
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(resp.RestartRequired, []string{"server.limits"}) {
+		t.Errorf("restart required for %v, want [server.limits]", resp.RestartRequired)
+	}
+	limits := config.Current().Server.Limits
+	if limits.ReadTimeout != running.Server.Limits.ReadTimeout {
+		t.Errorf("read timeout = %d, want the running %d", limits.ReadTimeout, running.Server.Limits.ReadTimeout)
+	}
+	if limits.MaxBodySize != updated.Server.Limits.MaxBodySize {
+		t.Errorf("max body size = %d, want the reloaded %d", limits.MaxBodySize, updated.Server.Limits.MaxBodySize)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"bytes"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/metrics"
+	"wiki-go/internal/rendercache"
+	"wiki-go/internal/utils"
+)
+
+// renderCache holds the HTML of recently viewed documents. applyConfig sizes it
+// from wiki.render_cache_size.
+var renderCache = rendercache.New(config.DefaultRenderCacheSize)
+
+// renderDocument renders the markdown of the document at docPath ("" for the
+// homepage), reusing the cached HTML when the document hasn't changed
+func renderDocument(markdown []byte, docPath string) []byte {
+	// Refresh the wiki link index first: when documents appear or disappear it
+	// purges the cache, since [[WikiLinks]] in other documents may now resolve differently
+	wikiLinkIndex()
+
+	// Statistics shortcodes list other documents and go stale, so those are always rendered
+	if bytes.Contains(markdown, []byte(":::stats")) {
+		return utils.RenderMarkdownWithPath(string(markdown), docPath)
+	}
+
+	if html, ok := renderCache.Get(docPath, markdown); ok {
+		metrics.RenderCacheLookups.Inc("hit")
+		return html
+	}
+	metrics.RenderCacheLookups.Inc("miss")
+
+	html := utils.RenderMarkdownWithPath(string(markdown), docPath)
+	renderCache.Put(docPath, markdown, html)
+	return html
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"strings"
+
+	"wiki-go/internal/config"
+)
+
+// reservedPath returns the entry of wiki.reserved_paths that docPath, a
+// slash-separated path relative to the documents directory, is or lies below.
+// Paths compare case-insensitively, as they do on some file systems.
+func reservedPath(docPath string, cfg *config.Config) (string, bool) {
+	docPath = strings.ToLower(strings.Trim(docPath, "/"))
+	for _, reserved := range cfg.Wiki.ReservedPaths {
+		reserved = strings.Trim(reserved, "/")
+		p := strings.ToLower(reserved)
+		if p != "" && (docPath == p || strings.HasPrefix(docPath, p+"/")) {
+			return reserved, true
+		}
+	}
+	return "", false
+}
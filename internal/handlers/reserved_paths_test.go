@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestReservedPath(t *testing.T) {
+	testCfg := &config.Config{}
+	testCfg.Wiki.ReservedPaths = []string{"api", "/static/", "team/private"}
+
+	tests := []struct {
+		docPath string
+		want    string
+	}{
+		{"api", "api"},
+		{"/API/", "api"},
+		{"api/notes", "api"},
+		{"static/css", "static"},
+		{"team/private/x", "team/private"},
+		{"team", ""},
+		{"apis", ""},
+		{"docs/api", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.docPath, func(t *testing.T) {
+			got, ok := reservedPath(tt.docPath, testCfg)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("reservedPath(%q) = %q, %v; want %q", tt.docPath, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveRejectsReservedPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"move into reserved folder", `{"sourcePath":"a","targetPath":"api"}`},
+		{"rename to reserved slug", `{"sourcePath":"a","targetPath":"","newSlug":"API"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "a")
+			testCfg.Wiki.ReservedPaths = []string{"api"}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MoveDocumentHandler(rec, req, testCfg)
+
+			// Translations aren't loaded in tests, so the message is its key
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "move.reserved_path") {
+				t.Errorf("status = %d, body = %s; want 400 for the reserved path", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateRejectsReservedPaths(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t)
+	testCfg.Wiki.ReservedPaths = []string{"api"}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/document/create", strings.NewReader(`{"title":"Notes","path":"api/notes"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	CreateDocumentHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `\"api\"`) {
+		t.Errorf("status = %d, body = %s; want 400 naming the reserved path", rec.Code, rec.Body.String())
+	}
+}
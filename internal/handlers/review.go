@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/utils"
+)
+
+// reviewDateFormat is the format of the review dates in front matter
+const reviewDateFormat = "2006-01-02"
+
+// ReviewStatus is the review schedule of a document
+type ReviewStatus struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	ReviewBy    string `json:"reviewBy"`
+	ReviewedBy  string `json:"reviewedBy,omitempty"`
+	ReviewedAt  string `json:"reviewedAt,omitempty"`
+	DaysOverdue int    `json:"daysOverdue"` // 0 while the document is not stale
+	Stale       bool   `json:"stale"`
+}
+
+// ReviewReportResponse is the JSON response of the needs review report
+type ReviewReportResponse struct {
+	Success   bool           `json:"success"`
+	Scanned   int            `json:"scanned"`
+	Documents []ReviewStatus `json:"documents"`
+}
+
+// MarkReviewedRequest represents the request to mark a document as reviewed
+type MarkReviewedRequest struct {
+	Path     string `json:"path"`               // URL path of the document, "" for the homepage
+	ReviewBy string `json:"reviewBy,omitempty"` // Next review date; omitted schedules it one review interval from today
+}
+
+// MarkReviewedResponse is the JSON response of a document marked as reviewed
+type MarkReviewedResponse struct {
+	Success  bool   `json:"success"`
+	ReviewBy string `json:"reviewBy"` // The new review date
+}
+
+// reviewToday returns the current date in the wiki's timezone, as midnight UTC
+// so it compares with dates parsed from front matter
+func reviewToday() time.Time {
+	cfg := config.Current()
+	loc, err := time.LoadLocation(cfg.Wiki.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	year, month, day := time.Now().In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// documentReviewStatus reads the review schedule from a document's front matter.
+// ok is false when the document has no valid reviewBy date.
+func documentReviewStatus(content string) (status ReviewStatus, ok bool) {
+	metadata, _, hasFrontmatter := frontmatter.Parse(content)
+	if !hasFrontmatter || metadata.ReviewBy == "" {
+		return status, false
+	}
+	reviewBy, err := time.Parse(reviewDateFormat, metadata.ReviewBy)
+	if err != nil {
+		return status, false
+	}
+
+	status = ReviewStatus{
+		ReviewBy:   metadata.ReviewBy,
+		ReviewedBy: metadata.ReviewedBy,
+		ReviewedAt: metadata.ReviewedAt,
+	}
+	if today := reviewToday(); today.After(reviewBy) {
+		status.Stale = true
+		status.DaysOverdue = int(today.Sub(reviewBy).Hours() / 24)
+	}
+	return status, true
+}
+
+// ReviewReportHandler lists the documents past their reviewBy date, most overdue
+// first. Pass ?all=1 to include every document with a review date.
+func ReviewReportHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		sendJSONError(w, "Failed to scan documents", http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The homepage lives outside the documents directory
+	documents = append([]utils.DocumentEntry{{Title: i18n.Translate("nav.home"), Path: "/"}}, documents...)
+
+	all := r.URL.Query().Get("all") == "1"
+	report := ReviewReportResponse{Success: true, Documents: []ReviewStatus{}}
+	for _, doc := range documents {
+		content, err := os.ReadFile(utils.DocumentFile(documentDir(doc.Path)))
+		if err != nil {
+			continue
+		}
+		report.Scanned++
+		status, ok := documentReviewStatus(string(content))
+		if !ok || (!status.Stale && !all) {
+			continue
+		}
+		status.Path, status.Title = doc.Path, doc.Title
+		report.Documents = append(report.Documents, status)
+	}
+
+	sort.SliceStable(report.Documents, func(i, j int) bool {
+		return report.Documents[i].ReviewBy < report.Documents[j].ReviewBy
+	})
+
+	json.NewEncoder(w).Encode(report)
+}
+
+// MarkReviewedHandler records that the current user reviewed a document and
+// moves its reviewBy date ahead, saving the change as a new revision
+func MarkReviewedHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	var req MarkReviewedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.ReviewBy != "" {
+		if _, err := time.Parse(reviewDateFormat, req.ReviewBy); err != nil {
+			sendJSONError(w, "Invalid review date", http.StatusBadRequest, "Use the YYYY-MM-DD format")
+			return
+		}
+	}
+
+	urlPath := "/" + strings.Trim(req.Path, "/")
+	docPath, relativePath := documentFile(urlPath, "")
+
+	unlock := doclock.Lock(docPath)
+	defer unlock()
+
+	content, err := os.ReadFile(docPath)
+	if err != nil {
+		sendJSONError(w, "Document not found", http.StatusNotFound, "")
+		return
+	}
+
+	today := reviewToday()
+	reviewBy := req.ReviewBy
+	if reviewBy == "" {
+		days := cfg.Wiki.Review.IntervalDays
+		if metadata, _, ok := frontmatter.Parse(string(content)); ok && metadata.ReviewEvery > 0 {
+			days = metadata.ReviewEvery
+		}
+		reviewBy = today.AddDate(0, 0, days).Format(reviewDateFormat)
+	}
+
+	session := auth.GetSession(r)
+	updated, err := frontmatter.SetFields(string(content), map[string]string{
+		"reviewBy":   reviewBy,
+		"reviewedBy": session.Username,
+		"reviewedAt": today.Format(reviewDateFormat),
+	})
+	if err != nil {
+		sendJSONError(w, "Failed to update front matter", http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := writeDocumentRevision(docPath, relativePath, []byte(updated), session.Username, "Marked as reviewed"); err != nil {
+		sendJSONError(w, "Failed to save document", http.StatusInternalServerError, err.Error())
+		return
+	}
+	renderCache.Invalidate(urlPath)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MarkReviewedResponse{Success: true, ReviewBy: reviewBy})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/frontmatter"
+)
+
+func writeTestDocument(t *testing.T, root, doc, content string) {
+	t.Helper()
+	file := filepath.Join(root, "documents", filepath.FromSlash(doc), "document.md")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReviewReport(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "stale", "current", "unscheduled")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "stale", "---\nreviewBy: 2000-01-01\n---\n\n# Stale")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "current", "---\nreviewBy: \"2999-01-01\"\n---\n\n# Current")
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"stale only", "", []string{"/stale"}},
+		{"all scheduled", "?all=1", []string{"/stale", "/current"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ReviewReportHandler(rec, httptest.NewRequest(http.MethodGet, "/api/review"+tt.query, nil))
+
+			var resp ReviewReportResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, doc := range resp.Documents {
+				got = append(got, doc.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("documents = %v, want %v", got, tt.want)
+			}
+			if resp.Documents[0].DaysOverdue <= 0 || !resp.Documents[0].Stale {
+				t.Errorf("stale document = %+v", resp.Documents[0])
+			}
+		})
+	}
+}
+
+func TestMarkReviewed(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		body     string
+		want     string // Expected reviewBy, or empty for days from today
+		days     int
+		status   int
+	}{
+		{
+			name:     "explicit date",
+			document: "---\nlayout: doc\nreviewBy: 2000-01-01\n---\n\n# Doc",
+			body:     `{"path":"doc","reviewBy":"2030-06-01"}`,
+			want:     "2030-06-01",
+			status:   http.StatusOK,
+		},
+		{
+			name:     "document interval",
+			document: "---\nreviewBy: 2000-01-01\nreviewEvery: 7\n---\n\n# Doc",
+			body:     `{"path":"doc"}`,
+			days:     7,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "wiki interval without front matter",
+			document: "# Doc",
+			body:     `{"path":"/doc/"}`,
+			days:     30,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "invalid date",
+			document: "# Doc",
+			body:     `{"path":"doc","reviewBy":"next week"}`,
+			status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "doc")
+			testCfg.Wiki.Review.IntervalDays = 30
+			writeTestDocument(t, testCfg.Wiki.RootDir, "doc", tt.document)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/review/mark", strings.NewReader(tt.body))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			MarkReviewedHandler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			content, err := os.ReadFile(filepath.Join(testCfg.Wiki.RootDir, "documents", "doc", "document.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			metadata, body, ok := frontmatter.Parse(string(content))
+			if !ok || body != "# Doc" {
+				t.Fatalf("document = %q", content)
+			}
+
+			today := reviewToday()
+			want := tt.want
+			if want == "" {
+				want = today.AddDate(0, 0, tt.days).Format(reviewDateFormat)
+			}
+			if metadata.ReviewBy != want || metadata.ReviewedBy != "editor" || metadata.ReviewedAt != today.Format(reviewDateFormat) {
+				t.Errorf("metadata = %+v, want reviewBy %s by editor today", metadata, want)
+			}
+			if strings.Contains(tt.document, "layout") && metadata.Layout != "doc" {
+				t.Errorf("layout lost: %q", content)
+			}
+		})
+	}
+}
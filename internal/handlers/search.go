@@ -2,25 +2,76 @@ package handlers
 
 import (
 	"encoding/json"
+	"html"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/search"
+	"wiki-go/internal/utils"
 )
 
 type SearchRequest struct {
-	Query string `json:"query"`
+	Query      string   `json:"query"`
+	Tags       []string `json:"tags,omitempty"`       // Only documents having every one of these tags
+	PathPrefix string   `json:"pathPrefix,omitempty"` // Only the document at this path and those below it
+	Highlight  bool     `json:"highlight,omitempty"`  // Excerpts are HTML with matches wrapped in <mark>
 }
 
 type SearchResult struct {
 	Title   string `json:"title"`
 	Path    string `json:"path"`
-	Excerpt string `json:"excerpt"`
+	Excerpt string `json:"excerpt"` // Text around the first match; HTML when highlighting
 }
 
+// searchOptions is a parsed search request
+type searchOptions struct {
+	terms      SearchTerms
+	tags       []string
+	pathPrefix string
+	highlight  bool
+	context    int // Characters of excerpt on each side of the first match
+
+	// maxDistance is the most edits a misspelled word may be from a word of a
+	// document; 0 disables fuzzy matching
+	maxDistance int
+	// fuzzy selects the pass over the documents that only match with edits
+	fuzzy bool
+}
+
+// newSearchOptions parses a search request
+func newSearchOptions(req SearchRequest, cfg *config.Config) searchOptions {
+	opts := searchOptions{
+		terms:      parseSearchQuery(req.Query),
+		pathPrefix: strings.Trim(req.PathPrefix, "/"),
+		highlight:  req.Highlight,
+		context:    cfg.Wiki.Search.ContextLength,
+	}
+	if cfg.Wiki.Search.Fuzzy {
+		opts.maxDistance = cfg.Wiki.Search.MaxDistance
+	}
+	for _, tag := range req.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.tags = append(opts.tags, tag)
+		}
+	}
+	return opts
+}
+
+// SearchHandler searches document contents. Results are streamed as a JSON
+// array; with the limit query parameter they are paginated instead, and the
+// X-Next-Cursor response header holds the cursor query parameter for the next
+// page, absent on the last one.
 func SearchHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -33,64 +84,194 @@ func SearchHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
 		return
 	}
 
-	session := auth.GetSession(r)
-	results := performSearch(req.Query, session, cfg)
+	page, err := parseListPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
+	session := auth.GetSession(r)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(results)
+
+	if page.limit > 0 {
+		results := make([]SearchResult, 0, page.limit)
+		lastPath := ""
+		searchDocuments(newSearchOptions(req, cfg), session, cfg, page.after, func(relPath string, result SearchResult) error {
+			if len(results) == page.limit {
+				// There is at least one more result
+				w.Header().Set("X-Next-Cursor", encodeCursor(lastPath))
+				return fs.SkipAll
+			}
+			results = append(results, result)
+			lastPath = relPath
+			return nil
+		})
+		json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	stream := newJSONArrayWriter(w, "[", "]\n")
+	searchDocuments(newSearchOptions(req, cfg), session, cfg, "", func(_ string, result SearchResult) error {
+		return stream.Write(result)
+	})
+	stream.Close()
 }
 
-func performSearch(query string, session *auth.Session, cfg *config.Config) []SearchResult {
-	var results []SearchResult
-	searchTerms := parseSearchQuery(query)
+// fuzzyCursorPrefix starts the cursors of results of the fuzzy pass. Document
+// names are slugs, so no document directory is named "fuzzy:".
+const fuzzyCursorPrefix = "fuzzy:/"
+
+// searchDocuments calls yield with the cursor and result of each matching
+// document, starting after the cursor after: first the documents matching
+// exactly, then, with fuzzy matching enabled, those only matching misspelled
+// words. An error from yield, such as fs.SkipAll, ends the search.
+func searchDocuments(opts searchOptions, session *auth.Session, cfg *config.Config, after string, yield func(cursor string, result SearchResult) error) error {
+	stopped := false
+	stop := func(err error) error {
+		stopped = err != nil
+		return err
+	}
+
+	fuzzyAfter, inFuzzyPass := strings.CutPrefix(after, fuzzyCursorPrefix)
+	if !inFuzzyPass {
+		err := searchPass(opts, session, cfg, after, func(relPath string, result SearchResult) error {
+			return stop(yield(relPath, result))
+		})
+		if err != nil || stopped {
+			return err
+		}
+		fuzzyAfter = ""
+	}
 
+	if opts.maxDistance <= 0 || len(opts.terms.IncludeWords) == 0 {
+		return nil
+	}
+	opts.fuzzy = true
+	return searchPass(opts, session, cfg, fuzzyAfter, func(relPath string, result SearchResult) error {
+		return yield(fuzzyCursorPrefix+relPath, result)
+	})
+}
+
+// searchPass goes through the documents in the order the documents directory
+// is walked, starting after the file after, and calls yield with the path of
+// each matching file relative to the documents directory and its result. An
+// error from yield, such as fs.SkipAll, ends the pass. With a search index
+// only the files it can't rule out are read.
+func searchPass(opts searchOptions, session *auth.Session, cfg *config.Config, after string, yield func(relPath string, result SearchResult) error) error {
 	// Full path to the documents directory
 	docsPath := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
 
-	err := filepath.Walk(docsPath, func(path string, info os.FileInfo, err error) error {
+	maxDistance := 0
+	if opts.fuzzy {
+		maxDistance = opts.maxDistance
+	}
+	if candidates, ok := searchCandidates(opts.terms, maxDistance); ok {
+		sort.Slice(candidates, func(i, j int) bool { return walkOrderLess(candidates[i], candidates[j]) })
+		for _, relPath := range candidates {
+			if after != "" && !walkOrderLess(after, relPath) {
+				continue
+			}
+			if result, ok := searchFile(docsPath, relPath, opts, session, cfg); ok {
+				if err := yield(relPath, result); err != nil {
+					if err == fs.SkipAll {
+						return nil
+					}
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return walkAfter(docsPath, after, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Only process markdown files
-		if !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".md") {
-			// Compute the document's URL path first for access checking
-			cleanPath := path
-			cleanPath = strings.ReplaceAll(cleanPath, "\\", "/")
-			prefix := strings.ReplaceAll(docsPath, "\\", "/") + "/"
-			cleanPath = strings.TrimPrefix(cleanPath, prefix)
-			cleanPath = strings.TrimSuffix(strings.Replace(cleanPath, "document.md", "", 1), ".md")
-			urlPath := "/" + cleanPath
-
-			// Skip documents the user cannot access
-			if !auth.CanAccessDocument(urlPath, session, cfg) {
-				return nil
+		// Skip the directories outside the section being searched
+		if d.IsDir() && opts.pathPrefix != "" {
+			if rel, err := filepath.Rel(docsPath, path); err == nil && rel != "." {
+				rel = filepath.ToSlash(rel)
+				if !isSameOrDescendant(rel, opts.pathPrefix) && !isSameOrDescendant(opts.pathPrefix, rel) {
+					return filepath.SkipDir
+				}
 			}
+		}
 
-			content, err := os.ReadFile(path)
+		// Only process markdown files
+		if !d.IsDir() && strings.HasSuffix(strings.ToLower(path), ".md") {
+			relPath, err := filepath.Rel(docsPath, path)
 			if err != nil {
 				return nil
 			}
-
-			if matches := matchContent(string(content), searchTerms); matches {
-				title := extractTitle(string(content))
-				excerpt := extractExcerpt(string(content), searchTerms)
-
-				results = append(results, SearchResult{
-					Title:   title,
-					Path:    urlPath,
-					Excerpt: excerpt,
-				})
+			relPath = filepath.ToSlash(relPath)
+			if result, ok := searchFile(docsPath, relPath, opts, session, cfg); ok {
+				return yield(relPath, result)
 			}
 		}
 		return nil
 	})
+}
 
+// searchFile matches the markdown file at relPath, relative to the documents
+// directory, against the search. ok is false when the file doesn't match,
+// can't be read or is a document the user cannot access.
+func searchFile(docsPath, relPath string, opts searchOptions, session *auth.Session, cfg *config.Config) (result SearchResult, ok bool) {
+	// Compute the document's URL path first for access checking
+	cleanPath := relPath
+	// Translations are listed under their document, selected with ?lang=
+	lang, isTranslation := utils.TranslationLanguage(path.Base(cleanPath))
+	if isTranslation {
+		cleanPath = strings.TrimSuffix(cleanPath, path.Base(cleanPath))
+	}
+	if utils.IsDocumentIndex(filepath.Join(docsPath, filepath.FromSlash(cleanPath))) {
+		cleanPath = strings.TrimSuffix(cleanPath, path.Base(cleanPath))
+	}
+	cleanPath = strings.TrimSuffix(cleanPath, ".md")
+	urlPath := "/" + cleanPath
+	docPath := strings.Trim(cleanPath, "/")
+
+	if opts.pathPrefix != "" && !isSameOrDescendant(docPath, opts.pathPrefix) {
+		return result, false
+	}
+
+	// Skip documents the user cannot access. Rules are matched against the
+	// path without a trailing slash, as when the document itself is viewed.
+	if !auth.CanAccessDocument("/"+docPath, session, cfg) {
+		return result, false
+	}
+
+	content, err := os.ReadFile(filepath.Join(docsPath, filepath.FromSlash(relPath)))
 	if err != nil {
-		return []SearchResult{}
+		return result, false
+	}
+
+	excerptTerms := opts.terms
+	if opts.fuzzy {
+		// Only documents the exact pass left out
+		if matchContent(string(content), opts.terms) {
+			return result, false
+		}
+		var matched bool
+		if excerptTerms, matched = matchFuzzy(string(content), opts.terms, opts.maxDistance); !matched {
+			return result, false
+		}
+	} else if !matchContent(string(content), opts.terms) {
+		return result, false
+	}
+	if !hasTags(string(content), opts.tags) {
+		return result, false
 	}
 
-	return results
+	resultPath := urlPath
+	if isTranslation {
+		resultPath += "?lang=" + lang
+	}
+	return SearchResult{
+		Title:   extractTitle(string(content)),
+		Path:    resultPath,
+		Excerpt: extractExcerpt(string(content), excerptTerms, opts.context, opts.highlight),
+	}, true
 }
 
 type SearchTerms struct {
@@ -170,6 +351,55 @@ func matchContent(content string, terms SearchTerms) bool {
 	return true
 }
 
+// matchFuzzy reports whether content matches terms when each included word may
+// instead be a word of content a few edits away, up to maxDistance. found are
+// the terms with the words of content that matched in place of misspelled ones.
+func matchFuzzy(content string, terms SearchTerms, maxDistance int) (found SearchTerms, ok bool) {
+	lower := strings.ToLower(content)
+	for _, phrase := range terms.ExactPhrases {
+		if !strings.Contains(lower, phrase) {
+			return found, false
+		}
+	}
+	for _, word := range terms.ExcludeWords {
+		if strings.Contains(lower, word) {
+			return found, false
+		}
+	}
+
+	found.ExactPhrases = terms.ExactPhrases
+	var words []string
+	for _, word := range terms.IncludeWords {
+		if strings.Contains(lower, word) {
+			found.IncludeWords = append(found.IncludeWords, word)
+			continue
+		}
+		if words == nil {
+			words = search.Words(lower)
+		}
+		closest, ok := search.Closest(words, word, search.AllowedDistance(word, maxDistance))
+		if !ok {
+			return found, false
+		}
+		found.IncludeWords = append(found.IncludeWords, closest)
+	}
+	return found, true
+}
+
+// hasTags reports whether the front matter of content has every tag, ignoring case
+func hasTags(content string, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	metadata, _, _ := frontmatter.Parse(content)
+	for _, tag := range tags {
+		if !slices.ContainsFunc(metadata.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+	return true
+}
+
 func extractTitle(content string) string {
 	lines := strings.Split(content, "\n")
 	for _, line := range lines {
@@ -180,51 +410,80 @@ func extractTitle(content string) string {
 	return "Untitled"
 }
 
-func extractExcerpt(content string, terms SearchTerms) string {
-	const excerptLength = 200
-	content = strings.ToLower(content)
+// extractExcerpt returns the text of content, without its front matter, from
+// context characters before the first match of terms to context characters
+// after it, trimmed to whole words. With highlight the excerpt is HTML and the
+// matches in it are wrapped in <mark>.
+func extractExcerpt(content string, terms SearchTerms, context int, highlight bool) string {
+	if _, body, ok := frontmatter.Parse(content); ok {
+		content = body
+	}
+	pattern := searchTermPattern(terms)
 
-	// First try to find a match with exact phrases
-	var matchIndex int
-	if len(terms.ExactPhrases) > 0 {
-		for _, phrase := range terms.ExactPhrases {
-			if idx := strings.Index(content, phrase); idx != -1 {
-				matchIndex = idx
-				break
-			}
-		}
-	} else if len(terms.IncludeWords) > 0 {
-		// Then try with included words
-		for _, word := range terms.IncludeWords {
-			if idx := strings.Index(content, word); idx != -1 {
-				matchIndex = idx
-				break
-			}
+	start, end := 0, 0
+	if pattern != nil {
+		if loc := pattern.FindStringIndex(content); loc != nil {
+			start, end = loc[0], loc[1]
 		}
 	}
 
-	// Calculate excerpt range
-	start := matchIndex - excerptLength/2
-	if start < 0 {
-		start = 0
+	// Widen the match by context characters on either side
+	for n := 0; n < context && start > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(content[:start])
+		start -= size
 	}
-	end := start + excerptLength
-	if end > len(content) {
-		end = len(content)
+	for n := 0; n < context && end < len(content); n++ {
+		_, size := utf8.DecodeRuneInString(content[end:])
+		end += size
 	}
 
 	// Trim to word boundaries
 	excerpt := content[start:end]
+	prefix, suffix := "", ""
 	if start > 0 {
 		if idx := strings.Index(excerpt, " "); idx != -1 {
-			excerpt = "..." + excerpt[idx:]
+			excerpt = excerpt[idx:]
+			prefix = "..."
 		}
 	}
 	if end < len(content) {
 		if idx := strings.LastIndex(excerpt, " "); idx != -1 {
-			excerpt = excerpt[:idx] + "..."
+			excerpt = excerpt[:idx]
+			suffix = "..."
 		}
 	}
 
-	return excerpt
+	if !highlight {
+		return prefix + excerpt + suffix
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	last := 0
+	if pattern != nil {
+		for _, loc := range pattern.FindAllStringIndex(excerpt, -1) {
+			b.WriteString(html.EscapeString(excerpt[last:loc[0]]))
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(excerpt[loc[0]:loc[1]]))
+			b.WriteString("</mark>")
+			last = loc[1]
+		}
+	}
+	b.WriteString(html.EscapeString(excerpt[last:]))
+	b.WriteString(suffix)
+	return b.String()
+}
+
+// searchTermPattern matches any of the phrases and words searched for, ignoring
+// case and preferring the longest; nil when nothing is searched for
+func searchTermPattern(terms SearchTerms) *regexp.Regexp {
+	all := slices.Concat(terms.ExactPhrases, terms.IncludeWords)
+	if len(all) == 0 {
+		return nil
+	}
+	sort.Slice(all, func(i, j int) bool { return len(all[i]) > len(all[j]) })
+	quoted := make([]string, len(all))
+	for i, term := range all {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
 }
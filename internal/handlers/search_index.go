@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/search"
+)
+
+// searchIndexFlushInterval is how often a persistent search index is saved
+const searchIndexFlushInterval = time.Minute
+
+var (
+	// searchBackend is the search index; nil searches read every document
+	searchBackend search.Backend
+	// searchReady is set once the index covers every document. Until then
+	// searches read every document.
+	searchReady atomic.Bool
+	// searchStale is set when documents may have changed without being indexed
+	searchStale atomic.Bool
+	// searchSyncMu serializes bringing the index up to date
+	searchSyncMu sync.Mutex
+)
+
+// initSearchIndex opens the configured search backend and brings it up to
+// date in the background
+func initSearchIndex() {
+	cfg := config.Current()
+	switch cfg.Wiki.Search.Backend {
+	case config.SearchBackendMemory:
+		searchBackend = search.NewMemory()
+	case config.SearchBackendDisk:
+		backend, err := search.OpenDisk(filepath.Join(cfg.Wiki.RootDir, "temp", "search-index.gob"), searchIndexFlushInterval)
+		if err != nil {
+			log.Printf("Warning: Failed to open search index, searching without it: %v", err)
+			return
+		}
+		searchBackend = backend
+	default:
+		return
+	}
+
+	go func() {
+		start := time.Now()
+		syncSearchIndex("")
+		searchReady.Store(true)
+		log.Printf("Search index ready (%s backend) in %s", cfg.Wiki.Search.Backend, time.Since(start).Round(time.Millisecond))
+	}()
+}
+
+// CloseSearchIndex saves a persistent search index; called on shutdown
+func CloseSearchIndex() error {
+	if searchBackend == nil {
+		return nil
+	}
+	return searchBackend.Close()
+}
+
+// syncSearchIndex brings the index up to date with the markdown files below
+// dir, relative to the documents directory ("" for all of them). Files whose
+// modification time is unchanged since they were indexed are not read again.
+func syncSearchIndex(dir string) {
+	updateSearchIndex(dir, false, nil)
+}
+
+// rebuildSearchIndex reads every markdown file into the index again, even
+// those that look unchanged, such as files restored from a backup with their
+// old modification times. progress is called after each file. Searches keep
+// using the index while it is rebuilt.
+func rebuildSearchIndex(progress func()) {
+	updateSearchIndex("", true, progress)
+}
+
+// updateSearchIndex indexes the markdown files below dir that changed, or all
+// of them with force, and drops those that are gone
+func updateSearchIndex(dir string, force bool, progress func()) {
+	cfg := config.Current()
+	if searchBackend == nil {
+		return
+	}
+	searchSyncMu.Lock()
+	defer searchSyncMu.Unlock()
+
+	indexed, err := searchBackend.Indexed()
+	if err != nil {
+		log.Printf("Warning: Failed to read search index: %v", err)
+		return
+	}
+
+	docsPath := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	seen := make(map[string]bool)
+	filepath.WalkDir(filepath.Join(docsPath, filepath.FromSlash(dir)), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(strings.ToLower(path), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(docsPath, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		if progress != nil {
+			defer progress()
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if modTime, ok := indexed[rel]; ok && !force && modTime.Equal(info.ModTime()) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if err := searchBackend.Index(rel, info.ModTime(), string(content)); err != nil {
+			log.Printf("Warning: Failed to index %s: %v", rel, err)
+		}
+		return nil
+	})
+
+	for rel := range indexed {
+		if !seen[rel] && (dir == "" || isSameOrDescendant(rel, dir)) {
+			searchBackend.Delete(rel)
+		}
+	}
+}
+
+// indexSearchFile indexes a markdown file that was just written. Files
+// outside the documents directory, such as the homepage, are not searched.
+func indexSearchFile(file string) {
+	cfg := config.Current()
+	if searchBackend == nil {
+		return
+	}
+	rel, err := filepath.Rel(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir), file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	if err := searchBackend.Index(filepath.ToSlash(rel), info.ModTime(), string(content)); err != nil {
+		log.Printf("Warning: Failed to index %s: %v", rel, err)
+	}
+}
+
+// moveSearchIndex moves the indexed documents at or below oldPath to newPath,
+// both relative to the documents directory
+func moveSearchIndex(oldPath, newPath string) {
+	if searchBackend == nil {
+		return
+	}
+	if err := searchBackend.Delete(oldPath); err != nil {
+		log.Printf("Warning: Failed to remove %s from the search index: %v", oldPath, err)
+	}
+	syncSearchIndex(newPath)
+}
+
+// markSearchIndexStale makes the next search bring the index up to date first
+func markSearchIndexStale() {
+	searchStale.Store(true)
+}
+
+// searchCandidates returns the files, relative to the documents directory,
+// that may match terms, or ok false when there is no usable index. With a
+// maxDistance the included words may be misspelled.
+func searchCandidates(terms SearchTerms, maxDistance int) (paths []string, ok bool) {
+	if searchBackend == nil || !searchReady.Load() {
+		return nil, false
+	}
+	if searchStale.Swap(false) {
+		syncSearchIndex("")
+	}
+	var err error
+	if maxDistance > 0 {
+		paths, err = searchBackend.QueryFuzzy(terms.IncludeWords, maxDistance)
+		if err == nil && len(terms.ExactPhrases) > 0 {
+			var exact []string
+			if exact, err = searchBackend.Query(terms.ExactPhrases); err == nil {
+				paths = slices.DeleteFunc(paths, func(p string) bool { return !slices.Contains(exact, p) })
+			}
+		}
+	} else {
+		paths, err = searchBackend.Query(slices.Concat(terms.ExactPhrases, terms.IncludeWords))
+	}
+	if err != nil {
+		log.Printf("Warning: Search index query failed, reading every document: %v", err)
+		return nil, false
+	}
+	return paths, true
+}
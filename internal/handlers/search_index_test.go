@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/search"
+)
+
+func TestSearchUsesIndex(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides/setup", "guides/testing", "bread")
+	root := testCfg.Wiki.RootDir
+	writeTestDocument(t, root, "guides/setup", "# Setup\n\nInstall the compiler.")
+	writeTestDocument(t, root, "guides/testing", "# Testing\n\nThe compiler runs tests.")
+	writeTestDocument(t, root, "bread", "# Bread\n\nKnead the dough.")
+
+	searchBackend = search.NewMemory()
+	t.Cleanup(func() {
+		searchBackend = nil
+		searchReady.Store(false)
+		searchStale.Store(false)
+	})
+	syncSearchIndex("")
+	searchReady.Store(true)
+
+	query := func(q string) []string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		SearchHandler(rec, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(`{"query":"`+q+`"}`)), testCfg)
+		var results []SearchResult
+		if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+			t.Fatalf("%v (%d)", err, rec.Code)
+		}
+		paths := []string{}
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"word", "compiler", []string{"/guides/setup/", "/guides/testing/"}},
+		{"phrase", `\"knead the\"`, []string{"/bread/"}},
+		{"excluded word", "compiler NOT tests", []string{"/guides/setup/"}},
+		{"no match", "oven", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := query(tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	// Moving a category reindexes its documents under their new paths
+	req := httptest.NewRequest(http.MethodPost, "/api/document/move", strings.NewReader(`{"sourcePath":"guides","targetPath":"bread"}`))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	MoveDocumentHandler(rec, req, testCfg)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if got, want := query("compiler"), []string{"/bread/guides/setup/", "/bread/guides/testing/"}; !slices.Equal(got, want) {
+		t.Errorf("search after move = %v, want %v", got, want)
+	}
+
+	// Documents changed behind the index are found once it is marked stale
+	writeTestDocument(t, root, "bread", "# Bread\n\nBake with the compiler.")
+	markSearchIndexStale()
+	if got, want := query("compiler"), []string{"/bread/", "/bread/guides/setup/", "/bread/guides/testing/"}; !slices.Equal(got, want) {
+		t.Errorf("search after change = %v, want %v", got, want)
+	}
+
+	// Misspelled words are looked up in the index's vocabulary
+	testCfg.Wiki.Search.Fuzzy = true
+	testCfg.Wiki.Search.MaxDistance = 2
+	if got, want := query("compilre"), []string{"/bread/", "/bread/guides/setup/", "/bread/guides/testing/"}; !slices.Equal(got, want) {
+		t.Errorf("fuzzy search = %v, want %v", got, want)
+	}
+}
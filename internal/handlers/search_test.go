@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestSearchFilters(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "guides/setup", "guides/tips", "secret", "team/plans")
+	root := testCfg.Wiki.RootDir
+	writeTestDocument(t, root, "guides/setup", "---\ntags: [go, Tooling]\n---\n\n# Setup\n\nInstall the compiler.")
+	writeTestDocument(t, root, "guides/tips", "---\ntags: [go]\n---\n\n# Tips\n\nThe compiler is fast.")
+	writeTestDocument(t, root, "secret", "# Secret\n\nThe compiler codename.")
+	writeTestDocument(t, root, "team/plans", "# Plans\n\nA new compiler.")
+	testCfg.Wiki.Search.ContextLength = 100
+	testCfg.AccessRules = []config.AccessRule{
+		{Pattern: "/secret", Access: "private"},
+		{Pattern: "/team/*", Access: "private"},
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"documents the user can't read are left out", `{"query":"compiler"}`, []string{"/guides/setup/", "/guides/tips/"}},
+		{"path prefix", `{"query":"compiler","pathPrefix":"/guides/setup"}`, []string{"/guides/setup/"}},
+		{"path prefix is not a string prefix", `{"query":"compiler","pathPrefix":"guide"}`, []string{}},
+		{"tags ignore case", `{"query":"compiler","tags":["tooling","GO"]}`, []string{"/guides/setup/"}},
+		{"unknown tag", `{"query":"compiler","tags":["rust"]}`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			SearchHandler(rec, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(tt.body)), testCfg)
+			var results []SearchResult
+			if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+				t.Fatalf("%v (%d)", err, rec.Code)
+			}
+			paths := []string{}
+			for _, result := range results {
+				paths = append(paths, result.Path)
+			}
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("results = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchFuzzy(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "a-kube", "b-typo", "c-exact", "d-other")
+	root := testCfg.Wiki.RootDir
+	writeTestDocument(t, root, "a-kube", "# Kube\n\nDeploying to Kubernetes clusters.")
+	writeTestDocument(t, root, "b-typo", "# Typo\n\nOur kubernates notes.")
+	writeTestDocument(t, root, "c-exact", "# Exact\n\nThe kubernates spelling again.")
+	writeTestDocument(t, root, "d-other", "# Other\n\nNothing to see.")
+	testCfg.Wiki.Search.ContextLength = 100
+	testCfg.Wiki.Search.Fuzzy = true
+	testCfg.Wiki.Search.MaxDistance = 2
+
+	search := func(query, params string) ([]SearchResult, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		SearchHandler(rec, httptest.NewRequest(http.MethodPost, "/api/search"+params, strings.NewReader(`{"query":"`+query+`","highlight":true}`)), testCfg)
+		var results []SearchResult
+		if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+			t.Fatalf("%v (%d)", err, rec.Code)
+		}
+		return results, rec.Header().Get("X-Next-Cursor")
+	}
+
+	// Exact matches come before fuzzy ones, whose excerpts mark the word found
+	results, _ := search("kubernates", "")
+	want := []string{"/b-typo/", "/c-exact/", "/a-kube/"}
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("results = %v, want %v", paths, want)
+	}
+	if !strings.Contains(results[2].Excerpt, "<mark>Kubernetes</mark>") {
+		t.Errorf("fuzzy excerpt = %q, want the matched word marked", results[2].Excerpt)
+	}
+
+	// Pages continue from the exact pass into the fuzzy pass
+	paths = nil
+	for cursor, pages := "", 0; pages == 0 || cursor != ""; pages++ {
+		params := "?limit=1"
+		if cursor != "" {
+			params += "&cursor=" + cursor
+		}
+		var page []SearchResult
+		page, cursor = search("kubernates", params)
+		for _, result := range page {
+			paths = append(paths, result.Path)
+		}
+		if pages > len(want) {
+			t.Fatal("paging does not end")
+		}
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("paged results = %v, want %v", paths, want)
+	}
+
+	// Short words and words too far off don't match fuzzily
+	testCfg.Wiki.Search.MaxDistance = 1
+	if results, _ := search("kuberxxtes", ""); len(results) != 0 {
+		t.Errorf("results for a word two edits off with max_distance 1 = %+v", results)
+	}
+	testCfg.Wiki.Search.Fuzzy = false
+	if results, _ := search("kubernates", ""); len(results) != 2 {
+		t.Errorf("results with fuzzy matching disabled = %+v, want the 2 exact matches", results)
+	}
+}
+
+func TestExtractExcerpt(t *testing.T) {
+	content := "---\ntags: [compiler]\n---\n\n# Notes\n\nFirst we <b>Build</b> the Compiler & then run the tests again."
+	tests := []struct {
+		name      string
+		query     string
+		context   int
+		highlight bool
+		want      string
+	}{
+		{"plain keeps case and skips front matter", "compiler", 10, false, "... the Compiler & then..."},
+		{"highlight escapes html", "compiler", 10, true, "... the <mark>Compiler</mark> &amp; then..."},
+		{"every term is highlighted", `"the compiler" run`, 15, true, "... &lt;b&gt;Build&lt;/b&gt; <mark>the Compiler</mark> &amp; then <mark>run</mark>..."},
+		{"no match starts at the top", "", 16, false, "# Notes\n\nFirst..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractExcerpt(content, parseSearchQuery(tt.query), tt.context, tt.highlight)
+			if got != tt.want {
+				t.Errorf("excerpt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,12 +4,9 @@ import (
     "encoding/json"
     "net/http"
     "os"
-    "sync"
     "wiki-go/internal/config"
 )
 
-var securityMu sync.Mutex
-
 // SecuritySettings represents the JSON payload for security settings.
 type SecuritySettings struct {
 	PasswordStrength int `yaml:"passwordstrength"`
@@ -20,6 +17,7 @@ type SecuritySettings struct {
         InitialBanSeconds int  `json:"initial_ban_seconds"`
         MaxBanSeconds     int  `json:"max_ban_seconds"`
     } `json:"login_ban"`
+    LoginErrors string `json:"login_errors"` // "generic" or "detailed"; left unchanged when empty
 }
 
 // SecuritySettingsHandler handles GET (read) and POST (update) of security settings.
@@ -35,6 +33,7 @@ func SecuritySettingsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleGetSecurity(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
     w.Header().Set("Content-Type", "application/json")
 
     var resp SecuritySettings
@@ -44,6 +43,7 @@ func handleGetSecurity(w http.ResponseWriter, r *http.Request) {
     resp.LoginBan.WindowSeconds = cfg.Security.LoginBan.WindowSeconds
     resp.LoginBan.InitialBanSeconds = cfg.Security.LoginBan.InitialBanSeconds
     resp.LoginBan.MaxBanSeconds = cfg.Security.LoginBan.MaxBanSeconds
+    resp.LoginErrors = cfg.Security.LoginErrors
 
     json.NewEncoder(w).Encode(resp)
 }
@@ -60,17 +60,27 @@ func handleUpdateSecurity(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "Invalid values", http.StatusBadRequest)
         return
     }
+    if req.LoginErrors != "" && req.LoginErrors != config.LoginErrorsGeneric && req.LoginErrors != config.LoginErrorsDetailed {
+        http.Error(w, "Invalid values", http.StatusBadRequest)
+        return
+    }
 
-    securityMu.Lock()
-    defer securityMu.Unlock()
+    configMu.Lock()
+    defer configMu.Unlock()
 
-    // Update cfg in-memory
+    // Update a copy of the running config in-memory
+    cfg := new(config.Config)
+    *cfg = *config.Current()
 	cfg.Security.PasswordStrength = req.PasswordStrength
     cfg.Security.LoginBan.Enabled = req.LoginBan.Enabled
     cfg.Security.LoginBan.MaxFailures = req.LoginBan.MaxFailures
     cfg.Security.LoginBan.WindowSeconds = req.LoginBan.WindowSeconds
     cfg.Security.LoginBan.InitialBanSeconds = req.LoginBan.InitialBanSeconds
     cfg.Security.LoginBan.MaxBanSeconds = req.LoginBan.MaxBanSeconds
+    if req.LoginErrors != "" {
+        cfg.Security.LoginErrors = req.LoginErrors
+    }
+    config.SetCurrent(cfg)
 
     // Persist to disk
     // Reuse SaveConfig with config.ConfigFilePath
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"wiki-go/internal/auth"
+)
+
+// SessionStatusResponse tells the browser how long its session has left, so it
+// can warn the user or refresh the session before requests start failing
+type SessionStatusResponse struct {
+	Success       bool       `json:"success"`
+	Authenticated bool       `json:"authenticated"`
+	Username      string     `json:"username,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	Remaining     int        `json:"remaining"`   // Seconds until the session expires
+	Refreshable   bool       `json:"refreshable"` // A refresh token may trade for a new session
+}
+
+// SessionStatusHandler reports whether the request has a session and how long
+// it has left. It always answers 200, and polling it doesn't count as activity
+// of the user.
+func SessionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	response := SessionStatusResponse{Success: true, Refreshable: auth.HasRefreshToken(r)}
+	if session := auth.PeekSession(r); session != nil {
+		response.Authenticated = true
+		response.Username = session.Username
+		response.ExpiresAt = &session.ExpiresAt
+		response.Remaining = max(0, int(time.Until(session.ExpiresAt).Seconds()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionStatusHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		session       bool
+		refresh       bool
+		authenticated bool
+	}{
+		{"signed out", false, false, false},
+		{"signed in", true, false, true},
+		{"kept login", true, true, true},
+		{"expired kept login", false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cookie := newMoveTestWiki(t)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/session/status", nil)
+			if tt.session {
+				req.AddCookie(cookie)
+			}
+			if tt.refresh {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "token"})
+			}
+			rec := httptest.NewRecorder()
+			SessionStatusHandler(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body.String())
+			}
+			var status SessionStatusResponse
+			if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+				t.Fatal(err)
+			}
+			if status.Authenticated != tt.authenticated || status.Refreshable != tt.refresh {
+				t.Errorf("authenticated = %v, refreshable = %v", status.Authenticated, status.Refreshable)
+			}
+			if tt.authenticated && (status.Username != "editor" || status.Remaining <= 0 || status.ExpiresAt == nil) {
+				t.Errorf("session status = %+v", status)
+			}
+			if !tt.authenticated && status.Remaining != 0 {
+				t.Errorf("remaining = %d without a session", status.Remaining)
+			}
+		})
+	}
+}
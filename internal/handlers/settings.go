@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
@@ -18,6 +19,7 @@ type WikiSettingsRequest struct {
 	Notice                      string `json:"notice"`
 	Timezone                    string `json:"timezone"`
 	Private                     bool   `json:"private"`
+	AccessMode                  string `json:"access_mode"`
 	DisableComments             bool   `json:"disable_comments"`
 	DisableFileUploadChecking   bool   `json:"disable_file_upload_checking"`
 	EnableLinkEmbedding         bool   `json:"enable_link_embedding"`
@@ -36,6 +38,7 @@ type WikiSettingsResponse struct {
 	Notice                      string   `json:"notice"`
 	Timezone                    string   `json:"timezone"`
 	Private                     bool     `json:"private"`
+	AccessMode                  string   `json:"access_mode"`
 	DisableComments             bool     `json:"disable_comments"`
 	DisableFileUploadChecking   bool     `json:"disable_file_upload_checking"`
 	EnableLinkEmbedding         bool     `json:"enable_link_embedding"`
@@ -44,6 +47,7 @@ type WikiSettingsResponse struct {
 	AlwaysOpenChildrenInSidebar bool     `json:"always_open_children_in_sidebar"`
 	MaxVersions                 int      `json:"max_versions"`
 	MaxUploadSize               int      `json:"max_upload_size"`
+	MaxAttachmentSize           int64    `json:"max_attachment_size"` // Effective attachment limit in bytes, read-only
 	Language                    string   `json:"language"`
 	Languages                   []string `json:"languages"`
 }
@@ -62,6 +66,8 @@ func WikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetWikiSettingsHandler handles requests to get the current wiki settings
 func GetWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Check if user is authenticated and has admin or editor role
 	session := auth.GetSession(r)
 	if session == nil || (session.Role != config.RoleAdmin && session.Role != config.RoleEditor) {
@@ -76,6 +82,7 @@ func GetWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		Notice:                      cfg.Wiki.Notice,
 		Timezone:                    cfg.Wiki.Timezone,
 		Private:                     cfg.Wiki.Private,
+		AccessMode:                  cfg.Wiki.AccessMode,
 		DisableComments:             cfg.Wiki.DisableComments,
 		DisableFileUploadChecking:   cfg.Wiki.DisableFileUploadChecking,
 		EnableLinkEmbedding:         cfg.Wiki.EnableLinkEmbedding,
@@ -84,6 +91,7 @@ func GetWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		AlwaysOpenChildrenInSidebar: cfg.Wiki.AlwaysOpenChildrenInSidebar,
 		MaxVersions:                 cfg.Wiki.MaxVersions,
 		MaxUploadSize:               cfg.Wiki.MaxUploadSize,
+		MaxAttachmentSize:           config.GetMaxAttachmentSizeBytes(cfg),
 		Language:                    cfg.Wiki.Language,
 		Languages:                   i18n.GetAvailableLanguages(),
 	}
@@ -95,6 +103,10 @@ func GetWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateWikiSettingsHandler handles requests to update the wiki settings
 func UpdateWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
+
 	// Check if user is authenticated and has admin role
 	session := auth.GetSession(r)
 	if session == nil || session.Role != config.RoleAdmin {
@@ -129,7 +141,18 @@ func UpdateWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	updatedConfig.Wiki.Owner = req.Owner
 	updatedConfig.Wiki.Notice = req.Notice
 	updatedConfig.Wiki.Timezone = req.Timezone
-	updatedConfig.Wiki.Private = req.Private
+	switch req.AccessMode {
+	case config.AccessModePublic, config.AccessModeReadOnlyPublic, config.AccessModePrivate:
+		updatedConfig.Wiki.AccessMode = req.AccessMode
+	default:
+		// Clients that only send the private flag
+		if req.Private {
+			updatedConfig.Wiki.AccessMode = config.AccessModePrivate
+		} else if cfg.Wiki.AccessMode == config.AccessModePrivate {
+			updatedConfig.Wiki.AccessMode = config.AccessModePublic
+		}
+	}
+	updatedConfig.Wiki.Private = updatedConfig.Wiki.AccessMode == config.AccessModePrivate
 	updatedConfig.Wiki.DisableComments = req.DisableComments
 	updatedConfig.Wiki.DisableFileUploadChecking = req.DisableFileUploadChecking
 	updatedConfig.Wiki.EnableLinkEmbedding = req.EnableLinkEmbedding
@@ -148,7 +171,7 @@ func UpdateWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
 
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
@@ -159,6 +182,11 @@ func UpdateWikiSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// configMu serializes changes to the configuration. Writers hold it from
+// reading config.Current() until they have saved and installed their copy, so
+// that no change overwrites another.
+var configMu sync.Mutex
+
 // saveConfig saves the configuration to a file
 func saveConfig(path string, cfg *config.Config) error {
 	// Create a backup of the current config file
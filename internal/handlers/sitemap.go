@@ -13,6 +13,7 @@ import (
 	"wiki-go/internal/config"
 	"wiki-go/internal/i18n"
 	"wiki-go/internal/resources"
+	"wiki-go/internal/utils"
 )
 
 // XML sitemap types
@@ -207,9 +208,9 @@ func gatherPages(baseURL string, cfg *config.Config, session *auth.Session) ([]S
 			return err
 		}
 
-		// Only include document.md files
-		if !info.IsDir() && filepath.Base(path) == "document.md" {
-			// Get the directory that contains this document.md
+		// Only include the files holding document content
+		if !info.IsDir() && utils.IsDocumentIndex(path) {
+			// Get the directory that contains this document
 			dirPath := filepath.Dir(path)
 
 			// Get path relative to documents directory - this is the URL path
@@ -298,10 +299,15 @@ func getDocumentTitle(filePath string) string {
 	return ""
 }
 
-// getBaseURL constructs the base URL from request and config
+// getBaseURL constructs the base URL from request and config. The configured
+// server.base_url wins, as behind a proxy the request may name another host.
 func getBaseURL(r *http.Request, cfg *config.Config) string {
+	if cfg.Server.BaseURL != "" {
+		return strings.TrimSuffix(cfg.Server.BaseURL, "/")
+	}
+
 	scheme := "http"
-	if cfg.Server.SSL || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+	if config.TLSEnabled(cfg) || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
 		scheme = "https"
 	}
 
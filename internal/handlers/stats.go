@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/utils"
+)
+
+// StatsResponse is the overview of the wiki served to the admin dashboard
+type StatsResponse struct {
+	Success        bool           `json:"success"`
+	Documents      int            `json:"documents"`
+	Categories     int            `json:"categories"` // Documents that have child documents
+	Comments       int            `json:"comments"`
+	Storage        StorageStats   `json:"storage"`
+	UsersByRole    map[string]int `json:"usersByRole"`
+	ActiveSessions int            `json:"activeSessions"`
+}
+
+// StorageStats holds the disk usage in bytes of each part of the wiki
+type StorageStats struct {
+	Documents int64 `json:"documents"` // Documents and their attachments
+	Versions  int64 `json:"versions"`
+	Comments  int64 `json:"comments"`
+	Total     int64 `json:"total"`
+}
+
+// StatsHandler returns document, comment, storage, user and session counts (GET /api/stats)
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	session := auth.GetSession(r)
+	if session == nil || session.Role != config.RoleAdmin {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized, "")
+		return
+	}
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	json.NewEncoder(w).Encode(collectStats(cfg))
+}
+
+// collectStats walks the documents, versions and comments directories of the wiki
+func collectStats(cfg *config.Config) StatsResponse {
+	stats := StatsResponse{
+		Success:        true,
+		UsersByRole:    map[string]int{config.RoleAdmin: 0, config.RoleEditor: 0, config.RoleViewer: 0},
+		ActiveSessions: auth.ActiveSessionCount(),
+	}
+	for _, user := range cfg.Users {
+		stats.UsersByRole[user.Role]++
+	}
+
+	// A category is a document directory with at least one child directory
+	documentsDir := filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	categories := make(map[string]bool)
+	stats.Storage.Documents = walkSize(documentsDir, func(path string, d fs.DirEntry) {
+		switch {
+		case d.IsDir() && path != documentsDir:
+			if parent := filepath.Dir(path); parent != documentsDir {
+				categories[parent] = true
+			}
+		case !d.IsDir() && utils.IsDocumentIndex(path):
+			stats.Documents++
+		}
+	})
+	stats.Categories = len(categories)
+
+	stats.Storage.Versions = walkSize(config.VersionsPath(cfg), nil)
+	stats.Storage.Comments = walkSize(config.CommentsPath(cfg), func(path string, d fs.DirEntry) {
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".md") {
+			stats.Comments++
+		}
+	})
+	stats.Storage.Total = stats.Storage.Documents + stats.Storage.Versions + stats.Storage.Comments
+	return stats
+}
+
+// walkSize returns the total size of the regular files under root, calling
+// visit, if set, for every entry on the way. A missing root has size 0.
+func walkSize(root string, visit func(path string, d fs.DirEntry)) int64 {
+	var size int64
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if visit != nil {
+			visit(path, d)
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/diff"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/ratelimit"
+	"wiki-go/internal/roles"
+	"wiki-go/internal/suggestions"
+)
+
+// suggestionLimiter limits how many suggestions each client IP submits
+var suggestionLimiter *ratelimit.Limiter
+
+// InitSuggestions configures the suggestion rate limit from cfg.Wiki.Suggestions
+func InitSuggestions(cfg *config.Config) {
+	suggestionLimiter = ratelimit.New(cfg.Wiki.Suggestions.MaxPerHour, time.Hour)
+}
+
+func suggestionBox() *suggestions.Box {
+	cfg := config.Current()
+	return suggestions.New(cfg.Wiki.RootDir)
+}
+
+// SuggestionRequest is the body of a new edit suggestion
+type SuggestionRequest struct {
+	Path           string `json:"path"`
+	Content        string `json:"content"` // The whole proposed markdown
+	Message        string `json:"message"` // Why the change is proposed
+	ChallengeToken string `json:"challengeToken,omitempty"`
+}
+
+// AcceptSuggestionRequest is the body of an accepted suggestion
+type AcceptSuggestionRequest struct {
+	ID    string `json:"id"`
+	Force bool   `json:"force"` // Apply even though the document changed since the suggestion was made
+}
+
+// SuggestionSummary describes a pending suggestion in the review list
+type SuggestionSummary struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"`
+	Author  string    `json:"author"`
+	Message string    `json:"message,omitempty"`
+	Created time.Time `json:"created"`
+	Stale   bool      `json:"stale"` // The document changed since the suggestion was made
+}
+
+// SuggestionDetail is a pending suggestion with the changes it makes to the
+// document as it is now
+type SuggestionDetail struct {
+	SuggestionSummary
+	Content string `json:"content"`
+	Diff    string `json:"diff"` // Unified diff from the current document to the suggestion
+}
+
+// SuggestionsHandler takes edit suggestions on POST from anyone who can read
+// the document, and lets editors list them (GET /api/suggestions, optionally
+// ?path=), review one with its diff (GET /api/suggestions/{id}) and reject it
+// (DELETE /api/suggestions/{id})
+func SuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if !cfg.Wiki.Suggestions.Enabled {
+		sendJSONError(w, "Edit suggestions are disabled", http.StatusForbidden, "")
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/suggestions"), "/")
+	if id == "" && r.Method == http.MethodPost {
+		submitSuggestion(w, r)
+		return
+	}
+
+	session := auth.GetSession(r)
+	if session == nil || (session.Role != roles.RoleAdmin && session.Role != roles.RoleEditor) {
+		sendJSONError(w, "Unauthorized. Admin or editor access required.", http.StatusUnauthorized, "")
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		list, err := suggestionBox().List()
+		if err != nil {
+			sendJSONError(w, "Failed to list suggestions", http.StatusInternalServerError, err.Error())
+			return
+		}
+		query := r.URL.Query()
+		summaries := []SuggestionSummary{}
+		for _, s := range list {
+			if query.Has("path") && s.Path != comparePath(query.Get("path")) {
+				continue
+			}
+			if !auth.CanAccessDocument("/"+s.Path, session, cfg) {
+				continue
+			}
+			current, _ := readSuggested(s.Path)
+			summaries = append(summaries, suggestionSummary(&s, current))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"suggestions": summaries,
+		})
+
+	case id != "" && r.Method == http.MethodGet:
+		s, ok := getSuggestion(w, id, session)
+		if !ok {
+			return
+		}
+		current, err := readSuggested(s.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			sendJSONError(w, "Failed to read document", http.StatusInternalServerError, "")
+			return
+		}
+		edits := diff.Lines(diff.SplitLines(string(current)), diff.SplitLines(s.Content))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"suggestion": SuggestionDetail{
+				SuggestionSummary: suggestionSummary(s, current),
+				Content:           s.Content,
+				Diff:              diff.Unified("/"+s.Path, "/"+s.Path+" (suggested)", edits, 3),
+			},
+		})
+
+	case id != "" && r.Method == http.MethodDelete:
+		s, ok := getSuggestion(w, id, session)
+		if !ok {
+			return
+		}
+		if err := suggestionBox().Delete(s.ID); err != nil && !errors.Is(err, suggestions.ErrNotFound) {
+			sendJSONError(w, "Failed to reject suggestion", http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.Printf("Suggestion %s for /%s rejected by %s", s.ID, s.Path, session.Username)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Suggestion rejected",
+		})
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+	}
+}
+
+// submitSuggestion stores a proposed new version of a document for review
+func submitSuggestion(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	session := auth.GetSession(r)
+	if session == nil && !cfg.Wiki.Suggestions.AllowAnonymous {
+		sendJSONError(w, "Authentication required", http.StatusUnauthorized, "")
+		return
+	}
+
+	var req SuggestionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.GetMaxUploadSizeBytes(cfg))).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendJSONError(w, "Suggestion too large", http.StatusRequestEntityTooLarge, "Maximum size is "+config.GetMaxUploadSizeFormatted(cfg))
+			return
+		}
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if err := checkDocumentSize([]byte(req.Content)); err != nil {
+		sendJSONError(w, "Suggestion too large", http.StatusRequestEntityTooLarge, "Maximum size is "+maxDocumentSizeText())
+		return
+	}
+	docPath := comparePath(req.Path)
+	if !auth.CanAccessDocument("/"+docPath, session, cfg) {
+		sendJSONError(w, "Access denied", http.StatusForbidden, "")
+		return
+	}
+
+	current, err := readSuggested(docPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			sendJSONError(w, "Document not found", http.StatusNotFound, "")
+			return
+		}
+		sendJSONError(w, "Failed to read document", http.StatusInternalServerError, "")
+		return
+	}
+	if req.Content == string(current) {
+		sendJSONError(w, "The suggestion does not change the document", http.StatusBadRequest, "")
+		return
+	}
+
+	// Readers must pass the anti-spam challenge of comments when one is configured
+	if challengeRequired(r) {
+		if err := commentChallenge.Verify(r.Context(), req.ChallengeToken, clientIP(r)); err != nil {
+			sendJSONError(w, "Challenge verification failed", http.StatusForbidden, err.Error())
+			return
+		}
+	}
+	if !auth.RequireRole(r, roles.RoleEditor) {
+		if allowed, retryAfter := suggestionLimiter.Allow(clientIP(r)); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			sendJSONError(w, "Too many suggestions; please wait before suggesting again", http.StatusTooManyRequests, "")
+			return
+		}
+	}
+
+	s := &suggestions.Suggestion{
+		Path:    docPath,
+		Message: strings.TrimSpace(req.Message),
+		Content: req.Content,
+		Base:    documentETag(current),
+	}
+	if session != nil {
+		s.Author = session.Username
+	}
+	if err := suggestionBox().Add(s); err != nil {
+		sendJSONError(w, "Failed to save suggestion", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Thank you! Your suggestion will be reviewed by an editor.",
+		"id":      s.ID,
+	})
+}
+
+// AcceptSuggestionHandler applies a suggestion as a new revision of its
+// document, keeping the previous content as a version. A suggestion made
+// against content that has changed since is only applied with force, as it
+// would undo those changes.
+func AcceptSuggestionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+	session := auth.GetSession(r)
+	if session == nil || (session.Role != roles.RoleAdmin && session.Role != roles.RoleEditor) {
+		sendJSONError(w, "Unauthorized. Admin or editor access required.", http.StatusUnauthorized, "")
+		return
+	}
+
+	var req AcceptSuggestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	s, ok := getSuggestion(w, req.ID, session)
+	if !ok {
+		return
+	}
+
+	file, relativePath := documentFile(s.Path, "")
+	unlock := doclock.Lock(file)
+	defer unlock()
+
+	// Another editor may have handled the suggestion while this one waited
+	if _, err := suggestionBox().Get(s.ID); err != nil {
+		sendJSONError(w, "Suggestion not found", http.StatusNotFound, "")
+		return
+	}
+	current, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			sendJSONError(w, "Document not found", http.StatusNotFound, "/"+s.Path)
+			return
+		}
+		sendJSONError(w, "Failed to read document", http.StatusInternalServerError, "")
+		return
+	}
+	if documentETag(current) != s.Base && !req.Force {
+		sendJSONError(w, "Document was changed", http.StatusConflict, "The document changed since the suggestion was made; review the diff again and accept with force")
+		return
+	}
+
+	// The limit may have been lowered since the suggestion was made
+	if err := checkDocumentSize([]byte(s.Content)); err != nil {
+		sendJSONError(w, "Document too large", http.StatusRequestEntityTooLarge, "Maximum size is "+maxDocumentSizeText())
+		return
+	}
+
+	message := "Accepted suggestion"
+	if s.Author != "" {
+		message += " by " + s.Author
+	}
+	if s.Message != "" {
+		message += ": " + s.Message
+	}
+	if err := writeDocumentRevision(file, relativePath, []byte(s.Content), session.Username, message); err != nil {
+		log.Printf("Error saving document %s: %v", file, err)
+		sendJSONError(w, "Failed to save document", http.StatusInternalServerError, "")
+		return
+	}
+	invalidateWikiLinkIndex()
+	renderCache.Invalidate(s.Path)
+	if err := suggestionBox().Delete(s.ID); err != nil {
+		log.Printf("Error removing accepted suggestion %s: %v", s.ID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Suggestion accepted",
+	})
+}
+
+// getSuggestion returns suggestion id if the session may review it, and
+// otherwise writes the error response
+func getSuggestion(w http.ResponseWriter, id string, session *auth.Session) (*suggestions.Suggestion, bool) {
+	cfg := config.Current()
+	s, err := suggestionBox().Get(id)
+	if err != nil {
+		if errors.Is(err, suggestions.ErrNotFound) {
+			sendJSONError(w, "Suggestion not found", http.StatusNotFound, "")
+			return nil, false
+		}
+		sendJSONError(w, "Failed to read suggestion", http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	if !auth.CanAccessDocument("/"+s.Path, session, cfg) {
+		sendJSONError(w, "Access denied", http.StatusForbidden, "")
+		return nil, false
+	}
+	return s, true
+}
+
+// readSuggested returns the current markdown of the document at docPath
+func readSuggested(docPath string) ([]byte, error) {
+	file, _ := documentFile(docPath, "")
+	return os.ReadFile(file)
+}
+
+// suggestionSummary describes s for the review list; current is the
+// document's content now
+func suggestionSummary(s *suggestions.Suggestion, current []byte) SuggestionSummary {
+	author := s.Author
+	if author == "" {
+		author = "anonymous"
+	}
+	return SuggestionSummary{
+		ID:      s.ID,
+		Path:    "/" + s.Path,
+		Author:  author,
+		Message: s.Message,
+		Created: s.Created,
+		Stale:   documentETag(current) != s.Base,
+	}
+}
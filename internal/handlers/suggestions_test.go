@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmitSuggestion(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		anonymous bool // Allow anonymous suggestions
+		signedIn  bool
+		wantCode  int
+	}{
+		{"signed in", `{"path":"guide","content":"# Guide\n\nFixed.\n"}`, false, true, http.StatusCreated},
+		{"anonymous allowed", `{"path":"/guide/","content":"# Guide\n\nFixed.\n"}`, true, false, http.StatusCreated},
+		{"anonymous not allowed", `{"path":"guide","content":"# Guide\n\nFixed.\n"}`, false, false, http.StatusUnauthorized},
+		{"unchanged", `{"path":"guide","content":"# Guide\n\nTypo.\n"}`, false, true, http.StatusBadRequest},
+		{"missing document", `{"path":"nope","content":"x"}`, false, true, http.StatusNotFound},
+		{"invalid body", `{"path":`, false, true, http.StatusBadRequest},
+		{"too large", `{"path":"guide","content":"` + strings.Repeat("x", 2048) + `"}`, false, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "guide")
+			writeTestDocument(t, testCfg.Wiki.RootDir, "guide", "# Guide\n\nTypo.\n")
+			testCfg.Wiki.Suggestions.Enabled = true
+			testCfg.Wiki.Suggestions.AllowAnonymous = tt.anonymous
+			testCfg.Wiki.MaxDocumentSize = 1 // KB
+
+			req := httptest.NewRequest(http.MethodPost, "/api/suggestions", strings.NewReader(tt.body))
+			if tt.signedIn {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			SuggestionsHandler(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			list, err := suggestionBox().List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored := len(list) == 1; stored != (tt.wantCode == http.StatusCreated) {
+				t.Fatalf("%d suggestions stored", len(list))
+			}
+			if stored := len(list) == 1; stored && (list[0].Path != "guide" || (list[0].Author != "") != tt.signedIn) {
+				t.Errorf("stored %+v", list[0])
+			}
+		})
+	}
+}
+
+func TestReviewSuggestion(t *testing.T) {
+	tests := []struct {
+		name        string
+		editFirst   bool // The document changes after the suggestion
+		action      string
+		wantCode    int
+		wantContent string
+	}{
+		{"accept", false, `{"id":"%s"}`, http.StatusOK, "# Guide\n\nFixed.\n"},
+		{"accept stale", true, `{"id":"%s"}`, http.StatusConflict, "# Guide\n\nEdited.\n"},
+		{"accept stale with force", true, `{"id":"%s","force":true}`, http.StatusOK, "# Guide\n\nFixed.\n"},
+		{"reject", false, "", http.StatusOK, "# Guide\n\nTypo.\n"},
+		{"accept unknown", false, `{"id":"nope"}`, http.StatusNotFound, "# Guide\n\nTypo.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "guide")
+			root := testCfg.Wiki.RootDir
+			writeTestDocument(t, root, "guide", "# Guide\n\nTypo.\n")
+			testCfg.Wiki.Suggestions.Enabled = true
+			testCfg.Wiki.MaxVersions = 5
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/suggestions",
+				strings.NewReader(`{"path":"guide","content":"# Guide\n\nFixed.\n","message":"typo"}`))
+			req.AddCookie(cookie)
+			SuggestionsHandler(rec, req)
+			var created struct{ ID string }
+			json.NewDecoder(rec.Body).Decode(&created)
+			if created.ID == "" {
+				t.Fatalf("no suggestion created: %d", rec.Code)
+			}
+			if tt.editFirst {
+				writeTestDocument(t, root, "guide", "# Guide\n\nEdited.\n")
+			}
+
+			// The review shows what accepting changes now
+			rec = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodGet, "/api/suggestions/"+created.ID, nil)
+			req.AddCookie(cookie)
+			SuggestionsHandler(rec, req)
+			var detail suggestionResponse
+			if err := json.NewDecoder(rec.Body).Decode(&detail); err != nil {
+				t.Fatal(err)
+			}
+			if detail.Suggestion.Stale != tt.editFirst || !strings.Contains(detail.Suggestion.Diff, "+Fixed.") {
+				t.Errorf("review = %+v", detail.Suggestion)
+			}
+
+			rec = httptest.NewRecorder()
+			if tt.action == "" {
+				req = httptest.NewRequest(http.MethodDelete, "/api/suggestions/"+created.ID, nil)
+				req.AddCookie(cookie)
+				SuggestionsHandler(rec, req)
+			} else {
+				body := strings.Replace(tt.action, "%s", created.ID, 1)
+				req = httptest.NewRequest(http.MethodPost, "/api/suggestions/accept", strings.NewReader(body))
+				req.AddCookie(cookie)
+				AcceptSuggestionHandler(rec, req)
+			}
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+
+			content, err := os.ReadFile(filepath.Join(root, "documents", "guide", "document.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("document = %q, want %q", content, tt.wantContent)
+			}
+			list, _ := suggestionBox().List()
+			if pending := len(list) == 1; pending != (tt.wantCode != http.StatusOK) {
+				t.Errorf("%d suggestions pending", len(list))
+			}
+			versions, _ := os.ReadDir(filepath.Join(root, "versions", "documents", "guide"))
+			if accepted := tt.action != "" && tt.wantCode == http.StatusOK; accepted != (len(versions) > 0) {
+				t.Errorf("%d versions after accepting = %t", len(versions), accepted)
+			}
+		})
+	}
+}
+
+// A suggestion over a size limit lowered after it was made is not accepted
+func TestAcceptSuggestionOverSizeLimit(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guide")
+	writeTestDocument(t, testCfg.Wiki.RootDir, "guide", "# Guide\n\nTypo.\n")
+	testCfg.Wiki.Suggestions.Enabled = true
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/suggestions",
+		strings.NewReader(`{"path":"guide","content":"`+strings.Repeat("x", 2048)+`"}`))
+	req.AddCookie(cookie)
+	SuggestionsHandler(rec, req)
+	var created struct{ ID string }
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.ID == "" {
+		t.Fatalf("no suggestion created: %d", rec.Code)
+	}
+
+	testCfg.Wiki.MaxDocumentSize = 1 // KB
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/suggestions/accept", strings.NewReader(`{"id":"`+created.ID+`"}`))
+	req.AddCookie(cookie)
+	AcceptSuggestionHandler(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	content, err := os.ReadFile(filepath.Join(testCfg.Wiki.RootDir, "documents", "guide", "document.md"))
+	if err != nil || string(content) != "# Guide\n\nTypo.\n" {
+		t.Errorf("document = %q, %v", content, err)
+	}
+}
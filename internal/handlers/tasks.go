@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/utils"
+)
+
+// TaskToggleRequest represents the request to check or uncheck a task list item
+type TaskToggleRequest struct {
+	Path    string `json:"path"`              // URL path of the document, "" for the homepage
+	Index   int    `json:"index"`             // Zero-based index of the task item in the document
+	Checked *bool  `json:"checked,omitempty"` // Desired state; omitted flips the current state
+	Lang    string `json:"lang,omitempty"`    // Translation variant, "" for document.md
+}
+
+// taskItemPattern matches a list item starting with a task checkbox, optionally
+// inside a blockquote. The checkbox is the second group.
+var taskItemPattern = regexp.MustCompile(`^(\s*(?:>\s*)*(?:[-*+]|\d{1,9}[.)])\s+)\[([ xX])\](\s|$)`)
+
+var errTaskNotFound = errors.New("task item not found")
+
+// toggleTaskItem sets the checkbox of the index-th task item in markdown, counting
+// in document order and skipping front matter and fenced code blocks the way they
+// are skipped when rendering. A nil checked flips the current state.
+func toggleTaskItem(markdown string, index int, checked *bool) (string, bool, error) {
+	lines := strings.Split(markdown, "\n")
+
+	start := 0
+	if strings.TrimRight(lines[0], "\r") == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimRight(lines[i], "\r") == "---" {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	fence := ""
+	count := 0
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			continue
+		}
+
+		m := taskItemPattern.FindStringSubmatchIndex(lines[i])
+		if m == nil {
+			continue
+		}
+		if count < index {
+			count++
+			continue
+		}
+
+		state := lines[i][m[4]] != ' '
+		if checked != nil {
+			state = *checked
+		} else {
+			state = !state
+		}
+		mark := " "
+		if state {
+			mark = "x"
+		}
+		lines[i] = lines[i][:m[4]] + mark + lines[i][m[5]:]
+		return strings.Join(lines, "\n"), state, nil
+	}
+	return markdown, false, errTaskNotFound
+}
+
+// TaskToggleHandler checks or unchecks a task list item in a document's source
+// and saves the change as a new revision
+func TaskToggleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	var req TaskToggleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Index < 0 {
+		sendJSONError(w, "Invalid task index", http.StatusBadRequest, "")
+		return
+	}
+
+	lang := ""
+	if req.Lang != "" {
+		var ok bool
+		if lang, ok = utils.NormalizeDocumentLanguage(req.Lang); !ok || lang != req.Lang {
+			sendJSONError(w, "Invalid language", http.StatusBadRequest, "")
+			return
+		}
+	}
+
+	docPath, relativePath := documentFile("/"+strings.Trim(req.Path, "/"), lang)
+
+	// The document is read and written back under its edit lock so that
+	// concurrent toggles and saves don't overwrite each other
+	unlock := doclock.Lock(docPath)
+	defer unlock()
+
+	content, err := os.ReadFile(docPath)
+	if err != nil {
+		sendJSONError(w, "Document not found", http.StatusNotFound, "")
+		return
+	}
+
+	updated, checked, err := toggleTaskItem(string(content), req.Index, req.Checked)
+	if err != nil {
+		sendJSONError(w, "Task item not found", http.StatusNotFound, "")
+		return
+	}
+
+	if updated != string(content) {
+		session := auth.GetSession(r)
+		message := "Toggled task item"
+		if err := writeDocumentRevision(docPath, relativePath, []byte(updated), session.Username, message); err != nil {
+			sendJSONError(w, "Failed to save document", http.StatusInternalServerError, err.Error())
+			return
+		}
+		renderCache.Invalidate(req.Path)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"checked": checked,
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import "testing"
+
+func TestToggleTaskItem(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		input   string
+		index   int
+		checked *bool
+		want    string
+		state   bool
+		wantErr bool
+	}{
+		{
+			name:  "flip first item",
+			input: "- [ ] one\n- [x] two\n",
+			index: 0,
+			want:  "- [x] one\n- [x] two\n",
+			state: true,
+		},
+		{
+			name:  "flip checked item",
+			input: "- [ ] one\n- [X] two\n",
+			index: 1,
+			want:  "- [ ] one\n- [ ] two\n",
+			state: false,
+		},
+		{
+			name:    "explicit state is idempotent",
+			input:   "- [x] done\n",
+			index:   0,
+			checked: &yes,
+			want:    "- [x] done\n",
+			state:   true,
+		},
+		{
+			name:    "explicit uncheck",
+			input:   "* [x] done\n",
+			index:   0,
+			checked: &no,
+			want:    "* [ ] done\n",
+			state:   false,
+		},
+		{
+			name:  "skips fenced code",
+			input: "```\n- [ ] code\n```\n~~~~\n- [ ] more\n~~~~\n1. [ ] real\n",
+			index: 0,
+			want:  "```\n- [ ] code\n```\n~~~~\n- [ ] more\n~~~~\n1. [x] real\n",
+			state: true,
+		},
+		{
+			name:  "skips front matter",
+			input: "---\ntitle: x\n---\n  - [ ] nested\r\n> - [ ] quoted\r\n",
+			index: 1,
+			want:  "---\ntitle: x\n---\n  - [ ] nested\r\n> - [x] quoted\r\n",
+			state: true,
+		},
+		{
+			name:  "ignores non-task brackets",
+			input: "- [link](x)\n- [ ]no space\n- [ ] task\n",
+			index: 0,
+			want:  "- [link](x)\n- [ ]no space\n- [x] task\n",
+			state: true,
+		},
+		{
+			name:    "index out of range",
+			input:   "- [ ] one\n",
+			index:   1,
+			want:    "- [ ] one\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, state, err := toggleTaskItem(tt.input, tt.index, tt.checked)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("toggleTaskItem() = %q, want %q", got, tt.want)
+			}
+			if !tt.wantErr && state != tt.state {
+				t.Errorf("state = %v, want %v", state, tt.state)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -12,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"wiki-go/internal/csp"
 	"wiki-go/internal/i18n"
 	"wiki-go/internal/resources"
 	"wiki-go/internal/types"
@@ -20,26 +23,107 @@ import (
 )
 
 // renderTemplate renders the base template with the given data
-func renderTemplate(w http.ResponseWriter, data *types.PageData) {
-	// Get the template from cache or load it
-	tmpl, err := getTemplate()
+func renderTemplate(w http.ResponseWriter, r *http.Request, data *types.PageData) {
+	buf, err := executeTemplate(r, data)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Execute template into buffer
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	// Write the rendered HTML to the response
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// renderDocumentTemplate renders a document view like renderTemplate, adding an
+// ETag and Last-Modified and answering conditional requests with 304 Not Modified
+// when the page is unchanged. The ETag hashes the rendered page, so edits, moves,
+// new comments and anything else that changes the HTML also change it.
+func renderDocumentTemplate(w http.ResponseWriter, r *http.Request, data *types.PageData) {
+	buf, err := executeTemplate(r, data)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Write the rendered HTML to the response
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// The CSP nonce differs on every request and is left out of the hash
+	hashed := buf.Bytes()
+	if data.CSPNonce != "" {
+		hashed = bytes.ReplaceAll(hashed, []byte(data.CSPNonce), nil)
+	}
+	sum := sha256.Sum256(hashed)
+	// Weak, since compression changes the bytes but not the page
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	// Pages differ per user, so only the browser may keep them and must revalidate
+	h.Set("Cache-Control", "private, no-cache")
+	h.Del("Pragma")
+	h.Del("Expires")
+	h.Set("ETag", etag)
+	if !data.LastModified.IsZero() {
+		h.Set("Last-Modified", data.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, data.LastModified) {
+		// The cached page carries the nonce it was sent with; a new policy would block its scripts
+		h.Del("Content-Security-Policy")
+		h.Del("Content-Security-Policy-Report-Only")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", "text/html; charset=utf-8")
 	buf.WriteTo(w)
 }
 
+// executeTemplate renders the base template with the given data into a buffer
+func executeTemplate(r *http.Request, data *types.PageData) (*bytes.Buffer, error) {
+	data.CSPNonce = csp.Nonce(r)
+
+	// Get the template from cache or load it
+	tmpl, err := getTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute template into buffer
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// notModified reports whether the client's cached copy, identified by the
+// If-None-Match or If-Modified-Since request headers, is still current.
+// If-Modified-Since is only consulted when If-None-Match is absent.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchesETag(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// matchesETag reports whether the If-Match or If-None-Match header value list,
+// which may be "*", names etag. Weak and strong tags compare alike.
+func matchesETag(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Cache for the parsed template
 var templateCache *template.Template
 var templateOnce sync.Once
@@ -57,6 +141,7 @@ func getTemplate() (*template.Template, error) {
 			"getVersion": func() string {
 				return version.Version
 			},
+			"timeAgo": timeAgo,
 			"hasFavicon": func(rootDir string, extension string) bool {
 				// Check if a specific favicon exists
 				path := filepath.Join(rootDir, "static", "favicon."+extension)
@@ -136,4 +221,29 @@ func getTemplate() (*template.Template, error) {
 	})
 
 	return templateCache, templateErr
-}
\ No newline at end of file
+}
+
+// timeAgo describes how long ago t was, e.g. "3 days ago", in the wiki language
+func timeAgo(t time.Time) string {
+	d := time.Since(t)
+	units := []struct {
+		size      time.Duration
+		one, many string
+	}{
+		{365 * 24 * time.Hour, "time.year_ago", "time.years_ago"},
+		{30 * 24 * time.Hour, "time.month_ago", "time.months_ago"},
+		{24 * time.Hour, "time.day_ago", "time.days_ago"},
+		{time.Hour, "time.hour_ago", "time.hours_ago"},
+		{time.Minute, "time.minute_ago", "time.minutes_ago"},
+	}
+	for _, u := range units {
+		n := int(d / u.size)
+		switch {
+		case n == 1:
+			return i18n.T("", u.one)
+		case n > 1:
+			return i18n.T("", u.many, n)
+		}
+	}
+	return i18n.T("", "time.just_now")
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/frontmatter"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/types"
+	"wiki-go/internal/utils"
+)
+
+// defaultDocumentLanguage returns the language of the document.md in dir:
+// its front-matter lang, or the wiki language
+func defaultDocumentLanguage(dir string) string {
+	cfg := config.Current()
+	if content, err := os.ReadFile(utils.DocumentFile(dir)); err == nil {
+		if metadata, _, ok := frontmatter.Parse(string(content)); ok {
+			if lang, ok := utils.NormalizeDocumentLanguage(metadata.Lang); ok {
+				return lang
+			}
+		}
+	}
+	if lang, ok := utils.NormalizeDocumentLanguage(cfg.Wiki.Language); ok {
+		return lang
+	}
+	return "en"
+}
+
+// selectTranslation picks the language variant of the document in dir to show.
+// An explicit ?lang= query parameter takes precedence over Accept-Language; when
+// no translation matches, the default document.md is used. It returns the variant
+// ("" for document.md), the language of the displayed content and the switcher
+// entries, which are empty when the document has no translations.
+func selectTranslation(w http.ResponseWriter, r *http.Request, dir, urlPath string) (string, string, []types.Translation) {
+	defaultLang := defaultDocumentLanguage(dir)
+	variants := slices.DeleteFunc(utils.DocumentTranslations(dir), func(lang string) bool {
+		return lang == defaultLang
+	})
+	if len(variants) == 0 {
+		return "", defaultLang, nil
+	}
+
+	// The response differs by Accept-Language once translations exist
+	w.Header().Add("Vary", "Accept-Language")
+
+	langs := append([]string{defaultLang}, variants...)
+	tags := make([]language.Tag, len(langs))
+	for i, lang := range langs {
+		tags[i] = language.Make(lang)
+	}
+
+	var prefs []language.Tag
+	if q := r.URL.Query().Get("lang"); q != "" {
+		if tag, err := language.Parse(q); err == nil {
+			prefs = []language.Tag{tag}
+		}
+	} else if accepted, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil {
+		prefs = accepted
+	}
+
+	// The matcher falls back to its first tag, the default language, if nothing matches
+	selected := 0
+	if len(prefs) > 0 {
+		_, index, confidence := language.NewMatcher(tags).Match(prefs...)
+		if confidence != language.No {
+			selected = index
+		}
+	}
+
+	uiLangs := i18n.GetAvailableLanguages()
+	translations := make([]types.Translation, len(langs))
+	for i, lang := range langs {
+		name := strings.ToUpper(lang)
+		if slices.Contains(uiLangs, lang) {
+			name = i18n.Translate("language.self_name", lang)
+		}
+		translations[i] = types.Translation{
+			Lang:      lang,
+			Name:      name,
+			URL:       urlPath + "?lang=" + url.QueryEscape(lang),
+			Active:    i == selected,
+			IsDefault: i == 0,
+		}
+	}
+
+	if selected == 0 {
+		return "", defaultLang, translations
+	}
+	return langs[selected], langs[selected], translations
+}
+
+// translationParam returns the validated ?lang= variant of an editor API request.
+// ok is false if the parameter is present but not a valid language tag.
+func translationParam(r *http.Request) (lang string, ok bool) {
+	q := r.URL.Query().Get("lang")
+	if q == "" {
+		return "", true
+	}
+	lang, ok = utils.NormalizeDocumentLanguage(q)
+	if !ok || lang != q {
+		return "", false
+	}
+	return lang, true
+}
+
+// translationPath returns the file holding the lang variant of the document in
+// dir. The document's default language maps to document.md and an empty lang.
+func translationPath(dir, lang string) (string, string) {
+	if lang != "" && lang == defaultDocumentLanguage(dir) {
+		lang = ""
+	}
+	return utils.TranslationFile(dir, lang), lang
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/trash"
+	"wiki-go/internal/utils"
+)
+
+// trashPurgeInterval is how often expired trash entries are purged
+const trashPurgeInterval = time.Hour
+
+var trashPurgeOnce sync.Once
+
+// trashBin returns the trash of the running wiki
+func trashBin() *trash.Bin {
+	cfg := config.Current()
+	versions, comments := documentDataDirs(cfg, "")
+	return trash.New(trash.Dirs{
+		Trash:     config.TrashPath(cfg),
+		Documents: filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir),
+		Versions:  versions,
+		Comments:  comments,
+	})
+}
+
+// initTrash starts purging trash entries older than wiki.trash.retention_days
+func initTrash() {
+	trashPurgeOnce.Do(func() {
+		go func() {
+			for {
+				purgeTrash()
+				time.Sleep(trashPurgeInterval)
+			}
+		}()
+	})
+}
+
+// purgeTrash removes entries past the retention period, read on every run so
+// that a config reload applies to the next one
+func purgeTrash() {
+	cfg := config.Current()
+	days := cfg.Wiki.Trash.RetentionDays
+	if days <= 0 {
+		return
+	}
+	purged, err := trashBin().Purge(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Warning: Failed to purge trash: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("Purged %d document(s) from the trash", purged)
+	}
+}
+
+// moveToTrash moves the document or category at docPath, relative to the
+// documents directory, to the trash
+func moveToTrash(docPath, user string) (*trash.Entry, error) {
+	cfg := config.Current()
+	docFile := utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, docPath))
+	return trashBin().Move(docPath, extractTitleFromMarkdown(docFile), user)
+}
+
+// TrashHandler lists the trash (GET /api/trash) for editors and removes an
+// entry for good (DELETE /api/trash/{id}) for admins
+func TrashHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	session := auth.GetSession(r)
+	if session == nil || (session.Role != config.RoleAdmin && session.Role != config.RoleEditor) {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized, "")
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/trash"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		entries, err := trashBin().List()
+		if err != nil {
+			sendJSONError(w, "Failed to list trash", http.StatusInternalServerError, err.Error())
+			return
+		}
+		// Documents keep their access rules in the trash
+		entries = slices.DeleteFunc(entries, func(e trash.Entry) bool {
+			return !auth.CanAccessDocument("/"+e.Path, session, cfg)
+		})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":       true,
+			"entries":       entries,
+			"retentionDays": cfg.Wiki.Trash.RetentionDays,
+		})
+
+	case id != "" && r.Method == http.MethodDelete:
+		if session.Role != config.RoleAdmin {
+			sendJSONError(w, "Admin access required", http.StatusForbidden, "")
+			return
+		}
+		if err := trashBin().Delete(id); err != nil {
+			if errors.Is(err, trash.ErrNotFound) {
+				sendJSONError(w, "Trash entry not found", http.StatusNotFound, "")
+				return
+			}
+			sendJSONError(w, "Failed to delete trash entry", http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Deleted permanently",
+		})
+
+	default:
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+	}
+}
+
+// RestoreTrashHandler puts a trashed document back at its original path
+// (POST /api/trash/restore with {"id": ...}). It fails with 409 Conflict if
+// that path has been taken since.
+func RestoreTrashHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+
+	bin := trashBin()
+	entry, err := bin.Get(req.ID)
+	if err != nil {
+		if errors.Is(err, trash.ErrNotFound) {
+			sendJSONError(w, "Trash entry not found", http.StatusNotFound, "")
+			return
+		}
+		sendJSONError(w, "Failed to read trash entry", http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !auth.CanAccessDocument("/"+entry.Path, auth.GetSession(r), cfg) {
+		sendJSONError(w, "Access denied", http.StatusForbidden, "")
+		return
+	}
+
+	// A document being created at the same path waits, or makes the restore fail
+	unlock := doclock.Lock(utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, entry.Path)))
+	defer unlock()
+
+	if _, err := bin.Restore(req.ID); err != nil {
+		switch {
+		case errors.Is(err, trash.ErrOccupied):
+			sendJSONError(w, "A document already exists at the original path", http.StatusConflict, entry.Path)
+		case errors.Is(err, trash.ErrNotFound):
+			sendJSONError(w, "Trash entry not found", http.StatusNotFound, "")
+		default:
+			sendJSONError(w, "Failed to restore document", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	invalidateWikiLinkIndex()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Document restored",
+		"path":    "/" + entry.Path,
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/trash"
+)
+
+// Trashed documents keep their access rules: editors outside the groups of a
+// restricted document neither see nor restore it
+func TestTrashAccessRules(t *testing.T) {
+	testCfg, editor := newMoveTestWiki(t, "open", "secret")
+	testCfg.Wiki.Trash.Enabled = true
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/secret", Access: "restricted", Groups: []string{"staff"}}}
+	admin := moveTestSession(t, testCfg, "ad", config.RoleAdmin)
+
+	ids := map[string]string{}
+	for _, docPath := range []string{"open", "secret"} {
+		entry, err := moveToTrash(docPath, "editor")
+		if entry == nil {
+			t.Fatal(err)
+		}
+		ids[docPath] = entry.ID
+	}
+
+	list := func(cookie *http.Cookie) []string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/trash", nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		TrashHandler(rec, req)
+		var resp struct{ Entries []trash.Entry }
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, e := range resp.Entries {
+			paths = append(paths, e.Path)
+		}
+		return paths
+	}
+	if got := list(editor); len(got) != 1 || got[0] != "open" {
+		t.Errorf("editor sees %v, want [open]", got)
+	}
+	if got := list(admin); len(got) != 2 {
+		t.Errorf("admin sees %v, want both entries", got)
+	}
+
+	restore := func(cookie *http.Cookie, id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/trash/restore", strings.NewReader(`{"id":"`+id+`"}`))
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		RestoreTrashHandler(rec, req)
+		return rec.Code
+	}
+	if code := restore(editor, ids["secret"]); code != http.StatusForbidden {
+		t.Errorf("editor restoring secret = %d, want 403", code)
+	}
+	if code := restore(editor, ids["open"]); code != http.StatusOK {
+		t.Errorf("editor restoring open = %d, want 200", code)
+	}
+	if code := restore(admin, ids["secret"]); code != http.StatusOK {
+		t.Errorf("admin restoring secret = %d, want 200", code)
+	}
+}
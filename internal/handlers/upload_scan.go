@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/scan"
+)
+
+// uploadScanner checks attachments for malware; nil when none is configured
+var uploadScanner scan.Scanner
+
+// quarantineScans tracks the asynchronous scans of quarantined attachments
+var quarantineScans sync.WaitGroup
+
+// InitUploadScanner configures the malware scanner for attachments from
+// cfg.Wiki.Uploads.Scan
+func InitUploadScanner(cfg *config.Config) {
+	settings := cfg.Wiki.Uploads.Scan
+	scanner, err := scan.New(settings.Provider, settings.Address, time.Duration(settings.TimeoutSeconds)*time.Second)
+	if err != nil {
+		log.Printf("Warning: attachment scanning disabled: %v", err)
+	}
+	uploadScanner = scanner
+}
+
+// quarantineDir holds attachments waiting for their asynchronous scan
+func quarantineDir() string {
+	cfg := config.Current()
+	return filepath.Join(cfg.Wiki.RootDir, "temp", "quarantine")
+}
+
+// initQuarantine removes attachments left in quarantine by a previous run.
+// Their scan never finished, so they are not released.
+func initQuarantine() {
+	entries, err := os.ReadDir(quarantineDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		log.Printf("Removing unscanned quarantined upload %s", entry.Name())
+		os.Remove(filepath.Join(quarantineDir(), entry.Name()))
+	}
+}
+
+// quarantineUpload saves content in quarantine and scans it in the background,
+// moving it to savePath once it passes. logger records the outcome with the
+// details of the upload.
+func quarantineUpload(content io.Reader, savePath string, logger *slog.Logger) error {
+	if err := os.MkdirAll(quarantineDir(), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(quarantineDir(), "upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	scanner := uploadScanner
+	quarantineScans.Add(1)
+	go func() {
+		defer quarantineScans.Done()
+		releaseQuarantined(scanner, tmp.Name(), savePath, logger)
+	}()
+	return nil
+}
+
+// releaseQuarantined scans a quarantined attachment and moves it to savePath
+// when it is clean. Infected files, and files that could not be scanned, are
+// deleted.
+func releaseQuarantined(scanner scan.Scanner, tmpPath, savePath string, logger *slog.Logger) {
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		logger.Error("quarantined upload missing", "error", err)
+		return
+	}
+	threat, err := scanner.Scan(context.Background(), f)
+	f.Close()
+	switch {
+	case err != nil:
+		logger.Error("upload scan failed; file deleted", "error", err)
+		os.Remove(tmpPath)
+	case threat != "":
+		logger.Warn("infected upload rejected", "threat", threat)
+		os.Remove(tmpPath)
+	default:
+		if err := os.Rename(tmpPath, savePath); err != nil {
+			logger.Error("failed to release scanned upload", "error", err)
+			os.Remove(tmpPath)
+			return
+		}
+		// Links to attachments render differently once they exist
+		renderCache.Purge()
+		logger.Info("scanned upload released")
+	}
+}
@@ -3,33 +3,44 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
+	"slices"
+	"time"
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
 	"wiki-go/internal/crypto"
+	"wiki-go/internal/i18n"
 )
 
 // User represents a user in the response
 type UserResponse struct {
 	Username string   `json:"username"`
-	Role     string   `json:"role"`             // "admin", "editor", or "viewer"
-	Groups   []string `json:"groups,omitempty"` // Optional groups
+	Role     string   `json:"role"`               // "admin", "editor", or "viewer"
+	Groups   []string `json:"groups,omitempty"`   // Optional groups
+	Language string   `json:"language,omitempty"` // Preferred language for server messages
+	Disabled bool     `json:"disabled,omitempty"` // The user can't sign in
+	// When the user last signed in; omitted when they haven't since logins were recorded
+	LastLogin *time.Time `json:"last_login,omitempty"`
 }
 
 // UserCreateRequest represents the request body for creating a user
 type UserCreateRequest struct {
 	Username string   `json:"username"`
 	Password string   `json:"password"`
-	Role     string   `json:"role"`             // "admin", "editor", or "viewer"
-	Groups   []string `json:"groups,omitempty"` // Optional groups
+	Role     string   `json:"role"`               // "admin", "editor", or "viewer"
+	Groups   []string `json:"groups,omitempty"`   // Optional groups
+	Language string   `json:"language,omitempty"` // Optional preferred language
 }
 
 // UserUpdateRequest represents the request body for updating a user
 type UserUpdateRequest struct {
 	Username    string   `json:"username"`
 	NewPassword string   `json:"new_password,omitempty"`
-	Role        string   `json:"role"`             // "admin", "editor", or "viewer"
-	Groups      []string `json:"groups,omitempty"` // Optional groups
+	Role        string   `json:"role"`               // "admin", "editor", or "viewer"
+	Groups      []string `json:"groups,omitempty"`   // Optional groups
+	Language    *string  `json:"language,omitempty"` // Preferred language; left unchanged when omitted
+	Disabled    *bool    `json:"disabled,omitempty"` // Whether the user can't sign in; left unchanged when omitted
 }
 
 // UsersHandler handles user management endpoints
@@ -57,6 +68,8 @@ func UsersHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetUsersHandler returns a list of all users (without passwords)
 func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+
 	// Check if user is authenticated and has admin role
 	session := auth.GetSession(r)
 	if session == nil || session.Role != config.RoleAdmin {
@@ -64,6 +77,11 @@ func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	logins, err := userLogins().All()
+	if err != nil {
+		log.Printf("Warning: Failed to read last logins: %v", err)
+	}
+
 	// Convert users to response objects (without passwords)
 	users := make([]UserResponse, 0, len(cfg.Users))
 	for _, user := range cfg.Users {
@@ -72,11 +90,17 @@ func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 			role = config.RoleViewer // Default to viewer if role not set
 		}
 		
-		users = append(users, UserResponse{
+		response := UserResponse{
 			Username: user.Username,
 			Role:     role,
 			Groups:   user.Groups,
-		})
+			Language: user.Language,
+			Disabled: user.Disabled,
+		}
+		if login, ok := logins[user.Username]; ok {
+			response.LastLogin = &login.Last.Time
+		}
+		users = append(users, response)
 	}
 
 	// Send the response
@@ -89,6 +113,10 @@ func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 
 // CreateUserHandler creates a new user
 func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
+
 	// Check if user is authenticated and has admin role
 	session := auth.GetSession(r)
 	if session == nil || session.Role != config.RoleAdmin {
@@ -110,6 +138,11 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !validUserLanguage(req.Language) {
+		sendJSONError(w, "Unsupported language", http.StatusBadRequest, "")
+		return
+	}
+
 	// Check if username already exists
 	for _, user := range cfg.Users {
 		if user.Username == req.Username {
@@ -119,7 +152,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Hash the password
-	hashedPassword, err := crypto.HashPassword(req.Password, cfg.Security.PasswordStrength)
+	hashedPassword, err := crypto.HashPasswordWith(req.Password, config.PasswordParams(cfg))
 	if err != nil {
 		sendJSONError(w, "Failed to hash password", http.StatusInternalServerError, err.Error())
 		return
@@ -127,6 +160,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create a copy of the current config
 	updatedConfig := *cfg
+	updatedConfig.Users = slices.Clone(cfg.Users)
 
 	// Validate role
 	if req.Role != config.RoleAdmin && req.Role != config.RoleEditor && req.Role != config.RoleViewer {
@@ -139,6 +173,8 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 		Password: hashedPassword,
 		Role:     req.Role,
 		Groups:   req.Groups,
+		Created:  time.Now(),
+		Language: req.Language,
 	})
 
 	// Save the updated config
@@ -149,7 +185,7 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
 
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
@@ -162,6 +198,10 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateUserHandler updates an existing user
 func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
+
 	// Check if user is authenticated and has admin role
 	session := auth.GetSession(r)
 	if session == nil || session.Role != config.RoleAdmin {
@@ -182,9 +222,23 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		sendJSONError(w, "Username is required", http.StatusBadRequest, "")
 		return
 	}
+	if req.Language != nil && !validUserLanguage(*req.Language) {
+		sendJSONError(w, "Unsupported language", http.StatusBadRequest, "")
+		return
+	}
+	disabling := req.Disabled != nil && *req.Disabled
+	if disabling && session.Username == req.Username {
+		sendJSONError(w, "Cannot disable your own account", http.StatusBadRequest, "")
+		return
+	}
+	if disabling && enabledAdmins(cfg.Users, req.Username) == 0 {
+		sendJSONError(w, "Cannot disable the last admin user", http.StatusBadRequest, "")
+		return
+	}
 
 	// Create a copy of the current config
 	updatedConfig := *cfg
+	updatedConfig.Users = slices.Clone(cfg.Users)
 
 	// Validate role
 	if req.Role != config.RoleAdmin && req.Role != config.RoleEditor && req.Role != config.RoleViewer {
@@ -199,9 +253,15 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 			updatedConfig.Users[i].Role = req.Role
 			// Update groups
 			updatedConfig.Users[i].Groups = req.Groups
+			if req.Language != nil {
+				updatedConfig.Users[i].Language = *req.Language
+			}
+			if req.Disabled != nil {
+				updatedConfig.Users[i].Disabled = *req.Disabled
+			}
 			// Update password if provided
 			if req.NewPassword != "" {
-				hashedPassword, err := crypto.HashPassword(req.NewPassword, updatedConfig.Security.PasswordStrength)
+				hashedPassword, err := crypto.HashPasswordWith(req.NewPassword, config.PasswordParams(&updatedConfig))
 				if err != nil {
 					sendJSONError(w, "Failed to hash password", http.StatusInternalServerError, err.Error())
 					return
@@ -227,7 +287,15 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
+
+	// Devices kept logged in with the old password must sign in again, and
+	// disabled users are signed out everywhere
+	if disabling {
+		auth.EndSessions(req.Username)
+	} else if req.NewPassword != "" {
+		auth.RevokeRefreshTokens(req.Username)
+	}
 
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
@@ -240,6 +308,10 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteUserHandler deletes a user
 func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg := config.Current()
+
 	// Check if user is authenticated and has admin role
 	session := auth.GetSession(r)
 	if session == nil || session.Role != config.RoleAdmin {
@@ -262,6 +334,7 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create a copy of the current config
 	updatedConfig := *cfg
+	updatedConfig.Users = slices.Clone(cfg.Users)
 
 	// Find and remove the user
 	userFound := false
@@ -279,15 +352,8 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Make sure we don't delete the last admin
-	adminCount := 0
-	for _, user := range updatedConfig.Users {
-		if user.Role == config.RoleAdmin {
-			adminCount++
-		}
-	}
-
-	if adminCount == 0 {
+	// Make sure we don't delete the last admin who can sign in
+	if enabledAdmins(updatedConfig.Users, "") == 0 {
 		sendJSONError(w, "Cannot delete the last admin user", http.StatusBadRequest, "")
 		return
 	}
@@ -300,7 +366,14 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the global config
-	*cfg = updatedConfig
+	config.SetCurrent(&updatedConfig)
+
+	// Sign the user out everywhere, including devices kept logged in
+	auth.EndSessions(username)
+
+	if err := userLogins().Remove(username); err != nil {
+		log.Printf("Warning: Failed to forget the logins of %s: %v", username, err)
+	}
 
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
@@ -311,8 +384,21 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// enabledAdmins returns the number of admins among users who can sign in,
+// leaving out the user named except
+func enabledAdmins(users []config.User, except string) int {
+	count := 0
+	for _, user := range users {
+		if user.Role == config.RoleAdmin && !user.Disabled && user.Username != except {
+			count++
+		}
+	}
+	return count
+}
+
 // GetUserByUsername retrieves a user by username (for internal use)
 func GetUserByUsername(username string) (*config.User, error) {
+	cfg := config.Current()
 	for _, user := range cfg.Users {
 		if user.Username == username {
 			return &user, nil
@@ -320,3 +406,8 @@ func GetUserByUsername(username string) (*config.User, error) {
 	}
 	return nil, errors.New("user not found")
 }
+
+// validUserLanguage reports whether lang is empty or one of the available catalogs
+func validUserLanguage(lang string) bool {
+	return lang == "" || slices.Contains(i18n.GetAvailableLanguages(), lang)
+}
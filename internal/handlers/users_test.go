@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/crypto"
+)
+
+func TestDisableUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		role     string
+		disabled bool
+		wantCode int
+	}{
+		{"disable", "vi", config.RoleViewer, true, http.StatusOK},
+		{"enable", "vi", config.RoleViewer, false, http.StatusOK},
+		{"disable yourself", "ad", config.RoleAdmin, true, http.StatusBadRequest},
+		{"missing user", "nobody", config.RoleViewer, true, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t)
+			hash, err := crypto.HashPassword("secret", 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCfg.Users = []config.User{
+				{Username: "ad", Password: hash, Role: config.RoleAdmin},
+				{Username: "vi", Password: hash, Role: config.RoleViewer},
+			}
+			previous := config.ConfigFilePath
+			config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+			t.Cleanup(func() { config.ConfigFilePath = previous })
+
+			admin := moveTestSession(t, testCfg, "ad", config.RoleAdmin)
+			viewer := moveTestSession(t, testCfg, "vi", config.RoleViewer)
+
+			body := fmt.Sprintf(`{"username":%q,"role":%q,"disabled":%t}`, tt.username, tt.role, tt.disabled)
+			req := httptest.NewRequest(http.MethodPut, "/api/users", strings.NewReader(body))
+			req.AddCookie(admin)
+			rec := httptest.NewRecorder()
+			UpdateUserHandler(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+
+			disabled := tt.wantCode == http.StatusOK && tt.disabled
+			req = httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(viewer)
+			if signedOut := auth.GetSession(req) == nil; signedOut != disabled {
+				t.Errorf("viewer signed out = %t, want %t", signedOut, disabled)
+			}
+			rec = httptest.NewRecorder()
+			LoginHandler(rec, httptest.NewRequest(http.MethodPost, "/api/login",
+				strings.NewReader(`{"username":"vi","password":"secret"}`)))
+			if signedIn := rec.Code == http.StatusOK; signedIn == disabled {
+				t.Errorf("viewer login status = %d with disabled = %t", rec.Code, disabled)
+			}
+		})
+	}
+}
+
+// Deleting a user signs them out, including devices kept logged in
+func TestDeleteUserEndsSessions(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t)
+	testCfg.Users = []config.User{
+		{Username: "ad", Role: config.RoleAdmin},
+		{Username: "vi", Role: config.RoleViewer},
+	}
+	previous := config.ConfigFilePath
+	config.ConfigFilePath = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { config.ConfigFilePath = previous })
+
+	admin := moveTestSession(t, testCfg, "ad", config.RoleAdmin)
+	login := httptest.NewRecorder()
+	if err := auth.CreateSession(login, httptest.NewRequest(http.MethodPost, "/api/login", nil), "vi", config.RoleViewer, nil, true, testCfg); err != nil {
+		t.Fatal(err)
+	}
+	viewer := httptest.NewRequest(http.MethodPost, "/api/refresh", nil)
+	for _, c := range login.Result().Cookies() {
+		viewer.AddCookie(c)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/users?username=vi", nil)
+	req.AddCookie(admin)
+	rec := httptest.NewRecorder()
+	DeleteUserHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	if auth.GetSession(viewer) != nil {
+		t.Error("session of the deleted user goes on")
+	}
+	if session, err := auth.RefreshSession(httptest.NewRecorder(), viewer, config.Current()); err == nil {
+		t.Errorf("refresh token of the deleted user made session %+v", session)
+	}
+}
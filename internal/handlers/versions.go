@@ -9,7 +9,9 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
 	"wiki-go/internal/utils"
 )
 
@@ -17,6 +19,8 @@ import (
 type VersionInfo struct {
 	Timestamp string `json:"timestamp"`
 	Path      string `json:"path"`
+	Author    string `json:"author,omitempty"`
+	Message   string `json:"message,omitempty"`
 }
 
 // VersionsListResponse is the JSON response for listing versions
@@ -123,15 +127,15 @@ func VersionsHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config)
 func handleListVersions(w http.ResponseWriter, _ *http.Request, cfg *config.Config, docPath string) {
 	// Adjust the path for the new versioning structure
 	var versionsDir string
-	if docPath == "pages/home" {
+	if docPath == homeDocPath {
 		// For homepage, use the new path
-		versionsDir = filepath.Join(cfg.Wiki.RootDir, "versions", "pages", "home")
+		versionsDir = homePageVersionsDir(cfg)
 	} else if strings.HasPrefix(docPath, "documents/") {
 		// Path already includes "documents/" prefix
-		versionsDir = filepath.Join(cfg.Wiki.RootDir, "versions", docPath)
+		versionsDir = filepath.Join(config.VersionsPath(cfg), docPath)
 	} else {
 		// Add "documents/" prefix for regular documents
-		versionsDir = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", docPath)
+		versionsDir = filepath.Join(config.VersionsPath(cfg), "documents", docPath)
 	}
 
 	// Check if versions directory exists
@@ -167,9 +171,12 @@ func handleListVersions(w http.ResponseWriter, _ *http.Request, cfg *config.Conf
 
 		// Only add valid timestamp files (14 digits: yyyymmddhhmmss)
 		if len(timestamp) == 14 && utils.IsNumeric(timestamp) {
+			meta := utils.ReadVersionMeta(versionsDir, timestamp)
 			versions = append(versions, VersionInfo{
 				Timestamp: timestamp,
 				Path:      filepath.Join(docPath, timestamp),
+				Author:    meta.Author,
+				Message:   meta.Message,
 			})
 		}
 	}
@@ -192,15 +199,15 @@ func handleListVersions(w http.ResponseWriter, _ *http.Request, cfg *config.Conf
 func handleGetVersion(w http.ResponseWriter, _ *http.Request, cfg *config.Config, docPath, timestamp string) {
 	// Adjust the path for the new versioning structure
 	var versionPath string
-	if docPath == "pages/home" {
+	if docPath == homeDocPath {
 		// For homepage, use the new path
-		versionPath = filepath.Join(cfg.Wiki.RootDir, "versions", "pages", "home", timestamp+".md")
+		versionPath = filepath.Join(homePageVersionsDir(cfg), timestamp+".md")
 	} else if strings.HasPrefix(docPath, "documents/") {
 		// Path already includes "documents/" prefix
-		versionPath = filepath.Join(cfg.Wiki.RootDir, "versions", docPath, timestamp+".md")
+		versionPath = filepath.Join(config.VersionsPath(cfg), docPath, timestamp+".md")
 	} else {
 		// Add "documents/" prefix for regular documents
-		versionPath = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", docPath, timestamp+".md")
+		versionPath = filepath.Join(config.VersionsPath(cfg), "documents", docPath, timestamp+".md")
 	}
 
 	// Check if version file exists
@@ -249,20 +256,20 @@ func handleVersionRestore(w http.ResponseWriter, r *http.Request, cfg *config.Co
 	var documentPath string
 	var versionRelativePath string
 
-	if docPath == "pages/home" {
+	if docPath == homeDocPath {
 		// For homepage, use the new paths
-		versionFilePath = filepath.Join(cfg.Wiki.RootDir, "versions", "pages", "home", timestamp+".md")
-		documentPath = filepath.Join(cfg.Wiki.RootDir, "pages", "home", "document.md")
-		versionRelativePath = "pages/home"
+		versionFilePath = filepath.Join(homePageVersionsDir(cfg), timestamp+".md")
+		documentPath = utils.DocumentFile(homePageDir(cfg))
+		versionRelativePath = homePagePath(cfg)
 	} else if strings.HasPrefix(docPath, "documents/") {
 		// Path already includes "documents/" prefix
-		versionFilePath = filepath.Join(cfg.Wiki.RootDir, "versions", docPath, timestamp+".md")
-		documentPath = filepath.Join(cfg.Wiki.RootDir, strings.TrimPrefix(docPath, "documents/"), "document.md")
+		versionFilePath = filepath.Join(config.VersionsPath(cfg), docPath, timestamp+".md")
+		documentPath = utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, strings.TrimPrefix(docPath, "documents/")))
 		versionRelativePath = docPath
 	} else {
 		// Add "documents/" prefix for regular documents
-		versionFilePath = filepath.Join(cfg.Wiki.RootDir, "versions", "documents", docPath, timestamp+".md")
-		documentPath = filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, docPath, "document.md")
+		versionFilePath = filepath.Join(config.VersionsPath(cfg), "documents", docPath, timestamp+".md")
+		documentPath = utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, docPath))
 		versionRelativePath = "documents/" + docPath
 	}
 
@@ -291,6 +298,10 @@ func handleVersionRestore(w http.ResponseWriter, r *http.Request, cfg *config.Co
 		return
 	}
 
+	// Saves and other restores of the document wait until the restore is done
+	unlock := doclock.Lock(documentPath)
+	defer unlock()
+
 	// Before overwriting current document, save it as a version
 	if _, err := os.Stat(documentPath); err == nil && cfg.Wiki.MaxVersions > 0 {
 		// Document exists, read its current content
@@ -300,7 +311,7 @@ func handleVersionRestore(w http.ResponseWriter, r *http.Request, cfg *config.Co
 			newTimestamp := time.Now().Format("20060102150405") // Format: yyyymmddhhmmss
 
 			// Create versions directory path that mirrors the document path
-			versionDir := filepath.Join(cfg.Wiki.RootDir, "versions", versionRelativePath)
+			versionDir := filepath.Join(config.VersionsPath(cfg), versionRelativePath)
 
 			// Ensure versions directory exists
 			if err := os.MkdirAll(versionDir, 0755); err == nil {
@@ -310,6 +321,9 @@ func handleVersionRestore(w http.ResponseWriter, r *http.Request, cfg *config.Co
 				// Save the current content as a version
 				_ = os.WriteFile(newVersionPath, currentContent, 0644) // Ignore error for now
 
+				// The metadata of the content we just archived moves with it
+				utils.ArchiveCurrentMeta(versionDir, newTimestamp)
+
 				// Clean up old versions if needed
 				utils.CleanupOldVersions(versionDir, cfg.Wiki.MaxVersions)
 			}
@@ -317,12 +331,27 @@ func handleVersionRestore(w http.ResponseWriter, r *http.Request, cfg *config.Co
 	}
 
 	// Write the version content to the document file
-	if err := os.WriteFile(documentPath, versionContent, 0644); err != nil {
+	if err := doclock.WriteFile(documentPath, versionContent, 0644); err != nil {
 		fmt.Printf("Error writing to document file: %v\n", err)
 		sendJSONErrorVersion(w, "Failed to restore document", http.StatusInternalServerError)
 		return
 	}
 
+	// Record the restore as a new revision
+	if cfg.Wiki.MaxVersions > 0 {
+		meta := utils.VersionMeta{
+			Message: "Restored version " + timestamp,
+			Time:    time.Now().Format("20060102150405"),
+		}
+		if session := auth.GetSession(r); session != nil {
+			meta.Author = session.Username
+		}
+		versionDir := filepath.Join(config.VersionsPath(cfg), versionRelativePath)
+		if err := utils.WriteCurrentMeta(versionDir, meta); err != nil {
+			fmt.Printf("Warning: couldn't write revision metadata: %v\n", err)
+		}
+	}
+
 	// Force update the file's modification time to ensure cache invalidation
 	now := time.Now()
 	if err := os.Chtimes(documentPath, now, now); err != nil {
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/i18n"
+	"wiki-go/internal/utils"
+	"wiki-go/internal/views"
+)
+
+// viewFlushInterval is how often view counts are written to disk
+const viewFlushInterval = time.Minute
+
+// viewCounts tracks document views; nil if the store could not be opened
+var viewCounts *views.Store
+
+// PopularDocument is an entry of the most viewed listing
+type PopularDocument struct {
+	Title string `json:"title"`
+	Path  string `json:"path"`
+	Views int64  `json:"views"`
+}
+
+// initViewCounts opens the view counter stored under the data directory
+func initViewCounts() {
+	cfg := config.Current()
+	if viewCounts != nil {
+		return
+	}
+	store, err := views.Open(filepath.Join(cfg.Wiki.RootDir, "stats", "views.json"), viewFlushInterval)
+	if err != nil {
+		log.Printf("Warning: Failed to load view counts: %v", err)
+		return
+	}
+	viewCounts = store
+}
+
+// CloseViewCounts writes pending view counts to disk; called on shutdown
+func CloseViewCounts() error {
+	if viewCounts == nil {
+		return nil
+	}
+	return viewCounts.Close()
+}
+
+// recordView counts a page view, identifying the visitor by username or client IP
+func recordView(r *http.Request, docPath string) int64 {
+	if viewCounts == nil {
+		return 0
+	}
+	visitor := "ip:" + clientIP(r)
+	if session := auth.GetSession(r); session != nil {
+		visitor = "user:" + session.Username
+	}
+	viewCounts.Record(docPath, visitor)
+	return viewCounts.Count(docPath)
+}
+
+// pruneViewCounts drops the view counts of documents that no longer exist and
+// returns how many it dropped
+func pruneViewCounts() int {
+	if viewCounts == nil {
+		return 0
+	}
+	return viewCounts.Prune(func(docPath string) bool {
+		_, err := os.Stat(utils.DocumentFile(documentDir(docPath)))
+		return err == nil
+	})
+}
+
+// PopularDocumentsHandler lists the most viewed documents the user can access.
+// Query parameter limit sets the number of results (default 10, max 100).
+func PopularDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+
+	session := auth.GetSession(r)
+	documents := []PopularDocument{}
+	if viewCounts != nil {
+		for _, entry := range viewCounts.Top(0) {
+			if len(documents) >= limit {
+				break
+			}
+			if !auth.CanAccessDocument(entry.Path, session, cfg) {
+				continue
+			}
+
+			// Skip counts left behind by deleted documents
+			dir := documentDir(entry.Path)
+			if _, err := os.Stat(utils.DocumentFile(dir)); err != nil {
+				continue
+			}
+			title := i18n.Translate("nav.home")
+			if entry.Path != "/" {
+				title = utils.GetDocumentTitle(dir)
+			}
+			documents = append(documents, PopularDocument{Title: title, Path: entry.Path, Views: entry.Views})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"documents": documents,
+	})
+}
+
+// DocumentViewsHandler returns the view count of one document.
+// URL format: /api/views/{document-path}; an empty path is the homepage.
+func DocumentViewsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Current()
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "Method not allowed", http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	docPath := "/" + strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/views"), "/")
+	if !auth.CanAccessDocument(docPath, auth.GetSession(r), cfg) {
+		sendJSONError(w, "Forbidden", http.StatusForbidden, "")
+		return
+	}
+
+	var count int64
+	if viewCounts != nil {
+		count = viewCounts.Count(docPath)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"path":    docPath,
+		"views":   count,
+	})
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/events"
+	"wiki-go/internal/roles"
+	"wiki-go/internal/utils"
+)
+
+// webDAVPrefix is where the documents tree is mounted for WebDAV clients
+const webDAVPrefix = "/dav/"
+
+// webDAVMethods are the methods answered by WebDAVHandler
+const webDAVMethods = "OPTIONS, GET, HEAD, PUT, MKCOL, PROPFIND, PROPPATCH, LOCK, UNLOCK"
+
+// davResource is a file or directory of the documents tree
+type davResource struct {
+	name    string // Path below the documents dir, "" for its root
+	file    string // Path on disk
+	info    os.FileInfo
+	docPath string // Document the resource belongs to, for access checks
+}
+
+// WebDAVHandler serves the documents tree at /dav/ so it can be mounted as a
+// network drive. Directories and their files can be listed and read, and
+// editors can create folders and write document.md files, which keeps their
+// versions like a save from the editor. Deleting, moving and uploading other
+// files is left to the web interface. Clients authenticate with HTTP Basic
+// auth; the session cookie of a signed-in browser is accepted too.
+func WebDAVHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if !cfg.Server.WebDAV.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	session := webDAVSession(w, r, cfg)
+	if session == nil {
+		return
+	}
+
+	name := strings.Trim(path.Clean("/"+strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(webDAVPrefix, "/"))), "/")
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	res := davResource{name: name, file: filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, filepath.FromSlash(name))}
+	res.info, _ = os.Stat(res.file)
+	res.docPath = name
+	if res.info == nil || !res.info.IsDir() {
+		res.docPath = davParent(name)
+	}
+	if !auth.CanAccessDocument("/"+res.docPath, session, cfg) {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("DAV", "1, 2")
+		w.Header().Set("Allow", webDAVMethods)
+		w.Header().Set("MS-Author-Via", "DAV")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		davGet(w, r, res)
+	case "PROPFIND":
+		davPropfind(w, r, res, session, cfg)
+	case "PROPPATCH":
+		davProppatch(w, r, res)
+	case http.MethodPut, "MKCOL", "LOCK", "UNLOCK":
+		if session.Role != roles.RoleAdmin && session.Role != roles.RoleEditor {
+			http.Error(w, "Editor or admin role required", http.StatusForbidden)
+			return
+		}
+		if reserved, ok := reservedPath(res.name, cfg); ok && res.info == nil && r.Method != "UNLOCK" {
+			http.Error(w, "The path \""+reserved+"\" is reserved", http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			davPut(w, r, res, session, cfg)
+		case "MKCOL":
+			davMkcol(w, r, res)
+		case "LOCK":
+			davLock(w, r, res)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		w.Header().Set("Allow", webDAVMethods)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// webDAVSession authenticates a WebDAV request from its Basic auth credentials
+// or session cookie. It writes the error response and returns nil when the
+// request is not authenticated. Failed logins count towards the login ban.
+func webDAVSession(w http.ResponseWriter, r *http.Request, cfg *config.Config) *auth.Session {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		if session := auth.GetSession(r); session != nil {
+			return session
+		}
+		w.Header().Set("WWW-Authenticate", davChallenge(cfg))
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil
+	}
+
+	ip := clientIP(r)
+	if loginBan != nil {
+		if remaining := loginBan.IsBanned(ip); remaining > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())))
+			http.Error(w, "Too many failed logins; try again later", http.StatusTooManyRequests)
+			return nil
+		}
+	}
+	valid, role, groups := auth.ValidateCredentials(username, password, cfg)
+	if !valid {
+		if loginBan != nil {
+			loginBan.RegisterFailure(ip)
+		}
+		w.Header().Set("WWW-Authenticate", davChallenge(cfg))
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return nil
+	}
+	return &auth.Session{Username: username, Role: role, Groups: groups}
+}
+
+// davChallenge returns the WWW-Authenticate header asking for the wiki password
+func davChallenge(cfg *config.Config) string {
+	return `Basic realm="` + strings.ReplaceAll(cfg.Wiki.Title, `"`, "") + `", charset="UTF-8"`
+}
+
+// davParent returns the parent of a slash separated path, "" for top-level names
+func davParent(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+func davGet(w http.ResponseWriter, r *http.Request, res davResource) {
+	if res.info == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if res.info.IsDir() {
+		http.Error(w, "Collections have no content; use PROPFIND to list them", http.StatusMethodNotAllowed)
+		return
+	}
+	file, err := os.Open(res.file)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("ETag", davETag(res.info))
+	http.ServeContent(w, r, res.info.Name(), res.info.ModTime(), file)
+}
+
+func davPut(w http.ResponseWriter, r *http.Request, res davResource, session *auth.Session, cfg *config.Config) {
+	if !utils.IsIndexFile(path.Base(res.name)) || res.docPath == "" {
+		http.Error(w, "Only document files inside a folder can be written", http.StatusForbidden)
+		return
+	}
+	if res.info != nil && res.info.IsDir() {
+		http.Error(w, "A folder has this name", http.StatusMethodNotAllowed)
+		return
+	}
+	if info, err := os.Stat(filepath.Dir(res.file)); err != nil || !info.IsDir() {
+		http.Error(w, "Parent folder does not exist", http.StatusConflict)
+		return
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.GetMaxUploadSizeBytes(cfg)))
+	if err != nil {
+		http.Error(w, "Document too large. Maximum size is "+config.GetMaxUploadSizeFormatted(cfg)+".", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := checkDocumentSize(content); err != nil {
+		http.Error(w, "Document too large. Maximum size is "+maxDocumentSizeText()+".", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	unlock := doclock.Lock(res.file)
+	defer unlock()
+	if err := writeDocumentRevision(res.file, "documents/"+res.docPath, content, session.Username, "Saved over WebDAV"); err != nil {
+		log.Printf("Error saving document %s over WebDAV: %v", res.file, err)
+		http.Error(w, "Failed to save document", http.StatusInternalServerError)
+		return
+	}
+	if res.info == nil {
+		publish(events.DocumentCreated{Path: events.CleanPath(res.docPath), User: session.Username})
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	publish(events.DocumentEdited{Path: events.CleanPath(res.docPath), User: session.Username})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func davMkcol(w http.ResponseWriter, r *http.Request, res davResource) {
+	if r.ContentLength > 0 {
+		http.Error(w, "MKCOL bodies are not supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	if res.info != nil {
+		http.Error(w, "Already exists", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := os.Mkdir(res.file, 0755); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "Parent folder does not exist", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create folder", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// davLock grants every LOCK request. Documents are already serialized by
+// doclock, but clients such as Finder and Windows Explorer only write to
+// servers that support locking.
+func davLock(w http.ResponseWriter, r *http.Request, res davResource) {
+	token := r.Header.Get("If")
+	if start, end := strings.Index(token, "<"), strings.Index(token, ">"); start >= 0 && end > start {
+		token = token[start+1 : end]
+	} else {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			http.Error(w, "Failed to create lock", http.StatusInternalServerError)
+			return
+		}
+		token = "opaquelocktoken:" + hex.EncodeToString(buf)
+	}
+
+	w.Header().Set("Lock-Token", "<"+token+">")
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<D:prop xmlns:D="DAV:"><D:lockdiscovery><D:activelock>
+<D:locktype><D:write/></D:locktype><D:lockscope><D:exclusive/></D:lockscope>
+<D:depth>0</D:depth><D:timeout>Second-3600</D:timeout>
+<D:locktoken><D:href>%s</D:href></D:locktoken>
+<D:lockroot><D:href>%s</D:href></D:lockroot>
+</D:activelock></D:lockdiscovery></D:prop>`, davEscape(token), davEscape(davHref(res)))
+}
+
+// davMultistatus is the body of a 207 Multi-Status response to PROPFIND
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"D:multistatus"`
+	XMLNS     string        `xml:"xmlns:D,attr"`
+	Responses []davResponse `xml:"D:response"`
+}
+
+type davResponse struct {
+	Href     string      `xml:"D:href"`
+	Propstat davPropstat `xml:"D:propstat"`
+}
+
+type davPropstat struct {
+	Prop   davProp `xml:"D:prop"`
+	Status string  `xml:"D:status"`
+}
+
+type davProp struct {
+	DisplayName   string          `xml:"D:displayname"`
+	ResourceType  davResourceType `xml:"D:resourcetype"`
+	ContentLength string          `xml:"D:getcontentlength,omitempty"`
+	ContentType   string          `xml:"D:getcontenttype,omitempty"`
+	LastModified  string          `xml:"D:getlastmodified"`
+	ETag          string          `xml:"D:getetag,omitempty"`
+}
+
+type davResourceType struct {
+	Collection *struct{} `xml:"D:collection"`
+}
+
+// davPropfind lists a resource, and with Depth: 1 the visible children of a
+// folder. The live properties are always returned, whichever were asked for.
+func davPropfind(w http.ResponseWriter, r *http.Request, res davResource, session *auth.Session, cfg *config.Config) {
+	if res.info == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	ms := davMultistatus{XMLNS: "DAV:", Responses: []davResponse{davPropResponse(res)}}
+	if res.info.IsDir() && r.Header.Get("Depth") != "0" {
+		entries, err := os.ReadDir(res.file)
+		if err != nil {
+			http.Error(w, "Failed to read folder", http.StatusInternalServerError)
+			return
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || (!info.IsDir() && !info.Mode().IsRegular()) {
+				continue
+			}
+			child := davResource{
+				name:    strings.TrimPrefix(res.name+"/"+entry.Name(), "/"),
+				file:    filepath.Join(res.file, entry.Name()),
+				info:    info,
+				docPath: res.docPath,
+			}
+			if info.IsDir() {
+				child.docPath = child.name
+				if !auth.CanAccessDocument("/"+child.docPath, session, cfg) {
+					continue
+				}
+			}
+			ms.Responses = append(ms.Responses, davPropResponse(child))
+		}
+	}
+
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(ms); err != nil {
+		log.Printf("Error writing PROPFIND response: %v", err)
+	}
+}
+
+func davPropResponse(res davResource) davResponse {
+	prop := davProp{
+		DisplayName:  res.info.Name(),
+		LastModified: res.info.ModTime().UTC().Format(http.TimeFormat),
+	}
+	if res.info.IsDir() {
+		prop.ResourceType.Collection = &struct{}{}
+	} else {
+		prop.ContentLength = strconv.FormatInt(res.info.Size(), 10)
+		prop.ContentType = mime.TypeByExtension(filepath.Ext(res.info.Name()))
+		if prop.ContentType == "" || filepath.Ext(res.info.Name()) == ".md" {
+			prop.ContentType = "text/markdown; charset=utf-8"
+		}
+		prop.ETag = davETag(res.info)
+	}
+	return davResponse{
+		Href:     davHref(res),
+		Propstat: davPropstat{Prop: prop, Status: "HTTP/1.1 200 OK"},
+	}
+}
+
+// davProppatch accepts and discards dead properties, such as the file times
+// Windows sets after copying a file, so that clients don't report a failure
+func davProppatch(w http.ResponseWriter, r *http.Request, res davResource) {
+	if res.info == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var props strings.Builder
+	dec := xml.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	depth, inProp := 0, false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if inProp && depth == 4 {
+				fmt.Fprintf(&props, `<x:%s xmlns:x="%s"/>`, t.Name.Local, davEscape(t.Name.Space))
+			}
+			if t.Name.Local == "prop" && depth == 3 {
+				inProp = true
+			}
+		case xml.EndElement:
+			if t.Name.Local == "prop" && depth == 3 {
+				inProp = false
+			}
+			depth--
+		}
+	}
+
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<D:multistatus xmlns:D="DAV:"><D:response><D:href>%s</D:href>
+<D:propstat><D:prop>%s</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat>
+</D:response></D:multistatus>`, davEscape(davHref(res)), props.String())
+}
+
+// davHref returns the escaped URL path of a resource; folders end with a slash
+func davHref(res davResource) string {
+	href := (&url.URL{Path: webDAVPrefix + res.name}).EscapedPath()
+	if res.info != nil && res.info.IsDir() && !strings.HasSuffix(href, "/") {
+		href += "/"
+	}
+	return href
+}
+
+func davETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+func davEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
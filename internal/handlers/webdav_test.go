@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestWebDAVPropfindHidesRestricted(t *testing.T) {
+	testCfg, cookie := newMoveTestWiki(t, "guides", "finance")
+	testCfg.Server.WebDAV.Enabled = true
+	testCfg.AccessRules = []config.AccessRule{{Pattern: "/finance/**", Access: "restricted", Groups: []string{"finance"}}}
+
+	req := httptest.NewRequest("PROPFIND", "/dav/", nil)
+	req.Header.Set("Depth", "1")
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	WebDAVHandler(rec, req, testCfg)
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "<D:href>/dav/guides/</D:href>") {
+		t.Errorf("listing lacks guides: %s", body)
+	}
+	if strings.Contains(body, "finance") {
+		t.Errorf("listing shows a restricted document: %s", body)
+	}
+}
+
+func TestWebDAVPut(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"replace document", "/dav/guides/document.md", http.StatusNoContent},
+		{"missing folder", "/dav/guides/setup/document.md", http.StatusConflict},
+		{"other file", "/dav/guides/notes.txt", http.StatusForbidden},
+		{"hidden file", "/dav/guides/.document.md.swp", http.StatusNotFound},
+		{"documents root", "/dav/document.md", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t, "guides")
+			testCfg.Server.WebDAV.Enabled = true
+			testCfg.Wiki.MaxVersions = 10
+
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader("# Guides\n\nUpdated"))
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			WebDAVHandler(rec, req, testCfg)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusNoContent {
+				return
+			}
+			content, err := os.ReadFile(filepath.Join(testCfg.Wiki.RootDir, "documents", "guides", "document.md"))
+			if err != nil || string(content) != "# Guides\n\nUpdated" {
+				t.Errorf("document = %q, %v", content, err)
+			}
+			versions, _ := filepath.Glob(filepath.Join(testCfg.Wiki.RootDir, "versions", "documents", "guides", "*.md"))
+			if len(versions) != 1 {
+				t.Errorf("versions = %q, want the previous content kept", versions)
+			}
+		})
+	}
+}
+
+func TestWebDAVRequiresAuthentication(t *testing.T) {
+	testCfg, _ := newMoveTestWiki(t, "guides")
+	testCfg.Server.WebDAV.Enabled = true
+
+	req := httptest.NewRequest("PROPFIND", "/dav/", nil)
+	req.SetBasicAuth("editor", "wrong")
+	rec := httptest.NewRecorder()
+	WebDAVHandler(rec, req, testCfg)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("no WWW-Authenticate challenge")
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/lastlogin"
+)
+
+// Permissions listed by /api/whoami; each names actions the endpoints allow
+const (
+	permissionRead    = "read"    // Read documents the access rules open to the user
+	permissionComment = "comment" // Post comments
+	permissionSuggest = "suggest" // Suggest edits for editors to review
+	permissionEdit    = "edit"    // Create, edit, move and delete documents and attachments
+	permissionReview  = "review"  // Accept or reject suggested edits
+	permissionPublish = "publish" // Move documents where more users can read them
+	permissionAdmin   = "admin"   // Manage users and settings
+)
+
+// whoamiResponse is the body of a successful /api/whoami
+type whoamiResponse struct {
+	Success     bool     `json:"success"`
+	Username    string   `json:"username"`
+	Role        string   `json:"role"`
+	Groups      []string `json:"groups"`
+	Permissions []string `json:"permissions"` // What the user may do under the current settings
+	// The current and the previous sign-in, so users can spot one that wasn't theirs
+	LastLogin     *lastlogin.Login `json:"last_login,omitempty"`
+	PreviousLogin *lastlogin.Login `json:"previous_login,omitempty"`
+}
+
+// WhoamiHandler describes the signed-in user and what they may do. Scripts and
+// pages use it to learn who is signed in, as the session cookie can't be read.
+func WhoamiHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	session := auth.GetSession(r)
+	if session == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	groups := session.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+	response := whoamiResponse{
+		Success:     true,
+		Username:    session.Username,
+		Role:        session.Role,
+		Groups:      groups,
+		Permissions: sessionPermissions(r, session),
+	}
+	if record, ok, err := userLogins().Get(session.Username); err != nil {
+		log.Printf("Warning: Failed to read the logins of %s: %v", session.Username, err)
+	} else if ok {
+		response.LastLogin = &record.Last
+		response.PreviousLogin = record.Previous
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// sessionPermissions returns the permissions of the user of session, checked
+// the way the endpoints they allow check them
+func sessionPermissions(r *http.Request, session *auth.Session) []string {
+	cfg := config.Current()
+	permissions := []string{permissionRead}
+	if !cfg.Wiki.DisableComments && auth.CanContribute(r, cfg) {
+		if ok, _ := canPostComments(r, session); ok {
+			permissions = append(permissions, permissionComment)
+		}
+	}
+	if cfg.Wiki.Suggestions.Enabled {
+		permissions = append(permissions, permissionSuggest)
+	}
+	if auth.RequireRole(r, config.RoleEditor) {
+		permissions = append(permissions, permissionEdit)
+		if cfg.Wiki.Suggestions.Enabled {
+			permissions = append(permissions, permissionReview)
+		}
+		if canPublish(session) {
+			permissions = append(permissions, permissionPublish)
+		}
+	}
+	if auth.RequireRole(r, config.RoleAdmin) {
+		permissions = append(permissions, permissionAdmin)
+	}
+	return permissions
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"wiki-go/internal/config"
+)
+
+func TestWhoami(t *testing.T) {
+	tests := []struct {
+		name            string
+		signedIn        bool
+		modify          func(c *config.Config)
+		wantCode        int
+		wantPermissions []string
+	}{
+		{"signed out", false, func(c *config.Config) {}, http.StatusUnauthorized, nil},
+		{"editor", true, func(c *config.Config) {}, http.StatusOK,
+			[]string{permissionRead, permissionComment, permissionEdit}},
+		{"editor with suggestions", true, func(c *config.Config) { c.Wiki.Suggestions.Enabled = true }, http.StatusOK,
+			[]string{permissionRead, permissionComment, permissionSuggest, permissionEdit, permissionReview}},
+		{"comments disabled", true, func(c *config.Config) { c.Wiki.DisableComments = true }, http.StatusOK,
+			[]string{permissionRead, permissionEdit}},
+		{"comments for admins only", true, func(c *config.Config) { c.Wiki.Comments.MinRole = config.RoleAdmin }, http.StatusOK,
+			[]string{permissionRead, permissionEdit}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, cookie := newMoveTestWiki(t)
+			tt.modify(testCfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+			if tt.signedIn {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			WhoamiHandler(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var resp whoamiResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Username != "editor" || resp.Role != config.RoleEditor {
+				t.Errorf("user = %q (%s)", resp.Username, resp.Role)
+			}
+			if !slices.Equal(resp.Permissions, tt.wantPermissions) {
+				t.Errorf("permissions = %v, want %v", resp.Permissions, tt.wantPermissions)
+			}
+		})
+	}
+}
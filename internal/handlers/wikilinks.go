@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/doclock"
+	"wiki-go/internal/goldext"
+	"wiki-go/internal/utils"
+)
+
+// wikiLinkIndexTTL bounds how stale the title index used for [[WikiLink]] resolution may get
+const wikiLinkIndexTTL = 10 * time.Second
+
+var (
+	wikiLinkMu      sync.Mutex
+	wikiLinkTargets []goldext.WikiLinkTarget
+	wikiLinkBuilt   time.Time
+	// wikiLinkLastTargets is the previous index, kept across invalidation to detect changes
+	wikiLinkLastTargets []goldext.WikiLinkTarget
+)
+
+// initWikiLinks installs the document index used to resolve [[WikiLinks]] at render time
+func initWikiLinks() {
+	cfg := config.Current()
+	goldext.WikiLinkResolution = cfg.Wiki.WikiLinkResolution
+	goldext.WikiLinkIndex = wikiLinkIndex
+}
+
+// wikiLinkIndex returns the cached list of documents, rebuilding it when it expires
+func wikiLinkIndex() []goldext.WikiLinkTarget {
+	cfg := config.Current()
+	wikiLinkMu.Lock()
+	defer wikiLinkMu.Unlock()
+
+	if wikiLinkTargets != nil && time.Since(wikiLinkBuilt) < wikiLinkIndexTTL {
+		return wikiLinkTargets
+	}
+
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		log.Printf("Warning: Failed to build wiki link index: %v", err)
+	}
+	targets := make([]goldext.WikiLinkTarget, 0, len(documents))
+	for _, doc := range documents {
+		targets = append(targets, goldext.WikiLinkTarget{Title: doc.Title, Path: doc.Path})
+	}
+
+	// Rendered documents may link to documents that have since appeared or gone
+	if !slices.Equal(targets, wikiLinkLastTargets) {
+		renderCache.Purge()
+	}
+	wikiLinkLastTargets = targets
+
+	wikiLinkTargets = targets
+	wikiLinkBuilt = time.Now()
+	return wikiLinkTargets
+}
+
+// invalidateWikiLinkIndex forces the next render to rebuild the index. It is
+// called whenever documents are saved, created or moved, so the related
+// documents and aliases read from their contents are dropped as well.
+func invalidateWikiLinkIndex() {
+	wikiLinkMu.Lock()
+	wikiLinkTargets = nil
+	wikiLinkMu.Unlock()
+	invalidateRelatedDocuments()
+	invalidateAliasIndex()
+	markSearchIndexStale()
+}
+
+// rewriteWikiLinks updates links to a moved document (or one below a moved
+// category) in every document: [[...]] links that address it by path or slug,
+// and links and images that reference its attachments by absolute /api/files/
+// path. Links by title keep working unchanged and are left alone, as are links
+// by a slug that other documents share and links in code. oldPath and newPath
+// are relative to the documents directory.
+func rewriteWikiLinks(oldPath, newPath string) {
+	cfg := config.Current()
+	oldPath = strings.Trim(filepath.ToSlash(oldPath), "/")
+	newPath = strings.Trim(filepath.ToSlash(newPath), "/")
+	if oldPath == "" || oldPath == newPath {
+		return
+	}
+	oldSlug, newSlug := path.Base(oldPath), path.Base(newPath)
+
+	documents, err := utils.ListDocuments(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir)
+	if err != nil {
+		log.Printf("Warning: Failed to list documents for wiki link rewrite: %v", err)
+		return
+	}
+
+	// A link by slug is rewritten only if it can't mean any other document,
+	// and to the new path where the new slug could
+	var others []goldext.WikiLinkTarget
+	for _, doc := range documents {
+		if strings.Trim(doc.Path, "/") != newPath {
+			others = append(others, goldext.WikiLinkTarget{Title: doc.Title, Path: doc.Path})
+		}
+	}
+	slugTarget := newSlug
+	if _, taken := goldext.ResolveWikiLink(newSlug, "", others); taken {
+		slugTarget = newPath
+	}
+
+	replaceTarget := func(target string) (string, bool) {
+		switch {
+		case strings.EqualFold(target, oldPath):
+			return newPath, true
+		case strings.EqualFold(target, "/"+oldPath):
+			return "/" + newPath, true
+		case len(target) > len(oldPath) && strings.EqualFold(target[:len(oldPath)+1], oldPath+"/"):
+			// A document below a moved category
+			return newPath + target[len(oldPath):], true
+		case strings.EqualFold(target, oldSlug) && oldSlug != newSlug:
+			if _, ambiguous := goldext.ResolveWikiLink(target, "", others); ambiguous {
+				return "", false
+			}
+			return slugTarget, true
+		}
+		return "", false
+	}
+
+	files := []string{utils.DocumentFile(homePageDir(cfg))}
+	for _, doc := range documents {
+		files = append(files, utils.DocumentFile(filepath.Join(cfg.Wiki.RootDir, cfg.Wiki.DocumentsDir, filepath.FromSlash(strings.TrimPrefix(doc.Path, "/")))))
+	}
+	for _, docFile := range files {
+		if err := rewriteLinksInFile(docFile, func(content string) string {
+			return goldext.ReplaceOutsideCode(content, func(text string) string {
+				text = replaceWikiLinks(text, replaceTarget)
+				return replaceAttachmentLinks(text, oldPath, newPath)
+			})
+		}); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to rewrite links in %s: %v", docFile, err)
+		}
+	}
+	invalidateWikiLinkIndex()
+}
+
+// replaceWikiLinks replaces wiki link targets for which replace returns true
+func replaceWikiLinks(content string, replace func(target string) (string, bool)) string {
+	if !strings.Contains(content, "[[") {
+		return content
+	}
+
+	return goldext.WikiLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := goldext.WikiLinkPattern.FindStringSubmatchIndex(match)
+		target := match[parts[2]:parts[3]]
+
+		anchor := ""
+		if idx := strings.Index(target, "#"); idx >= 0 {
+			anchor = target[idx:]
+			target = target[:idx]
+		}
+		replacement, ok := replace(strings.TrimSpace(target))
+		if !ok {
+			return match
+		}
+		return "[[" + replacement + anchor + match[parts[3]:]
+	})
+}
+
+// replaceAttachmentLinks points absolute references to attachments of the
+// document at oldPath, or of documents below it, at newPath. References are
+// the /api/files/ URLs that uploads insert, in markdown links and images,
+// reference definitions and HTML attributes; the path may be URL-escaped.
+func replaceAttachmentLinks(content, oldPath, newPath string) string {
+	if !strings.Contains(content, "/api/files/") {
+		return content
+	}
+
+	olds := []string{oldPath}
+	if escaped := escapePathSegments(oldPath); escaped != oldPath {
+		olds = append(olds, escaped)
+	}
+	for _, old := range olds {
+		// The reference must start a URL and old must be a whole path prefix
+		pattern := regexp.MustCompile(`(^|[\s(<"'=])/api/files/` + regexp.QuoteMeta(old) + `/`)
+		content = pattern.ReplaceAllString(content, "${1}/api/files/"+escapePathSegments(newPath)+"/")
+	}
+	return content
+}
+
+// escapePathSegments URL-escapes each segment of a slash-separated path
+func escapePathSegments(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// rewriteLinksInFile applies rewrite to the content of a document file under its
+// edit lock, writing it back only if it changed
+func rewriteLinksInFile(file string, rewrite func(content string) string) error {
+	unlock := doclock.Lock(file)
+	defer unlock()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	updated := rewrite(string(data))
+	if updated == string(data) {
+		return nil
+	}
+
+	// Rewriting links isn't an edit: keep the modification time that readers see as
+	// the last-edited date of documents without revision metadata
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if err := doclock.WriteFile(file, []byte(updated), 0644); err != nil {
+		return err
+	}
+	return os.Chtimes(file, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteWikiLinks(t *testing.T) {
+	const content = "[[setup]] and [[guides/setup#usage|Setup]], not `[[setup]]`\n\n```\n[[guides/setup]]\n```\n"
+	tests := []struct {
+		name string
+		docs []string
+		want string
+	}{
+		{
+			name: "unique slug",
+			docs: []string{"guides/setup", "notes"},
+			want: "[[install]] and [[manual/install#usage|Setup]], not `[[setup]]`\n\n```\n[[guides/setup]]\n```\n",
+		},
+		{
+			// [[setup]] may mean admin/setup, so it is left alone
+			name: "shared old slug",
+			docs: []string{"guides/setup", "admin/setup", "notes"},
+			want: "[[setup]] and [[manual/install#usage|Setup]], not `[[setup]]`\n\n```\n[[guides/setup]]\n```\n",
+		},
+		{
+			// [[install]] would mean admin/install as well
+			name: "shared new slug",
+			docs: []string{"guides/setup", "admin/install", "notes"},
+			want: "[[manual/install]] and [[manual/install#usage|Setup]], not `[[setup]]`\n\n```\n[[guides/setup]]\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg, _ := newMoveTestWiki(t, tt.docs...)
+			docsDir := filepath.Join(testCfg.Wiki.RootDir, "documents")
+			notes := filepath.Join(docsDir, "notes", "document.md")
+			if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Join(docsDir, "manual"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Rename(filepath.Join(docsDir, "guides", "setup"), filepath.Join(docsDir, "manual", "install")); err != nil {
+				t.Fatal(err)
+			}
+
+			rewriteWikiLinks("guides/setup", "manual/install")
+
+			data, err := os.ReadFile(notes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != tt.want {
+				t.Errorf("notes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
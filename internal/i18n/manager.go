@@ -7,10 +7,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 	"sync"
 	"wiki-go/internal/config"
 )
 
+// sourceLang is the catalog new keys are added to first
+const sourceLang = "en"
+
 // Regular expression to match placeholders like {{allowedTypes}}
 var placeholderRegex = regexp.MustCompile(`\{\{(\w+)\}\}`)
 
@@ -31,6 +35,15 @@ func NewTranslationManager(cfg *config.Config) *TranslationManager {
 	}
 }
 
+// currentConfig returns the running configuration, which a reload may have
+// replaced since the manager was created
+func (tm *TranslationManager) currentConfig() *config.Config {
+	if cfg := config.Current(); cfg != nil {
+		return cfg
+	}
+	return tm.config
+}
+
 // LoadTranslations loads translation files from the given directory
 func (tm *TranslationManager) LoadTranslations(rootDir string) error {
 	tm.mutex.Lock()
@@ -84,7 +97,7 @@ func (tm *TranslationManager) Translate(key string, langOverride ...string) stri
 	defer tm.mutex.RUnlock()
 
 	// Get current language from config
-	lang := tm.config.Wiki.Language
+	lang := tm.currentConfig().Wiki.Language
 
 	// Override with specified language if provided
 	if len(langOverride) > 0 && langOverride[0] != "" {
@@ -114,6 +127,13 @@ func (tm *TranslationManager) Translate(key string, langOverride ...string) stri
 		}
 	}
 
+	// English is the source catalog, so it has every key
+	if lang != sourceLang && tm.defaultLang != sourceLang {
+		if translation, found = tm.translations[sourceLang][key]; found {
+			return tm.processPlaceholders(translation)
+		}
+	}
+
 	// Return the key as a last resort
 	return key
 }
@@ -127,9 +147,9 @@ func (tm *TranslationManager) processPlaceholders(translation string) string {
 		// Handle specific placeholders
 		switch name {
 		case "allowedTypes":
-			return config.GetAllowedExtensionsDisplayText()
+			return strings.Join(config.GetAllowedUploadExtensions(tm.currentConfig()), ", ")
 		case "maxFileSize":
-			return config.GetMaxUploadSizeFormatted(tm.config)
+			return config.GetMaxAttachmentSizeFormatted(tm.currentConfig())
 		default:
 			return placeholder // Keep the placeholder if not recognized
 		}
@@ -0,0 +1,49 @@
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// T translates key into lang and formats the result with args, if any, using
+// fmt.Sprintf verbs. An empty lang uses the wiki's configured language. Missing
+// keys fall back to the configured language, then English. Catalogs are keyed,
+// so rename keys only together with every langs/*.json file.
+func T(lang, key string, args ...interface{}) string {
+	text := Translate(key, lang)
+	if len(args) > 0 {
+		text = fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// RequestLanguage picks the catalog for server-generated messages: the user's
+// preferred language if set and available, otherwise the best match for the
+// Accept-Language header. It returns "" when neither applies, which T treats as
+// the wiki's configured language.
+func RequestLanguage(r *http.Request, preferred string) string {
+	available := GetAvailableLanguages()
+	if len(available) == 0 {
+		return ""
+	}
+	if preferred != "" && slices.Contains(available, preferred) {
+		return preferred
+	}
+
+	accepted, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(accepted) == 0 {
+		return ""
+	}
+	tags := make([]language.Tag, len(available))
+	for i, lang := range available {
+		tags[i] = language.Make(lang)
+	}
+	_, index, confidence := language.NewMatcher(tags).Match(accepted...)
+	if confidence == language.No {
+		return ""
+	}
+	return available[index]
+}
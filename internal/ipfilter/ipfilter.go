@@ -0,0 +1,140 @@
+// Package ipfilter restricts access by client IP address using CIDR allow and deny lists.
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Filter decides whether a client address may access the wiki
+type Filter struct {
+	allowed    []netip.Prefix
+	denied     []netip.Prefix
+	trustProxy bool
+}
+
+// New builds a filter from CIDR strings. Plain addresses such as "10.0.0.5" are
+// accepted as single-host ranges. When trustProxy is set, the client address is
+// taken from X-Forwarded-For or X-Real-IP instead of the connection.
+func New(allowed, denied []string, trustProxy bool) (*Filter, error) {
+	allowedPrefixes, err := ParsePrefixes(allowed)
+	if err != nil {
+		return nil, err
+	}
+	deniedPrefixes, err := ParsePrefixes(denied)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{allowed: allowedPrefixes, denied: deniedPrefixes, trustProxy: trustProxy}, nil
+}
+
+// Enabled reports whether any allow or deny ranges are configured
+func (f *Filter) Enabled() bool {
+	return len(f.allowed) > 0 || len(f.denied) > 0
+}
+
+// Allowed reports whether addr may access the wiki. Deny ranges take precedence;
+// an empty allow list allows every address that isn't denied.
+func (f *Filter) Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range f.denied {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allowed) == 0 {
+		return true
+	}
+	for _, p := range f.allowed {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowRequest reports whether the request's client address may access the wiki.
+// Requests whose address cannot be determined are rejected.
+func (f *Filter) AllowRequest(r *http.Request) bool {
+	if !f.Enabled() {
+		return true
+	}
+	addr, ok := ClientAddr(r, f.trustProxy)
+	if !ok {
+		return false
+	}
+	return f.Allowed(addr)
+}
+
+// ParsePrefixes parses CIDR ranges and bare addresses
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+			}
+			if p.Addr().Is4In6() {
+				p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ClientAddr returns the address of the client that made the request. With
+// trustProxy, the right-most X-Forwarded-For entry (the one appended by the
+// proxy in front of the wiki) is used, then X-Real-IP, then the connection address.
+func ClientAddr(r *http.Request, trustProxy bool) (netip.Addr, bool) {
+	if trustProxy {
+		if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+			entries := strings.Split(strings.Join(xff, ","), ",")
+			for i := len(entries) - 1; i >= 0; i-- {
+				if addr, ok := parseAddr(entries[i]); ok {
+					return addr, true
+				}
+			}
+		}
+		if addr, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+			return addr, true
+		}
+	}
+
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	return parseAddr(host)
+}
+
+// parseAddr parses an address that may carry a port, brackets or an IPv6 zone
+func parseAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.WithZone("").Unmap(), true
+}
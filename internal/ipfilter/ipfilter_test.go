@@ -0,0 +1,199 @@
+package ipfilter
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestFilterAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		denied   []string
+		addr     string
+		expected bool
+	}{
+		{
+			name:     "No rules allows everything",
+			addr:     "203.0.113.7",
+			expected: true,
+		},
+		{
+			name:     "Inside allowed range",
+			allowed:  []string{"10.0.0.0/8"},
+			addr:     "10.1.2.3",
+			expected: true,
+		},
+		{
+			name:     "Outside allowed range",
+			allowed:  []string{"10.0.0.0/8"},
+			addr:     "192.168.1.1",
+			expected: false,
+		},
+		{
+			name:     "Denied range",
+			denied:   []string{"192.168.0.0/16"},
+			addr:     "192.168.4.20",
+			expected: false,
+		},
+		{
+			name:     "Deny takes precedence over allow",
+			allowed:  []string{"10.0.0.0/8"},
+			denied:   []string{"10.0.5.0/24"},
+			addr:     "10.0.5.9",
+			expected: false,
+		},
+		{
+			name:     "Single address without prefix length",
+			allowed:  []string{"198.51.100.4"},
+			addr:     "198.51.100.4",
+			expected: true,
+		},
+		{
+			name:     "Single address does not cover neighbours",
+			allowed:  []string{"198.51.100.4"},
+			addr:     "198.51.100.5",
+			expected: false,
+		},
+		{
+			name:     "IPv6 range",
+			allowed:  []string{"2001:db8::/32"},
+			addr:     "2001:db8:1::1",
+			expected: true,
+		},
+		{
+			name:     "IPv4-mapped IPv6 address matches IPv4 range",
+			allowed:  []string{"127.0.0.0/8"},
+			addr:     "::ffff:127.0.0.1",
+			expected: true,
+		},
+		{
+			name:     "Unnormalized CIDR is masked",
+			allowed:  []string{"10.1.2.3/16"},
+			addr:     "10.1.200.1",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.allowed, tt.denied, false)
+			if err != nil {
+				t.Fatalf("New returned error: %v", err)
+			}
+			result := f.Allowed(netip.MustParseAddr(tt.addr))
+			if result != tt.expected {
+				t.Errorf("Allowed(%s) = %v, expected %v", tt.addr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePrefixesInvalid(t *testing.T) {
+	for _, value := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0/8"} {
+		t.Run(value, func(t *testing.T) {
+			if _, err := ParsePrefixes([]string{value}); err == nil {
+				t.Errorf("Expected error for %q", value)
+			}
+		})
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		trustProxy bool
+		expected   string
+	}{
+		{
+			name:       "Connection address",
+			remoteAddr: "192.0.2.10:51234",
+			expected:   "192.0.2.10",
+		},
+		{
+			name:       "Forwarded header ignored without trusted proxy",
+			remoteAddr: "192.0.2.10:51234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5"},
+			expected:   "192.0.2.10",
+		},
+		{
+			name:       "Single forwarded address",
+			remoteAddr: "10.0.0.1:8080",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5"},
+			trustProxy: true,
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "Right-most forwarded address is used",
+			remoteAddr: "10.0.0.1:8080",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.5"},
+			trustProxy: true,
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "Invalid trailing entry is skipped",
+			remoteAddr: "10.0.0.1:8080",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5, unknown"},
+			trustProxy: true,
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "Forwarded address with port",
+			remoteAddr: "10.0.0.1:8080",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5:4711"},
+			trustProxy: true,
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "Forwarded IPv6 address in brackets",
+			remoteAddr: "10.0.0.1:8080",
+			headers:    map[string]string{"X-Forwarded-For": "[2001:db8::1]"},
+			trustProxy: true,
+			expected:   "2001:db8::1",
+		},
+		{
+			name:       "X-Real-IP fallback",
+			remoteAddr: "10.0.0.1:8080",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.9"},
+			trustProxy: true,
+			expected:   "203.0.113.9",
+		},
+		{
+			name:       "IPv6 connection address",
+			remoteAddr: "[2001:db8::2]:443",
+			expected:   "2001:db8::2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			addr, ok := ClientAddr(r, tt.trustProxy)
+			if !ok {
+				t.Fatalf("ClientAddr returned no address")
+			}
+			if addr.String() != tt.expected {
+				t.Errorf("Expected: %s, got: %s", tt.expected, addr)
+			}
+		})
+	}
+}
+
+func TestAllowRequestRejectsUnknownAddress(t *testing.T) {
+	f, err := New([]string{"10.0.0.0/8"}, nil, false)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "garbage"
+	if f.AllowRequest(r) {
+		t.Errorf("Expected request with unparsable address to be rejected")
+	}
+}
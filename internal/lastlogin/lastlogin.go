@@ -0,0 +1,128 @@
+// Package lastlogin records when each user last signed in, so admins can spot
+// dormant accounts and users can spot sign-ins that weren't theirs.
+//
+// The logins of all users are stored in <root>/logins.json, mapping usernames
+// to their latest two logins.
+package lastlogin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the logins file inside the wiki root
+const FileName = "logins.json"
+
+// mu serializes changes to logins files, which are read and written whole
+var mu sync.Mutex
+
+// Login is a successful sign-in
+type Login struct {
+	Time time.Time `json:"time"`
+	IP   string    `json:"ip,omitempty"` // Client address the user signed in from
+}
+
+// Record holds the latest logins of a user
+type Record struct {
+	Last     Login  `json:"last"`
+	Previous *Login `json:"previous,omitempty"` // The login before Last
+}
+
+// Store holds the logins of the users of one wiki
+type Store struct {
+	rootDir string
+}
+
+// New returns the logins of the wiki stored in rootDir
+func New(rootDir string) *Store {
+	return &Store{rootDir: rootDir}
+}
+
+func (s *Store) file() string {
+	return filepath.Join(s.rootDir, FileName)
+}
+
+// Record stores a login of username from ip, keeping the one before it as
+// the previous login
+func (s *Store) Record(username, ip string, at time.Time) error {
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	record := Record{Last: Login{Time: at.UTC(), IP: ip}}
+	if current, ok := all[username]; ok {
+		record.Previous = &current.Last
+	}
+	all[username] = record
+	return s.save(all)
+}
+
+// Get returns the logins of username. It reports false when the user hasn't
+// signed in since logins were recorded.
+func (s *Store) Get(username string) (Record, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return Record{}, false, err
+	}
+	record, ok := all[username]
+	return record, ok, nil
+}
+
+// All returns the logins of every user who signed in, by username
+func (s *Store) All() (map[string]Record, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return s.load()
+}
+
+// Remove forgets the logins of username, e.g. when the account is deleted
+func (s *Store) Remove(username string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[username]; !ok {
+		return nil
+	}
+	delete(all, username)
+	return s.save(all)
+}
+
+func (s *Store) load() (map[string]Record, error) {
+	all := make(map[string]Record)
+	data, err := os.ReadFile(s.file())
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (s *Store) save(all map[string]Record) error {
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.rootDir, 0755); err != nil {
+		return err
+	}
+	tmp := s.file() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file())
+}
@@ -0,0 +1,54 @@
+package lastlogin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	s := New(t.TempDir())
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	if _, ok, err := s.Get("ed"); ok || err != nil {
+		t.Fatalf("Get() before any login = %t, %v", ok, err)
+	}
+	if err := s.Record("ed", "10.0.0.1", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record("ed", "10.0.0.2", second); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record("vi", "", first); err != nil {
+		t.Fatal(err)
+	}
+
+	record, ok, err := s.Get("ed")
+	if !ok || err != nil {
+		t.Fatalf("Get() = %t, %v", ok, err)
+	}
+	if !record.Last.Time.Equal(second) || record.Last.IP != "10.0.0.2" {
+		t.Errorf("last login = %+v", record.Last)
+	}
+	if record.Previous == nil || !record.Previous.Time.Equal(first) || record.Previous.IP != "10.0.0.1" {
+		t.Errorf("previous login = %+v", record.Previous)
+	}
+
+	all, err := s.All()
+	if err != nil || len(all) != 2 {
+		t.Fatalf("All() = %v, %v", all, err)
+	}
+	if all["vi"].Previous != nil {
+		t.Errorf("first login has a previous login: %+v", all["vi"].Previous)
+	}
+
+	if err := s.Remove("ed"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get("ed"); ok {
+		t.Error("removed user still has logins")
+	}
+	if _, ok, _ := s.Get("vi"); !ok {
+		t.Error("removing a user dropped the logins of another")
+	}
+}
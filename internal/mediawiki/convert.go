@@ -0,0 +1,598 @@
+package mediawiki
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Converter turns wikitext into markdown. Constructs without a markdown
+// equivalent, such as templates, are kept as text or dropped, and reported.
+type Converter struct {
+	// Namespaces maps lowercased namespace names to their keys (see Dump.Namespaces)
+	Namespaces map[string]int
+	// Link returns the wiki path, e.g. "guides/setup", of the page with a main namespace title
+	Link func(title string) string
+}
+
+var (
+	commentPattern     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	nowikiPattern      = regexp.MustCompile(`(?is)<nowiki>(.*?)</nowiki>|<nowiki\s*/>`)
+	prePattern         = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	highlightPattern   = regexp.MustCompile(`(?is)<(?:syntaxhighlight|source)([^>]*)>(.*?)</(?:syntaxhighlight|source)>`)
+	langAttrPattern    = regexp.MustCompile(`(?i)\blang\s*=\s*"?([\w+#-]+)`)
+	codePattern        = regexp.MustCompile(`(?is)<code>(.*?)</code>`)
+	rawBlockPattern    = regexp.MustCompile(`(?is)<(gallery|math|poem|timeline|score)[^>]*>.*?</(?:gallery|math|poem|timeline|score)>`)
+	refPattern         = regexp.MustCompile(`(?is)<ref(\s[^>]*?)?>(.*?)</ref>`)
+	refSelfPattern     = regexp.MustCompile(`(?i)<ref(\s[^>]*?)/>`)
+	refNamePattern     = regexp.MustCompile(`(?i)\bname\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s/>]+))`)
+	referencesPattern  = regexp.MustCompile(`(?is)<references\s*/>|<references[^>]*>.*?</references>|\{\{\s*reflist[^{}]*\}\}`)
+	headingPattern     = regexp.MustCompile(`^(=+)\s*(.*?)\s*(=+)\s*$`)
+	listPattern        = regexp.MustCompile(`^([*#:;]+)\s*(.*)$`)
+	placeholderPattern = regexp.MustCompile("\x00(\\d+)\x00")
+	blockPattern       = regexp.MustCompile("^\x00(\\d+)\x00$")
+	internalPattern    = regexp.MustCompile(`\[\[([^\[\]]+?)\]\]([a-z]*)`)
+	externalPattern    = regexp.MustCompile(`\[((?:https?|ftp|mailto):[^\s\]]+)(?:\s+([^\]]*))?\]`)
+	boldItalicPattern  = regexp.MustCompile(`'''''(.+?)'''''`)
+	boldPattern        = regexp.MustCompile(`'''(.+?)'''`)
+	italicPattern      = regexp.MustCompile(`''(.+?)''`)
+	strikePattern      = regexp.MustCompile(`(?i)</?(?:s|del|strike)>`)
+	magicWordPattern   = regexp.MustCompile(`__[A-Z]+__`)
+	imageExtPattern    = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|svg|webp|bmp)$`)
+	imageOptionPattern = regexp.MustCompile(`^(thumb|thumbnail|frame|frameless|border|left|right|center|centre|none|upright(\s*=?\s*[\d.]+)?|baseline|sub|super|top|text-top|middle|bottom|text-bottom|\d*x?\d+px|(alt|link|page|class|lang)=.*)$`)
+)
+
+// conversion holds the state of one Convert call
+type conversion struct {
+	c      *Converter
+	title  string
+	held   []string     // Text set aside from conversion, see hold
+	blocks map[int]bool // Held texts that are blocks of their own
+	notes  []string
+	noted  map[string]bool
+	refs   []string // Footnote texts
+	named  map[string]int
+}
+
+// Convert returns the markdown for the wikitext of the page titled title, and
+// notes on what it could not convert
+func (c *Converter) Convert(title, wikitext string) (string, []string) {
+	cv := &conversion{c: c, title: title, blocks: map[int]bool{}, noted: map[string]bool{}, named: map[string]int{}}
+
+	text := strings.ReplaceAll(wikitext, "\r\n", "\n")
+	text = commentPattern.ReplaceAllString(text, "")
+
+	// Set aside what must not be converted
+	text = nowikiPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return cv.hold(nowikiPattern.FindStringSubmatch(m)[1], false)
+	})
+	text = highlightPattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := highlightPattern.FindStringSubmatch(m)
+		lang := ""
+		if l := langAttrPattern.FindStringSubmatch(parts[1]); l != nil {
+			lang = strings.ToLower(l[1])
+		}
+		return "\n" + cv.hold(fence(parts[2], lang), true) + "\n"
+	})
+	text = prePattern.ReplaceAllStringFunc(text, func(m string) string {
+		return "\n" + cv.hold(fence(prePattern.FindStringSubmatch(m)[1], ""), true) + "\n"
+	})
+	text = codePattern.ReplaceAllStringFunc(text, func(m string) string {
+		return cv.hold(codeSpan(codePattern.FindStringSubmatch(m)[1]), false)
+	})
+	text = rawBlockPattern.ReplaceAllStringFunc(text, func(m string) string {
+		cv.note("<%s> kept as HTML", rawBlockPattern.FindStringSubmatch(m)[1])
+		return cv.hold(m, false)
+	})
+
+	// References become footnotes, listed at the end
+	text = referencesPattern.ReplaceAllString(text, "")
+	text = refPattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := refPattern.FindStringSubmatch(m)
+		return cv.footnote(refName(parts[1]), parts[2])
+	})
+	text = refSelfPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return cv.footnote(refName(refSelfPattern.FindStringSubmatch(m)[1]), "")
+	})
+
+	text = cv.holdTemplates(text)
+
+	var out blockWriter
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			out.blank()
+
+		case strings.HasPrefix(trimmed, "{|"):
+			end := tableEnd(lines, i)
+			out.block("table", cv.table(lines[i:end+1]))
+			i = end
+
+		case blockPattern.MatchString(trimmed) && cv.isBlock(trimmed):
+			out.block("code", trimmed)
+
+		case strings.HasPrefix(line, " "):
+			// Lines starting with a space are preformatted
+			var pre []string
+			for ; i < len(lines) && strings.HasPrefix(lines[i], " ") && strings.TrimSpace(lines[i]) != ""; i++ {
+				pre = append(pre, lines[i][1:])
+			}
+			i--
+			out.block("code", fence(strings.Join(pre, "\n"), ""))
+
+		case headingPattern.MatchString(trimmed):
+			parts := headingPattern.FindStringSubmatch(trimmed)
+			level := min(len(parts[1]), len(parts[3]), 6)
+			out.block("heading", strings.Repeat("#", level)+" "+cv.inline(parts[2], false))
+
+		case strings.HasPrefix(trimmed, "----"):
+			out.block("rule", "---")
+
+		case trimmed == "__TOC__":
+			out.block("toc", "[toc]")
+
+		case listPattern.MatchString(line):
+			parts := listPattern.FindStringSubmatch(line)
+			cv.listItem(&out, parts[1], parts[2])
+
+		default:
+			out.paragraph(cv.inline(trimmed, false))
+		}
+	}
+
+	markdown := out.String()
+	if len(cv.refs) > 0 {
+		var notes strings.Builder
+		for i, ref := range cv.refs {
+			fmt.Fprintf(&notes, "[^%d]: %s\n", i+1, cv.inline(strings.Join(strings.Fields(ref), " "), false))
+		}
+		markdown += "\n" + notes.String()
+	}
+	return cv.restore(markdown), cv.notes
+}
+
+// hold sets text aside and returns a placeholder restored after conversion
+func (cv *conversion) hold(text string, block bool) string {
+	cv.held = append(cv.held, text)
+	if block {
+		cv.blocks[len(cv.held)-1] = true
+	}
+	return "\x00" + strconv.Itoa(len(cv.held)-1) + "\x00"
+}
+
+func (cv *conversion) isBlock(placeholder string) bool {
+	n, _ := strconv.Atoi(strings.Trim(placeholder, "\x00"))
+	return cv.blocks[n]
+}
+
+// restore puts held texts back in place of their placeholders
+func (cv *conversion) restore(text string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(m string) string {
+		n, _ := strconv.Atoi(strings.Trim(m, "\x00"))
+		return cv.restore(cv.held[n])
+	})
+}
+
+func (cv *conversion) note(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !cv.noted[msg] {
+		cv.noted[msg] = true
+		cv.notes = append(cv.notes, msg)
+	}
+}
+
+// footnote returns the marker of a reference, numbering named references once
+func (cv *conversion) footnote(name, text string) string {
+	if name != "" {
+		if n, ok := cv.named[name]; ok {
+			if cv.refs[n-1] == "" {
+				cv.refs[n-1] = text
+			}
+			return "[^" + strconv.Itoa(n) + "]"
+		}
+	}
+	cv.refs = append(cv.refs, text)
+	n := len(cv.refs)
+	if name != "" {
+		cv.named[name] = n
+	}
+	return "[^" + strconv.Itoa(n) + "]"
+}
+
+func refName(attrs string) string {
+	m := refNamePattern.FindStringSubmatch(attrs)
+	if m == nil {
+		return ""
+	}
+	return m[1] + m[2] + m[3]
+}
+
+// holdTemplates sets aside {{templates}}, {{{parameters}}} and {{#parser functions}},
+// which have no markdown equivalent, keeping their text
+func (cv *conversion) holdTemplates(text string) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			out.WriteString(text)
+			return out.String()
+		}
+		end, depth := start, 0
+		for end < len(text) {
+			switch {
+			case strings.HasPrefix(text[end:], "{{"):
+				depth++
+				end += 2
+			case strings.HasPrefix(text[end:], "}}"):
+				depth--
+				end += 2
+			default:
+				end++
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if depth != 0 {
+			// Unbalanced; leave the rest alone
+			out.WriteString(text)
+			return out.String()
+		}
+
+		template := text[start:end]
+		name := strings.TrimSpace(strings.Trim(strings.SplitN(strings.Trim(template, "{}"), "|", 2)[0], " \n"))
+		switch {
+		case strings.HasPrefix(name, "#"):
+			cv.note("parser function {{%s}} kept as text", strings.SplitN(name, ":", 2)[0])
+		case strings.HasPrefix(template, "{{{"):
+			cv.note("template parameter %s kept as text", cv.restore(template))
+		default:
+			cv.note("template {{%s}} kept as text", name)
+		}
+		out.WriteString(text[:start])
+		out.WriteString(cv.hold(template, false))
+		text = text[end:]
+	}
+}
+
+// listItem writes one line of a list, definition list or indented text
+func (cv *conversion) listItem(out *blockWriter, prefix, text string) {
+	last := prefix[len(prefix)-1]
+	text = strings.TrimSpace(text)
+
+	// ";term : definition" on one line
+	if last == ';' {
+		term, definition, found := strings.Cut(text, " : ")
+		out.block("definition", cv.inline(strings.TrimSpace(term), false))
+		if found {
+			out.line(": " + cv.inline(strings.TrimSpace(definition), false))
+		}
+		return
+	}
+	if prefix == ":" && out.kind == "definition" {
+		out.line(": " + cv.inline(text, false))
+		return
+	}
+	if strings.Trim(prefix, ":") == "" {
+		// Indentation only
+		out.block("quote", strings.Repeat("> ", len(prefix))+cv.inline(text, false))
+		return
+	}
+
+	indent := ""
+	for _, c := range prefix[:len(prefix)-1] {
+		if c == '#' {
+			indent += "   "
+		} else {
+			indent += "  "
+		}
+	}
+	switch last {
+	case '*':
+		out.block("list", indent+"- "+cv.inline(text, false))
+	case '#':
+		out.block("list", indent+"1. "+cv.inline(text, false))
+	default:
+		// "*:" continues the item above
+		out.block("list", indent+"  "+cv.inline(text, false))
+	}
+}
+
+// inline converts the markup within a line. Links in tables are plain
+// markdown links, whose "|"-free syntax doesn't end the cell.
+func (cv *conversion) inline(text string, inTable bool) string {
+	text = magicWordPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if m == "__TOC__" {
+			return "[toc]"
+		}
+		return ""
+	})
+	if strings.Contains(text, "~~~") {
+		cv.note("signature ~~~~ kept as text")
+	}
+
+	text = internalPattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := internalPattern.FindStringSubmatch(m)
+		return cv.internalLink(parts[1], parts[2], inTable)
+	})
+	text = externalPattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := externalPattern.FindStringSubmatch(m)
+		if label := strings.TrimSpace(parts[2]); label != "" {
+			return "[" + escapeLabel(label) + "](" + parts[1] + ")"
+		}
+		return "<" + parts[1] + ">"
+	})
+
+	text = boldItalicPattern.ReplaceAllString(text, "***$1***")
+	text = boldPattern.ReplaceAllString(text, "**$1**")
+	text = italicPattern.ReplaceAllString(text, "*$1*")
+	text = strikePattern.ReplaceAllString(text, "~~")
+	return text
+}
+
+// internalLink converts [[target|options]] followed by trail, the letters
+// MediaWiki includes in the link text
+func (cv *conversion) internalLink(inner, trail string, inTable bool) string {
+	target, options, _ := strings.Cut(inner, "|")
+	target = strings.TrimSpace(target)
+
+	// [[:Category:X]] links to the category instead of adding the page to it
+	colon := strings.HasPrefix(target, ":")
+	target = strings.TrimPrefix(target, ":")
+
+	if prefix, rest, ok := strings.Cut(target, ":"); ok {
+		if ns, known := cv.c.Namespaces[strings.ToLower(strings.TrimSpace(prefix))]; known {
+			switch {
+			case ns == NamespaceCategory && !colon:
+				cv.note("category %s dropped", strings.TrimSpace(rest))
+				return ""
+			case (ns == NamespaceFile || ns == NamespaceMedia) && !colon:
+				return cv.fileLink(strings.TrimSpace(rest), options)
+			default:
+				// Pages outside the main namespace are not imported
+				cv.note("link to %s kept as text", target)
+				if options != "" {
+					return options + trail
+				}
+				return target + trail
+			}
+		}
+	}
+
+	title, anchor, _ := strings.Cut(target, "#")
+	title = strings.TrimSpace(title)
+	label := strings.TrimSpace(options)
+	if label == "" {
+		label = strings.TrimPrefix(target, "/")
+	}
+	label += trail
+
+	if anchor != "" {
+		anchor = "#" + headingID(anchor)
+	}
+	if title == "" {
+		return "[" + escapeLabel(label) + "](" + anchor + ")"
+	}
+
+	// Subpage links are relative to the current page
+	if strings.HasPrefix(title, "/") {
+		title = cv.title + strings.TrimSuffix(title, "/")
+	} else if strings.HasPrefix(title, "../") {
+		title = path.Join(cv.title, title)
+	}
+	linkPath := cv.c.Link(title)
+	if inTable {
+		return "[" + escapeLabel(label) + "](/" + strings.ReplaceAll(linkPath, " ", "%20") + anchor + ")"
+	}
+	return "[[" + linkPath + anchor + "|" + label + "]]"
+}
+
+// fileLink converts [[File:name|options|caption]] to an image or a link to
+// the attachment. The file itself is not part of the dump.
+func (cv *conversion) fileLink(name, options string) string {
+	cv.note("file %s needs to be uploaded as an attachment", name)
+	caption := ""
+	if options != "" {
+		for _, option := range strings.Split(options, "|") {
+			option = strings.TrimSpace(option)
+			if option != "" && !imageOptionPattern.MatchString(option) {
+				caption = option
+			}
+		}
+	}
+	dest := strings.ReplaceAll(strings.ReplaceAll(name, "_", " "), " ", "%20")
+	if imageExtPattern.MatchString(name) {
+		return "![" + escapeLabel(caption) + "](" + dest + ")"
+	}
+	if caption == "" {
+		caption = name
+	}
+	return "[" + escapeLabel(caption) + "](" + dest + ")"
+}
+
+// tableEnd returns the index of the line closing the table opened at lines[start]
+func tableEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "{|") {
+			depth++
+		} else if strings.HasPrefix(trimmed, "|}") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(lines) - 1
+}
+
+// table converts a wikitext table to a markdown table. The first row is the
+// header. Nested tables are kept as text.
+func (cv *conversion) table(lines []string) string {
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(strings.TrimSpace(line), "{|") {
+			cv.note("nested table kept as text")
+			return cv.hold(strings.Join(lines, "\n"), true)
+		}
+	}
+
+	var caption string
+	var rows [][]string
+	var row []string
+	endRow := func() {
+		if len(row) > 0 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	addCells := func(text, separator string) {
+		for _, cell := range strings.Split(text, separator) {
+			row = append(row, cv.cell(cell))
+		}
+	}
+
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "|}"):
+		case strings.HasPrefix(trimmed, "|+"):
+			caption = cv.cell(trimmed[2:])
+		case strings.HasPrefix(trimmed, "|-"):
+			endRow()
+		case strings.HasPrefix(trimmed, "!"):
+			addCells(strings.ReplaceAll(trimmed[1:], "||", "!!"), "!!")
+		case strings.HasPrefix(trimmed, "|"):
+			addCells(trimmed[1:], "||")
+		case trimmed != "" && len(row) > 0:
+			// A cell's text continues on the next line
+			row[len(row)-1] = strings.TrimSpace(row[len(row)-1] + " " + cv.cell(trimmed))
+		}
+	}
+	endRow()
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, r := range rows {
+		columns = max(columns, len(r))
+	}
+	var b strings.Builder
+	if caption != "" {
+		b.WriteString("**" + caption + "**\n\n")
+	}
+	for i, r := range rows {
+		for len(r) < columns {
+			r = append(r, "")
+		}
+		b.WriteString("| " + strings.Join(r, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// cell converts the text of a table cell, dropping its attributes
+func (cv *conversion) cell(text string) string {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "[["):
+			depth++
+		case strings.HasPrefix(text[i:], "]]"):
+			depth--
+		case text[i] == '|' && depth == 0:
+			if attrs := text[:i]; strings.Contains(attrs, "=") {
+				if strings.Contains(attrs, "rowspan") || strings.Contains(attrs, "colspan") {
+					cv.note("table cells spanning rows or columns were split")
+				}
+				text = text[i+1:]
+			}
+			i = len(text)
+		}
+	}
+	text = cv.restore(cv.inline(strings.TrimSpace(text), true))
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// fence returns code as a fenced code block
+func fence(code, lang string) string {
+	code = strings.Trim(code, "\n")
+	marker := "```"
+	for strings.Contains(code, marker) {
+		marker += "`"
+	}
+	return marker + lang + "\n" + code + "\n" + marker
+}
+
+// codeSpan returns code as an inline code span
+func codeSpan(code string) string {
+	marker := "`"
+	for strings.Contains(code, marker) {
+		marker += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return marker + " " + code + " " + marker
+	}
+	return marker + code + marker
+}
+
+// headingID turns a section name into the anchor of the converted heading
+func headingID(section string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(section, "_", " "))), "-")
+}
+
+func escapeLabel(label string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(label)
+}
+
+// blockWriter assembles markdown blocks, separating blocks of different kinds
+// with a blank line and joining the lines of a paragraph
+type blockWriter struct {
+	b    strings.Builder
+	kind string // Kind of the last block; "" after a blank line
+}
+
+func (w *blockWriter) blank() {
+	w.kind = ""
+}
+
+// block starts a block of kind, or continues the last one if it is the same kind
+func (w *blockWriter) block(kind, text string) {
+	if w.b.Len() > 0 {
+		if kind != w.kind || kind == "code" || kind == "table" || kind == "heading" {
+			w.b.WriteString("\n\n")
+		} else {
+			w.b.WriteString("\n")
+		}
+	}
+	w.b.WriteString(text)
+	w.kind = kind
+}
+
+// line adds a line to the current block
+func (w *blockWriter) line(text string) {
+	w.b.WriteString("\n" + text)
+}
+
+// paragraph adds text to the current paragraph, as MediaWiki joins the lines of one
+func (w *blockWriter) paragraph(text string) {
+	if w.kind == "paragraph" {
+		w.b.WriteString(" " + text)
+		return
+	}
+	w.block("paragraph", text)
+}
+
+func (w *blockWriter) String() string {
+	return strings.TrimSpace(w.b.String()) + "\n"
+}
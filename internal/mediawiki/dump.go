@@ -0,0 +1,119 @@
+// Package mediawiki reads MediaWiki XML dumps, as written by Special:Export or
+// dumpBackup.php, and converts their wikitext to markdown.
+package mediawiki
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Namespace keys with a fixed meaning in every MediaWiki installation
+const (
+	NamespaceMedia    = -2
+	NamespaceMain     = 0
+	NamespaceFile     = 6
+	NamespaceCategory = 14
+)
+
+// ErrNotDump is returned when the input is XML but not a MediaWiki export
+var ErrNotDump = errors.New("not a MediaWiki XML dump")
+
+// Page is a page of the dump at its latest revision
+type Page struct {
+	Title     string
+	Namespace int
+	Redirect  string // Target title when the page is a redirect
+	Text      string // Wikitext
+}
+
+type xmlPage struct {
+	Title     string `xml:"title"`
+	Namespace int    `xml:"ns"`
+	Redirect  *struct {
+		Title string `xml:"title,attr"`
+	} `xml:"redirect"`
+	Revisions []struct {
+		Text string `xml:"text"`
+	} `xml:"revision"`
+}
+
+// Dump reads the pages of a dump one at a time
+type Dump struct {
+	dec        *xml.Decoder
+	started    bool
+	namespaces map[string]int
+}
+
+// NewDump returns a Dump reading from r
+func NewDump(r io.Reader) *Dump {
+	return &Dump{
+		dec: xml.NewDecoder(r),
+		namespaces: map[string]int{
+			"media":    NamespaceMedia,
+			"file":     NamespaceFile,
+			"image":    NamespaceFile,
+			"category": NamespaceCategory,
+		},
+	}
+}
+
+// Namespaces maps lowercased namespace names to their keys. It holds the
+// canonical names of the media, file and category namespaces, and the names
+// declared in the dump once the first page has been read.
+func (d *Dump) Namespaces() map[string]int {
+	return d.namespaces
+}
+
+// Next returns the next page, or io.EOF after the last one
+func (d *Dump) Next() (*Page, error) {
+	for {
+		tok, err := d.dec.Token()
+		if err == io.EOF && !d.started {
+			return nil, ErrNotDump
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !d.started {
+			if start.Name.Local != "mediawiki" {
+				return nil, ErrNotDump
+			}
+			d.started = true
+			continue
+		}
+
+		switch start.Name.Local {
+		case "namespace":
+			var ns struct {
+				Key  int    `xml:"key,attr"`
+				Name string `xml:",chardata"`
+			}
+			if err := d.dec.DecodeElement(&ns, &start); err != nil {
+				return nil, err
+			}
+			if ns.Name != "" {
+				d.namespaces[strings.ToLower(ns.Name)] = ns.Key
+			}
+		case "page":
+			var p xmlPage
+			if err := d.dec.DecodeElement(&p, &start); err != nil {
+				return nil, err
+			}
+			page := &Page{Title: p.Title, Namespace: p.Namespace}
+			if p.Redirect != nil {
+				page.Redirect = p.Redirect.Title
+			}
+			// Revisions are in chronological order
+			if n := len(p.Revisions); n > 0 {
+				page.Text = p.Revisions[n-1].Text
+			}
+			return page, nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package mediawiki
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	c := &Converter{
+		Namespaces: map[string]int{"file": NamespaceFile, "image": NamespaceFile, "category": NamespaceCategory},
+		Link: func(title string) string {
+			return "wiki/" + strings.ToLower(strings.ReplaceAll(title, " ", "-"))
+		},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		notes []string
+	}{
+		{
+			name:  "headings and emphasis",
+			input: "== Setup ==\nSome '''bold''' and ''italic'' text.",
+			want:  "## Setup\n\nSome **bold** and *italic* text.\n",
+		},
+		{
+			name:  "internal links",
+			input: "See [[Main Page]] and [[Install guide#Linux|installing]].",
+			want:  "See [[wiki/main-page|Main Page]] and [[wiki/install-guide#linux|installing]].\n",
+		},
+		{
+			name:  "lists",
+			input: "* one\n** two",
+			want:  "- one\n  - two\n",
+		},
+		{
+			name:  "table",
+			input: "{| class=\"wikitable\"\n! A !! B\n|-\n| 1 || 2\n|}",
+			want:  "| A | B |\n| --- | --- |\n| 1 | 2 |\n",
+		},
+		{
+			name:  "references",
+			input: "Fact.<ref>Source</ref>\n\n<references/>",
+			want:  "Fact.[^1]\n\n[^1]: Source\n",
+		},
+		{
+			name:  "code",
+			input: "<syntaxhighlight lang=\"go\">\nfmt.Println(1)\n</syntaxhighlight>",
+			want:  "```go\nfmt.Println(1)\n```\n",
+		},
+		{
+			name:  "external link",
+			input: "[https://example.com Example]",
+			want:  "[Example](https://example.com)\n",
+		},
+		{
+			name:  "template",
+			input: "{{Infobox|x=1}}",
+			want:  "{{Infobox|x=1}}\n",
+			notes: []string{"template {{Infobox}} kept as text"},
+		},
+		{
+			name:  "files and categories",
+			input: "[[Category:Guides]]\n[[File:Logo.png|thumb|The logo]]",
+			want:  "![The logo](Logo.png)\n",
+			notes: []string{"category Guides dropped", "file Logo.png needs to be uploaded as an attachment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes := c.Convert("Page", tt.input)
+			if got != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(notes, tt.notes) {
+				t.Errorf("notes = %q, want %q", notes, tt.notes)
+			}
+		})
+	}
+}
+
+func TestDump(t *testing.T) {
+	const xml = `<mediawiki xmlns="http://www.mediawiki.org/xml/export-0.11/">
+  <siteinfo>
+    <namespaces>
+      <namespace key="0" case="first-letter" />
+      <namespace key="6" case="first-letter">Datei</namespace>
+    </namespaces>
+  </siteinfo>
+  <page>
+    <title>Main Page</title>
+    <ns>0</ns>
+    <revision><text>old</text></revision>
+    <revision><text>new</text></revision>
+  </page>
+  <page>
+    <title>Home</title>
+    <ns>0</ns>
+    <redirect title="Main Page" />
+    <revision><text>#REDIRECT [[Main Page]]</text></revision>
+  </page>
+</mediawiki>`
+
+	dump := NewDump(strings.NewReader(xml))
+	var pages []Page
+	for {
+		page, err := dump.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, *page)
+	}
+
+	want := []Page{
+		{Title: "Main Page", Namespace: NamespaceMain, Text: "new"},
+		{Title: "Home", Namespace: NamespaceMain, Redirect: "Main Page", Text: "#REDIRECT [[Main Page]]"},
+	}
+	if !slices.Equal(pages, want) {
+		t.Errorf("pages = %+v, want %+v", pages, want)
+	}
+	if got := dump.Namespaces()["datei"]; got != NamespaceFile {
+		t.Errorf("namespace datei = %d, want %d", got, NamespaceFile)
+	}
+
+	if _, err := NewDump(strings.NewReader("<html></html>")).Next(); err != ErrNotDump {
+		t.Errorf("Next() on HTML = %v, want ErrNotDump", err)
+	}
+}
@@ -0,0 +1,59 @@
+package migration
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// StorageDir is a data directory of the wiki, such as the version history,
+// whose location can change between releases or through the configuration
+type StorageDir struct {
+	Path     string   // Where the data is kept now
+	Previous []string // Where it may have been kept before, most likely first
+}
+
+// MoveStorageDirs moves the data of each directory from its first previous
+// location that exists to its path, unless its path exists already. This keeps
+// the history, comments and trash of existing installs when their directories
+// are renamed in the configuration.
+func MoveStorageDirs(dirs []StorageDir) error {
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir.Path); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		for _, previous := range dir.Previous {
+			if samePath(previous, dir.Path) {
+				continue
+			}
+			info, err := os.Stat(previous)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+
+			log.Printf("Moving %s to %s", previous, dir.Path)
+			if err := os.MkdirAll(filepath.Dir(dir.Path), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(previous, dir.Path); err != nil {
+				return fmt.Errorf("failed to move %s to %s, move it by hand: %w", previous, dir.Path, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// samePath reports whether a and b name the same location
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveStorageDirs(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string          // Directories holding a file named after them
+		path     string            // StorageDir.Path
+		previous []string          // StorageDir.Previous
+		want     map[string]string // File expected in each directory afterwards
+	}{
+		{"renamed", []string{"versions"}, "history", []string{"versions"},
+			map[string]string{"history": "versions"}},
+		{"first previous location wins", []string{"old", "older"}, "new", []string{"old", "older"},
+			map[string]string{"new": "old", "older": "older"}},
+		{"falls back to a later location", []string{"older"}, "new", []string{"old", "older"},
+			map[string]string{"new": "older"}},
+		{"already in place", []string{"history", "versions"}, "history", []string{"versions"},
+			map[string]string{"history": "history", "versions": "versions"}},
+		{"unchanged name", []string{"versions"}, "versions", []string{"versions"},
+			map[string]string{"versions": "versions"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, dir := range tt.existing {
+				if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(root, dir, dir), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dir := StorageDir{Path: filepath.Join(root, tt.path)}
+			for _, previous := range tt.previous {
+				dir.Previous = append(dir.Previous, filepath.Join(root, previous))
+			}
+
+			if err := MoveStorageDirs([]StorageDir{dir}); err != nil {
+				t.Fatal(err)
+			}
+			for dir, file := range tt.want {
+				if _, err := os.Stat(filepath.Join(root, dir, file)); err != nil {
+					t.Errorf("%s/%s: %v", dir, file, err)
+				}
+			}
+		})
+	}
+}
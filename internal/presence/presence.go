@@ -0,0 +1,116 @@
+// Package presence tracks which users have a document open in the editor.
+//
+// Presence is advisory: editors announce themselves with periodic heartbeats
+// and are forgotten once they stop, so others can be told that someone else is
+// editing the same page. It doesn't keep anyone from saving; conflicting saves
+// are still caught by the ETag check.
+package presence
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Editor is a user editing a document
+type Editor struct {
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`    // When the user started editing
+	LastSeen time.Time `json:"lastSeen"` // Time of the last heartbeat
+}
+
+// Tracker keeps the editors of each document until their heartbeats stop for
+// longer than its TTL. It is safe for concurrent use.
+type Tracker struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	editors map[string]map[string]*Editor // Document path, then username
+}
+
+// New returns a tracker that forgets editors ttl after their last heartbeat
+func New(ttl time.Duration) *Tracker {
+	return &Tracker{ttl: ttl, now: time.Now, editors: make(map[string]map[string]*Editor)}
+}
+
+// Heartbeat records that username is editing docPath and returns the other
+// users editing it
+func (t *Tracker) Heartbeat(docPath, username string) []Editor {
+	docPath = normalize(docPath)
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+	editors := t.editors[docPath]
+	if editors == nil {
+		editors = make(map[string]*Editor)
+		t.editors[docPath] = editors
+	}
+	if e := editors[username]; e != nil {
+		e.LastSeen = now
+	} else {
+		editors[username] = &Editor{Username: username, Since: now, LastSeen: now}
+	}
+	return list(editors, username)
+}
+
+// Leave forgets that username is editing docPath
+func (t *Tracker) Leave(docPath, username string) {
+	docPath = normalize(docPath)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.editors[docPath], username)
+	if len(t.editors[docPath]) == 0 {
+		delete(t.editors, docPath)
+	}
+}
+
+// Editors returns the users editing docPath, longest editing first
+func (t *Tracker) Editors(docPath string) []Editor {
+	docPath = normalize(docPath)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(t.now())
+	return list(t.editors[docPath], "")
+}
+
+// expire drops the editors whose last heartbeat is older than the TTL
+func (t *Tracker) expire(now time.Time) {
+	for docPath, editors := range t.editors {
+		for username, e := range editors {
+			if now.Sub(e.LastSeen) > t.ttl {
+				delete(editors, username)
+			}
+		}
+		if len(editors) == 0 {
+			delete(t.editors, docPath)
+		}
+	}
+}
+
+// list returns copies of editors other than except, longest editing first
+func list(editors map[string]*Editor, except string) []Editor {
+	result := []Editor{}
+	for username, e := range editors {
+		if username != except {
+			result = append(result, *e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Since.Equal(result[j].Since) {
+			return result[i].Since.Before(result[j].Since)
+		}
+		return result[i].Username < result[j].Username
+	})
+	return result
+}
+
+// normalize returns docPath as a URL path with a leading and no trailing slash
+func normalize(docPath string) string {
+	return "/" + strings.Trim(docPath, "/")
+}
@@ -0,0 +1,58 @@
+package presence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := New(30 * time.Second)
+	tracker.now = func() time.Time { return now }
+
+	if others := tracker.Heartbeat("/guides/setup", "alice"); len(others) != 0 {
+		t.Errorf("first heartbeat: others = %+v, want none", others)
+	}
+
+	now = now.Add(10 * time.Second)
+	others := tracker.Heartbeat("guides/setup/", "bob")
+	if len(others) != 1 || others[0].Username != "alice" {
+		t.Errorf("bob's heartbeat: others = %+v, want alice", others)
+	}
+
+	editors := tracker.Editors("/guides/setup")
+	if len(editors) != 2 || editors[0].Username != "alice" || editors[1].Username != "bob" {
+		t.Errorf("editors = %+v, want alice then bob", editors)
+	}
+	if len(tracker.Editors("/guides")) != 0 {
+		t.Error("editors of another document listed")
+	}
+
+	// Alice stops sending heartbeats, bob keeps going
+	now = now.Add(25 * time.Second)
+	tracker.Heartbeat("/guides/setup", "bob")
+	if editors := tracker.Editors("/guides/setup"); len(editors) != 1 || editors[0].Username != "bob" {
+		t.Errorf("after alice's TTL: editors = %+v, want bob", editors)
+	}
+
+	tracker.Leave("/guides/setup", "bob")
+	if editors := tracker.Editors("/guides/setup"); len(editors) != 0 {
+		t.Errorf("after bob left: editors = %+v, want none", editors)
+	}
+}
+
+func TestHeartbeatKeepsStartTime(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	tracker := New(time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Heartbeat("/a", "alice")
+	now = now.Add(20 * time.Second)
+	tracker.Heartbeat("/a", "alice")
+
+	editors := tracker.Editors("/a")
+	if len(editors) != 1 || !editors[0].Since.Equal(start) || !editors[0].LastSeen.Equal(now) {
+		t.Errorf("editors = %+v, want alice since %v, last seen %v", editors, start, now)
+	}
+}
@@ -0,0 +1,282 @@
+package qrcode
+
+// matrix is a code being drawn. Function modules, the finder, timing and
+// alignment patterns and the format and version information, are marked so
+// codewords and masks skip them.
+type matrix struct {
+	version  int
+	size     int
+	modules  []bool
+	function []bool
+}
+
+func newMatrix(version int) *matrix {
+	size := 17 + 4*version
+	return &matrix{
+		version:  version,
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+}
+
+// setFunction draws the function module in column x of row y
+func (m *matrix) setFunction(x, y int, dark bool) {
+	m.modules[y*m.size+x] = dark
+	m.function[y*m.size+x] = true
+}
+
+// alignmentPositions returns the rows, and columns, the centers of the
+// alignment patterns of a version are on
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, 17+4*version-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (m *matrix) drawFunctionPatterns() {
+	for i := range m.size {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns in three corners, with their separators
+	for _, center := range [][2]int{{3, 3}, {m.size - 4, 3}, {3, m.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x < 0 || x >= m.size || y < 0 || y >= m.size {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				m.setFunction(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	// Alignment patterns, except where they would overlap finder patterns
+	positions := alignmentPositions(m.version)
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	m.drawFormat(0) // Reserves the modules; drawn for real once the mask is chosen
+	m.drawVersion()
+}
+
+// formatBits returns the format information of a mask at level M: the level
+// and mask, their BCH error correction, masked so it is never all light
+func formatBits(mask int) int {
+	data := 0b00<<3 | mask // Level M
+	rem := data
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormat draws both copies of the format information of mask
+func (m *matrix) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	// Around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+
+	// Beside the other two finder patterns
+	for i := range 8 {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	m.setFunction(8, m.size-8, true) // Always dark
+}
+
+// versionBits returns the version information of a version: the version and
+// its BCH error correction
+func versionBits(version int) int {
+	rem := version
+	for range 12 {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+// drawVersion draws both copies of the version information, which versions 7
+// and up carry
+func (m *matrix) drawVersion() {
+	if m.version < 7 {
+		return
+	}
+	bits := versionBits(m.version)
+	for i := range 18 {
+		dark := bits>>i&1 != 0
+		a, b := m.size-11+i%3, i/3
+		m.setFunction(a, b, dark)
+		m.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the bits of data in two-module columns, zigzagging up
+// and down from the bottom right corner around the function modules
+func (m *matrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := range m.size {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if m.function[y*m.size+x] || i >= len(data)*8 {
+					continue
+				}
+				m.modules[y*m.size+x] = data[i/8]>>(7-i%8)&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// masked reports whether mask flips the module in column x of row y
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask flips the modules mask selects, leaving function modules alone
+func (m *matrix) applyMask(mask int) {
+	for y := range m.size {
+		for x := range m.size {
+			if !m.function[y*m.size+x] && masked(mask, x, y) {
+				m.modules[y*m.size+x] = !m.modules[y*m.size+x]
+			}
+		}
+	}
+}
+
+// finderLike is a stretch of modules that scanners could mistake for part of
+// a finder pattern, and its mirror image
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores how hard the modules are to scan; the mask with the lowest
+// score is used
+func (m *matrix) penalty() int {
+	dark := func(x, y int) bool { return m.modules[y*m.size+x] }
+	penalty := 0
+
+	// Runs of five or more modules of one color in a row or column, and
+	// stretches that look like finder patterns
+	for _, transpose := range []bool{false, true} {
+		at := dark
+		if transpose {
+			at = func(x, y int) bool { return dark(y, x) }
+		}
+		for y := range m.size {
+			run := 1
+			for x := 1; x <= m.size; x++ {
+				if x < m.size && at(x, y) == at(x-1, y) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+11 <= m.size; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, want := range pattern {
+						if at(x+k, y) != want {
+							match = false
+							break
+						}
+					}
+					if match {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	// Two by two blocks of one color
+	for y := 0; y+1 < m.size; y++ {
+		for x := 0; x+1 < m.size; x++ {
+			c := dark(x, y)
+			if dark(x+1, y) == c && dark(x, y+1) == c && dark(x+1, y+1) == c {
+				penalty += 3
+			}
+		}
+	}
+
+	// Dark modules far from half of them
+	count := 0
+	for _, d := range m.modules {
+		if d {
+			count++
+		}
+	}
+	total := m.size * m.size
+	penalty += abs(count*20-total*10) / total * 10
+	return penalty
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
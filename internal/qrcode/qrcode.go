@@ -0,0 +1,218 @@
+// Package qrcode encodes text as a QR code (ISO/IEC 18004) and renders it as
+// SVG or PNG, using only the standard library.
+//
+// Text is encoded as bytes at error correction level M, which still scans with
+// about 15% of the code damaged or covered. Versions 1 to 20 are supported,
+// holding up to 666 bytes; plenty for the URL of a document.
+package qrcode
+
+import (
+	"errors"
+)
+
+// MaxVersion is the largest version, and so size, of the codes made
+const MaxVersion = 20
+
+// ErrTooLong is returned for text that doesn't fit in a code of MaxVersion
+var ErrTooLong = errors.New("qrcode: text too long")
+
+// Code is a QR code: a square of dark and light modules
+type Code struct {
+	Version int
+	Size    int    // Modules per side, without the quiet zone around the code
+	modules []bool // Row by row, true for dark
+}
+
+// Dark reports whether the module in column x of row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y*c.Size+x]
+}
+
+// blockSpec is how the codewords of a version are split into error
+// correction blocks: blocks1 blocks of data1 data codewords, then blocks2
+// blocks of data2, each followed by ecLen error correction codewords
+type blockSpec struct {
+	ecLen          int
+	blocks1, data1 int
+	blocks2, data2 int
+}
+
+// blocksM are the blocks of versions 1 to MaxVersion at level M
+var blocksM = [MaxVersion + 1]blockSpec{
+	1:  {10, 1, 16, 0, 0},
+	2:  {16, 1, 28, 0, 0},
+	3:  {26, 1, 44, 0, 0},
+	4:  {18, 2, 32, 0, 0},
+	5:  {24, 2, 43, 0, 0},
+	6:  {16, 4, 27, 0, 0},
+	7:  {18, 4, 31, 0, 0},
+	8:  {22, 2, 38, 2, 39},
+	9:  {22, 3, 36, 2, 37},
+	10: {26, 4, 43, 1, 44},
+	11: {30, 1, 50, 4, 51},
+	12: {22, 6, 36, 2, 37},
+	13: {22, 8, 37, 1, 38},
+	14: {24, 4, 40, 5, 41},
+	15: {24, 5, 41, 5, 42},
+	16: {28, 7, 45, 3, 46},
+	17: {28, 10, 46, 1, 47},
+	18: {26, 9, 43, 4, 44},
+	19: {26, 3, 44, 11, 45},
+	20: {26, 3, 41, 13, 42},
+}
+
+// dataCodewords returns the number of data codewords of a version
+func (b blockSpec) dataCodewords() int {
+	return b.blocks1*b.data1 + b.blocks2*b.data2
+}
+
+// countBits returns the length of the byte count of a version
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// Encode returns the smallest code holding text
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= 8*blocksM[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	m := newMatrix(version)
+	m.drawFunctionPatterns()
+	m.drawCodewords(interleave(version, dataSegment(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		m.applyMask(mask)
+		m.drawFormat(mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask) // Masking twice undoes it
+	}
+	m.applyMask(best)
+	m.drawFormat(best)
+
+	return &Code{Version: version, Size: m.size, modules: m.modules}, nil
+}
+
+// dataSegment returns the data codewords of a version holding data in byte
+// mode, padded to fill the code
+func dataSegment(version int, data []byte) []byte {
+	capacity := 8 * blocksM[version].dataCodewords()
+	var bits bitBuffer
+	bits.append(0b0100, 4) // Byte mode
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-bits.len())) // Terminator
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes
+}
+
+// bitBuffer is a sequence of bits, packed most significant first
+type bitBuffer struct {
+	bytes []byte
+	n     int
+}
+
+func (b *bitBuffer) len() int { return b.n }
+
+// append adds the low length bits of value, most significant first
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if value>>i&1 != 0 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// interleave splits data into the blocks of a version, adds the error
+// correction codewords of each and returns the codewords in the order they
+// are placed: the data of all blocks in turn, then their error correction
+func interleave(version int, data []byte) []byte {
+	spec := blocksM[version]
+	var blocks, ecBlocks [][]byte
+	for i := range spec.blocks1 + spec.blocks2 {
+		n := spec.data1
+		if i >= spec.blocks1 {
+			n = spec.data2
+		}
+		blocks = append(blocks, data[:n])
+		ecBlocks = append(ecBlocks, reedSolomon(data[:n], spec.ecLen))
+		data = data[n:]
+	}
+
+	var result []byte
+	for i := range max(spec.data1, spec.data2) {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := range spec.ecLen {
+		for _, ec := range ecBlocks {
+			result = append(result, ec[i])
+		}
+	}
+	return result
+}
+
+// reedSolomon returns the n error correction codewords of data
+func reedSolomon(data []byte, n int) []byte {
+	// The generator polynomial (x - α^0)(x - α^1)...(x - α^(n-1)), without its
+	// leading coefficient of 1, highest power first
+	generator := make([]byte, n)
+	generator[n-1] = 1
+	root := byte(1)
+	for range n {
+		for j := range n {
+			generator[j] = gfMul(generator[j], root)
+			if j+1 < n {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+
+	remainder := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[n-1] = 0
+		for i, coef := range generator {
+			remainder[i] ^= gfMul(coef, factor)
+		}
+	}
+	return remainder
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
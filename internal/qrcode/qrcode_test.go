@@ -0,0 +1,224 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The "HELLO WORLD" example of the standard, at version 1-M
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, len(want)); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomon() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	formats := []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	}
+	for mask, want := range formats {
+		if got := formatBits(mask); got != want {
+			t.Errorf("formatBits(%d) = %015b, want %015b", mask, got, want)
+		}
+	}
+	if got := versionBits(7); got != 0x07C94 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	tests := map[int][]int{1: nil, 2: {6, 18}, 7: {6, 22, 38}, 14: {6, 26, 46, 66}, 20: {6, 34, 62, 90}}
+	for version, want := range tests {
+		if got := alignmentPositions(version); !slices.Equal(got, want) {
+			t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+// The codewords of each version fill the modules left by its function
+// patterns, up to the remainder bits the standard lists
+func TestBlocksFillVersions(t *testing.T) {
+	for version := 1; version <= MaxVersion; version++ {
+		m := newMatrix(version)
+		m.drawFunctionPatterns()
+		free := 0
+		for _, function := range m.function {
+			if !function {
+				free++
+			}
+		}
+		spec := blocksM[version]
+		codewords := spec.dataCodewords() + (spec.blocks1+spec.blocks2)*spec.ecLen
+		remainder := 0
+		switch {
+		case version >= 14:
+			remainder = 3
+		case version >= 7: // None
+		case version >= 2:
+			remainder = 7
+		}
+		if free != codewords*8+remainder {
+			t.Errorf("version %d: %d free modules for %d codewords", version, free, codewords)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		text    string
+		version int
+	}{
+		{"", 1},
+		{"https://wiki.example.com/", 2},
+		{"https://wiki.example.com/guides/setup/installing-on-linux", 4},
+		{"https://wiki.example.com/" + strings.Repeat("runbooks/", 20), 10},
+		{strings.Repeat("é", 333), MaxVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text[:min(30, len(tt.text))], func(t *testing.T) {
+			code, err := Encode(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Version != tt.version || code.Size != 17+4*tt.version {
+				t.Errorf("version %d of size %d, want version %d", code.Version, code.Size, tt.version)
+			}
+			if got := decode(t, code); got != tt.text {
+				t.Errorf("decoded %q", got)
+			}
+		})
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("a", 667)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Encode() error = %v, want ErrTooLong", err)
+	}
+}
+
+// decode reads the text back from code the way a scanner does once it has
+// found the modules: from the format information, the codewords and their
+// error correction
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+	m := newMatrix(code.Version)
+	m.drawFunctionPatterns()
+
+	// The format information around the top left finder pattern
+	format := 0
+	for i, pos := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if code.Dark(pos[0], pos[1]) {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for candidate := range 8 {
+		if formatBits(candidate) == format {
+			mask = candidate
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format information %015b isn't level M", format)
+	}
+
+	// The codeword bits, in placement order
+	var bits bitBuffer
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range code.Size {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = code.Size - 1 - vert
+			}
+			for _, x := range []int{right, right - 1} {
+				if m.function[y*code.Size+x] {
+					continue
+				}
+				bit := 0
+				if code.Dark(x, y) != masked(mask, x, y) {
+					bit = 1
+				}
+				bits.append(bit, 1)
+			}
+		}
+	}
+
+	spec := blocksM[code.Version]
+	blocks := spec.blocks1 + spec.blocks2
+	data := make([][]byte, blocks)
+	codewords := bits.bytes
+	for i := range max(spec.data1, spec.data2) {
+		for b := range blocks {
+			if b < spec.blocks1 && i >= spec.data1 {
+				continue
+			}
+			data[b] = append(data[b], codewords[0])
+			codewords = codewords[1:]
+		}
+	}
+	ec := make([][]byte, blocks)
+	for range spec.ecLen {
+		for b := range blocks {
+			ec[b] = append(ec[b], codewords[0])
+			codewords = codewords[1:]
+		}
+	}
+	var stream []byte
+	for b := range blocks {
+		if want := reedSolomon(data[b], spec.ecLen); !bytes.Equal(ec[b], want) {
+			t.Fatalf("block %d: error correction doesn't match its data", b)
+		}
+		stream = append(stream, data[b]...)
+	}
+
+	if stream[0]>>4 != 0b0100 {
+		t.Fatalf("mode %04b isn't byte mode", stream[0]>>4)
+	}
+	read := func(bit, n int) int {
+		v := 0
+		for i := range n {
+			v = v<<1 | int(stream[(bit+i)/8]>>(7-(bit+i)%8)&1)
+		}
+		return v
+	}
+	length := read(4, countBits(code.Version))
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(4+countBits(code.Version)+8*i, 8))
+	}
+	return string(text)
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode("https://wiki.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := code.Size + 2*QuietZone
+
+	img := code.Image(3)
+	if got := img.Bounds().Dx(); got != side*3 {
+		t.Errorf("image width = %d, want %d", got, side*3)
+	}
+	// The top left module of the top left finder pattern is dark, the quiet
+	// zone around it light
+	if r, _, _, _ := img.At(QuietZone*3, QuietZone*3).RGBA(); r != 0 {
+		t.Error("finder pattern isn't dark")
+	}
+	if r, _, _, _ := img.At(QuietZone*3-1, QuietZone*3).RGBA(); r == 0 {
+		t.Error("quiet zone isn't light")
+	}
+
+	svg := string(code.SVG())
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 33 33"`) || !strings.Contains(svg, `d="M4,4h7v1h-7z`) {
+		t.Errorf("SVG = %.120s", svg)
+	}
+}
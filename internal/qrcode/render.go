@@ -0,0 +1,57 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// QuietZone is the width, in modules, of the light margin scanners need
+// around a code; the renderings include it
+const QuietZone = 4
+
+// SVG returns the code as an SVG image, one unit per module, which scales to
+// any print size without blurring
+func (c *Code) SVG() []byte {
+	side := c.Size + 2*QuietZone
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, side, side)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, side, side)
+	for y := range c.Size {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			run := 1
+			for x+run < c.Size && c.Dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d,%dh%dv1h-%dz", x+QuietZone, y+QuietZone, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return []byte(b.String())
+}
+
+// Image returns the code as a black and white image with scale pixels per
+// module
+func (c *Code) Image(scale int) image.Image {
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := range c.Size {
+		for x := range c.Size {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for py := range scale {
+				row := img.Pix[((y+QuietZone)*scale+py)*img.Stride:]
+				for px := range scale {
+					row[(x+QuietZone)*scale+px] = 1
+				}
+			}
+		}
+	}
+	return img
+}
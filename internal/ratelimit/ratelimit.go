@@ -0,0 +1,70 @@
+// Package ratelimit provides an in-memory sliding window rate limiter keyed by
+// arbitrary strings such as IP addresses or usernames.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows at most Max events per key within Window
+type Limiter struct {
+	mu     sync.Mutex
+	max    int
+	window time.Duration
+	events map[string][]time.Time
+	// swept is when quiet keys were last dropped
+	swept time.Time
+}
+
+// New creates a limiter. A max of zero or less disables limiting.
+func New(max int, window time.Duration) *Limiter {
+	return &Limiter{
+		max:    max,
+		window: window,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Allow records an event for key if the limit has not been reached. When the
+// limit is exceeded it returns false and the time until the next event is allowed.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.max <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	recent := l.prune(key, now)
+	if len(recent) >= l.max {
+		return false, recent[0].Add(l.window).Sub(now)
+	}
+	l.events[key] = append(recent, now)
+
+	// Drop keys that have gone quiet so the map doesn't grow forever. Sweeping
+	// at most once per window keeps Allow cheap when many keys are active.
+	if len(l.events) > 1024 && now.Sub(l.swept) >= l.window {
+		l.swept = now
+		for k := range l.events {
+			if len(l.prune(k, now)) == 0 {
+				delete(l.events, k)
+			}
+		}
+	}
+	return true, 0
+}
+
+// prune drops events of key that fell out of the window and returns the rest
+func (l *Limiter) prune(key string, now time.Time) []time.Time {
+	events := l.events[key]
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	events = events[i:]
+	l.events[key] = events
+	return events
+}
@@ -0,0 +1,121 @@
+// Package rendercache keeps the HTML of recently rendered documents in a bounded
+// least-recently-used cache. Entries are keyed by document path and a hash of
+// the markdown they were rendered from, so an edited document never hits a
+// stale entry even before it is invalidated.
+package rendercache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"strings"
+	"sync"
+)
+
+type key struct {
+	path string
+	hash [sha256.Size]byte
+}
+
+type entry struct {
+	key  key
+	html []byte
+}
+
+// Cache is a size-bounded LRU cache of rendered HTML. It is safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is the most recently used
+	entries map[key]*list.Element
+}
+
+// New creates a cache holding at most size documents. A size of 0 disables it.
+func New(size int) *Cache {
+	return &Cache{
+		size:    max(size, 0),
+		order:   list.New(),
+		entries: make(map[key]*list.Element),
+	}
+}
+
+// Get returns the HTML cached for the document at path rendered from markdown
+func (c *Cache) Get(path string, markdown []byte) ([]byte, bool) {
+	k := key{path: path, hash: sha256.Sum256(markdown)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry).html, true
+}
+
+// Put stores the HTML rendered for the document at path from markdown, evicting
+// the least recently used documents when the cache is full
+func (c *Cache) Put(path string, markdown, html []byte) {
+	k := key{path: path, hash: sha256.Sum256(markdown)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size == 0 {
+		return
+	}
+	if el, ok := c.entries[k]; ok {
+		el.Value.(*entry).html = html
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[k] = c.order.PushFront(&entry{key: k, html: html})
+	c.evict()
+}
+
+// Invalidate removes every entry for the document at path and, since a path may
+// also be a category, for the documents below it
+func (c *Cache) Invalidate(path string) {
+	path = strings.Trim(path, "/")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, el := range c.entries {
+		p := strings.Trim(k.path, "/")
+		if p == path || (path != "" && strings.HasPrefix(p, path+"/")) {
+			c.order.Remove(el)
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Purge empties the cache
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Resize changes the number of documents the cache holds, evicting the least
+// recently used ones if it shrinks
+func (c *Cache) Resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = max(size, 0)
+	c.evict()
+}
+
+// Len returns the number of cached documents
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// evict drops the least recently used entries beyond the size limit. c.mu must be held.
+func (c *Cache) evict() {
+	for c.order.Len() > c.size {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(*entry).key)
+	}
+}
@@ -16,6 +16,7 @@ document.addEventListener('DOMContentLoaded', function() {
     window.WikiConfig = {
         // Get config values from meta tags
         enableLinkEmbedding: document.querySelector('meta[name="enable-link-embedding"]')?.getAttribute('content') === 'true',
+        realtimeEditing: document.querySelector('meta[name="realtime-editing"]')?.getAttribute('content') === 'true',
         disableContentMaxWidth: document.querySelector('meta[name="disable-content-max-width"]')?.getAttribute('content') === 'true'
     };
 
@@ -48,6 +49,14 @@ document.addEventListener('DOMContentLoaded', function() {
         window.WikiEditor.initializeEditControls();
     }
 
+    // Button actions (kept out of inline handlers so the CSP can forbid them)
+    document.querySelector('.sitemap-button')?.addEventListener('click', function() {
+        window.open('/sitemap/', '_blank');
+    });
+    document.querySelector('.print-button')?.addEventListener('click', function() {
+        window.print();
+    });
+
     // Add scroll event listener to toggle shadows
     const breadcrumbs = document.querySelector('.breadcrumbs');
     const hamburger = document.querySelector('.hamburger');
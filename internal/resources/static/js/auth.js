@@ -10,6 +10,14 @@
     let errorMessage;
     let loginUsernameInput;
     let editCallback = null;
+    let sessionTimer = null;
+    let sessionWarned = false;
+    let sessionSeen = false;
+
+    // How long before the session expires the user is warned, and how often the
+    // session status is polled
+    const SESSION_WARNING_SECONDS = 5 * 60;
+    const SESSION_POLL_SECONDS = 60;
 
     // Initialize module when DOM is loaded
     document.addEventListener('DOMContentLoaded', function() {
@@ -67,6 +75,17 @@
             }
         });
 
+        // Sign back in a user who chose to stay logged in once their session ends
+        resumeKeptLogin();
+
+        // Warn before the session expires, and tell the user once it has
+        watchSession();
+        document.addEventListener('visibilitychange', function() {
+            if (document.visibilityState === 'visible' && sessionSeen) {
+                watchSession();
+            }
+        });
+
         // Check if default password is in use
         checkDefaultPassword();
 
@@ -74,6 +93,83 @@
         checkPendingActions();
     });
 
+    // Trade the refresh token for a new session when the session has expired.
+    // Only tried after a login with "keep me logged in", which leaves a hint in
+    // localStorage; the refresh token itself is not readable from scripts.
+    async function resumeKeptLogin() {
+        if (!localStorage.getItem('keptLogin')) {
+            return;
+        }
+        try {
+            const authResponse = await fetch('/api/check-auth');
+            if (authResponse.status !== 401) {
+                return;
+            }
+            const response = await fetch('/api/refresh', { method: 'POST' });
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                localStorage.removeItem('keptLogin');
+            }
+        } catch (error) {
+            console.error('Error resuming login:', error);
+        }
+    }
+
+    // Poll the session status while signed in. A kept login is refreshed shortly
+    // before it expires; otherwise the user is warned once, and asked to log in
+    // again when the session has ended so their next action doesn't fail.
+    async function watchSession() {
+        clearTimeout(sessionTimer);
+        const t = (key, fallback) => (window.i18n ? window.i18n.t(key) : fallback);
+        let status;
+        try {
+            const response = await fetch('/api/session/status', { cache: 'no-store' });
+            if (!response.ok) {
+                return;
+            }
+            status = await response.json();
+        } catch (error) {
+            sessionTimer = setTimeout(watchSession, SESSION_POLL_SECONDS * 1000);
+            return;
+        }
+
+        if (!status.authenticated) {
+            if (sessionSeen) {
+                sessionSeen = false;
+                showLoginDialog(null);
+                errorMessage.textContent = t('session.expired', 'Your session has expired. Please log in again.');
+                errorMessage.style.display = 'block';
+            }
+            return;
+        }
+        sessionSeen = true;
+
+        if (status.remaining <= SESSION_WARNING_SECONDS) {
+            if (status.refreshable && localStorage.getItem('keptLogin')) {
+                const response = await fetch('/api/refresh', { method: 'POST' });
+                if (response.ok) {
+                    sessionWarned = false;
+                    watchSession();
+                    return;
+                }
+                localStorage.removeItem('keptLogin');
+            }
+            if (!sessionWarned && window.DialogSystem) {
+                sessionWarned = true;
+                const minutes = Math.max(1, Math.round(status.remaining / 60));
+                window.DialogSystem.showMessageDialog(
+                    t('session.expiring_title', 'Session expiring'),
+                    t('session.expiring_message', 'Your session expires in {minutes} minutes. Save your work and log in again to continue.').replace('{minutes}', minutes)
+                );
+            }
+        }
+
+        // Check again at the next poll, or right after the session expires
+        const delay = Math.min(SESSION_POLL_SECONDS, status.remaining + 1);
+        sessionTimer = setTimeout(watchSession, delay * 1000);
+    }
+
     // Function to show login dialog
     function showLoginDialog(callback) {
         loginDialog.classList.add('active');
@@ -120,6 +216,11 @@
 
             if (response.ok) {
                 hideLoginDialog();
+                if (keepLoggedIn) {
+                    localStorage.setItem('keptLogin', '1');
+                } else {
+                    localStorage.removeItem('keptLogin');
+                }
                 if (window.loginCallback) {
                     // Store loginCallback info in localStorage
                     localStorage.setItem('pendingAction', 'loginCallback');
@@ -167,6 +268,7 @@
             const response = await fetch('/api/logout', {
                 method: 'POST'
             });
+            localStorage.removeItem('keptLogin');
 
             if (response.ok) {
                 // Update toolbar buttons after logout
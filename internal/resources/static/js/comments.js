@@ -1,3 +1,80 @@
+// Anti-spam challenge settings for the comment form, loaded once per page
+let commentChallenge = null;
+
+// CAPTCHA widgets: script to load and the class their auto-render looks for
+const challengeWidgets = {
+    hcaptcha: { src: 'https://js.hcaptcha.com/1/api.js', className: 'h-captcha' },
+    turnstile: { src: 'https://challenges.cloudflare.com/turnstile/v0/api.js', className: 'cf-turnstile' }
+};
+
+// Ask the server whether a challenge is required and render the CAPTCHA widget if so
+async function setupCommentChallenge(form) {
+    try {
+        const response = await fetch('/api/comments/challenge');
+        commentChallenge = await response.json();
+    } catch (error) {
+        console.error('Error loading comment challenge:', error);
+        return;
+    }
+
+    const widget = commentChallenge.required ? challengeWidgets[commentChallenge.provider] : null;
+    if (!widget) {
+        return;
+    }
+
+    const container = document.createElement('div');
+    container.className = `${widget.className} comment-challenge`;
+    container.dataset.sitekey = commentChallenge.siteKey;
+    form.querySelector('button[type="submit"]').before(container);
+
+    const script = document.createElement('script');
+    script.src = widget.src;
+    script.async = true;
+    script.defer = true;
+    document.head.appendChild(script);
+}
+
+// Return the token to submit with a comment, or an empty string when none is needed
+async function getCommentChallengeToken(form) {
+    if (!commentChallenge || !commentChallenge.required) {
+        return '';
+    }
+
+    if (commentChallenge.provider === 'pow') {
+        // Proof-of-work challenges are single use, so fetch a fresh one per submission
+        const response = await fetch('/api/comments/challenge');
+        const fresh = await response.json();
+        return solveProofOfWork(fresh.challenge, fresh.difficulty);
+    }
+
+    const field = form.querySelector('[name="h-captcha-response"], [name="cf-turnstile-response"]');
+    return field ? field.value : '';
+}
+
+// Find a nonce whose SHA-256 of "challenge:nonce" starts with `difficulty` zero bits
+async function solveProofOfWork(challenge, difficulty) {
+    const encoder = new TextEncoder();
+    for (let nonce = 0; ; nonce++) {
+        const candidate = `${challenge}:${nonce}`;
+        const digest = new Uint8Array(await crypto.subtle.digest('SHA-256', encoder.encode(candidate)));
+        if (leadingZeroBits(digest) >= difficulty) {
+            return candidate;
+        }
+    }
+}
+
+function leadingZeroBits(bytes) {
+    let count = 0;
+    for (const byte of bytes) {
+        if (byte === 0) {
+            count += 8;
+            continue;
+        }
+        return count + Math.clz32(byte) - 24;
+    }
+    return count;
+}
+
 // Comments functionality
 document.addEventListener('DOMContentLoaded', function() {
     // Translate comments section after DOM loads
@@ -31,7 +108,7 @@ document.addEventListener('DOMContentLoaded', function() {
             document.querySelectorAll('.login-prompt').forEach(el => {
                 if (el.innerHTML.includes('comments.login_required')) {
                     el.innerHTML = window.i18n.t('comments.login_required') +
-                                  ' <a href="javascript:void(0)" class="open-login">' +
+                                   <a href="#" class="open-login">' +
                                   window.i18n.t('comments.login') + '</a>';
                 }
             });
@@ -53,11 +130,15 @@ document.addEventListener('DOMContentLoaded', function() {
     // Handle comment form submission
     const commentForm = document.getElementById('comment-form');
     if (commentForm) {
+        setupCommentChallenge(commentForm);
+
         commentForm.addEventListener('submit', async function(e) {
             e.preventDefault();
 
-            // Get the comment content
+            // Get the comment content, and the author of an anonymous comment
             const commentContent = this.querySelector('textarea[name="content"]').value;
+            const nameInput = this.querySelector('input[name="name"]');
+            const emailInput = this.querySelector('input[name="email"]');
             if (!commentContent.trim()) {
                 return; // Don't submit empty comments
             }
@@ -72,13 +153,21 @@ document.addEventListener('DOMContentLoaded', function() {
                 submitButton.textContent = window.i18n ? window.i18n.t('common.sending') : 'Sending...';
                 submitButton.disabled = true;
 
+                // Solve or collect the anti-spam challenge, if one is required
+                const challengeToken = await getCommentChallengeToken(this);
+
                 // Send the comment to the server
                 const response = await fetch(`/api/comments/add/${docPath}`, {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json'
                     },
-                    body: JSON.stringify({ content: commentContent })
+                    body: JSON.stringify({
+                        content: commentContent,
+                        challengeToken: challengeToken,
+                        name: nameInput ? nameInput.value : undefined,
+                        email: emailInput ? emailInput.value : undefined
+                    })
                 });
 
                 // Check if the request was successful
@@ -221,13 +310,13 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     });
 
-    // Handle login link in the comments section
-    const loginLink = document.querySelector('.login-prompt .open-login');
-    if (loginLink) {
-        loginLink.addEventListener('click', function(e) {
+    // Handle login link in the comments section, which is added once the
+    // prompt is translated
+    document.addEventListener('click', function(e) {
+        if (e.target.closest('.login-prompt .open-login')) {
             e.preventDefault();
             showLoginDialog();
-        });
-    }
+        }
+    });
 
 });
\ No newline at end of file
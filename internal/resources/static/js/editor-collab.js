@@ -0,0 +1,295 @@
+// Real-time Editing
+// When realtime_editing is enabled, editors of the same document are joined
+// over a WebSocket to /api/collab/{path}. Local changes are sent as
+// operations (in the ot.js format: positive numbers retain, negative numbers
+// delete, strings insert) and changes of others are transformed against the
+// ones not yet acknowledged, so every copy ends up with the same text. Saving
+// still goes through /api/save.
+(function () {
+  // ---- Operations ----
+
+  function Operation() {
+    this.ops = [];
+    this.baseLength = 0;
+  }
+
+  Operation.prototype.retain = function (n) {
+    if (n <= 0) return this;
+    this.baseLength += n;
+    const last = this.ops[this.ops.length - 1];
+    if (typeof last === 'number' && last > 0) this.ops[this.ops.length - 1] += n;
+    else this.ops.push(n);
+    return this;
+  };
+
+  Operation.prototype.insert = function (text) {
+    if (!text) return this;
+    const ops = this.ops;
+    const last = ops[ops.length - 1];
+    if (typeof last === 'string') {
+      ops[ops.length - 1] += text;
+    } else if (typeof last === 'number' && last < 0) {
+      // Inserts go before deletes at the same position
+      if (typeof ops[ops.length - 2] === 'string') ops[ops.length - 2] += text;
+      else { ops.push(last); ops[ops.length - 2] = text; }
+    } else {
+      ops.push(text);
+    }
+    return this;
+  };
+
+  Operation.prototype.delete = function (n) {
+    if (n <= 0) return this;
+    this.baseLength += n;
+    const last = this.ops[this.ops.length - 1];
+    if (typeof last === 'number' && last < 0) this.ops[this.ops.length - 1] -= n;
+    else this.ops.push(-n);
+    return this;
+  };
+
+  Operation.fromJSON = function (list) {
+    const op = new Operation();
+    for (const c of list) {
+      if (typeof c === 'string') op.insert(c);
+      else if (c > 0) op.retain(c);
+      else op.delete(-c);
+    }
+    return op;
+  };
+
+  // transform returns [a', b'] for concurrent a and b, like the server's
+  // collab.Transform: inserts of a go first when both insert at one position
+  function transform(a, b) {
+    const aPrime = new Operation();
+    const bPrime = new Operation();
+    const as = a.ops.slice();
+    const bs = b.ops.slice();
+    let ac = as.shift();
+    let bc = bs.shift();
+    while (ac !== undefined || bc !== undefined) {
+      if (typeof ac === 'string') {
+        aPrime.insert(ac);
+        bPrime.retain(ac.length);
+        ac = as.shift();
+        continue;
+      }
+      if (typeof bc === 'string') {
+        aPrime.retain(bc.length);
+        bPrime.insert(bc);
+        bc = bs.shift();
+        continue;
+      }
+      if (ac === undefined || bc === undefined) throw new Error('operations of different lengths');
+
+      let n;
+      if (ac > 0 && bc > 0) {
+        n = Math.min(ac, bc);
+        aPrime.retain(n);
+        bPrime.retain(n);
+        ac -= n;
+        bc -= n;
+      } else if (ac < 0 && bc < 0) {
+        n = Math.min(-ac, -bc);
+        ac += n;
+        bc += n;
+      } else if (ac < 0) {
+        n = Math.min(-ac, bc);
+        aPrime.delete(n);
+        ac += n;
+        bc -= n;
+      } else {
+        n = Math.min(ac, -bc);
+        bPrime.delete(n);
+        ac -= n;
+        bc += n;
+      }
+      if (ac === 0) ac = as.shift();
+      if (bc === 0) bc = bs.shift();
+    }
+    return [aPrime, bPrime];
+  }
+
+  // ---- Session ----
+
+  let socket = null;
+  let editor = null;
+  let version = 0;
+  let pending = [];       // Local operations not yet acknowledged; the first one has been sent
+  let awaitingAck = false;
+  let applyingRemote = false;
+  let cursorTimer = null;
+  const peers = new Map(); // Connection id -> { user, marker }
+
+  function send(message) {
+    if (socket && socket.readyState === WebSocket.OPEN) socket.send(JSON.stringify(message));
+  }
+
+  function sendNext() {
+    if (awaitingAck || pending.length === 0) return;
+    awaitingAck = true;
+    send({ type: 'op', version: version, op: pending[0].ops });
+  }
+
+  function onBeforeChange(cm, change) {
+    if (applyingRemote) return;
+    const doc = cm.getDoc();
+    const length = doc.getValue().length;
+    const from = doc.indexFromPos(change.from);
+    const to = doc.indexFromPos(change.to);
+    pending.push(new Operation()
+      .retain(from)
+      .delete(to - from)
+      .insert(change.text.join('\n'))
+      .retain(length - to));
+    setTimeout(sendNext, 0);
+  }
+
+  function onCursorActivity(cm) {
+    clearTimeout(cursorTimer);
+    cursorTimer = setTimeout(() => {
+      send({ type: 'cursor', index: cm.getDoc().indexFromPos(cm.getCursor()) });
+    }, 200);
+  }
+
+  // applyRemote applies an operation of another editor to the local text
+  function applyRemote(op) {
+    const doc = editor.getDoc();
+    applyingRemote = true;
+    try {
+      editor.operation(() => {
+        let index = 0;
+        for (const c of op.ops) {
+          if (typeof c === 'string') {
+            doc.replaceRange(c, doc.posFromIndex(index), undefined, 'collab');
+            index += c.length;
+          } else if (c > 0) {
+            index += c;
+          } else {
+            doc.replaceRange('', doc.posFromIndex(index), doc.posFromIndex(index - c), 'collab');
+          }
+        }
+      });
+    } finally {
+      applyingRemote = false;
+    }
+  }
+
+  function replaceText(content) {
+    if (editor.getValue() === content) return;
+    applyingRemote = true;
+    try {
+      const cursor = editor.getCursor();
+      editor.setValue(content);
+      editor.setCursor(cursor);
+    } finally {
+      applyingRemote = false;
+    }
+  }
+
+  function showPeer(id, user, index) {
+    removePeer(id);
+    const label = document.createElement('span');
+    label.className = 'collab-cursor';
+    label.textContent = user;
+    const marker = editor.getDoc().setBookmark(editor.getDoc().posFromIndex(index), { widget: label, insertLeft: true });
+    peers.set(id, { user, marker });
+  }
+
+  function removePeer(id) {
+    const peer = peers.get(id);
+    if (peer) peer.marker.clear();
+    peers.delete(id);
+  }
+
+  function onMessage(event) {
+    const m = JSON.parse(event.data);
+    switch (m.type) {
+      case 'init':
+        // Local changes are shared from here on, based on the room's text
+        version = m.version || 0;
+        replaceText(m.content);
+        (m.peers || []).forEach(p => showPeer(p.id, p.user, p.cursor));
+        editor.on('beforeChange', onBeforeChange);
+        editor.on('cursorActivity', onCursorActivity);
+        break;
+      case 'ack':
+        version = m.version;
+        pending.shift();
+        awaitingAck = false;
+        sendNext();
+        break;
+      case 'op': {
+        version = m.version;
+        let op = Operation.fromJSON(m.op);
+        pending = pending.map(p => {
+          const [pPrime, opPrime] = transform(p, op);
+          op = opPrime;
+          return pPrime;
+        });
+        applyRemote(op);
+        break;
+      }
+      case 'sync':
+        // The text was replaced, e.g. by a save, or we fell out of step
+        version = m.version;
+        pending = [];
+        awaitingAck = false;
+        replaceText(m.content);
+        if (m.user) window.EditorCore.setOriginalContent(m.content);
+        break;
+      case 'saved':
+        if (pending.length === 0) window.EditorCore.setOriginalContent(editor.getValue());
+        break;
+      case 'cursor':
+        showPeer(m.id, m.user, m.index);
+        break;
+      case 'leave':
+        removePeer(m.id);
+        break;
+      case 'error':
+        console.warn('Real-time editing:', m.message);
+        break;
+    }
+  }
+
+  function start(cm) {
+    stop();
+    if (!window.WikiConfig || !window.WikiConfig.realtimeEditing) return;
+
+    editor = cm;
+    const path = window.location.pathname.replace(/^\/+|\/+$/g, '');
+    const scheme = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const ws = new WebSocket(`${scheme}//${window.location.host}/api/collab/${path}`);
+    ws.addEventListener('message', (event) => { if (socket === ws) onMessage(event); });
+    ws.addEventListener('close', (event) => {
+      if (socket !== ws) return;
+      if (event.code === 1001 && event.reason && window.showMessageDialog) {
+        const t = (key, fallback) => (window.i18n ? window.i18n.t(key) : fallback);
+        window.showMessageDialog(t('editor.collab_ended', 'Real-time editing ended'), event.reason);
+      }
+      detach();
+    });
+    socket = ws;
+  }
+
+  function detach() {
+    if (editor) {
+      editor.off('beforeChange', onBeforeChange);
+      editor.off('cursorActivity', onCursorActivity);
+      peers.forEach((_, id) => removePeer(id));
+    }
+    clearTimeout(cursorTimer);
+    socket = null;
+    editor = null;
+    version = 0;
+    pending = [];
+    awaitingAck = false;
+  }
+
+  function stop() {
+    if (socket) socket.close(1000);
+    detach();
+  }
+
+  window.EditorCollab = { start, stop, transform, Operation };
+})();
@@ -319,11 +319,19 @@ function wrapText(cm, prefix = '', suffix = '', placeholder = '') {
     cm.focus();
 }
 
+// Query string selecting the translation being edited: an explicit ?lang= in the
+// URL (which can start a new translation), otherwise the variant being displayed
+function getTranslationQuery() {
+    const lang = new URLSearchParams(window.location.search).get('lang') ||
+        document.querySelector('meta[name="document-translation"]')?.getAttribute('content');
+    return lang ? `?lang=${encodeURIComponent(lang)}` : '';
+}
+
 // Main editor loading function
 async function loadEditor(mainContent, editorContainer, viewToolbar, editToolbar) {
     try {
         const isHomepage = window.location.pathname === '/';
-        const apiPath = isHomepage ? '/api/source/' : `/api/source${window.location.pathname}`;
+        const apiPath = (isHomepage ? '/api/source/' : `/api/source${window.location.pathname}`) + getTranslationQuery();
 
         const response = await fetch(apiPath);
         if (!response.ok) throw new Error('Failed to fetch content');
@@ -371,6 +379,9 @@ async function loadEditor(mainContent, editorContainer, viewToolbar, editToolbar
         // 6. Create statusbar at the bottom
         const statusbar = createStatusbar(editorLayout);
 
+        // Show who else is editing this document
+        if (window.EditorPresence) window.EditorPresence.start(statusbar);
+
         // Reset the preview mode
         previewElement.classList.remove('editor-preview-active');
 
@@ -468,6 +479,9 @@ async function loadEditor(mainContent, editorContainer, viewToolbar, editToolbar
         // Force a refresh with multiple attempts to ensure editor renders properly
         refreshEditor(statusbar);
 
+        // Share changes with others editing the document, unless this is a translation
+        if (window.EditorCollab && !getTranslationQuery()) window.EditorCollab.start(editor);
+
     } catch (error) {
         console.error('Error:', error);
         alert('Failed to load content for editing');
@@ -538,6 +552,10 @@ function exitEditMode(mainContent, editorContainer, viewToolbar, editToolbar) {
     // Remove beforeunload handler first
     removeBeforeUnloadHandler();
 
+    // Stop announcing that this document is being edited
+    if (window.EditorPresence) window.EditorPresence.stop();
+    if (window.EditorCollab) window.EditorCollab.stop();
+
     // Clear any pending preview debounce timer and reset editor mode
     if (window.EditorPreview) {
         window.EditorPreview.clearDebounceTimer();
@@ -837,5 +855,6 @@ window.EditorCore = {
     // Getters
     getEditor: () => editor,
     getOriginalContent: () => originalContent,
-    setOriginalContent: (content) => { originalContent = content; }
+    setOriginalContent: (content) => { originalContent = content; },
+    getTranslationQuery
 };
\ No newline at end of file
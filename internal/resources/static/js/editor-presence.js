@@ -0,0 +1,68 @@
+// Editor Presence
+// While the editor is open it sends a heartbeat to /api/presence/{path} and
+// shows who else is editing the same document in the statusbar. This is only
+// a hint: saves are still checked for conflicting changes.
+(function () {
+  const HEARTBEAT_INTERVAL = 15000;
+
+  let timer = null;
+  let presenceUrl = null;
+  let indicator = null;
+
+  function t(key, fallback) {
+    return window.i18n ? window.i18n.t(key) : fallback;
+  }
+
+  function render(editors) {
+    if (!indicator) return;
+    const names = editors.map(e => e.username);
+    indicator.textContent = names.length
+      ? t('editor.also_editing', '%s is also editing').replace('%s', names.join(', '))
+      : '';
+    indicator.style.display = names.length ? '' : 'none';
+  }
+
+  async function heartbeat() {
+    if (!presenceUrl) return;
+    try {
+      const resp = await fetch(presenceUrl, { method: 'PUT' });
+      if (!resp.ok) return;
+      const data = await resp.json();
+      render(data.editors || []);
+    } catch (err) {
+      console.warn('Presence heartbeat failed:', err);
+    }
+  }
+
+  function leave() {
+    if (!presenceUrl) return;
+    fetch(presenceUrl, { method: 'DELETE', keepalive: true }).catch(() => {});
+  }
+
+  function start(statusbar) {
+    stop();
+    const path = window.location.pathname.replace(/^\/+|\/+$/g, '');
+    presenceUrl = '/api/presence/' + path;
+
+    indicator = document.createElement('span');
+    indicator.className = 'presence';
+    indicator.style.display = 'none';
+    statusbar.prepend(indicator);
+
+    heartbeat();
+    timer = setInterval(heartbeat, HEARTBEAT_INTERVAL);
+    window.addEventListener('pagehide', leave);
+  }
+
+  function stop() {
+    if (timer) clearInterval(timer);
+    window.removeEventListener('pagehide', leave);
+    leave();
+    if (indicator) indicator.remove();
+    timer = null;
+    presenceUrl = null;
+    indicator = null;
+  }
+
+  window.EditorPresence = { start, stop };
+})();
@@ -266,7 +266,7 @@ function initializeEditControls() {
         saveButton.addEventListener('click', async function() {
             try {
                 const isHomepage = window.location.pathname === '/';
-                const apiPath = isHomepage ? '/api/save/' : `/api/save${window.location.pathname}`;
+                const apiPath = (isHomepage ? '/api/save/' : `/api/save${window.location.pathname}`) + window.EditorCore.getTranslationQuery();
 
                 const content = getEditorContent();
 
@@ -278,7 +278,16 @@ function initializeEditControls() {
                     body: content
                 });
 
+                const result = await response.json().catch(() => ({}));
+                if (response.status === 413) {
+                    // Too large to save; keep the editor open so nothing is lost
+                    alert(result.message || 'Document too large');
+                    return;
+                }
                 if (!response.ok) throw new Error('Failed to save content');
+                if (result.warning) {
+                    alert(result.warning);
+                }
 
                 // Update originalContent to match what was just saved
                 if (window.EditorCore) {
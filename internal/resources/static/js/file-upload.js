@@ -175,6 +175,11 @@ async function handleFileUpload(e) {
         // Clear form and show success message
         fileUploadForm.reset();
 
+        // Files scanned in quarantine appear in the list once they pass
+        if (response.status === 202 && window.DialogSystem) {
+            window.DialogSystem.showMessageDialog('Upload', data.message);
+        }
+
         // Switch to the files tab and refresh the files list
         const filesTabBtn = Array.from(document.querySelectorAll('.file-upload-tabs .tab-button')).find(btn => btn.getAttribute('data-tab') === 'files-tab');
         if (filesTabBtn) {
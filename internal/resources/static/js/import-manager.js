@@ -45,15 +45,16 @@ document.addEventListener('DOMContentLoaded', function() {
 
         // Validate file selection
         if (!importZipFile.files.length) {
-            showImportError('Please select a ZIP file to import');
+            showImportError('Please select a ZIP archive or MediaWiki XML dump to import');
             return;
         }
 
         const file = importZipFile.files[0];
 
         // Validate file type
-        if (!file.name.toLowerCase().endsWith('.zip')) {
-            showImportError('Please select a valid ZIP file');
+        const fileName = file.name.toLowerCase();
+        if (!fileName.endsWith('.zip') && !fileName.endsWith('.xml')) {
+            showImportError('Please select a ZIP archive or MediaWiki XML dump');
             return;
         }
 
@@ -249,6 +250,20 @@ document.addEventListener('DOMContentLoaded', function() {
             resultsHtml += '</ul>';
         }
 
+        if (data.warnings && data.warnings.length) {
+            resultsHtml += '<h5>Warnings:</h5>';
+            resultsHtml += '<ul class="import-errors-list">';
+
+            data.warnings.forEach(warning => {
+                // Warnings quote wikitext such as <gallery>
+                const item = document.createElement('li');
+                item.textContent = warning;
+                resultsHtml += item.outerHTML;
+            });
+
+            resultsHtml += '</ul>';
+        }
+
         // Add summary
         resultsHtml += `<p class="import-summary">Successfully imported ${data.successCount || 0} files with ${data.errorCount || 0} errors.</p>`;
 
@@ -20,7 +20,7 @@ function showMoveDocDialog() {
 
         // Don't show for homepage
         const currentPath = getCurrentDocPath();
-        if (currentPath === '' || currentPath === '/' || currentPath.toLowerCase() === 'homepage') {
+        if (currentPath === '' || currentPath === '/' || currentPath === 'pages/home') {
             window.DialogSystem.showMessageDialog('Cannot Move Homepage', 'The homepage cannot be moved or renamed.');
             return;
         }
@@ -117,7 +117,10 @@ async function handleMoveDocumentSubmit(e) {
             }
         } else {
             const errorData = await response.json().catch(() => null);
-            if (errorData && errorData.message) {
+            if (errorData && errorData.conflict) {
+                // Name what is in the way: a document or a non-empty directory
+                moveDocErrorMessage.textContent = errorData.message + ': /' + errorData.conflict.path;
+            } else if (errorData && errorData.message) {
                 // Check for specific error messages
                 if (errorData.message.includes("already exists")) {
                     moveDocErrorMessage.textContent = window.i18n ? window.i18n.t('move.target_exists') : 'Target already exists';
@@ -143,7 +146,7 @@ function updateMoveButtonVisibility() {
     if (!moveDocButton) return;
 
     const currentPath = getCurrentDocPath();
-    if (currentPath === '' || currentPath === '/' || currentPath.toLowerCase() === 'homepage') {
+    if (currentPath === '' || currentPath === '/' || currentPath === 'pages/home') {
         moveDocButton.style.display = 'none';
     } else {
         // Ensure it is visible (inline-flex) so it overrides the admin-only-button default
@@ -0,0 +1,28 @@
+// Review Reminders
+// The banner on documents past their reviewBy date lets editors mark the
+// document as reviewed through /api/review/mark, which schedules the next review.
+(function () {
+  document.addEventListener('DOMContentLoaded', () => {
+    const banner = document.querySelector('.review-banner');
+    const button = banner?.querySelector('.review-mark-button');
+    if (!button) return;
+
+    button.addEventListener('click', async () => {
+      button.disabled = true;
+      try {
+        const resp = await fetch('/api/review/mark', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ path: banner.dataset.docPath }),
+        });
+        if (!resp.ok) throw new Error('mark reviewed failed');
+        banner.remove();
+      } catch (err) {
+        console.error(err);
+        const t = (key, fallback) => (window.i18n ? window.i18n.t(key) : fallback);
+        window.showMessageDialog(t('common.error', 'Error'), t('review.mark_failed', 'Failed to mark the document as reviewed'));
+        button.disabled = false;
+      }
+    });
+  });
+})();
@@ -66,7 +66,7 @@ const WikiSearch = (function() {
                 headers: {
                     'Content-Type': 'application/json',
                 },
-                body: JSON.stringify({ query })
+                body: JSON.stringify({ query, highlight: true })
             });
 
             if (!response.ok) {
@@ -122,9 +122,9 @@ const WikiSearch = (function() {
         const pattern = new RegExp(`(${terms.join('|')})`, 'gi');
 
         const html = results.map(result => {
-            // Highlight matches in title and excerpt
-            const highlightedTitle = result.title.replace(pattern, '<span class="search-result-highlight">$1</span>');
-            const highlightedExcerpt = result.excerpt.replace(pattern, '<span class="search-result-highlight">$1</span>');
+            // Highlight matches in the title; the server highlights the excerpt
+            const highlightedTitle = escapeHtml(result.title).replace(pattern, '<span class="search-result-highlight">$1</span>');
+            const highlightedExcerpt = result.excerpt;
 
             return `
                 <div class="search-result-item">
@@ -138,6 +138,16 @@ const WikiSearch = (function() {
         searchResultsContent.innerHTML = html;
     }
 
+    /**
+     * Escape text for insertion as HTML
+     * @param {string} text - The text to escape
+     */
+    function escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+
     // Hide search results function for keyboard shortcuts
     function hideSearchResults() {
         if (searchResults) {
@@ -235,7 +235,8 @@ document.addEventListener('DOMContentLoaded', function() {
             owner: document.getElementById('wikiOwner').value.trim(),
             notice: document.getElementById('wikiNotice').value.trim(),
             timezone: document.getElementById('wikiTimezone').value.trim(),
-            private: document.getElementById('wikiPrivate').checked,
+            access_mode: document.getElementById('wikiAccessMode').value,
+            private: document.getElementById('wikiAccessMode').value === 'private',
             disable_comments: document.getElementById('wikiDisableComments').checked,
             disable_file_upload_checking: document.getElementById('wikiDisableFileUploadChecking').checked,
             enable_link_embedding: document.getElementById('wikiEnableLinkEmbedding').checked,
@@ -390,7 +391,7 @@ document.addEventListener('DOMContentLoaded', function() {
             document.getElementById('wikiLanguage').value = settings.language || 'en';
 
             // Populate content form fields
-            document.getElementById('wikiPrivate').checked = settings.private || false;
+            document.getElementById('wikiAccessMode').value = settings.access_mode || (settings.private ? 'private' : 'public');
             document.getElementById('wikiDisableComments').checked = settings.disable_comments || false;
             document.getElementById('wikiDisableFileUploadChecking').checked = settings.disable_file_upload_checking || false;
             document.getElementById('wikiEnableLinkEmbedding').checked = settings.enable_link_embedding || false;
@@ -449,6 +450,7 @@ document.addEventListener('DOMContentLoaded', function() {
                     document.getElementById('loginBanWindow').value = sec.login_ban.window_seconds;
                     document.getElementById('loginBanInitial').value = sec.login_ban.initial_ban_seconds;
                     document.getElementById('loginBanMax').value = sec.login_ban.max_ban_seconds;
+                    document.getElementById('loginErrors').value = sec.login_errors || 'detailed';
                 }
             } catch (e) {}
         } catch (error) {
@@ -471,6 +473,8 @@ document.addEventListener('DOMContentLoaded', function() {
     const passwordInput = document.getElementById('userFormPassword');
     const passwordHelp = document.getElementById('password-help');
     const userRoleSelect = document.getElementById('userRole');
+    const userDisabledGroup = document.getElementById('userDisabledGroup');
+    const userDisabledCheckbox = document.getElementById('userDisabled');
     const saveUserBtn = document.getElementById('saveUserBtn');
     const cancelUserBtn = document.getElementById('cancelUserBtn');
     
@@ -551,7 +555,14 @@ document.addEventListener('DOMContentLoaded', function() {
                 throw new Error('Failed to load users');
             }
             const data = await response.json();
-            renderUsersList(data.users);
+
+            // Who is signed in, to highlight them in the list
+            let currentUsername = null;
+            const whoamiResponse = await fetch('/api/whoami');
+            if (whoamiResponse.ok) {
+                currentUsername = (await whoamiResponse.json()).username;
+            }
+            renderUsersList(data.users, currentUsername);
 
             // Create "Add New User" button if it doesn't exist
             if (!usersListContainer.querySelector('.add-user-btn')) {
@@ -573,7 +584,7 @@ document.addEventListener('DOMContentLoaded', function() {
     }
 
     // Function to render the users list
-    function renderUsersList(users) {
+    function renderUsersList(users, currentUsername) {
         if (!usersList) return;
 
         if (!users || users.length === 0) {
@@ -581,12 +592,6 @@ document.addEventListener('DOMContentLoaded', function() {
             return;
         }
 
-        // Get current username from session for highlighting
-        const currentUsername = document.cookie
-            .split('; ')
-            .find(row => row.startsWith('session_user='))
-            ?.split('=')[1];
-
         // Sort users: admins first, then editors, then viewers, then alphabetically
         users.sort((a, b) => {
             // Get roles with fallback for backward compatibility
@@ -626,18 +631,26 @@ document.addEventListener('DOMContentLoaded', function() {
                    </div>` 
                 : '';
 
+            // Render when the user last signed in
+            const lastLoginLabel = window.i18n ? window.i18n.t('users.last_login') : 'Last sign-in';
+            const lastLogin = user.last_login
+                ? new Date(user.last_login).toLocaleString()
+                : (window.i18n ? window.i18n.t('users.never_signed_in') : 'Never');
+
             return `
-                <div class="user-item" data-username="${user.username}">
+                <div class="user-item${user.disabled ? ' disabled' : ''}" data-username="${user.username}">
                     <div class="user-info">
                         <div class="user-main-info">
                             <span class="username">${user.username}</span>
                             <span class="${roleBadgeClass}">${roleDisplay}</span>
                             ${isCurrentUser ? `<span class="current-user-badge">${window.i18n ? window.i18n.t('common.you') : 'You'}</span>` : ''}
+                            ${user.disabled ? `<span class="disabled-user-badge">${window.i18n ? window.i18n.t('users.disabled_badge') : 'Disabled'}</span>` : ''}
                         </div>
                         ${groupsHtml}
+                        <div class="user-last-login">${lastLoginLabel}: ${lastLogin}</div>
                     </div>
                     <div class="user-actions">
-                        <button class="edit-user-btn" title="Edit user" data-username="${user.username}" data-user='${JSON.stringify({role: role, is_admin: user.is_admin, groups: user.groups || []})}'>
+                        <button class="edit-user-btn" title="Edit user" data-username="${user.username}" data-user='${JSON.stringify({role: role, is_admin: user.is_admin, groups: user.groups || [], disabled: !!user.disabled})}'>
                             <i class="fa fa-pencil"></i>
                         </button>
                         ${!isCurrentUser ? `
@@ -680,6 +693,8 @@ document.addEventListener('DOMContentLoaded', function() {
         passwordHelp.style.display = 'none';
         passwordInput.required = true;
         userRoleSelect.value = 'viewer'; // Default to viewer
+        userDisabledCheckbox.checked = false;
+        userDisabledGroup.style.display = 'none';
         saveUserBtn.textContent = 'Add User';
         saveUserBtn.setAttribute('data-i18n', 'users.add_button');
 
@@ -730,6 +745,10 @@ document.addEventListener('DOMContentLoaded', function() {
         renderUserGroups();
         if (userGroupInput) userGroupInput.value = '';
 
+        // Disabling only applies to existing users
+        userDisabledCheckbox.checked = !!user.disabled;
+        userDisabledGroup.style.display = 'block';
+
         saveUserBtn.textContent = 'Update User';
         saveUserBtn.setAttribute('data-i18n', 'users.update_button');
 
@@ -803,7 +822,8 @@ document.addEventListener('DOMContentLoaded', function() {
                         username,
                         new_password: password || undefined,
                         role: role,
-                        groups: currentUserGroups
+                        groups: currentUserGroups,
+                        disabled: userDisabledCheckbox.checked
                     })
                 });
             }
@@ -873,7 +893,12 @@ document.addEventListener('DOMContentLoaded', function() {
             const response = await fetch('/api/settings/wiki');
             if (response.ok) {
                 const settings = await response.json();
-                if (settings && settings.max_upload_size) {
+                if (settings && settings.max_attachment_size) {
+                    // Attachments may have their own limit, set in the config file
+                    maxFileUploadSizeBytes = settings.max_attachment_size;
+                    maxFileUploadSizeMB = Math.round(maxFileUploadSizeBytes / (1024 * 1024));
+                    console.log(`Max upload size updated to ${maxFileUploadSizeMB}MB`);
+                } else if (settings && settings.max_upload_size) {
                     maxFileUploadSizeMB = settings.max_upload_size;
                     maxFileUploadSizeBytes = maxFileUploadSizeMB * 1024 * 1024;
                     console.log(`Max upload size updated to ${maxFileUploadSizeMB}MB`);
@@ -902,7 +927,8 @@ document.addEventListener('DOMContentLoaded', function() {
                 window_seconds: parseInt(document.getElementById('loginBanWindow').value, 10) || 30,
                 initial_ban_seconds: parseInt(document.getElementById('loginBanInitial').value, 10) || 60,
                 max_ban_seconds: parseInt(document.getElementById('loginBanMax').value, 10) || 86400
-            }
+            },
+            login_errors: document.getElementById('loginErrors').value
         };
 
         try {
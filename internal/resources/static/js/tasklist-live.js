@@ -1,6 +1,6 @@
 // Task List Live Editing
 // This script enables live checkbox toggling for admins & editors.
-// It relies on the existing /api/source and /api/save endpoints.
+// Each click is sent to /api/tasks/toggle, which updates the markdown source.
 // Note: Kanban tasks are handled by kanban-tasks.js instead.
 // Permissions and checkbox enabling are handled by tasklist-permissions.js
 (function () {
@@ -20,14 +20,14 @@
       return checkbox.closest('.kanban-column-content') !== null;
     };
 
-    // Index only non-kanban checkboxes for sequential task matching
+    // Index checkboxes in document order, which is the order of task items in the
+    // source. Kanban checkboxes are counted too but managed by kanban-tasks.js.
     // Note: Checkbox enabling is handled by tasklist-permissions.js
-    const allCheckboxes = [...container.querySelectorAll('input[type="checkbox"]')]
-      .filter(cb => !isKanbanCheckbox(cb));
-
-    allCheckboxes.forEach((cb, idx) => {
+    [...container.querySelectorAll('input[type="checkbox"]')].forEach((cb, idx) => {
       cb.dataset.cbIndex = idx;
     });
+    const allCheckboxes = [...container.querySelectorAll('input[type="checkbox"]')]
+      .filter(cb => !isKanbanCheckbox(cb));
 
     console.log(`tasklist-live.js: Managing ${allCheckboxes.length} regular task checkboxes (excluding kanban)`);
 
@@ -75,6 +75,9 @@
         return;
       }
 
+      // The click has already flipped the checkbox; read the desired state before
+      // preventDefault reverts it once this handler yields
+      const desiredChecked = target.checked;
       e.preventDefault();
       const li = target.closest('li');
       if (!li) return;
@@ -83,42 +86,23 @@
       toggleAll(true);
 
       try {
-        // 1. Fetch current markdown
-        const srcResp = await fetch(`/api/source/${docPath}`);
-        if (!srcResp.ok) throw new Error('source fetch failed');
-        const markdown = await srcResp.text();
-
-        // 2. Build updated markdown by toggling the first matching task line.
-        const lines = markdown.split(/\r?\n/);
-        const desiredChecked = !target.checked;
-        // Determine checkbox index among all rendered checkboxes
-        const cbIndex = Number(target.dataset.cbIndex);
-
-        let currentIdx = -1;
-        const updatedLines = lines.map((line) => {
-          const match = line.match(/^(\s*[-*+]\s+)\[(.| )\]\s+(.*)$/);
-          if (!match) return line; // not a task list line
-          currentIdx += 1;
-          if (currentIdx === cbIndex) {
-            const [, prefix, , restText] = match;
-            const newMark = desiredChecked ? 'x' : ' ';
-            return `${prefix}[${newMark}] ${restText}`;
-          }
-          return line;
-        });
-        if (cbIndex > currentIdx) throw new Error('checkbox index exceeds markdown task list count');
+        const translation = document.querySelector('meta[name="document-translation"]')?.content || '';
 
-        // 3. Save updated markdown
-        const saveResp = await fetch(`/api/save/${docPath}`, {
+        const resp = await fetch('/api/tasks/toggle', {
           method: 'POST',
-          headers: { 'Content-Type': 'text/markdown' },
-          body: updatedLines.join('\n'),
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({
+            path: docPath,
+            index: Number(target.dataset.cbIndex),
+            checked: desiredChecked,
+            lang: translation,
+          }),
         });
-        if (!saveResp.ok) throw new Error('save failed');
+        if (!resp.ok) throw new Error('task toggle failed');
+        const result = await resp.json();
 
-        // 4. Update UI checkbox state
-        target.checked = desiredChecked;
-        // Success - no visual feedback needed
+        // Update UI checkbox state
+        target.checked = result.checked;
       } catch (err) {
         console.error(err);
         showState(li,'error','error');
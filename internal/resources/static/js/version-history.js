@@ -72,6 +72,12 @@
         console.log("Loading versions for document path:", path);
         versionList.innerHTML = '<div class="loading-spinner">Loading versions...</div>';
 
+        // The whole history, current content included, as a git bundle
+        const bundleLink = versionHistoryDialog.querySelector('.download-history-bundle');
+        if (bundleLink) {
+            bundleLink.href = '/api/export/bundle/' + window.location.pathname.replace(/^\/+|\/+$/g, '');
+        }
+
         try {
             const apiUrl = `/api/versions/${path}`;
             console.log("Requesting versions from:", apiUrl);
@@ -0,0 +1,219 @@
+package routes
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"wiki-go/internal/config"
+)
+
+// gzipWriters reuses compressors between responses; they are expensive to allocate
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// incompressibleTypes are content types that are already compressed, or are
+// streamed, and pass through unchanged. Prefixes match whole type families.
+var incompressibleTypes = []string{
+	"image/", // except SVG, see isCompressible
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/pdf",
+	"application/octet-stream",
+	"application/vnd.openxmlformats-officedocument.",
+	"text/event-stream",
+}
+
+// isCompressible reports whether a response of contentType benefits from gzip
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if contentType == "image/svg+xml" {
+		return true
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip, honouring
+// q=0 to refuse it
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		return q > 0
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether the
+// body is large enough and of a type worth compressing, then either compresses
+// the rest or passes it through unchanged
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer // Set once the response is being compressed
+	// hijacked is set once a handler has taken over the connection
+	hijacked bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	// Responses without a body are never compressed
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// decide settles whether to compress, writes the headers and flushes the
+// buffered start of the body. large reports whether the body reached minSize.
+func (w *gzipResponseWriter) decide(large bool) error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// Sniff before compressing; afterwards the server would sniff gzip bytes
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if large && h.Get("Content-Encoding") == "" && isCompressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		// The compressed bytes differ from the ones a strong ETag identifies
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close finishes the response: small bodies are sent as they are and the
+// compressor is flushed and returned to the pool
+func (w *gzipResponseWriter) close() {
+	if w.hijacked {
+		return
+	}
+	w.decide(false)
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// Flush sends what has been written so far. A response that is flushed before
+// reaching minSize is compressed if its type allows, since more is likely to follow.
+func (w *gzipResponseWriter) Flush() {
+	w.decide(true)
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection to handlers that take over the protocol
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CompressionMiddleware gzip-compresses responses of at least
+// cfg.Server.Compression.MinSize bytes for clients that accept it. Images and
+// other already-compressed types, range requests and HEAD requests are left alone.
+func CompressionMiddleware(cfg *config.Config, next http.Handler) http.Handler {
+	minSize := cfg.Server.Compression.MinSize
+	if minSize <= 0 {
+		minSize = config.DefaultCompressionMinSize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
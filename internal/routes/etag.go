@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// startTime stands in for the modification time of embedded assets, which
+// don't have one; they can only change when the binary is replaced
+var startTime = time.Now()
+
+// fileETag is a cached content hash, valid while the file keeps its size and
+// modification time
+type fileETag struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+var (
+	diskETagsMu sync.Mutex
+	diskETags   = make(map[string]fileETag)
+
+	embeddedETagsMu sync.Mutex
+	embeddedETags   = make(map[string]string)
+)
+
+// contentETag returns a strong ETag for the content read from r
+func contentETag(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// serveStaticFile serves a file from disk with an ETag and Last-Modified.
+// http.ServeFile answers If-None-Match and If-Modified-Since from them.
+func serveStaticFile(w http.ResponseWriter, r *http.Request, filePath string) {
+	if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
+		diskETagsMu.Lock()
+		cached, ok := diskETags[filePath]
+		diskETagsMu.Unlock()
+
+		if !ok || cached.size != info.Size() || !cached.modTime.Equal(info.ModTime()) {
+			if f, err := os.Open(filePath); err == nil {
+				etag, err := contentETag(f)
+				f.Close()
+				if err == nil {
+					cached = fileETag{size: info.Size(), modTime: info.ModTime(), etag: etag}
+					diskETagsMu.Lock()
+					diskETags[filePath] = cached
+					diskETagsMu.Unlock()
+					ok = true
+				}
+			}
+		}
+		if ok {
+			w.Header().Set("ETag", cached.etag)
+		}
+	}
+	http.ServeFile(w, r, filePath)
+}
+
+// serveEmbeddedStatic serves name from the embedded static files with an ETag
+// and the server start time as Last-Modified, answering conditional requests.
+// Directories and missing files are left to http.FileServer.
+func serveEmbeddedStatic(w http.ResponseWriter, r *http.Request, fsys http.FileSystem, name string) {
+	name = path.Clean("/" + name)
+	f, err := fsys.Open(name)
+	if err != nil {
+		http.StripPrefix("/static", http.FileServer(fsys)).ServeHTTP(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.StripPrefix("/static", http.FileServer(fsys)).ServeHTTP(w, r)
+		return
+	}
+
+	embeddedETagsMu.Lock()
+	etag, ok := embeddedETags[name]
+	embeddedETagsMu.Unlock()
+	if !ok {
+		if etag, err = contentETag(f); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		embeddedETagsMu.Lock()
+		embeddedETags[name] = etag
+		embeddedETagsMu.Unlock()
+	}
+
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, info.Name(), startTime, f)
+}
@@ -0,0 +1,342 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"wiki-go/internal/auth"
+	"wiki-go/internal/config"
+	"wiki-go/internal/ipfilter"
+	"wiki-go/internal/logging"
+	"wiki-go/internal/metrics"
+	"wiki-go/internal/utils"
+)
+
+// statusRecorder wraps an http.ResponseWriter to capture the status code
+// and number of bytes written by downstream handlers
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Flush forwards flushes so streaming handlers keep working through the recorder
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// routePatternKey is the context key of the route pattern recorded by
+// RoutePatternRecorder
+type routePatternKey struct{}
+
+// RoutePatternRecorder wraps the ServeMux to record the pattern it matched for
+// middleware further out. ServeMux stores the pattern on the request it is
+// handed, which isn't the request outer middleware holds once any middleware in
+// between adds to the context, as the CSP nonce does.
+func RoutePatternRecorder(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pattern, ok := r.Context().Value(routePatternKey{}).(*string); ok {
+			defer func() { *pattern = r.Pattern }()
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// MetricsMiddleware records request counts and latencies per route pattern
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+
+		pattern := new(string)
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routePatternKey{}, pattern)))
+
+		// The matched pattern, instead of the raw path, keeps label cardinality
+		// bounded
+		handler := *pattern
+		if handler == "" {
+			handler = "unmatched"
+		}
+		metrics.HTTPRequests.Inc(handler, r.Method, strconv.Itoa(rec.status))
+		metrics.HTTPRequestDuration.ObserveDuration(start, handler)
+	})
+}
+
+// RecoveryMiddleware turns a panic in a handler into a 500 response instead of
+// a dropped connection. The panic is logged with the request's correlation ID
+// and stack and counted in the metrics; the response only carries the ID, so
+// no internals leak. When the handler had started its response already, the
+// connection is aborted like net/http would, so the client doesn't take a
+// truncated response for a complete one.
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := newStatusRecorder(w)
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			handler := r.Pattern
+			if handler == "" {
+				handler = "unmatched"
+			}
+			metrics.HTTPPanics.Inc(handler)
+			logging.FromContext(r.Context()).Error("panic serving request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(p),
+				"stack", string(debug.Stack()))
+
+			if rec.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			writeInternalError(w, r)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// writeInternalError answers with a 500 that names the request's correlation
+// ID, as JSON to API requests and as a page otherwise
+func writeInternalError(w http.ResponseWriter, r *http.Request) {
+	requestID := logging.RequestID(r.Context())
+	// Drop what the handler prepared for the response it didn't send
+	for _, header := range []string{"Content-Disposition", "Content-Encoding", "Content-Length", "ETag", "Last-Modified"} {
+		w.Header().Del(header)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if strings.HasPrefix(r.URL.Path, "/api/") || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":   false,
+			"message":   "Internal server error",
+			"requestId": requestID,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>500 - Internal Server Error</title></head>
+<body><h1>Internal Server Error</h1><p>Something went wrong while serving this page.</p><p>Request ID: <code>%s</code></p></body></html>
+`, html.EscapeString(requestID))
+}
+
+// RequestLoggingMiddleware assigns each request a correlation ID, exposes it in the
+// X-Request-ID response header and request context, and emits one structured log
+// record per request once the response has been written.
+func RequestLoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Honour an upstream correlation ID (e.g. from a reverse proxy) when it looks sane
+		requestID := r.Header.Get(logging.RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set(logging.RequestIDHeader, requestID)
+		r = r.WithContext(logging.WithRequestID(r.Context(), requestID))
+
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", rec.bytes,
+		}
+		if session := auth.PeekSession(r); session != nil {
+			attrs = append(attrs, "user", session.Username)
+		}
+		logging.FromContext(r.Context()).Info("request", attrs...)
+	})
+}
+
+// BodyLimitMiddleware rejects request bodies larger than
+// server.limits.max_body_size with 413, so a client can't tie up memory with
+// a huge JSON body. Bodies that declare their length are refused before
+// reading; others fail once they pass the limit. Document content and files
+// are left to their handlers, which bound them by wiki.max_document_size and
+// wiki.max_upload_size.
+func BodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Current()
+		limit := int64(cfg.Server.Limits.MaxBodySize) * 1024
+		if limit <= 0 || r.Body == nil || r.Body == http.NoBody || carriesContent(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength > limit {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": fmt.Sprintf("Request body too large. Maximum size is %dKB.", cfg.Server.Limits.MaxBodySize),
+			})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// carriesContent reports whether r sends document content or files, whose
+// size is limited by the handlers of their routes instead
+func carriesContent(r *http.Request) bool {
+	p := r.URL.Path
+	switch {
+	case strings.HasPrefix(p, "/dav/"), strings.HasPrefix(p, "/api/save/"):
+		return true
+	case r.Method == http.MethodPut && strings.HasPrefix(p, "/api/document/"):
+		return true
+	}
+	switch p {
+	case "/api/files/upload", "/api/import", "/api/render-markdown", "/api/suggestions":
+		return true
+	}
+	return false
+}
+
+// IPFilterMiddleware rejects requests from client addresses outside
+// cfg.Server.AllowedCIDRs or inside cfg.Server.DeniedCIDRs with 403. It runs
+// before any handler, so denied clients can't reach the login form either.
+func IPFilterMiddleware(cfg *config.Config, next http.Handler) http.Handler {
+	filter, err := ipfilter.New(cfg.Server.AllowedCIDRs, cfg.Server.DeniedCIDRs, cfg.Server.TrustProxy)
+	if err != nil {
+		log.Fatalf("Invalid IP filter configuration: %v", err)
+	}
+	if !filter.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !filter.AllowRequest(r) {
+			logging.FromContext(r.Context()).Warn("request from disallowed address", "remote_addr", r.RemoteAddr)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HTTPSRedirectHandler answers every plain HTTP request with a permanent
+// redirect to the same URL over HTTPS on server.port
+func HTTPSRedirectHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		if host == "" {
+			http.Error(w, "Host header required", http.StatusBadRequest)
+			return
+		}
+		if cfg.Server.Port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(cfg.Server.Port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		// 308 keeps the method and body of form posts; older clients know 301 better
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+// CanonicalURLMiddleware redirects page requests to the canonical form of their
+// URL, so every page is reachable at one address: the trailing slash is
+// stripped or added as server.trailing_slash asks, and a category's
+// document.md leads to the category itself. It only wraps the page handler;
+// API and static routes keep their own URLs.
+func CanonicalURLMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Current()
+		canonical := canonicalPagePath(r.URL.Path, cfg.Server.TrailingSlash)
+		if canonical == r.URL.Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		target := url.URL{Path: canonical, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, target.String(), code)
+	})
+}
+
+// canonicalPagePath returns the canonical form of the page path p in the given
+// trailing slash style. The homepage is always "/".
+func canonicalPagePath(p, style string) string {
+	clean := path.Clean("/" + p)
+	if utils.IsIndexFile(path.Base(clean)) {
+		clean = path.Dir(clean)
+	}
+	if clean == "/" {
+		return clean
+	}
+
+	switch style {
+	case config.TrailingSlashStrip:
+		return clean
+	case config.TrailingSlashAdd:
+		return clean + "/"
+	}
+	if strings.HasSuffix(p, "/") {
+		return clean + "/"
+	}
+	return clean
+}
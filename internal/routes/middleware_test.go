@@ -0,0 +1,224 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/logging"
+	"wiki-go/internal/metrics"
+)
+
+// setTestConfig makes cfg the running configuration for the rest of the test
+func setTestConfig(t *testing.T, cfg *config.Config) {
+	previous := config.Current()
+	config.SetCurrent(cfg)
+	t.Cleanup(func() { config.SetCurrent(previous) })
+}
+
+func TestHTTPSRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     int
+		method   string
+		host     string
+		target   string
+		code     int
+		location string
+	}{
+		{"Default port", 443, http.MethodGet, "wiki.example.com", "/guides/setup?lang=de", http.StatusMovedPermanently, "https://wiki.example.com/guides/setup?lang=de"},
+		{"Port of the plain listener dropped", 443, http.MethodHead, "wiki.example.com:80", "/", http.StatusMovedPermanently, "https://wiki.example.com/"},
+		{"Other HTTPS port", 8443, http.MethodGet, "wiki.example.com:8080", "/a", http.StatusMovedPermanently, "https://wiki.example.com:8443/a"},
+		{"IPv6 host", 443, http.MethodGet, "[::1]:80", "/", http.StatusMovedPermanently, "https://[::1]/"},
+		{"Post keeps its method", 443, http.MethodPost, "wiki.example.com", "/api/login", http.StatusPermanentRedirect, "https://wiki.example.com/api/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Server.Port = tt.port
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			HTTPSRedirectHandler(cfg).ServeHTTP(rec, req)
+
+			if rec.Code != tt.code || rec.Header().Get("Location") != tt.location {
+				t.Errorf("got %d %q, want %d %q", rec.Code, rec.Header().Get("Location"), tt.code, tt.location)
+			}
+		})
+	}
+}
+
+func TestCanonicalURLMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    string
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{"Stripped", config.TrailingSlashStrip, http.MethodGet, "/guides/setup/", http.StatusMovedPermanently, "/guides/setup"},
+		{"Already stripped", config.TrailingSlashStrip, http.MethodGet, "/guides/setup", http.StatusOK, ""},
+		{"Added", config.TrailingSlashAdd, http.MethodGet, "/guides/setup?mode=edit", http.StatusMovedPermanently, "/guides/setup/?mode=edit"},
+		{"Already added", config.TrailingSlashAdd, http.MethodGet, "/guides/setup/", http.StatusOK, ""},
+		{"Homepage", config.TrailingSlashStrip, http.MethodGet, "/", http.StatusOK, ""},
+		{"Kept", config.TrailingSlashKeep, http.MethodGet, "/guides/setup/", http.StatusOK, ""},
+		{"Category document", config.TrailingSlashStrip, http.MethodGet, "/guides/document.md", http.StatusMovedPermanently, "/guides"},
+		{"Category document kept", config.TrailingSlashKeep, http.MethodGet, "/guides/document.md", http.StatusMovedPermanently, "/guides"},
+		{"Escaped characters", config.TrailingSlashStrip, http.MethodGet, "/a%20b/", http.StatusMovedPermanently, "/a%20b"},
+		{"Post keeps its method", config.TrailingSlashStrip, http.MethodPost, "/guides/", http.StatusPermanentRedirect, "/guides"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Server.TrailingSlash = tt.style
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			rec := httptest.NewRecorder()
+			setTestConfig(t, cfg)
+			CanonicalURLMiddleware(next).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.code || rec.Header().Get("Location") != tt.location {
+				t.Errorf("got %d %q, want %d %q", rec.Code, rec.Header().Get("Location"), tt.code, tt.location)
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		handler     http.HandlerFunc
+		code        int
+		contentType string
+	}{
+		{"API request", "/api/save/a", "", func(w http.ResponseWriter, r *http.Request) { panic("boom") }, http.StatusInternalServerError, "application/json"},
+		{"Page", "/guides", "text/html", func(w http.ResponseWriter, r *http.Request) { panic("boom") }, http.StatusInternalServerError, "text/html; charset=utf-8"},
+		{"JSON accepted", "/guides", "application/json", func(w http.ResponseWriter, r *http.Request) { panic("boom") }, http.StatusInternalServerError, "application/json"},
+		{"Prepared headers dropped", "/api/export/a", "", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", "attachment")
+			panic("boom")
+		}, http.StatusInternalServerError, "application/json"},
+		{"No panic", "/guides", "", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }, http.StatusTeapot, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req = req.WithContext(logging.WithRequestID(req.Context(), "abc123"))
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			RecoveryMiddleware(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if got := rec.Header().Get("Content-Type"); tt.contentType != "" && got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if tt.code == http.StatusInternalServerError {
+				if !strings.Contains(rec.Body.String(), "abc123") || strings.Contains(rec.Body.String(), "boom") {
+					t.Errorf("body = %q, want the request ID and not the panic", rec.Body.String())
+				}
+				if rec.Header().Get("Content-Disposition") != "" {
+					t.Error("Content-Disposition of the failed response kept")
+				}
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddlewareAbortsStartedResponses(t *testing.T) {
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/guides", nil))
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int // server.limits.max_body_size in KB
+		method  string
+		target  string
+		size    int
+		chunked bool // Send the body without a Content-Length
+		code    int
+	}{
+		{"Small body", 1, http.MethodPost, "/api/document/move", 100, false, http.StatusOK},
+		{"Declared too large", 1, http.MethodPost, "/api/document/move", 2048, false, http.StatusRequestEntityTooLarge},
+		{"Streamed too large", 1, http.MethodPost, "/api/document/move", 2048, true, http.StatusRequestEntityTooLarge},
+		{"Document content", 1, http.MethodPost, "/api/save/guides", 2048, false, http.StatusOK},
+		{"Document put", 1, http.MethodPut, "/api/document/guides", 2048, false, http.StatusOK},
+		{"Document post", 1, http.MethodPost, "/api/document/guides", 2048, false, http.StatusRequestEntityTooLarge},
+		{"Upload", 1, http.MethodPost, "/api/files/upload", 2048, false, http.StatusOK},
+		{"Unlimited", 0, http.MethodPost, "/api/document/move", 2048, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Server.Limits.MaxBodySize = tt.limit
+			setTestConfig(t, cfg)
+			handler := BodyLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := io.ReadAll(r.Body); err != nil {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				}
+			}))
+
+			var body io.Reader = strings.NewReader(strings.Repeat("a", tt.size))
+			if tt.chunked {
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(tt.method, tt.target, body)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
+
+// The route pattern reaches the metrics through middleware that replaces the
+// request, like the CSP nonce does
+func TestMetricsMiddlewareLabelsRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/metrics-test/{path...}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /api/metrics-panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	cfg := &config.Config{}
+	setTestConfig(t, cfg)
+	handler := MetricsMiddleware(SecurityHeadersMiddleware(cfg, RecoveryMiddleware(RoutePatternRecorder(mux))))
+
+	for _, target := range []string{"/api/metrics-test/a/b", "/api/metrics-panic"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	var out strings.Builder
+	metrics.Write(&out)
+	for _, want := range []string{
+		`wikigo_http_requests_total{handler="GET /api/metrics-test/{path...}",method="GET",code="200"} 1`,
+		`wikigo_http_requests_total{handler="GET /api/metrics-panic",method="GET",code="500"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
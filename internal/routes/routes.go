@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io/fs"
@@ -10,7 +11,9 @@ import (
 	"path/filepath"
 	"strings"
 	"wiki-go/internal/auth"
+	"wiki-go/internal/challenge"
 	"wiki-go/internal/config"
+	"wiki-go/internal/csp"
 	"wiki-go/internal/handlers"
 	"wiki-go/internal/resources"
 )
@@ -46,105 +49,131 @@ func addCacheControlHeaders(w http.ResponseWriter, filename string) {
 	}
 }
 
-// CSPMiddleware adds Content Security Policy headers to all responses
-func CSPMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set a less restrictive Content Security Policy that allows inline scripts and styles
-		csp := []string{
-			// Default to allowing same-origin resources and inline scripts/styles
-			"default-src 'self' 'unsafe-inline' 'unsafe-eval'",
-			// Allow inline styles and styles from same origin
-			"style-src 'self' 'unsafe-inline'",
-			// Allow scripts from same origin and inline scripts
-			"script-src 'self' 'unsafe-inline' 'unsafe-eval'",
-			// Images from same origin and data: URLs (for embedded images)
-			"img-src 'self' data: https://*.ytimg.com https://*.vimeocdn.com",
-			// Connect only to same origin
-			"connect-src 'self'",
-			// Fonts from same origin
-			"font-src 'self'",
-			// Allow object/embed only from same origin
-			"object-src 'self'",
-			// Media only from same origin
-			"media-src 'self' https://*.youtube.com https://*.vimeo.com",
-			// Allow frames from YouTube and Vimeo for video embeds
-			"frame-src 'self' https://*.youtube.com https://*.vimeo.com",
-			// Form submissions only to same origin
-			"form-action 'self'",
-			// Base URI restricted to same origin
-			"base-uri 'self'",
-		}
+// defaultCSP is the Content-Security-Policy used when cfg.Server.Headers doesn't override it.
+// Inline scripts must carry the per-request nonce; eval is still needed by MathJax and Mermaid.
+var defaultCSP = []string{
+	// Default to allowing same-origin resources only
+	"default-src 'self'",
+	// Allow inline styles and styles from same origin
+	"style-src 'self' 'unsafe-inline'",
+	// Allow scripts from same origin and nonced inline scripts
+	"script-src 'self' 'nonce-" + csp.Placeholder + "' 'unsafe-eval'",
+	// Images from same origin, data: URLs and any HTTPS source (documents may embed remote images)
+	"img-src 'self' data: https:",
+	// Connect only to same origin
+	"connect-src 'self'",
+	// Fonts from same origin
+	"font-src 'self'",
+	// Allow object/embed only from same origin
+	"object-src 'self'",
+	// Media only from same origin
+	"media-src 'self' https://*.youtube.com https://*.vimeo.com",
+	// Allow frames from YouTube and Vimeo for video embeds
+	"frame-src 'self' https://*.youtube.com https://*.vimeo.com",
+	// Form submissions only to same origin
+	"form-action 'self'",
+	// Base URI restricted to same origin
+	"base-uri 'self'",
+}
 
-		// Set the CSP header - using Content-Security-Policy-Report-Only first to avoid breaking things
-		// This will report violations but not enforce them
-		w.Header().Set("Content-Security-Policy-Report-Only", strings.Join(csp, "; "))
+// captchaSources are the origins the widget of each comment CAPTCHA provider
+// loads from, by directive. The default policy only allows those of the
+// configured provider.
+var captchaSources = map[string]map[string]string{
+	challenge.ProviderHCaptcha: {
+		"script-src":  "https://js.hcaptcha.com https://*.hcaptcha.com",
+		"connect-src": "https://*.hcaptcha.com",
+		"frame-src":   "https://*.hcaptcha.com",
+	},
+	challenge.ProviderTurnstile: {
+		"script-src": "https://challenges.cloudflare.com",
+		"frame-src":  "https://challenges.cloudflare.com",
+	},
+}
 
-		// Once you've fixed all the violations, you can switch to enforcing mode:
-		// w.Header().Set("Content-Security-Policy", strings.Join(csp, "; "))
+// defaultPolicy returns defaultCSP for a wiki whose comments use the CAPTCHA
+// provider, with frame-ancestors mirroring the X-Frame-Options header
+func defaultPolicy(provider, frameOptions string) string {
+	directives := make([]string, 0, len(defaultCSP)+1)
+	for _, directive := range defaultCSP {
+		name, _, _ := strings.Cut(directive, " ")
+		if sources := captchaSources[provider][name]; sources != "" {
+			directive += " " + sources
+		}
+		directives = append(directives, directive)
+	}
+	// Mirror X-Frame-Options for browsers that only honour frame-ancestors
+	switch strings.ToUpper(frameOptions) {
+	case "DENY":
+		directives = append(directives, "frame-ancestors 'none'")
+	case "SAMEORIGIN":
+		directives = append(directives, "frame-ancestors 'self'")
+	}
+	return strings.Join(directives, "; ")
+}
 
-		// Add other security headers
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+// SecurityHeadersMiddleware adds Content-Security-Policy, HSTS and other protective
+// headers to all responses, as configured under cfg.Server.Headers. A fresh nonce is
+// generated for every request and stored in its context for the templates; custom
+// policies can reference it with the {nonce} placeholder.
+func SecurityHeadersMiddleware(cfg *config.Config, next http.Handler) http.Handler {
+	headers := cfg.Server.Headers
+
+	// The default policy depends on the CAPTCHA provider, which a reload can
+	// change, so there is one for each
+	custom := strings.TrimSpace(headers.ContentSecurityPolicy)
+	policies := map[string]string{"": defaultPolicy("", headers.FrameOptions)}
+	for provider := range captchaSources {
+		policies[provider] = defaultPolicy(provider, headers.FrameOptions)
+	}
+	cspHeader := "Content-Security-Policy"
+	if headers.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
 
-		// Add preload header for emojis.json to avoid AJAX loading
-		// This works by telling the browser to preload this resource before it's needed
-		if strings.HasSuffix(r.URL.Path, ".html") || r.URL.Path == "/" || strings.HasSuffix(r.URL.Path, "/") {
-			w.Header().Add("Link", "</api/data/emojis>; rel=preload; as=fetch; crossorigin=anonymous")
+	hsts := ""
+	if headers.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", headers.HSTSMaxAge)
+		if headers.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
 		}
+	}
 
-		// Call the next handler
-		next.ServeHTTP(w, r)
-	})
-}
-
-/*
-// Example of how to implement nonce-based CSP (for future reference)
-func CSPMiddlewareWithNonce(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Generate a random nonce for this request
-		nonce := generateNonce()
-
-		// Store the nonce in the request context so templates can access it
-		ctx := context.WithValue(r.Context(), "csp-nonce", nonce)
-		r = r.WithContext(ctx)
-
-		// Set a strict Content Security Policy with nonce
-		csp := []string{
-			// Default to only allowing same-origin resources
-			"default-src 'self'",
-			// Allow styles from same origin and inline styles with nonce
-			fmt.Sprintf("style-src 'self' 'nonce-%s'", nonce),
-			// Allow scripts from same origin and inline scripts with nonce
-			fmt.Sprintf("script-src 'self' https://cdn.jsdelivr.net 'nonce-%s'", nonce),
-			// Images from same origin and data: URLs
-			"img-src 'self' data:",
-			// Other directives...
+		policy := custom
+		if policy == "" {
+			policy = cmp.Or(policies[config.Current().Wiki.Comments.Challenge.Provider], policies[""])
+		}
+		nonce := csp.NewNonce()
+		r = r.WithContext(csp.WithNonce(r.Context(), nonce))
+		if policy != "-" {
+			w.Header().Set(cspHeader, strings.ReplaceAll(policy, csp.Placeholder, nonce))
 		}
 
-		// Set the CSP header
-		w.Header().Set("Content-Security-Policy", strings.Join(csp, "; "))
+		// HSTS is only meaningful (and only honoured) over HTTPS
+		if hsts != "" && (r.TLS != nil || (cfg.Server.TrustProxy && r.Header.Get("X-Forwarded-Proto") == "https")) {
+			w.Header().Set("Strict-Transport-Security", hsts)
+		}
 
-		// Add other security headers
 		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if headers.FrameOptions != "" {
+			w.Header().Set("X-Frame-Options", headers.FrameOptions)
+		}
+		if headers.ReferrerPolicy != "" {
+			w.Header().Set("Referrer-Policy", headers.ReferrerPolicy)
+		}
+
+		// Add preload header for emojis.json to avoid AJAX loading
+		// This works by telling the browser to preload this resource before it's needed
+		if strings.HasSuffix(r.URL.Path, ".html") || r.URL.Path == "/" || strings.HasSuffix(r.URL.Path, "/") {
+			w.Header().Add("Link", "</api/data/emojis>; rel=preload; as=fetch; crossorigin=anonymous")
+		}
 
 		// Call the next handler
 		next.ServeHTTP(w, r)
 	})
 }
 
-// Generate a random nonce for CSP
-func generateNonce() string {
-	b := make([]byte, 16)
-	rand.Read(b)
-	return base64.StdEncoding.EncodeToString(b)
-}
-*/
-
 // Helper function to check if a file exists
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -169,7 +198,7 @@ func handleFaviconRequest(w http.ResponseWriter, r *http.Request, cfg *config.Co
 	// Check if this specific favicon exists in custom path
 	customPath := filepath.Join(cfg.Wiki.RootDir, "static", "favicon."+format)
 	if fileExists(customPath) {
-		http.ServeFile(w, r, customPath)
+		serveStaticFile(w, r, customPath)
 		return
 	}
 
@@ -180,11 +209,33 @@ func handleFaviconRequest(w http.ResponseWriter, r *http.Request, cfg *config.Co
 	}
 
 	// Fallback to embedded favicon only if no custom favicons exist
-	http.ServeFile(w, r, filepath.Join("internal", "resources", "static", "favicon."+format))
+	serveStaticFile(w, r, filepath.Join("internal", "resources", "static", "favicon."+format))
 }
 
 // SetupRoutes configures all routes for the application
 func SetupRoutes(cfg *config.Config) {
+	mux := newRouter(cfg)
+
+	// Apply middleware to all routes. Panics are recovered innermost, so the
+	// 500 they turn into is compressed, logged and counted like any response.
+	var handler http.Handler = RecoveryMiddleware(RoutePatternRecorder(mux))
+	if cfg.Server.Compression.Enabled {
+		handler = CompressionMiddleware(cfg, handler)
+	}
+	handler = BodyLimitMiddleware(handler)
+	handler = SecurityHeadersMiddleware(cfg, handler)
+	handler = IPFilterMiddleware(cfg, handler)
+	if cfg.Server.Metrics.Enabled {
+		handler = MetricsMiddleware(handler)
+	}
+	handler = RequestLoggingMiddleware(handler)
+
+	// Set the handler for the default ServeMux
+	http.Handle("/", handler)
+}
+
+// newRouter registers every route of the wiki on a new ServeMux
+func newRouter(cfg *config.Config) *http.ServeMux {
 	// Create a new ServeMux to apply middleware to all routes
 	mux := http.NewServeMux()
 
@@ -221,6 +272,7 @@ func SetupRoutes(cfg *config.Config) {
 
 	// Serve static files with custom handling to check data/static first
 	mux.HandleFunc("/static/", func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Current()
 		// Extract the file path from the URL
 		filename := strings.TrimPrefix(r.URL.Path, "/static/")
 
@@ -229,7 +281,8 @@ func SetupRoutes(cfg *config.Config) {
 			w.Header().Set("Content-Type", "application/javascript")
 			// Inject the file extensions configuration
 			fmt.Fprintf(w, "// File extensions configuration - dynamically generated\n")
-			fmt.Fprintf(w, "var ALLOWED_FILE_EXTENSIONS = %s;\n", config.GetAllowedExtensionsJSON())
+			allowed, _ := json.Marshal(config.GetAllowedUploadExtensions(cfg))
+			fmt.Fprintf(w, "var ALLOWED_FILE_EXTENSIONS = %s;\n", allowed)
 			fmt.Fprintf(w, "var FILE_EXTENSION_MIME_TYPES = %s;\n", config.GetExtensionMimeTypesJSON())
 			return
 		}
@@ -250,7 +303,7 @@ func SetupRoutes(cfg *config.Config) {
 		customPath := filepath.Join(cfg.Wiki.RootDir, "static", filename)
 		if fileExists(customPath) {
 			// File exists in data/static, serve it directly
-			http.ServeFile(w, r, customPath)
+			serveStaticFile(w, r, customPath)
 			return
 		}
 
@@ -262,42 +315,44 @@ func SetupRoutes(cfg *config.Config) {
 		}
 
 		// Fall back to embedded static files
-		http.StripPrefix("/static/", http.FileServer(resources.GetFileSystem())).ServeHTTP(w, r)
+		serveEmbeddedStatic(w, r, resources.GetFileSystem(), filename)
 	})
 
 	// Serve favicons directly from root path
 	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
-		handleFaviconRequest(w, r, cfg, "ico")
+		handleFaviconRequest(w, r, config.Current(), "ico")
 	})
 
 	mux.HandleFunc("/favicon.png", func(w http.ResponseWriter, r *http.Request) {
-		handleFaviconRequest(w, r, cfg, "png")
+		handleFaviconRequest(w, r, config.Current(), "png")
 	})
 
 	mux.HandleFunc("/favicon.svg", func(w http.ResponseWriter, r *http.Request) {
-		handleFaviconRequest(w, r, cfg, "svg")
+		handleFaviconRequest(w, r, config.Current(), "svg")
 	})
 
 	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Current()
 		// Add cache headers for logo (1 week)
 		w.Header().Set("Cache-Control", "public, max-age=604800")
 
 		customPath := filepath.Join(cfg.Wiki.RootDir, "static", "logo.png")
 		if _, err := os.Stat(customPath); err == nil {
-			http.ServeFile(w, r, customPath)
+			serveStaticFile(w, r, customPath)
 			return
 		}
-		http.ServeFile(w, r, filepath.Join("internal", "resources", "static", "logo.png"))
+		serveStaticFile(w, r, filepath.Join("internal", "resources", "static", "logo.png"))
 	})
 
 	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Current()
 		w.Header().Set("Content-Type", "application/manifest+json")
 		w.Header().Set("Cache-Control", "public, max-age=604800")
 
 		// Check if custom manifest exists
 		customPath := filepath.Join(cfg.Wiki.RootDir, "static", "manifest.json")
 		if fileExists(customPath) {
-			http.ServeFile(w, r, customPath)
+			serveStaticFile(w, r, customPath)
 			return
 		}
 
@@ -328,10 +383,30 @@ func SetupRoutes(cfg *config.Config) {
 		}
 	})
 
+	// Health and readiness probes - No auth required, even for private wikis
+	mux.HandleFunc("/healthz", handlers.HealthHandler)
+	mux.HandleFunc("/readyz", handlers.ReadyHandler)
+
+	// Prometheus metrics - only on the main listener when no dedicated address is configured
+	if cfg.Server.Metrics.Enabled && cfg.Server.Metrics.Listen == "" {
+		mux.HandleFunc("/metrics", handlers.MetricsHandler)
+	}
+
+	// WebDAV access to the documents tree, checked against server.webdav.enabled per request
+	mux.HandleFunc("/dav/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.WebDAVHandler(w, r, config.Current())
+	})
+
 	// API Routes
+	mux.HandleFunc("/api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		handlers.OpenAPIHandler(w, r, config.Current())
+	})
 	mux.HandleFunc("/api/login", handlers.LoginHandler)
 	mux.HandleFunc("/api/check-auth", handlers.CheckAuthHandler)
+	mux.HandleFunc("/api/whoami", handlers.WhoamiHandler)
 	mux.HandleFunc("/api/logout", handlers.LogoutHandler)
+	mux.HandleFunc("/api/refresh", handlers.RefreshHandler)
+	mux.HandleFunc("/api/session/status", handlers.SessionStatusHandler)
 	mux.HandleFunc("/api/check-default-password", handlers.CheckDefaultPasswordHandler)
 	mux.HandleFunc("/api/document/create", handlers.CreateDocumentHandler)
 	mux.HandleFunc("/api/document/", handlers.DocumentHandler)
@@ -340,58 +415,92 @@ func SetupRoutes(cfg *config.Config) {
 
 	// File API Routes
 	mux.HandleFunc("/api/files/upload", func(w http.ResponseWriter, r *http.Request) {
-		handlers.UploadFileHandler(w, r, cfg)
+		handlers.UploadFileHandler(w, r, config.Current())
 	})
 
 	mux.HandleFunc("/api/files/list/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ListFilesHandler(w, r, cfg)
+		handlers.ListFilesHandler(w, r, config.Current())
 	})
 
 	mux.HandleFunc("/api/files/delete/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.DeleteFileHandler(w, r, cfg)
+		handlers.DeleteFileHandler(w, r, config.Current())
 	})
 
 	mux.HandleFunc("/api/files/rename", func(w http.ResponseWriter, r *http.Request) {
-		handlers.RenameFileHandler(w, r, cfg)
+		handlers.RenameFileHandler(w, r, config.Current())
 	})
 
 	mux.HandleFunc("/api/files/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ServeFileHandler(w, r, cfg)
+		handlers.ServeFileHandler(w, r, config.Current())
+	})
+
+	// Export Routes
+	mux.HandleFunc("/api/export/docx/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ExportDocxHandler(w, r, config.Current())
 	})
+	mux.HandleFunc("/api/export/zip", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ExportZipHandler(w, r, config.Current())
+	})
+	// Version history as a git bundle - Editor or Admin, like the history itself
+	mux.HandleFunc("/api/export/bundle/", editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handlers.ExportBundleHandler(w, r, config.Current())
+	}))
 
 	// Comment API Routes
 	mux.HandleFunc("/api/comments/add/", handlers.AddCommentHandler)
+	mux.HandleFunc("/api/comments/challenge", handlers.CommentChallengeHandler)
 	mux.HandleFunc("/api/comments/delete/", handlers.DeleteCommentHandler)
 	mux.HandleFunc("/api/comments/", handlers.GetCommentsHandler)
 
 	// Search handler with wrapper to include config
 	mux.HandleFunc("/api/search", func(w http.ResponseWriter, r *http.Request) {
-		handlers.SearchHandler(w, r, cfg)
+		handlers.SearchHandler(w, r, config.Current())
 	})
 
 	// Settings API - Admin only
 	mux.HandleFunc("/api/settings/wiki", adminMiddleware(handlers.WikiSettingsHandler))
 	mux.HandleFunc("/api/settings/security", adminMiddleware(handlers.SecuritySettingsHandler))
+	mux.HandleFunc("/api/settings/reload", adminMiddleware(handlers.ReloadConfigHandler))
 
 	// User Management API - Admin only
 	mux.HandleFunc("/api/users", adminMiddleware(handlers.UsersHandler))
 
+	// Wiki statistics - Admin only
+	mux.HandleFunc("/api/stats", adminMiddleware(handlers.StatsHandler))
+
+	// Integrity check of versions and comments - Admin only
+	mux.HandleFunc("/api/integrity", adminMiddleware(handlers.IntegrityHandler))
+	mux.HandleFunc("/api/integrity/cleanup", adminMiddleware(handlers.IntegrityHandler))
+
+	// Rebuild of the indexes and caches derived from the documents - Admin only
+	mux.HandleFunc("/api/reindex", adminMiddleware(handlers.ReindexHandler))
+
 	// Access Rules API - Admin only
 	mux.HandleFunc("/api/access-rules", adminMiddleware(handlers.AccessRulesHandler))
 	mux.HandleFunc("/api/access-rules/", adminMiddleware(handlers.AccessRulesHandler))
 	mux.HandleFunc("/api/folders", adminMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.ListFoldersHandler(w, r, cfg)
+		handlers.ListFoldersHandler(w, r, config.Current())
 	}))
 
 	// Version history API - Editor or Admin
 	mux.HandleFunc("/api/versions/", editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.VersionsHandler(w, r, cfg)
+		handlers.VersionsHandler(w, r, config.Current())
+	}))
+
+	// Trash API - Editor or Admin; deleting entries for good is admin only
+	mux.HandleFunc("/api/trash", editorMiddleware(handlers.TrashHandler))
+	mux.HandleFunc("/api/trash/", editorMiddleware(handlers.TrashHandler))
+	mux.HandleFunc("/api/trash/restore", editorMiddleware(handlers.RestoreTrashHandler))
+
+	// Document move/rename and merge API - Editor or Admin
+	mux.HandleFunc("/api/category/order", editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryOrderHandler(w, r, config.Current())
 	}))
 
-	// Document move/rename API - Editor or Admin
 	mux.HandleFunc("/api/document/move", editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.MoveDocumentHandler(w, r, cfg)
+		handlers.MoveDocumentHandler(w, r, config.Current())
 	}))
+	mux.HandleFunc("/api/document/merge", editorMiddleware(handlers.MergeDocumentsHandler))
 
 	// Markdown rendering API - No auth required
 	mux.HandleFunc("/api/render-markdown", handlers.RenderMarkdownHandler)
@@ -412,40 +521,40 @@ func SetupRoutes(cfg *config.Config) {
 
 	// Documents list API - for document linking
 	mux.HandleFunc("/api/documents/list", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ListDocumentsHandler(w, r, cfg)
+		handlers.ListDocumentsHandler(w, r, config.Current())
 	})
 
 	// Import API - Admin only
 	mux.HandleFunc("/api/import", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ImportHandler(w, r, cfg)
+		handlers.ImportHandler(w, r, config.Current())
 	})
 
 	mux.HandleFunc("/api/import/status/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ImportStatusHandler(w, r, cfg)
+		handlers.ImportStatusHandler(w, r, config.Current())
 	})
 
 	// Backup API - Admin only
 	mux.HandleFunc("/api/backup/start", adminMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.StartBackupHandler(w, r, cfg)
+		handlers.StartBackupHandler(w, r, config.Current())
 	}))
 	mux.HandleFunc("/api/backup/list", adminMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.ListBackupsHandler(w, r, cfg)
+		handlers.ListBackupsHandler(w, r, config.Current())
 	}))
 	mux.HandleFunc("/api/backup/status/", adminMiddleware(handlers.BackupStatusHandler))
 	mux.HandleFunc("/api/backup/download/", adminMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.DownloadBackupHandler(w, r, cfg)
+		handlers.DownloadBackupHandler(w, r, config.Current())
 	}))
 	mux.HandleFunc("/api/backup/delete/", adminMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handlers.DeleteBackupHandler(w, r, cfg)
+		handlers.DeleteBackupHandler(w, r, config.Current())
 	}))
 
 	// Sitemap routes
 	mux.HandleFunc("/sitemap/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.SitemapHandler(w, r, cfg)
+		handlers.SitemapHandler(w, r, config.Current())
 	})
 
 	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
-		handlers.SitemapHandler(w, r, cfg)
+		handlers.SitemapHandler(w, r, config.Current())
 	})
 
 	// Utility API endpoints
@@ -453,12 +562,56 @@ func SetupRoutes(cfg *config.Config) {
 
 	// Links Metadata API - Editor or Admin only
 	mux.HandleFunc("/api/links/fetch-metadata", editorMiddleware(handlers.FetchMetadataHandler))
+	mux.HandleFunc("/api/links/suggest", editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handlers.LinkSuggestHandler(w, r, config.Current())
+	}))
+	mux.HandleFunc("/api/links/broken", editorMiddleware(handlers.BrokenLinksHandler))
+	mux.HandleFunc("/api/aliases", editorMiddleware(handlers.AliasesHandler))
+
+	// Task list checkboxes - Editor or Admin only
+	mux.HandleFunc("/api/tasks/toggle", editorMiddleware(handlers.TaskToggleHandler))
+
+	// Document review schedule - the report is for admins, marking for editors
+	mux.HandleFunc("/api/review", adminMiddleware(handlers.ReviewReportHandler))
+	mux.HandleFunc("/api/review/mark", editorMiddleware(handlers.MarkReviewedHandler))
+
+	// Titles and paths of all readable documents, for the quick switcher
+	mux.HandleFunc("/api/quick-switch", handlers.QuickSwitchHandler)
+
+	// Favorites of the signed-in user
+	mux.HandleFunc("/api/favorites", handlers.FavoritesHandler)
+	mux.HandleFunc("/api/favorites/", handlers.FavoritesHandler)
+
+	// Who else is editing a document - Editor or Admin only
+	mux.HandleFunc("/api/presence/", editorMiddleware(handlers.PresenceHandler))
+
+	// Real-time editing sessions over WebSocket - Editor or Admin only
+	mux.HandleFunc("/api/collab/", editorMiddleware(handlers.CollabHandler))
+
+	// Document view counts
+	mux.HandleFunc("/api/views/popular", handlers.PopularDocumentsHandler)
+	mux.HandleFunc("/api/views/", handlers.DocumentViewsHandler)
+
+	// Related documents
+	mux.HandleFunc("/api/related/", handlers.RelatedHandler)
+
+	// QR codes linking to documents, for printed signs
+	mux.HandleFunc("/api/qr/", handlers.QRCodeHandler)
+
+	// Diff of two documents
+	mux.HandleFunc("/api/compare", handlers.CompareDocumentsHandler)
+
+	// Edit suggestions - anyone who can read a document suggests, editors review
+	mux.HandleFunc("/api/suggestions", handlers.SuggestionsHandler)
+	mux.HandleFunc("/api/suggestions/", handlers.SuggestionsHandler)
+	mux.HandleFunc("/api/suggestions/accept", editorMiddleware(handlers.AcceptSuggestionHandler))
 
 	// Login page
 	mux.HandleFunc("/login", handlers.LoginPageHandler)
 
 	// Home page and other pages
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/", CanonicalURLMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Current()
 		// Check if authentication is required
 		if !auth.RequireAuth(r, cfg) {
 			// If private and not authenticated, redirect to login page
@@ -480,11 +633,7 @@ func SetupRoutes(cfg *config.Config) {
 
 		// Otherwise, serve the page based on the URL path
 		handlers.PageHandler(w, r, cfg)
-	})
-
-	// Apply middleware to all routes
-	handler := CSPMiddleware(mux)
+	})))
 
-	// Set the handler for the default ServeMux
-	http.Handle("/", handler)
+	return mux
 }
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wiki-go/internal/config"
+	"wiki-go/internal/handlers"
+)
+
+// TestAPIOperationsAreRouted checks that every endpoint in the OpenAPI spec
+// reaches a registered route instead of the page catch-all
+func TestAPIOperationsAreRouted(t *testing.T) {
+	mux := newRouter(&config.Config{})
+
+	for _, op := range handlers.APIOperations() {
+		t.Run(op.Method+" "+op.Path, func(t *testing.T) {
+			target := strings.NewReplacer("{path}", "guides/setup", "{commentId}", "20250101000000_admin.md").Replace(op.Path)
+			_, pattern := mux.Handler(httptest.NewRequest(op.Method, target, nil))
+			if pattern == "" || pattern == "/" {
+				t.Errorf("%s %s is not routed (pattern %q)", op.Method, target, pattern)
+			}
+		})
+	}
+}
+
+// The default policy only lets in the origins of the configured CAPTCHA
+// provider, following it across reloads
+func TestSecurityHeadersCaptchaSources(t *testing.T) {
+	tests := []struct {
+		provider  string
+		hcaptcha  bool
+		turnstile bool
+	}{
+		{"", false, false},
+		{"pow", false, false},
+		{"hcaptcha", true, false},
+		{"turnstile", false, true},
+	}
+	cfg := &config.Config{}
+	handler := SecurityHeadersMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			running := *cfg
+			running.Wiki.Comments.Challenge.Provider = tt.provider
+			setTestConfig(t, &running)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			policy := rec.Header().Get("Content-Security-Policy")
+			if got := strings.Contains(policy, "hcaptcha.com"); got != tt.hcaptcha {
+				t.Errorf("hCaptcha allowed = %t in %q", got, policy)
+			}
+			if got := strings.Contains(policy, "challenges.cloudflare.com"); got != tt.turnstile {
+				t.Errorf("Turnstile allowed = %t in %q", got, policy)
+			}
+			if !strings.Contains(policy, "script-src 'self' 'nonce-") {
+				t.Errorf("policy %q lacks the nonced script-src", policy)
+			}
+		})
+	}
+}
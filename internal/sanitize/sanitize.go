@@ -0,0 +1,423 @@
+// Package sanitize removes script-capable markup from rendered HTML using an
+// allowlist of tags, attributes and URL schemes.
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultTags are the elements produced by the markdown renderer and its extensions
+var DefaultTags = []string{
+	"a", "abbr", "article", "aside", "audio", "b", "bdi", "bdo", "blockquote", "br",
+	"button", "caption", "center", "cite", "code", "col", "colgroup", "dd", "del",
+	"details", "dfn", "div", "dl", "dt", "em", "figcaption", "figure", "font",
+	"footer", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "i", "iframe", "img",
+	"input", "ins", "kbd", "label", "li", "main", "mark", "nav", "ol", "optgroup",
+	"option", "p", "picture", "pre", "q", "rp", "rt", "ruby", "s", "samp", "section",
+	"select", "small", "source", "span", "strike", "strong", "sub", "summary", "sup",
+	"table", "tbody", "td", "tfoot", "th", "thead", "time", "tr", "tt", "u", "ul",
+	"var", "video", "wbr",
+}
+
+// DefaultAttributes are allowed on every permitted element. Any data-* and aria-*
+// attribute is allowed as well; event handlers (on*) never are.
+var DefaultAttributes = []string{
+	"abbr", "align", "allow", "allowfullscreen", "alt", "autoplay", "border", "checked",
+	"cite", "class", "color", "cols", "colspan", "controls", "datetime", "dir",
+	"disabled", "face", "for", "frameborder", "headers", "height", "href", "id",
+	"label", "lang", "loading", "loop", "muted", "name", "open", "poster",
+	"referrerpolicy", "rel", "reversed", "role", "rows", "rowspan", "scope",
+	"selected", "size", "span", "src", "start", "style", "tabindex", "target",
+	"title", "type", "valign", "value", "width",
+}
+
+// DefaultIframeHosts are the hosts iframes may load, matching the video embed extensions
+var DefaultIframeHosts = []string{
+	"www.youtube.com", "youtube.com", "www.youtube-nocookie.com", "player.vimeo.com",
+}
+
+// urlAttributes hold URLs and are checked against allowedSchemes
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "poster": true, "cite": true, "action": true,
+	"formaction": true, "background": true, "data": true, "xlink:href": true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http": true, "https": true, "mailto": true, "tel": true, "ftp": true,
+}
+
+// dropContent elements are removed together with everything inside them
+var dropContent = map[string]bool{
+	"script": true, "style": true, "template": true, "title": true, "textarea": true,
+	"xmp": true, "noembed": true, "noframes": true, "iframe": true, "object": true,
+	"applet": true, "svg": true, "math": true,
+}
+
+// voidElements never have content or an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// Policy is a set of allowed tags, attributes and iframe hosts
+type Policy struct {
+	tags        map[string]bool
+	attributes  map[string]bool
+	iframeHosts map[string]bool
+}
+
+// NewPolicy builds a policy from the defaults plus extra tags, attributes and iframe hosts
+func NewPolicy(extraTags, extraAttributes, iframeHosts []string) *Policy {
+	return &Policy{
+		tags:        toSet(DefaultTags, extraTags),
+		attributes:  toSet(DefaultAttributes, extraAttributes),
+		iframeHosts: toSet(iframeHosts),
+	}
+}
+
+func toSet(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, v := range list {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				set[v] = true
+			}
+		}
+	}
+	return set
+}
+
+var (
+	mu      sync.RWMutex
+	enabled = true
+	policy  = NewPolicy(nil, nil, DefaultIframeHosts)
+)
+
+// Configure sets whether documents are sanitized and the policy used. Comments are
+// always sanitized, whatever enabled says.
+func Configure(enable bool, extraTags, extraAttributes, iframeHosts []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = enable
+	policy = NewPolicy(extraTags, extraAttributes, iframeHosts)
+}
+
+// Document sanitizes rendered document HTML unless sanitization is disabled
+func Document(s string) string {
+	mu.RLock()
+	p, on := policy, enabled
+	mu.RUnlock()
+	if !on {
+		return s
+	}
+	return p.Sanitize(s)
+}
+
+// Comment sanitizes rendered comment HTML
+func Comment(s string) string {
+	mu.RLock()
+	p := policy
+	mu.RUnlock()
+	return p.Sanitize(s)
+}
+
+// Sanitize returns s with disallowed elements, attributes and URLs removed.
+// Text and permitted markup are kept as they are.
+func (p *Policy) Sanitize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	skip := "" // element whose content is being dropped
+	depth := 0 // nesting of skip inside itself
+
+	for i := 0; i < len(s); {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			if skip == "" {
+				b.WriteString(s[i:])
+			}
+			break
+		}
+		if skip == "" {
+			b.WriteString(s[i : i+lt])
+		}
+		i += lt
+		rest := s[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			// Comments are dropped
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return b.String()
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			// Doctypes, CDATA and processing instructions are dropped
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 1
+
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isLetter(rest[2]):
+			name, _ := readName(rest[2:])
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 1
+
+			if skip != "" {
+				if name == skip {
+					if depth--; depth == 0 {
+						skip = ""
+					}
+				}
+				continue
+			}
+			if p.tags[name] && !voidElements[name] {
+				b.WriteString("</" + name + ">")
+			}
+
+		case len(rest) > 1 && isLetter(rest[1]):
+			tag, n, ok := parseStartTag(rest)
+			if !ok {
+				// Not a complete tag: keep the "<" as text
+				if skip == "" {
+					b.WriteString("&lt;")
+				}
+				i++
+				continue
+			}
+			i += n
+
+			if skip != "" {
+				if tag.name == skip && !tag.selfClosing {
+					depth++
+				}
+				continue
+			}
+			if out, keep := p.filterTag(tag); keep {
+				b.WriteString(out)
+			} else if dropContent[tag.name] && !tag.selfClosing && !voidElements[tag.name] {
+				skip = tag.name
+				depth = 1
+			}
+
+		default:
+			if skip == "" {
+				b.WriteString("&lt;")
+			}
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+type attribute struct {
+	name, value string
+	hasValue    bool
+}
+
+type startTag struct {
+	name        string
+	attrs       []attribute
+	selfClosing bool
+}
+
+// filterTag renders tag with only its allowed attributes, or reports that the
+// tag must be dropped
+func (p *Policy) filterTag(tag startTag) (string, bool) {
+	if !p.tags[tag.name] {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + tag.name)
+	for _, a := range tag.attrs {
+		if !p.allowedAttribute(a.name) {
+			continue
+		}
+		value := a.value
+		if tag.name == "iframe" && a.name == "src" && !p.allowedIframe(value) {
+			return "", false
+		}
+		if urlAttributes[a.name] && !safeURL(tag.name, a.name, value) {
+			continue
+		}
+		if a.name == "style" && !safeStyle(value) {
+			continue
+		}
+		b.WriteString(" " + a.name)
+		if a.hasValue {
+			b.WriteString(`="` + html.EscapeString(value) + `"`)
+		}
+	}
+
+	// An iframe without an allowed source has nothing to show
+	if tag.name == "iframe" && !hasAttribute(tag, "src") {
+		return "", false
+	}
+
+	if tag.selfClosing && voidElements[tag.name] {
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+	return b.String(), true
+}
+
+func (p *Policy) allowedAttribute(name string) bool {
+	if strings.HasPrefix(name, "on") {
+		return false
+	}
+	if strings.HasPrefix(name, "data-") || strings.HasPrefix(name, "aria-") {
+		return true
+	}
+	return p.attributes[name]
+}
+
+func (p *Policy) allowedIframe(src string) bool {
+	u, err := url.Parse(strings.TrimSpace(src))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	return p.iframeHosts[strings.ToLower(u.Hostname())]
+}
+
+func hasAttribute(tag startTag, name string) bool {
+	for _, a := range tag.attrs {
+		if a.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// safeURL reports whether a URL attribute value uses an allowed scheme. Relative
+// URLs are allowed; data: URLs only for images.
+func safeURL(tagName, attrName, value string) bool {
+	// Browsers ignore control characters and whitespace inside the scheme
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+	cleaned = strings.ToLower(cleaned)
+
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 || strings.ContainsAny(cleaned[:colon], "/?#") {
+		return true // relative URL
+	}
+	scheme := cleaned[:colon]
+	if allowedSchemes[scheme] {
+		return true
+	}
+	return scheme == "data" && attrName == "src" && tagName == "img" &&
+		strings.HasPrefix(cleaned, "data:image/")
+}
+
+// safeStyle rejects inline styles that can run script in older browsers or load resources
+func safeStyle(value string) bool {
+	v := strings.ToLower(value)
+	for _, bad := range []string{"expression", "javascript:", "vbscript:", "behavior", "-moz-binding", "@import"} {
+		if strings.Contains(v, bad) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseStartTag parses "<name attr=value ...>" at the start of s and returns the
+// tag and the number of bytes it spans
+func parseStartTag(s string) (startTag, int, bool) {
+	name, n := readName(s[1:])
+	tag := startTag{name: name}
+	i := 1 + n
+
+	for {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			if s[i] == '/' {
+				tag.selfClosing = true
+			}
+			i++
+		}
+		if i >= len(s) {
+			return tag, 0, false
+		}
+		if s[i] == '>' {
+			return tag, i + 1, true
+		}
+		tag.selfClosing = false
+
+		// Attribute name
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '/' && s[i] != '>' && s[i] != '=' {
+			i++
+		}
+		if i == start {
+			// A stray "=" without a name
+			i++
+			continue
+		}
+		attr := attribute{name: strings.ToLower(s[start:i])}
+
+		// Optional value
+		j := i
+		for j < len(s) && isSpace(s[j]) {
+			j++
+		}
+		if j < len(s) && s[j] == '=' {
+			j++
+			for j < len(s) && isSpace(s[j]) {
+				j++
+			}
+			if j >= len(s) {
+				return tag, 0, false
+			}
+			attr.hasValue = true
+			switch q := s[j]; q {
+			case '"', '\'':
+				end := strings.IndexByte(s[j+1:], q)
+				if end < 0 {
+					return tag, 0, false
+				}
+				attr.value = s[j+1 : j+1+end]
+				i = j + 1 + end + 1
+			default:
+				k := j
+				for k < len(s) && !isSpace(s[k]) && s[k] != '>' {
+					k++
+				}
+				attr.value = s[j:k]
+				i = k
+			}
+			attr.value = html.UnescapeString(attr.value)
+		}
+		tag.attrs = append(tag.attrs, attr)
+	}
+}
+
+// readName reads a lowercase tag name from the start of s
+func readName(s string) (string, int) {
+	i := 0
+	for i < len(s) && (isLetter(s[i]) || (s[i] >= '0' && s[i] <= '9') || s[i] == '-' || s[i] == ':') {
+		i++
+	}
+	return strings.ToLower(s[:i]), i
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
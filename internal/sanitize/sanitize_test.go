@@ -0,0 +1,175 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeStripsXSS(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Script tag",
+			input:    `<p>Hello</p><script>alert(1)</script><p>World</p>`,
+			expected: `<p>Hello</p><p>World</p>`,
+		},
+		{
+			name:     "Uppercase script tag",
+			input:    `<SCRIPT SRC="https://evil.example/x.js"></SCRIPT>ok`,
+			expected: `ok`,
+		},
+		{
+			name:     "Image onerror handler",
+			input:    `<img src="x.png" onerror="alert(1)">`,
+			expected: `<img src="x.png">`,
+		},
+		{
+			name:     "Unquoted event handler",
+			input:    `<div onmouseover=alert(1) class=note>text</div>`,
+			expected: `<div class="note">text</div>`,
+		},
+		{
+			name:     "Slash separated attributes",
+			input:    `<img/src="x.png"/onerror=alert(1)>`,
+			expected: `<img src="x.png">`,
+		},
+		{
+			name:     "javascript URL",
+			input:    `<a href="javascript:alert(1)">click</a>`,
+			expected: `<a>click</a>`,
+		},
+		{
+			name:     "Obfuscated javascript URL",
+			input:    `<a href="  JaVa&#x09;ScRiPt&#58;alert(1)">click</a>`,
+			expected: `<a>click</a>`,
+		},
+		{
+			name:     "data URL link",
+			input:    `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+			expected: `<a>x</a>`,
+		},
+		{
+			name:     "vbscript URL",
+			input:    `<a href="vbscript:msgbox(1)">x</a>`,
+			expected: `<a>x</a>`,
+		},
+		{
+			name:     "Iframe from untrusted host",
+			input:    `<iframe src="https://evil.example/"><p>fallback</p></iframe>after`,
+			expected: `after`,
+		},
+		{
+			name:     "javascript iframe",
+			input:    `<iframe src="javascript:alert(1)"></iframe>`,
+			expected: ``,
+		},
+		{
+			name:     "SVG with onload",
+			input:    `<svg onload="alert(1)"><circle r="5"/></svg>ok`,
+			expected: `ok`,
+		},
+		{
+			name:     "Style expression",
+			input:    `<span style="width: expression(alert(1))">x</span>`,
+			expected: `<span>x</span>`,
+		},
+		{
+			name:     "Object and embed",
+			input:    `<object data="evil.swf"></object><embed src="evil.swf">`,
+			expected: ``,
+		},
+		{
+			name:     "Form with javascript action",
+			input:    `<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">go</button></form>`,
+			expected: `<button>go</button>`,
+		},
+		{
+			name:     "Meta refresh and base",
+			input:    `<meta http-equiv="refresh" content="0;url=javascript:alert(1)"><base href="https://evil.example/">`,
+			expected: ``,
+		},
+		{
+			name:     "Attribute value breaking out of quotes",
+			input:    `<a title='"><script>alert(1)</script>'>x</a>`,
+			expected: `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`,
+		},
+		{
+			name:     "Unterminated tag",
+			input:    `a <img src=x onerror=alert(1)`,
+			expected: `a &lt;img src=x onerror=alert(1)`,
+		},
+	}
+
+	p := NewPolicy(nil, nil, DefaultIframeHosts)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.Sanitize(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected: %q, got: %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSanitizeKeepsRenderedMarkup(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Headings and links",
+			input: `<h2 id="setup">Setup</h2><p>See <a href="/guides/install#step-1">install</a> or <a href="https://example.com">docs</a>.</p>`,
+		},
+		{
+			name:  "Mermaid block",
+			input: `<div class="mermaid">graph TD; A--&gt;B</div>`,
+		},
+		{
+			name:  "YouTube embed",
+			input: `<iframe width="560" height="315" src="https://www.youtube.com/embed/abc" frameborder="0" allowfullscreen></iframe>`,
+		},
+		{
+			name:  "Task list checkbox",
+			input: `<li><input type="checkbox" class="task-checkbox" checked disabled> done</li>`,
+		},
+		{
+			name:  "Inline data image",
+			input: `<img src="data:image/png;base64,iVBORw0KGgo=" alt="dot">`,
+		},
+		{
+			name:  "Data and aria attributes",
+			input: `<div class="kanban-column" data-column-id="1" aria-label="Todo"><button class="add-task-btn" title="Add">+</button></div>`,
+		},
+		{
+			name:  "Escaped text is left alone",
+			input: `<pre><code>&lt;script&gt;alert(1)&lt;/script&gt;</code></pre>`,
+		},
+	}
+
+	p := NewPolicy(nil, nil, DefaultIframeHosts)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.Sanitize(tt.input)
+			if result != tt.input {
+				t.Errorf("Expected markup to be unchanged.\nInput: %q\nGot:   %q", tt.input, result)
+			}
+		})
+	}
+}
+
+func TestSanitizeExtraTags(t *testing.T) {
+	p := NewPolicy([]string{"custom-note"}, []string{"data"}, nil)
+	result := p.Sanitize(`<custom-note onclick="x()">hi</custom-note>`)
+	if result != `<custom-note>hi</custom-note>` {
+		t.Errorf("Unexpected result: %q", result)
+	}
+
+	// Event handlers can't be allowed through configuration
+	p = NewPolicy(nil, []string{"onclick"}, nil)
+	if result := p.Sanitize(`<p onclick="x()">hi</p>`); strings.Contains(result, "onclick") {
+		t.Errorf("Event handler was kept: %q", result)
+	}
+}
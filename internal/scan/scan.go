@@ -0,0 +1,128 @@
+// Package scan checks uploaded files for malware with an external scanner,
+// such as a ClamAV daemon.
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Supported providers
+const (
+	ProviderClamd = "clamd"
+)
+
+// DefaultTimeout bounds a scan when no timeout is configured
+const DefaultTimeout = 30 * time.Second
+
+// Scanner checks file content for malware
+type Scanner interface {
+	// Scan reads r to the end and returns the name of the threat found in it,
+	// or "" when it is clean. An error means the content could not be scanned.
+	Scan(ctx context.Context, r io.Reader) (threat string, err error)
+}
+
+// New returns the scanner for provider, or nil when provider is empty.
+// address is where the scanner listens: a unix socket path or a host:port.
+func New(provider, address string, timeout time.Duration) (Scanner, error) {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "":
+		return nil, nil
+	case ProviderClamd:
+		if address == "" {
+			return nil, errors.New("clamd address is required")
+		}
+		return &Clamd{Address: address, Timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown scan provider %q", provider)
+	}
+}
+
+// Clamd scans content with a ClamAV daemon using its INSTREAM command
+type Clamd struct {
+	Address string        // Unix socket path, or host:port for TCP
+	Timeout time.Duration // Limit on a whole scan; DefaultTimeout when 0
+}
+
+// clamdChunkSize is the most content sent in one INSTREAM chunk. clamd's
+// StreamMaxLength still limits the whole stream.
+const clamdChunkSize = 64 * 1024
+
+// Scan streams r to clamd and parses its verdict
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (string, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	network := "tcp"
+	if strings.HasPrefix(c.Address, "/") {
+		network = "unix"
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, c.Address)
+	if err != nil {
+		return "", fmt.Errorf("connecting to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// The z prefix makes clamd end its reply with a NUL instead of a newline
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return "", fmt.Errorf("sending to clamd: %w", err)
+	}
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return "", fmt.Errorf("sending to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return "", readErr
+		}
+	}
+	// A zero-length chunk ends the stream
+	if _, err := conn.Write(make([]byte, 4)); err != nil {
+		return "", fmt.Errorf("sending to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", fmt.Errorf("reading clamd reply: %w", err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamdReply interprets a reply such as "stream: OK" or
+// "stream: Eicar-Test-Signature FOUND"
+func parseClamdReply(reply string) (string, error) {
+	result := reply
+	if i := strings.Index(reply, ": "); i >= 0 {
+		result = reply[i+2:]
+	}
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd: %s", reply)
+	}
+}
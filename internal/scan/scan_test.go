@@ -0,0 +1,132 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeClamd accepts one INSTREAM connection and answers with reply, or with
+// the reply for content containing "EICAR" when reply is empty
+func fakeClamd(t *testing.T, reply string) (string, <-chan []byte) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+			return
+		}
+		var content bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&content, r, int64(size)); err != nil {
+				return
+			}
+		}
+		received <- content.Bytes()
+		answer := reply
+		if answer == "" {
+			answer = "stream: OK"
+			if bytes.Contains(content.Bytes(), []byte("EICAR")) {
+				answer = "stream: Eicar-Test-Signature FOUND"
+			}
+		}
+		io.WriteString(conn, answer+"\x00")
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestClamdScan(t *testing.T) {
+	large := strings.Repeat("x", 3*clamdChunkSize+17)
+	tests := []struct {
+		name       string
+		content    string
+		reply      string
+		wantThreat string
+		wantErr    bool
+	}{
+		{"clean", "hello", "", "", false},
+		{"empty", "", "", "", false},
+		{"several chunks", large, "", "", false},
+		{"infected", "X5O!P%@AP...EICAR...", "", "Eicar-Test-Signature", false},
+		{"scanner error", "hello", "INSTREAM size limit exceeded. ERROR", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, received := fakeClamd(t, tt.reply)
+			scanner, err := New(ProviderClamd, addr, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			threat, err := scanner.Scan(context.Background(), strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if threat != tt.wantThreat {
+				t.Errorf("threat = %q, want %q", threat, tt.wantThreat)
+			}
+			if got := <-received; string(got) != tt.content {
+				t.Errorf("clamd received %d bytes, want %d", len(got), len(tt.content))
+			}
+		})
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	scanner, _ := New(ProviderClamd, addr, 0)
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("hello")); err == nil {
+		t.Error("scan without a scanner succeeded")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		provider string
+		address  string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{"", "", true, false},
+		{"clamd", "/run/clamav/clamd.ctl", false, false},
+		{"ClamD", "127.0.0.1:3310", false, false},
+		{"clamd", "", true, true},
+		{"virustotal", "x", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+" "+tt.address, func(t *testing.T) {
+			scanner, err := New(tt.provider, tt.address, 0)
+			if (err != nil) != tt.wantErr || (scanner == nil) != tt.wantNil {
+				t.Errorf("New = %v, %v", scanner, err)
+			}
+		})
+	}
+}
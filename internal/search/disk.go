@@ -0,0 +1,126 @@
+package search
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"wiki-go/internal/doclock"
+)
+
+// diskFormat is the version of the index file layout; files of another
+// version are ignored and the index is rebuilt
+const diskFormat = 2
+
+// diskIndex is the content of the index file
+type diskIndex struct {
+	Format int
+	Docs   map[string]entry
+}
+
+// Disk is a Backend kept in memory and saved to a file, so a restart only
+// reindexes the documents changed in the meantime
+type Disk struct {
+	*Memory
+	path  string
+	dirty bool // Guarded by Memory.mu
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenDisk loads the index saved at path, if any, and starts saving changes
+// to it every interval until Close is called. An unreadable index file is
+// logged and replaced by an empty index.
+func OpenDisk(path string, interval time.Duration) (*Disk, error) {
+	d := &Disk{
+		Memory: NewMemory(),
+		path:   path,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		var saved diskIndex
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&saved); err != nil {
+			log.Printf("Ignoring search index %s: %v", path, err)
+		} else if saved.Format == diskFormat && saved.Docs != nil {
+			d.docs = saved.Docs
+		}
+	}
+
+	go d.flushLoop(interval)
+	return d, nil
+}
+
+// Index adds or replaces the indexed content of a document
+func (d *Disk) Index(path string, modTime time.Time, content string) error {
+	d.Memory.Index(path, modTime, content)
+	d.markDirty()
+	return nil
+}
+
+// Delete removes a document, or every document below a directory
+func (d *Disk) Delete(path string) error {
+	d.Memory.Delete(path)
+	d.markDirty()
+	return nil
+}
+
+func (d *Disk) markDirty() {
+	d.mu.Lock()
+	d.dirty = true
+	d.mu.Unlock()
+}
+
+// Flush saves the index if it changed since it was last saved
+func (d *Disk) Flush() error {
+	d.mu.Lock()
+	if !d.dirty {
+		d.mu.Unlock()
+		return nil
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(diskIndex{Format: diskFormat, Docs: d.docs})
+	d.dirty = false
+	d.mu.Unlock()
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(d.path), 0755); err == nil {
+			err = doclock.WriteFile(d.path, buf.Bytes(), 0644)
+		}
+	}
+	if err != nil {
+		// Try again on the next flush
+		d.markDirty()
+	}
+	return err
+}
+
+// Close stops the background saving and saves any pending changes
+func (d *Disk) Close() error {
+	close(d.stop)
+	<-d.done
+	return d.Flush()
+}
+
+func (d *Disk) flushLoop(interval time.Duration) {
+	defer close(d.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.Flush()
+		case <-d.stop:
+			return
+		}
+	}
+}
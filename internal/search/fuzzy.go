@@ -0,0 +1,81 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Distance returns the number of single character insertions, deletions,
+// substitutions and transpositions of adjacent characters that turn a into b.
+// It stops counting past max and then returns max+1.
+func Distance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return max + 1
+	}
+
+	// Three rows of the edit distance matrix: two back, previous and current
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(rb)], max+1)
+}
+
+// AllowedDistance returns how many edits a search for term tolerates: one per
+// four characters, up to max. Short terms must match exactly, as a single edit
+// already turns them into unrelated words.
+func AllowedDistance(term string, max int) int {
+	return min(max, len([]rune(term))/4)
+}
+
+// Words returns the distinct lowercased words of s
+func Words(s string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Closest returns the word within maxDistance edits of term with the fewest
+// edits, and false when there is none
+func Closest(words []string, term string, maxDistance int) (string, bool) {
+	best, bestDistance := "", maxDistance+1
+	for _, word := range words {
+		if d := Distance(term, word, bestDistance-1); d < bestDistance {
+			best, bestDistance = word, d
+			if d == 0 {
+				break
+			}
+		}
+	}
+	return best, bestDistance <= maxDistance
+}
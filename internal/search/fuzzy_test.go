@@ -0,0 +1,88 @@
+package search
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"kubernetes", "kubernetes", 2, 0},
+		{"kubernates", "kubernetes", 2, 1},
+		{"teh", "the", 2, 1},
+		{"kubernetes", "kubernets", 2, 1},
+		{"conifg", "config", 2, 1},
+		{"déploy", "deploy", 2, 1},
+		{"install", "uninstall", 2, 2},
+		{"install", "compiler", 2, 3},
+		{"a", "abcdef", 2, 3},
+		{"", "ab", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b, tt.max); got != tt.want {
+				t.Errorf("Distance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosest(t *testing.T) {
+	words := Words("Deploy to Kubernetes; the kubectl CLI talks to kubernetes clusters.")
+	tests := []struct {
+		term string
+		want string
+		ok   bool
+	}{
+		{"kubernates", "kubernetes", true},
+		{"kubectl", "kubectl", true},
+		{"clustres", "clusters", true},
+		{"helm", "", false},
+		{"ot", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			got, ok := Closest(words, tt.term, AllowedDistance(tt.term, 2))
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Closest(%q) = %q, %t, want %q, %t", tt.term, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMemoryQueryFuzzy(t *testing.T) {
+	m := NewMemory()
+	m.Index("kube/document.md", time.Time{}, "Deploying to Kubernetes clusters.")
+	m.Index("helm/document.md", time.Time{}, "Helm charts.")
+	m.Index("swap/document.md", time.Time{}, "Deploying to Kuberentes.")
+
+	tests := []struct {
+		name  string
+		terms []string
+		max   int
+		want  []string
+	}{
+		{"substitution", []string{"kubernates"}, 2, []string{"kube/document.md", "swap/document.md"}},
+		{"transposition", []string{"kuberentes"}, 1, []string{"kube/document.md", "swap/document.md"}},
+		{"every term", []string{"kubernates", "clustres"}, 2, []string{"kube/document.md"}},
+		{"exact substrings", []string{"ploy"}, 2, []string{"kube/document.md", "swap/document.md"}},
+		{"no edits", []string{"kubernates"}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.QueryFuzzy(tt.terms, tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("QueryFuzzy(%q, %d) = %v, want %v", tt.terms, tt.max, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package search keeps an index of document contents so searches only read
+// the documents that can match. The index records the trigrams (runs of three
+// characters) of each document; a document can only contain a search term if
+// it has every trigram of the term. Candidates are confirmed by the caller
+// against the document itself, so the index never changes search results.
+package search
+
+import (
+	"hash/fnv"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend is a search index. Paths are slash-separated and relative to the
+// documents directory, e.g. "guides/setup/document.md".
+type Backend interface {
+	// Index adds or replaces the indexed content of a document
+	Index(path string, modTime time.Time, content string) error
+	// Delete removes a document, or every document below a directory
+	Delete(path string) error
+	// Query returns the documents that may contain every term, in no
+	// particular order. Without terms it returns every document.
+	Query(terms []string) ([]string, error)
+	// QueryFuzzy returns the documents that may contain, for every term,
+	// the term or a word within AllowedDistance(term, maxDistance) edits of it
+	QueryFuzzy(terms []string, maxDistance int) ([]string, error)
+	// Indexed returns the modification time of every indexed document as it
+	// was when indexed, to find the documents changed since
+	Indexed() (map[string]time.Time, error)
+	// Close releases the index, saving it if it is persistent
+	Close() error
+}
+
+// entry is an indexed document
+type entry struct {
+	ModTime  int64    // Unix nanoseconds
+	Trigrams []uint32 // Sorted hashes of the lowercased content's trigrams
+	Words    []string // Distinct lowercased words, for fuzzy queries
+}
+
+// Memory is a Backend kept in memory; it is rebuilt on every start
+type Memory struct {
+	mu    sync.RWMutex
+	docs  map[string]entry
+	vocab map[string][]string // Documents of each word; built by QueryFuzzy
+}
+
+// NewMemory returns an empty in-memory index
+func NewMemory() *Memory {
+	return &Memory{docs: make(map[string]entry)}
+}
+
+// Index adds or replaces the indexed content of a document
+func (m *Memory) Index(path string, modTime time.Time, content string) error {
+	e := entry{ModTime: modTime.UnixNano(), Trigrams: trigrams(content), Words: Words(content)}
+	m.mu.Lock()
+	m.docs[path] = e
+	m.vocab = nil
+	m.mu.Unlock()
+	return nil
+}
+
+// Delete removes a document, or every document below a directory
+func (m *Memory) Delete(path string) error {
+	path = strings.Trim(path, "/")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for p := range m.docs {
+		if p == path || path == "" || strings.HasPrefix(p, path+"/") {
+			delete(m.docs, p)
+		}
+	}
+	m.vocab = nil
+	return nil
+}
+
+// Query returns the documents having every trigram of every term
+func (m *Memory) Query(terms []string) ([]string, error) {
+	var wanted []uint32
+	for _, term := range terms {
+		wanted = append(wanted, trigrams(term)...)
+	}
+	slices.Sort(wanted)
+	wanted = slices.Compact(wanted)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var paths []string
+	for p, e := range m.docs {
+		if containsAll(e.Trigrams, wanted) {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// QueryFuzzy returns the documents that contain every term, or a word within
+// a few edits of it
+func (m *Memory) QueryFuzzy(terms []string, maxDistance int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.vocab == nil {
+		m.vocab = make(map[string][]string)
+		for p, e := range m.docs {
+			for _, word := range e.Words {
+				m.vocab[word] = append(m.vocab[word], p)
+			}
+		}
+	}
+
+	matches := make(map[string]bool, len(m.docs))
+	for p := range m.docs {
+		matches[p] = true
+	}
+	for _, term := range terms {
+		termMatches := make(map[string]bool)
+		wanted := trigrams(term)
+		for p, e := range m.docs {
+			if containsAll(e.Trigrams, wanted) {
+				termMatches[p] = true
+			}
+		}
+		allowed := AllowedDistance(term, maxDistance)
+		for word, paths := range m.vocab {
+			if Distance(term, word, allowed) <= allowed {
+				for _, p := range paths {
+					termMatches[p] = true
+				}
+			}
+		}
+		maps.DeleteFunc(matches, func(p string, _ bool) bool { return !termMatches[p] })
+	}
+	return slices.Collect(maps.Keys(matches)), nil
+}
+
+// Indexed returns the modification time of every indexed document
+func (m *Memory) Indexed() (map[string]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	indexed := make(map[string]time.Time, len(m.docs))
+	for p, e := range m.docs {
+		indexed[p] = time.Unix(0, e.ModTime)
+	}
+	return indexed, nil
+}
+
+// Close does nothing; the index is discarded
+func (m *Memory) Close() error {
+	return nil
+}
+
+// trigrams returns the sorted, distinct hashes of the trigrams of s, lowercased
+// the way searches compare content. Strings shorter than three characters have
+// none, so they match every document.
+func trigrams(s string) []uint32 {
+	runes := []rune(strings.ToLower(s))
+	if len(runes) < 3 {
+		return nil
+	}
+	hashes := make([]uint32, 0, len(runes)-2)
+	h := fnv.New32a()
+	for i := 0; i+3 <= len(runes); i++ {
+		h.Reset()
+		h.Write([]byte(string(runes[i : i+3])))
+		hashes = append(hashes, h.Sum32())
+	}
+	slices.Sort(hashes)
+	return slices.Compact(hashes)
+}
+
+// containsAll reports whether the sorted set has every value of sorted wanted
+func containsAll(set, wanted []uint32) bool {
+	for _, w := range wanted {
+		i := sort.Search(len(set), func(i int) bool { return set[i] >= w })
+		if i == len(set) || set[i] != w {
+			return false
+		}
+		set = set[i:]
+	}
+	return true
+}
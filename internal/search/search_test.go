@@ -0,0 +1,75 @@
+package search
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryQuery(t *testing.T) {
+	m := NewMemory()
+	modTime := time.Unix(1700000000, 0)
+	m.Index("go/setup/document.md", modTime, "# Setup\n\nInstall the Compiler.")
+	m.Index("go/testing/document.md", modTime, "# Testing\n\nRun the tests.")
+	m.Index("bread/document.md", modTime, "# Bread\n\nKnead the dough.")
+
+	tests := []struct {
+		name  string
+		terms []string
+		want  []string
+	}{
+		{"no terms", nil, []string{"bread/document.md", "go/setup/document.md", "go/testing/document.md"}},
+		{"case insensitive", []string{"compiler"}, []string{"go/setup/document.md"}},
+		{"every term", []string{"the", "tests"}, []string{"go/testing/document.md"}},
+		{"phrase", []string{"knead the dough"}, []string{"bread/document.md"}},
+		{"short terms match everything", []string{"go"}, []string{"bread/document.md", "go/setup/document.md", "go/testing/document.md"}},
+		{"no match", []string{"oven"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Query(tt.terms)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Query(%q) = %v, want %v", tt.terms, got, tt.want)
+			}
+		})
+	}
+
+	m.Delete("go")
+	if got, _ := m.Query(nil); !slices.Equal(got, []string{"bread/document.md"}) {
+		t.Errorf("after deleting go = %v, want only bread", got)
+	}
+}
+
+func TestDiskPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.gob")
+	modTime := time.Unix(1700000000, 123)
+
+	d, err := OpenDisk(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Index("a/document.md", modTime, "alpha content")
+	d.Index("b/document.md", modTime, "beta content")
+	d.Delete("b")
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err = OpenDisk(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	indexed, _ := d.Indexed()
+	if len(indexed) != 1 || !indexed["a/document.md"].Equal(modTime) {
+		t.Errorf("reopened index = %v, want a/document.md at %v", indexed, modTime)
+	}
+	if got, _ := d.Query([]string{"alpha"}); !slices.Equal(got, []string{"a/document.md"}) {
+		t.Errorf("Query(alpha) = %v", got)
+	}
+}
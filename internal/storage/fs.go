@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"wiki-go/internal/doclock"
+)
+
+// FS keeps files in a directory of the local filesystem
+type FS struct {
+	root string
+}
+
+// NewFS returns the Storage for the directory root
+func NewFS(root string) *FS {
+	return &FS{root: root}
+}
+
+// path returns the filesystem path of name
+func (s *FS) path(name string) string {
+	return filepath.Join(s.root, filepath.FromSlash(cleanName(name)))
+}
+
+// Read returns the content of a file
+func (s *FS) Read(name string) ([]byte, error) {
+	return os.ReadFile(s.path(name))
+}
+
+// Write replaces the file atomically, so readers never see partial content
+func (s *FS) Write(name string, data []byte) error {
+	file := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return doclock.WriteFile(file, data, 0644)
+}
+
+// Stat describes a file or directory
+func (s *FS) Stat(name string) (FileInfo, error) {
+	info, err := os.Stat(s.path(name))
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return fileInfo(info), nil
+}
+
+// List returns the entries of dir
+func (s *FS) List(dir string) ([]FileInfo, error) {
+	entries, err := os.ReadDir(s.path(dir))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed while listing
+		}
+		infos = append(infos, fileInfo(info))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Move renames from to to, which is atomic within one filesystem
+func (s *FS) Move(from, to string) error {
+	target := s.path(to)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Rename(s.path(from), target)
+}
+
+// Remove removes a file or directory tree
+func (s *FS) Remove(name string) error {
+	if cleanName(name) == "" {
+		return errors.New("storage: refusing to remove the root")
+	}
+	err := os.RemoveAll(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func fileInfo(info os.FileInfo) FileInfo {
+	return FileInfo{Name: info.Name(), Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}
+}
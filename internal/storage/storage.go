@@ -0,0 +1,45 @@
+// Package storage abstracts where the wiki keeps its files. Names are
+// slash-separated paths relative to the wiki root, such as
+// "documents/guides/setup/document.md"; directories are implied by the files
+// below them.
+package storage
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// FileInfo describes a file or directory
+type FileInfo struct {
+	Name    string // Base name
+	Size    int64
+	ModTime time.Time // Zero for directories on object stores
+	IsDir   bool
+}
+
+// Storage is implemented by the places the wiki can keep its files. Missing
+// files are reported with errors matching fs.ErrNotExist.
+type Storage interface {
+	// Read returns the content of a file
+	Read(name string) ([]byte, error)
+	// Write creates or replaces a file, creating its parent directories
+	Write(name string, data []byte) error
+	// Stat describes a file or directory
+	Stat(name string) (FileInfo, error)
+	// List returns the files and directories directly inside dir, sorted by name
+	List(dir string) ([]FileInfo, error)
+	// Move moves a file or a directory with everything below it, creating the
+	// parents of to. Object stores copy and then delete, so a failed move can
+	// leave part of the tree at both names.
+	Move(from, to string) error
+	// Remove removes a file or a directory with everything below it; removing
+	// what doesn't exist is not an error
+	Remove(name string) error
+}
+
+// cleanName normalizes a name to a slash-separated path without leading or
+// trailing slashes that can't leave the root. The root itself is "".
+func cleanName(name string) string {
+	return strings.Trim(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	backends := map[string]func(t *testing.T) Storage{
+		"fs": func(t *testing.T) Storage {
+			return NewFS(t.TempDir())
+		},
+	}
+
+	for name, newStorage := range backends {
+		t.Run(name, func(t *testing.T) {
+			s := newStorage(t)
+			for _, file := range []string{"documents/a/document.md", "documents/a/b/document.md", "documents/c/document.md"} {
+				if err := s.Write(file, []byte(file)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if data, err := s.Read("documents/a/document.md"); err != nil || string(data) != "documents/a/document.md" {
+				t.Errorf("Read = %q, %v", data, err)
+			}
+			if _, err := s.Read("documents/missing/document.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Read of a missing file = %v, want fs.ErrNotExist", err)
+			}
+			if info, err := s.Stat("documents/a"); err != nil || !info.IsDir || info.Name != "a" {
+				t.Errorf("Stat(dir) = %+v, %v", info, err)
+			}
+
+			entries, err := s.List("documents/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+			}
+			if want := []string{"b", "document.md"}; !slices.Equal(names, want) {
+				t.Errorf("List = %q, want %q", names, want)
+			}
+
+			if err := s.Move("documents/a", "documents/c/a"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Stat("documents/a"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("source still exists after Move: %v", err)
+			}
+			if data, err := s.Read("documents/c/a/b/document.md"); err != nil || string(data) != "documents/a/b/document.md" {
+				t.Errorf("moved file = %q, %v", data, err)
+			}
+
+			if err := s.Remove("documents/c"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Stat("documents/c/a/document.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("file still exists after Remove: %v", err)
+			}
+			if err := s.Remove("documents/c"); err != nil {
+				t.Errorf("Remove of a missing tree = %v", err)
+			}
+		})
+	}
+}
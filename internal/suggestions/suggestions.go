@@ -0,0 +1,141 @@
+// Package suggestions keeps proposed new versions of documents, submitted by
+// readers who cannot edit them, until an editor accepts or rejects them.
+//
+// Each suggestion is a JSON file under <root>/suggestions named by its ID.
+package suggestions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName is the suggestions directory inside the wiki root
+const DirName = "suggestions"
+
+// ErrNotFound is returned for an unknown suggestion ID
+var ErrNotFound = errors.New("suggestion not found")
+
+// Suggestion is a proposed new content of a document
+type Suggestion struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"`             // Document path relative to the documents directory; "" is the homepage
+	Author  string    `json:"author,omitempty"` // Username; empty for anonymous suggestions
+	Message string    `json:"message,omitempty"`
+	Content string    `json:"content"`
+	Base    string    `json:"base"` // ETag of the document the suggestion was made against
+	Created time.Time `json:"created"`
+}
+
+// Box holds the pending suggestions of one wiki
+type Box struct {
+	rootDir string
+}
+
+// New returns the suggestions of the wiki stored in rootDir
+func New(rootDir string) *Box {
+	return &Box{rootDir: rootDir}
+}
+
+func (b *Box) dir() string {
+	return filepath.Join(b.rootDir, DirName)
+}
+
+// file returns the file of suggestion id, rejecting IDs that aren't a plain name
+func (b *Box) file(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", ErrNotFound
+	}
+	return filepath.Join(b.dir(), id+".json"), nil
+}
+
+// Add stores s as a new suggestion, setting its ID and creation time
+func (b *Box) Add(s *Suggestion) error {
+	if err := os.MkdirAll(b.dir(), 0755); err != nil {
+		return err
+	}
+	s.ID = newID()
+	s.Created = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(b.dir(), s.ID+".json"), data, 0644)
+}
+
+// List returns the pending suggestions, oldest first
+func (b *Box) List() ([]Suggestion, error) {
+	files, err := os.ReadDir(b.dir())
+	if os.IsNotExist(err) {
+		return []Suggestion{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := []Suggestion{}
+	for _, f := range files {
+		id, ok := strings.CutSuffix(f.Name(), ".json")
+		if f.IsDir() || !ok {
+			continue
+		}
+		s, err := b.Get(id)
+		if err != nil {
+			continue
+		}
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	return list, nil
+}
+
+// Get returns suggestion id
+func (b *Box) Get(id string) (*Suggestion, error) {
+	file, err := b.file(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Suggestion
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	s.ID = id
+	return &s, nil
+}
+
+// Delete removes suggestion id, once it is accepted or rejected
+func (b *Box) Delete(id string) error {
+	file, err := b.file(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// newID returns a sortable, unique suggestion ID
+func newID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102150405") + "-" + hex.EncodeToString(b)
+}
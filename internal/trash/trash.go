@@ -0,0 +1,361 @@
+// Package trash keeps deleted documents, with their version history and
+// comments, so they can be restored until they are purged.
+//
+// Each deleted document is a directory in the trash named by its entry ID,
+// holding entry.json and the document, versions and comments directories as
+// they were.
+package trash
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const entryFile = "entry.json"
+
+var (
+	// ErrNotFound is returned for an unknown entry ID
+	ErrNotFound = errors.New("trash entry not found")
+	// ErrOccupied is returned when restoring to a path that is in use again
+	ErrOccupied = errors.New("original path is occupied")
+)
+
+// Entry describes a deleted document
+type Entry struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"` // Original path relative to the documents directory
+	Title     string    `json:"title,omitempty"`
+	DeletedAt time.Time `json:"deletedAt"`
+	DeletedBy string    `json:"deletedBy"`
+	// Only the versions and comments left behind by a document that no longer
+	// exists, without the data of documents below it
+	Orphaned bool `json:"orphaned,omitempty"`
+}
+
+// Dirs locates the trash and what is moved into it
+type Dirs struct {
+	Trash     string // The trash itself
+	Documents string // The documents, by document path
+	Versions  string // The version history of the documents, by document path
+	Comments  string // The comments, by document path
+}
+
+// Bin is the trash of one wiki
+type Bin struct {
+	dirs Dirs
+}
+
+// New returns the trash of the wiki whose files are in dirs
+func New(dirs Dirs) *Bin {
+	return &Bin{dirs: dirs}
+}
+
+// part is something that belongs to a document: its location in the wiki and
+// its name inside a trash entry
+type part struct {
+	name string
+	path string
+}
+
+// parts lists what is moved to the trash with the document at docPath; the
+// document itself comes first
+func (b *Bin) parts(docPath string) []part {
+	rel := filepath.FromSlash(docPath)
+	return []part{
+		{"document", filepath.Join(b.dirs.Documents, rel)},
+		{"versions", filepath.Join(b.dirs.Versions, rel)},
+		{"comments", filepath.Join(b.dirs.Comments, rel)},
+	}
+}
+
+func (b *Bin) dir() string {
+	return b.dirs.Trash
+}
+
+// entryDir returns the directory of entry id, rejecting IDs that aren't a plain name
+func (b *Bin) entryDir(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", ErrNotFound
+	}
+	return filepath.Join(b.dir(), id), nil
+}
+
+// Move puts the document or category at docPath, relative to the documents
+// directory, into the trash with its versions and comments
+func (b *Bin) Move(docPath, title, user string) (*Entry, error) {
+	docPath = strings.Trim(filepath.ToSlash(docPath), "/")
+	if docPath == "" {
+		return nil, errors.New("document path is required")
+	}
+	parts := b.parts(docPath)
+	if _, err := os.Stat(parts[0].path); err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		ID:        newID(),
+		Path:      docPath,
+		Title:     title,
+		DeletedAt: time.Now().UTC(),
+		DeletedBy: user,
+	}
+	dir := filepath.Join(b.dir(), entry.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := writeEntry(dir, entry); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	for i, p := range parts {
+		err := os.Rename(p.path, filepath.Join(dir, p.name))
+		if err == nil || (i > 0 && os.IsNotExist(err)) {
+			continue
+		}
+		if i == 0 {
+			// Nothing has been moved yet
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		return entry, fmt.Errorf("document moved to trash but its %s were not: %w", p.name, err)
+	}
+	return entry, nil
+}
+
+// MoveOrphaned puts the versions and comments kept for docPath, whose document
+// no longer exists, into the trash. Of the entries directly in their
+// directories only those own reports as belonging to docPath are moved, so the
+// data of documents below it stays in place.
+func (b *Bin) MoveOrphaned(docPath, user string, own func(name string, isDir bool) bool) (*Entry, error) {
+	docPath = strings.Trim(filepath.ToSlash(docPath), "/")
+	if docPath == "" {
+		return nil, errors.New("document path is required")
+	}
+
+	entry := &Entry{
+		ID:        newID(),
+		Path:      docPath,
+		DeletedAt: time.Now().UTC(),
+		DeletedBy: user,
+		Orphaned:  true,
+	}
+	dir := filepath.Join(b.dir(), entry.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := writeEntry(dir, entry); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	// The document itself is gone; only its data is moved
+	for _, p := range b.parts(docPath)[1:] {
+		files, err := os.ReadDir(p.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return entry, err
+		}
+		for _, file := range files {
+			if !own(file.Name(), file.IsDir()) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Join(dir, p.name), 0755); err != nil {
+				return entry, err
+			}
+			if err := os.Rename(filepath.Join(p.path, file.Name()), filepath.Join(dir, p.name, file.Name())); err != nil {
+				return entry, fmt.Errorf("failed to move %s to trash: %w", file.Name(), err)
+			}
+		}
+		// Leave no empty directory behind
+		os.Remove(p.path)
+	}
+	return entry, nil
+}
+
+// List returns the trashed documents, most recently deleted first
+func (b *Bin) List() ([]Entry, error) {
+	dirs, err := os.ReadDir(b.dir())
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		entry, err := readEntry(filepath.Join(b.dir(), d.Name()))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// Get returns entry id
+func (b *Bin) Get(id string) (*Entry, error) {
+	dir, err := b.entryDir(id)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := readEntry(dir)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return entry, err
+}
+
+// Restore moves entry id back to its original path. It fails with ErrOccupied,
+// leaving the entry in the trash, if anything exists at that path again.
+func (b *Bin) Restore(id string) (*Entry, error) {
+	entry, err := b.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(b.dir(), entry.ID)
+	parts := b.parts(entry.Path)
+	if entry.Orphaned {
+		return entry, b.restoreOrphaned(dir, parts[1:])
+	}
+
+	for _, p := range parts {
+		if _, err := os.Stat(filepath.Join(dir, p.name)); err != nil {
+			continue
+		}
+		if !isEmptyOrMissing(p.path) {
+			return nil, ErrOccupied
+		}
+	}
+
+	for _, p := range parts {
+		src := filepath.Join(dir, p.name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+			return nil, err
+		}
+		// An empty directory left at the path doesn't count as occupied
+		os.Remove(p.path)
+		if err := os.Rename(src, p.path); err != nil {
+			return nil, err
+		}
+	}
+	return entry, os.RemoveAll(dir)
+}
+
+// restoreOrphaned moves the files of an entry of orphaned data in dir back
+// into the directories of parts, next to the data of documents below its path
+func (b *Bin) restoreOrphaned(dir string, parts []part) error {
+	for _, p := range parts {
+		files, _ := os.ReadDir(filepath.Join(dir, p.name))
+		for _, file := range files {
+			if _, err := os.Stat(filepath.Join(p.path, file.Name())); err == nil {
+				return ErrOccupied
+			}
+		}
+	}
+
+	for _, p := range parts {
+		files, _ := os.ReadDir(filepath.Join(dir, p.name))
+		if len(files) > 0 {
+			if err := os.MkdirAll(p.path, 0755); err != nil {
+				return err
+			}
+		}
+		for _, file := range files {
+			if err := os.Rename(filepath.Join(dir, p.name, file.Name()), filepath.Join(p.path, file.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// Delete removes entry id from the trash for good
+func (b *Bin) Delete(id string) error {
+	dir, err := b.entryDir(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, entryFile)); err != nil {
+		return ErrNotFound
+	}
+	return os.RemoveAll(dir)
+}
+
+// Purge removes entries deleted before cutoff and returns how many it removed
+func (b *Bin) Purge(cutoff time.Time) (int, error) {
+	entries, err := b.List()
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	var errs []error
+	for _, entry := range entries {
+		if !entry.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if err := b.Delete(entry.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// isEmptyOrMissing reports whether path doesn't exist or is an empty directory
+func isEmptyOrMissing(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	return len(entries) == 0
+}
+
+func readEntry(dir string) (*Entry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, entryFile))
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	entry.ID = filepath.Base(dir)
+	return &entry, nil
+}
+
+func writeEntry(dir string, entry *Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, entryFile), data, 0644)
+}
+
+// newID returns a sortable, unique entry ID
+func newID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102150405") + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,160 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testBin returns a trash using the default layout of a wiki in root
+func testBin(root string) *Bin {
+	return New(Dirs{
+		Trash:     filepath.Join(root, ".trash"),
+		Documents: filepath.Join(root, "documents"),
+		Versions:  filepath.Join(root, "versions", "documents"),
+		Comments:  filepath.Join(root, "comments"),
+	})
+}
+
+func TestMoveAndRestore(t *testing.T) {
+	root := t.TempDir()
+	doc := filepath.Join(root, "documents", "guides", "setup", "document.md")
+	version := filepath.Join(root, "versions", "documents", "guides", "setup", "20260101000000.md")
+	comment := filepath.Join(root, "comments", "guides", "setup", "1.md")
+	writeFile(t, doc, "# Setup")
+	writeFile(t, version, "# Old")
+	writeFile(t, comment, "hi")
+
+	bin := testBin(root)
+	entry, err := bin.Move("guides/setup", "Setup", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{doc, version, comment} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after moving to trash", path)
+		}
+	}
+
+	entries, err := bin.List()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("List() = %v, %v; want one entry", entries, err)
+	}
+	if got := entries[0]; got.Path != "guides/setup" || got.DeletedBy != "alice" || got.ID != entry.ID {
+		t.Errorf("entry = %+v", got)
+	}
+
+	// Restoring fails while the path is taken
+	writeFile(t, doc, "# New setup")
+	if _, err := bin.Restore(entry.ID); !errors.Is(err, ErrOccupied) {
+		t.Fatalf("Restore onto an occupied path: err = %v, want ErrOccupied", err)
+	}
+	os.RemoveAll(filepath.Dir(doc))
+
+	if _, err := bin.Restore(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{doc: "# Setup", version: "# Old", comment: "hi"} {
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if entries, _ := bin.List(); len(entries) != 0 {
+		t.Errorf("trash still has %d entries after restore", len(entries))
+	}
+}
+
+func TestPurge(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "documents", "a", "document.md"), "# A")
+	writeFile(t, filepath.Join(root, "documents", "b", "document.md"), "# B")
+
+	bin := testBin(root)
+	old, err := bin.Move("a", "A", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.DeletedAt = time.Now().AddDate(0, 0, -40)
+	if err := writeEntry(filepath.Join(root, ".trash", old.ID), old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bin.Move("b", "B", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := bin.Purge(time.Now().AddDate(0, 0, -30))
+	if err != nil || purged != 1 {
+		t.Fatalf("Purge() = %d, %v; want 1", purged, err)
+	}
+	entries, _ := bin.List()
+	if len(entries) != 1 || entries[0].Path != "b" {
+		t.Errorf("remaining entries = %+v, want only b", entries)
+	}
+}
+
+func TestEntryIDsAreNames(t *testing.T) {
+	bin := testBin(t.TempDir())
+	for _, id := range []string{"", "..", "../documents", "a/b", ".hidden"} {
+		if _, err := bin.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) err = %v, want ErrNotFound", id, err)
+		}
+		if err := bin.Delete(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete(%q) err = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestMoveOrphanedAndRestore(t *testing.T) {
+	root := t.TempDir()
+	version := filepath.Join(root, "versions", "documents", "guides", "20260101000000.md")
+	translation := filepath.Join(root, "versions", "documents", "guides", "document.fr", "20260101000000.md")
+	comment := filepath.Join(root, "comments", "guides", "1.md")
+	// Data of a document below the orphaned one
+	childVersion := filepath.Join(root, "versions", "documents", "guides", "setup", "20260101000000.md")
+	for _, path := range []string{version, translation, comment, childVersion} {
+		writeFile(t, path, "data")
+	}
+
+	bin := testBin(root)
+	own := func(name string, isDir bool) bool { return !isDir || name == "document.fr" }
+	entry, err := bin.MoveOrphaned("guides", "alice", own)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{version, translation, comment} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after moving to trash", path)
+		}
+	}
+	if _, err := os.Stat(childVersion); err != nil {
+		t.Errorf("data of the document below was moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "comments", "guides")); !os.IsNotExist(err) {
+		t.Errorf("empty comments directory left behind")
+	}
+
+	if got, err := bin.Get(entry.ID); err != nil || !got.Orphaned || got.Path != "guides" {
+		t.Fatalf("Get() = %+v, %v", got, err)
+	}
+	if _, err := bin.Restore(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{version, translation, comment, childVersion} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s not restored: %v", path, err)
+		}
+	}
+}
@@ -50,9 +50,36 @@ type PageData struct {
 	Comments           []comments.Comment // Comments for the document
 	CommentsAllowed    bool               // Whether comments are allowed for this document
 	IsAuthenticated    bool               // Whether the user is authenticated
+	AnonymousComments  string             // How the visitor, who is not signed in, may comment; "" when they can't
 	UserRole           string             // User role: "admin", "editor", or "viewer"
 	DocPath            string             // Document path for API calls
 	DocumentLayout     string             // Document layout type from frontmatter (e.g., "kanban")
 	IsEditMode         bool               // Whether page is in edit mode (separate edit page architecture)
 	RawContent         string             // Raw markdown content with frontmatter for edit mode
+	ViewCount          int64              // Number of times the document has been viewed
+	LastEditedBy       string             // Author of the last edit, from the revision metadata
+	CSPNonce           string             // Content-Security-Policy nonce for inline scripts
+	DocumentLang       string             // Language of the displayed document content
+	Translations       []Translation      // Language variants of the document, for the switcher
+	ReviewBy           string             // Date the document is due for review, from its front matter
+	ReviewOverdue      bool               // Whether the review date has passed
+	RelatedDocuments   []DocumentLink     // Documents suggested as related, shown below the content
+	WordCount          int                // Words of prose in the document
+	ReadingTime        int                // Estimated minutes to read the document; 0 hides the estimate
+	DirHeading         string             // Heading above the listing of a category's children, from its front matter
+}
+
+// DocumentLink is a link to a document by its title
+type DocumentLink struct {
+	Title string
+	Path  string
+}
+
+// Translation is a language variant of a document offered by the language switcher
+type Translation struct {
+	Lang      string // Language tag, e.g. "fr"
+	Name      string // Display name of the language
+	URL       string // Link that selects this variant
+	Active    bool   // Whether this variant is being displayed
+	IsDefault bool   // Whether this is the default document.md
 }
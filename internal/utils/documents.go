@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// DocumentEntry is a document's title and URL path
+type DocumentEntry struct {
+	Title string `json:"title"`
+	Path  string `json:"path"` // URL path, e.g. "/guides/setup"
+}
+
+// ListDocuments walks the documents directory and returns every document with its title
+func ListDocuments(rootDir, documentsDir string) ([]DocumentEntry, error) {
+	docsPath := filepath.Join(rootDir, documentsDir)
+
+	var documents []DocumentEntry
+	err := filepath.WalkDir(docsPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != docsPath && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !IsDocumentIndex(path) {
+			return nil
+		}
+
+		docDir := filepath.Dir(path)
+		relPath, err := filepath.Rel(docsPath, docDir)
+		if err != nil || relPath == "." {
+			return nil
+		}
+
+		documents = append(documents, DocumentEntry{
+			Title: GetDocumentTitle(docDir),
+			Path:  "/" + filepath.ToSlash(relPath),
+		})
+		return nil
+	})
+	return documents, err
+}
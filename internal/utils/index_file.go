@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"wiki-go/internal/goldext"
+)
+
+// DefaultIndexFile is the name of the file holding a document's content
+const DefaultIndexFile = "document.md"
+
+var (
+	indexMu    sync.RWMutex
+	indexFiles = []string{DefaultIndexFile}
+)
+
+func init() {
+	goldext.SetDocumentIndex(IsDocumentIndex)
+}
+
+// SetIndexFiles sets the names of the file holding a document's content. New
+// documents are written to primary; an existing document is read from the first
+// of primary, fallbacks and document.md that exists in its directory, so trees
+// using index.md or README.md as their landing files resolve.
+func SetIndexFiles(primary string, fallbacks []string) {
+	if primary == "" {
+		primary = DefaultIndexFile
+	}
+	names := []string{primary}
+	for _, name := range append(fallbacks, DefaultIndexFile) {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	indexMu.Lock()
+	indexFiles = names
+	indexMu.Unlock()
+}
+
+// IndexFile returns the name new documents are written to
+func IndexFile() string {
+	indexMu.RLock()
+	defer indexMu.RUnlock()
+	return indexFiles[0]
+}
+
+// IndexFiles returns the names a document's content is looked up under, in order
+func IndexFiles() []string {
+	indexMu.RLock()
+	defer indexMu.RUnlock()
+	return slices.Clone(indexFiles)
+}
+
+// IsIndexFile reports whether name is one of the names a document's content is
+// looked up under
+func IsIndexFile(name string) bool {
+	indexMu.RLock()
+	defer indexMu.RUnlock()
+	return slices.Contains(indexFiles, name)
+}
+
+// DocumentFile returns the path of the file holding the content of the document
+// in dir: the first index file that exists there, or the primary one when none
+// does, which is where a new document in dir is written
+func DocumentFile(dir string) string {
+	names := IndexFiles()
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+	return filepath.Join(dir, names[0])
+}
+
+// IsDocumentIndex reports whether file holds the content of the document in its
+// directory. Of several index files in one directory only the one DocumentFile
+// picks does, so walks don't count a document twice.
+func IsDocumentIndex(file string) bool {
+	return IsIndexFile(filepath.Base(file)) && DocumentFile(filepath.Dir(file)) == file
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDocumentFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   string
+		fallbacks []string
+		files     []string // Files in the document directory
+		want      string   // File holding the content
+	}{
+		{"Default", "", nil, []string{"document.md"}, "document.md"},
+		{"New document", "", nil, nil, "document.md"},
+		{"Configured name", "index.md", nil, []string{"index.md"}, "index.md"},
+		{"New document with configured name", "index.md", nil, nil, "index.md"},
+		{"document.md still resolves", "index.md", nil, []string{"document.md"}, "document.md"},
+		{"Fallback", "", []string{"README.md"}, []string{"README.md"}, "README.md"},
+		{"First fallback wins", "", []string{"README.md", "index.md"}, []string{"index.md", "README.md"}, "README.md"},
+		{"Primary wins over fallbacks", "", []string{"README.md"}, []string{"README.md", "document.md"}, "document.md"},
+		{"Unlisted name", "", nil, []string{"README.md"}, "document.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetIndexFiles(tt.primary, tt.fallbacks)
+			t.Cleanup(func() { SetIndexFiles("", nil) })
+
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("# Doc"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want := filepath.Join(dir, tt.want)
+			if got := DocumentFile(dir); got != want {
+				t.Errorf("DocumentFile() = %q, want %q", got, want)
+			}
+			for _, name := range tt.files {
+				file := filepath.Join(dir, name)
+				if got := IsDocumentIndex(file); got != (file == want) {
+					t.Errorf("IsDocumentIndex(%q) = %v", name, got)
+				}
+			}
+		})
+	}
+}
@@ -5,13 +5,19 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"unicode"
 	"wiki-go/internal/frontmatter"
 	"wiki-go/internal/goldext"
+	"wiki-go/internal/sanitize"
 
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 )
 
 // RenderMarkdownFile reads a markdown file and returns its HTML representation
@@ -44,7 +50,8 @@ func RenderMarkdown(md string) []byte {
 	return RenderMarkdownWithPath(md, "")
 }
 
-// RenderMarkdownWithPath converts markdown text to HTML with the current document path
+// RenderMarkdownWithPath converts markdown text to HTML with the current document path.
+// The result is sanitized unless sanitization is disabled in the configuration.
 func RenderMarkdownWithPath(md string, docPath string) []byte {
 	// Check for frontmatter
 	metadata, contentWithoutFrontmatter, hasFrontmatter := frontmatter.Parse(md)
@@ -76,7 +83,7 @@ func RenderMarkdownWithPath(md string, docPath string) []byte {
 		})
 
 		kanbanHTML := frontmatter.RenderKanbanWithProcessors(contentWithoutFrontmatter, preprocessors, postProcessors)
-		return []byte(kanbanHTML)
+		return []byte(sanitize.Document(kanbanHTML))
 	}
 
 	// If this has links layout, render as links document
@@ -86,7 +93,7 @@ func RenderMarkdownWithPath(md string, docPath string) []byte {
 			// If links rendering fails, fall back to regular markdown
 			md = contentWithoutFrontmatter
 		} else {
-			return []byte(linksHTML)
+			return []byte(sanitize.Document(linksHTML))
 		}
 	}
 
@@ -95,40 +102,26 @@ func RenderMarkdownWithPath(md string, docPath string) []byte {
 		md = contentWithoutFrontmatter
 	}
 
+	// Show HTML written in the document as text when raw HTML is disabled
+	if !rawHTMLEnabled() {
+		md = goldext.EscapeRawHTML(md)
+	}
+
 	// Apply any custom extensions via pre-processing
 	md = goldext.ProcessMarkdown(md, docPath)
 
-	// Configure Goldmark with all needed extensions
-	markdown := goldmark.New(
-		// Enable common extensions
-		goldmark.WithExtensions(
-			extension.Table,         // Enable tables
-			extension.Strikethrough, // Enable ~~strikethrough~~
-			extension.Linkify,       // Auto-link URLs
-			// extension.TaskList,    // Disabled - we use our own task list processor
-			extension.Footnote,       // Enable footnotes
-			extension.DefinitionList, // Enable definition lists
-			extension.GFM,            // GitHub Flavored Markdown
-			goldext.OnePasswordIgnore, // Add data-1p-ignore to code blocks
-			// MathJax is now handled via client-side JavaScript
-		),
-		// Parser options
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(), // Enable auto heading IDs
-			parser.WithAttribute(),     // Enable attributes
-		),
-		// Renderer options
-		goldmark.WithRendererOptions(
-			html.WithUnsafe(), // Allow raw HTML in the markdown
-			html.WithHardWraps(),
-		),
-	)
+	// Use the shared renderer built from the markdown configuration
+	markdown := NewMarkdown()
 
 	// Create a buffer to store the rendered HTML
 	var buf bytes.Buffer
 
-	// Convert markdown to HTML
-	if err := markdown.Convert([]byte(md), &buf); err != nil {
+	// Convert markdown to HTML. Footnote anchors are scoped to the document so
+	// that pages shown or exported together don't share ids.
+	source := []byte(md)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+	doc.OwnerDocument().AddMeta(footnoteIDPrefixMeta, FootnoteIDPrefix(docPath))
+	if err := markdown.Renderer().Render(&buf, source, doc); err != nil {
 		// If there's an error, return an error message
 		errMsg := []byte("<p>Error rendering markdown with Goldmark: " + err.Error() + "</p>")
 		return errMsg
@@ -141,6 +134,153 @@ func RenderMarkdownWithPath(md string, docPath string) []byte {
 	// This ensures RTL/LTR content is properly rendered with Markdown formatting
 	htmlResult = goldext.RestoreDirectionBlocks(htmlResult)
 
-	// Return the post-processed HTML
-	return []byte(htmlResult)
-}
\ No newline at end of file
+	// Strip script-capable markup that raw HTML in the document may have introduced
+	return []byte(sanitize.Document(htmlResult))
+}
+
+// MarkdownOptions selects the optional Goldmark features used for rendering.
+// Each field maps to a cfg.Wiki.Markdown toggle.
+type MarkdownOptions struct {
+	Tables          bool // extension.Table: | pipe | tables |
+	Strikethrough   bool // extension.Strikethrough: ~~text~~
+	Autolinks       bool // extension.Linkify: bare URLs, www. and email addresses
+	TaskLists       bool // extension.TaskList: - [ ] and - [x] list items
+	Footnotes       bool // extension.Footnote: [^1] references and definitions
+	DefinitionLists bool // extension.DefinitionList: term / ": definition" lists
+	RawHTML         bool // html.WithUnsafe; when off, tags are escaped by goldext.EscapeRawHTML
+	HardWraps       bool // html.WithHardWraps: single newlines become <br>
+	Emoji           bool // goldext.EmojiPreprocessor: :smile: shortcodes
+}
+
+// DefaultMarkdownOptions enables every feature, matching the default configuration
+func DefaultMarkdownOptions() MarkdownOptions {
+	return MarkdownOptions{
+		Tables:          true,
+		Strikethrough:   true,
+		Autolinks:       true,
+		TaskLists:       true,
+		Footnotes:       true,
+		DefinitionLists: true,
+		RawHTML:         true,
+		HardWraps:       true,
+		Emoji:           true,
+	}
+}
+
+var (
+	markdownMu       sync.RWMutex
+	markdownOptions  = DefaultMarkdownOptions()
+	markdownRenderer goldmark.Markdown
+)
+
+// ConfigureMarkdown builds the shared renderer from opts. It is called when the
+// configuration is loaded; rendering before that uses the defaults.
+func ConfigureMarkdown(opts MarkdownOptions) {
+	markdownMu.Lock()
+	defer markdownMu.Unlock()
+	markdownOptions = opts
+	markdownRenderer = buildMarkdown(opts)
+	goldext.SetEmojiEnabled(opts.Emoji)
+}
+
+// NewMarkdown returns the Goldmark instance used for page rendering. Other output
+// formats (e.g. DOCX export) parse with the same instance so documents are
+// interpreted consistently. The instance is shared and safe for concurrent use.
+func NewMarkdown() goldmark.Markdown {
+	markdownMu.RLock()
+	md := markdownRenderer
+	markdownMu.RUnlock()
+	if md != nil {
+		return md
+	}
+
+	markdownMu.Lock()
+	defer markdownMu.Unlock()
+	if markdownRenderer == nil {
+		markdownRenderer = buildMarkdown(markdownOptions)
+	}
+	return markdownRenderer
+}
+
+// rawHTMLEnabled reports whether HTML written in documents is passed through
+func rawHTMLEnabled() bool {
+	markdownMu.RLock()
+	defer markdownMu.RUnlock()
+	return markdownOptions.RawHTML
+}
+
+// footnoteIDPrefixMeta is the document metadata key holding the footnote id prefix
+const footnoteIDPrefixMeta = "footnoteIDPrefix"
+
+// FootnoteIDPrefix returns the prefix for footnote ids in the document at docPath,
+// e.g. "guides-setup-" giving "guides-setup-fn:1". Documents rendered without a
+// path keep goldmark's plain "fn:1" ids.
+func FootnoteIDPrefix(docPath string) string {
+	docPath = strings.Trim(docPath, "/")
+	if docPath == "" {
+		return ""
+	}
+	prefix := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, docPath)
+	return prefix + "-"
+}
+
+// footnoteIDPrefix reads the prefix stored in the metadata of the document being rendered
+func footnoteIDPrefix(node ast.Node) []byte {
+	if doc := node.OwnerDocument(); doc != nil {
+		if prefix, ok := doc.Meta()[footnoteIDPrefixMeta].(string); ok {
+			return []byte(prefix)
+		}
+	}
+	return nil
+}
+
+// buildMarkdown creates a Goldmark instance with the extensions selected by opts
+func buildMarkdown(opts MarkdownOptions) goldmark.Markdown {
+	extensions := []goldmark.Extender{
+		goldext.OnePasswordIgnore, // Add data-1p-ignore to code blocks
+		// MathJax is handled via client-side JavaScript
+	}
+	if opts.Tables {
+		extensions = append(extensions, extension.Table)
+	}
+	if opts.Strikethrough {
+		extensions = append(extensions, extension.Strikethrough)
+	}
+	if opts.Autolinks {
+		extensions = append(extensions, extension.Linkify)
+	}
+	if opts.TaskLists {
+		extensions = append(extensions, extension.TaskList)
+	}
+	if opts.Footnotes {
+		extensions = append(extensions, extension.NewFootnote(
+			extension.WithFootnoteIDPrefixFunction(footnoteIDPrefix),
+		))
+	}
+	if opts.DefinitionLists {
+		extensions = append(extensions, extension.DefinitionList)
+	}
+
+	// Preprocessors emit HTML of their own (videos, alerts, mermaid), so the
+	// renderer always passes HTML through; author-written HTML is escaped
+	// beforehand when RawHTML is off.
+	rendererOptions := []renderer.Option{html.WithUnsafe()}
+	if opts.HardWraps {
+		rendererOptions = append(rendererOptions, html.WithHardWraps())
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		// Parser options
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(), // Enable auto heading IDs
+			parser.WithAttribute(),     // Enable attributes
+		),
+		goldmark.WithRendererOptions(rendererOptions...),
+	)
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the longest file or folder name, in bytes, that common
+// file systems accept
+const MaxNameLength = 255
+
+// illegalNameChars can't appear in file names on Windows; "/" and "\" are
+// path separators everywhere the wiki runs
+const illegalNameChars = `<>:"/\|?*`
+
+// reservedDeviceNames name devices on Windows, which refuses them as file
+// names with any extension
+var reservedDeviceNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidateName returns why name can't be used as a file or folder name on
+// every platform, or nil if it can. Names are checked the same way on all
+// hosts, so that a wiki copied from Linux to Windows keeps working.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("the name is empty")
+	case name == "." || name == "..":
+		return errors.New("the name refers to a folder")
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("the name is longer than %d bytes", MaxNameLength)
+	case !utf8.ValidString(name):
+		return errors.New("the name is not valid UTF-8")
+	}
+
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f {
+			return errors.New("the name contains a control character")
+		}
+		if strings.ContainsRune(illegalNameChars, c) {
+			return fmt.Errorf("the name contains %q", c)
+		}
+	}
+
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return errors.New("the name ends with a dot or a space")
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	if base = strings.ToUpper(strings.TrimRight(base, " ")); reservedDeviceNames[base] {
+		return fmt.Errorf("%s is a reserved device name", base)
+	}
+	return nil
+}
+
+// ValidatePath checks every segment of the slash-separated path p with
+// ValidateName and returns the first offending segment with its error
+func ValidatePath(p string) (string, error) {
+	for _, segment := range strings.Split(strings.Trim(p, "/"), "/") {
+		if err := ValidateName(segment); err != nil {
+			return segment, err
+		}
+	}
+	return "", nil
+}
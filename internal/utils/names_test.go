@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"Plain slug", "getting-started", false},
+		{"Dots inside", "v1.2.notes", false},
+		{"Unicode", "café-notes", false},
+		{"Device name as part", "console", false},
+		{"Device prefix with number", "com10", false},
+		{"Empty", "", true},
+		{"Dot dot", "..", true},
+		{"Colon", "a:b", true},
+		{"Question mark", "what?", true},
+		{"Asterisk", "all*", true},
+		{"Pipe", "a|b", true},
+		{"Quote", `say"hi"`, true},
+		{"Backslash", `a\b`, true},
+		{"Control character", "a\tb", true},
+		{"Trailing dot", "notes.", true},
+		{"Trailing space", "notes ", true},
+		{"Device name", "CON", true},
+		{"Device name lower case", "nul", true},
+		{"Device name with extension", "lpt1.txt", true},
+		{"Device name with space", "aux .md", true},
+		{"Too long", strings.Repeat("a", MaxNameLength+1), true},
+		{"Invalid UTF-8", "a\xffb", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateName(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateName(%q) = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	if segment, err := ValidatePath("/guides/setup/"); err != nil {
+		t.Errorf("ValidatePath = %q, %v; want no error", segment, err)
+	}
+	if segment, err := ValidatePath("guides/con/setup"); err == nil || segment != "con" {
+		t.Errorf("ValidatePath = %q, %v; want an error for con", segment, err)
+	}
+}
+
+// TestValidNamesAreStoredUnchanged checks that names passing ValidateName are
+// created as given by the host file system, while on Windows the names it
+// rejects are refused or altered by the OS.
+func TestValidNamesAreStoredUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"getting-started", "v1.2.notes", "console"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatalf("Mkdir(%q): %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Stat(%q): %v", name, err)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		// Unix hosts accept these names, so only ValidateName keeps them
+		// out of wikis that may later be served from Windows
+		for _, name := range []string{"a:b", "notes.", "CON"} {
+			if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+				t.Errorf("Mkdir(%q): %v", name, err)
+			}
+		}
+		return
+	}
+
+	for _, name := range []string{"a:b", "notes.", "what?"} {
+		err := os.Mkdir(filepath.Join(dir, name), 0755)
+		if err != nil {
+			continue
+		}
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.Name() == name {
+				t.Errorf("Mkdir(%q) created the name unchanged", name)
+			}
+		}
+	}
+}
@@ -22,12 +22,12 @@ type NavItem struct {
 	IsActive bool
 }
 
-// GetDocumentTitle extracts the first H1 title from document.md
+// GetDocumentTitle extracts the first H1 title from the document in dirPath
 func GetDocumentTitle(dirPath string) string {
-	docPath := filepath.Join(dirPath, "document.md")
+	docPath := DocumentFile(dirPath)
 	file, err := os.Open(docPath)
 	if err != nil {
-		// If no document.md or can't read it, use directory name
+		// If no document or can't read it, use directory name
 		return FormatDirName(filepath.Base(dirPath))
 	}
 	defer file.Close()
@@ -63,8 +63,10 @@ func ToURLPath(path string) string {
 	return strings.ReplaceAll(path, " ", "-")
 }
 
-// BuildNavigation builds the navigation structure from the root directory
-func BuildNavigation(rootDir string, documentsDir string) (*types.NavItem, error) {
+// BuildNavigation builds the navigation structure from the root directory.
+// versionsDir holds the version history, which dates documents for categories
+// sorted by creation.
+func BuildNavigation(rootDir, documentsDir, versionsDir string) (*types.NavItem, error) {
 	root := &types.NavItem{
 		Title:    "Wiki-Go",
 		Path:     "/",
@@ -83,6 +85,9 @@ func BuildNavigation(rootDir string, documentsDir string) (*types.NavItem, error
 		}
 	}
 
+	// Remember each node's directory relative to docsPath so children can be ordered afterwards
+	relDirs := map[*types.NavItem]string{root: ""}
+
 	err := filepath.Walk(docsPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -93,8 +98,8 @@ func BuildNavigation(rootDir string, documentsDir string) (*types.NavItem, error
 			return nil
 		}
 
-		// Skip hidden directories and document.md files
-		if strings.HasPrefix(filepath.Base(path), ".") || filepath.Base(path) == "document.md" {
+		// Skip hidden directories
+		if strings.HasPrefix(filepath.Base(path), ".") {
 			return nil
 		}
 
@@ -145,14 +150,42 @@ func BuildNavigation(rootDir string, documentsDir string) (*types.NavItem, error
 					Children: make([]*types.NavItem, 0),
 				}
 				current.Children = append(current.Children, found)
+				relDirs[found] = filepath.Join(parts[:i+1]...)
 			}
 			current = found
 		}
 
 		return nil
 	})
+	if err != nil {
+		return root, err
+	}
+
+	sortNavChildren(root, rootDir, documentsDir, versionsDir, relDirs)
+
+	return root, nil
+}
+
+// sortNavChildren orders every node's children according to its category's sort manifest
+func sortNavChildren(node *types.NavItem, rootDir, documentsDir, versionsDir string, relDirs map[*types.NavItem]string) {
+	if len(node.Children) > 1 {
+		names := make([]string, len(node.Children))
+		byName := make(map[string]*types.NavItem, len(node.Children))
+		for i, child := range node.Children {
+			names[i] = filepath.Base(relDirs[child])
+			byName[names[i]] = child
+		}
+
+		SortCategoryChildren(rootDir, documentsDir, versionsDir, relDirs[node], names)
 
-	return root, err
+		for i, name := range names {
+			node.Children[i] = byName[name]
+		}
+	}
+
+	for _, child := range node.Children {
+		sortNavChildren(child, rootDir, documentsDir, versionsDir, relDirs)
+	}
 }
 
 // FindNavItem finds a navigation item by its path
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"wiki-go/internal/frontmatter"
+)
+
+// Markdown syntax removed or reduced to its text before counting words
+var (
+	inlineCodePattern = regexp.MustCompile("`[^`]*`")
+	imagePattern      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	wikiLinkPattern   = regexp.MustCompile(`\[\[(?:[^\]|]*\|)?([^\]]*)\]\]`)
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	bareURLPattern    = regexp.MustCompile(`https?://\S+`)
+)
+
+// WordCount counts the words of a document's prose. Front matter, code blocks,
+// inline code, HTML tags, URLs and markdown syntax are not counted; links and
+// images count their text.
+func WordCount(markdown string) int {
+	_, body, _ := frontmatter.Parse(markdown)
+
+	count := 0
+	fence := ""
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		line = inlineCodePattern.ReplaceAllString(line, " ")
+		line = imagePattern.ReplaceAllString(line, " $1 ")
+		line = wikiLinkPattern.ReplaceAllString(line, " $1 ")
+		line = linkPattern.ReplaceAllString(line, " $1 ")
+		line = htmlTagPattern.ReplaceAllString(line, " ")
+		line = bareURLPattern.ReplaceAllString(line, " ")
+
+		// Heading, list, quote and emphasis markers are words without letters
+		for _, word := range strings.Fields(line) {
+			if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// ReadingTime returns the minutes needed to read words at wpm words per
+// minute, rounded up; 0 when there is nothing to read or wpm is not positive
+func ReadingTime(words, wpm int) int {
+	if words <= 0 || wpm <= 0 {
+		return 0
+	}
+	return (words + wpm - 1) / wpm
+}
+
+// DocumentReadingTime returns the word count and reading time of a document at
+// wpm words per minute. A readingTime in the front matter replaces the estimate.
+func DocumentReadingTime(markdown string, wpm int) (words, minutes int) {
+	words = WordCount(markdown)
+	minutes = ReadingTime(words, wpm)
+	if metadata, _, ok := frontmatter.Parse(markdown); ok && metadata.ReadingTime > 0 {
+		minutes = metadata.ReadingTime
+	}
+	return words, minutes
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     int
+	}{
+		{"Plain prose", "One two three.", 3},
+		{"Markdown syntax", "# Title here\n\n- **bold** item\n> quoted _text_\n\n---", 6},
+		{"Front matter", "---\nlayout: doc\ntags: [a, b]\n---\n\nJust this", 2},
+		{"Code blocks", "Before\n```go\nfunc main() { fmt.Println(\"x\") }\n```\nAfter `inline code` end", 3},
+		{"Links and images", "See [the guide](/guides/setup) and ![a diagram](/img.png) or [[setup|Setup page]] at https://example.com", 10},
+		{"HTML tags", "<div class=\"note\">Two words</div>", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WordCount(tt.markdown); got != tt.want {
+				t.Errorf("WordCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentReadingTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		markdown    string
+		wpm         int
+		wantMinutes int
+	}{
+		{"Empty", "", 200, 0},
+		{"Rounds up", "word word word", 2, 2},
+		{"Front matter override", "---\nreadingTime: 12\n---\n\nshort", 200, 12},
+		{"Disabled", "word", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := DocumentReadingTime(tt.markdown, tt.wpm); got != tt.wantMinutes {
+				t.Errorf("minutes = %d, want %d", got, tt.wantMinutes)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Category sort modes
+const (
+	SortAlphabetical = "alphabetical" // By directory name (default)
+	SortModified     = "modified"     // Most recently modified first
+	SortCreated      = "created"      // Most recently created first
+	SortManual       = "manual"       // Order listed in the manifest, remaining entries alphabetically
+)
+
+// OrderManifestFile is the per-category manifest holding the sort mode and manual order.
+// It is a dotfile so navigation and attachment listings ignore it.
+const OrderManifestFile = ".order.json"
+
+// CategoryOrder describes how the children of a category are ordered
+type CategoryOrder struct {
+	Mode  string   `json:"mode"`
+	Order []string `json:"order,omitempty"` // Child directory names, used in manual mode
+}
+
+// IsValidSortMode reports whether mode is one of the supported sort modes
+func IsValidSortMode(mode string) bool {
+	switch mode {
+	case SortAlphabetical, SortModified, SortCreated, SortManual:
+		return true
+	}
+	return false
+}
+
+// LoadCategoryOrder reads the order manifest of a category directory. A missing
+// or unreadable manifest yields alphabetical order.
+func LoadCategoryOrder(categoryDir string) CategoryOrder {
+	order := CategoryOrder{Mode: SortAlphabetical}
+	data, err := os.ReadFile(filepath.Join(categoryDir, OrderManifestFile))
+	if err != nil {
+		return order
+	}
+	if err := json.Unmarshal(data, &order); err != nil || !IsValidSortMode(order.Mode) {
+		return CategoryOrder{Mode: SortAlphabetical}
+	}
+	return order
+}
+
+// SaveCategoryOrder writes the order manifest of a category directory. Setting
+// alphabetical order without a manual list removes the manifest.
+func SaveCategoryOrder(categoryDir string, order CategoryOrder) error {
+	manifestPath := filepath.Join(categoryDir, OrderManifestFile)
+	if order.Mode == SortAlphabetical && len(order.Order) == 0 {
+		if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, data, 0644)
+}
+
+// SortCategoryChildren sorts the child directory names of a category in place
+// according to its order manifest. relDir is the category path relative to the
+// documents directory ("" for the top level); versionsDir holds the version
+// history, used to sort by creation.
+func SortCategoryChildren(rootDir, documentsDir, versionsDir, relDir string, names []string) {
+	categoryDir := filepath.Join(rootDir, documentsDir, relDir)
+	order := LoadCategoryOrder(categoryDir)
+
+	switch order.Mode {
+	case SortModified, SortCreated:
+		times := make(map[string]time.Time, len(names))
+		for _, name := range names {
+			if order.Mode == SortModified {
+				times[name] = documentModTime(filepath.Join(categoryDir, name))
+			} else {
+				times[name] = documentCreatedTime(versionsDir, filepath.Join(relDir, name), filepath.Join(categoryDir, name))
+			}
+		}
+		sort.SliceStable(names, func(i, j int) bool {
+			if times[names[i]].Equal(times[names[j]]) {
+				return names[i] < names[j]
+			}
+			return times[names[i]].After(times[names[j]])
+		})
+
+	case SortManual:
+		position := make(map[string]int, len(order.Order))
+		for i, name := range order.Order {
+			position[name] = i
+		}
+		sort.SliceStable(names, func(i, j int) bool {
+			pi, iListed := position[names[i]]
+			pj, jListed := position[names[j]]
+			switch {
+			case iListed && jListed:
+				return pi < pj
+			case iListed != jListed:
+				return iListed
+			default:
+				return names[i] < names[j]
+			}
+		})
+
+	default:
+		sort.Strings(names)
+	}
+}
+
+// documentModTime returns the modification time of a document, falling back to its directory
+func documentModTime(dir string) time.Time {
+	if info, err := os.Stat(DocumentFile(dir)); err == nil {
+		return info.ModTime()
+	}
+	if info, err := os.Stat(dir); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// documentCreatedTime approximates when a document was created. Portable file
+// systems don't expose a birth time, so the oldest saved version is used when
+// available, otherwise the document's modification time.
+func documentCreatedTime(versionsDir, relPath, dir string) time.Time {
+	created := documentModTime(dir)
+
+	versionDir := filepath.Join(versionsDir, "documents", relPath)
+	entries, err := os.ReadDir(versionDir)
+	if err != nil {
+		return created
+	}
+	for _, entry := range entries {
+		timestamp := strings.TrimSuffix(entry.Name(), ".md")
+		if entry.IsDir() || len(timestamp) != 14 || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		t, err := time.ParseInLocation("20060102150405", timestamp, time.Local)
+		if err == nil && t.Before(created) {
+			created = t
+		}
+	}
+	return created
+}
+
+// RenameInCategoryOrder updates a manual order manifest after a child directory
+// was renamed, so the renamed entry keeps its position
+func RenameInCategoryOrder(categoryDir, oldName, newName string) error {
+	order := LoadCategoryOrder(categoryDir)
+	changed := false
+	for i, name := range order.Order {
+		if name == oldName {
+			order.Order[i] = newName
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return SaveCategoryOrder(categoryDir, order)
+}
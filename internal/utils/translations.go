@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// TranslationFileName returns the file holding a document's translation into lang.
+// An empty lang is the default document.md.
+func TranslationFileName(lang string) string {
+	if lang == "" {
+		return "document.md"
+	}
+	return "document." + lang + ".md"
+}
+
+// TranslationFile returns the file in dir holding the document's translation
+// into lang. An empty lang is the document's own content file.
+func TranslationFile(dir, lang string) string {
+	if lang == "" {
+		return DocumentFile(dir)
+	}
+	return filepath.Join(dir, TranslationFileName(lang))
+}
+
+// NormalizeDocumentLanguage validates a language tag such as "fr" or "pt-BR" and
+// returns it in canonical form. Tags are restricted to letters, digits and
+// hyphens so they are safe to use in file names.
+func NormalizeDocumentLanguage(lang string) (string, bool) {
+	lang = strings.TrimSpace(lang)
+	if lang == "" || len(lang) > 35 {
+		return "", false
+	}
+	for _, c := range lang {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return "", false
+		}
+	}
+	tag, err := language.Parse(lang)
+	if err != nil || tag == language.Und {
+		return "", false
+	}
+	return tag.String(), true
+}
+
+// TranslationLanguage returns the language of a document.<lang>.md file name.
+// ok is false for document.md and for files that aren't translations.
+func TranslationLanguage(name string) (lang string, ok bool) {
+	if name == "document.md" || !strings.HasPrefix(name, "document.") || !strings.HasSuffix(name, ".md") {
+		return "", false
+	}
+	code := strings.TrimSuffix(strings.TrimPrefix(name, "document."), ".md")
+	// Only canonical tags are picked up, so each variant maps to one file name
+	if lang, ok := NormalizeDocumentLanguage(code); ok && lang == code {
+		return lang, true
+	}
+	return "", false
+}
+
+// IsDocumentFile reports whether name is an index file or one of the translations
+func IsDocumentFile(name string) bool {
+	if IsIndexFile(name) {
+		return true
+	}
+	_, ok := TranslationLanguage(name)
+	return ok
+}
+
+// DocumentTranslations lists the languages that have a document.<lang>.md variant
+// in the document directory, sorted by tag
+func DocumentTranslations(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var langs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if lang, ok := TranslationLanguage(entry.Name()); ok {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// TranslationExists reports whether the document directory has a variant for lang
+func TranslationExists(dir, lang string) bool {
+	info, err := os.Stat(TranslationFile(dir, lang))
+	return err == nil && !info.IsDir()
+}
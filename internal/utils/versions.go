@@ -1,11 +1,13 @@
 package utils
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
 // CleanupOldVersions removes old versions if the number of versions exceeds maxVersions
@@ -58,5 +60,90 @@ func CleanupOldVersions(versionDir string, maxVersions int) {
 		} else {
 			log.Printf("Deleted old version: %s", versionPath)
 		}
+
+		// Remove the version's metadata sidecar, if any
+		metaPath := filepath.Join(versionDir, strings.TrimSuffix(versions[i], ".md")+".json")
+		if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error deleting version metadata %s: %v", metaPath, err)
+		}
+	}
+}
+
+// currentMetaFile holds the metadata of the live document content. When the
+// document is saved again it becomes the sidecar of the newly archived version.
+const currentMetaFile = "current.json"
+
+// VersionMeta records who produced a revision and why
+type VersionMeta struct {
+	Author  string `json:"author,omitempty"`
+	Message string `json:"message,omitempty"`
+	Time    string `json:"time,omitempty"` // Format: yyyymmddhhmmss
+}
+
+// ReadVersionMeta returns the metadata stored next to the version with the given
+// timestamp. Versions created before metadata was recorded return an empty value.
+func ReadVersionMeta(versionDir, timestamp string) VersionMeta {
+	return readMeta(filepath.Join(versionDir, timestamp+".json"))
+}
+
+// ReadCurrentMeta returns the metadata of the live document content
+func ReadCurrentMeta(versionDir string) VersionMeta {
+	return readMeta(filepath.Join(versionDir, currentMetaFile))
+}
+
+// ArchiveCurrentMeta attaches the live document's metadata to the version that
+// was just created from it with the given timestamp
+func ArchiveCurrentMeta(versionDir, timestamp string) {
+	currentPath := filepath.Join(versionDir, currentMetaFile)
+	if _, err := os.Stat(currentPath); err != nil {
+		return
+	}
+	if err := os.Rename(currentPath, filepath.Join(versionDir, timestamp+".json")); err != nil {
+		log.Printf("Error archiving version metadata in %s: %v", versionDir, err)
+	}
+}
+
+// WriteCurrentMeta records the metadata of the live document content
+func WriteCurrentMeta(versionDir string, meta VersionMeta) error {
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(versionDir, currentMetaFile), data, 0644)
+}
+
+// LastEdit returns when the document file was last edited and by whom. The
+// revision metadata in versionDir is used when it describes the current content;
+// otherwise (no metadata, or the file was changed outside the wiki since) the
+// file's modification time is returned with an empty author.
+func LastEdit(docFile, versionDir string) (time.Time, string) {
+	info, err := os.Stat(docFile)
+	if err != nil {
+		return time.Time{}, ""
+	}
+	modTime := info.ModTime()
+
+	meta := ReadCurrentMeta(versionDir)
+	edited, err := time.ParseInLocation("20060102150405", meta.Time, time.Local)
+	if err != nil {
+		return modTime, ""
+	}
+	// Metadata is written right after the file and truncated to the second
+	if modTime.After(edited.Add(2 * time.Second)) {
+		return modTime, ""
+	}
+	return edited, meta.Author
+}
+
+func readMeta(path string) VersionMeta {
+	var meta VersionMeta
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return meta
 	}
+	_ = json.Unmarshal(data, &meta)
+	return meta
 }
@@ -0,0 +1,217 @@
+// Package views counts document page views. Counts are kept in memory and
+// written to disk periodically, and repeat views by the same visitor within a
+// short window are ignored so refreshing a page does not inflate its count.
+package views
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Debounce is how long repeat views of a document by the same visitor are ignored
+const Debounce = 30 * time.Minute
+
+// Entry is a document and its view count
+type Entry struct {
+	Path  string `json:"path"`
+	Views int64  `json:"views"`
+}
+
+// Store holds view counts for every document, keyed by URL path ("/" is the homepage)
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	counts map[string]int64
+	seen   map[string]time.Time // "visitor|document" -> last counted view
+	dirty  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open loads counts from path, if it exists, and starts flushing changes to it
+// every interval until Close is called
+func Open(path string, interval time.Duration) (*Store, error) {
+	s := &Store{
+		path:   path,
+		counts: make(map[string]int64),
+		seen:   make(map[string]time.Time),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.counts); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.flushLoop(interval)
+	return s, nil
+}
+
+// Record counts a view of docPath by visitor unless the same visitor viewed it recently
+func (s *Store) Record(docPath, visitor string) {
+	docPath = normalize(docPath)
+	key := visitor + "|" + docPath
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.seen[key]; ok && now.Sub(last) < Debounce {
+		return
+	}
+	s.seen[key] = now
+	s.counts[docPath]++
+	s.dirty = true
+}
+
+// Count returns the number of views of docPath
+func (s *Store) Count(docPath string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[normalize(docPath)]
+}
+
+// Top returns the limit most viewed documents, most viewed first
+func (s *Store) Top(limit int) []Entry {
+	s.mu.Lock()
+	entries := make([]Entry, 0, len(s.counts))
+	for p, n := range s.counts {
+		entries = append(entries, Entry{Path: p, Views: n})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Views != entries[j].Views {
+			return entries[i].Views > entries[j].Views
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// Move transfers the counts of oldPath and every document below it to newPath
+func (s *Store) Move(oldPath, newPath string) {
+	oldPath, newPath = normalize(oldPath), normalize(newPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for p, n := range s.counts {
+		var target string
+		switch {
+		case p == oldPath:
+			target = newPath
+		case strings.HasPrefix(p, oldPath+"/"):
+			target = newPath + strings.TrimPrefix(p, oldPath)
+		default:
+			continue
+		}
+		delete(s.counts, p)
+		s.counts[target] += n
+		s.dirty = true
+	}
+}
+
+// Prune drops the counts of documents for which exists returns false and
+// returns how many it dropped
+func (s *Store) Prune(exists func(docPath string) bool) int {
+	s.mu.Lock()
+	paths := make([]string, 0, len(s.counts))
+	for p := range s.counts {
+		paths = append(paths, p)
+	}
+	s.mu.Unlock()
+
+	var gone []string
+	for _, p := range paths {
+		if !exists(p) {
+			gone = append(gone, p)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range gone {
+		delete(s.counts, p)
+		s.dirty = true
+	}
+	return len(gone)
+}
+
+// Flush writes the counts to disk if they changed since the last flush
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(s.counts)
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Close stops the background flush and writes any pending counts
+func (s *Store) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Flush()
+}
+
+func (s *Store) flushLoop(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+			s.pruneSeen()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// pruneSeen forgets visitors whose debounce window has passed
+func (s *Store) pruneSeen() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, last := range s.seen {
+		if now.Sub(last) >= Debounce {
+			delete(s.seen, key)
+		}
+	}
+}
+
+// normalize turns a document path into the "/a/b" form used as a key
+func normalize(docPath string) string {
+	return "/" + strings.Trim(strings.ReplaceAll(docPath, "\\", "/"), "/")
+}
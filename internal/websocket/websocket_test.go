@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// dial opens a WebSocket to the test server, sending origin as Origin
+func dial(t *testing.T, server *httptest.Server, origin string) (net.Conn, *bufio.Reader, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	request := "GET / HTTP/1.1\r\nHost: " + strings.TrimPrefix(server.URL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"
+	if origin != "" {
+		request += "Origin: " + origin + "\r\n"
+	}
+	if _, err := conn.Write([]byte(request + "\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, br, resp.Status + " " + resp.Header.Get("Sec-WebSocket-Accept")
+}
+
+// writeClientFrame sends a masked frame, as browsers do
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads an unmasked frame of at most 125 bytes
+func readServerFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, header[1]&0x7f)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0f, payload
+}
+
+func TestEcho(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, message)
+		}
+	}))
+	defer server.Close()
+
+	conn, br, status := dial(t, server, "")
+	// The accept value of the handshake example in RFC 6455
+	if want := "101 Switching Protocols s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; status != want {
+		t.Fatalf("handshake = %q, want %q", status, want)
+	}
+
+	t.Run("single frame", func(t *testing.T) {
+		writeClientFrame(t, conn, true, TextMessage, []byte("hello"))
+		if op, payload := readServerFrame(t, br); op != TextMessage || string(payload) != "hello" {
+			t.Errorf("echo = %d %q", op, payload)
+		}
+	})
+
+	t.Run("fragments with a ping between them", func(t *testing.T) {
+		writeClientFrame(t, conn, false, TextMessage, []byte("hel"))
+		writeClientFrame(t, conn, true, opPing, []byte("p"))
+		writeClientFrame(t, conn, true, opContinuation, []byte("lo"))
+		if op, payload := readServerFrame(t, br); op != opPong || string(payload) != "p" {
+			t.Errorf("pong = %d %q", op, payload)
+		}
+		if op, payload := readServerFrame(t, br); op != TextMessage || string(payload) != "hello" {
+			t.Errorf("echo = %d %q", op, payload)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		writeClientFrame(t, conn, true, opClose, binary.BigEndian.AppendUint16(nil, CloseNormal))
+		if op, payload := readServerFrame(t, br); op != opClose || binary.BigEndian.Uint16(payload) != CloseNormal {
+			t.Errorf("close = %d %v", op, payload)
+		}
+	})
+}
+
+func TestUpgradeRefusesOtherOrigins(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := Upgrade(w, r); err == nil {
+			conn.Close(CloseNormal, "")
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{server.URL, "101 Switching Protocols"},
+		{"https://evil.example", "403 Forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if _, _, status := dial(t, server, tt.origin); !strings.HasPrefix(status, tt.want) {
+				t.Errorf("status = %q, want %q", status, tt.want)
+			}
+		})
+	}
+}
@@ -5,20 +5,32 @@ import (
 	"fmt"
 	"flag"
 	"log"
+	"context"
 	"net/http"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"wiki-go/internal/auth"
 	"wiki-go/internal/config"
 	"wiki-go/internal/handlers"
+	"wiki-go/internal/logging"
 	"wiki-go/internal/migration"
 	"wiki-go/internal/routes"
 	"wiki-go/internal/static"
 
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
 	// Import goldext package for its initialization side effects
 	_ "wiki-go/internal/goldext"
 )
 
+// shutdownTimeout bounds how long in-flight requests may run after a termination signal
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	configfilepath := flag.String("configfile",
 		GetEnvString("CONFIGFILE", config.ConfigFilePath),
@@ -43,11 +55,35 @@ func main() {
 		log.Fatal("Error loading config:", err)
 	}
 
+	// Refuse to start with a configuration that would fail at runtime
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration in %s:\n", config.ConfigFilePath)
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		os.Exit(1)
+	}
+
+	// Move the version history, comments and trash of existing installs to the
+	// configured directories. Comments used to be kept in data/comments below the
+	// working directory, whatever the root directory.
+	if err := migration.MoveStorageDirs([]migration.StorageDir{
+		{Path: config.VersionsPath(cfg), Previous: []string{filepath.Join(cfg.Wiki.RootDir, config.DefaultVersionsDir)}},
+		{Path: config.CommentsPath(cfg), Previous: []string{filepath.Join(cfg.Wiki.RootDir, config.DefaultCommentsDir), filepath.Join("data", "comments")}},
+		{Path: config.TrashPath(cfg), Previous: []string{filepath.Join(cfg.Wiki.RootDir, config.DefaultTrashDir)}},
+	}); err != nil {
+		log.Fatal("Error moving data directories:", err)
+	}
+
+	// Switch to structured JSON logging at the configured level
+	logging.Init(cfg.Server.LogLevel)
+
 	// Initialize session store for persistent logins
-	sessionPath := filepath.Join(cfg.Wiki.RootDir, "temp", "sessions.json")
-	if err := auth.InitSessionStore(sessionPath); err != nil {
-		log.Printf("Warning: Failed to initialize session store: %v", err)
+	sessionStore, err := auth.OpenSessionStore(cfg)
+	if err != nil {
+		log.Fatal("Error opening session store:", err)
 	}
+	auth.InitSessionStore(sessionStore)
 
 	// Ensure the homepage exists
 	if err := handlers.EnsureHomepageExists(cfg); err != nil {
@@ -65,19 +101,120 @@ func main() {
 	// Setup all routes
 	routes.SetupRoutes(cfg)
 
+	// Serve metrics on a dedicated listener when configured, keeping them off the public port
+	var metricsServer *http.Server
+	if cfg.Server.Metrics.Enabled && cfg.Server.Metrics.Listen != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.HandleFunc("/metrics", handlers.MetricsHandler)
+		metricsServer = &http.Server{Addr: cfg.Server.Metrics.Listen, Handler: metricsMux}
+		go func() {
+			fmt.Printf("Metrics server starting on %s...\n", cfg.Server.Metrics.Listen)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
+	// Obtain and renew certificates from the ACME CA; the account key and
+	// certificates are cached under temp/, which backups skip
+	var certManager *autocert.Manager
+	if cfg.Server.TLS.Mode == config.TLSModeACME {
+		certManager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Server.TLS.ACME.Hosts...),
+			Cache:      autocert.DirCache(filepath.Join(cfg.Wiki.RootDir, "temp", "acme")),
+			Email:      cfg.Server.TLS.ACME.Email,
+		}
+		if cfg.Server.TLS.ACME.Directory != "" {
+			certManager.Client = &acme.Client{DirectoryURL: cfg.Server.TLS.ACME.Directory}
+		}
+	}
+
+	// Redirect plain HTTP to HTTPS on a second listener when configured, which
+	// also answers http-01 challenges
+	var redirectServer *http.Server
+	if config.TLSEnabled(cfg) && cfg.Server.TLS.RedirectHTTP != "" {
+		redirect := routes.HTTPSRedirectHandler(cfg)
+		if certManager != nil {
+			redirect = certManager.HTTPHandler(redirect)
+		}
+		redirectServer = &http.Server{Addr: cfg.Server.TLS.RedirectHTTP, Handler: redirect}
+		go func() {
+			fmt.Printf("HTTP to HTTPS redirect starting on %s...\n", cfg.Server.TLS.RedirectHTTP)
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Redirect server error: %v", err)
+			}
+		}()
+	}
+
 	// Start the server
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	if cfg.Server.SSL && cfg.Server.SSLCert != "" && cfg.Server.SSLKey != "" {
-		fmt.Printf("HTTPS server starting on %s...\n", addr)
-		if err := http.ListenAndServeTLS(addr, cfg.Server.SSLCert, cfg.Server.SSLKey, nil); err != nil {
-			log.Fatal(err)
+	limits := cfg.Server.Limits
+	server := &http.Server{
+		Addr:              addr,
+		ReadHeaderTimeout: time.Duration(limits.ReadHeaderTimeout) * time.Second,
+		ReadTimeout:       time.Duration(limits.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(limits.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(limits.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    limits.MaxHeaderBytes * 1024,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		switch cfg.Server.TLS.Mode {
+		case config.TLSModeStatic:
+			fmt.Printf("HTTPS server starting on %s...\n", addr)
+			serverErr <- server.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		case config.TLSModeACME:
+			server.TLSConfig = certManager.TLSConfig()
+			fmt.Printf("HTTPS server starting on %s with certificates for %s...\n", addr, strings.Join(cfg.Server.TLS.ACME.Hosts, ", "))
+			serverErr <- server.ListenAndServeTLS("", "")
+		default:
+			fmt.Printf("HTTP server starting on %s...\n", addr)
+			serverErr <- server.ListenAndServe()
 		}
-	} else {
-		fmt.Printf("HTTP server starting on %s...\n", addr)
-		if err := http.ListenAndServe(addr, nil); err != nil {
+	}()
+
+	// Wait for a termination signal or a fatal server error
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal(err)
 		}
+	case sig := <-stop:
+		log.Printf("Received %s, shutting down...", sig)
+	}
+
+	// Stop accepting new connections and let in-flight requests (saves, moves) finish
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Warning: Server did not shut down cleanly: %v", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			log.Printf("Warning: Metrics server did not shut down cleanly: %v", err)
+		}
+	}
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			log.Printf("Warning: Redirect server did not shut down cleanly: %v", err)
+		}
+	}
+
+	// Stop background workers and persist sessions
+	if err := auth.StopSessionStore(); err != nil {
+		log.Printf("Warning: Failed to persist sessions: %v", err)
+	}
+	if err := handlers.CloseViewCounts(); err != nil {
+		log.Printf("Warning: Failed to persist view counts: %v", err)
 	}
+	if err := handlers.CloseSearchIndex(); err != nil {
+		log.Printf("Warning: Failed to persist search index: %v", err)
+	}
+
+	log.Printf("Shutdown complete")
 }
 
 func GetEnvString(name, defaultvalue string) string {